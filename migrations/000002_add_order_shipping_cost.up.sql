@@ -0,0 +1 @@
+ALTER TABLE orders ADD COLUMN shipping_cost DECIMAL(15,2) NOT NULL DEFAULT 0;
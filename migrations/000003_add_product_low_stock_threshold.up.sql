@@ -0,0 +1 @@
+ALTER TABLE products ADD COLUMN low_stock_threshold INTEGER;
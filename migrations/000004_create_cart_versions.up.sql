@@ -0,0 +1,5 @@
+CREATE TABLE cart_versions (
+    user_id UUID PRIMARY KEY REFERENCES users(id),
+    version BIGINT NOT NULL DEFAULT 0,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
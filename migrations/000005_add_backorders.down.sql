@@ -0,0 +1,2 @@
+ALTER TABLE order_items DROP COLUMN IF EXISTS backordered;
+ALTER TABLE products DROP COLUMN IF EXISTS allow_backorder;
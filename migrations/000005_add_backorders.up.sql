@@ -0,0 +1,2 @@
+ALTER TABLE products ADD COLUMN allow_backorder BOOLEAN NOT NULL DEFAULT FALSE;
+ALTER TABLE order_items ADD COLUMN backordered BOOLEAN NOT NULL DEFAULT FALSE;
@@ -0,0 +1,10 @@
+CREATE TABLE product_attributes (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    product_id UUID NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+    key VARCHAR(50) NOT NULL,
+    value VARCHAR(255) NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    UNIQUE (product_id, key)
+);
+
+CREATE INDEX idx_product_attributes_key_value ON product_attributes(key, value);
@@ -0,0 +1,8 @@
+DELETE FROM orders WHERE user_id IS NULL;
+
+ALTER TABLE orders DROP CONSTRAINT IF EXISTS chk_orders_buyer;
+ALTER TABLE orders DROP COLUMN IF EXISTS lookup_token_hash;
+ALTER TABLE orders DROP COLUMN IF EXISTS guest_id;
+ALTER TABLE orders ALTER COLUMN user_id SET NOT NULL;
+
+DROP TABLE IF EXISTS guests;
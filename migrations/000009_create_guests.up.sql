@@ -0,0 +1,14 @@
+CREATE TABLE guests (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    email VARCHAR(255) NOT NULL,
+    name VARCHAR(255) NOT NULL DEFAULT '',
+    phone VARCHAR(50) NOT NULL DEFAULT '',
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+CREATE INDEX idx_guests_email ON guests(email);
+
+ALTER TABLE orders ALTER COLUMN user_id DROP NOT NULL;
+ALTER TABLE orders ADD COLUMN guest_id UUID REFERENCES guests(id);
+ALTER TABLE orders ADD COLUMN lookup_token_hash VARCHAR(64) NOT NULL DEFAULT '';
+ALTER TABLE orders ADD CONSTRAINT chk_orders_buyer CHECK (user_id IS NOT NULL OR guest_id IS NOT NULL);
@@ -0,0 +1 @@
+ALTER TABLE users ADD COLUMN password_changed_at TIMESTAMP WITH TIME ZONE;
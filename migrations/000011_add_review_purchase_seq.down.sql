@@ -0,0 +1,5 @@
+DELETE FROM reviews WHERE purchase_seq > 1;
+
+ALTER TABLE reviews DROP CONSTRAINT IF EXISTS reviews_user_id_product_id_purchase_seq_key;
+ALTER TABLE reviews ADD CONSTRAINT reviews_user_id_product_id_key UNIQUE (user_id, product_id);
+ALTER TABLE reviews DROP COLUMN IF EXISTS purchase_seq;
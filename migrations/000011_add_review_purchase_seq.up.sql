@@ -0,0 +1,5 @@
+-- Reviews are unique per purchase rather than per product, so repeat buyers
+-- can review again when REVIEW_ALLOW_REPEAT_PURCHASE is enabled.
+ALTER TABLE reviews ADD COLUMN purchase_seq INTEGER NOT NULL DEFAULT 1;
+ALTER TABLE reviews DROP CONSTRAINT IF EXISTS reviews_user_id_product_id_key;
+ALTER TABLE reviews ADD CONSTRAINT reviews_user_id_product_id_purchase_seq_key UNIQUE (user_id, product_id, purchase_seq);
@@ -0,0 +1,2 @@
+ALTER TABLE stores DROP COLUMN IF EXISTS thumbnail_url;
+ALTER TABLE products DROP COLUMN IF EXISTS thumbnail_url;
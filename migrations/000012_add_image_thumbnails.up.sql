@@ -0,0 +1,2 @@
+ALTER TABLE products ADD COLUMN thumbnail_url VARCHAR(500) DEFAULT '';
+ALTER TABLE stores ADD COLUMN thumbnail_url VARCHAR(500) DEFAULT '';
@@ -0,0 +1,15 @@
+CREATE TABLE product_images (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    product_id UUID NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+    url VARCHAR(500) NOT NULL,
+    thumbnail_url VARCHAR(500) DEFAULT '',
+    sort_order INTEGER NOT NULL DEFAULT 0,
+    is_primary BOOLEAN NOT NULL DEFAULT FALSE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+CREATE INDEX idx_product_images_product_id ON product_images(product_id, sort_order);
+CREATE UNIQUE INDEX idx_product_images_primary ON product_images(product_id) WHERE is_primary;
+
+INSERT INTO product_images (product_id, url, thumbnail_url, sort_order, is_primary)
+SELECT id, image_url, thumbnail_url, 0, TRUE FROM products WHERE image_url <> '';
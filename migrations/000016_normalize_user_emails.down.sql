@@ -0,0 +1,3 @@
+-- The original casing of emails is not kept, so this migration cannot be
+-- undone; normalized emails keep working with the previous code.
+SELECT 1;
@@ -0,0 +1,13 @@
+-- Emails are stored trimmed and lowercased from now on, and logins look them
+-- up in that form. Normalize existing rows to match. Accounts whose
+-- normalized email would collide with another account are left untouched:
+-- they cannot log in until merged or renamed by hand. Find them with
+--   SELECT LOWER(TRIM(email)), COUNT(*) FROM users
+--   GROUP BY 1 HAVING COUNT(*) > 1;
+UPDATE users u
+SET email = LOWER(TRIM(u.email))
+WHERE u.email <> LOWER(TRIM(u.email))
+  AND NOT EXISTS (
+    SELECT 1 FROM users o
+    WHERE o.id <> u.id AND LOWER(TRIM(o.email)) = LOWER(TRIM(u.email))
+  );
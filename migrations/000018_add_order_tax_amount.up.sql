@@ -0,0 +1 @@
+ALTER TABLE orders ADD COLUMN tax_amount DECIMAL(15,2) NOT NULL DEFAULT 0;
@@ -0,0 +1 @@
+ALTER TABLE stores DROP COLUMN IF EXISTS active;
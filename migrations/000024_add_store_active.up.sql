@@ -0,0 +1 @@
+ALTER TABLE stores ADD COLUMN active BOOLEAN NOT NULL DEFAULT TRUE;
@@ -0,0 +1 @@
+ALTER TABLE products ADD COLUMN unavailable BOOLEAN NOT NULL DEFAULT FALSE;
@@ -0,0 +1,6 @@
+-- The unique constraint on email compares exact strings, so a row written
+-- before emails were normalized can still share an address with a new one
+-- in different case. Enforce uniqueness case-insensitively as well. This
+-- fails while accounts left over by 000016 still collide; merge or rename
+-- them first (000016 has the query that finds them).
+CREATE UNIQUE INDEX idx_users_email_lower ON users (LOWER(email));
@@ -28,7 +28,8 @@ func main() {
 
 	ctx := context.Background()
 
-	paymentSvc := service.NewPaymentService()
+	gateway := service.NewMockGateway(cfg.Payment.MockFailureRate, cfg.Payment.MockLatency)
+	paymentSvc := service.NewPaymentService(gateway, cfg.Payment.GatewayTimeout)
 
 	nsqProducer, err := nsq.NewProducer(cfg.NSQ.NsqdAddr, nsq.NewConfig())
 	if err != nil {
@@ -1,7 +1,9 @@
 package config
 
 import (
+	"fmt"
 	"strings"
+	"time"
 
 	pkgconstant "github.com/1tsndre/mini-go-project/pkg/constant"
 	"github.com/spf13/viper"
@@ -24,6 +26,13 @@ type NSQConfig struct {
 
 type PaymentConfig struct {
 	GRPCPort string
+	// GatewayTimeout bounds a single charge; a slower gateway is treated as
+	// unavailable and the payment fails.
+	GatewayTimeout time.Duration
+	// MockFailureRate is the share (0–1) of charges the mock gateway declines.
+	MockFailureRate float64
+	// MockLatency is how long the mock gateway takes to answer a charge.
+	MockLatency time.Duration
 }
 
 func Load() (*Config, error) {
@@ -37,9 +46,27 @@ func Load() (*Config, error) {
 	v.SetDefault("NSQ_LOOKUPD_ADDR", "localhost:4161")
 	v.SetDefault("NSQD_ADDR", "localhost:4150")
 	v.SetDefault("PAYMENT_GRPC_PORT", "50051")
+	v.SetDefault("PAYMENT_GATEWAY_TIMEOUT", "10s")
+	v.SetDefault("MOCK_FAILURE_RATE", 0.1)
+	v.SetDefault("MOCK_LATENCY", "1s")
 
 	_ = v.ReadInConfig()
 
+	gatewayTimeout, err := time.ParseDuration(v.GetString("PAYMENT_GATEWAY_TIMEOUT"))
+	if err != nil || gatewayTimeout <= 0 {
+		return nil, fmt.Errorf("invalid PAYMENT_GATEWAY_TIMEOUT: must be a positive duration")
+	}
+
+	failureRate := v.GetFloat64("MOCK_FAILURE_RATE")
+	if failureRate < 0 || failureRate > 1 {
+		return nil, fmt.Errorf("invalid MOCK_FAILURE_RATE: must be between 0 and 1")
+	}
+
+	mockLatency, err := time.ParseDuration(v.GetString("MOCK_LATENCY"))
+	if err != nil || mockLatency < 0 {
+		return nil, fmt.Errorf("invalid MOCK_LATENCY: must be a non-negative duration")
+	}
+
 	return &Config{
 		App: AppConfig{
 			Env: v.GetString("APP_ENV"),
@@ -49,7 +76,10 @@ func Load() (*Config, error) {
 			NsqdAddr:    v.GetString("NSQD_ADDR"),
 		},
 		Payment: PaymentConfig{
-			GRPCPort: v.GetString("PAYMENT_GRPC_PORT"),
+			GRPCPort:        v.GetString("PAYMENT_GRPC_PORT"),
+			GatewayTimeout:  gatewayTimeout,
+			MockFailureRate: failureRate,
+			MockLatency:     mockLatency,
 		},
 	}, nil
 }
@@ -2,9 +2,12 @@ package handler
 
 import (
 	"context"
+	"errors"
 
 	"github.com/1tsndre/mini-go-project/payment-service/internal/service"
 	pb "github.com/1tsndre/mini-go-project/proto/payment"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
 type PaymentGRPCHandler struct {
@@ -19,22 +22,35 @@ func NewPaymentGRPCHandler(svc *service.PaymentService) *PaymentGRPCHandler {
 func (h *PaymentGRPCHandler) ProcessPayment(ctx context.Context, req *pb.ProcessPaymentRequest) (*pb.ProcessPaymentResponse, error) {
 	result := h.service.ProcessPayment(ctx, req.OrderId, req.Amount, req.Method)
 
-	status := "failed"
-	if result.Success {
-		status = "success"
-	}
-
 	return &pb.ProcessPaymentResponse{
 		Success:   result.Success,
 		PaymentId: result.PaymentID,
-		Status:    status,
+		Status:    resultStatus(result),
 		Message:   result.Message,
 	}, nil
 }
 
+// GetPaymentStatus reports the gateway's outcome of charging an order, or
+// NotFound if it was never charged.
 func (h *PaymentGRPCHandler) GetPaymentStatus(ctx context.Context, req *pb.GetPaymentStatusRequest) (*pb.GetPaymentStatusResponse, error) {
+	result, err := h.service.PaymentStatus(ctx, req.OrderId)
+	if errors.Is(err, service.ErrChargeNotFound) {
+		return nil, status.Error(codes.NotFound, "no payment found for order")
+	}
+	if err != nil {
+		return nil, status.Error(codes.Unavailable, "payment gateway unavailable")
+	}
+
 	return &pb.GetPaymentStatusResponse{
-		OrderId: req.OrderId,
-		Status:  "mock",
+		PaymentId: result.PaymentID,
+		OrderId:   result.OrderID,
+		Status:    resultStatus(&result),
 	}, nil
 }
+
+func resultStatus(result *service.PaymentResult) string {
+	if result.Success {
+		return "success"
+	}
+	return "failed"
+}
@@ -2,9 +2,9 @@ package nsq
 
 import (
 	"context"
-	"encoding/json"
 
 	"github.com/1tsndre/mini-go-project/payment-service/internal/service"
+	"github.com/1tsndre/mini-go-project/pkg/events"
 	"github.com/1tsndre/mini-go-project/pkg/logger"
 	"github.com/nsqio/go-nsq"
 )
@@ -49,15 +49,13 @@ func (c *OrderConsumer) Start(lookupdAddr string) error {
 }
 
 func (c *OrderConsumer) handleOrderCreated(message *nsq.Message) error {
-	var payload struct {
-		OrderID     string `json:"order_id"`
-		UserID      string `json:"user_id"`
-		TotalAmount string `json:"total_amount"`
-	}
+	var payload events.OrderCreated
 
 	ctx := context.Background()
 
-	if err := json.Unmarshal(message.Body, &payload); err != nil {
+	// Unknown or newer versions cannot be charged correctly; redelivering
+	// them would not help, so they are finished.
+	if _, err := events.Unmarshal(message.Body, &payload); err != nil {
 		logger.Error(ctx, "failed to unmarshal order.created, skipping", err)
 		return nil
 	}
@@ -66,10 +64,11 @@ func (c *OrderConsumer) handleOrderCreated(message *nsq.Message) error {
 
 	result := c.paymentService.ProcessPayment(ctx, payload.OrderID, payload.TotalAmount, "mock")
 
-	response, err := json.Marshal(map[string]string{
-		"order_id":   result.OrderID,
-		"payment_id": result.PaymentID,
-		"message":    result.Message,
+	response, err := events.Marshal(events.PaymentResult{
+		OrderID:   result.OrderID,
+		PaymentID: result.PaymentID,
+		Success:   result.Success,
+		Message:   result.Message,
 	})
 	if err != nil {
 		logger.Error(ctx, "failed to marshal payment response, skipping", err, map[string]any{"order_id": result.OrderID})
@@ -0,0 +1,84 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+)
+
+// ChargeResult is a gateway's answer to a charge it was able to process.
+// A declined charge is a result, not an error; errors mean the gateway could
+// not give an answer at all (timeout, outage).
+type ChargeResult struct {
+	Approved      bool
+	TransactionID string
+	Reason        string
+}
+
+// ErrChargeNotFound is returned by Gateway.Lookup for an order the provider
+// has no charge for.
+var ErrChargeNotFound = errors.New("charge not found")
+
+// Gateway charges an order's amount with a payment provider.
+type Gateway interface {
+	Charge(ctx context.Context, orderID string, amount decimal.Decimal) (ChargeResult, error)
+	// Lookup returns the provider's record of the latest charge for
+	// orderID, or ErrChargeNotFound.
+	Lookup(ctx context.Context, orderID string) (ChargeResult, error)
+}
+
+// MockGateway simulates a payment provider. It declines a FailureRate share
+// of charges at random and takes Latency to answer, so declines and timeouts
+// can be exercised end-to-end without a real provider.
+//
+// Like a real provider it keeps a record of every charge it answered, which
+// Lookup serves. Those records stand in for the provider's own storage and
+// are lost on restart.
+type MockGateway struct {
+	failureRate float64
+	latency     time.Duration
+
+	mu      sync.RWMutex
+	charges map[string]ChargeResult
+}
+
+func NewMockGateway(failureRate float64, latency time.Duration) *MockGateway {
+	return &MockGateway{failureRate: failureRate, latency: latency, charges: make(map[string]ChargeResult)}
+}
+
+func (g *MockGateway) Charge(ctx context.Context, orderID string, amount decimal.Decimal) (ChargeResult, error) {
+	if g.latency > 0 {
+		timer := time.NewTimer(g.latency)
+		defer timer.Stop()
+		select {
+		case <-ctx.Done():
+			return ChargeResult{}, ctx.Err()
+		case <-timer.C:
+		}
+	}
+
+	result := ChargeResult{Approved: true, TransactionID: "mock_" + uuid.NewString()}
+	if rand.Float64() < g.failureRate {
+		result = ChargeResult{Reason: "payment declined"}
+	}
+
+	g.mu.Lock()
+	g.charges[orderID] = result
+	g.mu.Unlock()
+	return result, nil
+}
+
+func (g *MockGateway) Lookup(_ context.Context, orderID string) (ChargeResult, error) {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	result, ok := g.charges[orderID]
+	if !ok {
+		return ChargeResult{}, ErrChargeNotFound
+	}
+	return result, nil
+}
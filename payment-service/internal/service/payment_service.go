@@ -2,10 +2,10 @@ package service
 
 import (
 	"context"
-	"math/rand"
 	"time"
 
 	"github.com/1tsndre/mini-go-project/pkg/logger"
+	"github.com/1tsndre/mini-go-project/pkg/money"
 )
 
 type PaymentResult struct {
@@ -15,35 +15,91 @@ type PaymentResult struct {
 	Message   string
 }
 
-type PaymentService struct{}
+type PaymentService struct {
+	gateway Gateway
+	timeout time.Duration
+}
 
-func NewPaymentService() *PaymentService {
-	return &PaymentService{}
+// NewPaymentService creates a service that charges through gateway, giving
+// up on a charge that takes longer than timeout.
+func NewPaymentService(gateway Gateway, timeout time.Duration) *PaymentService {
+	return &PaymentService{gateway: gateway, timeout: timeout}
 }
 
-func (s *PaymentService) ProcessPayment(ctx context.Context, orderID, amount, method string) *PaymentResult {
-	time.Sleep(time.Duration(500+rand.Intn(1500)) * time.Millisecond)
+// PaymentStatus asks the gateway for the outcome of charging orderID, so the
+// answer survives restarts of this service. It returns ErrChargeNotFound if
+// the order was never charged.
+func (s *PaymentService) PaymentStatus(ctx context.Context, orderID string) (PaymentResult, error) {
+	lookupCtx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
 
-	// Mock: 90% success rate
-	success := rand.Float32() < 0.9
+	charge, err := s.gateway.Lookup(lookupCtx, orderID)
+	if err != nil {
+		return PaymentResult{}, err
+	}
+
+	result := PaymentResult{
+		OrderID:   orderID,
+		PaymentID: paymentID(orderID),
+		Success:   charge.Approved,
+		Message:   "payment processed successfully",
+	}
+	if !charge.Approved {
+		result.Message = chargeDeclinedMessage(charge)
+	}
+	return result, nil
+}
 
+func paymentID(orderID string) string {
 	suffix := orderID
 	if len(orderID) > 8 {
 		suffix = orderID[:8]
 	}
+	return "pay_" + suffix
+}
+
+func chargeDeclinedMessage(charge ChargeResult) string {
+	if charge.Reason != "" {
+		return charge.Reason
+	}
+	return "payment declined"
+}
+
+func (s *PaymentService) ProcessPayment(ctx context.Context, orderID, amount, method string) *PaymentResult {
 	result := &PaymentResult{
 		OrderID:   orderID,
-		Success:   success,
-		PaymentID: "pay_" + suffix,
+		PaymentID: paymentID(orderID),
+	}
+
+	value, err := money.Parse(amount, money.Positive)
+	if err != nil {
+		result.Message = "invalid payment amount"
+		logger.Warn(ctx, "invalid payment amount", map[string]any{"order_id": orderID, "amount": amount, "reason": err.Error()})
+		return result
+	}
+
+	chargeCtx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+
+	charge, err := s.gateway.Charge(chargeCtx, orderID, value)
+	if err != nil {
+		result.Message = "payment gateway unavailable"
+		logger.Error(ctx, "payment gateway charge failed", err, map[string]any{"order_id": orderID, "amount": amount, "method": method})
+		return result
 	}
 
-	if success {
-		result.Message = "payment processed successfully"
-		logger.Info(ctx, "payment processed successfully", map[string]any{"order_id": orderID, "amount": amount})
-	} else {
-		result.Message = "payment declined"
-		logger.Warn(ctx, "payment declined", map[string]any{"order_id": orderID, "amount": amount})
+	if !charge.Approved {
+		result.Message = chargeDeclinedMessage(charge)
+		logger.Warn(ctx, "payment declined", map[string]any{"order_id": orderID, "amount": amount, "reason": charge.Reason})
+		return result
 	}
 
+	result.Success = true
+	result.Message = "payment processed successfully"
+	logger.Info(ctx, "payment processed successfully", map[string]any{
+		"order_id":       orderID,
+		"amount":         amount,
+		"transaction_id": charge.TransactionID,
+	})
 	return result
 }
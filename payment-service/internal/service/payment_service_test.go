@@ -0,0 +1,124 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+)
+
+type stubGateway struct {
+	result ChargeResult
+	err    error
+}
+
+func (g *stubGateway) Charge(context.Context, string, decimal.Decimal) (ChargeResult, error) {
+	return g.result, g.err
+}
+
+func (g *stubGateway) Lookup(context.Context, string) (ChargeResult, error) {
+	return ChargeResult{}, ErrChargeNotFound
+}
+
+func TestPaymentService_ProcessPayment(t *testing.T) {
+	tests := []struct {
+		name        string
+		gateway     Gateway
+		amount      string
+		wantSuccess bool
+		wantMessage string
+	}{
+		{
+			name:        "succeeding gateway",
+			gateway:     NewMockGateway(0, 0),
+			amount:      "150000",
+			wantSuccess: true,
+			wantMessage: "payment processed successfully",
+		},
+		{
+			name:        "declining gateway",
+			gateway:     NewMockGateway(1, 0),
+			amount:      "150000",
+			wantMessage: "payment declined",
+		},
+		{
+			name:        "decline reason is passed through",
+			gateway:     &stubGateway{result: ChargeResult{Reason: "insufficient funds"}},
+			amount:      "150000",
+			wantMessage: "insufficient funds",
+		},
+		{
+			name:        "gateway error fails the payment",
+			gateway:     &stubGateway{err: errors.New("connection reset")},
+			amount:      "150000",
+			wantMessage: "payment gateway unavailable",
+		},
+		{
+			name:        "gateway slower than timeout fails the payment",
+			gateway:     NewMockGateway(0, time.Second),
+			amount:      "150000",
+			wantMessage: "payment gateway unavailable",
+		},
+		{
+			name:        "invalid amount is rejected before charging",
+			gateway:     &stubGateway{result: ChargeResult{Approved: true}},
+			amount:      "abc",
+			wantMessage: "invalid payment amount",
+		},
+		{
+			name:        "zero amount is rejected before charging",
+			gateway:     &stubGateway{result: ChargeResult{Approved: true}},
+			amount:      "0",
+			wantMessage: "invalid payment amount",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := NewPaymentService(tt.gateway, 50*time.Millisecond)
+
+			result := svc.ProcessPayment(context.Background(), "8a3c2a52-6f1e-4c1b-9d7a-2f3b6f0c9e11", tt.amount, "mock")
+
+			assert.Equal(t, tt.wantSuccess, result.Success)
+			assert.Equal(t, tt.wantMessage, result.Message)
+			assert.Equal(t, "8a3c2a52-6f1e-4c1b-9d7a-2f3b6f0c9e11", result.OrderID)
+			assert.Equal(t, "pay_8a3c2a52", result.PaymentID)
+		})
+	}
+}
+
+func TestPaymentService_PaymentStatus(t *testing.T) {
+	const orderID = "8a3c2a52-6f1e-4c1b-9d7a-2f3b6f0c9e11"
+
+	tests := []struct {
+		name    string
+		gateway *MockGateway
+	}{
+		{"approved charge", NewMockGateway(0, 0)},
+		{"declined charge", NewMockGateway(1, 0)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			charged := NewPaymentService(tt.gateway, time.Second).ProcessPayment(context.Background(), orderID, "150000", "mock")
+
+			// A fresh service stands in for a restarted one: the status
+			// must come from the gateway, not from this process.
+			status, err := NewPaymentService(tt.gateway, time.Second).PaymentStatus(context.Background(), orderID)
+
+			assert.NoError(t, err)
+			assert.Equal(t, *charged, status)
+		})
+	}
+}
+
+func TestPaymentService_PaymentStatus_Unknown(t *testing.T) {
+	svc := NewPaymentService(NewMockGateway(0, 0), time.Second)
+
+	_, err := svc.PaymentStatus(context.Background(), "8a3c2a52-6f1e-4c1b-9d7a-2f3b6f0c9e11")
+
+	assert.ErrorIs(t, err, ErrChargeNotFound)
+}
@@ -0,0 +1,109 @@
+// Package events defines the messages the services exchange over NSQ. Every
+// message is an Envelope around a typed payload, so a consumer can tell what
+// it received and which version of the payload schema it was written with.
+package events
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var (
+	// ErrUnknownEvent is returned when a message is not an envelope or
+	// carries a different event type than expected.
+	ErrUnknownEvent = errors.New("unknown event")
+	// ErrUnsupportedVersion is returned for a known event type whose
+	// payload version this build does not understand.
+	ErrUnsupportedVersion = errors.New("unsupported event version")
+)
+
+// legacyVersion is the payload version of messages published without an
+// envelope.
+const legacyVersion = 1
+
+// Envelope wraps every published event.
+type Envelope struct {
+	EventType  string          `json:"event_type"`
+	Version    int             `json:"version"`
+	ID         string          `json:"id"`
+	OccurredAt time.Time       `json:"occurred_at"`
+	Payload    json.RawMessage `json:"payload"`
+}
+
+// Event is a payload that can be carried in an Envelope. Bump EventVersion
+// when the payload changes in a way older consumers cannot read.
+type Event interface {
+	EventType() string
+	EventVersion() int
+}
+
+// OrderCreated asks the payment service to charge an order.
+type OrderCreated struct {
+	OrderID     string `json:"order_id"`
+	UserID      string `json:"user_id"`
+	TotalAmount string `json:"total_amount"`
+}
+
+func (OrderCreated) EventType() string { return "order.created" }
+func (OrderCreated) EventVersion() int { return 1 }
+
+// PaymentResult reports the outcome of charging an order. It is published
+// to payment.success or payment.failed depending on the outcome.
+type PaymentResult struct {
+	OrderID   string `json:"order_id"`
+	PaymentID string `json:"payment_id"`
+	Success   bool   `json:"success"`
+	Message   string `json:"message"`
+}
+
+func (PaymentResult) EventType() string { return "payment.result" }
+func (PaymentResult) EventVersion() int { return 1 }
+
+// Marshal wraps event in a new Envelope and encodes it.
+func Marshal(event Event) ([]byte, error) {
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(Envelope{
+		EventType:  event.EventType(),
+		Version:    event.EventVersion(),
+		ID:         uuid.NewString(),
+		OccurredAt: time.Now().UTC(),
+		Payload:    payload,
+	})
+}
+
+// Unmarshal decodes an envelope from data into event and returns it. It
+// fails with ErrUnknownEvent if data is not an envelope of event's type, and
+// with ErrUnsupportedVersion if the version differs from event's.
+//
+// Messages published before envelopes were introduced carry the bare v1
+// payload. They are decoded as such and returned with an empty ID, since
+// they have none to deduplicate by.
+func Unmarshal(data []byte, event Event) (*Envelope, error) {
+	var env Envelope
+	if err := json.Unmarshal(data, &env); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrUnknownEvent, err)
+	}
+	if env.EventType == "" && env.Version == 0 {
+		if event.EventVersion() != legacyVersion {
+			return nil, fmt.Errorf("%w: bare %s payload", ErrUnsupportedVersion, event.EventType())
+		}
+		env = Envelope{EventType: event.EventType(), Version: legacyVersion, Payload: data}
+	}
+	if env.EventType != event.EventType() {
+		return nil, fmt.Errorf("%w: got %q, want %q", ErrUnknownEvent, env.EventType, event.EventType())
+	}
+	if env.Version != event.EventVersion() {
+		return nil, fmt.Errorf("%w: %s version %d", ErrUnsupportedVersion, env.EventType, env.Version)
+	}
+	if err := json.Unmarshal(env.Payload, event); err != nil {
+		return nil, fmt.Errorf("invalid %s payload: %w", env.EventType, err)
+	}
+	return &env, nil
+}
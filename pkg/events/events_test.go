@@ -0,0 +1,94 @@
+package events
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMarshal_RoundTrip(t *testing.T) {
+	tests := []struct {
+		name  string
+		event Event
+		into  Event
+	}{
+		{
+			name:  "order created",
+			event: &OrderCreated{OrderID: uuid.NewString(), UserID: uuid.NewString(), TotalAmount: "150000.50"},
+			into:  &OrderCreated{},
+		},
+		{
+			name:  "payment succeeded",
+			event: &PaymentResult{OrderID: uuid.NewString(), PaymentID: "pay_8a3c2a52", Success: true, Message: "payment processed successfully"},
+			into:  &PaymentResult{},
+		},
+		{
+			name:  "payment failed",
+			event: &PaymentResult{OrderID: uuid.NewString(), PaymentID: "pay_8a3c2a52", Message: "payment declined"},
+			into:  &PaymentResult{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := Marshal(tt.event)
+			assert.NoError(t, err)
+
+			env, err := Unmarshal(data, tt.into)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.event, tt.into)
+			assert.Equal(t, tt.event.EventType(), env.EventType)
+			assert.Equal(t, tt.event.EventVersion(), env.Version)
+			assert.NoError(t, uuid.Validate(env.ID))
+			assert.WithinDuration(t, time.Now(), env.OccurredAt, time.Minute)
+		})
+	}
+}
+
+func TestMarshal_EnvelopeFields(t *testing.T) {
+	data, err := Marshal(OrderCreated{OrderID: "o-1", UserID: "u-1", TotalAmount: "10"})
+	assert.NoError(t, err)
+
+	var raw map[string]json.RawMessage
+	assert.NoError(t, json.Unmarshal(data, &raw))
+	assert.JSONEq(t, `"order.created"`, string(raw["event_type"]))
+	assert.JSONEq(t, `1`, string(raw["version"]))
+	assert.JSONEq(t, `{"order_id":"o-1","user_id":"u-1","total_amount":"10"}`, string(raw["payload"]))
+	assert.Contains(t, raw, "id")
+	assert.Contains(t, raw, "occurred_at")
+}
+
+func TestUnmarshal_Rejects(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		wantErr error
+	}{
+		{"not json", `not json`, ErrUnknownEvent},
+		{"other event type", `{"event_type":"payment.result","version":1,"payload":{}}`, ErrUnknownEvent},
+		{"newer version", `{"event_type":"order.created","version":2,"payload":{"order_id":"o-1"}}`, ErrUnsupportedVersion},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var event OrderCreated
+			_, err := Unmarshal([]byte(tt.data), &event)
+			assert.ErrorIs(t, err, tt.wantErr)
+			assert.Equal(t, OrderCreated{}, event)
+		})
+	}
+}
+
+func TestUnmarshal_LegacyPayload(t *testing.T) {
+	var event OrderCreated
+	env, err := Unmarshal([]byte(`{"order_id":"o-1","user_id":"u-1","total_amount":"10"}`), &event)
+
+	assert.NoError(t, err)
+	assert.Equal(t, OrderCreated{OrderID: "o-1", UserID: "u-1", TotalAmount: "10"}, event)
+	assert.Equal(t, "order.created", env.EventType)
+	assert.Equal(t, 1, env.Version)
+	assert.Empty(t, env.ID)
+}
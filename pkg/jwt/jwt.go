@@ -16,9 +16,25 @@ type Claims struct {
 	UserID string `json:"user_id"`
 	Email  string `json:"email"`
 	Role   string `json:"role"`
+	// AuthTime is when the user last signed in with a password. Refreshing
+	// carries it over, so it bounds the age of a session however often its
+	// tokens are rotated.
+	AuthTime *jwt.NumericDate `json:"auth_time,omitempty"`
 	jwt.RegisteredClaims
 }
 
+// AuthenticatedAt returns AuthTime, or IssuedAt for tokens issued before
+// auth_time existed.
+func (c *Claims) AuthenticatedAt() time.Time {
+	if c.AuthTime != nil {
+		return c.AuthTime.Time
+	}
+	if c.IssuedAt != nil {
+		return c.IssuedAt.Time
+	}
+	return time.Time{}
+}
+
 type TokenPair struct {
 	AccessToken  string `json:"access_token"`
 	RefreshToken string `json:"refresh_token"`
@@ -38,13 +54,24 @@ func NewJWTManager(secret string, accessExpiry, refreshExpiry time.Duration) *JW
 	}
 }
 
+// GenerateTokenPair issues tokens for a user who has just authenticated.
 func (m *JWTManager) GenerateTokenPair(userID, email, role string) (*TokenPair, error) {
-	accessToken, err := m.generateToken(userID, email, role, m.accessExpiry)
+	return m.generateTokenPair(userID, email, role, time.Now())
+}
+
+// RefreshTokenPair issues tokens that continue the session of claims,
+// keeping its original authentication time.
+func (m *JWTManager) RefreshTokenPair(claims *Claims) (*TokenPair, error) {
+	return m.generateTokenPair(claims.UserID, claims.Email, claims.Role, claims.AuthenticatedAt())
+}
+
+func (m *JWTManager) generateTokenPair(userID, email, role string, authTime time.Time) (*TokenPair, error) {
+	accessToken, err := m.generateToken(userID, email, role, authTime, m.accessExpiry)
 	if err != nil {
 		return nil, err
 	}
 
-	refreshToken, err := m.generateToken(userID, email, role, m.refreshExpiry)
+	refreshToken, err := m.generateToken(userID, email, role, authTime, m.refreshExpiry)
 	if err != nil {
 		return nil, err
 	}
@@ -77,11 +104,12 @@ func (m *JWTManager) ValidateToken(tokenString string) (*Claims, error) {
 	return claims, nil
 }
 
-func (m *JWTManager) generateToken(userID, email, role string, expiry time.Duration) (string, error) {
+func (m *JWTManager) generateToken(userID, email, role string, authTime time.Time, expiry time.Duration) (string, error) {
 	claims := &Claims{
-		UserID: userID,
-		Email:  email,
-		Role:   role,
+		UserID:   userID,
+		Email:    email,
+		Role:     role,
+		AuthTime: jwt.NewNumericDate(authTime),
 		RegisteredClaims: jwt.RegisteredClaims{
 			ExpiresAt: jwt.NewNumericDate(time.Now().Add(expiry)),
 			IssuedAt:  jwt.NewNumericDate(time.Now()),
@@ -3,6 +3,7 @@ package logger
 import (
 	"context"
 	"fmt"
+	"io"
 	"os"
 	"runtime"
 	"strings"
@@ -21,6 +22,10 @@ const (
 
 var log zerolog.Logger
 
+// events receives business events. It is kept apart from log so the events
+// stay JSON, and free of debug noise, whatever the environment.
+var events = zerolog.Nop()
+
 func Init(env string) {
 	if env == constant.EnvDevelopment {
 		output := zerolog.ConsoleWriter{
@@ -34,6 +39,27 @@ func Init(env string) {
 	} else {
 		log = zerolog.New(os.Stdout).With().Timestamp().Logger()
 	}
+	events = newEventLogger(os.Stdout)
+}
+
+// SetEventsEnabled turns business events on or off. Init enables them.
+func SetEventsEnabled(enabled bool) {
+	if enabled {
+		events = newEventLogger(os.Stdout)
+	} else {
+		events = zerolog.Nop()
+	}
+}
+
+func newEventLogger(w io.Writer) zerolog.Logger {
+	return zerolog.New(w).With().Timestamp().Str("category", "business").Logger()
+}
+
+// SetOutput replaces the logger with a JSON logger writing to w. It is meant
+// for tests that assert on log output.
+func SetOutput(w io.Writer) {
+	log = zerolog.New(w).With().Timestamp().Logger()
+	events = newEventLogger(w)
 }
 
 func WithRequestID(ctx context.Context, requestID string) context.Context {
@@ -113,6 +139,19 @@ func Fatal(ctx context.Context, msg string, err error, fields ...map[string]inte
 	event.Msg(msg)
 }
 
+// Event logs a business event, such as an order being paid, for analytics.
+// Events carry category "business", the event name and the request ID, but
+// none of the caller fields of the other levels, so a data pipeline can pick
+// them out by category and rely on their fields alone.
+func Event(ctx context.Context, name string, fields map[string]interface{}) {
+	event := events.Log().Str("event", name)
+	if requestID := GetRequestID(ctx); requestID != "" {
+		event = event.Str("request_id", requestID)
+	}
+	applyFields(event, fields)
+	event.Send()
+}
+
 func applyFields(event *zerolog.Event, fields ...map[string]interface{}) {
 	for _, f := range fields {
 		for k, v := range f {
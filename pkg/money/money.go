@@ -0,0 +1,109 @@
+package money
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/shopspring/decimal"
+)
+
+// Constraint restricts the range of amounts Parse accepts.
+type Constraint int
+
+const (
+	// Any accepts any finite decimal, including negative amounts.
+	Any Constraint = iota
+	// NonNegative rejects amounts below zero.
+	NonNegative
+	// Positive rejects zero and negative amounts.
+	Positive
+)
+
+const (
+	ReasonRequired    = "is required"
+	ReasonInvalid     = "must be a valid decimal number"
+	ReasonNegative    = "must not be negative"
+	ReasonNotPositive = "must be greater than zero"
+)
+
+// ParseError reports why a string could not be parsed as an amount. Its
+// message is one of the Reason constants so callers can prefix it with the
+// field name, e.g. "invalid price: must not be negative".
+type ParseError struct {
+	Input  string
+	Reason string
+}
+
+func (e *ParseError) Error() string {
+	return e.Reason
+}
+
+// Parse converts s to a decimal amount, trimming surrounding whitespace and
+// enforcing the given constraint.
+func Parse(s string, c Constraint) (decimal.Decimal, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return decimal.Zero, &ParseError{Input: s, Reason: ReasonRequired}
+	}
+
+	amount, err := decimal.NewFromString(s)
+	if err != nil {
+		return decimal.Zero, &ParseError{Input: s, Reason: ReasonInvalid}
+	}
+
+	switch {
+	case c == NonNegative && amount.IsNegative():
+		return decimal.Zero, &ParseError{Input: s, Reason: ReasonNegative}
+	case c == Positive && !amount.IsPositive():
+		return decimal.Zero, &ParseError{Input: s, Reason: ReasonNotPositive}
+	}
+
+	return amount, nil
+}
+
+// Scale is the number of decimal places amounts are shown and charged in.
+const Scale = 2
+
+// Rounding is how an amount with more than Scale decimal places is brought
+// to Scale.
+type Rounding string
+
+const (
+	// RoundHalfUp rounds halves away from zero: 0.125 becomes 0.13.
+	RoundHalfUp Rounding = "half_up"
+	// RoundHalfEven rounds halves to the even neighbour: 0.125 becomes 0.12.
+	RoundHalfEven Rounding = "half_even"
+	// RoundDown truncates toward zero.
+	RoundDown Rounding = "down"
+	// RoundUp rounds away from zero.
+	RoundUp Rounding = "up"
+)
+
+// ParseRounding validates the name of a rounding mode.
+func ParseRounding(s string) (Rounding, error) {
+	switch r := Rounding(strings.TrimSpace(s)); r {
+	case RoundHalfUp, RoundHalfEven, RoundDown, RoundUp:
+		return r, nil
+	}
+	return "", fmt.Errorf("unknown rounding mode %q (want half_up, half_even, down or up)", s)
+}
+
+// Round brings amount to Scale. The zero Rounding rounds half up.
+func (r Rounding) Round(amount decimal.Decimal) decimal.Decimal {
+	switch r {
+	case RoundHalfEven:
+		return amount.RoundBank(Scale)
+	case RoundDown:
+		return amount.RoundDown(Scale)
+	case RoundUp:
+		return amount.RoundUp(Scale)
+	default:
+		return amount.Round(Scale)
+	}
+}
+
+// LineTotal is price times quantity rounded to Scale. Totals are sums of
+// line totals, so the lines a buyer sees always add up to what is charged.
+func (r Rounding) LineTotal(price decimal.Decimal, quantity int) decimal.Decimal {
+	return r.Round(price.Mul(decimal.NewFromInt(int64(quantity))))
+}
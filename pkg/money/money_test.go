@@ -0,0 +1,102 @@
+package money
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name       string
+		input      string
+		constraint Constraint
+		want       string
+		wantReason string
+	}{
+		{name: "integer", input: "15000", constraint: Positive, want: "15000"},
+		{name: "fraction", input: "19.99", constraint: Positive, want: "19.99"},
+		{name: "surrounding whitespace", input: "  250.5 ", constraint: NonNegative, want: "250.5"},
+		{name: "zero allowed when non-negative", input: "0", constraint: NonNegative, want: "0"},
+		{name: "negative allowed when any", input: "-5", constraint: Any, want: "-5"},
+		{name: "empty", input: "", constraint: Any, wantReason: ReasonRequired},
+		{name: "whitespace only", input: "   ", constraint: Any, wantReason: ReasonRequired},
+		{name: "not a number", input: "abc", constraint: Any, wantReason: ReasonInvalid},
+		{name: "trailing garbage", input: "10,000", constraint: Any, wantReason: ReasonInvalid},
+		{name: "negative when non-negative", input: "-0.01", constraint: NonNegative, wantReason: ReasonNegative},
+		{name: "zero when positive", input: "0.00", constraint: Positive, wantReason: ReasonNotPositive},
+		{name: "negative when positive", input: "-1", constraint: Positive, wantReason: ReasonNotPositive},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Parse(tt.input, tt.constraint)
+
+			if tt.wantReason != "" {
+				var parseErr *ParseError
+				assert.True(t, errors.As(err, &parseErr))
+				assert.Equal(t, tt.wantReason, parseErr.Reason)
+				assert.Equal(t, tt.wantReason, err.Error())
+				return
+			}
+			assert.NoError(t, err)
+			assert.True(t, decimal.RequireFromString(tt.want).Equal(got), "got %s", got)
+		})
+	}
+}
+
+func TestRounding_Round(t *testing.T) {
+	tests := []struct {
+		rounding Rounding
+		input    string
+		want     string
+	}{
+		{rounding: "", input: "0.125", want: "0.13"},
+		{rounding: RoundHalfUp, input: "0.125", want: "0.13"},
+		{rounding: RoundHalfUp, input: "-0.125", want: "-0.13"},
+		{rounding: RoundHalfEven, input: "0.125", want: "0.12"},
+		{rounding: RoundHalfEven, input: "0.135", want: "0.14"},
+		{rounding: RoundDown, input: "0.129", want: "0.12"},
+		{rounding: RoundUp, input: "0.121", want: "0.13"},
+		{rounding: RoundUp, input: "0.12", want: "0.12"},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.rounding)+" "+tt.input, func(t *testing.T) {
+			got := tt.rounding.Round(decimal.RequireFromString(tt.input))
+			assert.True(t, decimal.RequireFromString(tt.want).Equal(got), "got %s", got)
+		})
+	}
+}
+
+func TestRounding_LineTotalsSum(t *testing.T) {
+	// Three lines of 0.335: each is shown as 0.34, so the total must be
+	// 1.02. Summing the exact products gives 1.005, which rounds to 1.01
+	// and would not match the lines the buyer sees.
+	price := decimal.RequireFromString("0.335")
+
+	naive := decimal.Zero
+	total := decimal.Zero
+	for i := 0; i < 3; i++ {
+		naive = naive.Add(price.Mul(decimal.NewFromInt(1)))
+		total = total.Add(RoundHalfUp.LineTotal(price, 1))
+	}
+
+	assert.Equal(t, "1.005", naive.String())
+	assert.Equal(t, "1.01", RoundHalfUp.Round(naive).String())
+	assert.Equal(t, "1.02", total.String())
+	assert.Equal(t, "1.00", RoundHalfEven.LineTotal(price, 3).StringFixed(Scale), "a single line is rounded once, half to even")
+}
+
+func TestParseRounding(t *testing.T) {
+	for _, name := range []string{"half_up", "half_even", "down", "up"} {
+		r, err := ParseRounding(name)
+		assert.NoError(t, err)
+		assert.Equal(t, Rounding(name), r)
+	}
+
+	_, err := ParseRounding("bankers")
+	assert.Error(t, err)
+}
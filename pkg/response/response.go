@@ -15,6 +15,7 @@ type Meta struct {
 	RequestID  string      `json:"request_id"`
 	Timestamp  string      `json:"timestamp"`
 	Pagination *Pagination `json:"pagination,omitempty"`
+	NextCursor string      `json:"next_cursor,omitempty"`
 }
 
 type Pagination struct {
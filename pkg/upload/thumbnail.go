@@ -0,0 +1,131 @@
+package upload
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ThumbnailWidth is the width in pixels of generated thumbnails; the height
+// follows the source aspect ratio.
+const ThumbnailWidth = 200
+
+const thumbnailSuffix = "_thumb"
+
+var errThumbnailUnsupported = errors.New("thumbnails are only generated for JPEG and PNG images")
+
+// Thumbnail writes a ThumbnailWidth-wide copy of a previously uploaded image
+// next to the original and returns its relative path. Images already no
+// wider than ThumbnailWidth are returned unchanged. The image header is
+// checked against the dimension limit before any pixels are decoded, so the
+// work stays bounded and it is safe to call synchronously.
+func (u *Uploader) Thumbnail(relativePath string) (string, error) {
+	ext := strings.ToLower(filepath.Ext(relativePath))
+	format := allowedExtensions[ext]
+	if format != "jpeg" && format != "png" {
+		return "", errThumbnailUnsupported
+	}
+
+	src, err := os.Open(filepath.Join(u.baseDir, relativePath))
+	if err != nil {
+		return "", fmt.Errorf("failed to open image: %w", err)
+	}
+	defer src.Close()
+
+	cfg, _, err := image.DecodeConfig(src)
+	if err != nil {
+		return "", fmt.Errorf("failed to decode image: %w", err)
+	}
+	if err := u.checkDimensions(cfg); err != nil {
+		return "", err
+	}
+	if _, err := src.Seek(0, io.SeekStart); err != nil {
+		return "", fmt.Errorf("failed to read image: %w", err)
+	}
+	img, _, err := image.Decode(src)
+	if err != nil {
+		return "", fmt.Errorf("failed to decode image: %w", err)
+	}
+
+	bounds := img.Bounds()
+	if bounds.Dx() <= ThumbnailWidth {
+		return relativePath, nil
+	}
+
+	height := bounds.Dy() * ThumbnailWidth / bounds.Dx()
+	if height < 1 {
+		height = 1
+	}
+	thumb := downscale(img, ThumbnailWidth, height)
+
+	thumbPath := strings.TrimSuffix(relativePath, filepath.Ext(relativePath)) + thumbnailSuffix + filepath.Ext(relativePath)
+	fullPath := filepath.Join(u.baseDir, thumbPath)
+	dst, err := os.Create(fullPath)
+	if err != nil {
+		return "", fmt.Errorf("failed to create thumbnail: %w", err)
+	}
+
+	if format == "png" {
+		err = png.Encode(dst, thumb)
+	} else {
+		err = jpeg.Encode(dst, thumb, &jpeg.Options{Quality: 85})
+	}
+	if err != nil {
+		dst.Close()
+		os.Remove(fullPath)
+		return "", fmt.Errorf("failed to write thumbnail: %w", err)
+	}
+	if err := dst.Close(); err != nil {
+		os.Remove(fullPath)
+		return "", fmt.Errorf("failed to finalize thumbnail: %w", err)
+	}
+
+	return thumbPath, nil
+}
+
+// downscale shrinks img to width x height by averaging the source pixels
+// that fall into each destination pixel.
+func downscale(img image.Image, width, height int) *image.RGBA {
+	b := img.Bounds()
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+
+	for y := 0; y < height; y++ {
+		y0 := b.Min.Y + y*b.Dy()/height
+		y1 := b.Min.Y + (y+1)*b.Dy()/height
+		if y1 <= y0 {
+			y1 = y0 + 1
+		}
+		for x := 0; x < width; x++ {
+			x0 := b.Min.X + x*b.Dx()/width
+			x1 := b.Min.X + (x+1)*b.Dx()/width
+			if x1 <= x0 {
+				x1 = x0 + 1
+			}
+
+			var r, g, bl, a, n uint64
+			for sy := y0; sy < y1; sy++ {
+				for sx := x0; sx < x1; sx++ {
+					pr, pg, pb, pa := img.At(sx, sy).RGBA()
+					r += uint64(pr)
+					g += uint64(pg)
+					bl += uint64(pb)
+					a += uint64(pa)
+					n++
+				}
+			}
+
+			i := dst.PixOffset(x, y)
+			dst.Pix[i+0] = uint8(r / n >> 8)
+			dst.Pix[i+1] = uint8(g / n >> 8)
+			dst.Pix[i+2] = uint8(bl / n >> 8)
+			dst.Pix[i+3] = uint8(a / n >> 8)
+		}
+	}
+	return dst
+}
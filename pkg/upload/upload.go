@@ -1,8 +1,14 @@
 package upload
 
 import (
+	"bytes"
+	"errors"
 	"fmt"
+	"image"
+	_ "image/jpeg"
+	_ "image/png"
 	"io"
+	"mime"
 	"mime/multipart"
 	"os"
 	"path/filepath"
@@ -11,22 +17,49 @@ import (
 	"github.com/google/uuid"
 )
 
-var allowedExtensions = map[string]bool{
-	".jpg":  true,
-	".jpeg": true,
-	".png":  true,
-	".webp": true,
+var (
+	// ErrInvalidImage means the file's content is not a JPEG, PNG or WebP
+	// image matching its extension.
+	ErrInvalidImage = errors.New("file is not a valid image")
+	// ErrImageTooLarge means the image is valid but wider or taller than
+	// allowed.
+	ErrImageTooLarge = errors.New("image dimensions too large")
+	// ErrOutsideUploadDir means a path given to Delete does not name a file
+	// inside the upload directory.
+	ErrOutsideUploadDir = errors.New("path is outside the upload directory")
+)
+
+// MaxImageDimension is the hard cap on the width and height of images an
+// Uploader accepts or decodes, whatever limit it was created with.
+const MaxImageDimension = 16384
+
+// allowedExtensions maps each accepted extension to the image format its
+// content must decode as.
+var allowedExtensions = map[string]string{
+	".jpg":  "jpeg",
+	".jpeg": "jpeg",
+	".png":  "png",
+	".webp": "webp",
 }
 
 type Uploader struct {
-	baseDir string
-	maxSize int64
+	baseDir      string
+	maxSize      int64
+	maxDimension int
 }
 
-func NewUploader(baseDir string, maxSize int64) *Uploader {
+// NewUploader creates an Uploader storing files under baseDir. Files larger
+// than maxSize bytes, or images wider or taller than maxDimension pixels,
+// are rejected; a maxDimension of zero, or one above MaxImageDimension,
+// means MaxImageDimension.
+func NewUploader(baseDir string, maxSize int64, maxDimension int) *Uploader {
+	if maxDimension <= 0 || maxDimension > MaxImageDimension {
+		maxDimension = MaxImageDimension
+	}
 	return &Uploader{
-		baseDir: baseDir,
-		maxSize: maxSize,
+		baseDir:      baseDir,
+		maxSize:      maxSize,
+		maxDimension: maxDimension,
 	}
 }
 
@@ -36,10 +69,18 @@ func (u *Uploader) Upload(file multipart.File, header *multipart.FileHeader, sub
 	}
 
 	ext := strings.ToLower(filepath.Ext(header.Filename))
-	if !allowedExtensions[ext] {
+	format, ok := allowedExtensions[ext]
+	if !ok {
 		return "", fmt.Errorf("file extension %s is not allowed", ext)
 	}
 
+	if err := u.validateImage(file, header, format); err != nil {
+		return "", err
+	}
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return "", fmt.Errorf("failed to read file: %w", err)
+	}
+
 	dir := filepath.Join(u.baseDir, subDir)
 	if err := os.MkdirAll(dir, 0755); err != nil {
 		return "", fmt.Errorf("failed to create upload directory: %w", err)
@@ -67,10 +108,60 @@ func (u *Uploader) Upload(file multipart.File, header *multipart.FileHeader, sub
 	return filepath.Join(subDir, filename), nil
 }
 
+// Delete removes a previously uploaded file. Paths that are absolute or
+// escape the upload directory, such as stored external URLs, are refused.
 func (u *Uploader) Delete(relativePath string) error {
+	if !filepath.IsLocal(relativePath) {
+		return fmt.Errorf("%w: %s", ErrOutsideUploadDir, relativePath)
+	}
 	fullPath := filepath.Join(u.baseDir, relativePath)
 	if err := os.Remove(fullPath); err != nil && !os.IsNotExist(err) {
 		return fmt.Errorf("failed to delete file: %w", err)
 	}
 	return nil
 }
+
+// validateImage reads the image header and checks that the content is of the
+// format the extension (and a declared image content type, if any) claims,
+// and that its dimensions are within limits. Only the header is decoded, so
+// oversized images are rejected before anything allocates their pixels.
+func (u *Uploader) validateImage(file io.Reader, header *multipart.FileHeader, format string) error {
+	cfg, detected, err := decodeImageConfig(file)
+	if err != nil {
+		return ErrInvalidImage
+	}
+	if detected != format {
+		return fmt.Errorf("%w: content is %s but the extension says %s", ErrInvalidImage, detected, format)
+	}
+
+	if declared, _, err := mime.ParseMediaType(header.Header.Get("Content-Type")); err == nil &&
+		strings.HasPrefix(declared, "image/") && declared != "image/"+format {
+		return fmt.Errorf("%w: content is %s but the content type says %s", ErrInvalidImage, detected, declared)
+	}
+
+	return u.checkDimensions(cfg)
+}
+
+func (u *Uploader) checkDimensions(cfg image.Config) error {
+	if cfg.Width > u.maxDimension || cfg.Height > u.maxDimension {
+		return fmt.Errorf("%w: %dx%d exceeds %dx%d", ErrImageTooLarge, cfg.Width, cfg.Height, u.maxDimension, u.maxDimension)
+	}
+	return nil
+}
+
+// decodeImageConfig is image.DecodeConfig with WebP support, which the
+// standard library lacks.
+func decodeImageConfig(r io.Reader) (image.Config, string, error) {
+	head := make([]byte, webpHeaderSize)
+	n, err := io.ReadFull(r, head)
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) {
+		return image.Config{}, "", err
+	}
+	head = head[:n]
+
+	if isWebP(head) {
+		cfg, err := decodeWebPConfig(head)
+		return cfg, "webp", err
+	}
+	return image.DecodeConfig(io.MultiReader(bytes.NewReader(head), r))
+}
@@ -0,0 +1,194 @@
+package upload
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+	"image/jpeg"
+	"image/png"
+	"mime/multipart"
+	"net/textproto"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// memFile is an in-memory multipart.File.
+type memFile struct {
+	*bytes.Reader
+}
+
+func (memFile) Close() error { return nil }
+
+func encodePNG(t *testing.T, w, h int) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	assert.NoError(t, png.Encode(&buf, image.NewRGBA(image.Rect(0, 0, w, h))))
+	return buf.Bytes()
+}
+
+func encodeJPEG(t *testing.T, w, h int) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	assert.NoError(t, jpeg.Encode(&buf, image.NewRGBA(image.Rect(0, 0, w, h)), nil))
+	return buf.Bytes()
+}
+
+// losslessWebP returns the header of a VP8L WebP image of the given size,
+// which is all the uploader inspects.
+func losslessWebP(w, h int) []byte {
+	b := make([]byte, webpHeaderSize)
+	copy(b[0:4], "RIFF")
+	binary.LittleEndian.PutUint32(b[4:8], webpHeaderSize-8)
+	copy(b[8:12], "WEBP")
+	copy(b[12:16], "VP8L")
+	binary.LittleEndian.PutUint32(b[16:20], webpHeaderSize-20)
+	b[20] = 0x2f
+	binary.LittleEndian.PutUint32(b[21:25], uint32(w-1)|uint32(h-1)<<14)
+	return b
+}
+
+func TestUploader_Upload(t *testing.T) {
+	tests := []struct {
+		name        string
+		filename    string
+		contentType string
+		content     []byte
+		wantErr     error
+	}{
+		{name: "valid png", filename: "logo.png", content: encodePNG(t, 16, 16)},
+		{name: "valid jpeg", filename: "photo.JPG", content: encodeJPEG(t, 16, 16)},
+		{name: "valid webp", filename: "photo.webp", content: losslessWebP(16, 16)},
+		{name: "text renamed to png", filename: "notes.png", content: []byte("just some text, not an image at all"), wantErr: ErrInvalidImage},
+		{name: "jpeg renamed to png", filename: "photo.png", content: encodeJPEG(t, 16, 16), wantErr: ErrInvalidImage},
+		{name: "declared content type mismatch", filename: "logo.png", contentType: "image/jpeg", content: encodePNG(t, 16, 16), wantErr: ErrInvalidImage},
+		{name: "png too wide", filename: "banner.png", content: encodePNG(t, 65, 8), wantErr: ErrImageTooLarge},
+		{name: "webp too tall", filename: "banner.webp", content: losslessWebP(8, 65), wantErr: ErrImageTooLarge},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			u := NewUploader(dir, 1<<20, 64)
+
+			header := &multipart.FileHeader{
+				Filename: tt.filename,
+				Size:     int64(len(tt.content)),
+				Header:   textproto.MIMEHeader{},
+			}
+			if tt.contentType != "" {
+				header.Header.Set("Content-Type", tt.contentType)
+			}
+
+			path, err := u.Upload(memFile{bytes.NewReader(tt.content)}, header, "products")
+
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				entries, _ := os.ReadDir(filepath.Join(dir, "products"))
+				assert.Empty(t, entries, "rejected files must not be written")
+				return
+			}
+			assert.NoError(t, err)
+			written, err := os.ReadFile(filepath.Join(dir, path))
+			assert.NoError(t, err)
+			assert.Equal(t, tt.content, written, "the whole file is stored, not just the inspected header")
+		})
+	}
+}
+
+func TestUploader_Thumbnail(t *testing.T) {
+	tests := []struct {
+		name       string
+		filename   string
+		content    []byte
+		wantWidth  int
+		wantHeight int
+		wantSame   bool
+		wantErr    bool
+	}{
+		{name: "wide png is scaled down", filename: "banner.png", content: encodePNG(t, 400, 200), wantWidth: 200, wantHeight: 100},
+		{name: "wide jpeg is scaled down", filename: "photo.jpg", content: encodeJPEG(t, 600, 900), wantWidth: 200, wantHeight: 300},
+		{name: "small image is its own thumbnail", filename: "icon.png", content: encodePNG(t, 64, 64), wantSame: true},
+		{name: "webp is skipped", filename: "photo.webp", content: losslessWebP(400, 200), wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			u := NewUploader(dir, 1<<20, 1000)
+
+			header := &multipart.FileHeader{
+				Filename: tt.filename,
+				Size:     int64(len(tt.content)),
+				Header:   textproto.MIMEHeader{},
+			}
+			path, err := u.Upload(memFile{bytes.NewReader(tt.content)}, header, "products")
+			assert.NoError(t, err)
+
+			thumb, err := u.Thumbnail(path)
+
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			if tt.wantSame {
+				assert.Equal(t, path, thumb)
+				return
+			}
+			assert.NotEqual(t, path, thumb)
+			assert.Equal(t, filepath.Dir(path), filepath.Dir(thumb), "thumbnails are stored alongside the original")
+
+			f, err := os.Open(filepath.Join(dir, thumb))
+			if !assert.NoError(t, err) {
+				return
+			}
+			defer f.Close()
+			cfg, _, err := image.DecodeConfig(f)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.wantWidth, cfg.Width)
+			assert.Equal(t, tt.wantHeight, cfg.Height)
+		})
+	}
+}
+
+func TestUploader_Thumbnail_ChecksDimensionsFirst(t *testing.T) {
+	dir := t.TempDir()
+	u := NewUploader(dir, 1<<20, 100)
+	// Written behind the uploader's back, e.g. under an older, higher limit.
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "banner.png"), encodePNG(t, 400, 200), 0644))
+
+	_, err := u.Thumbnail("banner.png")
+
+	assert.ErrorIs(t, err, ErrImageTooLarge)
+}
+
+func TestUploader_DimensionHardCap(t *testing.T) {
+	u := NewUploader(t.TempDir(), 1<<20, 0)
+	content := encodePNG(t, MaxImageDimension+1, 1)
+	header := &multipart.FileHeader{Filename: "strip.png", Size: int64(len(content)), Header: textproto.MIMEHeader{}}
+
+	_, err := u.Upload(memFile{bytes.NewReader(content)}, header, "products")
+
+	assert.ErrorIs(t, err, ErrImageTooLarge)
+}
+
+func TestUploader_Delete(t *testing.T) {
+	dir := t.TempDir()
+	u := NewUploader(filepath.Join(dir, "uploads"), 1<<20, 0)
+	outside := filepath.Join(dir, "secret.txt")
+	assert.NoError(t, os.WriteFile(outside, []byte("keep me"), 0644))
+	assert.NoError(t, os.MkdirAll(filepath.Join(dir, "uploads", "products"), 0755))
+	inside := filepath.Join(dir, "uploads", "products", "a.png")
+	assert.NoError(t, os.WriteFile(inside, []byte("x"), 0644))
+
+	assert.ErrorIs(t, u.Delete("../secret.txt"), ErrOutsideUploadDir)
+	assert.ErrorIs(t, u.Delete(outside), ErrOutsideUploadDir)
+	assert.FileExists(t, outside)
+
+	assert.NoError(t, u.Delete("products/a.png"))
+	assert.NoFileExists(t, inside)
+	assert.NoError(t, u.Delete("products/a.png"), "deleting a missing file is not an error")
+}
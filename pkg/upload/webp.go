@@ -0,0 +1,51 @@
+package upload
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"image"
+)
+
+// webpHeaderSize covers the RIFF header, the first chunk header and enough
+// of its payload to read the canvas size of any WebP variant.
+const webpHeaderSize = 30
+
+var errInvalidWebP = errors.New("invalid webp header")
+
+func isWebP(b []byte) bool {
+	return len(b) >= 12 && bytes.Equal(b[0:4], []byte("RIFF")) && bytes.Equal(b[8:12], []byte("WEBP"))
+}
+
+// decodeWebPConfig reads the canvas size from the first chunk of a WebP
+// file: VP8 (lossy), VP8L (lossless) or VP8X (extended).
+func decodeWebPConfig(b []byte) (image.Config, error) {
+	if len(b) < webpHeaderSize {
+		return image.Config{}, errInvalidWebP
+	}
+
+	data := b[20:]
+	var width, height int
+	switch string(b[12:16]) {
+	case "VP8 ":
+		if !bytes.Equal(data[3:6], []byte{0x9d, 0x01, 0x2a}) {
+			return image.Config{}, errInvalidWebP
+		}
+		width = int(binary.LittleEndian.Uint16(data[6:8]) & 0x3fff)
+		height = int(binary.LittleEndian.Uint16(data[8:10]) & 0x3fff)
+	case "VP8L":
+		if data[0] != 0x2f {
+			return image.Config{}, errInvalidWebP
+		}
+		bits := binary.LittleEndian.Uint32(data[1:5])
+		width = int(bits&0x3fff) + 1
+		height = int((bits>>14)&0x3fff) + 1
+	case "VP8X":
+		width = int(uint32(data[4])|uint32(data[5])<<8|uint32(data[6])<<16) + 1
+		height = int(uint32(data[7])|uint32(data[8])<<8|uint32(data[9])<<16) + 1
+	default:
+		return image.Config{}, errInvalidWebP
+	}
+
+	return image.Config{Width: width, Height: height}, nil
+}
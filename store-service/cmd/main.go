@@ -2,41 +2,53 @@ package main
 
 import (
 	"context"
+	"io"
 	"log"
 	"net/http"
 	"os"
 	"os/signal"
+	"sync"
 	"syscall"
+	"time"
 
 	pkgjwt "github.com/1tsndre/mini-go-project/pkg/jwt"
 	"github.com/1tsndre/mini-go-project/pkg/logger"
 	"github.com/1tsndre/mini-go-project/pkg/upload"
+	pb "github.com/1tsndre/mini-go-project/proto/payment"
 	"github.com/1tsndre/mini-go-project/store-service/internal/config"
 	"github.com/1tsndre/mini-go-project/store-service/internal/handler"
+	"github.com/1tsndre/mini-go-project/store-service/internal/health"
 	"github.com/1tsndre/mini-go-project/store-service/internal/nsq"
+	"github.com/1tsndre/mini-go-project/store-service/internal/password"
 	"github.com/1tsndre/mini-go-project/store-service/internal/repository"
 	rediscache "github.com/1tsndre/mini-go-project/store-service/internal/repository/caches/redis"
+	"github.com/1tsndre/mini-go-project/store-service/internal/repository/databases"
+	"github.com/1tsndre/mini-go-project/store-service/internal/repository/databases/migrate"
 	"github.com/1tsndre/mini-go-project/store-service/internal/repository/databases/postgres"
 	"github.com/1tsndre/mini-go-project/store-service/internal/router"
 	"github.com/1tsndre/mini-go-project/store-service/internal/service"
+	"github.com/1tsndre/mini-go-project/store-service/internal/worker"
 	goredis "github.com/redis/go-redis/v9"
 
 	"github.com/go-redsync/redsync/v4"
 	redsyncredis "github.com/go-redsync/redsync/v4/redis/goredis/v9"
 	gonsq "github.com/nsqio/go-nsq"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
 )
 
+// readinessCheckTimeout bounds a /readyz probe so a hung dependency is
+// reported as down instead of stalling the orchestrator.
+const readinessCheckTimeout = 2 * time.Second
+
 func main() {
 	cfg, err := config.Load()
 	if err != nil {
 		log.Fatalf("failed to load config: %v", err)
 	}
 
-	if cfg.JWT.Secret == "" {
-		log.Fatal("JWT_SECRET must be set")
-	}
-
 	logger.Init(cfg.App.Env)
+	logger.SetEventsEnabled(cfg.Log.BusinessEvents)
 
 	ctx := context.Background()
 
@@ -46,6 +58,24 @@ func main() {
 	}
 	logger.Info(ctx, "connected to database")
 
+	// "store-service migrate" applies pending migrations and exits.
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		if err := runMigrations(ctx, db, cfg.DB.MigrationsDir); err != nil {
+			logger.Fatal(ctx, "failed to migrate database", err)
+		}
+		return
+	}
+
+	if cfg.JWT.Secret == "" {
+		log.Fatal("JWT_SECRET must be set")
+	}
+
+	if cfg.DB.AutoMigrate {
+		if err := runMigrations(ctx, db, cfg.DB.MigrationsDir); err != nil {
+			logger.Fatal(ctx, "failed to migrate database", err)
+		}
+	}
+
 	redisClient := goredis.NewClient(&goredis.Options{
 		Addr:     cfg.Redis.Addr(),
 		Password: cfg.Redis.Password,
@@ -67,44 +97,185 @@ func main() {
 
 	cache := rediscache.NewRedisCache(redisClient)
 
-	userRepo := repository.NewUserRepository(db)
-	storeRepo := repository.NewStoreRepository(db)
+	userRepo := repository.NewUserRepository(db, cache)
+	storeRepo := repository.NewStoreRepository(db, cache)
 	categoryRepo := repository.NewCategoryRepository(db)
-	productRepo := repository.NewProductRepository(db, cache)
-	cartRepo := repository.NewCartRepository(db, cache)
+	productRepo := repository.NewProductRepository(db, cache, cfg.Product.CacheTTL, cfg.Product.ListCacheTTL, cfg.Product.AlsoBoughtCacheTTL)
+	cartRepo := repository.NewCartRepository(db, cache, cfg.Cart.CacheTTL)
 	orderRepo := repository.NewOrderRepository(db)
 	reviewRepo := repository.NewReviewRepository(db)
+	announcementRepo := repository.NewAnnouncementRepository(db)
+	idempotencyRepo := repository.NewIdempotencyRepository(cache, cfg.Order.IdempotencyTTL)
+	outboxRepo := repository.NewOutboxRepository(db)
+	var stockMovementRepo repository.StockMovementRepository
+	if cfg.Product.StockLedger {
+		stockMovementRepo = repository.NewStockMovementRepository(db)
+	}
 
 	jwtManager := pkgjwt.NewJWTManager(cfg.JWT.Secret, cfg.JWT.AccessExpiry, cfg.JWT.RefreshExpiry)
 
-	authService := service.NewAuthService(userRepo, jwtManager)
-	storeService := service.NewStoreService(storeRepo, userRepo)
-	categoryService := service.NewCategoryService(categoryRepo)
-	productService := service.NewProductService(productRepo, storeRepo)
-	cartService := service.NewCartService(cartRepo, productRepo, rs)
-	orderService := service.NewOrderService(orderRepo, cartRepo, productRepo, storeRepo, rs, nsqProducer)
-	reviewService := service.NewReviewService(reviewRepo)
+	passwordResetRepo := repository.NewPasswordResetRepository(cache, cfg.Auth.PasswordResetTTL)
+	uploadSlotRepo := repository.NewUploadSlotRepository(cache)
+	loginAttemptRepo := repository.NewLoginAttemptRepository(cache, cfg.Auth.LoginFailureWindow, cfg.Auth.LoginLockout)
+	passwordPolicy := password.Policy{
+		MinLength:     cfg.Auth.PasswordMinLength,
+		RequireLetter: cfg.Auth.PasswordRequireLetter,
+		RequireDigit:  cfg.Auth.PasswordRequireDigit,
+		RequireSymbol: cfg.Auth.PasswordRequireSymbol,
+	}
+	authService := service.NewAuthService(userRepo, passwordResetRepo, jwtManager, nsqProducer, loginAttemptRepo, service.AuthConfig{
+		SessionLifetime:  cfg.Auth.SessionLifetime,
+		BcryptCost:       cfg.Auth.BcryptCost,
+		MaxLoginFailures: cfg.Auth.LoginMaxFailures,
+		PasswordPolicy:   passwordPolicy,
+	})
+	storeService := service.NewStoreService(storeRepo, userRepo, productRepo, orderRepo, cfg.Platform.FeePercent, cfg.Platform.ProcessingTimeWindow)
+	categoryService := service.NewCategoryService(categoryRepo, productRepo)
+	productService := service.NewProductService(productRepo, storeRepo, cartRepo, stockMovementRepo, service.ProductConfig{
+		MaxStoreImages:   cfg.Upload.MaxImagesPerStore,
+		MaxAttributes:    cfg.Product.MaxAttributes,
+		AlsoBoughtLimit:  cfg.Product.AlsoBoughtLimit,
+		RelatedLimit:     cfg.Product.RelatedLimit,
+		InStockFirst:     cfg.Product.InStockFirst,
+		DeleteCartPolicy: cfg.Product.DeleteCartPolicy,
+	})
+	cartService := service.NewCartService(cartRepo, productRepo, rs, service.CartConfig{
+		OptimisticLocking: cfg.Cart.OptimisticLocking,
+		SyncConflicts:     cfg.Cart.SyncConflicts,
+		Rounding:          cfg.Platform.Rounding,
+	})
+	shippingCalculator := service.NewFlatRateShippingCalculator(cfg.Shipping.RegionRates, cfg.Shipping.DefaultRate)
+	taxCalculator := service.NewRegionalTaxCalculator(cfg.Tax.RegionRates)
+	orderCfg := service.OrderConfig{
+		HoldThreshold:            cfg.Order.HoldThreshold,
+		LowStockThreshold:        cfg.Order.LowStockThreshold,
+		PaymentUnavailablePolicy: cfg.Order.PaymentUnavailablePolicy,
+		RefundWindow:             cfg.Order.RefundWindow,
+		PaidCancelWindow:         cfg.Order.PaidCancelWindow,
+		GuestCheckoutEnabled:     cfg.Order.GuestCheckoutEnabled,
+		BlockSelfPurchase:        cfg.Order.BlockSelfPurchase,
+		PaymentsDisabled:         cfg.Order.PaymentsDisabled,
+		FeePercent:               cfg.Platform.FeePercent,
+		Rounding:                 cfg.Platform.Rounding,
+		ExpectedTotalTolerance:   cfg.Order.ExpectedTotalTolerance,
+		ReservationTTL:           cfg.Order.ReservationTTL,
+		PaymentTimeout:           cfg.Order.PaymentTimeout,
+	}
+	if err := orderCfg.Validate(nsqProducer); err != nil {
+		logger.Fatal(ctx, "invalid order config", err)
+	}
+	if orderCfg.PaymentsDisabled {
+		logger.Info(ctx, "payments are disabled: orders stay pending until their reservation or payment timeout cancels them")
+	}
+	orderService := service.NewOrderService(orderRepo, cartRepo, productRepo, storeRepo, idempotencyRepo, outboxRepo, stockMovementRepo, rs, nsqProducer, shippingCalculator, taxCalculator, orderCfg)
+	reviewService := service.NewReviewService(reviewRepo, cfg.Review.AllowRepeatPurchase)
+	announcementService := service.NewAnnouncementService(announcementRepo)
+	userService := service.NewUserService(userRepo, storeRepo, productRepo)
+
+	uploader := upload.NewUploader(cfg.Upload.Dir, cfg.Upload.MaxSize, cfg.Upload.MaxImageDimension)
+	uploadLimiter := service.NewUploadLimiter(uploadSlotRepo, cfg.Upload.MaxConcurrent)
 
-	uploader := upload.NewUploader(cfg.Upload.Dir, cfg.Upload.MaxSize)
+	checker := health.NewChecker(readinessCheckTimeout)
+	checker.Add("postgres", func(ctx context.Context) error {
+		sqlDB, err := db.DB().DB()
+		if err != nil {
+			return err
+		}
+		return sqlDB.PingContext(ctx)
+	})
+	checker.Add("redis", func(ctx context.Context) error {
+		return redisClient.Ping(ctx).Err()
+	})
+	checker.Add("nsq", func(context.Context) error {
+		return nsqProducer.Ping()
+	})
 
 	handlers := router.Handlers{
-		Auth:     handler.NewAuthHandler(authService),
-		Store:    handler.NewStoreHandler(storeService, uploader),
-		Category: handler.NewCategoryHandler(categoryService),
-		Product:  handler.NewProductHandler(productService, uploader),
-		Cart:     handler.NewCartHandler(cartService),
-		Order:    handler.NewOrderHandler(orderService),
-		Review:   handler.NewReviewHandler(reviewService),
+		Auth:         handler.NewAuthHandler(authService),
+		Store:        handler.NewStoreHandler(storeService, uploader, uploadLimiter),
+		Category:     handler.NewCategoryHandler(categoryService),
+		Product:      handler.NewProductHandler(productService, uploader, uploadLimiter),
+		Cart:         handler.NewCartHandler(cartService),
+		Order:        handler.NewOrderHandler(orderService),
+		Review:       handler.NewReviewHandler(reviewService),
+		Health:       handler.NewHealthHandler(checker),
+		Announcement: handler.NewAnnouncementHandler(announcementService),
+		User:         handler.NewUserHandler(userService),
 	}
 
-	paymentConsumer := nsq.NewPaymentResultConsumer(orderService)
+	processedEventRepo := repository.NewProcessedEventRepository(cache, cfg.NSQ.DedupeTTL)
+	paymentConsumer := nsq.NewPaymentResultConsumer(orderService, processedEventRepo, nsqProducer, cfg.NSQ.MaxAttempts)
 	if err := paymentConsumer.Start(cfg.NSQ.LookupdAddr); err != nil {
 		logger.Warn(ctx, "failed to start NSQ consumer, payment callbacks won't work", map[string]interface{}{
 			"error": err.Error(),
 		})
 	}
 
-	handler := router.NewRouter(handlers, jwtManager, redisClient, cfg.Upload.Dir, cfg.App.RequestTimeout, cfg.Rate)
+	timeoutConsumer := nsq.NewOrderTimeoutConsumer(orderService)
+	if cfg.Order.PaymentTimeout > 0 {
+		if err := timeoutConsumer.Start(cfg.NSQ.LookupdAddr); err != nil {
+			logger.Warn(ctx, "failed to start NSQ order timeout consumer, unpaid orders won't be cancelled", map[string]interface{}{
+				"error": err.Error(),
+			})
+		}
+	}
+
+	workerCtx, stopWorkers := context.WithCancel(ctx)
+	var workers sync.WaitGroup
+
+	// Nothing is kept in process memory yet, so invalidations announced by
+	// other instances are only traced. In-process caches evict here.
+	workers.Add(1)
+	go func() {
+		defer workers.Done()
+		err := cache.Subscribe(workerCtx, func(key string) {
+			logger.Debug(workerCtx, "cache key invalidated", map[string]interface{}{
+				"key": key,
+			})
+		})
+		if err != nil {
+			logger.Error(ctx, "cache invalidation subscription failed", err)
+		}
+	}()
+
+	cartSweeper := worker.NewCartSweeper(cartRepo, cfg.Cart.ItemMaxAge, cfg.Cart.SweepInterval)
+	workers.Add(1)
+	go func() {
+		defer workers.Done()
+		cartSweeper.Run(workerCtx)
+	}()
+
+	outboxRelay := worker.NewOutboxRelay(outboxRepo, nsqProducer, cfg.Order.OutboxRelayInterval)
+	workers.Add(1)
+	go func() {
+		defer workers.Done()
+		outboxRelay.Run(workerCtx)
+	}()
+
+	if cfg.Order.ReservationTTL > 0 {
+		reservationReaper := worker.NewReservationReaper(orderService, cfg.Order.ReservationReapInterval)
+		workers.Add(1)
+		go func() {
+			defer workers.Done()
+			reservationReaper.Run(workerCtx)
+		}()
+	}
+
+	var paymentConn *grpc.ClientConn
+	if cfg.Order.PaymentReconcileAfter > 0 && !orderCfg.PaymentsDisabled {
+		paymentConn, err = grpc.NewClient(cfg.Order.PaymentGRPCAddr, grpc.WithTransportCredentials(insecure.NewCredentials()))
+		if err != nil {
+			logger.Fatal(ctx, "failed to create payment service client", err)
+		}
+		paymentReconciler := worker.NewPaymentReconciler(orderRepo, orderService, pb.NewPaymentServiceClient(paymentConn), cfg.Order.PaymentReconcileAfter, cfg.Order.PaymentReconcileInterval)
+		workers.Add(1)
+		go func() {
+			defer workers.Done()
+			paymentReconciler.Run(workerCtx)
+		}()
+	}
+
+	handler := router.NewRouter(handlers, jwtManager, userRepo, redisClient, cfg.Upload.Dir, cfg.App.RequestTimeout, cfg.App.MaxBodyBytes, cfg.Upload.MaxSize, cfg.App.TrustedProxies, cfg.Rate, cfg.Log, cfg.CORS, cfg.Compress)
 
 	server := &http.Server{
 		Addr:         ":" + cfg.App.Port,
@@ -132,13 +303,40 @@ func main() {
 	shutdownCtx, cancel := context.WithTimeout(ctx, cfg.App.ShutdownTimeout)
 	defer cancel()
 
-	paymentConsumer.Stop()
-	nsqProducer.Stop()
-	redisClient.Close()
-
-	if err := server.Shutdown(shutdownCtx); err != nil {
+	// In-flight requests may still publish to NSQ and use Redis, so they
+	// drain before anything they depend on is stopped.
+	stoppers := []stopper{
+		stopFunc(func() {
+			stopWorkers()
+			workers.Wait()
+		}),
+		stopFunc(func() { paymentConsumer.Stop(shutdownCtx) }),
+		stopFunc(func() { timeoutConsumer.Stop(shutdownCtx) }),
+		nsqProducer,
+	}
+	closers := []io.Closer{redisClient}
+	if paymentConn != nil {
+		closers = append(closers, paymentConn)
+	}
+	if err := shutdown(shutdownCtx, server, cfg.App.ShutdownGracePeriod, stoppers, closers); err != nil {
 		logger.Fatal(ctx, "server forced to shutdown", err)
 	}
 
 	logger.Info(ctx, "server stopped")
 }
+
+// runMigrations applies the migrations in dir that db does not have yet.
+func runMigrations(ctx context.Context, db databases.Database, dir string) error {
+	migrations, err := migrate.Load(os.DirFS(dir))
+	if err != nil {
+		return err
+	}
+	applied, err := migrate.NewRunner(db, migrations).Up(ctx)
+	if err != nil {
+		return err
+	}
+	logger.Info(ctx, "database schema is up to date", map[string]interface{}{
+		"applied": len(applied),
+	})
+	return nil
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"context"
+	"io"
+	"time"
+
+	"github.com/1tsndre/mini-go-project/pkg/logger"
+)
+
+// drainer stops accepting requests and waits for in-flight ones, like
+// http.Server.
+type drainer interface {
+	Shutdown(ctx context.Context) error
+}
+
+// stopper is a dependency whose Stop returns once it has stopped, like the
+// NSQ producer. NSQ consumers only begin stopping in Stop, so they are
+// adapted with stopFunc to wait for their in-flight messages.
+type stopper interface {
+	Stop()
+}
+
+// stopFunc adapts a function to stopper.
+type stopFunc func()
+
+func (f stopFunc) Stop() { f() }
+
+// shutdown drains server, waits grace for work that outlives its request
+// (a handler still running after the timeout middleware answered), then
+// stops stoppers and closes closers in order. Everything is stopped even if
+// draining fails; the draining error is returned.
+func shutdown(ctx context.Context, server drainer, grace time.Duration, stoppers []stopper, closers []io.Closer) error {
+	err := server.Shutdown(ctx)
+	if err != nil {
+		logger.Error(ctx, "server did not drain in time", err)
+	}
+
+	if grace > 0 {
+		timer := time.NewTimer(grace)
+		select {
+		case <-ctx.Done():
+		case <-timer.C:
+		}
+		timer.Stop()
+	}
+
+	for _, s := range stoppers {
+		s.Stop()
+	}
+	for _, c := range closers {
+		if cerr := c.Close(); cerr != nil {
+			logger.Error(ctx, "failed to close dependency on shutdown", cerr)
+		}
+	}
+	return err
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// shutdownLog records the order dependencies were stopped in.
+type shutdownLog []string
+
+type fakeServer struct {
+	log *shutdownLog
+	err error
+}
+
+func (s *fakeServer) Shutdown(context.Context) error {
+	*s.log = append(*s.log, "server")
+	return s.err
+}
+
+type fakeStopper struct {
+	log  *shutdownLog
+	name string
+}
+
+func (s *fakeStopper) Stop() {
+	*s.log = append(*s.log, s.name)
+}
+
+type fakeCloser struct {
+	log  *shutdownLog
+	name string
+	err  error
+}
+
+func (c *fakeCloser) Close() error {
+	*c.log = append(*c.log, c.name)
+	return c.err
+}
+
+func TestShutdown_Order(t *testing.T) {
+	tests := []struct {
+		name      string
+		serverErr error
+		closeErr  error
+	}{
+		{name: "clean shutdown"},
+		{name: "server fails to drain", serverErr: context.DeadlineExceeded},
+		{name: "close fails", closeErr: errors.New("connection reset")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var log shutdownLog
+			stoppers := []stopper{
+				stopFunc(func() { log = append(log, "workers") }),
+				&fakeStopper{log: &log, name: "consumer"},
+				&fakeStopper{log: &log, name: "producer"},
+			}
+			closers := []io.Closer{&fakeCloser{log: &log, name: "redis", err: tt.closeErr}}
+
+			err := shutdown(context.Background(), &fakeServer{log: &log, err: tt.serverErr}, 0, stoppers, closers)
+
+			assert.Equal(t, tt.serverErr, err)
+			assert.Equal(t, shutdownLog{"server", "workers", "consumer", "producer", "redis"}, log)
+		})
+	}
+}
+
+func TestShutdown_GracePeriod(t *testing.T) {
+	var log shutdownLog
+	var stoppedAfter time.Duration
+	start := time.Now()
+	stoppers := []stopper{stopFunc(func() { stoppedAfter = time.Since(start) })}
+
+	assert.NoError(t, shutdown(context.Background(), &fakeServer{log: &log}, 50*time.Millisecond, stoppers, nil))
+	assert.GreaterOrEqual(t, stoppedAfter, 50*time.Millisecond)
+}
+
+func TestShutdown_GracePeriodBoundedByContext(t *testing.T) {
+	var log shutdownLog
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	assert.NoError(t, shutdown(ctx, &fakeServer{log: &log}, time.Minute, []stopper{&fakeStopper{log: &log, name: "producer"}}, nil))
+	assert.Less(t, time.Since(start), time.Second)
+	assert.Equal(t, shutdownLog{"server", "producer"}, log)
+}
@@ -2,22 +2,39 @@ package config
 
 import (
 	"fmt"
+	"math"
+	"net/netip"
 	"net/url"
+	"slices"
 	"strings"
 	"time"
 
 	pkgconstant "github.com/1tsndre/mini-go-project/pkg/constant"
+	"github.com/1tsndre/mini-go-project/pkg/money"
+	"github.com/shopspring/decimal"
 	"github.com/spf13/viper"
+	"golang.org/x/crypto/bcrypt"
 )
 
 type Config struct {
-	App    AppConfig
-	DB     DBConfig
-	Redis  RedisConfig
-	NSQ    NSQConfig
-	JWT    JWTConfig
-	Rate   RateConfig
-	Upload UploadConfig
+	App      AppConfig
+	DB       DBConfig
+	Redis    RedisConfig
+	NSQ      NSQConfig
+	JWT      JWTConfig
+	Auth     AuthConfig
+	Rate     RateConfig
+	Upload   UploadConfig
+	Shipping ShippingConfig
+	Tax      TaxConfig
+	Order    OrderConfig
+	Cart     CartConfig
+	Product  ProductConfig
+	Review   ReviewConfig
+	Log      LogConfig
+	CORS     CORSConfig
+	Compress CompressConfig
+	Platform PlatformConfig
 }
 
 type AppConfig struct {
@@ -27,7 +44,15 @@ type AppConfig struct {
 	WriteTimeout    time.Duration
 	IdleTimeout     time.Duration
 	ShutdownTimeout time.Duration
-	RequestTimeout  time.Duration
+	// ShutdownGracePeriod is waited after in-flight requests drain and
+	// before NSQ and Redis are stopped.
+	ShutdownGracePeriod time.Duration
+	RequestTimeout      time.Duration
+	// MaxBodyBytes caps non-upload request bodies.
+	MaxBodyBytes int64
+	// TrustedProxies are the reverse proxies whose X-Forwarded-For is
+	// believed when identifying clients for rate limiting and logging.
+	TrustedProxies []netip.Prefix
 }
 
 type DBConfig struct {
@@ -37,6 +62,11 @@ type DBConfig struct {
 	Password string
 	Name     string
 	SSLMode  string
+	// MigrationsDir holds the versioned *.up.sql files applied by the
+	// migrate subcommand, or at startup with AutoMigrate.
+	MigrationsDir string
+	// AutoMigrate applies pending migrations before the server starts.
+	AutoMigrate bool
 }
 
 type RedisConfig struct {
@@ -49,6 +79,15 @@ type RedisConfig struct {
 type NSQConfig struct {
 	LookupdAddr string
 	NsqdAddr    string
+	// MaxAttempts is how many times a consumed message is tried before it
+	// is moved to its dead-letter topic.
+	MaxAttempts uint16
+	// DedupeTTL is how long handled event ids are remembered so redelivered
+	// events are skipped.
+	DedupeTTL time.Duration
+	// MaxReqTimeout is nsqd's --max-req-timeout, the longest a message can
+	// be deferred.
+	MaxReqTimeout time.Duration
 }
 
 type JWTConfig struct {
@@ -57,15 +96,152 @@ type JWTConfig struct {
 	RefreshExpiry time.Duration
 }
 
+type AuthConfig struct {
+	// PasswordResetTTL is how long a forgot-password token stays valid.
+	PasswordResetTTL time.Duration
+	// SessionLifetime is how long after signing in a session can still be
+	// refreshed, however recently its tokens were rotated; zero disables it.
+	SessionLifetime time.Duration
+	// BcryptCost is the cost of new password hashes. Existing hashes keep
+	// the cost they were made with.
+	BcryptCost int
+	// LoginMaxFailures failed logins in a row within LoginFailureWindow lock
+	// the email out for LoginLockout; zero disables the lockout.
+	LoginMaxFailures   int
+	LoginFailureWindow time.Duration
+	LoginLockout       time.Duration
+	// PasswordMinLength and the Password* requirements are the strength
+	// policy every new password must meet.
+	PasswordMinLength     int
+	PasswordRequireLetter bool
+	PasswordRequireDigit  bool
+	PasswordRequireSymbol bool
+}
+
 type RateConfig struct {
 	Public int
 	Auth   int
 	Login  int
+	// LoginIP caps login attempts from one IP across all accounts; Login
+	// caps attempts on each account.
+	LoginIP int
+	// FailOpen lets login requests through when Redis is down. When false
+	// the login limiter answers 503 instead, so a Redis outage cannot be
+	// used to bypass login throttling.
+	FailOpen bool
 }
 
 type UploadConfig struct {
-	MaxSize int64
-	Dir     string
+	MaxSize           int64
+	Dir               string
+	MaxImagesPerStore int
+	MaxConcurrent     int
+	// MaxImageDimension caps the width and height of uploaded images.
+	MaxImageDimension int
+}
+
+type CartConfig struct {
+	CacheTTL          time.Duration
+	ItemMaxAge        time.Duration
+	SweepInterval     time.Duration
+	OptimisticLocking bool
+	SyncConflicts     bool
+}
+
+type OrderConfig struct {
+	HoldThreshold     decimal.Decimal
+	LowStockThreshold int
+	IdempotencyTTL    time.Duration
+	// PaymentUnavailablePolicy is "fail" or "outbox"; see
+	// service.OrderConfig.
+	PaymentUnavailablePolicy string
+	OutboxRelayInterval      time.Duration
+	RefundWindow             time.Duration
+	PaidCancelWindow         time.Duration
+	GuestCheckoutEnabled     bool
+	BlockSelfPurchase        bool
+	PaymentsDisabled         bool
+	ExpectedTotalTolerance   decimal.Decimal
+	ReservationTTL           time.Duration
+	ReservationReapInterval  time.Duration
+	PaymentTimeout           time.Duration
+	// PaymentGRPCAddr is where the payment service answers status queries.
+	PaymentGRPCAddr string
+	// PaymentReconcileAfter is how long an order may stay pending before
+	// its payment status is asked for directly, in case the callback was
+	// lost; zero disables reconciliation.
+	PaymentReconcileAfter    time.Duration
+	PaymentReconcileInterval time.Duration
+}
+
+type PlatformConfig struct {
+	// FeePercent is the commission the platform keeps from seller sales.
+	FeePercent decimal.Decimal
+	// ProcessingTimeWindow is how far back orders count toward a store's
+	// average processing time; zero counts every order.
+	ProcessingTimeWindow time.Duration
+	// Rounding is how cart and order amounts are brought to cents.
+	Rounding money.Rounding
+}
+
+type CORSConfig struct {
+	AllowedOrigins   []string
+	AllowedMethods   []string
+	AllowedHeaders   []string
+	AllowCredentials bool
+	MaxAge           time.Duration
+}
+
+type CompressConfig struct {
+	Enabled  bool
+	MinBytes int
+}
+
+type LogConfig struct {
+	RequestBody  bool
+	BodyMaxBytes int
+	// BusinessEvents logs order lifecycle events with category "business"
+	// for analytics pipelines.
+	BusinessEvents bool
+}
+
+type ProductConfig struct {
+	// CacheTTL is how long a single product stays cached in Redis; zero
+	// disables the cache.
+	CacheTTL     time.Duration
+	ListCacheTTL time.Duration
+	// MaxAttributes caps attributes per product; zero disables the cap.
+	MaxAttributes int
+	// AlsoBoughtLimit is how many "customers also bought" products are
+	// returned; AlsoBoughtCacheTTL is how long that list is cached.
+	AlsoBoughtLimit    int
+	AlsoBoughtCacheTTL time.Duration
+	// RelatedLimit is how many related products are returned.
+	RelatedLimit int
+	// InStockFirst ranks out-of-stock products last within any sort.
+	InStockFirst bool
+	// DeleteCartPolicy is what deleting a product still in buyers' carts
+	// does, one of the constant.ProductDelete* policies.
+	DeleteCartPolicy string
+	// StockLedger records every stock change in the stock_movements table.
+	StockLedger bool
+}
+
+type ReviewConfig struct {
+	// AllowRepeatPurchase lets buyers review a product again after each
+	// further purchase of it.
+	AllowRepeatPurchase bool
+}
+
+type ShippingConfig struct {
+	DefaultRate decimal.Decimal
+	RegionRates map[string]decimal.Decimal
+}
+
+type TaxConfig struct {
+	// RegionRates are tax percentages per region; other regions are not
+	// taxed.
+	RegionRates map[string]decimal.Decimal
 }
 
 func (d DBConfig) DSN() string {
@@ -105,27 +281,98 @@ func Load() (*Config, error) {
 	v.SetDefault("APP_WRITE_TIMEOUT", "15s")
 	v.SetDefault("APP_IDLE_TIMEOUT", "60s")
 	v.SetDefault("APP_SHUTDOWN_TIMEOUT", "30s")
+	v.SetDefault("APP_SHUTDOWN_GRACE_PERIOD", "2s")
 	v.SetDefault("APP_REQUEST_TIMEOUT", "30s")
+	v.SetDefault("APP_MAX_BODY_BYTES", 1048576)
+	v.SetDefault("TRUSTED_PROXIES", "")
 	v.SetDefault("DB_HOST", "localhost")
 	v.SetDefault("DB_PORT", "5432")
 	v.SetDefault("DB_USER", "postgres")
 	v.SetDefault("DB_PASSWORD", "")
 	v.SetDefault("DB_NAME", "mini_go_ecommerce")
 	v.SetDefault("DB_SSLMODE", "disable")
+	v.SetDefault("DB_MIGRATIONS_DIR", "./migrations")
+	v.SetDefault("DB_AUTO_MIGRATE", false)
 	v.SetDefault("REDIS_HOST", "localhost")
 	v.SetDefault("REDIS_PORT", "6379")
 	v.SetDefault("REDIS_PASSWORD", "")
 	v.SetDefault("REDIS_DB", 0)
 	v.SetDefault("NSQ_LOOKUPD_ADDR", "localhost:4161")
 	v.SetDefault("NSQD_ADDR", "localhost:4150")
+	v.SetDefault("NSQ_MAX_ATTEMPTS", 5)
+	v.SetDefault("NSQ_DEDUPE_TTL", "24h")
+	v.SetDefault("NSQ_MAX_REQ_TIMEOUT", "1h")
 	v.SetDefault("JWT_SECRET", "your-super-secret-key-change-this")
 	v.SetDefault("JWT_ACCESS_EXPIRY", "15m")
 	v.SetDefault("JWT_REFRESH_EXPIRY", "168h")
+	v.SetDefault("PASSWORD_RESET_TTL", "30m")
+	v.SetDefault("SESSION_LIFETIME", "0")
+	v.SetDefault("BCRYPT_COST", bcrypt.DefaultCost)
+	v.SetDefault("LOGIN_MAX_FAILURES", 5)
+	v.SetDefault("LOGIN_FAILURE_WINDOW", "15m")
+	v.SetDefault("LOGIN_LOCKOUT", "15m")
+	v.SetDefault("PASSWORD_MIN_LENGTH", 6)
+	v.SetDefault("PASSWORD_REQUIRE_LETTER", false)
+	v.SetDefault("PASSWORD_REQUIRE_DIGIT", false)
+	v.SetDefault("PASSWORD_REQUIRE_SYMBOL", false)
 	v.SetDefault("RATE_LIMIT_PUBLIC", 60)
 	v.SetDefault("RATE_LIMIT_AUTH", 120)
 	v.SetDefault("RATE_LIMIT_LOGIN", 10)
+	v.SetDefault("RATE_LIMIT_LOGIN_IP", 30)
+	v.SetDefault("RATE_LIMIT_FAIL_OPEN", true)
 	v.SetDefault("UPLOAD_MAX_SIZE", 5242880)
 	v.SetDefault("UPLOAD_DIR", "./uploads")
+	v.SetDefault("UPLOAD_MAX_IMAGES_PER_STORE", 500)
+	v.SetDefault("UPLOAD_MAX_CONCURRENT", 3)
+	v.SetDefault("UPLOAD_MAX_IMAGE_DIMENSION", 8000)
+	v.SetDefault("SHIPPING_DEFAULT_RATE", "20000")
+	v.SetDefault("SHIPPING_RATES", "")
+	v.SetDefault("TAX_RATES", "")
+	v.SetDefault("ORDER_HOLD_THRESHOLD", "0")
+	v.SetDefault("ORDER_EXPECTED_TOTAL_TOLERANCE", "0")
+	v.SetDefault("PLATFORM_FEE_PERCENT", "0")
+	v.SetDefault("STORE_PROCESSING_TIME_WINDOW", "2160h")
+	v.SetDefault("MONEY_ROUNDING_MODE", "half_up")
+	v.SetDefault("LOW_STOCK_THRESHOLD", 5)
+	v.SetDefault("ORDER_IDEMPOTENCY_TTL", "24h")
+	v.SetDefault("ORDER_PAYMENT_UNAVAILABLE_POLICY", "outbox")
+	v.SetDefault("OUTBOX_RELAY_INTERVAL", "30s")
+	v.SetDefault("ORDER_REFUND_WINDOW", "720h")
+	v.SetDefault("ORDER_PAID_CANCEL_WINDOW", "0")
+	v.SetDefault("ORDER_GUEST_CHECKOUT_ENABLED", false)
+	v.SetDefault("ORDER_BLOCK_SELF_PURCHASE", false)
+	v.SetDefault("ORDER_PAYMENTS_DISABLED", false)
+	v.SetDefault("ORDER_RESERVATION_TTL", "30m")
+	v.SetDefault("ORDER_RESERVATION_REAP_INTERVAL", "1m")
+	v.SetDefault("ORDER_PAYMENT_TIMEOUT", "0")
+	v.SetDefault("PAYMENT_GRPC_ADDR", "localhost:50051")
+	v.SetDefault("ORDER_PAYMENT_RECONCILE_AFTER", "15m")
+	v.SetDefault("ORDER_PAYMENT_RECONCILE_INTERVAL", "5m")
+	v.SetDefault("CART_CACHE_TTL", "72h")
+	v.SetDefault("CART_ITEM_MAX_AGE", "720h")
+	v.SetDefault("CART_SWEEP_INTERVAL", "1h")
+	v.SetDefault("CART_OPTIMISTIC_LOCKING", true)
+	v.SetDefault("CART_SYNC_CONFLICTS", false)
+	v.SetDefault("PRODUCT_CACHE_TTL", "15m")
+	v.SetDefault("PRODUCT_LIST_CACHE_TTL", "30s")
+	v.SetDefault("PRODUCT_MAX_ATTRIBUTES", 50)
+	v.SetDefault("PRODUCT_ALSO_BOUGHT_LIMIT", 10)
+	v.SetDefault("PRODUCT_RELATED_LIMIT", 10)
+	v.SetDefault("PRODUCT_ALSO_BOUGHT_CACHE_TTL", "1h")
+	v.SetDefault("PRODUCT_IN_STOCK_FIRST", false)
+	v.SetDefault("PRODUCT_DELETE_CART_POLICY", "remove")
+	v.SetDefault("STOCK_LEDGER_ENABLED", true)
+	v.SetDefault("REVIEW_ALLOW_REPEAT_PURCHASE", false)
+	v.SetDefault("COMPRESS_ENABLED", true)
+	v.SetDefault("COMPRESS_MIN_BYTES", 1024)
+	v.SetDefault("CORS_ALLOWED_ORIGINS", "")
+	v.SetDefault("CORS_ALLOWED_METHODS", "GET,POST,PUT,PATCH,DELETE")
+	v.SetDefault("CORS_ALLOWED_HEADERS", "Authorization,Content-Type,Idempotency-Key,X-Request-ID")
+	v.SetDefault("CORS_ALLOW_CREDENTIALS", false)
+	v.SetDefault("CORS_MAX_AGE", "10m")
+	v.SetDefault("LOG_REQUEST_BODY", false)
+	v.SetDefault("LOG_BODY_MAX_BYTES", 4096)
+	v.SetDefault("LOG_BUSINESS_EVENTS", true)
 
 	_ = v.ReadInConfig()
 
@@ -139,6 +386,50 @@ func Load() (*Config, error) {
 		return nil, fmt.Errorf("invalid JWT_REFRESH_EXPIRY: %w", err)
 	}
 
+	passwordResetTTL, err := time.ParseDuration(v.GetString("PASSWORD_RESET_TTL"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid PASSWORD_RESET_TTL: %w", err)
+	}
+	if passwordResetTTL <= 0 {
+		return nil, fmt.Errorf("invalid PASSWORD_RESET_TTL: must be positive")
+	}
+
+	bcryptCost := v.GetInt("BCRYPT_COST")
+	if bcryptCost < bcrypt.MinCost || bcryptCost > bcrypt.MaxCost {
+		return nil, fmt.Errorf("invalid BCRYPT_COST: must be between %d and %d", bcrypt.MinCost, bcrypt.MaxCost)
+	}
+
+	// bcrypt only hashes the first 72 bytes, so a longer minimum would be
+	// meaningless.
+	passwordMinLength := v.GetInt("PASSWORD_MIN_LENGTH")
+	if passwordMinLength < 1 || passwordMinLength > 72 {
+		return nil, fmt.Errorf("invalid PASSWORD_MIN_LENGTH: must be between 1 and 72")
+	}
+
+	loginMaxFailures := v.GetInt("LOGIN_MAX_FAILURES")
+	if loginMaxFailures < 0 {
+		return nil, fmt.Errorf("invalid LOGIN_MAX_FAILURES: must not be negative")
+	}
+	loginFailureWindow, err := time.ParseDuration(v.GetString("LOGIN_FAILURE_WINDOW"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid LOGIN_FAILURE_WINDOW: %w", err)
+	}
+	loginLockout, err := time.ParseDuration(v.GetString("LOGIN_LOCKOUT"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid LOGIN_LOCKOUT: %w", err)
+	}
+	if loginMaxFailures > 0 && (loginFailureWindow <= 0 || loginLockout <= 0) {
+		return nil, fmt.Errorf("invalid LOGIN_FAILURE_WINDOW/LOGIN_LOCKOUT: must be positive when LOGIN_MAX_FAILURES is set")
+	}
+
+	sessionLifetime, err := time.ParseDuration(v.GetString("SESSION_LIFETIME"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid SESSION_LIFETIME: %w", err)
+	}
+	if sessionLifetime < 0 {
+		return nil, fmt.Errorf("invalid SESSION_LIFETIME: must not be negative")
+	}
+
 	readTimeout, err := time.ParseDuration(v.GetString("APP_READ_TIMEOUT"))
 	if err != nil {
 		return nil, fmt.Errorf("invalid APP_READ_TIMEOUT: %w", err)
@@ -159,28 +450,278 @@ func Load() (*Config, error) {
 		return nil, fmt.Errorf("invalid APP_SHUTDOWN_TIMEOUT: %w", err)
 	}
 
+	shutdownGracePeriod, err := time.ParseDuration(v.GetString("APP_SHUTDOWN_GRACE_PERIOD"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid APP_SHUTDOWN_GRACE_PERIOD: %w", err)
+	}
+	if shutdownGracePeriod < 0 {
+		return nil, fmt.Errorf("invalid APP_SHUTDOWN_GRACE_PERIOD: must not be negative")
+	}
+
 	requestTimeout, err := time.ParseDuration(v.GetString("APP_REQUEST_TIMEOUT"))
 	if err != nil {
 		return nil, fmt.Errorf("invalid APP_REQUEST_TIMEOUT: %w", err)
 	}
 
+	maxBodyBytes := v.GetInt64("APP_MAX_BODY_BYTES")
+	if maxBodyBytes <= 0 {
+		return nil, fmt.Errorf("invalid APP_MAX_BODY_BYTES: must be positive")
+	}
+
+	idempotencyTTL, err := time.ParseDuration(v.GetString("ORDER_IDEMPOTENCY_TTL"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid ORDER_IDEMPOTENCY_TTL: %w", err)
+	}
+
+	nsqMaxAttempts := v.GetInt("NSQ_MAX_ATTEMPTS")
+	if nsqMaxAttempts < 1 || nsqMaxAttempts > math.MaxUint16 {
+		return nil, fmt.Errorf("invalid NSQ_MAX_ATTEMPTS: must be between 1 and %d", math.MaxUint16)
+	}
+
+	nsqDedupeTTL, err := time.ParseDuration(v.GetString("NSQ_DEDUPE_TTL"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid NSQ_DEDUPE_TTL: %w", err)
+	}
+	if nsqDedupeTTL <= 0 {
+		return nil, fmt.Errorf("invalid NSQ_DEDUPE_TTL: must be positive")
+	}
+
+	nsqMaxReqTimeout, err := time.ParseDuration(v.GetString("NSQ_MAX_REQ_TIMEOUT"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid NSQ_MAX_REQ_TIMEOUT: %w", err)
+	}
+	if nsqMaxReqTimeout <= 0 {
+		return nil, fmt.Errorf("invalid NSQ_MAX_REQ_TIMEOUT: must be positive")
+	}
+
+	platformFee, err := money.Parse(v.GetString("PLATFORM_FEE_PERCENT"), money.NonNegative)
+	if err != nil {
+		return nil, fmt.Errorf("invalid PLATFORM_FEE_PERCENT: %w", err)
+	}
+	if platformFee.GreaterThan(decimal.NewFromInt(100)) {
+		return nil, fmt.Errorf("invalid PLATFORM_FEE_PERCENT: must not exceed 100")
+	}
+
+	rounding, err := money.ParseRounding(v.GetString("MONEY_ROUNDING_MODE"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid MONEY_ROUNDING_MODE: %w", err)
+	}
+
+	processingTimeWindow, err := time.ParseDuration(v.GetString("STORE_PROCESSING_TIME_WINDOW"))
+	if err != nil || processingTimeWindow < 0 {
+		return nil, fmt.Errorf("invalid STORE_PROCESSING_TIME_WINDOW: %q", v.GetString("STORE_PROCESSING_TIME_WINDOW"))
+	}
+
+	paymentPolicy := v.GetString("ORDER_PAYMENT_UNAVAILABLE_POLICY")
+	if paymentPolicy != "fail" && paymentPolicy != "outbox" {
+		return nil, fmt.Errorf("invalid ORDER_PAYMENT_UNAVAILABLE_POLICY: %q (want fail or outbox)", paymentPolicy)
+	}
+
+	outboxRelayInterval, err := time.ParseDuration(v.GetString("OUTBOX_RELAY_INTERVAL"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid OUTBOX_RELAY_INTERVAL: %w", err)
+	}
+	if outboxRelayInterval <= 0 {
+		return nil, fmt.Errorf("invalid OUTBOX_RELAY_INTERVAL: must be positive")
+	}
+
+	refundWindow, err := time.ParseDuration(v.GetString("ORDER_REFUND_WINDOW"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid ORDER_REFUND_WINDOW: %w", err)
+	}
+	if refundWindow <= 0 {
+		return nil, fmt.Errorf("invalid ORDER_REFUND_WINDOW: must be positive")
+	}
+
+	paidCancelWindow, err := time.ParseDuration(v.GetString("ORDER_PAID_CANCEL_WINDOW"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid ORDER_PAID_CANCEL_WINDOW: %w", err)
+	}
+	if paidCancelWindow < 0 {
+		return nil, fmt.Errorf("invalid ORDER_PAID_CANCEL_WINDOW: must not be negative")
+	}
+
+	reservationTTL, err := time.ParseDuration(v.GetString("ORDER_RESERVATION_TTL"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid ORDER_RESERVATION_TTL: %w", err)
+	}
+	if reservationTTL < 0 {
+		return nil, fmt.Errorf("invalid ORDER_RESERVATION_TTL: must not be negative")
+	}
+
+	reservationReapInterval, err := time.ParseDuration(v.GetString("ORDER_RESERVATION_REAP_INTERVAL"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid ORDER_RESERVATION_REAP_INTERVAL: %w", err)
+	}
+	if reservationReapInterval <= 0 {
+		return nil, fmt.Errorf("invalid ORDER_RESERVATION_REAP_INTERVAL: must be positive")
+	}
+
+	paymentTimeout, err := time.ParseDuration(v.GetString("ORDER_PAYMENT_TIMEOUT"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid ORDER_PAYMENT_TIMEOUT: %w", err)
+	}
+	if paymentTimeout < 0 {
+		return nil, fmt.Errorf("invalid ORDER_PAYMENT_TIMEOUT: must not be negative")
+	}
+	// nsqd rejects a deferral beyond its max-req-timeout, so a longer
+	// timeout would never be scheduled.
+	if paymentTimeout > nsqMaxReqTimeout {
+		return nil, fmt.Errorf("invalid ORDER_PAYMENT_TIMEOUT: must not exceed NSQ_MAX_REQ_TIMEOUT (%s)", nsqMaxReqTimeout)
+	}
+
+	paymentReconcileAfter, err := time.ParseDuration(v.GetString("ORDER_PAYMENT_RECONCILE_AFTER"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid ORDER_PAYMENT_RECONCILE_AFTER: %w", err)
+	}
+	if paymentReconcileAfter < 0 {
+		return nil, fmt.Errorf("invalid ORDER_PAYMENT_RECONCILE_AFTER: must not be negative")
+	}
+
+	paymentReconcileInterval, err := time.ParseDuration(v.GetString("ORDER_PAYMENT_RECONCILE_INTERVAL"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid ORDER_PAYMENT_RECONCILE_INTERVAL: %w", err)
+	}
+	if paymentReconcileInterval <= 0 {
+		return nil, fmt.Errorf("invalid ORDER_PAYMENT_RECONCILE_INTERVAL: must be positive")
+	}
+
+	productCacheTTL, err := time.ParseDuration(v.GetString("PRODUCT_CACHE_TTL"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid PRODUCT_CACHE_TTL: %w", err)
+	}
+
+	productListCacheTTL, err := time.ParseDuration(v.GetString("PRODUCT_LIST_CACHE_TTL"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid PRODUCT_LIST_CACHE_TTL: %w", err)
+	}
+
+	maxAttributes := v.GetInt("PRODUCT_MAX_ATTRIBUTES")
+	if maxAttributes < 0 {
+		return nil, fmt.Errorf("invalid PRODUCT_MAX_ATTRIBUTES: must not be negative")
+	}
+
+	alsoBoughtLimit := v.GetInt("PRODUCT_ALSO_BOUGHT_LIMIT")
+	if alsoBoughtLimit <= 0 {
+		return nil, fmt.Errorf("invalid PRODUCT_ALSO_BOUGHT_LIMIT: must be positive")
+	}
+	alsoBoughtCacheTTL, err := time.ParseDuration(v.GetString("PRODUCT_ALSO_BOUGHT_CACHE_TTL"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid PRODUCT_ALSO_BOUGHT_CACHE_TTL: %w", err)
+	}
+
+	relatedLimit := v.GetInt("PRODUCT_RELATED_LIMIT")
+	if relatedLimit <= 0 {
+		return nil, fmt.Errorf("invalid PRODUCT_RELATED_LIMIT: must be positive")
+	}
+
+	deleteCartPolicy := v.GetString("PRODUCT_DELETE_CART_POLICY")
+	if deleteCartPolicy != "remove" && deleteCartPolicy != "unavailable" {
+		return nil, fmt.Errorf("invalid PRODUCT_DELETE_CART_POLICY: %q (want remove or unavailable)", deleteCartPolicy)
+	}
+
+	cartCacheTTL, err := time.ParseDuration(v.GetString("CART_CACHE_TTL"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid CART_CACHE_TTL: %w", err)
+	}
+
+	cartItemMaxAge, err := time.ParseDuration(v.GetString("CART_ITEM_MAX_AGE"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid CART_ITEM_MAX_AGE: %w", err)
+	}
+	if cartItemMaxAge <= 0 {
+		return nil, fmt.Errorf("invalid CART_ITEM_MAX_AGE: must be positive")
+	}
+
+	cartSweepInterval, err := time.ParseDuration(v.GetString("CART_SWEEP_INTERVAL"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid CART_SWEEP_INTERVAL: %w", err)
+	}
+	if cartSweepInterval <= 0 {
+		return nil, fmt.Errorf("invalid CART_SWEEP_INTERVAL: must be positive")
+	}
+
+	shippingDefaultRate, err := money.Parse(v.GetString("SHIPPING_DEFAULT_RATE"), money.NonNegative)
+	if err != nil {
+		return nil, fmt.Errorf("invalid SHIPPING_DEFAULT_RATE: %w", err)
+	}
+
+	trustedProxies, err := parseTrustedProxies(v.GetString("TRUSTED_PROXIES"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid TRUSTED_PROXIES: %w", err)
+	}
+
+	shippingRates, err := parseRegionRates(v.GetString("SHIPPING_RATES"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid SHIPPING_RATES: %w", err)
+	}
+
+	taxRates, err := parseRegionRates(v.GetString("TAX_RATES"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid TAX_RATES: %w", err)
+	}
+	for region, rate := range taxRates {
+		if rate.GreaterThan(decimal.NewFromInt(100)) {
+			return nil, fmt.Errorf("invalid TAX_RATES: rate for %q must not exceed 100", region)
+		}
+	}
+
+	holdThreshold, err := money.Parse(v.GetString("ORDER_HOLD_THRESHOLD"), money.NonNegative)
+	if err != nil {
+		return nil, fmt.Errorf("invalid ORDER_HOLD_THRESHOLD: %w", err)
+	}
+
+	expectedTotalTolerance, err := money.Parse(v.GetString("ORDER_EXPECTED_TOTAL_TOLERANCE"), money.NonNegative)
+	if err != nil {
+		return nil, fmt.Errorf("invalid ORDER_EXPECTED_TOTAL_TOLERANCE: %w", err)
+	}
+
+	maxConcurrentUploads := v.GetInt("UPLOAD_MAX_CONCURRENT")
+	if maxConcurrentUploads < 0 {
+		return nil, fmt.Errorf("invalid UPLOAD_MAX_CONCURRENT: must not be negative")
+	}
+	maxImageDimension := v.GetInt("UPLOAD_MAX_IMAGE_DIMENSION")
+	if maxImageDimension <= 0 {
+		return nil, fmt.Errorf("invalid UPLOAD_MAX_IMAGE_DIMENSION: must be positive")
+	}
+
+	compressMinBytes := v.GetInt("COMPRESS_MIN_BYTES")
+	if compressMinBytes < 0 {
+		return nil, fmt.Errorf("invalid COMPRESS_MIN_BYTES: must not be negative")
+	}
+
+	corsOrigins := splitList(v.GetString("CORS_ALLOWED_ORIGINS"))
+	corsCredentials := v.GetBool("CORS_ALLOW_CREDENTIALS")
+	if corsCredentials && slices.Contains(corsOrigins, "*") {
+		return nil, fmt.Errorf("invalid CORS_ALLOWED_ORIGINS: \"*\" cannot be combined with CORS_ALLOW_CREDENTIALS")
+	}
+	corsMaxAge, err := time.ParseDuration(v.GetString("CORS_MAX_AGE"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid CORS_MAX_AGE: %w", err)
+	}
+
 	return &Config{
 		App: AppConfig{
-			Port:            v.GetString("APP_PORT"),
-			Env:             v.GetString("APP_ENV"),
-			ReadTimeout:     readTimeout,
-			WriteTimeout:    writeTimeout,
-			IdleTimeout:     idleTimeout,
-			ShutdownTimeout: shutdownTimeout,
-			RequestTimeout:  requestTimeout,
+			Port:                v.GetString("APP_PORT"),
+			Env:                 v.GetString("APP_ENV"),
+			ReadTimeout:         readTimeout,
+			WriteTimeout:        writeTimeout,
+			IdleTimeout:         idleTimeout,
+			ShutdownTimeout:     shutdownTimeout,
+			ShutdownGracePeriod: shutdownGracePeriod,
+			RequestTimeout:      requestTimeout,
+			MaxBodyBytes:        maxBodyBytes,
+			TrustedProxies:      trustedProxies,
 		},
 		DB: DBConfig{
-			Host:     v.GetString("DB_HOST"),
-			Port:     v.GetString("DB_PORT"),
-			User:     v.GetString("DB_USER"),
-			Password: v.GetString("DB_PASSWORD"),
-			Name:     v.GetString("DB_NAME"),
-			SSLMode:  v.GetString("DB_SSLMODE"),
+			Host:          v.GetString("DB_HOST"),
+			Port:          v.GetString("DB_PORT"),
+			User:          v.GetString("DB_USER"),
+			Password:      v.GetString("DB_PASSWORD"),
+			Name:          v.GetString("DB_NAME"),
+			SSLMode:       v.GetString("DB_SSLMODE"),
+			MigrationsDir: v.GetString("DB_MIGRATIONS_DIR"),
+			AutoMigrate:   v.GetBool("DB_AUTO_MIGRATE"),
 		},
 		Redis: RedisConfig{
 			Host:     v.GetString("REDIS_HOST"),
@@ -189,22 +730,166 @@ func Load() (*Config, error) {
 			DB:       v.GetInt("REDIS_DB"),
 		},
 		NSQ: NSQConfig{
-			LookupdAddr: v.GetString("NSQ_LOOKUPD_ADDR"),
-			NsqdAddr:    v.GetString("NSQD_ADDR"),
+			LookupdAddr:   v.GetString("NSQ_LOOKUPD_ADDR"),
+			NsqdAddr:      v.GetString("NSQD_ADDR"),
+			MaxAttempts:   uint16(nsqMaxAttempts),
+			DedupeTTL:     nsqDedupeTTL,
+			MaxReqTimeout: nsqMaxReqTimeout,
 		},
 		JWT: JWTConfig{
 			Secret:        v.GetString("JWT_SECRET"),
 			AccessExpiry:  accessExpiry,
 			RefreshExpiry: refreshExpiry,
 		},
+		Auth: AuthConfig{
+			PasswordResetTTL:   passwordResetTTL,
+			SessionLifetime:    sessionLifetime,
+			BcryptCost:         bcryptCost,
+			LoginMaxFailures:   loginMaxFailures,
+			LoginFailureWindow: loginFailureWindow,
+			LoginLockout:       loginLockout,
+
+			PasswordMinLength:     passwordMinLength,
+			PasswordRequireLetter: v.GetBool("PASSWORD_REQUIRE_LETTER"),
+			PasswordRequireDigit:  v.GetBool("PASSWORD_REQUIRE_DIGIT"),
+			PasswordRequireSymbol: v.GetBool("PASSWORD_REQUIRE_SYMBOL"),
+		},
 		Rate: RateConfig{
-			Public: v.GetInt("RATE_LIMIT_PUBLIC"),
-			Auth:   v.GetInt("RATE_LIMIT_AUTH"),
-			Login:  v.GetInt("RATE_LIMIT_LOGIN"),
+			Public:   v.GetInt("RATE_LIMIT_PUBLIC"),
+			Auth:     v.GetInt("RATE_LIMIT_AUTH"),
+			Login:    v.GetInt("RATE_LIMIT_LOGIN"),
+			LoginIP:  v.GetInt("RATE_LIMIT_LOGIN_IP"),
+			FailOpen: v.GetBool("RATE_LIMIT_FAIL_OPEN"),
 		},
 		Upload: UploadConfig{
-			MaxSize: v.GetInt64("UPLOAD_MAX_SIZE"),
-			Dir:     v.GetString("UPLOAD_DIR"),
+			MaxSize:           v.GetInt64("UPLOAD_MAX_SIZE"),
+			Dir:               v.GetString("UPLOAD_DIR"),
+			MaxImagesPerStore: v.GetInt("UPLOAD_MAX_IMAGES_PER_STORE"),
+			MaxConcurrent:     maxConcurrentUploads,
+			MaxImageDimension: maxImageDimension,
+		},
+		Shipping: ShippingConfig{
+			DefaultRate: shippingDefaultRate,
+			RegionRates: shippingRates,
+		},
+		Tax: TaxConfig{
+			RegionRates: taxRates,
+		},
+		Order: OrderConfig{
+			HoldThreshold:            holdThreshold,
+			LowStockThreshold:        v.GetInt("LOW_STOCK_THRESHOLD"),
+			IdempotencyTTL:           idempotencyTTL,
+			PaymentUnavailablePolicy: paymentPolicy,
+			OutboxRelayInterval:      outboxRelayInterval,
+			RefundWindow:             refundWindow,
+			PaidCancelWindow:         paidCancelWindow,
+			GuestCheckoutEnabled:     v.GetBool("ORDER_GUEST_CHECKOUT_ENABLED"),
+			BlockSelfPurchase:        v.GetBool("ORDER_BLOCK_SELF_PURCHASE"),
+			PaymentsDisabled:         v.GetBool("ORDER_PAYMENTS_DISABLED"),
+			ExpectedTotalTolerance:   expectedTotalTolerance,
+			ReservationTTL:           reservationTTL,
+			ReservationReapInterval:  reservationReapInterval,
+			PaymentTimeout:           paymentTimeout,
+			PaymentGRPCAddr:          v.GetString("PAYMENT_GRPC_ADDR"),
+			PaymentReconcileAfter:    paymentReconcileAfter,
+			PaymentReconcileInterval: paymentReconcileInterval,
+		},
+		Cart: CartConfig{
+			CacheTTL:          cartCacheTTL,
+			ItemMaxAge:        cartItemMaxAge,
+			SweepInterval:     cartSweepInterval,
+			OptimisticLocking: v.GetBool("CART_OPTIMISTIC_LOCKING"),
+			SyncConflicts:     v.GetBool("CART_SYNC_CONFLICTS"),
+		},
+		Product: ProductConfig{
+			CacheTTL:           productCacheTTL,
+			ListCacheTTL:       productListCacheTTL,
+			MaxAttributes:      maxAttributes,
+			AlsoBoughtLimit:    alsoBoughtLimit,
+			RelatedLimit:       relatedLimit,
+			AlsoBoughtCacheTTL: alsoBoughtCacheTTL,
+			InStockFirst:       v.GetBool("PRODUCT_IN_STOCK_FIRST"),
+			DeleteCartPolicy:   deleteCartPolicy,
+			StockLedger:        v.GetBool("STOCK_LEDGER_ENABLED"),
+		},
+		Review: ReviewConfig{
+			AllowRepeatPurchase: v.GetBool("REVIEW_ALLOW_REPEAT_PURCHASE"),
+		},
+		Platform: PlatformConfig{
+			FeePercent:           platformFee,
+			ProcessingTimeWindow: processingTimeWindow,
+			Rounding:             rounding,
+		},
+		Log: LogConfig{
+			RequestBody:    v.GetBool("LOG_REQUEST_BODY"),
+			BodyMaxBytes:   v.GetInt("LOG_BODY_MAX_BYTES"),
+			BusinessEvents: v.GetBool("LOG_BUSINESS_EVENTS"),
+		},
+		CORS: CORSConfig{
+			AllowedOrigins:   corsOrigins,
+			AllowedMethods:   splitList(v.GetString("CORS_ALLOWED_METHODS")),
+			AllowedHeaders:   splitList(v.GetString("CORS_ALLOWED_HEADERS")),
+			AllowCredentials: corsCredentials,
+			MaxAge:           corsMaxAge,
+		},
+		Compress: CompressConfig{
+			Enabled:  v.GetBool("COMPRESS_ENABLED"),
+			MinBytes: compressMinBytes,
 		},
 	}, nil
 }
+
+// splitList splits a comma-separated value, dropping blank entries.
+func splitList(raw string) []string {
+	var items []string
+	for _, item := range strings.Split(raw, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// parseTrustedProxies parses a comma-separated list of CIDRs or single
+// addresses, e.g. "10.0.0.0/8,192.168.1.10".
+func parseTrustedProxies(raw string) ([]netip.Prefix, error) {
+	var prefixes []netip.Prefix
+	for _, item := range splitList(raw) {
+		if !strings.Contains(item, "/") {
+			addr, err := netip.ParseAddr(item)
+			if err != nil {
+				return nil, fmt.Errorf("malformed entry %q", item)
+			}
+			prefixes = append(prefixes, netip.PrefixFrom(addr, addr.BitLen()))
+			continue
+		}
+		prefix, err := netip.ParsePrefix(item)
+		if err != nil {
+			return nil, fmt.Errorf("malformed entry %q", item)
+		}
+		prefixes = append(prefixes, prefix.Masked())
+	}
+	return prefixes, nil
+}
+
+// parseRegionRates parses a comma-separated list of region:rate pairs,
+// e.g. "jakarta:10000,bandung:15000".
+func parseRegionRates(raw string) (map[string]decimal.Decimal, error) {
+	rates := make(map[string]decimal.Decimal)
+	for _, pair := range strings.Split(raw, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+		region, rate, ok := strings.Cut(pair, ":")
+		if !ok || strings.TrimSpace(region) == "" {
+			return nil, fmt.Errorf("malformed entry %q", pair)
+		}
+		amount, err := money.Parse(rate, money.NonNegative)
+		if err != nil {
+			return nil, fmt.Errorf("invalid rate for %q: %w", region, err)
+		}
+		rates[strings.TrimSpace(region)] = amount
+	}
+	return rates, nil
+}
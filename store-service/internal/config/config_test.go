@@ -0,0 +1,142 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/crypto/bcrypt"
+)
+
+func TestLoad_BcryptCost(t *testing.T) {
+	t.Run("defaults to bcrypt's default cost", func(t *testing.T) {
+		cfg, err := Load()
+
+		assert.NoError(t, err)
+		assert.Equal(t, bcrypt.DefaultCost, cfg.Auth.BcryptCost)
+	})
+
+	t.Run("valid cost is used", func(t *testing.T) {
+		t.Setenv("BCRYPT_COST", "12")
+
+		cfg, err := Load()
+
+		assert.NoError(t, err)
+		assert.Equal(t, 12, cfg.Auth.BcryptCost)
+	})
+
+	for _, cost := range []string{"3", "32"} {
+		t.Run("out of range cost "+cost+" is rejected", func(t *testing.T) {
+			t.Setenv("BCRYPT_COST", cost)
+
+			_, err := Load()
+
+			assert.ErrorContains(t, err, "invalid BCRYPT_COST")
+		})
+	}
+}
+
+func TestLoad_PasswordPolicy(t *testing.T) {
+	t.Run("defaults to six characters and no other rules", func(t *testing.T) {
+		cfg, err := Load()
+
+		assert.NoError(t, err)
+		assert.Equal(t, 6, cfg.Auth.PasswordMinLength)
+		assert.False(t, cfg.Auth.PasswordRequireLetter)
+		assert.False(t, cfg.Auth.PasswordRequireDigit)
+		assert.False(t, cfg.Auth.PasswordRequireSymbol)
+	})
+
+	t.Run("rules are read from the environment", func(t *testing.T) {
+		t.Setenv("PASSWORD_MIN_LENGTH", "10")
+		t.Setenv("PASSWORD_REQUIRE_LETTER", "true")
+		t.Setenv("PASSWORD_REQUIRE_DIGIT", "true")
+		t.Setenv("PASSWORD_REQUIRE_SYMBOL", "true")
+
+		cfg, err := Load()
+
+		assert.NoError(t, err)
+		assert.Equal(t, 10, cfg.Auth.PasswordMinLength)
+		assert.True(t, cfg.Auth.PasswordRequireLetter)
+		assert.True(t, cfg.Auth.PasswordRequireDigit)
+		assert.True(t, cfg.Auth.PasswordRequireSymbol)
+	})
+
+	for _, length := range []string{"0", "73"} {
+		t.Run("out of range min length "+length+" is rejected", func(t *testing.T) {
+			t.Setenv("PASSWORD_MIN_LENGTH", length)
+
+			_, err := Load()
+
+			assert.ErrorContains(t, err, "invalid PASSWORD_MIN_LENGTH")
+		})
+	}
+}
+
+func TestLoad_CartSweeper(t *testing.T) {
+	for _, key := range []string{"CART_ITEM_MAX_AGE", "CART_SWEEP_INTERVAL"} {
+		for _, value := range []string{"0s", "-1h"} {
+			t.Run(key+"="+value+" is rejected", func(t *testing.T) {
+				t.Setenv(key, value)
+
+				_, err := Load()
+
+				assert.ErrorContains(t, err, "invalid "+key)
+			})
+		}
+	}
+}
+
+func TestLoad_OutboxRelayInterval(t *testing.T) {
+	for _, value := range []string{"0s", "-30s"} {
+		t.Run(value+" is rejected", func(t *testing.T) {
+			t.Setenv("OUTBOX_RELAY_INTERVAL", value)
+
+			_, err := Load()
+
+			assert.ErrorContains(t, err, "invalid OUTBOX_RELAY_INTERVAL")
+		})
+	}
+}
+
+func TestLoad_MaxImageDimension(t *testing.T) {
+	for _, value := range []string{"0", "-1"} {
+		t.Run(value+" is rejected", func(t *testing.T) {
+			t.Setenv("UPLOAD_MAX_IMAGE_DIMENSION", value)
+
+			_, err := Load()
+
+			assert.ErrorContains(t, err, "invalid UPLOAD_MAX_IMAGE_DIMENSION")
+		})
+	}
+}
+
+func TestLoad_PaymentTimeout(t *testing.T) {
+	tests := []struct {
+		name       string
+		timeout    string
+		maxTimeout string
+		wantErr    string
+	}{
+		{name: "within the default nsqd limit", timeout: "30m"},
+		{name: "beyond the default nsqd limit", timeout: "2h", wantErr: "invalid ORDER_PAYMENT_TIMEOUT"},
+		{name: "within a raised nsqd limit", timeout: "2h", maxTimeout: "3h"},
+		{name: "non-positive nsqd limit", timeout: "0s", maxTimeout: "0s", wantErr: "invalid NSQ_MAX_REQ_TIMEOUT"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("ORDER_PAYMENT_TIMEOUT", tt.timeout)
+			if tt.maxTimeout != "" {
+				t.Setenv("NSQ_MAX_REQ_TIMEOUT", tt.maxTimeout)
+			}
+
+			_, err := Load()
+
+			if tt.wantErr != "" {
+				assert.ErrorContains(t, err, tt.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
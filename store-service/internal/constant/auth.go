@@ -5,3 +5,10 @@ const (
 	RoleBuyer  = "buyer"
 	RoleSeller = "seller"
 )
+
+// Roles is every role a user can have.
+var Roles = map[string]bool{
+	RoleAdmin:  true,
+	RoleBuyer:  true,
+	RoleSeller: true,
+}
@@ -4,14 +4,55 @@ import "time"
 
 const (
 	KeyProduct   = "product:%s"
+	KeyStore     = "store:%s"
 	KeyCart      = "cart:%s"
 	KeyUser      = "user:%s"
 	KeyRateLimit = "rate_limit:%s:%s"
 	KeyStockLock = "stock_lock:%s"
 	KeyCartLock  = "cart_lock:%s"
+
+	// KeyStoreOwner maps a user id to the id of the store they own.
+	KeyStoreOwner = "store_owner:%s"
+	// KeyStoreProcessingTime caches a store's average order processing
+	// time, dropped together with KeyStore.
+	KeyStoreProcessingTime = "store_processing_time:%s"
+
+	KeyProductList    = "product_list:%s"
+	KeyProductListGen = "product_list_gen:%s"
+
+	// KeyProductAlsoBought holds ranked product ids, keyed by product, limit
+	// and the generation in KeyProductListGen under the also_bought scope.
+	KeyProductAlsoBought = "product_also_bought:%s:%d:%s"
+
+	KeyCheckoutIdempotency     = "idempotency:checkout:%s:%s"
+	KeyCheckoutIdempotencyLock = "idempotency_lock:checkout:%s:%s"
+
+	KeyPasswordReset = "password_reset:%s"
+
+	// KeyLoginFailures counts recent failed logins for an email, and
+	// KeyLoginLock marks the email locked out.
+	KeyLoginFailures = "login_failures:%s"
+	KeyLoginLock     = "login_lock:%s"
+
+	KeyUploadSlots = "upload_slots:%s"
+
+	// KeyUserActive caches whether a user is allowed to use their tokens.
+	KeyUserActive = "user_active:%s"
+
+	// KeyProcessedEvent marks an NSQ event id as handled by a consumer.
+	KeyProcessedEvent = "processed_event:%s:%s"
 )
 
+// ChannelCacheInvalidation is the Redis pub/sub channel on which invalidated
+// cache keys are announced.
+const ChannelCacheInvalidation = "cache_invalidation"
+
 const (
-	TTLProduct = 15 * time.Minute
-	TTLCart    = 0 // no expiry
+	TTLStore = 15 * time.Minute
+	// TTLUploadSlots bounds how long a leaked upload slot (e.g. after a
+	// crash) can count against a user.
+	TTLUploadSlots = 15 * time.Minute
+	// TTLUserActive bounds how long a banned user's tokens keep working if
+	// dropping the cached flag fails.
+	TTLUserActive = 30 * time.Second
 )
@@ -1,14 +1,21 @@
 package constant
 
 const (
-	ErrCodeValidation        = "VALIDATION_ERROR"
-	ErrCodeNotFound          = "NOT_FOUND"
-	ErrCodeUnauthorized      = "UNAUTHORIZED"
-	ErrCodeForbidden         = "FORBIDDEN"
-	ErrCodeConflict          = "CONFLICT"
-	ErrCodeInternal          = "INTERNAL_ERROR"
-	ErrCodeRateLimited       = "RATE_LIMITED"
-	ErrCodeInsufficientStock = "INSUFFICIENT_STOCK"
-	ErrCodeInvalidStatus     = "INVALID_STATUS"
-	ErrCodeTimeout           = "REQUEST_TIMEOUT"
+	ErrCodeValidation         = "VALIDATION_ERROR"
+	ErrCodeNotFound           = "NOT_FOUND"
+	ErrCodeUnauthorized       = "UNAUTHORIZED"
+	ErrCodeForbidden          = "FORBIDDEN"
+	ErrCodeConflict           = "CONFLICT"
+	ErrCodeInternal           = "INTERNAL_ERROR"
+	ErrCodeRateLimited        = "RATE_LIMITED"
+	ErrCodeInsufficientStock  = "INSUFFICIENT_STOCK"
+	ErrCodeInvalidStatus      = "INVALID_STATUS"
+	ErrCodeTimeout            = "REQUEST_TIMEOUT"
+	ErrCodeIdempotencyReused  = "IDEMPOTENCY_KEY_REUSED"
+	ErrCodePaymentUnavailable = "PAYMENT_UNAVAILABLE"
+	ErrCodePayloadTooLarge    = "PAYLOAD_TOO_LARGE"
+	ErrCodeServiceUnavailable = "SERVICE_UNAVAILABLE"
+	ErrCodePriceChanged       = "PRICE_CHANGED"
+	ErrCodeAccountLocked      = "ACCOUNT_LOCKED"
+	ErrCodeAccountDisabled    = "ACCOUNT_DISABLED"
 )
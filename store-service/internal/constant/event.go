@@ -0,0 +1,9 @@
+package constant
+
+// Business events logged for analytics, see logger.Event.
+const (
+	EventOrderCreated   = "order_created"
+	EventOrderPaid      = "order_paid"
+	EventOrderCancelled = "order_cancelled"
+	EventOrderShipped   = "order_shipped"
+)
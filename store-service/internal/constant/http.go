@@ -4,4 +4,7 @@ const (
 	HeaderAuthorization = "Authorization"
 	BearerScheme        = "Bearer"
 	HeaderRequestID     = "X-Request-ID"
+	HeaderIdempotency   = "Idempotency-Key"
+
+	MaxIdempotencyKeyLength = 255
 )
@@ -1,9 +1,18 @@
 package constant
 
 const (
-	TopicOrderCreated   = "order.created"
-	TopicPaymentSuccess = "payment.success"
-	TopicPaymentFailed  = "payment.failed"
+	TopicOrderCreated    = "order.created"
+	TopicPaymentSuccess  = "payment.success"
+	TopicPaymentFailed   = "payment.failed"
+	TopicProductLowStock = "product.low_stock"
+
+	// TopicOrderPaymentTimeout is published deferred at checkout; when it
+	// arrives an order still awaiting payment is cancelled.
+	TopicOrderPaymentTimeout = "order.payment_timeout"
+
+	TopicPasswordResetRequested = "password.reset.requested"
+
+	TopicPaymentResultDLQ = "payment.result.dlq"
 
 	ChannelPaymentService = "payment-service"
 	ChannelStoreService   = "store-service"
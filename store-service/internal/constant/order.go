@@ -1,6 +1,7 @@
 package constant
 
 const (
+	OrderStatusOnHold     = "on_hold"
 	OrderStatusPending    = "pending"
 	OrderStatusPaid       = "paid"
 	OrderStatusProcessing = "processing"
@@ -8,14 +9,64 @@ const (
 	OrderStatusShipped    = "shipped"
 	OrderStatusCompleted  = "completed"
 	OrderStatusCancelled  = "cancelled"
+	OrderStatusRefunded   = "refunded"
 )
 
+// OrderStatuses is every status an order can be in.
+var OrderStatuses = map[string]bool{
+	OrderStatusOnHold:     true,
+	OrderStatusPending:    true,
+	OrderStatusPaid:       true,
+	OrderStatusProcessing: true,
+	OrderStatusShipping:   true,
+	OrderStatusShipped:    true,
+	OrderStatusCompleted:  true,
+	OrderStatusCancelled:  true,
+	OrderStatusRefunded:   true,
+}
+
+// Payment-unavailable policies decide what checkout does when the
+// order.created message cannot be published.
+const (
+	PaymentPolicyFail   = "fail"
+	PaymentPolicyOutbox = "outbox"
+)
+
+// MaxOrderStatusBatch caps how many order ids one status-batch request may ask for.
+const MaxOrderStatusBatch = 100
+
 var CancellableStatuses = map[string]bool{
+	OrderStatusOnHold:     true,
 	OrderStatusPending:    true,
 	OrderStatusPaid:       true,
 	OrderStatusProcessing: true,
 }
 
+// RefundableStatuses are the statuses of paid orders that can no longer be
+// cancelled because they have left the seller, so a refund is the way back.
+var RefundableStatuses = map[string]bool{
+	OrderStatusShipping:  true,
+	OrderStatusShipped:   true,
+	OrderStatusCompleted: true,
+}
+
+// RevenueStatuses are the statuses of orders whose payment has been received
+// and not refunded by cancellation.
+var RevenueStatuses = []string{
+	OrderStatusPaid,
+	OrderStatusProcessing,
+	OrderStatusShipping,
+	OrderStatusShipped,
+	OrderStatusCompleted,
+}
+
+// PendingFulfillmentStatuses are the statuses of paid orders the seller has
+// not shipped yet.
+var PendingFulfillmentStatuses = []string{
+	OrderStatusPaid,
+	OrderStatusProcessing,
+}
+
 var OrderStatusTransitions = map[string][]string{
 	OrderStatusPaid:       {OrderStatusProcessing},
 	OrderStatusProcessing: {OrderStatusShipping},
@@ -0,0 +1,15 @@
+package constant
+
+// What deleting a product does when it is still in buyers' carts.
+const (
+	// ProductDeleteRemoveFromCarts takes it out of every cart, then deletes it.
+	ProductDeleteRemoveFromCarts = "remove"
+	// ProductDeleteMarkUnavailable keeps it, out of stock, while any cart
+	// holds it, so buyers see it as unavailable and checkout refuses it.
+	ProductDeleteMarkUnavailable = "unavailable"
+)
+
+// MinFullTextSearchLength is the shortest product search, in characters,
+// matched against the full-text index. Shorter terms are mostly prefixes
+// of words, which stemming cannot match, so they fall back to ILIKE.
+const MinFullTextSearchLength = 3
@@ -4,4 +4,6 @@ const (
 	RateLimitKeyPublic = "public"
 	RateLimitKeyAuth   = "auth"
 	RateLimitKeyLogin  = "login"
+	// RateLimitKeyLoginIP counts login attempts per IP across all accounts.
+	RateLimitKeyLoginIP = "login_ip"
 )
@@ -0,0 +1,20 @@
+package constant
+
+// Reasons recorded on stock movements.
+const (
+	StockReasonCreated = "created"
+	// StockReasonAdjustment is a seller setting the stock by hand, including
+	// zeroing it when a product in carts is marked unavailable.
+	StockReasonAdjustment = "adjustment"
+	StockReasonCheckout   = "checkout"
+	// StockReasonCheckoutUndone puts back stock taken by a checkout whose
+	// order was dropped because payment could not be triggered.
+	StockReasonCheckoutUndone = "checkout_undone"
+	StockReasonCancel         = "cancel"
+	// StockReasonReservationExpired puts back stock held by an order that
+	// was not paid before its reservation expired.
+	StockReasonReservationExpired = "reservation_expired"
+	// StockReasonPaymentTimeout puts back stock held by an order cancelled
+	// by its order.payment_timeout check.
+	StockReasonPaymentTimeout = "payment_timeout"
+)
@@ -0,0 +1,71 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/1tsndre/mini-go-project/pkg/response"
+	"github.com/1tsndre/mini-go-project/store-service/internal/constant"
+	"github.com/1tsndre/mini-go-project/store-service/internal/middleware"
+	"github.com/1tsndre/mini-go-project/store-service/internal/model"
+	"github.com/1tsndre/mini-go-project/store-service/internal/service"
+	"github.com/google/uuid"
+)
+
+type AnnouncementHandler struct {
+	service service.AnnouncementService
+}
+
+func NewAnnouncementHandler(service service.AnnouncementService) *AnnouncementHandler {
+	return &AnnouncementHandler{service: service}
+}
+
+func (h *AnnouncementHandler) CreateAnnouncement(w http.ResponseWriter, r *http.Request) {
+	meta := middleware.BuildMeta(r)
+
+	adminID, err := uuid.Parse(middleware.GetUserID(r.Context()))
+	if err != nil {
+		response.ErrorResponse(w, http.StatusUnauthorized, meta,
+			response.NewError(constant.ErrCodeUnauthorized, "invalid user"),
+		)
+		return
+	}
+
+	var req model.CreateAnnouncementRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		response.ErrorResponse(w, http.StatusBadRequest, meta,
+			response.NewError(constant.ErrCodeValidation, "invalid request body"),
+		)
+		return
+	}
+
+	announcement, err := h.service.CreateAnnouncement(r.Context(), adminID, req)
+	if err != nil {
+		msg := err.Error()
+		if strings.Contains(msg, "failed") {
+			response.ErrorResponse(w, http.StatusInternalServerError, meta,
+				response.NewError(constant.ErrCodeInternal, msg))
+		} else {
+			response.ErrorResponse(w, http.StatusBadRequest, meta,
+				response.NewError(constant.ErrCodeValidation, msg))
+		}
+		return
+	}
+
+	response.Success(w, http.StatusCreated, announcement, meta)
+}
+
+func (h *AnnouncementHandler) GetAnnouncements(w http.ResponseWriter, r *http.Request) {
+	meta := middleware.BuildMeta(r)
+
+	announcements, err := h.service.GetActiveAnnouncements(r.Context(), middleware.GetUserRole(r.Context()))
+	if err != nil {
+		response.ErrorResponse(w, http.StatusInternalServerError, meta,
+			response.NewError(constant.ErrCodeInternal, err.Error()),
+		)
+		return
+	}
+
+	response.Success(w, http.StatusOK, announcements, meta)
+}
@@ -2,19 +2,19 @@ package handler
 
 import (
 	"encoding/json"
+	"errors"
 	"net/http"
-	"regexp"
 	"strings"
 
 	"github.com/1tsndre/mini-go-project/pkg/response"
 	"github.com/1tsndre/mini-go-project/store-service/internal/constant"
 	"github.com/1tsndre/mini-go-project/store-service/internal/middleware"
 	"github.com/1tsndre/mini-go-project/store-service/internal/model"
+	"github.com/1tsndre/mini-go-project/store-service/internal/password"
 	"github.com/1tsndre/mini-go-project/store-service/internal/service"
+	"github.com/google/uuid"
 )
 
-var emailRegex = regexp.MustCompile(`^[a-zA-Z0-9._%+\-]+@[a-zA-Z0-9.\-]+\.[a-zA-Z]{2,}$`)
-
 type AuthHandler struct {
 	service service.AuthService
 }
@@ -34,27 +34,19 @@ func (h *AuthHandler) Register(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	var errors []response.Error
-	if req.Email == "" {
-		errors = append(errors, response.NewFieldError(constant.ErrCodeValidation, "email", "is required"))
-	} else if !emailRegex.MatchString(req.Email) {
-		errors = append(errors, response.NewFieldError(constant.ErrCodeValidation, "email", "invalid email format"))
-	}
-	if req.Password == "" {
-		errors = append(errors, response.NewFieldError(constant.ErrCodeValidation, "password", "is required"))
-	} else if len(req.Password) < 6 {
-		errors = append(errors, response.NewFieldError(constant.ErrCodeValidation, "password", "minimum 6 characters"))
-	}
-	if req.Name == "" {
-		errors = append(errors, response.NewFieldError(constant.ErrCodeValidation, "name", "is required"))
-	}
-	if len(errors) > 0 {
-		response.ValidationError(w, meta, errors)
+	req.Email = model.NormalizeEmail(req.Email)
+
+	if errs := validationErrors(req); len(errs) > 0 {
+		response.ValidationError(w, meta, errs)
 		return
 	}
 
 	resp, err := h.service.Register(r.Context(), req)
 	if err != nil {
+		if fieldErrors, ok := passwordPolicyErrors(err, "password"); ok {
+			response.ValidationError(w, meta, fieldErrors)
+			return
+		}
 		msg := err.Error()
 		if strings.Contains(msg, "already") {
 			response.ErrorResponse(w, http.StatusConflict, meta,
@@ -80,22 +72,21 @@ func (h *AuthHandler) Login(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	var errors []response.Error
-	if req.Email == "" {
-		errors = append(errors, response.NewFieldError(constant.ErrCodeValidation, "email", "is required"))
-	}
-	if req.Password == "" {
-		errors = append(errors, response.NewFieldError(constant.ErrCodeValidation, "password", "is required"))
-	}
-	if len(errors) > 0 {
-		response.ValidationError(w, meta, errors)
+	if errs := validationErrors(req); len(errs) > 0 {
+		response.ValidationError(w, meta, errs)
 		return
 	}
 
 	tokenPair, err := h.service.Login(r.Context(), req)
 	if err != nil {
 		msg := err.Error()
-		if strings.Contains(msg, "internal") {
+		if errors.Is(err, service.ErrAccountLocked) {
+			response.ErrorResponse(w, http.StatusTooManyRequests, meta,
+				response.NewError(constant.ErrCodeAccountLocked, msg))
+		} else if errors.Is(err, service.ErrAccountDisabled) {
+			response.ErrorResponse(w, http.StatusForbidden, meta,
+				response.NewError(constant.ErrCodeAccountDisabled, msg))
+		} else if strings.Contains(msg, "internal") {
 			response.ErrorResponse(w, http.StatusInternalServerError, meta,
 				response.NewError(constant.ErrCodeInternal, msg))
 		} else {
@@ -119,17 +110,18 @@ func (h *AuthHandler) Refresh(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if req.RefreshToken == "" {
-		response.ValidationError(w, meta, []response.Error{
-			response.NewFieldError(constant.ErrCodeValidation, "refresh_token", "is required"),
-		})
+	if errs := validationErrors(req); len(errs) > 0 {
+		response.ValidationError(w, meta, errs)
 		return
 	}
 
 	tokenPair, err := h.service.RefreshToken(r.Context(), req)
 	if err != nil {
 		msg := err.Error()
-		if strings.Contains(msg, "internal") {
+		if errors.Is(err, service.ErrAccountDisabled) {
+			response.ErrorResponse(w, http.StatusForbidden, meta,
+				response.NewError(constant.ErrCodeAccountDisabled, msg))
+		} else if strings.Contains(msg, "internal") {
 			response.ErrorResponse(w, http.StatusInternalServerError, meta,
 				response.NewError(constant.ErrCodeInternal, msg))
 		} else {
@@ -141,3 +133,127 @@ func (h *AuthHandler) Refresh(w http.ResponseWriter, r *http.Request) {
 
 	response.Success(w, http.StatusOK, tokenPair, meta)
 }
+
+func (h *AuthHandler) ChangePassword(w http.ResponseWriter, r *http.Request) {
+	meta := middleware.BuildMeta(r)
+
+	userID, err := uuid.Parse(middleware.GetUserID(r.Context()))
+	if err != nil {
+		response.ErrorResponse(w, http.StatusUnauthorized, meta,
+			response.NewError(constant.ErrCodeUnauthorized, "invalid user"),
+		)
+		return
+	}
+
+	var req model.ChangePasswordRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		response.ErrorResponse(w, http.StatusBadRequest, meta,
+			response.NewError(constant.ErrCodeValidation, "invalid request body"),
+		)
+		return
+	}
+
+	if errs := validationErrors(req); len(errs) > 0 {
+		response.ValidationError(w, meta, errs)
+		return
+	}
+
+	tokenPair, err := h.service.ChangePassword(r.Context(), userID, req)
+	if err != nil {
+		if fieldErrors, ok := passwordPolicyErrors(err, "new_password"); ok {
+			response.ValidationError(w, meta, fieldErrors)
+			return
+		}
+		msg := err.Error()
+		switch {
+		case strings.Contains(msg, "incorrect"), strings.Contains(msg, "not found"):
+			response.ErrorResponse(w, http.StatusUnauthorized, meta,
+				response.NewError(constant.ErrCodeUnauthorized, msg))
+		default:
+			response.ErrorResponse(w, http.StatusInternalServerError, meta,
+				response.NewError(constant.ErrCodeInternal, msg))
+		}
+		return
+	}
+
+	response.Success(w, http.StatusOK, tokenPair, meta)
+}
+
+func (h *AuthHandler) ForgotPassword(w http.ResponseWriter, r *http.Request) {
+	meta := middleware.BuildMeta(r)
+
+	var req model.ForgotPasswordRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		response.ErrorResponse(w, http.StatusBadRequest, meta,
+			response.NewError(constant.ErrCodeValidation, "invalid request body"),
+		)
+		return
+	}
+
+	if errs := validationErrors(req); len(errs) > 0 {
+		response.ValidationError(w, meta, errs)
+		return
+	}
+
+	if err := h.service.ForgotPassword(r.Context(), req); err != nil {
+		response.ErrorResponse(w, http.StatusInternalServerError, meta,
+			response.NewError(constant.ErrCodeInternal, err.Error()))
+		return
+	}
+
+	response.Success(w, http.StatusOK, map[string]string{
+		"message": "if the email is registered, a password reset link has been sent",
+	}, meta)
+}
+
+func (h *AuthHandler) ResetPassword(w http.ResponseWriter, r *http.Request) {
+	meta := middleware.BuildMeta(r)
+
+	var req model.ResetPasswordRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		response.ErrorResponse(w, http.StatusBadRequest, meta,
+			response.NewError(constant.ErrCodeValidation, "invalid request body"),
+		)
+		return
+	}
+
+	if errs := validationErrors(req); len(errs) > 0 {
+		response.ValidationError(w, meta, errs)
+		return
+	}
+
+	if err := h.service.ResetPassword(r.Context(), req); err != nil {
+		if fieldErrors, ok := passwordPolicyErrors(err, "new_password"); ok {
+			response.ValidationError(w, meta, fieldErrors)
+			return
+		}
+		msg := err.Error()
+		switch {
+		case strings.Contains(msg, "invalid or expired"):
+			response.ErrorResponse(w, http.StatusBadRequest, meta,
+				response.NewError(constant.ErrCodeValidation, msg))
+		default:
+			response.ErrorResponse(w, http.StatusInternalServerError, meta,
+				response.NewError(constant.ErrCodeInternal, msg))
+		}
+		return
+	}
+
+	response.Success(w, http.StatusOK, map[string]string{
+		"message": "password has been reset",
+	}, meta)
+}
+
+// passwordPolicyErrors turns a password policy failure into one error on
+// field per broken rule. It reports false for any other error.
+func passwordPolicyErrors(err error, field string) ([]response.Error, bool) {
+	var policyErr *password.PolicyError
+	if !errors.As(err, &policyErr) {
+		return nil, false
+	}
+	fieldErrors := make([]response.Error, 0, len(policyErr.Violations))
+	for _, violation := range policyErr.Violations {
+		fieldErrors = append(fieldErrors, response.NewFieldError(constant.ErrCodeValidation, field, violation))
+	}
+	return fieldErrors, true
+}
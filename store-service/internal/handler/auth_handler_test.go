@@ -0,0 +1,51 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/1tsndre/mini-go-project/pkg/jwt"
+	"github.com/1tsndre/mini-go-project/pkg/response"
+	"github.com/1tsndre/mini-go-project/store-service/internal/constant"
+	"github.com/1tsndre/mini-go-project/store-service/internal/mocks"
+	"github.com/1tsndre/mini-go-project/store-service/internal/password"
+	"github.com/1tsndre/mini-go-project/store-service/internal/service"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+)
+
+func TestAuthHandler_Register_PasswordPolicy(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	// The policy is checked before any lookup, so the repository is never hit.
+	repo := mocks.NewMockUserRepository(ctrl)
+	policy := password.Policy{MinLength: 8, RequireDigit: true, RequireSymbol: true}
+	svc := service.NewAuthService(repo, nil, jwt.NewJWTManager("test-secret", time.Minute, time.Hour), nil, nil, service.AuthConfig{PasswordPolicy: policy})
+	h := NewAuthHandler(svc)
+
+	body := `{"email": "jane@example.com", "password": "short", "name": "Jane"}`
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/auth/register", strings.NewReader(body))
+	rec := httptest.NewRecorder()
+
+	h.Register(rec, req)
+
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	var resp struct {
+		Errors []response.Error `json:"errors"`
+	}
+	assert.NoError(t, json.NewDecoder(rec.Body).Decode(&resp))
+	if assert.Len(t, resp.Errors, 3) {
+		for _, e := range resp.Errors {
+			assert.Equal(t, constant.ErrCodeValidation, e.Code)
+			assert.Equal(t, "password", e.Field)
+		}
+		assert.Equal(t, "must be at least 8 characters", resp.Errors[0].Message)
+		assert.Equal(t, "must contain a digit", resp.Errors[1].Message)
+		assert.Equal(t, "must contain a symbol", resp.Errors[2].Message)
+	}
+}
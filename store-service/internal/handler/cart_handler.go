@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"net/http"
 	"strings"
+	"time"
 
 	"github.com/1tsndre/mini-go-project/pkg/response"
 	"github.com/1tsndre/mini-go-project/store-service/internal/constant"
@@ -81,6 +82,9 @@ func (h *CartHandler) AddItem(w http.ResponseWriter, r *http.Request) {
 		case strings.Contains(msg, "not found"):
 			response.ErrorResponse(w, http.StatusNotFound, meta,
 				response.NewError(constant.ErrCodeNotFound, msg))
+		case strings.Contains(msg, "modified by another request"):
+			response.ErrorResponse(w, http.StatusConflict, meta,
+				response.NewError(constant.ErrCodeConflict, msg))
 		case strings.Contains(msg, "failed"):
 			response.ErrorResponse(w, http.StatusInternalServerError, meta,
 				response.NewError(constant.ErrCodeInternal, msg))
@@ -135,6 +139,9 @@ func (h *CartHandler) UpdateItem(w http.ResponseWriter, r *http.Request) {
 		case strings.Contains(msg, "not found"):
 			response.ErrorResponse(w, http.StatusNotFound, meta,
 				response.NewError(constant.ErrCodeNotFound, msg))
+		case strings.Contains(msg, "modified by another request"):
+			response.ErrorResponse(w, http.StatusConflict, meta,
+				response.NewError(constant.ErrCodeConflict, msg))
 		case strings.Contains(msg, "failed"):
 			response.ErrorResponse(w, http.StatusInternalServerError, meta,
 				response.NewError(constant.ErrCodeInternal, msg))
@@ -167,13 +174,28 @@ func (h *CartHandler) RemoveItem(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	resp, err := h.service.RemoveItem(r.Context(), userID, productID)
+	var req model.RemoveCartItemRequest
+	if raw := r.URL.Query().Get("updated_at"); raw != "" {
+		updatedAt, err := time.Parse(time.RFC3339Nano, raw)
+		if err != nil {
+			response.ValidationError(w, meta, []response.Error{
+				response.NewFieldError(constant.ErrCodeValidation, "updated_at", "must be an RFC 3339 timestamp"),
+			})
+			return
+		}
+		req.UpdatedAt = &updatedAt
+	}
+
+	resp, err := h.service.RemoveItem(r.Context(), userID, productID, req)
 	if err != nil {
 		msg := err.Error()
 		switch {
 		case strings.Contains(msg, "not found"):
 			response.ErrorResponse(w, http.StatusNotFound, meta,
 				response.NewError(constant.ErrCodeNotFound, msg))
+		case strings.Contains(msg, "modified by another request"):
+			response.ErrorResponse(w, http.StatusConflict, meta,
+				response.NewError(constant.ErrCodeConflict, msg))
 		case strings.Contains(msg, "failed"):
 			response.ErrorResponse(w, http.StatusInternalServerError, meta,
 				response.NewError(constant.ErrCodeInternal, msg))
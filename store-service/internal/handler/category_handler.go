@@ -2,17 +2,23 @@ package handler
 
 import (
 	"encoding/json"
+	"fmt"
 	"net/http"
+	"strconv"
 	"strings"
 
 	"github.com/1tsndre/mini-go-project/pkg/response"
 	"github.com/1tsndre/mini-go-project/store-service/internal/constant"
 	"github.com/1tsndre/mini-go-project/store-service/internal/middleware"
 	"github.com/1tsndre/mini-go-project/store-service/internal/model"
+	"github.com/1tsndre/mini-go-project/store-service/internal/pagination"
 	"github.com/1tsndre/mini-go-project/store-service/internal/service"
 	"github.com/google/uuid"
 )
 
+// maxBulkCategories caps one bulk import request.
+const maxBulkCategories = 500
+
 type CategoryHandler struct {
 	service service.CategoryService
 }
@@ -42,10 +48,14 @@ func (h *CategoryHandler) CreateCategory(w http.ResponseWriter, r *http.Request)
 	resp, err := h.service.CreateCategory(r.Context(), req)
 	if err != nil {
 		msg := err.Error()
-		if strings.Contains(msg, "already exists") {
+		switch {
+		case strings.Contains(msg, "already exists"):
 			response.ErrorResponse(w, http.StatusConflict, meta,
 				response.NewError(constant.ErrCodeConflict, msg))
-		} else {
+		case strings.Contains(msg, "parent"):
+			response.ErrorResponse(w, http.StatusBadRequest, meta,
+				response.NewFieldError(constant.ErrCodeValidation, "parent_id", msg))
+		default:
 			response.ErrorResponse(w, http.StatusInternalServerError, meta,
 				response.NewError(constant.ErrCodeInternal, msg))
 		}
@@ -55,10 +65,90 @@ func (h *CategoryHandler) CreateCategory(w http.ResponseWriter, r *http.Request)
 	response.Success(w, http.StatusCreated, resp, meta)
 }
 
+func (h *CategoryHandler) CreateCategories(w http.ResponseWriter, r *http.Request) {
+	meta := middleware.BuildMeta(r)
+
+	var req model.BulkCreateCategoriesRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		response.ErrorResponse(w, http.StatusBadRequest, meta,
+			response.NewError(constant.ErrCodeValidation, "invalid request body"),
+		)
+		return
+	}
+
+	if len(req.Categories) == 0 {
+		response.ValidationError(w, meta, []response.Error{
+			response.NewFieldError(constant.ErrCodeValidation, "categories", "is required"),
+		})
+		return
+	}
+	if len(req.Categories) > maxBulkCategories {
+		response.ValidationError(w, meta, []response.Error{
+			response.NewFieldError(constant.ErrCodeValidation, "categories", fmt.Sprintf("must not exceed %d entries", maxBulkCategories)),
+		})
+		return
+	}
+	var errs []response.Error
+	for i, c := range req.Categories {
+		if strings.TrimSpace(c.Name) == "" {
+			errs = append(errs, response.NewFieldError(constant.ErrCodeValidation, fmt.Sprintf("categories[%d].name", i), "is required"))
+		}
+	}
+	if len(errs) > 0 {
+		response.ValidationError(w, meta, errs)
+		return
+	}
+
+	resp, err := h.service.CreateMany(r.Context(), req)
+	if err != nil {
+		msg := err.Error()
+		switch {
+		case strings.Contains(msg, "already exists"):
+			response.ErrorResponse(w, http.StatusConflict, meta,
+				response.NewError(constant.ErrCodeConflict, msg))
+		case strings.Contains(msg, "parent"):
+			response.ErrorResponse(w, http.StatusBadRequest, meta,
+				response.NewError(constant.ErrCodeValidation, msg))
+		default:
+			response.ErrorResponse(w, http.StatusInternalServerError, meta,
+				response.NewError(constant.ErrCodeInternal, msg))
+		}
+		return
+	}
+
+	response.Success(w, http.StatusOK, resp, meta)
+}
+
+// GetCategories lists every category unless page or per_page is given, in
+// which case the flat listing is paginated.
 func (h *CategoryHandler) GetCategories(w http.ResponseWriter, r *http.Request) {
 	meta := middleware.BuildMeta(r)
 
-	resp, err := h.service.GetAllCategories(r.Context())
+	q := r.URL.Query()
+	if q.Has("page") || q.Has("per_page") {
+		if format := q.Get("format"); format != "" && format != "flat" {
+			response.ValidationError(w, meta, []response.Error{
+				response.NewFieldError(constant.ErrCodeValidation, "format", "pagination is only supported for the flat format"),
+			})
+			return
+		}
+		h.getCategoriesPage(w, r, meta)
+		return
+	}
+
+	var resp []model.CategoryResponse
+	var err error
+	switch q.Get("format") {
+	case "", "flat":
+		resp, err = h.service.GetAllCategories(r.Context())
+	case "tree":
+		resp, err = h.service.GetCategoryTree(r.Context())
+	default:
+		response.ValidationError(w, meta, []response.Error{
+			response.NewFieldError(constant.ErrCodeValidation, "format", "must be flat or tree"),
+		})
+		return
+	}
 	if err != nil {
 		response.ErrorResponse(w, http.StatusInternalServerError, meta,
 			response.NewError(constant.ErrCodeInternal, err.Error()),
@@ -91,10 +181,14 @@ func (h *CategoryHandler) UpdateCategory(w http.ResponseWriter, r *http.Request)
 	resp, err := h.service.UpdateCategory(r.Context(), id, req)
 	if err != nil {
 		msg := err.Error()
-		if strings.Contains(msg, "not found") {
+		switch {
+		case strings.Contains(msg, "parent"), strings.Contains(msg, "ancestor"), strings.Contains(msg, "too deep"):
+			response.ErrorResponse(w, http.StatusBadRequest, meta,
+				response.NewFieldError(constant.ErrCodeValidation, "parent_id", msg))
+		case strings.Contains(msg, "not found"):
 			response.ErrorResponse(w, http.StatusNotFound, meta,
 				response.NewError(constant.ErrCodeNotFound, msg))
-		} else {
+		default:
 			response.ErrorResponse(w, http.StatusInternalServerError, meta,
 				response.NewError(constant.ErrCodeInternal, msg))
 		}
@@ -117,10 +211,14 @@ func (h *CategoryHandler) DeleteCategory(w http.ResponseWriter, r *http.Request)
 
 	if err := h.service.DeleteCategory(r.Context(), id); err != nil {
 		msg := err.Error()
-		if strings.Contains(msg, "not found") {
+		switch {
+		case strings.Contains(msg, "not found"):
 			response.ErrorResponse(w, http.StatusNotFound, meta,
 				response.NewError(constant.ErrCodeNotFound, msg))
-		} else {
+		case strings.Contains(msg, "category has"):
+			response.ErrorResponse(w, http.StatusConflict, meta,
+				response.NewError(constant.ErrCodeConflict, msg))
+		default:
 			response.ErrorResponse(w, http.StatusInternalServerError, meta,
 				response.NewError(constant.ErrCodeInternal, msg))
 		}
@@ -129,3 +227,25 @@ func (h *CategoryHandler) DeleteCategory(w http.ResponseWriter, r *http.Request)
 
 	response.Success(w, http.StatusOK, map[string]string{"message": "category deleted"}, meta)
 }
+
+func (h *CategoryHandler) getCategoriesPage(w http.ResponseWriter, r *http.Request, meta *response.Meta) {
+	q := r.URL.Query()
+	page, _ := strconv.Atoi(q.Get("page"))
+	perPage, _ := strconv.Atoi(q.Get("per_page"))
+
+	resp, total, err := h.service.GetCategoriesPage(r.Context(), page, perPage)
+	if err != nil {
+		response.ErrorResponse(w, http.StatusInternalServerError, meta,
+			response.NewError(constant.ErrCodeInternal, err.Error()),
+		)
+		return
+	}
+
+	page, perPage = pagination.Normalize(page, perPage)
+	response.SuccessWithPagination(w, http.StatusOK, resp, meta, &response.Pagination{
+		CurrentPage: page,
+		PerPage:     perPage,
+		TotalItems:  total,
+		TotalPages:  pagination.TotalPages(total, perPage),
+	})
+}
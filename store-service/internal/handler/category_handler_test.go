@@ -0,0 +1,115 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/1tsndre/mini-go-project/pkg/response"
+	"github.com/1tsndre/mini-go-project/store-service/internal/constant"
+	"github.com/1tsndre/mini-go-project/store-service/internal/mocks"
+	"github.com/1tsndre/mini-go-project/store-service/internal/model"
+	"github.com/1tsndre/mini-go-project/store-service/internal/service"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+)
+
+func TestCategoryHandler_GetCategories_Pagination(t *testing.T) {
+	categories := []model.Category{
+		{ID: uuid.New(), Name: "Books"},
+		{ID: uuid.New(), Name: "Clothing"},
+	}
+
+	tests := []struct {
+		name           string
+		query          string
+		mockSetup      func(repo *mocks.MockCategoryRepository)
+		wantStatus     int
+		wantErrCode    string
+		wantCount      int
+		wantPagination *response.Pagination
+	}{
+		{
+			name:  "no pagination params returns everything",
+			query: "",
+			mockSetup: func(repo *mocks.MockCategoryRepository) {
+				repo.EXPECT().FindAll(gomock.Any()).Return(categories, nil)
+			},
+			wantStatus: http.StatusOK,
+			wantCount:  2,
+		},
+		{
+			name:  "page and per_page paginate",
+			query: "?page=2&per_page=2",
+			mockSetup: func(repo *mocks.MockCategoryRepository) {
+				repo.EXPECT().FindAllPaginated(gomock.Any(), 2, 2).Return(categories, int64(5), nil)
+			},
+			wantStatus: http.StatusOK,
+			wantCount:  2,
+			wantPagination: &response.Pagination{
+				CurrentPage: 2,
+				PerPage:     2,
+				TotalItems:  5,
+				TotalPages:  3,
+			},
+		},
+		{
+			name:  "per_page alone starts at the first page",
+			query: "?per_page=20",
+			mockSetup: func(repo *mocks.MockCategoryRepository) {
+				repo.EXPECT().FindAllPaginated(gomock.Any(), 1, 20).Return(categories, int64(2), nil)
+			},
+			wantStatus: http.StatusOK,
+			wantCount:  2,
+			wantPagination: &response.Pagination{
+				CurrentPage: 1,
+				PerPage:     20,
+				TotalItems:  2,
+				TotalPages:  1,
+			},
+		},
+		{
+			name:        "tree cannot be paginated",
+			query:       "?format=tree&page=1",
+			mockSetup:   func(_ *mocks.MockCategoryRepository) {},
+			wantStatus:  http.StatusBadRequest,
+			wantErrCode: constant.ErrCodeValidation,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			repo := mocks.NewMockCategoryRepository(ctrl)
+			tt.mockSetup(repo)
+
+			h := NewCategoryHandler(service.NewCategoryService(repo, nil))
+
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/categories"+tt.query, nil)
+			rec := httptest.NewRecorder()
+
+			h.GetCategories(rec, req)
+
+			assert.Equal(t, tt.wantStatus, rec.Code)
+
+			var body struct {
+				Data   []model.CategoryResponse `json:"data"`
+				Meta   response.Meta            `json:"meta"`
+				Errors []response.Error         `json:"errors"`
+			}
+			assert.NoError(t, json.NewDecoder(rec.Body).Decode(&body))
+
+			if tt.wantErrCode != "" {
+				assert.Len(t, body.Errors, 1)
+				assert.Equal(t, tt.wantErrCode, body.Errors[0].Code)
+				return
+			}
+			assert.Len(t, body.Data, tt.wantCount)
+			assert.Equal(t, tt.wantPagination, body.Meta.Pagination)
+		})
+	}
+}
@@ -0,0 +1,41 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/1tsndre/mini-go-project/pkg/response"
+	"github.com/1tsndre/mini-go-project/store-service/internal/constant"
+	"github.com/1tsndre/mini-go-project/store-service/internal/service"
+)
+
+// writeServiceError answers with the status and code for the kind of a
+// service error. The specific failures are checked before the kinds they
+// belong to; an error of no known kind is treated as internal. Errors tied
+// to a request field are reported against it.
+func writeServiceError(w http.ResponseWriter, meta *response.Meta, err error) {
+	status, code := http.StatusInternalServerError, constant.ErrCodeInternal
+	switch {
+	case errors.Is(err, service.ErrIdempotencyKeyReused):
+		status, code = http.StatusUnprocessableEntity, constant.ErrCodeIdempotencyReused
+	case errors.Is(err, service.ErrPriceChanged):
+		status, code = http.StatusConflict, constant.ErrCodePriceChanged
+	case errors.Is(err, service.ErrInvalidStatus):
+		status, code = http.StatusBadRequest, constant.ErrCodeInvalidStatus
+	case errors.Is(err, service.ErrPaymentUnavailable):
+		status, code = http.StatusServiceUnavailable, constant.ErrCodePaymentUnavailable
+	case errors.Is(err, service.ErrNotFound):
+		status, code = http.StatusNotFound, constant.ErrCodeNotFound
+	case errors.Is(err, service.ErrForbidden):
+		status, code = http.StatusForbidden, constant.ErrCodeForbidden
+	case errors.Is(err, service.ErrConflict):
+		status, code = http.StatusConflict, constant.ErrCodeConflict
+	case errors.Is(err, service.ErrValidation):
+		status, code = http.StatusBadRequest, constant.ErrCodeValidation
+	}
+	if field := service.ErrorField(err); field != "" {
+		response.ErrorResponse(w, status, meta, response.NewFieldError(code, field, err.Error()))
+		return
+	}
+	response.ErrorResponse(w, status, meta, response.NewError(code, err.Error()))
+}
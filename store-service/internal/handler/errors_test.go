@@ -0,0 +1,94 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/1tsndre/mini-go-project/pkg/response"
+	"github.com/1tsndre/mini-go-project/store-service/internal/constant"
+	"github.com/1tsndre/mini-go-project/store-service/internal/middleware"
+	"github.com/1tsndre/mini-go-project/store-service/internal/service"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+// opaqueError has a message that says nothing about its kind, so only
+// errors.Is can classify it.
+type opaqueError struct {
+	kind error
+}
+
+func (e opaqueError) Error() string { return "request rejected" }
+func (e opaqueError) Unwrap() error { return e.kind }
+
+func TestWriteServiceError(t *testing.T) {
+	tests := []struct {
+		name     string
+		err      error
+		wantCode int
+		wantErr  string
+	}{
+		{"not found", fmt.Errorf("loading order: %w", service.ErrNotFound), http.StatusNotFound, constant.ErrCodeNotFound},
+		{"forbidden", fmt.Errorf("checking owner: %w", service.ErrForbidden), http.StatusForbidden, constant.ErrCodeForbidden},
+		{"forbidden without the word", opaqueError{service.ErrForbidden}, http.StatusForbidden, constant.ErrCodeForbidden},
+		{"conflict", fmt.Errorf("saving: %w", service.ErrConflict), http.StatusConflict, constant.ErrCodeConflict},
+		{"validation", fmt.Errorf("parsing: %w", service.ErrValidation), http.StatusBadRequest, constant.ErrCodeValidation},
+		{"internal", fmt.Errorf("writing: %w", service.ErrInternal), http.StatusInternalServerError, constant.ErrCodeInternal},
+		{"price changed before conflict", fmt.Errorf("checkout: %w", service.ErrPriceChanged), http.StatusConflict, constant.ErrCodePriceChanged},
+		{"key reused before conflict", service.ErrIdempotencyKeyReused, http.StatusUnprocessableEntity, constant.ErrCodeIdempotencyReused},
+		{"payment unavailable", service.ErrPaymentUnavailable, http.StatusServiceUnavailable, constant.ErrCodePaymentUnavailable},
+		{"invalid status before validation", fmt.Errorf("cancel: %w", service.ErrInvalidStatus), http.StatusBadRequest, constant.ErrCodeInvalidStatus},
+		// Message text no longer decides the status.
+		{"untagged not found text", errors.New("product not found"), http.StatusInternalServerError, constant.ErrCodeInternal},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			writeServiceError(rec, &response.Meta{}, tt.err)
+
+			assert.Equal(t, tt.wantCode, rec.Code)
+
+			var body response.Response
+			assert.NoError(t, json.NewDecoder(rec.Body).Decode(&body))
+			if assert.Len(t, body.Errors, 1) {
+				assert.Equal(t, tt.wantErr, body.Errors[0].Code)
+				assert.Equal(t, tt.err.Error(), body.Errors[0].Message)
+			}
+		})
+	}
+}
+
+type deleteProductService struct {
+	service.ProductService
+	err error
+}
+
+func (s *deleteProductService) DeleteProduct(context.Context, uuid.UUID, uuid.UUID) error {
+	return s.err
+}
+
+func TestProductHandler_DeleteProduct_WrappedForbidden(t *testing.T) {
+	productID := uuid.New()
+	h := NewProductHandler(&deleteProductService{err: opaqueError{service.ErrForbidden}}, nil, nil)
+
+	req := httptest.NewRequest(http.MethodDelete, "/api/v1/products/"+productID.String(), nil)
+	req.SetPathValue("id", productID.String())
+	req = req.WithContext(context.WithValue(req.Context(), middleware.ContextUserID, uuid.New().String()))
+	rec := httptest.NewRecorder()
+
+	h.DeleteProduct(rec, req)
+
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+	var body response.Response
+	assert.NoError(t, json.NewDecoder(rec.Body).Decode(&body))
+	if assert.Len(t, body.Errors, 1) {
+		assert.Equal(t, constant.ErrCodeForbidden, body.Errors[0].Code)
+		assert.Equal(t, "request rejected", body.Errors[0].Message)
+	}
+}
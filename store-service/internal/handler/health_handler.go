@@ -0,0 +1,41 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/1tsndre/mini-go-project/pkg/response"
+	"github.com/1tsndre/mini-go-project/store-service/internal/health"
+	"github.com/1tsndre/mini-go-project/store-service/internal/middleware"
+)
+
+type HealthHandler struct {
+	checker *health.Checker
+}
+
+func NewHealthHandler(checker *health.Checker) *HealthHandler {
+	return &HealthHandler{checker: checker}
+}
+
+// Liveness reports that the process is up. It never touches dependencies.
+func (h *HealthHandler) Liveness(w http.ResponseWriter, r *http.Request) {
+	meta := middleware.BuildMeta(r)
+	response.Success(w, http.StatusOK, map[string]string{"status": "ok"}, meta)
+}
+
+// Readiness checks every registered dependency and answers 503 with the
+// per-dependency statuses when any of them is down.
+func (h *HealthHandler) Readiness(w http.ResponseWriter, r *http.Request) {
+	meta := middleware.BuildMeta(r)
+
+	checks, healthy := h.checker.Check(r.Context())
+
+	status, code := "ok", http.StatusOK
+	if !healthy {
+		status, code = "unavailable", http.StatusServiceUnavailable
+	}
+
+	response.Success(w, code, map[string]interface{}{
+		"status": status,
+		"checks": checks,
+	}, meta)
+}
@@ -0,0 +1,89 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/1tsndre/mini-go-project/store-service/internal/health"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHealthHandler_Readiness(t *testing.T) {
+	up := func(context.Context) error { return nil }
+	down := func(context.Context) error { return errors.New("connection refused") }
+
+	tests := []struct {
+		name       string
+		checks     map[string]health.CheckFunc
+		wantStatus int
+		wantBody   string
+		wantChecks map[string]string
+	}{
+		{
+			name:       "all dependencies up",
+			checks:     map[string]health.CheckFunc{"postgres": up, "redis": up, "nsq": up},
+			wantStatus: http.StatusOK,
+			wantBody:   "ok",
+			wantChecks: map[string]string{"postgres": health.StatusUp, "redis": health.StatusUp, "nsq": health.StatusUp},
+		},
+		{
+			name:       "redis down",
+			checks:     map[string]health.CheckFunc{"postgres": up, "redis": down, "nsq": up},
+			wantStatus: http.StatusServiceUnavailable,
+			wantBody:   "unavailable",
+			wantChecks: map[string]string{"postgres": health.StatusUp, "redis": health.StatusDown, "nsq": health.StatusUp},
+		},
+		{
+			name: "check exceeding the timeout is down",
+			checks: map[string]health.CheckFunc{
+				"postgres": up,
+				"nsq": func(ctx context.Context) error {
+					<-ctx.Done()
+					return ctx.Err()
+				},
+			},
+			wantStatus: http.StatusServiceUnavailable,
+			wantBody:   "unavailable",
+			wantChecks: map[string]string{"postgres": health.StatusUp, "nsq": health.StatusDown},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			checker := health.NewChecker(50 * time.Millisecond)
+			for name, fn := range tt.checks {
+				checker.Add(name, fn)
+			}
+			h := NewHealthHandler(checker)
+
+			rec := httptest.NewRecorder()
+			h.Readiness(rec, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+
+			assert.Equal(t, tt.wantStatus, rec.Code)
+
+			var body struct {
+				Data struct {
+					Status string            `json:"status"`
+					Checks map[string]string `json:"checks"`
+				} `json:"data"`
+			}
+			assert.NoError(t, json.NewDecoder(rec.Body).Decode(&body))
+			assert.Equal(t, tt.wantBody, body.Data.Status)
+			assert.Equal(t, tt.wantChecks, body.Data.Checks)
+		})
+	}
+}
+
+func TestHealthHandler_Liveness(t *testing.T) {
+	h := NewHealthHandler(health.NewChecker(time.Second))
+
+	rec := httptest.NewRecorder()
+	h.Liveness(rec, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+}
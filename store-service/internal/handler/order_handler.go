@@ -2,9 +2,10 @@ package handler
 
 import (
 	"encoding/json"
+	"fmt"
 	"net/http"
 	"strconv"
-	"strings"
+	"time"
 
 	"github.com/1tsndre/mini-go-project/pkg/response"
 	"github.com/1tsndre/mini-go-project/store-service/internal/constant"
@@ -49,24 +50,92 @@ func (h *OrderHandler) Checkout(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	resp, err := h.service.Checkout(r.Context(), userID, req.ShippingAddress)
+	key := r.Header.Get(constant.HeaderIdempotency)
+	if len(key) > constant.MaxIdempotencyKeyLength {
+		response.ValidationError(w, meta, []response.Error{
+			response.NewFieldError(constant.ErrCodeValidation, constant.HeaderIdempotency, "is too long"),
+		})
+		return
+	}
+
+	var resp *model.OrderResponse
+	if key != "" {
+		resp, err = h.service.CheckoutIdempotent(r.Context(), userID, key, req)
+	} else {
+		resp, err = h.service.Checkout(r.Context(), userID, req)
+	}
 	if err != nil {
-		msg := err.Error()
-		switch {
-		case strings.Contains(msg, "not found"):
-			response.ErrorResponse(w, http.StatusNotFound, meta,
-				response.NewError(constant.ErrCodeNotFound, msg))
-		case strings.Contains(msg, "failed"):
-			response.ErrorResponse(w, http.StatusInternalServerError, meta,
-				response.NewError(constant.ErrCodeInternal, msg))
-		default:
-			response.ErrorResponse(w, http.StatusBadRequest, meta,
-				response.NewError(constant.ErrCodeValidation, msg))
-		}
+		writeServiceError(w, meta, err)
+		return
+	}
+
+	// An order accepted with payment still pending has not been charged yet.
+	status := http.StatusCreated
+	if resp.PaymentPending {
+		status = http.StatusAccepted
+	}
+	response.Success(w, status, resp, meta)
+}
+
+func (h *OrderHandler) GuestCheckout(w http.ResponseWriter, r *http.Request) {
+	meta := middleware.BuildMeta(r)
+
+	var req model.GuestCheckoutRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		response.ErrorResponse(w, http.StatusBadRequest, meta,
+			response.NewError(constant.ErrCodeValidation, "invalid request body"),
+		)
+		return
+	}
+
+	errs := validationErrors(req)
+	if len(req.Items) == 0 {
+		errs = append(errs, response.NewFieldError(constant.ErrCodeValidation, "items", "is required"))
+	}
+	if len(errs) > 0 {
+		response.ValidationError(w, meta, errs)
+		return
+	}
+
+	resp, err := h.service.GuestCheckout(r.Context(), req)
+	if err != nil {
+		writeServiceError(w, meta, err)
+		return
+	}
+
+	status := http.StatusCreated
+	if resp.PaymentPending {
+		status = http.StatusAccepted
+	}
+	response.Success(w, status, resp, meta)
+}
+
+func (h *OrderHandler) GetGuestOrder(w http.ResponseWriter, r *http.Request) {
+	meta := middleware.BuildMeta(r)
+
+	id, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		response.ErrorResponse(w, http.StatusBadRequest, meta,
+			response.NewError(constant.ErrCodeValidation, "invalid order id"),
+		)
 		return
 	}
 
-	response.Success(w, http.StatusCreated, resp, meta)
+	token := r.URL.Query().Get("token")
+	if token == "" {
+		response.ValidationError(w, meta, []response.Error{
+			response.NewFieldError(constant.ErrCodeValidation, "token", "is required"),
+		})
+		return
+	}
+
+	resp, err := h.service.GetGuestOrder(r.Context(), id, token)
+	if err != nil {
+		writeServiceError(w, meta, err)
+		return
+	}
+
+	response.Success(w, http.StatusOK, resp, meta)
 }
 
 func (h *OrderHandler) GetOrders(w http.ResponseWriter, r *http.Request) {
@@ -86,9 +155,7 @@ func (h *OrderHandler) GetOrders(w http.ResponseWriter, r *http.Request) {
 
 	orders, total, err := h.service.GetOrders(r.Context(), userID, page, perPage)
 	if err != nil {
-		response.ErrorResponse(w, http.StatusInternalServerError, meta,
-			response.NewError(constant.ErrCodeInternal, err.Error()),
-		)
+		writeServiceError(w, meta, err)
 		return
 	}
 
@@ -122,20 +189,123 @@ func (h *OrderHandler) GetOrder(w http.ResponseWriter, r *http.Request) {
 
 	resp, err := h.service.GetOrderByID(r.Context(), userID, id)
 	if err != nil {
-		msg := err.Error()
-		if strings.Contains(msg, "not found") {
-			response.ErrorResponse(w, http.StatusNotFound, meta,
-				response.NewError(constant.ErrCodeNotFound, msg))
-		} else {
-			response.ErrorResponse(w, http.StatusForbidden, meta,
-				response.NewError(constant.ErrCodeForbidden, msg))
-		}
+		writeServiceError(w, meta, err)
 		return
 	}
 
 	response.Success(w, http.StatusOK, resp, meta)
 }
 
+func (h *OrderHandler) GetOrderTimeline(w http.ResponseWriter, r *http.Request) {
+	meta := middleware.BuildMeta(r)
+
+	userID, err := uuid.Parse(middleware.GetUserID(r.Context()))
+	if err != nil {
+		response.ErrorResponse(w, http.StatusUnauthorized, meta,
+			response.NewError(constant.ErrCodeUnauthorized, "invalid user"),
+		)
+		return
+	}
+
+	id, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		response.ErrorResponse(w, http.StatusBadRequest, meta,
+			response.NewError(constant.ErrCodeValidation, "invalid order id"),
+		)
+		return
+	}
+
+	timeline, err := h.service.GetOrderTimeline(r.Context(), userID, id)
+	if err != nil {
+		writeServiceError(w, meta, err)
+		return
+	}
+
+	response.Success(w, http.StatusOK, timeline, meta)
+}
+
+func (h *OrderHandler) GetOrderPayment(w http.ResponseWriter, r *http.Request) {
+	meta := middleware.BuildMeta(r)
+
+	userID, err := uuid.Parse(middleware.GetUserID(r.Context()))
+	if err != nil {
+		response.ErrorResponse(w, http.StatusUnauthorized, meta,
+			response.NewError(constant.ErrCodeUnauthorized, "invalid user"),
+		)
+		return
+	}
+
+	id, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		response.ErrorResponse(w, http.StatusBadRequest, meta,
+			response.NewError(constant.ErrCodeValidation, "invalid order id"),
+		)
+		return
+	}
+
+	payment, err := h.service.GetOrderPayment(r.Context(), userID, id)
+	if err != nil {
+		writeServiceError(w, meta, err)
+		return
+	}
+
+	response.Success(w, http.StatusOK, payment, meta)
+}
+
+func (h *OrderHandler) GetOrderStatuses(w http.ResponseWriter, r *http.Request) {
+	meta := middleware.BuildMeta(r)
+
+	userID, err := uuid.Parse(middleware.GetUserID(r.Context()))
+	if err != nil {
+		response.ErrorResponse(w, http.StatusUnauthorized, meta,
+			response.NewError(constant.ErrCodeUnauthorized, "invalid user"),
+		)
+		return
+	}
+
+	var req model.OrderStatusBatchRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		response.ErrorResponse(w, http.StatusBadRequest, meta,
+			response.NewError(constant.ErrCodeValidation, "invalid request body"),
+		)
+		return
+	}
+
+	if len(req.OrderIDs) == 0 {
+		response.ValidationError(w, meta, []response.Error{
+			response.NewFieldError(constant.ErrCodeValidation, "order_ids", "is required"),
+		})
+		return
+	}
+	if len(req.OrderIDs) > constant.MaxOrderStatusBatch {
+		response.ValidationError(w, meta, []response.Error{
+			response.NewFieldError(constant.ErrCodeValidation, "order_ids",
+				fmt.Sprintf("must not contain more than %d ids", constant.MaxOrderStatusBatch)),
+		})
+		return
+	}
+
+	ids := make([]uuid.UUID, 0, len(req.OrderIDs))
+	for _, raw := range req.OrderIDs {
+		id, err := uuid.Parse(raw)
+		if err != nil {
+			response.ValidationError(w, meta, []response.Error{
+				response.NewFieldError(constant.ErrCodeValidation, "order_ids", "must contain valid order ids"),
+			})
+			return
+		}
+		ids = append(ids, id)
+	}
+
+	statuses, err := h.service.GetOrderStatuses(r.Context(), userID, ids)
+	if err != nil {
+		writeServiceError(w, meta, err)
+		return
+	}
+
+	response.Success(w, http.StatusOK, statuses, meta)
+}
+
 func (h *OrderHandler) CancelOrder(w http.ResponseWriter, r *http.Request) {
 	meta := middleware.BuildMeta(r)
 
@@ -156,27 +326,40 @@ func (h *OrderHandler) CancelOrder(w http.ResponseWriter, r *http.Request) {
 	}
 
 	if err := h.service.CancelOrder(r.Context(), userID, id); err != nil {
-		msg := err.Error()
-		switch {
-		case strings.Contains(msg, "not found"):
-			response.ErrorResponse(w, http.StatusNotFound, meta,
-				response.NewError(constant.ErrCodeNotFound, msg))
-		case strings.Contains(msg, "forbidden"):
-			response.ErrorResponse(w, http.StatusForbidden, meta,
-				response.NewError(constant.ErrCodeForbidden, msg))
-		case strings.Contains(msg, "failed"):
-			response.ErrorResponse(w, http.StatusInternalServerError, meta,
-				response.NewError(constant.ErrCodeInternal, msg))
-		default:
-			response.ErrorResponse(w, http.StatusBadRequest, meta,
-				response.NewError(constant.ErrCodeInvalidStatus, msg))
-		}
+		writeServiceError(w, meta, err)
 		return
 	}
 
 	response.Success(w, http.StatusOK, map[string]string{"message": "order cancelled"}, meta)
 }
 
+func (h *OrderHandler) RefundOrder(w http.ResponseWriter, r *http.Request) {
+	meta := middleware.BuildMeta(r)
+
+	userID, err := uuid.Parse(middleware.GetUserID(r.Context()))
+	if err != nil {
+		response.ErrorResponse(w, http.StatusUnauthorized, meta,
+			response.NewError(constant.ErrCodeUnauthorized, "invalid user"),
+		)
+		return
+	}
+
+	id, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		response.ErrorResponse(w, http.StatusBadRequest, meta,
+			response.NewError(constant.ErrCodeValidation, "invalid order id"),
+		)
+		return
+	}
+
+	if err := h.service.RefundOrder(r.Context(), userID, id); err != nil {
+		writeServiceError(w, meta, err)
+		return
+	}
+
+	response.Success(w, http.StatusOK, map[string]string{"message": "order refunded"}, meta)
+}
+
 func (h *OrderHandler) UpdateOrderStatus(w http.ResponseWriter, r *http.Request) {
 	meta := middleware.BuildMeta(r)
 
@@ -212,21 +395,7 @@ func (h *OrderHandler) UpdateOrderStatus(w http.ResponseWriter, r *http.Request)
 	}
 
 	if err := h.service.UpdateOrderStatus(r.Context(), userID, id, req.Status); err != nil {
-		msg := err.Error()
-		switch {
-		case strings.Contains(msg, "not found"):
-			response.ErrorResponse(w, http.StatusNotFound, meta,
-				response.NewError(constant.ErrCodeNotFound, msg))
-		case strings.Contains(msg, "forbidden"):
-			response.ErrorResponse(w, http.StatusForbidden, meta,
-				response.NewError(constant.ErrCodeForbidden, msg))
-		case strings.Contains(msg, "failed"):
-			response.ErrorResponse(w, http.StatusInternalServerError, meta,
-				response.NewError(constant.ErrCodeInternal, msg))
-		default:
-			response.ErrorResponse(w, http.StatusBadRequest, meta,
-				response.NewError(constant.ErrCodeInvalidStatus, msg))
-		}
+		writeServiceError(w, meta, err)
 		return
 	}
 
@@ -250,14 +419,70 @@ func (h *OrderHandler) GetSellerOrders(w http.ResponseWriter, r *http.Request) {
 
 	orders, total, err := h.service.GetSellerOrders(r.Context(), userID, page, perPage)
 	if err != nil {
-		msg := err.Error()
-		if strings.Contains(msg, "not found") {
-			response.ErrorResponse(w, http.StatusNotFound, meta,
-				response.NewError(constant.ErrCodeNotFound, msg))
+		writeServiceError(w, meta, err)
+		return
+	}
+
+	page, perPage = pagination.Normalize(page, perPage)
+	response.SuccessWithPagination(w, http.StatusOK, orders, meta, &response.Pagination{
+		CurrentPage: page,
+		PerPage:     perPage,
+		TotalItems:  total,
+		TotalPages:  pagination.TotalPages(total, perPage),
+	})
+}
+
+// GetAllOrders lists orders across all users for admins. from and to are
+// YYYY-MM-DD dates, both inclusive, and either may be omitted.
+func (h *OrderHandler) GetAllOrders(w http.ResponseWriter, r *http.Request) {
+	meta := middleware.BuildMeta(r)
+
+	q := r.URL.Query()
+	page, _ := strconv.Atoi(q.Get("page"))
+	perPage, _ := strconv.Atoi(q.Get("per_page"))
+	filter := model.OrderFilter{Page: page, PerPage: perPage}
+
+	var errors []response.Error
+	if status := q.Get("status"); status != "" {
+		if !constant.OrderStatuses[status] {
+			errors = append(errors, response.NewFieldError(constant.ErrCodeValidation, "status", "unknown order status"))
+		}
+		filter.Status = status
+	}
+	if userID := q.Get("user_id"); userID != "" {
+		id, err := uuid.Parse(userID)
+		if err != nil {
+			errors = append(errors, response.NewFieldError(constant.ErrCodeValidation, "user_id", "must be a valid UUID"))
+		}
+		filter.UserID = id
+	}
+	if from := q.Get("from"); from != "" {
+		t, err := time.Parse(time.DateOnly, from)
+		if err != nil {
+			errors = append(errors, response.NewFieldError(constant.ErrCodeValidation, "from", "from must be a date in YYYY-MM-DD format"))
+		}
+		filter.CreatedFrom = t
+	}
+	if to := q.Get("to"); to != "" {
+		t, err := time.Parse(time.DateOnly, to)
+		if err != nil {
+			errors = append(errors, response.NewFieldError(constant.ErrCodeValidation, "to", "to must be a date in YYYY-MM-DD format"))
 		} else {
-			response.ErrorResponse(w, http.StatusInternalServerError, meta,
-				response.NewError(constant.ErrCodeInternal, msg))
+			filter.CreatedTo = t.AddDate(0, 0, 1)
 		}
+	}
+	if len(errors) == 0 && !filter.CreatedFrom.IsZero() && !filter.CreatedTo.IsZero() &&
+		!filter.CreatedTo.After(filter.CreatedFrom) {
+		errors = append(errors, response.NewFieldError(constant.ErrCodeValidation, "to", "to must not be before from"))
+	}
+	if len(errors) > 0 {
+		response.ValidationError(w, meta, errors)
+		return
+	}
+
+	orders, total, err := h.service.GetAllOrders(r.Context(), filter)
+	if err != nil {
+		writeServiceError(w, meta, err)
 		return
 	}
 
@@ -269,3 +494,22 @@ func (h *OrderHandler) GetSellerOrders(w http.ResponseWriter, r *http.Request) {
 		TotalPages:  pagination.TotalPages(total, perPage),
 	})
 }
+
+func (h *OrderHandler) ReleaseOrder(w http.ResponseWriter, r *http.Request) {
+	meta := middleware.BuildMeta(r)
+
+	id, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		response.ErrorResponse(w, http.StatusBadRequest, meta,
+			response.NewError(constant.ErrCodeValidation, "invalid order id"),
+		)
+		return
+	}
+
+	if err := h.service.ReleaseOrder(r.Context(), id); err != nil {
+		writeServiceError(w, meta, err)
+		return
+	}
+
+	response.Success(w, http.StatusOK, map[string]string{"message": "order released"}, meta)
+}
@@ -0,0 +1,144 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/1tsndre/mini-go-project/pkg/response"
+	"github.com/1tsndre/mini-go-project/store-service/internal/constant"
+	"github.com/1tsndre/mini-go-project/store-service/internal/middleware"
+	"github.com/1tsndre/mini-go-project/store-service/internal/mocks"
+	"github.com/1tsndre/mini-go-project/store-service/internal/model"
+	"github.com/1tsndre/mini-go-project/store-service/internal/service"
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+	"gorm.io/gorm"
+)
+
+func TestOrderHandler_GetOrderPayment(t *testing.T) {
+	ownerID := uuid.New()
+	orderID := uuid.New()
+	paidAt := time.Date(2024, 3, 1, 10, 30, 0, 0, time.UTC)
+	order := &model.Order{ID: orderID, UserID: ownerID}
+
+	tests := []struct {
+		name        string
+		userID      uuid.UUID
+		mockSetup   func(orderRepo *mocks.MockOrderRepository)
+		wantStatus  int
+		wantErrCode string
+	}{
+		{
+			name:   "owner sees the payment",
+			userID: ownerID,
+			mockSetup: func(orderRepo *mocks.MockOrderRepository) {
+				orderRepo.EXPECT().FindByID(gomock.Any(), orderID).Return(order, nil)
+				orderRepo.EXPECT().FindPaymentByOrderID(gomock.Any(), orderID).Return(&model.Payment{
+					ID:      uuid.New(),
+					OrderID: orderID,
+					Method:  "mock",
+					Status:  "paid",
+					Amount:  decimal.RequireFromString("150.50"),
+					PaidAt:  &paidAt,
+				}, nil)
+			},
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:   "another user is forbidden",
+			userID: uuid.New(),
+			mockSetup: func(orderRepo *mocks.MockOrderRepository) {
+				orderRepo.EXPECT().FindByID(gomock.Any(), orderID).Return(order, nil)
+			},
+			wantStatus:  http.StatusForbidden,
+			wantErrCode: constant.ErrCodeForbidden,
+		},
+		{
+			name:   "no payment recorded",
+			userID: ownerID,
+			mockSetup: func(orderRepo *mocks.MockOrderRepository) {
+				orderRepo.EXPECT().FindByID(gomock.Any(), orderID).Return(order, nil)
+				orderRepo.EXPECT().FindPaymentByOrderID(gomock.Any(), orderID).Return(nil, gorm.ErrRecordNotFound)
+			},
+			wantStatus:  http.StatusNotFound,
+			wantErrCode: constant.ErrCodeNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			orderRepo := mocks.NewMockOrderRepository(ctrl)
+			tt.mockSetup(orderRepo)
+
+			h := NewOrderHandler(service.NewOrderService(orderRepo, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, service.OrderConfig{}))
+
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/orders/"+orderID.String()+"/payment", nil)
+			req.SetPathValue("id", orderID.String())
+			req = req.WithContext(context.WithValue(req.Context(), middleware.ContextUserID, tt.userID.String()))
+			rec := httptest.NewRecorder()
+
+			h.GetOrderPayment(rec, req)
+
+			assert.Equal(t, tt.wantStatus, rec.Code)
+
+			var body struct {
+				Data   model.PaymentResponse `json:"data"`
+				Errors []response.Error      `json:"errors"`
+			}
+			assert.NoError(t, json.NewDecoder(rec.Body).Decode(&body))
+
+			if tt.wantErrCode != "" {
+				if assert.Len(t, body.Errors, 1) {
+					assert.Equal(t, tt.wantErrCode, body.Errors[0].Code)
+				}
+				return
+			}
+			assert.Equal(t, orderID, body.Data.OrderID)
+			assert.Equal(t, "paid", body.Data.Status)
+			assert.Equal(t, "mock", body.Data.Method)
+			assert.True(t, decimal.RequireFromString("150.50").Equal(body.Data.Amount))
+			if assert.NotNil(t, body.Data.PaidAt) {
+				assert.True(t, paidAt.Equal(*body.Data.PaidAt))
+			}
+		})
+	}
+}
+
+func TestOrderHandler_CancelOrder_InvalidStatus(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	userID := uuid.New()
+	orderID := uuid.New()
+	orderRepo := mocks.NewMockOrderRepository(ctrl)
+	orderRepo.EXPECT().FindByID(gomock.Any(), orderID).Return(&model.Order{
+		ID:     orderID,
+		UserID: userID,
+		Status: constant.OrderStatusShipped,
+	}, nil)
+
+	h := NewOrderHandler(service.NewOrderService(orderRepo, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, service.OrderConfig{}))
+
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/orders/"+orderID.String()+"/cancel", nil)
+	req.SetPathValue("id", orderID.String())
+	req = req.WithContext(context.WithValue(req.Context(), middleware.ContextUserID, userID.String()))
+	rec := httptest.NewRecorder()
+
+	h.CancelOrder(rec, req)
+
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	var body response.Response
+	assert.NoError(t, json.NewDecoder(rec.Body).Decode(&body))
+	if assert.Len(t, body.Errors, 1) {
+		assert.Equal(t, constant.ErrCodeInvalidStatus, body.Errors[0].Code)
+	}
+}
@@ -2,10 +2,13 @@ package handler
 
 import (
 	"encoding/json"
+	"errors"
 	"net/http"
+	"net/url"
 	"strconv"
 	"strings"
 
+	"github.com/1tsndre/mini-go-project/pkg/logger"
 	"github.com/1tsndre/mini-go-project/pkg/response"
 	"github.com/1tsndre/mini-go-project/pkg/upload"
 	"github.com/1tsndre/mini-go-project/store-service/internal/constant"
@@ -17,12 +20,13 @@ import (
 )
 
 type ProductHandler struct {
-	service  service.ProductService
-	uploader *upload.Uploader
+	service       service.ProductService
+	uploader      *upload.Uploader
+	uploadLimiter service.UploadLimiter
 }
 
-func NewProductHandler(service service.ProductService, uploader *upload.Uploader) *ProductHandler {
-	return &ProductHandler{service: service, uploader: uploader}
+func NewProductHandler(service service.ProductService, uploader *upload.Uploader, uploadLimiter service.UploadLimiter) *ProductHandler {
+	return &ProductHandler{service: service, uploader: uploader, uploadLimiter: uploadLimiter}
 }
 
 func (h *ProductHandler) CreateProduct(w http.ResponseWriter, r *http.Request) {
@@ -44,69 +48,84 @@ func (h *ProductHandler) CreateProduct(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	var errors []response.Error
-	if req.Name == "" {
-		errors = append(errors, response.NewFieldError(constant.ErrCodeValidation, "name", "is required"))
-	}
-	if req.Price == "" {
-		errors = append(errors, response.NewFieldError(constant.ErrCodeValidation, "price", "is required"))
-	}
-	if req.CategoryID == "" {
-		errors = append(errors, response.NewFieldError(constant.ErrCodeValidation, "category_id", "is required"))
-	}
-	if len(errors) > 0 {
-		response.ValidationError(w, meta, errors)
+	if errs := validationErrors(req); len(errs) > 0 {
+		response.ValidationError(w, meta, errs)
 		return
 	}
 
 	resp, err := h.service.CreateProduct(r.Context(), userID, req)
 	if err != nil {
-		msg := err.Error()
-		switch {
-		case strings.Contains(msg, "not found"):
-			response.ErrorResponse(w, http.StatusNotFound, meta,
-				response.NewError(constant.ErrCodeNotFound, msg))
-		case strings.Contains(msg, "failed"):
-			response.ErrorResponse(w, http.StatusInternalServerError, meta,
-				response.NewError(constant.ErrCodeInternal, msg))
-		default:
-			response.ErrorResponse(w, http.StatusBadRequest, meta,
-				response.NewError(constant.ErrCodeValidation, msg))
-		}
+		writeServiceError(w, meta, err)
 		return
 	}
 
 	response.Success(w, http.StatusCreated, resp, meta)
 }
 
-func (h *ProductHandler) GetProducts(w http.ResponseWriter, r *http.Request) {
-	meta := middleware.BuildMeta(r)
-
+func productFilterFromQuery(r *http.Request) (model.ProductFilter, error) {
 	q := r.URL.Query()
 	page, _ := strconv.Atoi(q.Get("page"))
 	perPage, _ := strconv.Atoi(q.Get("per_page"))
 
-	filter := model.ProductFilter{
-		CategoryID: q.Get("category_id"),
-		StoreID:    q.Get("store_id"),
-		Search:     q.Get("search"),
-		MinPrice:   q.Get("min_price"),
-		MaxPrice:   q.Get("max_price"),
-		SortBy:     q.Get("sort_by"),
-		SortOrder:  q.Get("sort_order"),
-		Page:       page,
-		PerPage:    perPage,
+	if cursor := q.Get("cursor"); cursor != "" {
+		if _, err := pagination.DecodeCursor(cursor); err != nil {
+			return model.ProductFilter{}, err
+		}
 	}
 
-	products, total, err := h.service.GetProducts(r.Context(), filter)
-	if err != nil {
-		response.ErrorResponse(w, http.StatusInternalServerError, meta,
-			response.NewError(constant.ErrCodeInternal, err.Error()),
-		)
-		return
+	return model.ProductFilter{
+		CategoryID:           q.Get("category_id"),
+		IncludeSubcategories: q.Get("include_subcategories") == "true",
+		StoreID:              q.Get("store_id"),
+		Search:               q.Get("search"),
+		MinPrice:             q.Get("min_price"),
+		MaxPrice:             q.Get("max_price"),
+		SortBy:               q.Get("sort_by"),
+		SortOrder:            q.Get("sort_order"),
+		Page:                 page,
+		PerPage:              perPage,
+		Cursor:               q.Get("cursor"),
+		Attributes:           attributeFilterFromQuery(q),
+		// Listings are public; a request carrying credentials may get a
+		// personalized variant, so it never shares the listing cache.
+		SkipCache: r.Header.Get(constant.HeaderAuthorization) != "",
+	}, nil
+}
+
+// attributeFilterFromQuery collects attr.<key>=<value> query parameters.
+func attributeFilterFromQuery(q url.Values) map[string]string {
+	var attrs map[string]string
+	for param, values := range q {
+		key, ok := strings.CutPrefix(param, "attr.")
+		if !ok || key == "" || len(values) == 0 {
+			continue
+		}
+		if attrs == nil {
+			attrs = make(map[string]string)
+		}
+		attrs[key] = values[0]
 	}
+	return attrs
+}
 
+// writeProductPage writes a product listing. A next_cursor is included
+// whenever the page is full and ordered by creation time, so offset clients
+// can switch to cursor mode from any page. In cursor mode no totals are
+// known and the pagination block is omitted.
+func writeProductPage(w http.ResponseWriter, meta *response.Meta, products []model.ProductResponse, total int64, filter model.ProductFilter) {
 	filter.Page, filter.PerPage = pagination.Normalize(filter.Page, filter.PerPage)
+
+	byCreatedAt := filter.Cursor != "" || filter.SortBy == "" || filter.SortBy == "created_at"
+	if byCreatedAt && len(products) == filter.PerPage {
+		last := products[len(products)-1]
+		meta.NextCursor = pagination.EncodeCursor(last.CreatedAt, last.ID)
+	}
+
+	if filter.Cursor != "" {
+		response.Success(w, http.StatusOK, products, meta)
+		return
+	}
+
 	response.SuccessWithPagination(w, http.StatusOK, products, meta, &response.Pagination{
 		CurrentPage: filter.Page,
 		PerPage:     filter.PerPage,
@@ -115,6 +134,93 @@ func (h *ProductHandler) GetProducts(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
+func (h *ProductHandler) GetProducts(w http.ResponseWriter, r *http.Request) {
+	meta := middleware.BuildMeta(r)
+
+	filter, err := productFilterFromQuery(r)
+	if err != nil {
+		response.ValidationError(w, meta, []response.Error{
+			response.NewFieldError(constant.ErrCodeValidation, "cursor", err.Error()),
+		})
+		return
+	}
+
+	if r.URL.Query().Get("exclude_own") == "true" {
+		userID, err := uuid.Parse(middleware.GetUserID(r.Context()))
+		if err != nil || middleware.GetUserRole(r.Context()) != constant.RoleSeller {
+			response.ErrorResponse(w, http.StatusForbidden, meta,
+				response.NewError(constant.ErrCodeForbidden, "exclude_own requires a seller login"),
+			)
+			return
+		}
+		filter.ExcludeOwnerID = userID
+	}
+
+	products, total, err := h.service.GetProducts(r.Context(), filter)
+	if err != nil {
+		writeServiceError(w, meta, err)
+		return
+	}
+
+	writeProductPage(w, meta, products, total, filter)
+}
+
+func (h *ProductHandler) GetStoreProducts(w http.ResponseWriter, r *http.Request) {
+	meta := middleware.BuildMeta(r)
+
+	storeID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		response.ErrorResponse(w, http.StatusBadRequest, meta,
+			response.NewError(constant.ErrCodeValidation, "invalid store id"),
+		)
+		return
+	}
+
+	filter, err := productFilterFromQuery(r)
+	if err != nil {
+		response.ValidationError(w, meta, []response.Error{
+			response.NewFieldError(constant.ErrCodeValidation, "cursor", err.Error()),
+		})
+		return
+	}
+
+	products, total, err := h.service.GetStoreProducts(r.Context(), storeID, filter)
+	if err != nil {
+		writeServiceError(w, meta, err)
+		return
+	}
+
+	writeProductPage(w, meta, products, total, filter)
+}
+
+func (h *ProductHandler) GetSellerProducts(w http.ResponseWriter, r *http.Request) {
+	meta := middleware.BuildMeta(r)
+
+	userID, err := uuid.Parse(middleware.GetUserID(r.Context()))
+	if err != nil {
+		response.ErrorResponse(w, http.StatusUnauthorized, meta,
+			response.NewError(constant.ErrCodeUnauthorized, "invalid user"),
+		)
+		return
+	}
+
+	filter, err := productFilterFromQuery(r)
+	if err != nil {
+		response.ValidationError(w, meta, []response.Error{
+			response.NewFieldError(constant.ErrCodeValidation, "cursor", err.Error()),
+		})
+		return
+	}
+
+	products, total, err := h.service.GetSellerProducts(r.Context(), userID, filter)
+	if err != nil {
+		writeServiceError(w, meta, err)
+		return
+	}
+
+	writeProductPage(w, meta, products, total, filter)
+}
+
 func (h *ProductHandler) GetProduct(w http.ResponseWriter, r *http.Request) {
 	meta := middleware.BuildMeta(r)
 
@@ -128,9 +234,7 @@ func (h *ProductHandler) GetProduct(w http.ResponseWriter, r *http.Request) {
 
 	resp, err := h.service.GetProductByID(r.Context(), id)
 	if err != nil {
-		response.ErrorResponse(w, http.StatusNotFound, meta,
-			response.NewError(constant.ErrCodeNotFound, err.Error()),
-		)
+		writeServiceError(w, meta, err)
 		return
 	}
 
@@ -164,23 +268,14 @@ func (h *ProductHandler) UpdateProduct(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if errs := validationErrors(req); len(errs) > 0 {
+		response.ValidationError(w, meta, errs)
+		return
+	}
+
 	resp, err := h.service.UpdateProduct(r.Context(), userID, id, req)
 	if err != nil {
-		msg := err.Error()
-		switch {
-		case strings.Contains(msg, "not found"):
-			response.ErrorResponse(w, http.StatusNotFound, meta,
-				response.NewError(constant.ErrCodeNotFound, msg))
-		case strings.Contains(msg, "forbidden"):
-			response.ErrorResponse(w, http.StatusForbidden, meta,
-				response.NewError(constant.ErrCodeForbidden, msg))
-		case strings.Contains(msg, "failed"):
-			response.ErrorResponse(w, http.StatusInternalServerError, meta,
-				response.NewError(constant.ErrCodeInternal, msg))
-		default:
-			response.ErrorResponse(w, http.StatusBadRequest, meta,
-				response.NewError(constant.ErrCodeValidation, msg))
-		}
+		writeServiceError(w, meta, err)
 		return
 	}
 
@@ -207,18 +302,7 @@ func (h *ProductHandler) DeleteProduct(w http.ResponseWriter, r *http.Request) {
 	}
 
 	if err := h.service.DeleteProduct(r.Context(), userID, id); err != nil {
-		msg := err.Error()
-		switch {
-		case strings.Contains(msg, "not found"):
-			response.ErrorResponse(w, http.StatusNotFound, meta,
-				response.NewError(constant.ErrCodeNotFound, msg))
-		case strings.Contains(msg, "forbidden"):
-			response.ErrorResponse(w, http.StatusForbidden, meta,
-				response.NewError(constant.ErrCodeForbidden, msg))
-		default:
-			response.ErrorResponse(w, http.StatusInternalServerError, meta,
-				response.NewError(constant.ErrCodeInternal, msg))
-		}
+		writeServiceError(w, meta, err)
 		return
 	}
 
@@ -244,6 +328,15 @@ func (h *ProductHandler) UploadImage(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	release, err := h.uploadLimiter.Acquire(r.Context(), userID)
+	if err != nil {
+		response.ErrorResponse(w, http.StatusTooManyRequests, meta,
+			response.NewError(constant.ErrCodeRateLimited, err.Error()),
+		)
+		return
+	}
+	defer release()
+
 	file, header, err := r.FormFile("image")
 	if err != nil {
 		response.ErrorResponse(w, http.StatusBadRequest, meta,
@@ -261,22 +354,364 @@ func (h *ProductHandler) UploadImage(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	resp, err := h.service.UpdateImage(r.Context(), userID, id, path)
+	// A missing thumbnail only costs list views bandwidth, so it never fails
+	// the upload.
+	thumbPath, err := h.uploader.Thumbnail(path)
 	if err != nil {
-		msg := err.Error()
-		switch {
-		case strings.Contains(msg, "not found"):
-			response.ErrorResponse(w, http.StatusNotFound, meta,
-				response.NewError(constant.ErrCodeNotFound, msg))
-		case strings.Contains(msg, "forbidden"):
-			response.ErrorResponse(w, http.StatusForbidden, meta,
-				response.NewError(constant.ErrCodeForbidden, msg))
-		default:
-			response.ErrorResponse(w, http.StatusInternalServerError, meta,
-				response.NewError(constant.ErrCodeInternal, msg))
-		}
+		logger.Error(r.Context(), "failed to generate thumbnail", err, map[string]interface{}{
+			"path": path,
+		})
+		thumbPath = ""
+	}
+
+	resp, replaced, err := h.service.UpdateImage(r.Context(), userID, id, path, thumbPath)
+	if err != nil {
+		removeImageFiles(r, h.uploader, path, thumbPath)
+
+		writeServiceError(w, meta, err)
 		return
 	}
 
+	// The old files are only removed once nothing references them anymore.
+	if replaced != nil && replaced.URL != path {
+		removeImageFiles(r, h.uploader, replaced.URL, replaced.ThumbnailURL)
+	}
+
 	response.Success(w, http.StatusOK, resp, meta)
 }
+
+func (h *ProductHandler) ListProductImages(w http.ResponseWriter, r *http.Request) {
+	meta := middleware.BuildMeta(r)
+
+	id, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		response.ErrorResponse(w, http.StatusBadRequest, meta,
+			response.NewError(constant.ErrCodeValidation, "invalid product id"),
+		)
+		return
+	}
+
+	images, err := h.service.ListProductImages(r.Context(), id)
+	if err != nil {
+		writeServiceError(w, meta, err)
+		return
+	}
+
+	response.Success(w, http.StatusOK, images, meta)
+}
+
+func (h *ProductHandler) AddProductImage(w http.ResponseWriter, r *http.Request) {
+	meta := middleware.BuildMeta(r)
+
+	userID, err := uuid.Parse(middleware.GetUserID(r.Context()))
+	if err != nil {
+		response.ErrorResponse(w, http.StatusUnauthorized, meta,
+			response.NewError(constant.ErrCodeUnauthorized, "invalid user"),
+		)
+		return
+	}
+
+	id, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		response.ErrorResponse(w, http.StatusBadRequest, meta,
+			response.NewError(constant.ErrCodeValidation, "invalid product id"),
+		)
+		return
+	}
+
+	release, err := h.uploadLimiter.Acquire(r.Context(), userID)
+	if err != nil {
+		response.ErrorResponse(w, http.StatusTooManyRequests, meta,
+			response.NewError(constant.ErrCodeRateLimited, err.Error()),
+		)
+		return
+	}
+	defer release()
+
+	file, header, err := r.FormFile("image")
+	if err != nil {
+		response.ErrorResponse(w, http.StatusBadRequest, meta,
+			response.NewError(constant.ErrCodeValidation, "image file is required"),
+		)
+		return
+	}
+	defer file.Close()
+
+	path, err := h.uploader.Upload(file, header, "products")
+	if err != nil {
+		response.ErrorResponse(w, http.StatusBadRequest, meta,
+			response.NewError(constant.ErrCodeValidation, err.Error()),
+		)
+		return
+	}
+
+	thumbPath, err := h.uploader.Thumbnail(path)
+	if err != nil {
+		logger.Error(r.Context(), "failed to generate thumbnail", err, map[string]interface{}{
+			"path": path,
+		})
+		thumbPath = ""
+	}
+
+	image, err := h.service.AddProductImage(r.Context(), userID, id, path, thumbPath)
+	if err != nil {
+		removeImageFiles(r, h.uploader, path, thumbPath)
+
+		writeServiceError(w, meta, err)
+		return
+	}
+
+	response.Success(w, http.StatusCreated, image, meta)
+}
+
+func (h *ProductHandler) DeleteProductImage(w http.ResponseWriter, r *http.Request) {
+	meta := middleware.BuildMeta(r)
+
+	userID, err := uuid.Parse(middleware.GetUserID(r.Context()))
+	if err != nil {
+		response.ErrorResponse(w, http.StatusUnauthorized, meta,
+			response.NewError(constant.ErrCodeUnauthorized, "invalid user"),
+		)
+		return
+	}
+
+	id, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		response.ErrorResponse(w, http.StatusBadRequest, meta,
+			response.NewError(constant.ErrCodeValidation, "invalid product id"),
+		)
+		return
+	}
+
+	imageID, err := uuid.Parse(r.PathValue("image_id"))
+	if err != nil {
+		response.ErrorResponse(w, http.StatusBadRequest, meta,
+			response.NewError(constant.ErrCodeValidation, "invalid image id"),
+		)
+		return
+	}
+
+	image, err := h.service.DeleteProductImage(r.Context(), userID, id, imageID)
+	if err != nil {
+		writeServiceError(w, meta, err)
+		return
+	}
+
+	removeImageFiles(r, h.uploader, image.URL, image.ThumbnailURL)
+
+	response.Success(w, http.StatusOK, map[string]string{"message": "image deleted"}, meta)
+}
+
+func (h *ProductHandler) ReorderProductImages(w http.ResponseWriter, r *http.Request) {
+	meta := middleware.BuildMeta(r)
+
+	userID, err := uuid.Parse(middleware.GetUserID(r.Context()))
+	if err != nil {
+		response.ErrorResponse(w, http.StatusUnauthorized, meta,
+			response.NewError(constant.ErrCodeUnauthorized, "invalid user"),
+		)
+		return
+	}
+
+	id, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		response.ErrorResponse(w, http.StatusBadRequest, meta,
+			response.NewError(constant.ErrCodeValidation, "invalid product id"),
+		)
+		return
+	}
+
+	var req model.ReorderProductImagesRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		response.ErrorResponse(w, http.StatusBadRequest, meta,
+			response.NewError(constant.ErrCodeValidation, "invalid request body"),
+		)
+		return
+	}
+
+	images, err := h.service.ReorderProductImages(r.Context(), userID, id, req.ImageIDs)
+	if err != nil {
+		writeServiceError(w, meta, err)
+		return
+	}
+
+	response.Success(w, http.StatusOK, images, meta)
+}
+
+// removeImageFiles deletes an image and its thumbnail from disk. Failures are
+// only logged: the database no longer references the files.
+func removeImageFiles(r *http.Request, uploader *upload.Uploader, path, thumbPath string) {
+	if path == "" {
+		return
+	}
+	if err := uploader.Delete(path); err != nil {
+		logger.Error(r.Context(), "failed to remove image file", err, map[string]interface{}{
+			"path": path,
+		})
+	}
+	if thumbPath != "" && thumbPath != path {
+		if err := uploader.Delete(thumbPath); err != nil {
+			logger.Error(r.Context(), "failed to remove thumbnail file", err)
+		}
+	}
+}
+
+func (h *ProductHandler) ExportProducts(w http.ResponseWriter, r *http.Request) {
+	meta := middleware.BuildMeta(r)
+
+	userID, err := uuid.Parse(middleware.GetUserID(r.Context()))
+	if err != nil {
+		response.ErrorResponse(w, http.StatusUnauthorized, meta,
+			response.NewError(constant.ErrCodeUnauthorized, "invalid user"),
+		)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/csv")
+	w.Header().Set("Content-Disposition", `attachment; filename="products.csv"`)
+
+	if err := h.service.ExportProducts(r.Context(), userID, w); err != nil {
+		if errors.Is(err, service.ErrNotFound) {
+			writeServiceError(w, meta, err)
+		} else {
+			// The CSV stream may already be partially written; nothing more
+			// useful can be sent to the client at this point.
+			logger.Error(r.Context(), "product export aborted", err)
+		}
+		return
+	}
+}
+
+func (h *ProductHandler) GetProductAttributes(w http.ResponseWriter, r *http.Request) {
+	meta := middleware.BuildMeta(r)
+
+	id, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		response.ErrorResponse(w, http.StatusBadRequest, meta,
+			response.NewError(constant.ErrCodeValidation, "invalid product id"),
+		)
+		return
+	}
+
+	attrs, err := h.service.GetProductAttributes(r.Context(), id)
+	if err != nil {
+		writeServiceError(w, meta, err)
+		return
+	}
+
+	response.Success(w, http.StatusOK, attrs, meta)
+}
+
+func (h *ProductHandler) GetAlsoBought(w http.ResponseWriter, r *http.Request) {
+	meta := middleware.BuildMeta(r)
+
+	id, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		response.ErrorResponse(w, http.StatusBadRequest, meta,
+			response.NewError(constant.ErrCodeValidation, "invalid product id"),
+		)
+		return
+	}
+
+	products, err := h.service.GetAlsoBought(r.Context(), id)
+	if err != nil {
+		writeServiceError(w, meta, err)
+		return
+	}
+
+	response.Success(w, http.StatusOK, products, meta)
+}
+
+func (h *ProductHandler) GetRelatedProducts(w http.ResponseWriter, r *http.Request) {
+	meta := middleware.BuildMeta(r)
+
+	id, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		response.ErrorResponse(w, http.StatusBadRequest, meta,
+			response.NewError(constant.ErrCodeValidation, "invalid product id"),
+		)
+		return
+	}
+
+	products, err := h.service.GetRelatedProducts(r.Context(), id)
+	if err != nil {
+		writeServiceError(w, meta, err)
+		return
+	}
+
+	response.Success(w, http.StatusOK, products, meta)
+}
+
+func (h *ProductHandler) SetProductAttributes(w http.ResponseWriter, r *http.Request) {
+	meta := middleware.BuildMeta(r)
+
+	userID, err := uuid.Parse(middleware.GetUserID(r.Context()))
+	if err != nil {
+		response.ErrorResponse(w, http.StatusUnauthorized, meta,
+			response.NewError(constant.ErrCodeUnauthorized, "invalid user"),
+		)
+		return
+	}
+
+	id, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		response.ErrorResponse(w, http.StatusBadRequest, meta,
+			response.NewError(constant.ErrCodeValidation, "invalid product id"),
+		)
+		return
+	}
+
+	var req model.SetProductAttributesRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		response.ErrorResponse(w, http.StatusBadRequest, meta,
+			response.NewError(constant.ErrCodeValidation, "invalid request body"),
+		)
+		return
+	}
+
+	attrs, err := h.service.SetProductAttributes(r.Context(), userID, id, req.Attributes)
+	if err != nil {
+		writeServiceError(w, meta, err)
+		return
+	}
+
+	response.Success(w, http.StatusOK, attrs, meta)
+}
+
+func (h *ProductHandler) GetStockHistory(w http.ResponseWriter, r *http.Request) {
+	meta := middleware.BuildMeta(r)
+
+	userID, err := uuid.Parse(middleware.GetUserID(r.Context()))
+	if err != nil {
+		response.ErrorResponse(w, http.StatusUnauthorized, meta,
+			response.NewError(constant.ErrCodeUnauthorized, "invalid user"),
+		)
+		return
+	}
+
+	id, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		response.ErrorResponse(w, http.StatusBadRequest, meta,
+			response.NewError(constant.ErrCodeValidation, "invalid product id"),
+		)
+		return
+	}
+
+	q := r.URL.Query()
+	page, _ := strconv.Atoi(q.Get("page"))
+	perPage, _ := strconv.Atoi(q.Get("per_page"))
+
+	movements, total, err := h.service.GetStockHistory(r.Context(), userID, id, page, perPage)
+	if err != nil {
+		writeServiceError(w, meta, err)
+		return
+	}
+
+	page, perPage = pagination.Normalize(page, perPage)
+	response.SuccessWithPagination(w, http.StatusOK, movements, meta, &response.Pagination{
+		CurrentPage: page,
+		PerPage:     perPage,
+		TotalItems:  total,
+		TotalPages:  pagination.TotalPages(total, perPage),
+	})
+}
@@ -0,0 +1,488 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"image"
+	"image/png"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/1tsndre/mini-go-project/pkg/jwt"
+	"github.com/1tsndre/mini-go-project/pkg/response"
+	"github.com/1tsndre/mini-go-project/pkg/upload"
+	"github.com/1tsndre/mini-go-project/store-service/internal/constant"
+	"github.com/1tsndre/mini-go-project/store-service/internal/middleware"
+	"github.com/1tsndre/mini-go-project/store-service/internal/mocks"
+	"github.com/1tsndre/mini-go-project/store-service/internal/model"
+	"github.com/1tsndre/mini-go-project/store-service/internal/pagination"
+	"github.com/1tsndre/mini-go-project/store-service/internal/service"
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+)
+
+func TestProductHandler_GetStoreProducts(t *testing.T) {
+	storeID := uuid.New()
+
+	tests := []struct {
+		name           string
+		storeID        string
+		mockSetup      func(prodRepo *mocks.MockProductRepository, storeRepo *mocks.MockStoreRepository)
+		wantStatus     int
+		wantErrCode    string
+		wantCount      int
+		wantPagination *response.Pagination
+	}{
+		{
+			name:    "populated store",
+			storeID: storeID.String(),
+			mockSetup: func(prodRepo *mocks.MockProductRepository, storeRepo *mocks.MockStoreRepository) {
+				storeRepo.EXPECT().FindByID(gomock.Any(), storeID).Return(&model.Store{ID: storeID}, nil)
+				prodRepo.EXPECT().FindAll(gomock.Any(), gomock.Any()).
+					DoAndReturn(func(_ context.Context, filter model.ProductFilter) ([]model.Product, int64, error) {
+						assert.Equal(t, storeID.String(), filter.StoreID)
+						return []model.Product{
+							{ID: uuid.New(), StoreID: storeID, Name: "A", Price: decimal.NewFromInt(1000)},
+							{ID: uuid.New(), StoreID: storeID, Name: "B", Price: decimal.NewFromInt(2000)},
+						}, int64(12), nil
+					})
+			},
+			wantStatus: http.StatusOK,
+			wantCount:  2,
+			wantPagination: &response.Pagination{
+				CurrentPage: 2,
+				PerPage:     10,
+				TotalItems:  12,
+				TotalPages:  2,
+			},
+		},
+		{
+			name:    "nonexistent store",
+			storeID: storeID.String(),
+			mockSetup: func(_ *mocks.MockProductRepository, storeRepo *mocks.MockStoreRepository) {
+				storeRepo.EXPECT().FindByID(gomock.Any(), storeID).Return(nil, errors.New("record not found"))
+			},
+			wantStatus:  http.StatusNotFound,
+			wantErrCode: constant.ErrCodeNotFound,
+		},
+		{
+			name:        "invalid store id",
+			storeID:     "not-a-uuid",
+			mockSetup:   func(_ *mocks.MockProductRepository, _ *mocks.MockStoreRepository) {},
+			wantStatus:  http.StatusBadRequest,
+			wantErrCode: constant.ErrCodeValidation,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			prodRepo := mocks.NewMockProductRepository(ctrl)
+			storeRepo := mocks.NewMockStoreRepository(ctrl)
+			tt.mockSetup(prodRepo, storeRepo)
+
+			h := NewProductHandler(service.NewProductService(prodRepo, storeRepo, nil, nil, service.ProductConfig{}), nil, nil)
+
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/stores/"+tt.storeID+"/products?page=2&per_page=10", nil)
+			req.SetPathValue("id", tt.storeID)
+			rec := httptest.NewRecorder()
+
+			h.GetStoreProducts(rec, req)
+
+			assert.Equal(t, tt.wantStatus, rec.Code)
+
+			var body struct {
+				Data   []model.ProductResponse `json:"data"`
+				Meta   response.Meta           `json:"meta"`
+				Errors []response.Error        `json:"errors"`
+			}
+			assert.NoError(t, json.NewDecoder(rec.Body).Decode(&body))
+
+			if tt.wantErrCode != "" {
+				assert.Len(t, body.Errors, 1)
+				assert.Equal(t, tt.wantErrCode, body.Errors[0].Code)
+				return
+			}
+			assert.Len(t, body.Data, tt.wantCount)
+			assert.Equal(t, tt.wantPagination, body.Meta.Pagination)
+		})
+	}
+}
+
+func TestProductHandler_GetProducts_Cursor(t *testing.T) {
+	createdAt := time.Date(2024, 3, 1, 10, 30, 0, 0, time.UTC)
+	lastID := uuid.New()
+	cursor := pagination.EncodeCursor(createdAt.Add(time.Hour), uuid.New())
+
+	tests := []struct {
+		name           string
+		query          string
+		mockSetup      func(prodRepo *mocks.MockProductRepository)
+		wantStatus     int
+		wantNextCursor string
+		wantPagination bool
+	}{
+		{
+			name:  "full page returns next cursor",
+			query: "?per_page=2&cursor=" + cursor,
+			mockSetup: func(prodRepo *mocks.MockProductRepository) {
+				prodRepo.EXPECT().FindAll(gomock.Any(), gomock.Any()).
+					DoAndReturn(func(_ context.Context, filter model.ProductFilter) ([]model.Product, int64, error) {
+						assert.Equal(t, cursor, filter.Cursor)
+						return []model.Product{
+							{ID: uuid.New(), CreatedAt: createdAt.Add(time.Minute)},
+							{ID: lastID, CreatedAt: createdAt},
+						}, 0, nil
+					})
+			},
+			wantStatus:     http.StatusOK,
+			wantNextCursor: pagination.EncodeCursor(createdAt, lastID),
+		},
+		{
+			name:  "last page has no next cursor",
+			query: "?per_page=2&cursor=" + cursor,
+			mockSetup: func(prodRepo *mocks.MockProductRepository) {
+				prodRepo.EXPECT().FindAll(gomock.Any(), gomock.Any()).
+					Return([]model.Product{{ID: lastID, CreatedAt: createdAt}}, int64(0), nil)
+			},
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:  "offset page offers a cursor to switch modes",
+			query: "?per_page=1",
+			mockSetup: func(prodRepo *mocks.MockProductRepository) {
+				prodRepo.EXPECT().FindAll(gomock.Any(), gomock.Any()).
+					Return([]model.Product{{ID: lastID, CreatedAt: createdAt}}, int64(5), nil)
+			},
+			wantStatus:     http.StatusOK,
+			wantNextCursor: pagination.EncodeCursor(createdAt, lastID),
+			wantPagination: true,
+		},
+		{
+			name:       "invalid cursor",
+			query:      "?cursor=bm9wZQ",
+			mockSetup:  func(_ *mocks.MockProductRepository) {},
+			wantStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			prodRepo := mocks.NewMockProductRepository(ctrl)
+			tt.mockSetup(prodRepo)
+
+			h := NewProductHandler(service.NewProductService(prodRepo, mocks.NewMockStoreRepository(ctrl), nil, nil, service.ProductConfig{}), nil, nil)
+
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/products"+tt.query, nil)
+			rec := httptest.NewRecorder()
+
+			h.GetProducts(rec, req)
+
+			assert.Equal(t, tt.wantStatus, rec.Code)
+
+			var body struct {
+				Meta response.Meta `json:"meta"`
+			}
+			assert.NoError(t, json.NewDecoder(rec.Body).Decode(&body))
+			assert.Equal(t, tt.wantNextCursor, body.Meta.NextCursor)
+			assert.Equal(t, tt.wantPagination, body.Meta.Pagination != nil)
+		})
+	}
+}
+
+func TestProductHandler_GetProducts_ExcludeOwn(t *testing.T) {
+	jwtManager := jwt.NewJWTManager("test-secret", time.Minute, time.Hour)
+	sellerID := uuid.New()
+	storeID := uuid.New()
+
+	token := func(role string) string {
+		pair, err := jwtManager.GenerateTokenPair(sellerID.String(), "seller@example.com", role)
+		assert.NoError(t, err)
+		return "Bearer " + pair.AccessToken
+	}
+
+	tests := []struct {
+		name       string
+		auth       string
+		wantStatus int
+	}{
+		{name: "seller excludes own store", auth: token(constant.RoleSeller), wantStatus: http.StatusOK},
+		{name: "buyer cannot use exclude_own", auth: token(constant.RoleBuyer), wantStatus: http.StatusForbidden},
+		{name: "anonymous cannot use exclude_own", wantStatus: http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			prodRepo := mocks.NewMockProductRepository(ctrl)
+			storeRepo := mocks.NewMockStoreRepository(ctrl)
+			userRepo := mocks.NewMockUserRepository(ctrl)
+			userRepo.EXPECT().IsActive(gomock.Any(), sellerID).Return(true, nil).AnyTimes()
+			if tt.wantStatus == http.StatusOK {
+				storeRepo.EXPECT().FindByUserID(gomock.Any(), sellerID).Return(&model.Store{ID: storeID, UserID: sellerID}, nil)
+				prodRepo.EXPECT().FindAll(gomock.Any(), gomock.Any()).
+					DoAndReturn(func(_ context.Context, filter model.ProductFilter) ([]model.Product, int64, error) {
+						assert.Equal(t, storeID.String(), filter.ExcludeStoreID)
+						assert.True(t, filter.SkipCache)
+						return nil, 0, nil
+					})
+			}
+
+			h := NewProductHandler(service.NewProductService(prodRepo, storeRepo, nil, nil, service.ProductConfig{}), nil, nil)
+			handler := middleware.OptionalAuth(jwtManager, userRepo)(http.HandlerFunc(h.GetProducts))
+
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/products?exclude_own=true", nil)
+			if tt.auth != "" {
+				req.Header.Set(constant.HeaderAuthorization, tt.auth)
+			}
+			rec := httptest.NewRecorder()
+
+			handler.ServeHTTP(rec, req)
+
+			assert.Equal(t, tt.wantStatus, rec.Code)
+		})
+	}
+}
+
+func TestProductHandler_GetRelatedProducts(t *testing.T) {
+	productID := uuid.New()
+	categoryID := uuid.New()
+
+	tests := []struct {
+		name        string
+		productID   string
+		mockSetup   func(prodRepo *mocks.MockProductRepository)
+		wantStatus  int
+		wantErrCode string
+		wantNames   []string
+	}{
+		{
+			name:      "category with several products",
+			productID: productID.String(),
+			mockSetup: func(prodRepo *mocks.MockProductRepository) {
+				prodRepo.EXPECT().FindByID(gomock.Any(), productID).
+					Return(&model.Product{ID: productID, CategoryID: categoryID}, nil)
+				prodRepo.EXPECT().FindAll(gomock.Any(), gomock.Any()).
+					DoAndReturn(func(_ context.Context, filter model.ProductFilter) ([]model.Product, int64, error) {
+						assert.Equal(t, categoryID.String(), filter.CategoryID)
+						assert.Equal(t, productID.String(), filter.ExcludeID)
+						assert.Equal(t, "rating", filter.SortBy)
+						assert.Equal(t, "desc", filter.SortOrder)
+						assert.Equal(t, 1, filter.Page)
+						assert.Equal(t, 3, filter.PerPage)
+						return []model.Product{
+							{ID: uuid.New(), CategoryID: categoryID, Name: "Best rated"},
+							{ID: uuid.New(), CategoryID: categoryID, Name: "Newer"},
+							{ID: uuid.New(), CategoryID: categoryID, Name: "Older"},
+						}, int64(5), nil
+					})
+			},
+			wantStatus: http.StatusOK,
+			wantNames:  []string{"Best rated", "Newer", "Older"},
+		},
+		{
+			name:      "only product in its category",
+			productID: productID.String(),
+			mockSetup: func(prodRepo *mocks.MockProductRepository) {
+				prodRepo.EXPECT().FindByID(gomock.Any(), productID).
+					Return(&model.Product{ID: productID, CategoryID: categoryID}, nil)
+				prodRepo.EXPECT().FindAll(gomock.Any(), gomock.Any()).Return(nil, int64(0), nil)
+			},
+			wantStatus: http.StatusOK,
+			wantNames:  []string{},
+		},
+		{
+			name:      "nonexistent product",
+			productID: productID.String(),
+			mockSetup: func(prodRepo *mocks.MockProductRepository) {
+				prodRepo.EXPECT().FindByID(gomock.Any(), productID).Return(nil, errors.New("record not found"))
+			},
+			wantStatus:  http.StatusNotFound,
+			wantErrCode: constant.ErrCodeNotFound,
+		},
+		{
+			name:        "invalid product id",
+			productID:   "not-a-uuid",
+			mockSetup:   func(_ *mocks.MockProductRepository) {},
+			wantStatus:  http.StatusBadRequest,
+			wantErrCode: constant.ErrCodeValidation,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			prodRepo := mocks.NewMockProductRepository(ctrl)
+			tt.mockSetup(prodRepo)
+
+			h := NewProductHandler(service.NewProductService(prodRepo, mocks.NewMockStoreRepository(ctrl), nil, nil, service.ProductConfig{RelatedLimit: 3}), nil, nil)
+
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/products/"+tt.productID+"/related", nil)
+			req.SetPathValue("id", tt.productID)
+			rec := httptest.NewRecorder()
+
+			h.GetRelatedProducts(rec, req)
+
+			assert.Equal(t, tt.wantStatus, rec.Code)
+
+			var body struct {
+				Data   []model.ProductResponse `json:"data"`
+				Errors []response.Error        `json:"errors"`
+			}
+			assert.NoError(t, json.NewDecoder(rec.Body).Decode(&body))
+
+			if tt.wantErrCode != "" {
+				assert.Len(t, body.Errors, 1)
+				assert.Equal(t, tt.wantErrCode, body.Errors[0].Code)
+				return
+			}
+			names := make([]string, 0, len(body.Data))
+			for _, p := range body.Data {
+				names = append(names, p.Name)
+			}
+			assert.Equal(t, tt.wantNames, names)
+		})
+	}
+}
+
+func TestProductHandler_UploadImage_ConcurrencyLimit(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	userID := uuid.New()
+	productID := uuid.New()
+
+	slotRepo := mocks.NewMockUploadSlotRepository(ctrl)
+	slotRepo.EXPECT().Acquire(gomock.Any(), userID, 1).Return(false, nil)
+
+	limiter := service.NewUploadLimiter(slotRepo, 1)
+	h := NewProductHandler(nil, nil, limiter)
+
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/products/"+productID.String()+"/image", nil)
+	req.SetPathValue("id", productID.String())
+	req = req.WithContext(context.WithValue(req.Context(), middleware.ContextUserID, userID.String()))
+	rec := httptest.NewRecorder()
+
+	h.UploadImage(rec, req)
+
+	assert.Equal(t, http.StatusTooManyRequests, rec.Code)
+
+	var body struct {
+		Errors []response.Error `json:"errors"`
+	}
+	assert.NoError(t, json.NewDecoder(rec.Body).Decode(&body))
+	assert.Len(t, body.Errors, 1)
+	assert.Equal(t, constant.ErrCodeRateLimited, body.Errors[0].Code)
+}
+
+func TestProductHandler_UploadImage_RemovesReplacedFiles(t *testing.T) {
+	tests := []struct {
+		name         string
+		updateErr    error
+		wantStatus   int
+		wantOldFiles bool
+	}{
+		{name: "successful replace removes old files", wantStatus: http.StatusOK},
+		{name: "failed update keeps old files", updateErr: errors.New("db error"), wantStatus: http.StatusInternalServerError, wantOldFiles: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			userID := uuid.New()
+			storeID := uuid.New()
+			productID := uuid.New()
+
+			dir := t.TempDir()
+			assert.NoError(t, os.MkdirAll(filepath.Join(dir, "products"), 0755))
+			for _, name := range []string{"old.png", "old_thumb.png"} {
+				assert.NoError(t, os.WriteFile(filepath.Join(dir, "products", name), []byte("old"), 0644))
+			}
+
+			prodRepo := mocks.NewMockProductRepository(ctrl)
+			storeRepo := mocks.NewMockStoreRepository(ctrl)
+			storeRepo.EXPECT().FindByUserID(gomock.Any(), userID).Return(&model.Store{ID: storeID, UserID: userID}, nil)
+			prodRepo.EXPECT().FindByID(gomock.Any(), productID).Return(&model.Product{ID: productID, StoreID: storeID}, nil)
+			prodRepo.EXPECT().FindImages(gomock.Any(), productID).Return([]model.ProductImage{
+				{ID: uuid.New(), ProductID: productID, URL: "products/old.png", ThumbnailURL: "products/old_thumb.png", IsPrimary: true},
+			}, nil)
+			prodRepo.EXPECT().UpdateImage(gomock.Any(), gomock.Any(), gomock.Any()).Return(tt.updateErr)
+
+			h := NewProductHandler(
+				service.NewProductService(prodRepo, storeRepo, nil, nil, service.ProductConfig{}),
+				upload.NewUploader(dir, 1<<20, 0),
+				service.NewUploadLimiter(nil, 0),
+			)
+
+			var body bytes.Buffer
+			mw := multipart.NewWriter(&body)
+			part, err := mw.CreateFormFile("image", "new.png")
+			assert.NoError(t, err)
+			assert.NoError(t, png.Encode(part, image.NewRGBA(image.Rect(0, 0, 8, 8))))
+			assert.NoError(t, mw.Close())
+
+			req := httptest.NewRequest(http.MethodPost, "/api/v1/products/"+productID.String()+"/image", &body)
+			req.Header.Set("Content-Type", mw.FormDataContentType())
+			req.SetPathValue("id", productID.String())
+			req = req.WithContext(context.WithValue(req.Context(), middleware.ContextUserID, userID.String()))
+			rec := httptest.NewRecorder()
+
+			h.UploadImage(rec, req)
+
+			assert.Equal(t, tt.wantStatus, rec.Code)
+			for _, name := range []string{"old.png", "old_thumb.png"} {
+				_, err := os.Stat(filepath.Join(dir, "products", name))
+				assert.Equal(t, tt.wantOldFiles, err == nil, name)
+			}
+			entries, err := os.ReadDir(filepath.Join(dir, "products"))
+			assert.NoError(t, err)
+			if tt.wantOldFiles {
+				assert.Len(t, entries, 2, "the rejected upload is removed")
+			} else {
+				assert.Len(t, entries, 1, "only the new image remains")
+			}
+		})
+	}
+}
+
+func TestProductHandler_SetProductAttributes_FieldError(t *testing.T) {
+	userID := uuid.New()
+	productID := uuid.New()
+	h := NewProductHandler(service.NewProductService(nil, nil, nil, nil, service.ProductConfig{MaxAttributes: 1}), nil, nil)
+
+	body := `{"attributes":{"color":"red","size":"M"}}`
+	req := httptest.NewRequest(http.MethodPut, "/api/v1/products/"+productID.String()+"/attributes", bytes.NewBufferString(body))
+	req.SetPathValue("id", productID.String())
+	req = req.WithContext(context.WithValue(req.Context(), middleware.ContextUserID, userID.String()))
+	rec := httptest.NewRecorder()
+
+	h.SetProductAttributes(rec, req)
+
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	var resp response.Response
+	assert.NoError(t, json.NewDecoder(rec.Body).Decode(&resp))
+	if assert.Len(t, resp.Errors, 1) {
+		assert.Equal(t, constant.ErrCodeValidation, resp.Errors[0].Code)
+		assert.Equal(t, "attributes", resp.Errors[0].Field)
+	}
+}
@@ -1,6 +1,7 @@
 package handler
 
 import (
+	"context"
 	"encoding/json"
 	"net/http"
 	"strconv"
@@ -66,7 +67,7 @@ func (h *ReviewHandler) CreateReview(w http.ResponseWriter, r *http.Request) {
 		case strings.Contains(msg, "already reviewed"):
 			response.ErrorResponse(w, http.StatusConflict, meta,
 				response.NewError(constant.ErrCodeConflict, msg))
-		case strings.Contains(msg, "must purchase"):
+		case strings.Contains(msg, "must purchase"), strings.Contains(msg, "own product"):
 			response.ErrorResponse(w, http.StatusForbidden, meta,
 				response.NewError(constant.ErrCodeForbidden, msg))
 		case strings.Contains(msg, "failed"):
@@ -82,6 +83,101 @@ func (h *ReviewHandler) CreateReview(w http.ResponseWriter, r *http.Request) {
 	response.Success(w, http.StatusCreated, resp, meta)
 }
 
+func (h *ReviewHandler) UpdateReview(w http.ResponseWriter, r *http.Request) {
+	meta := middleware.BuildMeta(r)
+
+	userID, err := uuid.Parse(middleware.GetUserID(r.Context()))
+	if err != nil {
+		response.ErrorResponse(w, http.StatusUnauthorized, meta,
+			response.NewError(constant.ErrCodeUnauthorized, "invalid user"),
+		)
+		return
+	}
+
+	productID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		response.ErrorResponse(w, http.StatusBadRequest, meta,
+			response.NewError(constant.ErrCodeValidation, "invalid product id"),
+		)
+		return
+	}
+
+	var req model.UpdateReviewRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		response.ErrorResponse(w, http.StatusBadRequest, meta,
+			response.NewError(constant.ErrCodeValidation, "invalid request body"),
+		)
+		return
+	}
+
+	if req.Rating < 1 || req.Rating > 5 {
+		response.ValidationError(w, meta, []response.Error{
+			response.NewFieldError(constant.ErrCodeValidation, "rating", "must be between 1 and 5"),
+		})
+		return
+	}
+
+	resp, err := h.service.UpdateReview(r.Context(), userID, productID, req)
+	if err != nil {
+		msg := err.Error()
+		switch {
+		case strings.Contains(msg, "not found"):
+			response.ErrorResponse(w, http.StatusNotFound, meta,
+				response.NewError(constant.ErrCodeNotFound, msg))
+		case strings.Contains(msg, "must purchase"), strings.Contains(msg, "forbidden"):
+			response.ErrorResponse(w, http.StatusForbidden, meta,
+				response.NewError(constant.ErrCodeForbidden, msg))
+		case strings.Contains(msg, "failed"):
+			response.ErrorResponse(w, http.StatusInternalServerError, meta,
+				response.NewError(constant.ErrCodeInternal, msg))
+		default:
+			response.ErrorResponse(w, http.StatusBadRequest, meta,
+				response.NewError(constant.ErrCodeValidation, msg))
+		}
+		return
+	}
+
+	response.Success(w, http.StatusOK, resp, meta)
+}
+
+func (h *ReviewHandler) DeleteReview(w http.ResponseWriter, r *http.Request) {
+	meta := middleware.BuildMeta(r)
+
+	userID, err := uuid.Parse(middleware.GetUserID(r.Context()))
+	if err != nil {
+		response.ErrorResponse(w, http.StatusUnauthorized, meta,
+			response.NewError(constant.ErrCodeUnauthorized, "invalid user"),
+		)
+		return
+	}
+
+	reviewID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		response.ErrorResponse(w, http.StatusBadRequest, meta,
+			response.NewError(constant.ErrCodeValidation, "invalid review id"),
+		)
+		return
+	}
+
+	if err := h.service.DeleteReview(r.Context(), userID, middleware.GetUserRole(r.Context()), reviewID); err != nil {
+		msg := err.Error()
+		switch {
+		case strings.Contains(msg, "not found"):
+			response.ErrorResponse(w, http.StatusNotFound, meta,
+				response.NewError(constant.ErrCodeNotFound, msg))
+		case strings.Contains(msg, "forbidden"):
+			response.ErrorResponse(w, http.StatusForbidden, meta,
+				response.NewError(constant.ErrCodeForbidden, msg))
+		default:
+			response.ErrorResponse(w, http.StatusInternalServerError, meta,
+				response.NewError(constant.ErrCodeInternal, msg))
+		}
+		return
+	}
+
+	response.Success(w, http.StatusOK, map[string]string{"message": "review deleted"}, meta)
+}
+
 func (h *ReviewHandler) GetProductReviews(w http.ResponseWriter, r *http.Request) {
 	meta := middleware.BuildMeta(r)
 
@@ -96,8 +192,24 @@ func (h *ReviewHandler) GetProductReviews(w http.ResponseWriter, r *http.Request
 	q := r.URL.Query()
 	page, _ := strconv.Atoi(q.Get("page"))
 	perPage, _ := strconv.Atoi(q.Get("per_page"))
+	filter := model.ReviewFilter{
+		SortBy:    q.Get("sort_by"),
+		SortOrder: q.Get("sort_order"),
+		Page:      page,
+		PerPage:   perPage,
+	}
+	if raw := q.Get("rating"); raw != "" {
+		rating, err := strconv.Atoi(raw)
+		if err != nil || rating < 1 || rating > 5 {
+			response.ValidationError(w, meta, []response.Error{
+				response.NewFieldError(constant.ErrCodeValidation, "rating", "must be between 1 and 5"),
+			})
+			return
+		}
+		filter.Rating = rating
+	}
 
-	reviews, total, err := h.service.GetProductReviews(r.Context(), productID, page, perPage)
+	reviews, total, err := h.service.GetProductReviews(r.Context(), productID, filter)
 	if err != nil {
 		response.ErrorResponse(w, http.StatusInternalServerError, meta,
 			response.NewError(constant.ErrCodeInternal, err.Error()),
@@ -113,3 +225,97 @@ func (h *ReviewHandler) GetProductReviews(w http.ResponseWriter, r *http.Request
 		TotalPages:  pagination.TotalPages(total, perPage),
 	})
 }
+
+func (h *ReviewHandler) AddHelpfulVote(w http.ResponseWriter, r *http.Request) {
+	h.helpfulVote(w, r, h.service.AddHelpfulVote)
+}
+
+func (h *ReviewHandler) RemoveHelpfulVote(w http.ResponseWriter, r *http.Request) {
+	h.helpfulVote(w, r, h.service.RemoveHelpfulVote)
+}
+
+func (h *ReviewHandler) helpfulVote(w http.ResponseWriter, r *http.Request, vote func(ctx context.Context, userID, reviewID uuid.UUID) (*model.HelpfulVoteResponse, error)) {
+	meta := middleware.BuildMeta(r)
+
+	userID, err := uuid.Parse(middleware.GetUserID(r.Context()))
+	if err != nil {
+		response.ErrorResponse(w, http.StatusUnauthorized, meta,
+			response.NewError(constant.ErrCodeUnauthorized, "invalid user"),
+		)
+		return
+	}
+
+	reviewID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		response.ErrorResponse(w, http.StatusBadRequest, meta,
+			response.NewError(constant.ErrCodeValidation, "invalid review id"),
+		)
+		return
+	}
+
+	resp, err := vote(r.Context(), userID, reviewID)
+	if err != nil {
+		msg := err.Error()
+		if strings.Contains(msg, "not found") {
+			response.ErrorResponse(w, http.StatusNotFound, meta,
+				response.NewError(constant.ErrCodeNotFound, msg))
+			return
+		}
+		response.ErrorResponse(w, http.StatusInternalServerError, meta,
+			response.NewError(constant.ErrCodeInternal, msg))
+		return
+	}
+
+	response.Success(w, http.StatusOK, resp, meta)
+}
+
+func (h *ReviewHandler) ReplyToReview(w http.ResponseWriter, r *http.Request) {
+	meta := middleware.BuildMeta(r)
+
+	userID, err := uuid.Parse(middleware.GetUserID(r.Context()))
+	if err != nil {
+		response.ErrorResponse(w, http.StatusUnauthorized, meta,
+			response.NewError(constant.ErrCodeUnauthorized, "invalid user"),
+		)
+		return
+	}
+
+	reviewID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		response.ErrorResponse(w, http.StatusBadRequest, meta,
+			response.NewError(constant.ErrCodeValidation, "invalid review id"),
+		)
+		return
+	}
+
+	var req model.ReplyReviewRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		response.ErrorResponse(w, http.StatusBadRequest, meta,
+			response.NewError(constant.ErrCodeValidation, "invalid request body"),
+		)
+		return
+	}
+
+	resp, err := h.service.ReplyToReview(r.Context(), userID, reviewID, req)
+	if err != nil {
+		msg := err.Error()
+		switch {
+		case strings.Contains(msg, "not found"):
+			response.ErrorResponse(w, http.StatusNotFound, meta,
+				response.NewError(constant.ErrCodeNotFound, msg))
+		case strings.Contains(msg, "forbidden"):
+			response.ErrorResponse(w, http.StatusForbidden, meta,
+				response.NewError(constant.ErrCodeForbidden, msg))
+		case strings.Contains(msg, "failed"):
+			response.ErrorResponse(w, http.StatusInternalServerError, meta,
+				response.NewError(constant.ErrCodeInternal, msg))
+		default:
+			response.ValidationError(w, meta, []response.Error{
+				response.NewFieldError(constant.ErrCodeValidation, "comment", msg),
+			})
+		}
+		return
+	}
+
+	response.Success(w, http.StatusOK, resp, meta)
+}
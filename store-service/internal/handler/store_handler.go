@@ -2,9 +2,12 @@ package handler
 
 import (
 	"encoding/json"
+	"fmt"
 	"net/http"
 	"strings"
+	"time"
 
+	"github.com/1tsndre/mini-go-project/pkg/logger"
 	"github.com/1tsndre/mini-go-project/pkg/response"
 	"github.com/1tsndre/mini-go-project/pkg/upload"
 	"github.com/1tsndre/mini-go-project/store-service/internal/constant"
@@ -15,12 +18,13 @@ import (
 )
 
 type StoreHandler struct {
-	service  service.StoreService
-	uploader *upload.Uploader
+	service       service.StoreService
+	uploader      *upload.Uploader
+	uploadLimiter service.UploadLimiter
 }
 
-func NewStoreHandler(service service.StoreService, uploader *upload.Uploader) *StoreHandler {
-	return &StoreHandler{service: service, uploader: uploader}
+func NewStoreHandler(service service.StoreService, uploader *upload.Uploader, uploadLimiter service.UploadLimiter) *StoreHandler {
+	return &StoreHandler{service: service, uploader: uploader, uploadLimiter: uploadLimiter}
 }
 
 func (h *StoreHandler) CreateStore(w http.ResponseWriter, r *http.Request) {
@@ -153,6 +157,15 @@ func (h *StoreHandler) UploadLogo(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	release, err := h.uploadLimiter.Acquire(r.Context(), userID)
+	if err != nil {
+		response.ErrorResponse(w, http.StatusTooManyRequests, meta,
+			response.NewError(constant.ErrCodeRateLimited, err.Error()),
+		)
+		return
+	}
+	defer release()
+
 	file, header, err := r.FormFile("logo")
 	if err != nil {
 		response.ErrorResponse(w, http.StatusBadRequest, meta,
@@ -170,8 +183,20 @@ func (h *StoreHandler) UploadLogo(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	resp, err := h.service.UpdateLogo(r.Context(), userID, id, path)
+	// A missing thumbnail only costs list views bandwidth, so it never fails
+	// the upload.
+	thumbPath, err := h.uploader.Thumbnail(path)
+	if err != nil {
+		logger.Error(r.Context(), "failed to generate thumbnail", err, map[string]interface{}{
+			"path": path,
+		})
+		thumbPath = ""
+	}
+
+	resp, replaced, err := h.service.UpdateLogo(r.Context(), userID, id, path, thumbPath)
 	if err != nil {
+		removeImageFiles(r, h.uploader, path, thumbPath)
+
 		msg := err.Error()
 		switch {
 		case strings.Contains(msg, "not found"):
@@ -187,5 +212,139 @@ func (h *StoreHandler) UploadLogo(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// The old files are only removed once nothing references them anymore.
+	if replaced != nil && replaced.URL != path {
+		removeImageFiles(r, h.uploader, replaced.URL, replaced.ThumbnailURL)
+	}
+
+	response.Success(w, http.StatusOK, resp, meta)
+}
+
+func (h *StoreHandler) GetSellerStats(w http.ResponseWriter, r *http.Request) {
+	meta := middleware.BuildMeta(r)
+
+	userID, err := uuid.Parse(middleware.GetUserID(r.Context()))
+	if err != nil {
+		response.ErrorResponse(w, http.StatusUnauthorized, meta,
+			response.NewError(constant.ErrCodeUnauthorized, "invalid user"),
+		)
+		return
+	}
+
+	resp, err := h.service.GetSellerStats(r.Context(), userID)
+	if err != nil {
+		msg := err.Error()
+		if strings.Contains(msg, "not found") {
+			response.ErrorResponse(w, http.StatusNotFound, meta,
+				response.NewError(constant.ErrCodeNotFound, msg))
+		} else {
+			response.ErrorResponse(w, http.StatusInternalServerError, meta,
+				response.NewError(constant.ErrCodeInternal, msg))
+		}
+		return
+	}
+
+	response.Success(w, http.StatusOK, resp, meta)
+}
+
+// maxCommissionRangeDays bounds the commission report so a single request
+// cannot aggregate the store's entire history.
+const maxCommissionRangeDays = 366
+
+// GetSellerCommission reports the seller's commission for an inclusive
+// from/to date range given as YYYY-MM-DD.
+func (h *StoreHandler) GetSellerCommission(w http.ResponseWriter, r *http.Request) {
+	meta := middleware.BuildMeta(r)
+
+	userID, err := uuid.Parse(middleware.GetUserID(r.Context()))
+	if err != nil {
+		response.ErrorResponse(w, http.StatusUnauthorized, meta,
+			response.NewError(constant.ErrCodeUnauthorized, "invalid user"),
+		)
+		return
+	}
+
+	q := r.URL.Query()
+	var errs []response.Error
+	from, err := time.Parse(time.DateOnly, q.Get("from"))
+	if err != nil {
+		errs = append(errs, response.NewFieldError(constant.ErrCodeValidation, "from", "from must be a date in YYYY-MM-DD format"))
+	}
+	to, err := time.Parse(time.DateOnly, q.Get("to"))
+	if err != nil {
+		errs = append(errs, response.NewFieldError(constant.ErrCodeValidation, "to", "to must be a date in YYYY-MM-DD format"))
+	}
+	if len(errs) == 0 {
+		switch {
+		case to.Before(from):
+			errs = append(errs, response.NewFieldError(constant.ErrCodeValidation, "to", "to must not be before from"))
+		case to.Sub(from) >= maxCommissionRangeDays*24*time.Hour:
+			errs = append(errs, response.NewFieldError(constant.ErrCodeValidation, "to",
+				fmt.Sprintf("date range must not exceed %d days", maxCommissionRangeDays)))
+		}
+	}
+	if len(errs) > 0 {
+		response.ValidationError(w, meta, errs)
+		return
+	}
+
+	resp, err := h.service.GetSellerCommission(r.Context(), userID, from, to.AddDate(0, 0, 1))
+	if err != nil {
+		msg := err.Error()
+		if strings.Contains(msg, "not found") {
+			response.ErrorResponse(w, http.StatusNotFound, meta,
+				response.NewError(constant.ErrCodeNotFound, msg))
+		} else {
+			response.ErrorResponse(w, http.StatusInternalServerError, meta,
+				response.NewError(constant.ErrCodeInternal, msg))
+		}
+		return
+	}
+	resp.From = from.Format(time.DateOnly)
+	resp.To = to.Format(time.DateOnly)
+
+	response.Success(w, http.StatusOK, resp, meta)
+}
+
+// UpdateStoreStatus deactivates or reactivates a store.
+func (h *StoreHandler) UpdateStoreStatus(w http.ResponseWriter, r *http.Request) {
+	meta := middleware.BuildMeta(r)
+
+	id, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		response.ErrorResponse(w, http.StatusBadRequest, meta,
+			response.NewError(constant.ErrCodeValidation, "invalid store id"),
+		)
+		return
+	}
+
+	var req model.UpdateStoreStatusRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		response.ErrorResponse(w, http.StatusBadRequest, meta,
+			response.NewError(constant.ErrCodeValidation, "invalid request body"),
+		)
+		return
+	}
+
+	if req.Active == nil {
+		response.ValidationError(w, meta, []response.Error{
+			response.NewFieldError(constant.ErrCodeValidation, "active", "is required"),
+		})
+		return
+	}
+
+	resp, err := h.service.SetStoreActive(r.Context(), id, *req.Active)
+	if err != nil {
+		msg := err.Error()
+		if strings.Contains(msg, "not found") {
+			response.ErrorResponse(w, http.StatusNotFound, meta,
+				response.NewError(constant.ErrCodeNotFound, msg))
+		} else {
+			response.ErrorResponse(w, http.StatusInternalServerError, meta,
+				response.NewError(constant.ErrCodeInternal, msg))
+		}
+		return
+	}
+
 	response.Success(w, http.StatusOK, resp, meta)
 }
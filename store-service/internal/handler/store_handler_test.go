@@ -0,0 +1,93 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"image"
+	"image/png"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/1tsndre/mini-go-project/pkg/upload"
+	"github.com/1tsndre/mini-go-project/store-service/internal/middleware"
+	"github.com/1tsndre/mini-go-project/store-service/internal/mocks"
+	"github.com/1tsndre/mini-go-project/store-service/internal/model"
+	"github.com/1tsndre/mini-go-project/store-service/internal/service"
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+)
+
+func TestStoreHandler_UploadLogo_RemovesReplacedFiles(t *testing.T) {
+	tests := []struct {
+		name         string
+		updateErr    error
+		wantStatus   int
+		wantOldFiles bool
+	}{
+		{name: "successful replace removes old files", wantStatus: http.StatusOK},
+		{name: "failed update keeps old files", updateErr: errors.New("db error"), wantStatus: http.StatusInternalServerError, wantOldFiles: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			userID := uuid.New()
+			storeID := uuid.New()
+
+			dir := t.TempDir()
+			assert.NoError(t, os.MkdirAll(filepath.Join(dir, "stores"), 0755))
+			for _, name := range []string{"old.png", "old_thumb.png"} {
+				assert.NoError(t, os.WriteFile(filepath.Join(dir, "stores", name), []byte("old"), 0644))
+			}
+
+			storeRepo := mocks.NewMockStoreRepository(ctrl)
+			storeRepo.EXPECT().FindByID(gomock.Any(), storeID).Return(&model.Store{
+				ID: storeID, UserID: userID, LogoURL: "stores/old.png", ThumbnailURL: "stores/old_thumb.png",
+			}, nil)
+			storeRepo.EXPECT().Update(gomock.Any(), gomock.Any()).Return(tt.updateErr)
+
+			h := NewStoreHandler(
+				service.NewStoreService(storeRepo, nil, nil, nil, decimal.Zero, 0),
+				upload.NewUploader(dir, 1<<20, 0),
+				service.NewUploadLimiter(nil, 0),
+			)
+
+			var body bytes.Buffer
+			mw := multipart.NewWriter(&body)
+			part, err := mw.CreateFormFile("logo", "new.png")
+			assert.NoError(t, err)
+			assert.NoError(t, png.Encode(part, image.NewRGBA(image.Rect(0, 0, 8, 8))))
+			assert.NoError(t, mw.Close())
+
+			req := httptest.NewRequest(http.MethodPost, "/api/v1/stores/"+storeID.String()+"/logo", &body)
+			req.Header.Set("Content-Type", mw.FormDataContentType())
+			req.SetPathValue("id", storeID.String())
+			req = req.WithContext(context.WithValue(req.Context(), middleware.ContextUserID, userID.String()))
+			rec := httptest.NewRecorder()
+
+			h.UploadLogo(rec, req)
+
+			assert.Equal(t, tt.wantStatus, rec.Code)
+			for _, name := range []string{"old.png", "old_thumb.png"} {
+				_, err := os.Stat(filepath.Join(dir, "stores", name))
+				assert.Equal(t, tt.wantOldFiles, err == nil, name)
+			}
+			entries, err := os.ReadDir(filepath.Join(dir, "stores"))
+			assert.NoError(t, err)
+			if tt.wantOldFiles {
+				assert.Len(t, entries, 2, "the rejected upload is removed")
+			} else {
+				assert.Len(t, entries, 1, "only the new logo remains")
+			}
+		})
+	}
+}
@@ -0,0 +1,194 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/1tsndre/mini-go-project/pkg/response"
+	"github.com/1tsndre/mini-go-project/store-service/internal/constant"
+	"github.com/1tsndre/mini-go-project/store-service/internal/middleware"
+	"github.com/1tsndre/mini-go-project/store-service/internal/model"
+	"github.com/1tsndre/mini-go-project/store-service/internal/pagination"
+	"github.com/1tsndre/mini-go-project/store-service/internal/service"
+	"github.com/google/uuid"
+)
+
+type UserHandler struct {
+	service service.UserService
+}
+
+func NewUserHandler(service service.UserService) *UserHandler {
+	return &UserHandler{service: service}
+}
+
+// GetUsers lists users for admins, optionally filtered by exact role and by
+// part of the email address.
+func (h *UserHandler) GetUsers(w http.ResponseWriter, r *http.Request) {
+	meta := middleware.BuildMeta(r)
+
+	q := r.URL.Query()
+	page, _ := strconv.Atoi(q.Get("page"))
+	perPage, _ := strconv.Atoi(q.Get("per_page"))
+	filter := model.UserFilter{
+		Role:    q.Get("role"),
+		Email:   q.Get("email"),
+		Page:    page,
+		PerPage: perPage,
+	}
+
+	if filter.Role != "" && !constant.Roles[filter.Role] {
+		response.ValidationError(w, meta, []response.Error{
+			response.NewFieldError(constant.ErrCodeValidation, "role", "unknown role"),
+		})
+		return
+	}
+
+	users, total, err := h.service.GetUsers(r.Context(), filter)
+	if err != nil {
+		response.ErrorResponse(w, http.StatusInternalServerError, meta,
+			response.NewError(constant.ErrCodeInternal, err.Error()))
+		return
+	}
+
+	page, perPage = pagination.Normalize(page, perPage)
+	response.SuccessWithPagination(w, http.StatusOK, users, meta, &response.Pagination{
+		CurrentPage: page,
+		PerPage:     perPage,
+		TotalItems:  total,
+		TotalPages:  pagination.TotalPages(total, perPage),
+	})
+}
+
+func (h *UserHandler) GetUser(w http.ResponseWriter, r *http.Request) {
+	meta := middleware.BuildMeta(r)
+
+	id, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		response.ErrorResponse(w, http.StatusBadRequest, meta,
+			response.NewError(constant.ErrCodeValidation, "invalid user id"),
+		)
+		return
+	}
+
+	resp, err := h.service.GetUser(r.Context(), id)
+	if err != nil {
+		msg := err.Error()
+		if strings.Contains(msg, "not found") {
+			response.ErrorResponse(w, http.StatusNotFound, meta,
+				response.NewError(constant.ErrCodeNotFound, msg))
+		} else {
+			response.ErrorResponse(w, http.StatusInternalServerError, meta,
+				response.NewError(constant.ErrCodeInternal, msg))
+		}
+		return
+	}
+
+	response.Success(w, http.StatusOK, resp, meta)
+}
+
+func (h *UserHandler) UpdateUserRole(w http.ResponseWriter, r *http.Request) {
+	meta := middleware.BuildMeta(r)
+
+	id, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		response.ErrorResponse(w, http.StatusBadRequest, meta,
+			response.NewError(constant.ErrCodeValidation, "invalid user id"),
+		)
+		return
+	}
+
+	var req model.UpdateUserRoleRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		response.ErrorResponse(w, http.StatusBadRequest, meta,
+			response.NewError(constant.ErrCodeValidation, "invalid request body"),
+		)
+		return
+	}
+
+	if req.Role == "" {
+		response.ValidationError(w, meta, []response.Error{
+			response.NewFieldError(constant.ErrCodeValidation, "role", "is required"),
+		})
+		return
+	}
+
+	resp, err := h.service.UpdateUserRole(r.Context(), id, req.Role)
+	if err != nil {
+		msg := err.Error()
+		switch {
+		case strings.Contains(msg, "must be"):
+			response.ValidationError(w, meta, []response.Error{
+				response.NewFieldError(constant.ErrCodeValidation, "role", msg),
+			})
+		case strings.Contains(msg, "not found"):
+			response.ErrorResponse(w, http.StatusNotFound, meta,
+				response.NewError(constant.ErrCodeNotFound, msg))
+		case strings.Contains(msg, "last admin"):
+			response.ErrorResponse(w, http.StatusConflict, meta,
+				response.NewError(constant.ErrCodeConflict, msg))
+		default:
+			response.ErrorResponse(w, http.StatusInternalServerError, meta,
+				response.NewError(constant.ErrCodeInternal, msg))
+		}
+		return
+	}
+
+	response.Success(w, http.StatusOK, resp, meta)
+}
+
+// UpdateUserStatus deactivates or reactivates a user.
+func (h *UserHandler) UpdateUserStatus(w http.ResponseWriter, r *http.Request) {
+	meta := middleware.BuildMeta(r)
+
+	adminID, err := uuid.Parse(middleware.GetUserID(r.Context()))
+	if err != nil {
+		response.ErrorResponse(w, http.StatusUnauthorized, meta,
+			response.NewError(constant.ErrCodeUnauthorized, "invalid user"),
+		)
+		return
+	}
+
+	id, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		response.ErrorResponse(w, http.StatusBadRequest, meta,
+			response.NewError(constant.ErrCodeValidation, "invalid user id"),
+		)
+		return
+	}
+
+	var req model.UpdateUserStatusRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		response.ErrorResponse(w, http.StatusBadRequest, meta,
+			response.NewError(constant.ErrCodeValidation, "invalid request body"),
+		)
+		return
+	}
+
+	if req.Active == nil {
+		response.ValidationError(w, meta, []response.Error{
+			response.NewFieldError(constant.ErrCodeValidation, "active", "is required"),
+		})
+		return
+	}
+
+	resp, err := h.service.SetUserActive(r.Context(), adminID, id, *req.Active)
+	if err != nil {
+		msg := err.Error()
+		switch {
+		case strings.Contains(msg, "not found"):
+			response.ErrorResponse(w, http.StatusNotFound, meta,
+				response.NewError(constant.ErrCodeNotFound, msg))
+		case strings.Contains(msg, "your own account"):
+			response.ErrorResponse(w, http.StatusConflict, meta,
+				response.NewError(constant.ErrCodeConflict, msg))
+		default:
+			response.ErrorResponse(w, http.StatusInternalServerError, meta,
+				response.NewError(constant.ErrCodeInternal, msg))
+		}
+		return
+	}
+
+	response.Success(w, http.StatusOK, resp, meta)
+}
@@ -0,0 +1,76 @@
+package handler
+
+import (
+	"errors"
+	"reflect"
+	"regexp"
+	"strings"
+
+	"github.com/1tsndre/mini-go-project/pkg/response"
+	"github.com/1tsndre/mini-go-project/store-service/internal/constant"
+	"github.com/go-playground/validator/v10"
+)
+
+var validate = newValidator()
+
+// emailPattern is the one rule every email the API accepts is held to, so an
+// address that can check out as a guest can also register, and vice versa.
+var emailPattern = regexp.MustCompile(`^[a-zA-Z0-9._%+\-]+@[a-zA-Z0-9.\-]+\.[a-zA-Z]{2,}$`)
+
+func newValidator() *validator.Validate {
+	v := validator.New(validator.WithRequiredStructEnabled())
+	// Report fields by their JSON names, which is what the client sent.
+	v.RegisterTagNameFunc(func(field reflect.StructField) string {
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "-" {
+			return ""
+		}
+		return name
+	})
+	// Used instead of the built-in email tag; see emailPattern.
+	_ = v.RegisterValidation("email_address", func(fl validator.FieldLevel) bool {
+		return emailPattern.MatchString(fl.Field().String())
+	})
+	return v
+}
+
+// validationErrors checks req against its validate struct tags and returns
+// one field error per failed rule, or nil when req is valid.
+func validationErrors(req any) []response.Error {
+	err := validate.Struct(req)
+	if err == nil {
+		return nil
+	}
+
+	var fieldErrs validator.ValidationErrors
+	if !errors.As(err, &fieldErrs) {
+		return []response.Error{response.NewError(constant.ErrCodeValidation, err.Error())}
+	}
+	errs := make([]response.Error, 0, len(fieldErrs))
+	for _, fe := range fieldErrs {
+		errs = append(errs, response.NewFieldError(constant.ErrCodeValidation, fe.Field(), validationMessage(fe)))
+	}
+	return errs
+}
+
+func validationMessage(fe validator.FieldError) string {
+	switch fe.Tag() {
+	case "required":
+		return "is required"
+	case "email_address":
+		return "invalid email format"
+	case "uuid":
+		return "must be a valid id"
+	case "min":
+		if fe.Kind() == reflect.String {
+			return "must be at least " + fe.Param() + " characters"
+		}
+		return "must be at least " + fe.Param()
+	case "max":
+		if fe.Kind() == reflect.String {
+			return "must be at most " + fe.Param() + " characters"
+		}
+		return "must be at most " + fe.Param()
+	}
+	return "is invalid"
+}
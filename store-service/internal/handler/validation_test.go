@@ -0,0 +1,157 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/1tsndre/mini-go-project/pkg/response"
+	"github.com/1tsndre/mini-go-project/store-service/internal/constant"
+	"github.com/1tsndre/mini-go-project/store-service/internal/middleware"
+	"github.com/1tsndre/mini-go-project/store-service/internal/model"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidationErrors(t *testing.T) {
+	negative, zero := -1, 0
+
+	tests := []struct {
+		name string
+		req  any
+		want []response.Error
+	}{
+		{
+			name: "valid register",
+			req:  model.RegisterRequest{Email: "jane@example.com", Password: "secret", Name: "Jane"},
+		},
+		{
+			name: "register with bad email and no name",
+			req:  model.RegisterRequest{Email: "jane@", Password: "secret"},
+			want: []response.Error{
+				response.NewFieldError(constant.ErrCodeValidation, "email", "invalid email format"),
+				response.NewFieldError(constant.ErrCodeValidation, "name", "is required"),
+			},
+		},
+		{
+			name: "register with a host-only email",
+			req:  model.RegisterRequest{Email: "jane@localhost", Password: "secret", Name: "Jane"},
+			want: []response.Error{
+				response.NewFieldError(constant.ErrCodeValidation, "email", "invalid email format"),
+			},
+		},
+		{
+			name: "guest checkout with a host-only email",
+			req:  model.GuestCheckoutRequest{Email: "jane@localhost", ShippingAddress: "1 Main St"},
+			want: []response.Error{
+				response.NewFieldError(constant.ErrCodeValidation, "email", "invalid email format"),
+			},
+		},
+		{
+			name: "valid guest checkout",
+			req:  model.GuestCheckoutRequest{Email: "jane@example.com", ShippingAddress: "1 Main St"},
+		},
+		{
+			name: "empty login",
+			req:  model.LoginRequest{},
+			want: []response.Error{
+				response.NewFieldError(constant.ErrCodeValidation, "email", "is required"),
+				response.NewFieldError(constant.ErrCodeValidation, "password", "is required"),
+			},
+		},
+		{
+			name: "create product with negative stock and bad category",
+			req:  model.CreateProductRequest{CategoryID: "shoes", Name: "Boot", Price: "10", Stock: -3, LowStockThreshold: &negative},
+			want: []response.Error{
+				response.NewFieldError(constant.ErrCodeValidation, "category_id", "must be a valid id"),
+				response.NewFieldError(constant.ErrCodeValidation, "stock", "must be at least 0"),
+				response.NewFieldError(constant.ErrCodeValidation, "low_stock_threshold", "must be at least 0"),
+			},
+		},
+		{
+			name: "create product with zero stock",
+			req:  model.CreateProductRequest{CategoryID: uuid.NewString(), Name: "Boot", Price: "10", Stock: 0},
+		},
+		{
+			name: "update product clearing stock",
+			req:  model.UpdateProductRequest{Stock: &zero},
+		},
+		{
+			name: "update product with negative stock",
+			req:  model.UpdateProductRequest{Stock: &negative},
+			want: []response.Error{
+				response.NewFieldError(constant.ErrCodeValidation, "stock", "must be at least 0"),
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, validationErrors(tt.req))
+		})
+	}
+}
+
+// The handlers reject malformed bodies before reaching the service, which
+// is nil here.
+func TestHandlers_MalformedRequests(t *testing.T) {
+	userID := uuid.New().String()
+
+	tests := []struct {
+		name    string
+		handle  http.HandlerFunc
+		body    string
+		wantErr map[string]string
+	}{
+		{
+			name:    "register",
+			handle:  NewAuthHandler(nil).Register,
+			body:    `{"email": "not-an-email", "password": ""}`,
+			wantErr: map[string]string{"email": "invalid email format", "password": "is required", "name": "is required"},
+		},
+		{
+			name:    "guest checkout",
+			handle:  NewOrderHandler(nil).GuestCheckout,
+			body:    `{"email": "not-an-email"}`,
+			wantErr: map[string]string{"email": "invalid email format", "shipping_address": "is required", "items": "is required"},
+		},
+		{
+			name:    "reset password",
+			handle:  NewAuthHandler(nil).ResetPassword,
+			body:    `{"email": "jane@example.com"}`,
+			wantErr: map[string]string{"token": "is required", "new_password": "is required"},
+		},
+		{
+			name:    "create product",
+			handle:  NewProductHandler(nil, nil, nil).CreateProduct,
+			body:    `{"name": "Boot", "stock": -1}`,
+			wantErr: map[string]string{"category_id": "is required", "price": "is required", "stock": "must be at least 0"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(tt.body))
+			req = req.WithContext(context.WithValue(req.Context(), middleware.ContextUserID, userID))
+			rec := httptest.NewRecorder()
+
+			tt.handle(rec, req)
+
+			assert.Equal(t, http.StatusBadRequest, rec.Code)
+			var resp struct {
+				Errors []response.Error `json:"errors"`
+			}
+			assert.NoError(t, json.NewDecoder(rec.Body).Decode(&resp))
+
+			got := make(map[string]string, len(resp.Errors))
+			for _, e := range resp.Errors {
+				assert.Equal(t, constant.ErrCodeValidation, e.Code)
+				got[e.Field] = e.Message
+			}
+			assert.Equal(t, tt.wantErr, got)
+		})
+	}
+}
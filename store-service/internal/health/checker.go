@@ -0,0 +1,78 @@
+package health
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/1tsndre/mini-go-project/pkg/logger"
+)
+
+const (
+	StatusUp   = "up"
+	StatusDown = "down"
+)
+
+// CheckFunc reports whether a single dependency is reachable.
+type CheckFunc func(ctx context.Context) error
+
+type check struct {
+	name string
+	fn   CheckFunc
+}
+
+// Checker aggregates dependency checks for the readiness probe. Checks run
+// concurrently, each bounded by the checker's timeout.
+type Checker struct {
+	timeout time.Duration
+	checks  []check
+}
+
+func NewChecker(timeout time.Duration) *Checker {
+	return &Checker{timeout: timeout}
+}
+
+// Add registers a named check. It is not safe to call once the checker is
+// serving requests.
+func (c *Checker) Add(name string, fn CheckFunc) {
+	c.checks = append(c.checks, check{name: name, fn: fn})
+}
+
+// Check runs every registered check and returns the status of each
+// dependency keyed by name, plus whether all of them are up.
+func (c *Checker) Check(ctx context.Context) (map[string]string, bool) {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	var (
+		mu       sync.Mutex
+		wg       sync.WaitGroup
+		statuses = make(map[string]string, len(c.checks))
+		healthy  = true
+	)
+
+	for _, chk := range c.checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			status := StatusUp
+			if err := chk.fn(ctx); err != nil {
+				status = StatusDown
+				logger.Error(ctx, "health check failed", err, map[string]interface{}{
+					"dependency": chk.name,
+				})
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+			statuses[chk.name] = status
+			if status != StatusUp {
+				healthy = false
+			}
+		}()
+	}
+	wg.Wait()
+
+	return statuses, healthy
+}
@@ -2,6 +2,7 @@ package middleware
 
 import (
 	"context"
+	"errors"
 	"net/http"
 	"strings"
 
@@ -9,6 +10,8 @@ import (
 	"github.com/1tsndre/mini-go-project/pkg/logger"
 	"github.com/1tsndre/mini-go-project/pkg/response"
 	"github.com/1tsndre/mini-go-project/store-service/internal/constant"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
 )
 
 type contextKey string
@@ -19,7 +22,15 @@ const (
 	ContextRole   contextKey = "role"
 )
 
-func Auth(jwtManager *jwt.JWTManager) func(http.Handler) http.Handler {
+// UserStatus reports whether a user may still use their tokens.
+// repository.UserRepository satisfies it.
+type UserStatus interface {
+	IsActive(ctx context.Context, id uuid.UUID) (bool, error)
+}
+
+// Auth rejects requests without a valid bearer token, and with 403 those of
+// users who have been deactivated since the token was issued.
+func Auth(jwtManager *jwt.JWTManager, users UserStatus) func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			authHeader := r.Header.Get(constant.HeaderAuthorization)
@@ -49,17 +60,78 @@ func Auth(jwtManager *jwt.JWTManager) func(http.Handler) http.Handler {
 				return
 			}
 
-			ctx := r.Context()
-			ctx = context.WithValue(ctx, ContextUserID, claims.UserID)
-			ctx = context.WithValue(ctx, ContextEmail, claims.Email)
-			ctx = context.WithValue(ctx, ContextRole, claims.Role)
-			ctx = logger.WithUserID(ctx, claims.UserID)
+			active, err := isActive(r.Context(), users, claims.UserID)
+			if err != nil {
+				meta := BuildMeta(r)
+				if errors.Is(err, gorm.ErrRecordNotFound) {
+					response.ErrorResponse(w, http.StatusUnauthorized, meta,
+						response.NewError(constant.ErrCodeUnauthorized, "invalid or expired token"),
+					)
+					return
+				}
+				logger.Error(r.Context(), "failed to check user status", err, map[string]interface{}{
+					"user_id": claims.UserID,
+				})
+				response.ErrorResponse(w, http.StatusInternalServerError, meta,
+					response.NewError(constant.ErrCodeInternal, "failed to verify account"),
+				)
+				return
+			}
+			if !active {
+				meta := BuildMeta(r)
+				response.ErrorResponse(w, http.StatusForbidden, meta,
+					response.NewError(constant.ErrCodeAccountDisabled, "account is deactivated"),
+				)
+				return
+			}
 
-			next.ServeHTTP(w, r.WithContext(ctx))
+			next.ServeHTTP(w, r.WithContext(withClaims(r.Context(), claims)))
 		})
 	}
 }
 
+// OptionalAuth identifies the caller on public endpoints that personalize
+// their response. A request without a valid bearer token, or from a
+// deactivated user, passes through anonymously instead of being rejected.
+func OptionalAuth(jwtManager *jwt.JWTManager, users UserStatus) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			scheme, token, ok := strings.Cut(r.Header.Get(constant.HeaderAuthorization), " ")
+			if !ok || scheme != constant.BearerScheme {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			claims, err := jwtManager.ValidateToken(token)
+			if err != nil {
+				next.ServeHTTP(w, r)
+				return
+			}
+			if active, err := isActive(r.Context(), users, claims.UserID); err != nil || !active {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			next.ServeHTTP(w, r.WithContext(withClaims(r.Context(), claims)))
+		})
+	}
+}
+
+func isActive(ctx context.Context, users UserStatus, userID string) (bool, error) {
+	id, err := uuid.Parse(userID)
+	if err != nil {
+		return false, gorm.ErrRecordNotFound
+	}
+	return users.IsActive(ctx, id)
+}
+
+func withClaims(ctx context.Context, claims *jwt.Claims) context.Context {
+	ctx = context.WithValue(ctx, ContextUserID, claims.UserID)
+	ctx = context.WithValue(ctx, ContextEmail, claims.Email)
+	ctx = context.WithValue(ctx, ContextRole, claims.Role)
+	return logger.WithUserID(ctx, claims.UserID)
+}
+
 func RequireRole(roles ...string) func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
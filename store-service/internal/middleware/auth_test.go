@@ -0,0 +1,113 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/1tsndre/mini-go-project/pkg/jwt"
+	"github.com/1tsndre/mini-go-project/store-service/internal/constant"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+// fakeUserStatus answers IsActive from a fixed map; unknown users are
+// reported as not found.
+type fakeUserStatus struct {
+	active map[uuid.UUID]bool
+	err    error
+}
+
+func (f *fakeUserStatus) IsActive(_ context.Context, id uuid.UUID) (bool, error) {
+	if f.err != nil {
+		return false, f.err
+	}
+	active, ok := f.active[id]
+	if !ok {
+		return false, gorm.ErrRecordNotFound
+	}
+	return active, nil
+}
+
+func TestAuth_UserStatus(t *testing.T) {
+	jwtManager := jwt.NewJWTManager("test-secret", time.Minute, time.Hour)
+	activeID := uuid.New()
+	bannedID := uuid.New()
+	deletedID := uuid.New()
+
+	token := func(id uuid.UUID) string {
+		pair, err := jwtManager.GenerateTokenPair(id.String(), "user@example.com", constant.RoleBuyer)
+		assert.NoError(t, err)
+		return "Bearer " + pair.AccessToken
+	}
+
+	tests := []struct {
+		name       string
+		userID     uuid.UUID
+		err        error
+		wantStatus int
+	}{
+		{name: "active user passes", userID: activeID, wantStatus: http.StatusOK},
+		{name: "deactivated user is rejected", userID: bannedID, wantStatus: http.StatusForbidden},
+		{name: "deleted user is rejected", userID: deletedID, wantStatus: http.StatusUnauthorized},
+		{name: "status lookup fails", userID: activeID, err: errors.New("db down"), wantStatus: http.StatusInternalServerError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			users := &fakeUserStatus{
+				active: map[uuid.UUID]bool{activeID: true, bannedID: false},
+				err:    tt.err,
+			}
+			var gotUserID string
+			handler := Auth(jwtManager, users)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				gotUserID = GetUserID(r.Context())
+				w.WriteHeader(http.StatusOK)
+			}))
+
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.Header.Set(constant.HeaderAuthorization, token(tt.userID))
+			rec := httptest.NewRecorder()
+
+			handler.ServeHTTP(rec, req)
+
+			assert.Equal(t, tt.wantStatus, rec.Code)
+			if tt.wantStatus == http.StatusOK {
+				assert.Equal(t, tt.userID.String(), gotUserID)
+			} else {
+				assert.Empty(t, gotUserID)
+			}
+			if tt.wantStatus == http.StatusForbidden {
+				assert.Contains(t, rec.Body.String(), constant.ErrCodeAccountDisabled)
+			}
+		})
+	}
+}
+
+func TestOptionalAuth_DeactivatedUserIsAnonymous(t *testing.T) {
+	jwtManager := jwt.NewJWTManager("test-secret", time.Minute, time.Hour)
+	bannedID := uuid.New()
+	users := &fakeUserStatus{active: map[uuid.UUID]bool{bannedID: false}}
+
+	pair, err := jwtManager.GenerateTokenPair(bannedID.String(), "user@example.com", constant.RoleBuyer)
+	assert.NoError(t, err)
+
+	gotUserID := "unset"
+	handler := OptionalAuth(jwtManager, users)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotUserID = GetUserID(r.Context())
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set(constant.HeaderAuthorization, "Bearer "+pair.AccessToken)
+	rec := httptest.NewRecorder()
+
+	handler.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Empty(t, gotUserID)
+}
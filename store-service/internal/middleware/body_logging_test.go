@@ -0,0 +1,137 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/1tsndre/mini-go-project/pkg/logger"
+	"github.com/stretchr/testify/assert"
+)
+
+// captureLog routes the logger to a buffer for the test and returns the
+// decoded "request body" entry, if one was logged.
+func captureLog(t *testing.T, run func()) map[string]interface{} {
+	t.Helper()
+
+	var buf bytes.Buffer
+	logger.SetOutput(&buf)
+	t.Cleanup(func() { logger.SetOutput(io.Discard) })
+
+	run()
+
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		var entry map[string]interface{}
+		if json.Unmarshal([]byte(line), &entry) == nil && entry["message"] == "request body" {
+			return entry
+		}
+	}
+	return nil
+}
+
+func TestBodyLogging(t *testing.T) {
+	echo := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		w.Write(body)
+	})
+
+	tests := []struct {
+		name        string
+		contentType string
+		body        string
+		maxBytes    int
+		wantLogged  bool
+		wantReq     interface{}
+	}{
+		{
+			name:        "password fields are redacted",
+			contentType: "application/json",
+			body:        `{"email":"a@b.c","password":"hunter2","nested":{"new_password":"x","Authorization":"Bearer t"}}`,
+			maxBytes:    1024,
+			wantLogged:  true,
+			wantReq: map[string]interface{}{
+				"email":    "a@b.c",
+				"password": "***",
+				"nested":   map[string]interface{}{"new_password": "***", "Authorization": "***"},
+			},
+		},
+		{
+			name:        "refresh tokens in arrays are redacted",
+			contentType: "application/json; charset=utf-8",
+			body:        `[{"refresh_token":"r1"},{"id":1}]`,
+			maxBytes:    1024,
+			wantLogged:  true,
+			wantReq: []interface{}{
+				map[string]interface{}{"refresh_token": "***"},
+				map[string]interface{}{"id": float64(1)},
+			},
+		},
+		{
+			name:        "password reset tokens are redacted",
+			contentType: "application/json",
+			body:        `{"email":"a@b.c","token":"t0k3n","new_password":"x"}`,
+			maxBytes:    1024,
+			wantLogged:  true,
+			wantReq:     map[string]interface{}{"email": "a@b.c", "token": "***", "new_password": "***"},
+		},
+		{
+			name:        "guest order lookup tokens are redacted",
+			contentType: "application/json",
+			body:        `{"data":{"id":"o-1","lookup_token":"l00kup"}}`,
+			maxBytes:    1024,
+			wantLogged:  true,
+			wantReq:     map[string]interface{}{"data": map[string]interface{}{"id": "o-1", "lookup_token": "***"}},
+		},
+		{
+			name:        "oversized body is omitted, not truncated",
+			contentType: "application/json",
+			body:        `{"password":"hunter2","padding":"` + strings.Repeat("x", 64) + `"}`,
+			maxBytes:    32,
+			wantLogged:  true,
+			wantReq:     "[omitted: exceeds 32 byte log limit]",
+		},
+		{
+			name:        "multipart upload is skipped",
+			contentType: "multipart/form-data; boundary=x",
+			body:        "--x\r\n\r\npassword=hunter2\r\n--x--",
+			maxBytes:    1024,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			entry := captureLog(t, func() {
+				req := httptest.NewRequest(http.MethodPost, "/api/v1/auth/login", strings.NewReader(tt.body))
+				req.Header.Set("Content-Type", tt.contentType)
+				BodyLogging(tt.maxBytes)(echo).ServeHTTP(rec, req)
+			})
+
+			// The handler still sees the full, unmodified body.
+			assert.Equal(t, tt.body, rec.Body.String())
+			assert.Equal(t, http.StatusCreated, rec.Code)
+
+			if !tt.wantLogged {
+				assert.Nil(t, entry)
+				return
+			}
+			assert.NotNil(t, entry)
+			assert.Equal(t, tt.wantReq, entry["request_body"])
+			assert.Equal(t, tt.wantReq, entry["response_body"])
+			assert.NotContains(t, mustJSON(t, entry), "hunter2")
+		})
+	}
+}
+
+func mustJSON(t *testing.T, v interface{}) string {
+	t.Helper()
+	b, err := json.Marshal(v)
+	assert.NoError(t, err)
+	return string(b)
+}
@@ -0,0 +1,82 @@
+package middleware
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+)
+
+const ContextClientIP contextKey = "client_ip"
+
+// ClientIP resolves each request's client address with ResolveClientIP and
+// stores it for GetClientIP. It must run ahead of anything that identifies
+// clients by address, such as Logging and the rate limiter.
+func ClientIP(trustedProxies []netip.Prefix) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx := context.WithValue(r.Context(), ContextClientIP, ResolveClientIP(r, trustedProxies))
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// ResolveClientIP returns the address of the client that sent r. A direct
+// connection is identified by RemoteAddr and its X-Forwarded-For is ignored,
+// since anyone can send one. When RemoteAddr is a trusted proxy, the
+// X-Forwarded-For entries are walked from the right, past any further
+// trusted proxies, and the first untrusted one is the client; entries to its
+// left were supplied by the client and cannot be believed.
+func ResolveClientIP(r *http.Request, trustedProxies []netip.Prefix) string {
+	client := remoteHost(r)
+	addr, err := netip.ParseAddr(client)
+	if err != nil || !isTrusted(addr, trustedProxies) {
+		return client
+	}
+
+	var hops []string
+	for _, header := range r.Header.Values("X-Forwarded-For") {
+		hops = append(hops, strings.Split(header, ",")...)
+	}
+	for i := len(hops) - 1; i >= 0; i-- {
+		hop, err := netip.ParseAddr(strings.TrimSpace(hops[i]))
+		if err != nil {
+			// A garbled entry cannot be attributed; the hop that passed it
+			// on is the best known client.
+			return client
+		}
+		client = hop.Unmap().String()
+		if !isTrusted(hop, trustedProxies) {
+			return client
+		}
+	}
+	return client
+}
+
+// GetClientIP returns the address stored by ClientIP, or the host part of
+// RemoteAddr when the middleware did not run.
+func GetClientIP(r *http.Request) string {
+	if val, ok := r.Context().Value(ContextClientIP).(string); ok {
+		return val
+	}
+	return remoteHost(r)
+}
+
+func remoteHost(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+func isTrusted(addr netip.Addr, trustedProxies []netip.Prefix) bool {
+	addr = addr.Unmap()
+	for _, prefix := range trustedProxies {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
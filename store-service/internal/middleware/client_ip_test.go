@@ -0,0 +1,91 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestResolveClientIP(t *testing.T) {
+	trusted := []netip.Prefix{
+		netip.MustParsePrefix("10.0.0.0/8"),
+		netip.MustParsePrefix("192.168.1.10/32"),
+	}
+
+	tests := []struct {
+		name       string
+		remoteAddr string
+		xff        []string
+		want       string
+	}{
+		{
+			name:       "direct connection",
+			remoteAddr: "203.0.113.5:5123",
+			want:       "203.0.113.5",
+		},
+		{
+			name:       "single proxy",
+			remoteAddr: "10.0.0.2:443",
+			xff:        []string{"203.0.113.5"},
+			want:       "203.0.113.5",
+		},
+		{
+			name:       "spoofed XFF from an untrusted source is ignored",
+			remoteAddr: "203.0.113.5:5123",
+			xff:        []string{"198.51.100.1"},
+			want:       "203.0.113.5",
+		},
+		{
+			name:       "spoofed entry forwarded by a proxy is ignored",
+			remoteAddr: "10.0.0.2:443",
+			xff:        []string{"198.51.100.1, 203.0.113.5"},
+			want:       "203.0.113.5",
+		},
+		{
+			name:       "chain of trusted proxies",
+			remoteAddr: "10.0.0.2:443",
+			xff:        []string{"203.0.113.5, 192.168.1.10", "10.1.2.3"},
+			want:       "203.0.113.5",
+		},
+		{
+			name:       "proxy without XFF",
+			remoteAddr: "10.0.0.2:443",
+			want:       "10.0.0.2",
+		},
+		{
+			name:       "garbled entry",
+			remoteAddr: "10.0.0.2:443",
+			xff:        []string{"203.0.113.5, not-an-ip"},
+			want:       "10.0.0.2",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.RemoteAddr = tt.remoteAddr
+			for _, xff := range tt.xff {
+				req.Header.Add("X-Forwarded-For", xff)
+			}
+			assert.Equal(t, tt.want, ResolveClientIP(req, trusted))
+		})
+	}
+}
+
+func TestClientIP_Middleware(t *testing.T) {
+	var got string
+	handler := ClientIP([]netip.Prefix{netip.MustParsePrefix("10.0.0.0/8")})(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			got = GetClientIP(r)
+		}))
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.RemoteAddr = "10.0.0.2:443"
+	req.Header.Set("X-Forwarded-For", "203.0.113.5")
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	assert.Equal(t, "203.0.113.5", got)
+}
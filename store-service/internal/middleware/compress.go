@@ -0,0 +1,192 @@
+package middleware
+
+import (
+	"compress/gzip"
+	"compress/zlib"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+
+	"github.com/1tsndre/mini-go-project/pkg/logger"
+)
+
+// incompressibleTypes are media types that are already compressed, so
+// encoding them again only costs CPU.
+var incompressibleTypes = map[string]bool{
+	"application/gzip":   true,
+	"application/zip":    true,
+	"application/x-gzip": true,
+	"application/pdf":    true,
+}
+
+// compressWriter buffers the start of the response until it holds minSize
+// bytes, then decides whether to compress. Small responses are sent as-is,
+// since the encoding overhead would outweigh the saving.
+type compressWriter struct {
+	http.ResponseWriter
+	encoding   string
+	minSize    int
+	statusCode int
+	buf        []byte
+	decided    bool
+	encoder    io.WriteCloser
+}
+
+func (cw *compressWriter) WriteHeader(code int) {
+	if cw.decided || cw.statusCode != 0 {
+		return
+	}
+	cw.statusCode = code
+}
+
+func (cw *compressWriter) Write(b []byte) (int, error) {
+	if cw.decided {
+		if cw.encoder != nil {
+			return cw.encoder.Write(b)
+		}
+		return cw.ResponseWriter.Write(b)
+	}
+
+	cw.buf = append(cw.buf, b...)
+	if len(cw.buf) < cw.minSize {
+		return len(b), nil
+	}
+	if err := cw.start(true); err != nil {
+		return 0, err
+	}
+	return len(b), nil
+}
+
+// Flush sends whatever is buffered so streamed responses keep streaming.
+func (cw *compressWriter) Flush() {
+	if !cw.decided {
+		if err := cw.start(len(cw.buf) >= cw.minSize); err != nil {
+			return
+		}
+	}
+	if f, ok := cw.encoder.(interface{ Flush() error }); ok {
+		f.Flush()
+	}
+	if f, ok := cw.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+func (cw *compressWriter) Unwrap() http.ResponseWriter {
+	return cw.ResponseWriter
+}
+
+// start writes the status line and any buffered bytes, compressing from here
+// on if compress is set and the response is eligible.
+func (cw *compressWriter) start(compress bool) error {
+	cw.decided = true
+	if cw.statusCode == 0 {
+		cw.statusCode = http.StatusOK
+	}
+
+	h := cw.ResponseWriter.Header()
+	if compress && cw.compressible(h) {
+		h.Set("Content-Encoding", cw.encoding)
+		h.Del("Content-Length")
+		if cw.encoding == "gzip" {
+			cw.encoder = gzip.NewWriter(cw.ResponseWriter)
+		} else {
+			// HTTP's deflate is zlib-wrapped (RFC 9110), not raw DEFLATE.
+			cw.encoder = zlib.NewWriter(cw.ResponseWriter)
+		}
+	}
+	cw.ResponseWriter.WriteHeader(cw.statusCode)
+
+	buf := cw.buf
+	cw.buf = nil
+	if len(buf) == 0 {
+		return nil
+	}
+	if cw.encoder != nil {
+		_, err := cw.encoder.Write(buf)
+		return err
+	}
+	_, err := cw.ResponseWriter.Write(buf)
+	return err
+}
+
+func (cw *compressWriter) compressible(h http.Header) bool {
+	if h.Get("Content-Encoding") != "" {
+		return false
+	}
+	if cw.statusCode < http.StatusOK || cw.statusCode == http.StatusNoContent || cw.statusCode == http.StatusNotModified {
+		return false
+	}
+
+	contentType := h.Get("Content-Type")
+	if contentType == "" {
+		contentType = http.DetectContentType(cw.buf)
+	}
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	switch {
+	case strings.HasPrefix(mediaType, "image/"),
+		strings.HasPrefix(mediaType, "video/"),
+		strings.HasPrefix(mediaType, "audio/"),
+		incompressibleTypes[mediaType]:
+		return false
+	}
+	return true
+}
+
+// close flushes a response that never reached minSize and finishes the
+// compressed stream.
+func (cw *compressWriter) close() error {
+	if !cw.decided {
+		if err := cw.start(false); err != nil {
+			return err
+		}
+	}
+	if cw.encoder != nil {
+		return cw.encoder.Close()
+	}
+	return nil
+}
+
+// Compress gzip- or deflate-encodes responses of at least minSize bytes for
+// clients that accept it. Uploaded files under /uploads/ are served as-is,
+// as are images and other already-compressed media types.
+func Compress(minSize int) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			encoding := negotiateEncoding(r.Header.Get("Accept-Encoding"))
+			if encoding == "" || r.Method == http.MethodHead || strings.HasPrefix(r.URL.Path, "/uploads/") {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			w.Header().Add("Vary", "Accept-Encoding")
+			cw := &compressWriter{ResponseWriter: w, encoding: encoding, minSize: minSize}
+			next.ServeHTTP(cw, r)
+			// Not deferred: after a panic Recovery writes its own response,
+			// and the buffered body must not go out ahead of it.
+			if err := cw.close(); err != nil {
+				logger.Error(r.Context(), "failed to finish compressed response", err)
+			}
+		})
+	}
+}
+
+// negotiateEncoding picks gzip over deflate from an Accept-Encoding header,
+// honouring q=0 exclusions. It returns "" when neither is acceptable.
+func negotiateEncoding(header string) string {
+	accepted := map[string]bool{}
+	for _, part := range strings.Split(header, ",") {
+		name, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		name = strings.ToLower(strings.TrimSpace(name))
+		q := strings.ReplaceAll(params, " ", "")
+		accepted[name] = q != "q=0" && q != "q=0.0" && q != "q=0.00" && q != "q=0.000"
+	}
+	switch {
+	case accepted["gzip"]:
+		return "gzip"
+	case accepted["deflate"]:
+		return "deflate"
+	}
+	return ""
+}
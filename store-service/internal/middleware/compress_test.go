@@ -0,0 +1,99 @@
+package middleware
+
+import (
+	"compress/gzip"
+	"compress/zlib"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/1tsndre/mini-go-project/pkg/response"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCompress(t *testing.T) {
+	items := make([]map[string]string, 100)
+	for i := range items {
+		items[i] = map[string]string{"id": fmt.Sprint(i), "name": "Product name"}
+	}
+	list := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		response.Success(w, http.StatusOK, items, &response.Meta{})
+	})
+	small := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		response.Success(w, http.StatusCreated, map[string]string{"message": "ok"}, &response.Meta{})
+	})
+	image := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "image/png")
+		w.Write(make([]byte, 4096))
+	})
+
+	tests := []struct {
+		name           string
+		handler        http.Handler
+		path           string
+		acceptEncoding string
+		wantStatus     int
+		wantEncoding   string
+		wantRawLen     int
+	}{
+		{name: "json list is gzipped", handler: list, path: "/api/v1/products", acceptEncoding: "gzip, deflate", wantStatus: http.StatusOK, wantEncoding: "gzip"},
+		{name: "deflate when gzip is not accepted", handler: list, path: "/api/v1/products", acceptEncoding: "gzip;q=0, deflate", wantStatus: http.StatusOK, wantEncoding: "deflate"},
+		{name: "no accept-encoding", handler: list, path: "/api/v1/products", wantStatus: http.StatusOK},
+		{name: "below threshold", handler: small, path: "/api/v1/cart", acceptEncoding: "gzip", wantStatus: http.StatusCreated},
+		{name: "uploads are skipped", handler: list, path: "/uploads/products/a.png", acceptEncoding: "gzip", wantStatus: http.StatusOK},
+		{name: "images are skipped", handler: image, path: "/api/v1/products/export", acceptEncoding: "gzip", wantStatus: http.StatusOK, wantRawLen: 4096},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			if tt.acceptEncoding != "" {
+				req.Header.Set("Accept-Encoding", tt.acceptEncoding)
+			}
+			rec := httptest.NewRecorder()
+
+			Compress(1024)(tt.handler).ServeHTTP(rec, req)
+
+			assert.Equal(t, tt.wantStatus, rec.Code)
+			assert.Equal(t, tt.wantEncoding, rec.Header().Get("Content-Encoding"))
+
+			var body io.Reader = rec.Body
+			switch tt.wantEncoding {
+			case "gzip":
+				zr, err := gzip.NewReader(rec.Body)
+				assert.NoError(t, err)
+				body = zr
+			case "deflate":
+				zr, err := zlib.NewReader(rec.Body)
+				assert.NoError(t, err)
+				body = zr
+			}
+			data, err := io.ReadAll(body)
+			assert.NoError(t, err)
+
+			if tt.wantRawLen > 0 {
+				assert.Len(t, data, tt.wantRawLen)
+				return
+			}
+			var decoded map[string]any
+			assert.NoError(t, json.Unmarshal(data, &decoded), "body should decode to the original JSON")
+		})
+	}
+}
+
+func TestCompress_SetsVary(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/products", nil)
+	req.Header.Set("Accept-Encoding", "gzip")
+	rec := httptest.NewRecorder()
+
+	Compress(0)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	})).ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusNoContent, rec.Code)
+	assert.Equal(t, "Accept-Encoding", rec.Header().Get("Vary"))
+	assert.Empty(t, rec.Header().Get("Content-Encoding"))
+}
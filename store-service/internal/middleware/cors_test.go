@@ -0,0 +1,128 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCORS(t *testing.T) {
+	cfg := CORSConfig{
+		AllowedOrigins:   []string{"https://shop.example.com"},
+		AllowedMethods:   []string{"GET", "POST"},
+		AllowedHeaders:   []string{"Authorization", "Content-Type"},
+		AllowCredentials: true,
+		MaxAge:           10 * time.Minute,
+	}
+
+	tests := []struct {
+		name            string
+		cfg             CORSConfig
+		method          string
+		origin          string
+		preflight       bool
+		wantStatus      int
+		wantNextCalled  bool
+		wantAllowOrigin string
+		wantCredentials string
+		wantMethods     string
+		wantMaxAge      string
+	}{
+		{
+			name:            "preflight from allowed origin",
+			cfg:             cfg,
+			method:          http.MethodOptions,
+			origin:          "https://shop.example.com",
+			preflight:       true,
+			wantStatus:      http.StatusNoContent,
+			wantAllowOrigin: "https://shop.example.com",
+			wantCredentials: "true",
+			wantMethods:     "GET, POST",
+			wantMaxAge:      "600",
+		},
+		{
+			name:       "preflight from disallowed origin",
+			cfg:        cfg,
+			method:     http.MethodOptions,
+			origin:     "https://evil.example.com",
+			preflight:  true,
+			wantStatus: http.StatusNoContent,
+		},
+		{
+			name:            "simple request from allowed origin",
+			cfg:             cfg,
+			method:          http.MethodGet,
+			origin:          "https://shop.example.com",
+			wantStatus:      http.StatusOK,
+			wantNextCalled:  true,
+			wantAllowOrigin: "https://shop.example.com",
+			wantCredentials: "true",
+		},
+		{
+			name:           "simple request from disallowed origin",
+			cfg:            cfg,
+			method:         http.MethodGet,
+			origin:         "https://evil.example.com",
+			wantStatus:     http.StatusOK,
+			wantNextCalled: true,
+		},
+		{
+			name:           "request without origin passes through",
+			cfg:            cfg,
+			method:         http.MethodGet,
+			wantStatus:     http.StatusOK,
+			wantNextCalled: true,
+		},
+		{
+			name:           "options without preflight header reaches handler",
+			cfg:            cfg,
+			method:         http.MethodOptions,
+			origin:         "https://shop.example.com",
+			wantStatus:     http.StatusOK,
+			wantNextCalled: true,
+			// Still a cross-origin response, so the origin is echoed.
+			wantAllowOrigin: "https://shop.example.com",
+			wantCredentials: "true",
+		},
+		{
+			name:            "wildcard allows any origin",
+			cfg:             CORSConfig{AllowedOrigins: []string{"*"}},
+			method:          http.MethodGet,
+			origin:          "https://anywhere.example.com",
+			wantStatus:      http.StatusOK,
+			wantNextCalled:  true,
+			wantAllowOrigin: "https://anywhere.example.com",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			nextCalled := false
+			next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				nextCalled = true
+				w.WriteHeader(http.StatusOK)
+			})
+
+			req := httptest.NewRequest(tt.method, "/api/v1/products", nil)
+			if tt.origin != "" {
+				req.Header.Set("Origin", tt.origin)
+			}
+			if tt.preflight {
+				req.Header.Set("Access-Control-Request-Method", http.MethodPost)
+			}
+			rec := httptest.NewRecorder()
+
+			CORS(tt.cfg)(next).ServeHTTP(rec, req)
+
+			assert.Equal(t, tt.wantStatus, rec.Code)
+			assert.Equal(t, tt.wantNextCalled, nextCalled)
+			assert.Equal(t, tt.wantAllowOrigin, rec.Header().Get("Access-Control-Allow-Origin"))
+			assert.Equal(t, tt.wantCredentials, rec.Header().Get("Access-Control-Allow-Credentials"))
+			assert.Equal(t, tt.wantMethods, rec.Header().Get("Access-Control-Allow-Methods"))
+			assert.Equal(t, tt.wantMaxAge, rec.Header().Get("Access-Control-Max-Age"))
+		})
+	}
+}
@@ -33,7 +33,7 @@ func Logging(next http.Handler) http.Handler {
 			"path":    r.URL.Path,
 			"status":  rw.statusCode,
 			"latency": time.Since(start).String(),
-			"ip":      r.RemoteAddr,
+			"ip":      GetClientIP(r),
 		})
 	})
 }
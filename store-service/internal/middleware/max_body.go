@@ -0,0 +1,96 @@
+package middleware
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+
+	"github.com/1tsndre/mini-go-project/pkg/response"
+	"github.com/1tsndre/mini-go-project/store-service/internal/constant"
+)
+
+// MaxBodyBytes caps request bodies at limit bytes and answers 413 when a
+// client sends more. A declared Content-Length over the limit is rejected
+// before the handler runs; otherwise the body is read through
+// http.MaxBytesReader and the handler's "invalid request body" error is
+// replaced with the 413. Multipart bodies are capped at multipartLimit
+// instead, which must fit an upload plus its form envelope. The Content-Type
+// is the client's claim, so it only ever buys the larger cap, never none.
+func MaxBodyBytes(limit, multipartLimit int64) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Body == nil || r.Body == http.NoBody {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			limit := limit
+			if isMultipart(r.Header.Get("Content-Type")) {
+				limit = multipartLimit
+			}
+
+			if r.ContentLength > limit {
+				writePayloadTooLarge(w, r, limit)
+				return
+			}
+
+			body := &limitedBody{ReadCloser: http.MaxBytesReader(w, r.Body, limit)}
+			r.Body = body
+			next.ServeHTTP(&maxBodyWriter{ResponseWriter: w, r: r, body: body, limit: limit}, r)
+		})
+	}
+}
+
+func isMultipart(contentType string) bool {
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	return mediaType == "multipart/form-data"
+}
+
+func writePayloadTooLarge(w http.ResponseWriter, r *http.Request, limit int64) {
+	response.ErrorResponse(w, http.StatusRequestEntityTooLarge, BuildMeta(r),
+		response.NewError(constant.ErrCodePayloadTooLarge, fmt.Sprintf("request body exceeds %d bytes", limit)),
+	)
+}
+
+// limitedBody records whether the wrapped MaxBytesReader hit its limit.
+type limitedBody struct {
+	io.ReadCloser
+	exceeded bool
+}
+
+func (b *limitedBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	var maxErr *http.MaxBytesError
+	if errors.As(err, &maxErr) {
+		b.exceeded = true
+	}
+	return n, err
+}
+
+// maxBodyWriter turns the client error a handler reports after its body read
+// failed on the limit into a 413.
+type maxBodyWriter struct {
+	http.ResponseWriter
+	r           *http.Request
+	body        *limitedBody
+	limit       int64
+	intercepted bool
+}
+
+func (m *maxBodyWriter) WriteHeader(code int) {
+	if m.body.exceeded && code >= http.StatusBadRequest && code < http.StatusInternalServerError {
+		m.intercepted = true
+		writePayloadTooLarge(m.ResponseWriter, m.r, m.limit)
+		return
+	}
+	m.ResponseWriter.WriteHeader(code)
+}
+
+func (m *maxBodyWriter) Write(b []byte) (int, error) {
+	if m.intercepted {
+		return len(b), nil
+	}
+	return m.ResponseWriter.Write(b)
+}
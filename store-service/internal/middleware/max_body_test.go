@@ -0,0 +1,73 @@
+package middleware
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/1tsndre/mini-go-project/pkg/response"
+	"github.com/1tsndre/mini-go-project/store-service/internal/constant"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMaxBodyBytes(t *testing.T) {
+	// decodeHandler mirrors the handlers: a decode failure is a 400.
+	decodeHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req map[string]string
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			response.ErrorResponse(w, http.StatusBadRequest, BuildMeta(r),
+				response.NewError(constant.ErrCodeValidation, "invalid request body"),
+			)
+			return
+		}
+		response.Success(w, http.StatusCreated, req, BuildMeta(r))
+	})
+
+	oversized := `{"email":"` + strings.Repeat("a", 200) + `@example.com"}`
+
+	tests := []struct {
+		name          string
+		body          string
+		contentType   string
+		unknownLength bool
+		wantStatus    int
+		wantErrCode   string
+	}{
+		{name: "within limit", body: `{"email":"a@example.com"}`, contentType: "application/json", wantStatus: http.StatusCreated},
+		{name: "declared length over limit", body: oversized, contentType: "application/json", wantStatus: http.StatusRequestEntityTooLarge, wantErrCode: constant.ErrCodePayloadTooLarge},
+		{name: "chunked body over limit", body: oversized, contentType: "application/json", unknownLength: true, wantStatus: http.StatusRequestEntityTooLarge, wantErrCode: constant.ErrCodePayloadTooLarge},
+		{name: "malformed body within limit stays 400", body: `{"email":`, contentType: "application/json", wantStatus: http.StatusBadRequest, wantErrCode: constant.ErrCodeValidation},
+		{name: "multipart gets the upload limit", body: oversized, contentType: "multipart/form-data; boundary=x", wantStatus: http.StatusCreated},
+		{name: "multipart over the upload limit", body: `{"email":"` + strings.Repeat("a", 1024) + `@example.com"}`, contentType: "multipart/form-data; boundary=x", wantStatus: http.StatusRequestEntityTooLarge, wantErrCode: constant.ErrCodePayloadTooLarge},
+		{name: "chunked multipart over the upload limit", body: `{"email":"` + strings.Repeat("a", 1024) + `@example.com"}`, contentType: "multipart/form-data; boundary=x", unknownLength: true, wantStatus: http.StatusRequestEntityTooLarge, wantErrCode: constant.ErrCodePayloadTooLarge},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/api/v1/auth/register", strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", tt.contentType)
+			if tt.unknownLength {
+				req.ContentLength = -1
+			}
+			rec := httptest.NewRecorder()
+
+			MaxBodyBytes(64, 512)(decodeHandler).ServeHTTP(rec, req)
+
+			assert.Equal(t, tt.wantStatus, rec.Code)
+
+			var body struct {
+				Errors []response.Error `json:"errors"`
+			}
+			assert.NoError(t, json.NewDecoder(rec.Body).Decode(&body))
+			if tt.wantErrCode == "" {
+				assert.Empty(t, body.Errors)
+				return
+			}
+			if assert.Len(t, body.Errors, 1) {
+				assert.Equal(t, tt.wantErrCode, body.Errors[0].Code)
+			}
+		})
+	}
+}
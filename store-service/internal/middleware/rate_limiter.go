@@ -1,54 +1,98 @@
 package middleware
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
 	"fmt"
-	"net"
+	"io"
 	"net/http"
 	"strconv"
 	"time"
 
+	"github.com/1tsndre/mini-go-project/pkg/logger"
 	"github.com/1tsndre/mini-go-project/pkg/response"
 	"github.com/1tsndre/mini-go-project/store-service/internal/constant"
+	"github.com/1tsndre/mini-go-project/store-service/internal/model"
+	"github.com/google/uuid"
 	"github.com/redis/go-redis/v9"
 )
 
+// slidingWindowScript counts requests in a sorted set of their timestamps
+// and records the new one only if it fits under the limit, all in one atomic
+// step so concurrent requests cannot both see room for the last slot.
+// KEYS[1] is the counter; ARGV are now and window in milliseconds, the limit
+// and a unique member for this request. It returns allowed (0 or 1), the
+// remaining requests and when the oldest request leaves the window, in
+// milliseconds.
+var slidingWindowScript = redis.NewScript(`
+local now = tonumber(ARGV[1])
+local window = tonumber(ARGV[2])
+local limit = tonumber(ARGV[3])
+
+redis.call('ZREMRANGEBYSCORE', KEYS[1], '-inf', now - window)
+local count = redis.call('ZCARD', KEYS[1])
+local allowed = 0
+if count < limit then
+	redis.call('ZADD', KEYS[1], now, ARGV[4])
+	count = count + 1
+	allowed = 1
+end
+redis.call('PEXPIRE', KEYS[1], window)
+
+local reset = now + window
+local oldest = redis.call('ZRANGE', KEYS[1], 0, 0, 'WITHSCORES')
+if oldest[2] then
+	reset = tonumber(oldest[2]) + window
+end
+return {allowed, limit - count, reset}
+`)
+
 type RateLimiter struct {
-	client *redis.Client
+	client redis.Scripter
 }
 
-func NewRateLimiter(client *redis.Client) *RateLimiter {
+func NewRateLimiter(client redis.Scripter) *RateLimiter {
 	return &RateLimiter{client: client}
 }
 
-func (rl *RateLimiter) Limit(limit int, window time.Duration, keyType string) func(http.Handler) http.Handler {
+// Limit allows limit requests per window for each client. failOpen decides
+// what happens when Redis cannot be reached: true lets the request through,
+// false rejects it with 503 so an outage cannot be used to skip the limit.
+func (rl *RateLimiter) Limit(limit int, window time.Duration, keyType string, failOpen bool) func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			identifier, _, _ := net.SplitHostPort(r.RemoteAddr)
-			if identifier == "" {
-				identifier = r.RemoteAddr
-			}
+			identifier := GetClientIP(r)
 			if userID := GetUserID(r.Context()); userID != "" {
 				identifier = userID
 			}
 
-			key := fmt.Sprintf(constant.KeyRateLimit, keyType, identifier)
-			allowed, remaining, resetAt, err := rl.allow(r.Context(), key, limit, window)
-			if err != nil {
-				// If Redis fails, allow the request
+			if rl.enforce(w, r, limit, window, keyType, identifier, failOpen) {
 				next.ServeHTTP(w, r)
-				return
 			}
+		})
+	}
+}
 
-			w.Header().Set("X-RateLimit-Limit", strconv.Itoa(limit))
-			w.Header().Set("X-RateLimit-Remaining", strconv.Itoa(remaining))
-			w.Header().Set("X-RateLimit-Reset", strconv.FormatInt(resetAt.Unix(), 10))
+// LimitComposite enforces a per-IP and a per-account limit on the same
+// route and rejects the request if either is exceeded, so neither rotating
+// accounts from one IP nor spreading attempts on one account across IPs gets
+// around it. The account is the authenticated user, or the email in the
+// body for routes such as login; bodies without one share a single
+// per-account limit.
+// The rate limit headers describe the last limit checked.
+func (rl *RateLimiter) LimitComposite(userLimit, ipLimit int, window time.Duration, userKeyType, ipKeyType string, failOpen bool) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if !rl.enforce(w, r, ipLimit, window, ipKeyType, GetClientIP(r), failOpen) {
+				return
+			}
 
-			if !allowed {
-				meta := BuildMeta(r)
-				response.ErrorResponse(w, http.StatusTooManyRequests, meta,
-					response.NewError(constant.ErrCodeRateLimited, "rate limit exceeded"),
-				)
+			account := GetUserID(r.Context())
+			if account == "" {
+				account = bodyEmail(r)
+			}
+			if !rl.enforce(w, r, userLimit, window, userKeyType, account, failOpen) {
 				return
 			}
 
@@ -57,27 +101,91 @@ func (rl *RateLimiter) Limit(limit int, window time.Duration, keyType string) fu
 	}
 }
 
-func (rl *RateLimiter) allow(ctx context.Context, key string, limit int, window time.Duration) (bool, int, time.Time, error) {
-	now := time.Now().UnixMilli()
-	windowStart := now - window.Milliseconds()
-	resetAt := time.Now().Add(window)
+// enforce counts the request against one limit and reports whether it may
+// proceed. When it may not, the error response has already been written.
+func (rl *RateLimiter) enforce(w http.ResponseWriter, r *http.Request, limit int, window time.Duration, keyType, identifier string, failOpen bool) bool {
+	key := fmt.Sprintf(constant.KeyRateLimit, keyType, identifier)
+	allowed, remaining, resetAt, err := rl.allow(r.Context(), key, limit, window)
+	if err != nil {
+		logger.Error(r.Context(), "rate limit check failed", err, map[string]interface{}{
+			"key_type":  keyType,
+			"fail_open": failOpen,
+		})
+		if failOpen {
+			return true
+		}
+		meta := BuildMeta(r)
+		response.ErrorResponse(w, http.StatusServiceUnavailable, meta,
+			response.NewError(constant.ErrCodeServiceUnavailable, "service temporarily unavailable"),
+		)
+		return false
+	}
 
-	pipe := rl.client.Pipeline()
-	pipe.ZRemRangeByScore(ctx, key, "0", fmt.Sprintf("%d", windowStart))
-	pipe.ZAdd(ctx, key, redis.Z{Score: float64(now), Member: now})
-	countCmd := pipe.ZCard(ctx, key)
-	pipe.Expire(ctx, key, window)
+	w.Header().Set("X-RateLimit-Limit", strconv.Itoa(limit))
+	w.Header().Set("X-RateLimit-Remaining", strconv.Itoa(remaining))
+	w.Header().Set("X-RateLimit-Reset", strconv.FormatInt(resetAt.Unix(), 10))
 
-	_, err := pipe.Exec(ctx)
+	if !allowed {
+		meta := BuildMeta(r)
+		response.ErrorResponse(w, http.StatusTooManyRequests, meta,
+			response.NewError(constant.ErrCodeRateLimited, "rate limit exceeded"),
+		)
+		return false
+	}
+	return true
+}
+
+// unknownAccount is the per-account key for requests whose body names no
+// account, so they still share a per-account limit.
+const unknownAccount = "unknown"
+
+// bodyEmail returns the normalized email of a request body, leaving the body
+// intact for the handler. It decodes the body the way the handlers do,
+// whatever its Content-Type and however it is padded; the body's size is
+// already bounded by MaxBodyBytes. It returns unknownAccount if there is no
+// email to find.
+func bodyEmail(r *http.Request) string {
+	if r.Body == nil || r.Body == http.NoBody {
+		return unknownAccount
+	}
+
+	raw, _ := io.ReadAll(r.Body)
+	r.Body = struct {
+		io.Reader
+		io.Closer
+	}{io.MultiReader(bytes.NewReader(raw), r.Body), r.Body}
+
+	var body struct {
+		Email string `json:"email"`
+	}
+	if err := json.NewDecoder(bytes.NewReader(raw)).Decode(&body); err != nil {
+		return unknownAccount
+	}
+	if email := model.NormalizeEmail(body.Email); email != "" {
+		return email
+	}
+	return unknownAccount
+}
+
+func (rl *RateLimiter) allow(ctx context.Context, key string, limit int, window time.Duration) (bool, int, time.Time, error) {
+	now := time.Now()
+	resetAt := now.Add(window)
+
+	// The member must be unique: requests arriving in the same millisecond
+	// would otherwise collapse into one entry and go uncounted.
+	member := strconv.FormatInt(now.UnixMilli(), 10) + "-" + uuid.NewString()
+	res, err := slidingWindowScript.Run(ctx, rl.client, []string{key},
+		now.UnixMilli(), window.Milliseconds(), limit, member).Int64Slice()
 	if err != nil {
 		return true, limit, resetAt, err
 	}
+	if len(res) != 3 {
+		return true, limit, resetAt, fmt.Errorf("unexpected rate limit script result %v", res)
+	}
 
-	count := int(countCmd.Val())
-	remaining := limit - count
+	remaining := int(res[1])
 	if remaining < 0 {
 		remaining = 0
 	}
-
-	return count <= limit, remaining, resetAt, nil
+	return res[0] == 1, remaining, time.UnixMilli(res[2]), nil
 }
@@ -0,0 +1,272 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeScripter mirrors the sliding-window script in Go, one call at a time
+// the way Redis runs scripts. It covers the limiter around the script, not
+// the Lua itself; that only runs when REDIS_TEST_ADDR points at a real Redis.
+type fakeScripter struct {
+	mu   sync.Mutex
+	sets map[string]map[string]int64
+	err  error
+}
+
+func newTestScripter(t *testing.T) redis.Scripter {
+	if addr := os.Getenv("REDIS_TEST_ADDR"); addr != "" {
+		client := redis.NewClient(&redis.Options{Addr: addr})
+		t.Cleanup(func() { client.Close() })
+		return client
+	}
+	return &fakeScripter{sets: make(map[string]map[string]int64)}
+}
+
+func (f *fakeScripter) EvalSha(_ context.Context, _ string, keys []string, args ...interface{}) *redis.Cmd {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.err != nil {
+		return redis.NewCmdResult(nil, f.err)
+	}
+
+	now, window, limit := args[0].(int64), args[1].(int64), int64(args[2].(int))
+	set := f.sets[keys[0]]
+	if set == nil {
+		set = make(map[string]int64)
+		f.sets[keys[0]] = set
+	}
+	for member, score := range set {
+		if score <= now-window {
+			delete(set, member)
+		}
+	}
+
+	allowed := int64(0)
+	if int64(len(set)) < limit {
+		set[args[3].(string)] = now
+		allowed = 1
+	}
+
+	scores := make([]int64, 0, len(set))
+	for _, score := range set {
+		scores = append(scores, score)
+	}
+	sort.Slice(scores, func(i, j int) bool { return scores[i] < scores[j] })
+	reset := now + window
+	if len(scores) > 0 {
+		reset = scores[0] + window
+	}
+	return redis.NewCmdResult([]interface{}{allowed, limit - int64(len(set)), reset}, nil)
+}
+
+func (f *fakeScripter) Eval(ctx context.Context, _ string, keys []string, args ...interface{}) *redis.Cmd {
+	return f.EvalSha(ctx, "", keys, args...)
+}
+
+func (f *fakeScripter) EvalRO(context.Context, string, []string, ...interface{}) *redis.Cmd {
+	panic("not used")
+}
+
+func (f *fakeScripter) EvalShaRO(context.Context, string, []string, ...interface{}) *redis.Cmd {
+	panic("not used")
+}
+
+func (f *fakeScripter) ScriptExists(context.Context, ...string) *redis.BoolSliceCmd {
+	panic("not used")
+}
+
+func (f *fakeScripter) ScriptLoad(context.Context, string) *redis.StringCmd {
+	panic("not used")
+}
+
+func TestRateLimiter_Concurrent(t *testing.T) {
+	const limit = 10
+	rl := NewRateLimiter(newTestScripter(t))
+	// A fresh key type per run keeps a shared Redis from leaking counts
+	// between runs.
+	handler := rl.Limit(limit, time.Minute, "test-"+uuid.NewString(), true)(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusOK)
+		}))
+
+	var wg sync.WaitGroup
+	statuses := make(chan int, limit+5)
+	start := make(chan struct{})
+	for i := 0; i < limit+5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			<-start
+			rec := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.RemoteAddr = "203.0.113.7:4000"
+			handler.ServeHTTP(rec, req)
+			statuses <- rec.Code
+		}()
+	}
+	close(start)
+	wg.Wait()
+	close(statuses)
+
+	counts := map[int]int{}
+	for code := range statuses {
+		counts[code]++
+	}
+	assert.Equal(t, limit, counts[http.StatusOK])
+	assert.Equal(t, 5, counts[http.StatusTooManyRequests])
+}
+
+func TestRateLimiter_Headers(t *testing.T) {
+	rl := NewRateLimiter(newTestScripter(t))
+	handler := rl.Limit(2, time.Minute, "test-"+uuid.NewString(), true)(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusOK)
+		}))
+
+	before := time.Now()
+	var recs []*httptest.ResponseRecorder
+	for i := 0; i < 3; i++ {
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.RemoteAddr = "203.0.113.8:4000"
+		handler.ServeHTTP(rec, req)
+		recs = append(recs, rec)
+	}
+
+	assert.Equal(t, []int{http.StatusOK, http.StatusOK, http.StatusTooManyRequests},
+		[]int{recs[0].Code, recs[1].Code, recs[2].Code})
+	assert.Equal(t, []string{"1", "0", "0"}, []string{
+		recs[0].Header().Get("X-RateLimit-Remaining"),
+		recs[1].Header().Get("X-RateLimit-Remaining"),
+		recs[2].Header().Get("X-RateLimit-Remaining"),
+	})
+
+	// The window resets when the first request ages out of it.
+	reset, err := strconv.ParseInt(recs[2].Header().Get("X-RateLimit-Reset"), 10, 64)
+	assert.NoError(t, err)
+	assert.InDelta(t, before.Add(time.Minute).Unix(), reset, 1)
+}
+
+func TestRateLimiter_RedisError(t *testing.T) {
+	tests := []struct {
+		name     string
+		failOpen bool
+		wantCode int
+	}{
+		{name: "fail open allows", failOpen: true, wantCode: http.StatusOK},
+		{name: "fail closed rejects", failOpen: false, wantCode: http.StatusServiceUnavailable},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rl := NewRateLimiter(&fakeScripter{err: errors.New("connection refused")})
+			handler := rl.Limit(1, time.Minute, "test", tt.failOpen)(
+				http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					w.WriteHeader(http.StatusOK)
+				}))
+
+			for i := 0; i < 3; i++ {
+				rec := httptest.NewRecorder()
+				handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+				assert.Equal(t, tt.wantCode, rec.Code)
+				assert.Empty(t, rec.Header().Get("X-RateLimit-Remaining"))
+			}
+		})
+	}
+}
+
+func TestRateLimiter_LimitComposite(t *testing.T) {
+	loginRequest := func(ip, email string) *http.Request {
+		req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{"email":"`+email+`","password":"secret"}`))
+		req.Header.Set("Content-Type", "application/json")
+		req.RemoteAddr = ip + ":4000"
+		return req
+	}
+
+	tests := []struct {
+		name    string
+		request func(i int) *http.Request
+	}{
+		{
+			// Rotating accounts from one IP is stopped by the IP limit.
+			name:    "per-IP limit",
+			request: func(i int) *http.Request { return loginRequest("203.0.113.9", fmt.Sprintf("user%d@example.com", i)) },
+		},
+		{
+			// Spreading attempts on one account across IPs is stopped by
+			// the account limit, however the email is cased.
+			name: "per-account limit",
+			request: func(i int) *http.Request {
+				email := "Victim@Example.com"
+				if i%2 == 0 {
+					email = " victim@example.com"
+				}
+				return loginRequest(fmt.Sprintf("198.51.100.%d", i+1), email)
+			},
+		},
+		{
+			// The handlers decode the body whatever its Content-Type.
+			name: "per-account limit without a JSON content type",
+			request: func(i int) *http.Request {
+				req := loginRequest(fmt.Sprintf("198.51.100.%d", i+1), "victim@example.com")
+				req.Header.Del("Content-Type")
+				return req
+			},
+		},
+		{
+			name: "per-account limit with a padded body",
+			request: func(i int) *http.Request {
+				body := strings.Repeat(" ", 8<<10) + `{"email":"victim@example.com","password":"secret"}`
+				req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+				req.Header.Set("Content-Type", "application/json")
+				req.RemoteAddr = fmt.Sprintf("198.51.100.%d:4000", i+1)
+				return req
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			const limit = 3
+			rl := NewRateLimiter(newTestScripter(t))
+			suffix := uuid.NewString()
+			var bodies []string
+			handler := rl.LimitComposite(limit, limit, time.Minute, "user-"+suffix, "ip-"+suffix, true)(
+				http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					body, _ := io.ReadAll(r.Body)
+					bodies = append(bodies, string(body))
+					w.WriteHeader(http.StatusOK)
+				}))
+
+			var codes []int
+			for i := 0; i < limit+1; i++ {
+				rec := httptest.NewRecorder()
+				handler.ServeHTTP(rec, tt.request(i))
+				codes = append(codes, rec.Code)
+			}
+
+			assert.Equal(t, []int{http.StatusOK, http.StatusOK, http.StatusOK, http.StatusTooManyRequests}, codes)
+			// The handler still reads the full body after the email was peeked.
+			if assert.Len(t, bodies, limit) {
+				assert.Contains(t, bodies[0], `"password":"secret"`)
+			}
+		})
+	}
+}
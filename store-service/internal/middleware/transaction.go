@@ -0,0 +1,87 @@
+package middleware
+
+import (
+	"bytes"
+	"net/http"
+
+	"github.com/1tsndre/mini-go-project/pkg/logger"
+	"github.com/1tsndre/mini-go-project/pkg/response"
+	"github.com/1tsndre/mini-go-project/store-service/internal/constant"
+	"github.com/1tsndre/mini-go-project/store-service/internal/repository/databases"
+)
+
+// bufferedWriter holds the handler's response until the transaction outcome
+// is known, so a failed commit can still be reported to the client.
+type bufferedWriter struct {
+	http.ResponseWriter
+	statusCode int
+	body       bytes.Buffer
+}
+
+func (bw *bufferedWriter) WriteHeader(code int) {
+	if bw.statusCode == 0 {
+		bw.statusCode = code
+	}
+}
+
+func (bw *bufferedWriter) Write(b []byte) (int, error) {
+	if bw.statusCode == 0 {
+		bw.statusCode = http.StatusOK
+	}
+	return bw.body.Write(b)
+}
+
+func (bw *bufferedWriter) flush() {
+	if bw.statusCode == 0 {
+		bw.statusCode = http.StatusOK
+	}
+	bw.ResponseWriter.WriteHeader(bw.statusCode)
+	bw.ResponseWriter.Write(bw.body.Bytes())
+}
+
+// Transaction runs the handler inside a single database transaction. Every
+// repository call made with the request context joins it via
+// databases.FromContext. The transaction commits when the handler responds
+// with a status below 400 and rolls back on an error status or a panic; the
+// panic is re-raised for Recovery to handle.
+func Transaction(db databases.Database) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			tx := db.DB().WithContext(r.Context()).Begin()
+			if tx.Error != nil {
+				logger.Error(r.Context(), "failed to begin transaction", tx.Error)
+				response.ErrorResponse(w, http.StatusInternalServerError, BuildMeta(r),
+					response.NewError(constant.ErrCodeInternal, "internal server error"),
+				)
+				return
+			}
+
+			bw := &bufferedWriter{ResponseWriter: w}
+			defer func() {
+				if p := recover(); p != nil {
+					tx.Rollback()
+					panic(p)
+				}
+			}()
+
+			next.ServeHTTP(bw, r.WithContext(databases.WithTx(r.Context(), tx)))
+
+			if bw.statusCode >= http.StatusBadRequest {
+				if err := tx.Rollback().Error; err != nil {
+					logger.Error(r.Context(), "failed to rollback transaction", err)
+				}
+				bw.flush()
+				return
+			}
+
+			if err := tx.Commit().Error; err != nil {
+				logger.Error(r.Context(), "failed to commit transaction", err)
+				response.ErrorResponse(w, http.StatusInternalServerError, BuildMeta(r),
+					response.NewError(constant.ErrCodeInternal, "internal server error"),
+				)
+				return
+			}
+			bw.flush()
+		})
+	}
+}
@@ -0,0 +1,195 @@
+package middleware
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/1tsndre/mini-go-project/store-service/internal/repository/databases"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// recordingDriver is a database/sql driver that keeps statements executed
+// inside a transaction pending until commit, and drops them on rollback.
+type recordingDriver struct {
+	mu        sync.Mutex
+	pending   []string
+	committed []string
+	rollbacks int
+}
+
+func (d *recordingDriver) Open(string) (driver.Conn, error) { return &recordingConn{d: d}, nil }
+
+func (d *recordingDriver) Committed() []string {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return append([]string(nil), d.committed...)
+}
+
+type recordingConn struct {
+	d    *recordingDriver
+	inTx bool
+}
+
+func (c *recordingConn) Prepare(query string) (driver.Stmt, error) {
+	return &recordingStmt{c: c, query: query}, nil
+}
+func (c *recordingConn) Close() error              { return nil }
+func (c *recordingConn) Begin() (driver.Tx, error) { c.inTx = true; return c, nil }
+
+func (c *recordingConn) Commit() error {
+	c.d.mu.Lock()
+	defer c.d.mu.Unlock()
+	c.d.committed = append(c.d.committed, c.d.pending...)
+	c.d.pending = nil
+	c.inTx = false
+	return nil
+}
+
+func (c *recordingConn) Rollback() error {
+	c.d.mu.Lock()
+	defer c.d.mu.Unlock()
+	c.d.pending = nil
+	c.d.rollbacks++
+	c.inTx = false
+	return nil
+}
+
+type recordingStmt struct {
+	c     *recordingConn
+	query string
+}
+
+func (s *recordingStmt) Close() error  { return nil }
+func (s *recordingStmt) NumInput() int { return -1 }
+
+func (s *recordingStmt) Exec([]driver.Value) (driver.Result, error) {
+	s.c.d.mu.Lock()
+	defer s.c.d.mu.Unlock()
+	if s.c.inTx {
+		s.c.d.pending = append(s.c.d.pending, s.query)
+	} else {
+		s.c.d.committed = append(s.c.d.committed, s.query)
+	}
+	return driver.RowsAffected(1), nil
+}
+
+func (s *recordingStmt) Query([]driver.Value) (driver.Rows, error) { return emptyRows{}, nil }
+
+type emptyRows struct{}
+
+func (emptyRows) Columns() []string         { return nil }
+func (emptyRows) Close() error              { return nil }
+func (emptyRows) Next([]driver.Value) error { return io.EOF }
+
+type recordingDB struct{ db *gorm.DB }
+
+func (r *recordingDB) DB() *gorm.DB { return r.db }
+
+func newRecordingDB(t *testing.T) (*recordingDB, *recordingDriver) {
+	t.Helper()
+
+	drv := &recordingDriver{}
+	sqlDB := sql.OpenDB(connector{drv})
+	sqlDB.SetMaxOpenConns(1)
+	t.Cleanup(func() { sqlDB.Close() })
+
+	db, err := gorm.Open(postgres.New(postgres.Config{Conn: sqlDB}), &gorm.Config{
+		SkipDefaultTransaction: true,
+		Logger:                 logger.Discard,
+	})
+	if err != nil {
+		t.Fatalf("failed to open gorm: %v", err)
+	}
+	return &recordingDB{db: db}, drv
+}
+
+type connector struct{ d *recordingDriver }
+
+func (c connector) Connect(context.Context) (driver.Conn, error) { return c.d.Open("") }
+func (c connector) Driver() driver.Driver                        { return c.d }
+
+func TestTransaction(t *testing.T) {
+	tests := []struct {
+		name          string
+		handler       func(db databases.Database) http.HandlerFunc
+		wantStatus    int
+		wantCommitted int
+		wantPanic     bool
+	}{
+		{
+			name: "success commits every write",
+			handler: func(db databases.Database) http.HandlerFunc {
+				return func(w http.ResponseWriter, r *http.Request) {
+					databases.FromContext(r.Context(), db).Exec("UPDATE products SET stock = 1")
+					databases.FromContext(r.Context(), db).Exec("UPDATE products SET stock = 2")
+					w.WriteHeader(http.StatusCreated)
+				}
+			},
+			wantStatus:    http.StatusCreated,
+			wantCommitted: 2,
+		},
+		{
+			name: "handler error rolls back all writes",
+			handler: func(db databases.Database) http.HandlerFunc {
+				return func(w http.ResponseWriter, r *http.Request) {
+					databases.FromContext(r.Context(), db).Exec("UPDATE products SET stock = 1")
+					databases.FromContext(r.Context(), db).Exec("UPDATE products SET stock = 2")
+					w.WriteHeader(http.StatusUnprocessableEntity)
+				}
+			},
+			wantStatus: http.StatusUnprocessableEntity,
+		},
+		{
+			name: "panic rolls back and propagates",
+			handler: func(db databases.Database) http.HandlerFunc {
+				return func(w http.ResponseWriter, r *http.Request) {
+					databases.FromContext(r.Context(), db).Exec("UPDATE products SET stock = 1")
+					panic("boom")
+				}
+			},
+			wantPanic: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, drv := newRecordingDB(t)
+			h := Transaction(db)(tt.handler(db))
+
+			req := httptest.NewRequest(http.MethodPost, "/", nil)
+			rec := httptest.NewRecorder()
+
+			if tt.wantPanic {
+				assert.Panics(t, func() { h.ServeHTTP(rec, req) })
+				assert.Empty(t, drv.Committed())
+				assert.Equal(t, 1, drv.rollbacks)
+				return
+			}
+
+			h.ServeHTTP(rec, req)
+
+			assert.Equal(t, tt.wantStatus, rec.Code)
+			assert.Len(t, drv.Committed(), tt.wantCommitted)
+			if tt.wantCommitted == 0 {
+				assert.Equal(t, 1, drv.rollbacks)
+			}
+		})
+	}
+}
+
+func TestFromContext_WithoutTransaction(t *testing.T) {
+	db, drv := newRecordingDB(t)
+
+	databases.FromContext(context.Background(), db).Exec("UPDATE products SET stock = 1")
+
+	assert.Equal(t, []string{"UPDATE products SET stock = 1"}, drv.Committed())
+}
@@ -12,6 +12,7 @@ package mocks
 import (
 	context "context"
 	reflect "reflect"
+	time "time"
 
 	model "github.com/1tsndre/mini-go-project/store-service/internal/model"
 	uuid "github.com/google/uuid"
@@ -56,6 +57,36 @@ func (mr *MockCartRepositoryMockRecorder) DeleteCart(ctx, userID any) *gomock.Ca
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteCart", reflect.TypeOf((*MockCartRepository)(nil).DeleteCart), ctx, userID)
 }
 
+// DeleteItemsByProduct mocks base method.
+func (m *MockCartRepository) DeleteItemsByProduct(ctx context.Context, productID uuid.UUID) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteItemsByProduct", ctx, productID)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeleteItemsByProduct indicates an expected call of DeleteItemsByProduct.
+func (mr *MockCartRepositoryMockRecorder) DeleteItemsByProduct(ctx, productID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteItemsByProduct", reflect.TypeOf((*MockCartRepository)(nil).DeleteItemsByProduct), ctx, productID)
+}
+
+// DeleteItemsOlderThan mocks base method.
+func (m *MockCartRepository) DeleteItemsOlderThan(ctx context.Context, cutoff time.Time) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteItemsOlderThan", ctx, cutoff)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeleteItemsOlderThan indicates an expected call of DeleteItemsOlderThan.
+func (mr *MockCartRepositoryMockRecorder) DeleteItemsOlderThan(ctx, cutoff any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteItemsOlderThan", reflect.TypeOf((*MockCartRepository)(nil).DeleteItemsOlderThan), ctx, cutoff)
+}
+
 // GetCart mocks base method.
 func (m *MockCartRepository) GetCart(ctx context.Context, userID uuid.UUID) (*model.Cart, error) {
 	m.ctrl.T.Helper()
@@ -71,6 +102,21 @@ func (mr *MockCartRepositoryMockRecorder) GetCart(ctx, userID any) *gomock.Call
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetCart", reflect.TypeOf((*MockCartRepository)(nil).GetCart), ctx, userID)
 }
 
+// ProductInCarts mocks base method.
+func (m *MockCartRepository) ProductInCarts(ctx context.Context, productID uuid.UUID) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ProductInCarts", ctx, productID)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ProductInCarts indicates an expected call of ProductInCarts.
+func (mr *MockCartRepositoryMockRecorder) ProductInCarts(ctx, productID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ProductInCarts", reflect.TypeOf((*MockCartRepository)(nil).ProductInCarts), ctx, productID)
+}
+
 // SaveCart mocks base method.
 func (m *MockCartRepository) SaveCart(ctx context.Context, cart *model.Cart) error {
 	m.ctrl.T.Helper()
@@ -84,3 +130,17 @@ func (mr *MockCartRepositoryMockRecorder) SaveCart(ctx, cart any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveCart", reflect.TypeOf((*MockCartRepository)(nil).SaveCart), ctx, cart)
 }
+
+// SaveCartIfVersion mocks base method.
+func (m *MockCartRepository) SaveCartIfVersion(ctx context.Context, cart *model.Cart) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SaveCartIfVersion", ctx, cart)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SaveCartIfVersion indicates an expected call of SaveCartIfVersion.
+func (mr *MockCartRepositoryMockRecorder) SaveCartIfVersion(ctx, cart any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveCartIfVersion", reflect.TypeOf((*MockCartRepository)(nil).SaveCartIfVersion), ctx, cart)
+}
@@ -56,6 +56,21 @@ func (mr *MockCategoryRepositoryMockRecorder) Create(ctx, category any) *gomock.
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockCategoryRepository)(nil).Create), ctx, category)
 }
 
+// CreateMany mocks base method.
+func (m *MockCategoryRepository) CreateMany(ctx context.Context, categories []model.Category) ([]string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateMany", ctx, categories)
+	ret0, _ := ret[0].([]string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateMany indicates an expected call of CreateMany.
+func (mr *MockCategoryRepositoryMockRecorder) CreateMany(ctx, categories any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateMany", reflect.TypeOf((*MockCategoryRepository)(nil).CreateMany), ctx, categories)
+}
+
 // Delete mocks base method.
 func (m *MockCategoryRepository) Delete(ctx context.Context, id uuid.UUID) error {
 	m.ctrl.T.Helper()
@@ -85,6 +100,22 @@ func (mr *MockCategoryRepositoryMockRecorder) FindAll(ctx any) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindAll", reflect.TypeOf((*MockCategoryRepository)(nil).FindAll), ctx)
 }
 
+// FindAllPaginated mocks base method.
+func (m *MockCategoryRepository) FindAllPaginated(ctx context.Context, page, perPage int) ([]model.Category, int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindAllPaginated", ctx, page, perPage)
+	ret0, _ := ret[0].([]model.Category)
+	ret1, _ := ret[1].(int64)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// FindAllPaginated indicates an expected call of FindAllPaginated.
+func (mr *MockCategoryRepositoryMockRecorder) FindAllPaginated(ctx, page, perPage any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindAllPaginated", reflect.TypeOf((*MockCategoryRepository)(nil).FindAllPaginated), ctx, page, perPage)
+}
+
 // FindByID mocks base method.
 func (m *MockCategoryRepository) FindByID(ctx context.Context, id uuid.UUID) (*model.Category, error) {
 	m.ctrl.T.Helper()
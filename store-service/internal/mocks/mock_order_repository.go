@@ -12,9 +12,11 @@ package mocks
 import (
 	context "context"
 	reflect "reflect"
+	time "time"
 
 	model "github.com/1tsndre/mini-go-project/store-service/internal/model"
 	uuid "github.com/google/uuid"
+	decimal "github.com/shopspring/decimal"
 	gomock "go.uber.org/mock/gomock"
 )
 
@@ -42,6 +44,35 @@ func (m *MockOrderRepository) EXPECT() *MockOrderRepositoryMockRecorder {
 	return m.recorder
 }
 
+// AddStatusHistory mocks base method.
+func (m *MockOrderRepository) AddStatusHistory(ctx context.Context, entry *model.OrderStatusHistory) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddStatusHistory", ctx, entry)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AddStatusHistory indicates an expected call of AddStatusHistory.
+func (mr *MockOrderRepositoryMockRecorder) AddStatusHistory(ctx, entry any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddStatusHistory", reflect.TypeOf((*MockOrderRepository)(nil).AddStatusHistory), ctx, entry)
+}
+
+// CountByStore mocks base method.
+func (m *MockOrderRepository) CountByStore(ctx context.Context, storeID uuid.UUID, statuses []string) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CountByStore", ctx, storeID, statuses)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CountByStore indicates an expected call of CountByStore.
+func (mr *MockOrderRepositoryMockRecorder) CountByStore(ctx, storeID, statuses any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CountByStore", reflect.TypeOf((*MockOrderRepository)(nil).CountByStore), ctx, storeID, statuses)
+}
+
 // Create mocks base method.
 func (m *MockOrderRepository) Create(ctx context.Context, order *model.Order) error {
 	m.ctrl.T.Helper()
@@ -70,6 +101,50 @@ func (mr *MockOrderRepositoryMockRecorder) CreatePayment(ctx, payment any) *gomo
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreatePayment", reflect.TypeOf((*MockOrderRepository)(nil).CreatePayment), ctx, payment)
 }
 
+// CreateReservation mocks base method.
+func (m *MockOrderRepository) CreateReservation(ctx context.Context, reservation *model.StockReservation) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateReservation", ctx, reservation)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateReservation indicates an expected call of CreateReservation.
+func (mr *MockOrderRepositoryMockRecorder) CreateReservation(ctx, reservation any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateReservation", reflect.TypeOf((*MockOrderRepository)(nil).CreateReservation), ctx, reservation)
+}
+
+// Delete mocks base method.
+func (m *MockOrderRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Delete", ctx, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Delete indicates an expected call of Delete.
+func (mr *MockOrderRepositoryMockRecorder) Delete(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*MockOrderRepository)(nil).Delete), ctx, id)
+}
+
+// FindAll mocks base method.
+func (m *MockOrderRepository) FindAll(ctx context.Context, filter model.OrderFilter) ([]model.Order, int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindAll", ctx, filter)
+	ret0, _ := ret[0].([]model.Order)
+	ret1, _ := ret[1].(int64)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// FindAll indicates an expected call of FindAll.
+func (mr *MockOrderRepositoryMockRecorder) FindAll(ctx, filter any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindAll", reflect.TypeOf((*MockOrderRepository)(nil).FindAll), ctx, filter)
+}
+
 // FindByID mocks base method.
 func (m *MockOrderRepository) FindByID(ctx context.Context, id uuid.UUID) (*model.Order, error) {
 	m.ctrl.T.Helper()
@@ -117,6 +192,21 @@ func (mr *MockOrderRepositoryMockRecorder) FindByUserID(ctx, userID, page, perPa
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindByUserID", reflect.TypeOf((*MockOrderRepository)(nil).FindByUserID), ctx, userID, page, perPage)
 }
 
+// FindExpiredReservations mocks base method.
+func (m *MockOrderRepository) FindExpiredReservations(ctx context.Context, now time.Time, limit int) ([]model.StockReservation, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindExpiredReservations", ctx, now, limit)
+	ret0, _ := ret[0].([]model.StockReservation)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindExpiredReservations indicates an expected call of FindExpiredReservations.
+func (mr *MockOrderRepositoryMockRecorder) FindExpiredReservations(ctx, now, limit any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindExpiredReservations", reflect.TypeOf((*MockOrderRepository)(nil).FindExpiredReservations), ctx, now, limit)
+}
+
 // FindPaymentByOrderID mocks base method.
 func (m *MockOrderRepository) FindPaymentByOrderID(ctx context.Context, orderID uuid.UUID) (*model.Payment, error) {
 	m.ctrl.T.Helper()
@@ -132,6 +222,96 @@ func (mr *MockOrderRepositoryMockRecorder) FindPaymentByOrderID(ctx, orderID any
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindPaymentByOrderID", reflect.TypeOf((*MockOrderRepository)(nil).FindPaymentByOrderID), ctx, orderID)
 }
 
+// FindReservation mocks base method.
+func (m *MockOrderRepository) FindReservation(ctx context.Context, orderID uuid.UUID) (*model.StockReservation, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindReservation", ctx, orderID)
+	ret0, _ := ret[0].(*model.StockReservation)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindReservation indicates an expected call of FindReservation.
+func (mr *MockOrderRepositoryMockRecorder) FindReservation(ctx, orderID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindReservation", reflect.TypeOf((*MockOrderRepository)(nil).FindReservation), ctx, orderID)
+}
+
+// FindStalePending mocks base method.
+func (m *MockOrderRepository) FindStalePending(ctx context.Context, before time.Time, after *model.Order, limit int) ([]model.Order, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindStalePending", ctx, before, after, limit)
+	ret0, _ := ret[0].([]model.Order)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindStalePending indicates an expected call of FindStalePending.
+func (mr *MockOrderRepositoryMockRecorder) FindStalePending(ctx, before, after, limit any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindStalePending", reflect.TypeOf((*MockOrderRepository)(nil).FindStalePending), ctx, before, after, limit)
+}
+
+// FindStatusHistory mocks base method.
+func (m *MockOrderRepository) FindStatusHistory(ctx context.Context, orderID uuid.UUID) ([]model.OrderStatusHistory, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindStatusHistory", ctx, orderID)
+	ret0, _ := ret[0].([]model.OrderStatusHistory)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindStatusHistory indicates an expected call of FindStatusHistory.
+func (mr *MockOrderRepositoryMockRecorder) FindStatusHistory(ctx, orderID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindStatusHistory", reflect.TypeOf((*MockOrderRepository)(nil).FindStatusHistory), ctx, orderID)
+}
+
+// FindStatusesByUser mocks base method.
+func (m *MockOrderRepository) FindStatusesByUser(ctx context.Context, userID uuid.UUID, ids []uuid.UUID) ([]model.Order, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindStatusesByUser", ctx, userID, ids)
+	ret0, _ := ret[0].([]model.Order)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindStatusesByUser indicates an expected call of FindStatusesByUser.
+func (mr *MockOrderRepositoryMockRecorder) FindStatusesByUser(ctx, userID, ids any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindStatusesByUser", reflect.TypeOf((*MockOrderRepository)(nil).FindStatusesByUser), ctx, userID, ids)
+}
+
+// StoreRevenue mocks base method.
+func (m *MockOrderRepository) StoreRevenue(ctx context.Context, storeID uuid.UUID, statuses []string) (decimal.Decimal, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "StoreRevenue", ctx, storeID, statuses)
+	ret0, _ := ret[0].(decimal.Decimal)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// StoreRevenue indicates an expected call of StoreRevenue.
+func (mr *MockOrderRepositoryMockRecorder) StoreRevenue(ctx, storeID, statuses any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StoreRevenue", reflect.TypeOf((*MockOrderRepository)(nil).StoreRevenue), ctx, storeID, statuses)
+}
+
+// StoreRevenueBetween mocks base method.
+func (m *MockOrderRepository) StoreRevenueBetween(ctx context.Context, storeID uuid.UUID, statuses []string, from, to time.Time) (decimal.Decimal, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "StoreRevenueBetween", ctx, storeID, statuses, from, to)
+	ret0, _ := ret[0].(decimal.Decimal)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// StoreRevenueBetween indicates an expected call of StoreRevenueBetween.
+func (mr *MockOrderRepositoryMockRecorder) StoreRevenueBetween(ctx, storeID, statuses, from, to any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StoreRevenueBetween", reflect.TypeOf((*MockOrderRepository)(nil).StoreRevenueBetween), ctx, storeID, statuses, from, to)
+}
+
 // UpdatePayment mocks base method.
 func (m *MockOrderRepository) UpdatePayment(ctx context.Context, payment *model.Payment) error {
 	m.ctrl.T.Helper()
@@ -146,6 +326,21 @@ func (mr *MockOrderRepositoryMockRecorder) UpdatePayment(ctx, payment any) *gomo
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdatePayment", reflect.TypeOf((*MockOrderRepository)(nil).UpdatePayment), ctx, payment)
 }
 
+// UpdateReservationStatus mocks base method.
+func (m *MockOrderRepository) UpdateReservationStatus(ctx context.Context, orderID uuid.UUID, from, to string) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateReservationStatus", ctx, orderID, from, to)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateReservationStatus indicates an expected call of UpdateReservationStatus.
+func (mr *MockOrderRepositoryMockRecorder) UpdateReservationStatus(ctx, orderID, from, to any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateReservationStatus", reflect.TypeOf((*MockOrderRepository)(nil).UpdateReservationStatus), ctx, orderID, from, to)
+}
+
 // UpdateStatus mocks base method.
 func (m *MockOrderRepository) UpdateStatus(ctx context.Context, id uuid.UUID, status string) error {
 	m.ctrl.T.Helper()
@@ -160,3 +355,17 @@ func (mr *MockOrderRepositoryMockRecorder) UpdateStatus(ctx, id, status any) *go
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateStatus", reflect.TypeOf((*MockOrderRepository)(nil).UpdateStatus), ctx, id, status)
 }
 
+// UpdateStatusFrom mocks base method.
+func (m *MockOrderRepository) UpdateStatusFrom(ctx context.Context, id uuid.UUID, from, to string) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateStatusFrom", ctx, id, from, to)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateStatusFrom indicates an expected call of UpdateStatusFrom.
+func (mr *MockOrderRepositoryMockRecorder) UpdateStatusFrom(ctx, id, from, to any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateStatusFrom", reflect.TypeOf((*MockOrderRepository)(nil).UpdateStatusFrom), ctx, id, from, to)
+}
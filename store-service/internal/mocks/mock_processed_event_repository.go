@@ -0,0 +1,70 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: store-service/internal/repository/processed_event_repository.go
+//
+// Generated by this command:
+//
+//	mockgen -source=store-service/internal/repository/processed_event_repository.go -destination=store-service/internal/mocks/mock_processed_event_repository.go -package=mocks
+//
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	context "context"
+	reflect "reflect"
+
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockProcessedEventRepository is a mock of ProcessedEventRepository interface.
+type MockProcessedEventRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockProcessedEventRepositoryMockRecorder
+	isgomock struct{}
+}
+
+// MockProcessedEventRepositoryMockRecorder is the mock recorder for MockProcessedEventRepository.
+type MockProcessedEventRepositoryMockRecorder struct {
+	mock *MockProcessedEventRepository
+}
+
+// NewMockProcessedEventRepository creates a new mock instance.
+func NewMockProcessedEventRepository(ctrl *gomock.Controller) *MockProcessedEventRepository {
+	mock := &MockProcessedEventRepository{ctrl: ctrl}
+	mock.recorder = &MockProcessedEventRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockProcessedEventRepository) EXPECT() *MockProcessedEventRepositoryMockRecorder {
+	return m.recorder
+}
+
+// Claim mocks base method.
+func (m *MockProcessedEventRepository) Claim(ctx context.Context, consumer, eventID string) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Claim", ctx, consumer, eventID)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Claim indicates an expected call of Claim.
+func (mr *MockProcessedEventRepositoryMockRecorder) Claim(ctx, consumer, eventID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Claim", reflect.TypeOf((*MockProcessedEventRepository)(nil).Claim), ctx, consumer, eventID)
+}
+
+// Release mocks base method.
+func (m *MockProcessedEventRepository) Release(ctx context.Context, consumer, eventID string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Release", ctx, consumer, eventID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Release indicates an expected call of Release.
+func (mr *MockProcessedEventRepositoryMockRecorder) Release(ctx, consumer, eventID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Release", reflect.TypeOf((*MockProcessedEventRepository)(nil).Release), ctx, consumer, eventID)
+}
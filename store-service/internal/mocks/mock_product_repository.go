@@ -42,18 +42,67 @@ func (m *MockProductRepository) EXPECT() *MockProductRepositoryMockRecorder {
 	return m.recorder
 }
 
+// AddImage mocks base method.
+func (m *MockProductRepository) AddImage(ctx context.Context, product *model.Product, image *model.ProductImage) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddImage", ctx, product, image)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AddImage indicates an expected call of AddImage.
+func (mr *MockProductRepositoryMockRecorder) AddImage(ctx, product, image any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddImage", reflect.TypeOf((*MockProductRepository)(nil).AddImage), ctx, product, image)
+}
+
+// CountByStore mocks base method.
+func (m *MockProductRepository) CountByStore(ctx context.Context, storeID uuid.UUID) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CountByStore", ctx, storeID)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CountByStore indicates an expected call of CountByStore.
+func (mr *MockProductRepositoryMockRecorder) CountByStore(ctx, storeID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CountByStore", reflect.TypeOf((*MockProductRepository)(nil).CountByStore), ctx, storeID)
+}
+
+// CountImagesByStore mocks base method.
+func (m *MockProductRepository) CountImagesByStore(ctx context.Context, storeID uuid.UUID) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CountImagesByStore", ctx, storeID)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CountImagesByStore indicates an expected call of CountImagesByStore.
+func (mr *MockProductRepositoryMockRecorder) CountImagesByStore(ctx, storeID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CountImagesByStore", reflect.TypeOf((*MockProductRepository)(nil).CountImagesByStore), ctx, storeID)
+}
+
 // Create mocks base method.
-func (m *MockProductRepository) Create(ctx context.Context, product *model.Product) error {
+func (m *MockProductRepository) Create(ctx context.Context, product *model.Product, movements ...model.StockMovement) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "Create", ctx, product)
+	varargs := []any{ctx, product}
+	for _, a := range movements {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "Create", varargs...)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // Create indicates an expected call of Create.
-func (mr *MockProductRepositoryMockRecorder) Create(ctx, product any) *gomock.Call {
+func (mr *MockProductRepositoryMockRecorder) Create(ctx, product any, movements ...any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockProductRepository)(nil).Create), ctx, product)
+	varargs := append([]any{ctx, product}, movements...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockProductRepository)(nil).Create), varargs...)
 }
 
 // Delete mocks base method.
@@ -70,6 +119,20 @@ func (mr *MockProductRepositoryMockRecorder) Delete(ctx, id any) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*MockProductRepository)(nil).Delete), ctx, id)
 }
 
+// DeleteImage mocks base method.
+func (m *MockProductRepository) DeleteImage(ctx context.Context, product *model.Product, image, promoted *model.ProductImage) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteImage", ctx, product, image, promoted)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteImage indicates an expected call of DeleteImage.
+func (mr *MockProductRepositoryMockRecorder) DeleteImage(ctx, product, image, promoted any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteImage", reflect.TypeOf((*MockProductRepository)(nil).DeleteImage), ctx, product, image, promoted)
+}
+
 // FindAll mocks base method.
 func (m *MockProductRepository) FindAll(ctx context.Context, filter model.ProductFilter) ([]model.Product, int64, error) {
 	m.ctrl.T.Helper()
@@ -86,6 +149,21 @@ func (mr *MockProductRepositoryMockRecorder) FindAll(ctx, filter any) *gomock.Ca
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindAll", reflect.TypeOf((*MockProductRepository)(nil).FindAll), ctx, filter)
 }
 
+// FindAlsoBought mocks base method.
+func (m *MockProductRepository) FindAlsoBought(ctx context.Context, productID uuid.UUID, limit int) ([]model.Product, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindAlsoBought", ctx, productID, limit)
+	ret0, _ := ret[0].([]model.Product)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindAlsoBought indicates an expected call of FindAlsoBought.
+func (mr *MockProductRepositoryMockRecorder) FindAlsoBought(ctx, productID, limit any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindAlsoBought", reflect.TypeOf((*MockProductRepository)(nil).FindAlsoBought), ctx, productID, limit)
+}
+
 // FindByID mocks base method.
 func (m *MockProductRepository) FindByID(ctx context.Context, id uuid.UUID) (*model.Product, error) {
 	m.ctrl.T.Helper()
@@ -101,30 +179,140 @@ func (mr *MockProductRepositoryMockRecorder) FindByID(ctx, id any) *gomock.Call
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindByID", reflect.TypeOf((*MockProductRepository)(nil).FindByID), ctx, id)
 }
 
+// FindByStoreIDInBatches mocks base method.
+func (m *MockProductRepository) FindByStoreIDInBatches(ctx context.Context, storeID uuid.UUID, batchSize int, fn func([]model.Product) error) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindByStoreIDInBatches", ctx, storeID, batchSize, fn)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// FindByStoreIDInBatches indicates an expected call of FindByStoreIDInBatches.
+func (mr *MockProductRepositoryMockRecorder) FindByStoreIDInBatches(ctx, storeID, batchSize, fn any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindByStoreIDInBatches", reflect.TypeOf((*MockProductRepository)(nil).FindByStoreIDInBatches), ctx, storeID, batchSize, fn)
+}
+
+// FindImages mocks base method.
+func (m *MockProductRepository) FindImages(ctx context.Context, productID uuid.UUID) ([]model.ProductImage, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindImages", ctx, productID)
+	ret0, _ := ret[0].([]model.ProductImage)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindImages indicates an expected call of FindImages.
+func (mr *MockProductRepositoryMockRecorder) FindImages(ctx, productID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindImages", reflect.TypeOf((*MockProductRepository)(nil).FindImages), ctx, productID)
+}
+
+// InvalidateCategoryListings mocks base method.
+func (m *MockProductRepository) InvalidateCategoryListings(ctx context.Context, categoryIDs ...uuid.UUID) {
+	m.ctrl.T.Helper()
+	varargs := []any{ctx}
+	for _, a := range categoryIDs {
+		varargs = append(varargs, a)
+	}
+	m.ctrl.Call(m, "InvalidateCategoryListings", varargs...)
+}
+
+// InvalidateCategoryListings indicates an expected call of InvalidateCategoryListings.
+func (mr *MockProductRepositoryMockRecorder) InvalidateCategoryListings(ctx any, categoryIDs ...any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]any{ctx}, categoryIDs...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "InvalidateCategoryListings", reflect.TypeOf((*MockProductRepository)(nil).InvalidateCategoryListings), varargs...)
+}
+
+// InvalidateStoreListings mocks base method.
+func (m *MockProductRepository) InvalidateStoreListings(ctx context.Context, storeID uuid.UUID) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "InvalidateStoreListings", ctx, storeID)
+}
+
+// InvalidateStoreListings indicates an expected call of InvalidateStoreListings.
+func (mr *MockProductRepositoryMockRecorder) InvalidateStoreListings(ctx, storeID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "InvalidateStoreListings", reflect.TypeOf((*MockProductRepository)(nil).InvalidateStoreListings), ctx, storeID)
+}
+
+// SaveImageOrder mocks base method.
+func (m *MockProductRepository) SaveImageOrder(ctx context.Context, product *model.Product, images []model.ProductImage) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SaveImageOrder", ctx, product, images)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SaveImageOrder indicates an expected call of SaveImageOrder.
+func (mr *MockProductRepositoryMockRecorder) SaveImageOrder(ctx, product, images any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveImageOrder", reflect.TypeOf((*MockProductRepository)(nil).SaveImageOrder), ctx, product, images)
+}
+
+// SetAttributes mocks base method.
+func (m *MockProductRepository) SetAttributes(ctx context.Context, product *model.Product, attrs map[string]string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetAttributes", ctx, product, attrs)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetAttributes indicates an expected call of SetAttributes.
+func (mr *MockProductRepositoryMockRecorder) SetAttributes(ctx, product, attrs any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetAttributes", reflect.TypeOf((*MockProductRepository)(nil).SetAttributes), ctx, product, attrs)
+}
+
 // Update mocks base method.
-func (m *MockProductRepository) Update(ctx context.Context, product *model.Product) error {
+func (m *MockProductRepository) Update(ctx context.Context, product *model.Product, movements ...model.StockMovement) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "Update", ctx, product)
+	varargs := []any{ctx, product}
+	for _, a := range movements {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "Update", varargs...)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // Update indicates an expected call of Update.
-func (mr *MockProductRepositoryMockRecorder) Update(ctx, product any) *gomock.Call {
+func (mr *MockProductRepositoryMockRecorder) Update(ctx, product any, movements ...any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]any{ctx, product}, movements...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Update", reflect.TypeOf((*MockProductRepository)(nil).Update), varargs...)
+}
+
+// UpdateImage mocks base method.
+func (m *MockProductRepository) UpdateImage(ctx context.Context, product *model.Product, image *model.ProductImage) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateImage", ctx, product, image)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateImage indicates an expected call of UpdateImage.
+func (mr *MockProductRepositoryMockRecorder) UpdateImage(ctx, product, image any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Update", reflect.TypeOf((*MockProductRepository)(nil).Update), ctx, product)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateImage", reflect.TypeOf((*MockProductRepository)(nil).UpdateImage), ctx, product, image)
 }
 
 // UpdateStock mocks base method.
-func (m *MockProductRepository) UpdateStock(ctx context.Context, id uuid.UUID, quantity int) error {
+func (m *MockProductRepository) UpdateStock(ctx context.Context, product *model.Product, quantity int, movements ...model.StockMovement) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "UpdateStock", ctx, id, quantity)
+	varargs := []any{ctx, product, quantity}
+	for _, a := range movements {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "UpdateStock", varargs...)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // UpdateStock indicates an expected call of UpdateStock.
-func (mr *MockProductRepositoryMockRecorder) UpdateStock(ctx, id, quantity any) *gomock.Call {
+func (mr *MockProductRepositoryMockRecorder) UpdateStock(ctx, product, quantity any, movements ...any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateStock", reflect.TypeOf((*MockProductRepository)(nil).UpdateStock), ctx, id, quantity)
+	varargs := append([]any{ctx, product, quantity}, movements...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateStock", reflect.TypeOf((*MockProductRepository)(nil).UpdateStock), varargs...)
 }
@@ -42,6 +42,50 @@ func (m *MockReviewRepository) EXPECT() *MockReviewRepositoryMockRecorder {
 	return m.recorder
 }
 
+// AddVote mocks base method.
+func (m *MockReviewRepository) AddVote(ctx context.Context, reviewID, userID uuid.UUID) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddVote", ctx, reviewID, userID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AddVote indicates an expected call of AddVote.
+func (mr *MockReviewRepositoryMockRecorder) AddVote(ctx, reviewID, userID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddVote", reflect.TypeOf((*MockReviewRepository)(nil).AddVote), ctx, reviewID, userID)
+}
+
+// CountUserPurchases mocks base method.
+func (m *MockReviewRepository) CountUserPurchases(ctx context.Context, userID, productID uuid.UUID) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CountUserPurchases", ctx, userID, productID)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CountUserPurchases indicates an expected call of CountUserPurchases.
+func (mr *MockReviewRepositoryMockRecorder) CountUserPurchases(ctx, userID, productID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CountUserPurchases", reflect.TypeOf((*MockReviewRepository)(nil).CountUserPurchases), ctx, userID, productID)
+}
+
+// CountVotes mocks base method.
+func (m *MockReviewRepository) CountVotes(ctx context.Context, reviewID uuid.UUID) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CountVotes", ctx, reviewID)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CountVotes indicates an expected call of CountVotes.
+func (mr *MockReviewRepositoryMockRecorder) CountVotes(ctx, reviewID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CountVotes", reflect.TypeOf((*MockReviewRepository)(nil).CountVotes), ctx, reviewID)
+}
+
 // Create mocks base method.
 func (m *MockReviewRepository) Create(ctx context.Context, review *model.Review) error {
 	m.ctrl.T.Helper()
@@ -56,10 +100,39 @@ func (mr *MockReviewRepositoryMockRecorder) Create(ctx, review any) *gomock.Call
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockReviewRepository)(nil).Create), ctx, review)
 }
 
+// Delete mocks base method.
+func (m *MockReviewRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Delete", ctx, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Delete indicates an expected call of Delete.
+func (mr *MockReviewRepositoryMockRecorder) Delete(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*MockReviewRepository)(nil).Delete), ctx, id)
+}
+
+// FindByID mocks base method.
+func (m *MockReviewRepository) FindByID(ctx context.Context, id uuid.UUID) (*model.Review, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindByID", ctx, id)
+	ret0, _ := ret[0].(*model.Review)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindByID indicates an expected call of FindByID.
+func (mr *MockReviewRepositoryMockRecorder) FindByID(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindByID", reflect.TypeOf((*MockReviewRepository)(nil).FindByID), ctx, id)
+}
+
 // FindByProductID mocks base method.
-func (m *MockReviewRepository) FindByProductID(ctx context.Context, productID uuid.UUID, page, perPage int) ([]model.Review, int64, error) {
+func (m *MockReviewRepository) FindByProductID(ctx context.Context, productID uuid.UUID, filter model.ReviewFilter) ([]model.Review, int64, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "FindByProductID", ctx, productID, page, perPage)
+	ret := m.ctrl.Call(m, "FindByProductID", ctx, productID, filter)
 	ret0, _ := ret[0].([]model.Review)
 	ret1, _ := ret[1].(int64)
 	ret2, _ := ret[2].(error)
@@ -67,9 +140,54 @@ func (m *MockReviewRepository) FindByProductID(ctx context.Context, productID uu
 }
 
 // FindByProductID indicates an expected call of FindByProductID.
-func (mr *MockReviewRepositoryMockRecorder) FindByProductID(ctx, productID, page, perPage any) *gomock.Call {
+func (mr *MockReviewRepositoryMockRecorder) FindByProductID(ctx, productID, filter any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindByProductID", reflect.TypeOf((*MockReviewRepository)(nil).FindByProductID), ctx, productID, filter)
+}
+
+// FindByUserAndProduct mocks base method.
+func (m *MockReviewRepository) FindByUserAndProduct(ctx context.Context, userID, productID uuid.UUID) (*model.Review, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindByUserAndProduct", ctx, userID, productID)
+	ret0, _ := ret[0].(*model.Review)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindByUserAndProduct indicates an expected call of FindByUserAndProduct.
+func (mr *MockReviewRepositoryMockRecorder) FindByUserAndProduct(ctx, userID, productID any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindByProductID", reflect.TypeOf((*MockReviewRepository)(nil).FindByProductID), ctx, productID, page, perPage)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindByUserAndProduct", reflect.TypeOf((*MockReviewRepository)(nil).FindByUserAndProduct), ctx, userID, productID)
+}
+
+// FindProductOwnerID mocks base method.
+func (m *MockReviewRepository) FindProductOwnerID(ctx context.Context, productID uuid.UUID) (uuid.UUID, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindProductOwnerID", ctx, productID)
+	ret0, _ := ret[0].(uuid.UUID)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindProductOwnerID indicates an expected call of FindProductOwnerID.
+func (mr *MockReviewRepositoryMockRecorder) FindProductOwnerID(ctx, productID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindProductOwnerID", reflect.TypeOf((*MockReviewRepository)(nil).FindProductOwnerID), ctx, productID)
+}
+
+// FindProductStore mocks base method.
+func (m *MockReviewRepository) FindProductStore(ctx context.Context, productID uuid.UUID) (*model.Store, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindProductStore", ctx, productID)
+	ret0, _ := ret[0].(*model.Store)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindProductStore indicates an expected call of FindProductStore.
+func (mr *MockReviewRepositoryMockRecorder) FindProductStore(ctx, productID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindProductStore", reflect.TypeOf((*MockReviewRepository)(nil).FindProductStore), ctx, productID)
 }
 
 // HasUserPurchased mocks base method.
@@ -101,3 +219,60 @@ func (mr *MockReviewRepositoryMockRecorder) HasUserReviewed(ctx, userID, product
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "HasUserReviewed", reflect.TypeOf((*MockReviewRepository)(nil).HasUserReviewed), ctx, userID, productID)
 }
+
+// LatestPurchaseSeq mocks base method.
+func (m *MockReviewRepository) LatestPurchaseSeq(ctx context.Context, userID, productID uuid.UUID) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "LatestPurchaseSeq", ctx, userID, productID)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// LatestPurchaseSeq indicates an expected call of LatestPurchaseSeq.
+func (mr *MockReviewRepositoryMockRecorder) LatestPurchaseSeq(ctx, userID, productID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LatestPurchaseSeq", reflect.TypeOf((*MockReviewRepository)(nil).LatestPurchaseSeq), ctx, userID, productID)
+}
+
+// RemoveVote mocks base method.
+func (m *MockReviewRepository) RemoveVote(ctx context.Context, reviewID, userID uuid.UUID) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RemoveVote", ctx, reviewID, userID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RemoveVote indicates an expected call of RemoveVote.
+func (mr *MockReviewRepositoryMockRecorder) RemoveVote(ctx, reviewID, userID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RemoveVote", reflect.TypeOf((*MockReviewRepository)(nil).RemoveVote), ctx, reviewID, userID)
+}
+
+// SaveReply mocks base method.
+func (m *MockReviewRepository) SaveReply(ctx context.Context, reply *model.ReviewReply) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SaveReply", ctx, reply)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SaveReply indicates an expected call of SaveReply.
+func (mr *MockReviewRepositoryMockRecorder) SaveReply(ctx, reply any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveReply", reflect.TypeOf((*MockReviewRepository)(nil).SaveReply), ctx, reply)
+}
+
+// Update mocks base method.
+func (m *MockReviewRepository) Update(ctx context.Context, review *model.Review) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Update", ctx, review)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Update indicates an expected call of Update.
+func (mr *MockReviewRepositoryMockRecorder) Update(ctx, review any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Update", reflect.TypeOf((*MockReviewRepository)(nil).Update), ctx, review)
+}
@@ -0,0 +1,59 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: store-service/internal/repository/stock_movement_repository.go
+//
+// Generated by this command:
+//
+//	mockgen -source=store-service/internal/repository/stock_movement_repository.go -destination=store-service/internal/mocks/mock_stock_movement_repository.go -package=mocks
+//
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	context "context"
+	reflect "reflect"
+
+	model "github.com/1tsndre/mini-go-project/store-service/internal/model"
+	uuid "github.com/google/uuid"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockStockMovementRepository is a mock of StockMovementRepository interface.
+type MockStockMovementRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockStockMovementRepositoryMockRecorder
+	isgomock struct{}
+}
+
+// MockStockMovementRepositoryMockRecorder is the mock recorder for MockStockMovementRepository.
+type MockStockMovementRepositoryMockRecorder struct {
+	mock *MockStockMovementRepository
+}
+
+// NewMockStockMovementRepository creates a new mock instance.
+func NewMockStockMovementRepository(ctrl *gomock.Controller) *MockStockMovementRepository {
+	mock := &MockStockMovementRepository{ctrl: ctrl}
+	mock.recorder = &MockStockMovementRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockStockMovementRepository) EXPECT() *MockStockMovementRepositoryMockRecorder {
+	return m.recorder
+}
+
+// FindByProductID mocks base method.
+func (m *MockStockMovementRepository) FindByProductID(ctx context.Context, productID uuid.UUID, page, perPage int) ([]model.StockMovement, int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindByProductID", ctx, productID, page, perPage)
+	ret0, _ := ret[0].([]model.StockMovement)
+	ret1, _ := ret[1].(int64)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// FindByProductID indicates an expected call of FindByProductID.
+func (mr *MockStockMovementRepositoryMockRecorder) FindByProductID(ctx, productID, page, perPage any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindByProductID", reflect.TypeOf((*MockStockMovementRepository)(nil).FindByProductID), ctx, productID, page, perPage)
+}
@@ -12,6 +12,7 @@ package mocks
 import (
 	context "context"
 	reflect "reflect"
+	time "time"
 
 	model "github.com/1tsndre/mini-go-project/store-service/internal/model"
 	uuid "github.com/google/uuid"
@@ -42,18 +43,19 @@ func (m *MockStoreRepository) EXPECT() *MockStoreRepositoryMockRecorder {
 	return m.recorder
 }
 
-// Delete mocks base method.
-func (m *MockStoreRepository) Delete(ctx context.Context, id uuid.UUID) error {
+// AvgProcessingTime mocks base method.
+func (m *MockStoreRepository) AvgProcessingTime(ctx context.Context, storeID uuid.UUID, since time.Time) (*int64, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "Delete", ctx, id)
-	ret0, _ := ret[0].(error)
-	return ret0
+	ret := m.ctrl.Call(m, "AvgProcessingTime", ctx, storeID, since)
+	ret0, _ := ret[0].(*int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
 }
 
-// Delete indicates an expected call of Delete.
-func (mr *MockStoreRepositoryMockRecorder) Delete(ctx, id any) *gomock.Call {
+// AvgProcessingTime indicates an expected call of AvgProcessingTime.
+func (mr *MockStoreRepositoryMockRecorder) AvgProcessingTime(ctx, storeID, since any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*MockStoreRepository)(nil).Delete), ctx, id)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AvgProcessingTime", reflect.TypeOf((*MockStoreRepository)(nil).AvgProcessingTime), ctx, storeID, since)
 }
 
 // Create mocks base method.
@@ -70,6 +72,20 @@ func (mr *MockStoreRepositoryMockRecorder) Create(ctx, store any) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockStoreRepository)(nil).Create), ctx, store)
 }
 
+// Delete mocks base method.
+func (m *MockStoreRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Delete", ctx, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Delete indicates an expected call of Delete.
+func (mr *MockStoreRepositoryMockRecorder) Delete(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*MockStoreRepository)(nil).Delete), ctx, id)
+}
+
 // FindByID mocks base method.
 func (m *MockStoreRepository) FindByID(ctx context.Context, id uuid.UUID) (*model.Store, error) {
 	m.ctrl.T.Helper()
@@ -100,6 +116,20 @@ func (mr *MockStoreRepositoryMockRecorder) FindByUserID(ctx, userID any) *gomock
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindByUserID", reflect.TypeOf((*MockStoreRepository)(nil).FindByUserID), ctx, userID)
 }
 
+// SetActive mocks base method.
+func (m *MockStoreRepository) SetActive(ctx context.Context, id uuid.UUID, active bool) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetActive", ctx, id, active)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetActive indicates an expected call of SetActive.
+func (mr *MockStoreRepositoryMockRecorder) SetActive(ctx, id, active any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetActive", reflect.TypeOf((*MockStoreRepository)(nil).SetActive), ctx, id, active)
+}
+
 // Update mocks base method.
 func (m *MockStoreRepository) Update(ctx context.Context, store *model.Store) error {
 	m.ctrl.T.Helper()
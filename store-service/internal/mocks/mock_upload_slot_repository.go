@@ -0,0 +1,71 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: store-service/internal/repository/upload_slot_repository.go
+//
+// Generated by this command:
+//
+//	mockgen -source=store-service/internal/repository/upload_slot_repository.go -destination=store-service/internal/mocks/mock_upload_slot_repository.go -package=mocks
+//
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	context "context"
+	reflect "reflect"
+
+	uuid "github.com/google/uuid"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockUploadSlotRepository is a mock of UploadSlotRepository interface.
+type MockUploadSlotRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockUploadSlotRepositoryMockRecorder
+	isgomock struct{}
+}
+
+// MockUploadSlotRepositoryMockRecorder is the mock recorder for MockUploadSlotRepository.
+type MockUploadSlotRepositoryMockRecorder struct {
+	mock *MockUploadSlotRepository
+}
+
+// NewMockUploadSlotRepository creates a new mock instance.
+func NewMockUploadSlotRepository(ctrl *gomock.Controller) *MockUploadSlotRepository {
+	mock := &MockUploadSlotRepository{ctrl: ctrl}
+	mock.recorder = &MockUploadSlotRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockUploadSlotRepository) EXPECT() *MockUploadSlotRepositoryMockRecorder {
+	return m.recorder
+}
+
+// Acquire mocks base method.
+func (m *MockUploadSlotRepository) Acquire(ctx context.Context, userID uuid.UUID, limit int) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Acquire", ctx, userID, limit)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Acquire indicates an expected call of Acquire.
+func (mr *MockUploadSlotRepositoryMockRecorder) Acquire(ctx, userID, limit any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Acquire", reflect.TypeOf((*MockUploadSlotRepository)(nil).Acquire), ctx, userID, limit)
+}
+
+// Release mocks base method.
+func (m *MockUploadSlotRepository) Release(ctx context.Context, userID uuid.UUID) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Release", ctx, userID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Release indicates an expected call of Release.
+func (mr *MockUploadSlotRepositoryMockRecorder) Release(ctx, userID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Release", reflect.TypeOf((*MockUploadSlotRepository)(nil).Release), ctx, userID)
+}
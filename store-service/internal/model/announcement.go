@@ -0,0 +1,38 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	AnnouncementAudienceAll     = "all"
+	AnnouncementAudienceBuyers  = "buyers"
+	AnnouncementAudienceSellers = "sellers"
+
+	MaxAnnouncementTitleLength = 200
+)
+
+// Announcement is a site-wide message shown to its audience between StartsAt
+// and EndsAt. A nil EndsAt keeps it active until removed.
+type Announcement struct {
+	ID        uuid.UUID  `gorm:"type:uuid;primaryKey;default:gen_random_uuid()" json:"id"`
+	Title     string     `gorm:"not null" json:"title"`
+	Message   string     `gorm:"not null" json:"message"`
+	Audience  string     `gorm:"not null;default:all" json:"audience"`
+	StartsAt  time.Time  `gorm:"not null" json:"starts_at"`
+	EndsAt    *time.Time `json:"ends_at"`
+	CreatedBy uuid.UUID  `gorm:"type:uuid;not null" json:"created_by"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+// CreateAnnouncementRequest creates an announcement. Audience defaults to
+// "all" and StartsAt to now.
+type CreateAnnouncementRequest struct {
+	Title    string     `json:"title"`
+	Message  string     `json:"message"`
+	Audience string     `json:"audience"`
+	StartsAt *time.Time `json:"starts_at"`
+	EndsAt   *time.Time `json:"ends_at"`
+}
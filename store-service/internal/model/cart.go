@@ -10,6 +10,7 @@ import (
 type Cart struct {
 	UserID    uuid.UUID  `json:"user_id"`
 	Items     []CartItem `json:"items"`
+	Version   int64      `json:"version"`
 	UpdatedAt time.Time  `json:"updated_at"`
 }
 
@@ -38,13 +39,30 @@ func (CartItemDB) TableName() string {
 	return "cart_items"
 }
 
+// CartVersion tracks how many times a user's cart has been written, so saves
+// can be rejected when the cart changed after it was read.
+type CartVersion struct {
+	UserID    uuid.UUID `gorm:"type:uuid;primaryKey" json:"user_id"`
+	Version   int64     `gorm:"not null;default:0" json:"version"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// Cart edit requests may carry the updated_at of the cart the client last
+// read; when sync conflict detection is on, the edit is rejected if the cart
+// has been saved since.
 type AddCartItemRequest struct {
-	ProductID string `json:"product_id"`
-	Quantity  int    `json:"quantity"`
+	ProductID string     `json:"product_id"`
+	Quantity  int        `json:"quantity"`
+	UpdatedAt *time.Time `json:"updated_at,omitempty"`
 }
 
 type UpdateCartItemRequest struct {
-	Quantity int `json:"quantity"`
+	Quantity  int        `json:"quantity"`
+	UpdatedAt *time.Time `json:"updated_at,omitempty"`
+}
+
+type RemoveCartItemRequest struct {
+	UpdatedAt *time.Time
 }
 
 type CartResponse struct {
@@ -6,32 +6,64 @@ import (
 	"github.com/google/uuid"
 )
 
+// Category is a product category. Categories nest through ParentID; a nil
+// ParentID is a top-level category.
 type Category struct {
-	ID        uuid.UUID `gorm:"type:uuid;primaryKey;default:gen_random_uuid()" json:"id"`
-	Name      string    `gorm:"uniqueIndex;not null" json:"name"`
-	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
+	ID        uuid.UUID  `gorm:"type:uuid;primaryKey;default:gen_random_uuid()" json:"id"`
+	Name      string     `gorm:"uniqueIndex;not null" json:"name"`
+	ParentID  *uuid.UUID `gorm:"type:uuid;index" json:"parent_id"`
+	CreatedAt time.Time  `json:"created_at"`
+	UpdatedAt time.Time  `json:"updated_at"`
 }
 
 type CreateCategoryRequest struct {
-	Name string `json:"name"`
+	Name     string `json:"name"`
+	ParentID string `json:"parent_id"`
 }
 
+// UpdateCategoryRequest changes a category. A nil ParentID keeps the current
+// parent and an empty one moves the category to the top level.
 type UpdateCategoryRequest struct {
-	Name string `json:"name"`
+	Name     string  `json:"name"`
+	ParentID *string `json:"parent_id"`
+}
+
+// BulkCategory is one entry of a bulk import. Parent names either an
+// existing category or one listed earlier in the same request.
+type BulkCategory struct {
+	Name   string `json:"name"`
+	Parent string `json:"parent"`
+}
+
+type BulkCreateCategoriesRequest struct {
+	Categories []BulkCategory `json:"categories"`
+}
+
+// SkippedCategory reports a bulk entry that was not created.
+type SkippedCategory struct {
+	Name   string `json:"name"`
+	Reason string `json:"reason"`
+}
+
+type BulkCreateCategoriesResponse struct {
+	Created []CategoryResponse `json:"created"`
+	Skipped []SkippedCategory  `json:"skipped"`
 }
 
 type CategoryResponse struct {
-	ID        uuid.UUID `json:"id"`
-	Name      string    `json:"name"`
-	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
+	ID        uuid.UUID          `json:"id"`
+	Name      string             `json:"name"`
+	ParentID  *uuid.UUID         `json:"parent_id"`
+	Children  []CategoryResponse `json:"children,omitempty"`
+	CreatedAt time.Time          `json:"created_at"`
+	UpdatedAt time.Time          `json:"updated_at"`
 }
 
 func (c *Category) ToResponse() CategoryResponse {
 	return CategoryResponse{
 		ID:        c.ID,
 		Name:      c.Name,
+		ParentID:  c.ParentID,
 		CreatedAt: c.CreatedAt,
 		UpdatedAt: c.UpdatedAt,
 	}
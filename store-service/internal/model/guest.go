@@ -0,0 +1,32 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Guest is the contact record behind an order placed without an account.
+// Each guest checkout creates its own record; guests are never merged.
+type Guest struct {
+	ID        uuid.UUID `gorm:"type:uuid;primaryKey;default:gen_random_uuid()" json:"id"`
+	Email     string    `gorm:"not null" json:"email"`
+	Name      string    `gorm:"not null;default:''" json:"name"`
+	Phone     string    `gorm:"not null;default:''" json:"phone"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type GuestCheckoutItem struct {
+	ProductID string `json:"product_id"`
+	Quantity  int    `json:"quantity"`
+}
+
+// GuestCheckoutRequest carries everything a guest checkout needs, since a
+// guest has no server-side cart.
+type GuestCheckoutRequest struct {
+	Email           string              `json:"email" validate:"required,email_address"`
+	Name            string              `json:"name"`
+	Phone           string              `json:"phone"`
+	ShippingAddress string              `json:"shipping_address" validate:"required"`
+	Items           []GuestCheckoutItem `json:"items"`
+}
@@ -0,0 +1,11 @@
+package model
+
+import "github.com/google/uuid"
+
+// IdempotencyRecord remembers the outcome of a checkout made with an
+// Idempotency-Key. RequestHash identifies the request body the key was
+// first used with, so a reuse with different parameters can be rejected.
+type IdempotencyRecord struct {
+	RequestHash string    `json:"request_hash"`
+	OrderID     uuid.UUID `json:"order_id"`
+}
@@ -7,27 +7,39 @@ import (
 	"github.com/shopspring/decimal"
 )
 
+// Order is placed either by a user or, through guest checkout, by a guest; a
+// guest order leaves UserID zero and sets GuestID. LookupTokenHash is the
+// SHA-256 of the token a guest uses to look the order up.
 type Order struct {
 	ID              uuid.UUID       `gorm:"type:uuid;primaryKey;default:gen_random_uuid()" json:"id"`
-	UserID          uuid.UUID       `gorm:"type:uuid;not null;index" json:"user_id"`
+	UserID          uuid.UUID       `gorm:"type:uuid;index;default:null" json:"user_id"`
+	GuestID         *uuid.UUID      `gorm:"type:uuid" json:"guest_id,omitempty"`
 	Status          string          `gorm:"not null;default:pending" json:"status"`
 	TotalAmount     decimal.Decimal `gorm:"type:decimal(15,2);not null" json:"total_amount"`
+	ShippingCost    decimal.Decimal `gorm:"type:decimal(15,2);not null;default:0" json:"shipping_cost"`
+	TaxAmount       decimal.Decimal `gorm:"type:decimal(15,2);not null;default:0" json:"tax_amount"`
 	ShippingAddress string          `gorm:"not null;default:''" json:"shipping_address"`
+	LookupTokenHash string          `gorm:"not null;default:''" json:"-"`
 	CreatedAt       time.Time       `json:"created_at"`
 	UpdatedAt       time.Time       `json:"updated_at"`
 
 	User       User        `gorm:"foreignKey:UserID" json:"-"`
+	Guest      *Guest      `gorm:"foreignKey:GuestID" json:"-"`
 	OrderItems []OrderItem `gorm:"foreignKey:OrderID" json:"items,omitempty"`
 	Payment    *Payment    `gorm:"foreignKey:OrderID" json:"payment,omitempty"`
+	// Reservation is set on checkout for orders awaiting payment when stock
+	// reservations are enabled, and saved with the order.
+	Reservation *StockReservation `gorm:"foreignKey:OrderID" json:"-"`
 }
 
 type OrderItem struct {
-	ID        uuid.UUID       `gorm:"type:uuid;primaryKey;default:gen_random_uuid()" json:"id"`
-	OrderID   uuid.UUID       `gorm:"type:uuid;not null;index" json:"order_id"`
-	ProductID uuid.UUID       `gorm:"type:uuid;not null" json:"product_id"`
-	Quantity  int             `gorm:"not null" json:"quantity"`
-	Price     decimal.Decimal `gorm:"type:decimal(15,2);not null" json:"price"`
-	CreatedAt time.Time       `json:"created_at"`
+	ID          uuid.UUID       `gorm:"type:uuid;primaryKey;default:gen_random_uuid()" json:"id"`
+	OrderID     uuid.UUID       `gorm:"type:uuid;not null;index" json:"order_id"`
+	ProductID   uuid.UUID       `gorm:"type:uuid;not null" json:"product_id"`
+	Quantity    int             `gorm:"not null" json:"quantity"`
+	Price       decimal.Decimal `gorm:"type:decimal(15,2);not null" json:"price"`
+	Backordered bool            `gorm:"not null;default:false" json:"backordered"`
+	CreatedAt   time.Time       `json:"created_at"`
 
 	Product Product `gorm:"foreignKey:ProductID" json:"-"`
 }
@@ -38,34 +50,79 @@ type UpdateOrderStatusRequest struct {
 
 type CheckoutRequest struct {
 	ShippingAddress string `json:"shipping_address"`
+	// ExpectedTotal is the cart total the buyer reviewed, items only. When
+	// set, checkout is refused if current prices no longer add up to it.
+	ExpectedTotal string `json:"expected_total,omitempty"`
+}
+
+// OrderFilter narrows an admin order listing. Zero values leave a field
+// unfiltered, so CreatedFrom or CreatedTo alone give an open-ended range.
+type OrderFilter struct {
+	Status string
+	UserID uuid.UUID
+	// CreatedFrom is inclusive and CreatedTo exclusive.
+	CreatedFrom time.Time
+	CreatedTo   time.Time
+	Page        int
+	PerPage     int
+}
+
+type OrderStatusBatchRequest struct {
+	OrderIDs []string `json:"order_ids"`
 }
 
 type OrderResponse struct {
 	ID              uuid.UUID           `json:"id"`
 	UserID          uuid.UUID           `json:"user_id"`
+	GuestID         *uuid.UUID          `json:"guest_id,omitempty"`
 	Status          string              `json:"status"`
 	TotalAmount     decimal.Decimal     `json:"total_amount"`
+	ShippingCost    decimal.Decimal     `json:"shipping_cost"`
+	TaxAmount       decimal.Decimal     `json:"tax_amount"`
 	ShippingAddress string              `json:"shipping_address"`
 	Items           []OrderItemResponse `json:"items"`
 	Payment         *PaymentResponse    `json:"payment,omitempty"`
-	CreatedAt       time.Time           `json:"created_at"`
-	UpdatedAt       time.Time           `json:"updated_at"`
+	// PaymentPending is set on a checkout response when the order was
+	// accepted but payment could not be triggered yet and will be retried.
+	PaymentPending bool `json:"payment_pending,omitempty"`
+	// LookupToken is only returned once, on the guest checkout response.
+	LookupToken string `json:"lookup_token,omitempty"`
+	// Payout is only included on seller order listings, for orders whose
+	// payment has been received.
+	Payout *SellerPayoutResponse `json:"payout,omitempty"`
+	// StatusHistory is only included on the order detail.
+	StatusHistory []OrderStatusHistoryResponse `json:"status_history,omitempty"`
+	CreatedAt     time.Time                    `json:"created_at"`
+	UpdatedAt     time.Time                    `json:"updated_at"`
+}
+
+// SellerPayoutResponse splits the seller's share of an order between the
+// platform fee and the seller's net payout.
+type SellerPayoutResponse struct {
+	Gross      decimal.Decimal `json:"gross"`
+	FeePercent decimal.Decimal `json:"fee_percent"`
+	Fee        decimal.Decimal `json:"fee"`
+	Net        decimal.Decimal `json:"net"`
 }
 
 type OrderItemResponse struct {
-	ID        uuid.UUID       `json:"id"`
-	ProductID uuid.UUID       `json:"product_id"`
-	Quantity  int             `json:"quantity"`
-	Price     decimal.Decimal `json:"price"`
-	Subtotal  decimal.Decimal `json:"subtotal"`
+	ID          uuid.UUID       `json:"id"`
+	ProductID   uuid.UUID       `json:"product_id"`
+	Quantity    int             `json:"quantity"`
+	Price       decimal.Decimal `json:"price"`
+	Subtotal    decimal.Decimal `json:"subtotal"`
+	Backordered bool            `json:"backordered"`
 }
 
 func (o *Order) ToResponse() OrderResponse {
 	resp := OrderResponse{
 		ID:              o.ID,
 		UserID:          o.UserID,
+		GuestID:         o.GuestID,
 		Status:          o.Status,
 		TotalAmount:     o.TotalAmount,
+		ShippingCost:    o.ShippingCost,
+		TaxAmount:       o.TaxAmount,
 		ShippingAddress: o.ShippingAddress,
 		CreatedAt:       o.CreatedAt,
 		UpdatedAt:       o.UpdatedAt,
@@ -73,11 +130,12 @@ func (o *Order) ToResponse() OrderResponse {
 
 	for _, item := range o.OrderItems {
 		resp.Items = append(resp.Items, OrderItemResponse{
-			ID:        item.ID,
-			ProductID: item.ProductID,
-			Quantity:  item.Quantity,
-			Price:     item.Price,
-			Subtotal:  item.Price.Mul(decimal.NewFromInt(int64(item.Quantity))),
+			ID:          item.ID,
+			ProductID:   item.ProductID,
+			Quantity:    item.Quantity,
+			Price:       item.Price,
+			Subtotal:    item.Price.Mul(decimal.NewFromInt(int64(item.Quantity))),
+			Backordered: item.Backordered,
 		})
 	}
 
@@ -0,0 +1,76 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// OrderStatusHistory records one status change of an order. FromStatus is
+// empty for the status an order was created with, and ChangedBy is nil for
+// changes made by the system, such as payment results.
+type OrderStatusHistory struct {
+	ID         uuid.UUID  `gorm:"type:uuid;primaryKey;default:gen_random_uuid()" json:"id"`
+	OrderID    uuid.UUID  `gorm:"type:uuid;not null;index" json:"order_id"`
+	FromStatus string     `gorm:"not null;default:''" json:"from_status"`
+	ToStatus   string     `gorm:"not null" json:"to_status"`
+	ChangedBy  *uuid.UUID `gorm:"type:uuid" json:"changed_by"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+func (OrderStatusHistory) TableName() string {
+	return "order_status_history"
+}
+
+type OrderStatusHistoryResponse struct {
+	FromStatus string     `json:"from_status"`
+	ToStatus   string     `json:"to_status"`
+	ChangedBy  *uuid.UUID `json:"changed_by"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+func (h *OrderStatusHistory) ToResponse() OrderStatusHistoryResponse {
+	return OrderStatusHistoryResponse{
+		FromStatus: h.FromStatus,
+		ToStatus:   h.ToStatus,
+		ChangedBy:  h.ChangedBy,
+		CreatedAt:  h.CreatedAt,
+	}
+}
+
+const (
+	TimelineActorBuyer  = "buyer"
+	TimelineActorSeller = "seller"
+	TimelineActorSystem = "system"
+)
+
+// OrderTimelineEvent is one step of an order's journey as shown to its
+// buyer. Actor says who made the change; only the buyer's own id is exposed.
+type OrderTimelineEvent struct {
+	Status     string     `json:"status"`
+	FromStatus string     `json:"from_status,omitempty"`
+	Actor      string     `json:"actor"`
+	ActorID    *uuid.UUID `json:"actor_id,omitempty"`
+	At         time.Time  `json:"at"`
+}
+
+// TimelineEvent converts a history entry of an order placed by buyerID.
+// Changes by anyone other than the buyer are made by the seller, and
+// changes without an actor by the system (payments, admin release).
+func (h *OrderStatusHistory) TimelineEvent(buyerID uuid.UUID) OrderTimelineEvent {
+	event := OrderTimelineEvent{
+		Status:     h.ToStatus,
+		FromStatus: h.FromStatus,
+		Actor:      TimelineActorSystem,
+		At:         h.CreatedAt,
+	}
+	switch {
+	case h.ChangedBy == nil:
+	case *h.ChangedBy == buyerID:
+		event.Actor = TimelineActorBuyer
+		event.ActorID = h.ChangedBy
+	default:
+		event.Actor = TimelineActorSeller
+	}
+	return event
+}
@@ -0,0 +1,19 @@
+package model
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// OutboxEvent is an NSQ message that could not be published when it was
+// produced and is waiting for the outbox relay to retry it.
+type OutboxEvent struct {
+	ID          uuid.UUID       `gorm:"type:uuid;primaryKey;default:gen_random_uuid()" json:"id"`
+	Topic       string          `gorm:"not null" json:"topic"`
+	Payload     json.RawMessage `gorm:"type:jsonb;not null" json:"payload"`
+	Attempts    int             `gorm:"not null;default:0" json:"attempts"`
+	PublishedAt *time.Time      `json:"published_at"`
+	CreatedAt   time.Time       `json:"created_at"`
+}
@@ -19,11 +19,15 @@ type Payment struct {
 }
 
 const (
-	PaymentStatusPending = "pending"
-	PaymentStatusSuccess = "success"
-	PaymentStatusFailed  = "failed"
+	PaymentStatusPending  = "pending"
+	PaymentStatusSuccess  = "success"
+	PaymentStatusFailed   = "failed"
+	PaymentStatusRefunded = "refunded"
 
 	PaymentMethodMock = "mock"
+	// PaymentMethodFree records the payment of a zero-total order, which
+	// never goes through the payment service.
+	PaymentMethodFree = "free"
 )
 
 type PaymentResponse struct {
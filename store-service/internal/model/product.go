@@ -8,35 +8,47 @@ import (
 )
 
 type Product struct {
-	ID          uuid.UUID       `gorm:"type:uuid;primaryKey;default:gen_random_uuid()" json:"id"`
-	StoreID     uuid.UUID       `gorm:"type:uuid;not null;index" json:"store_id"`
-	CategoryID  uuid.UUID       `gorm:"type:uuid;not null;index" json:"category_id"`
-	Name        string          `gorm:"not null" json:"name"`
-	Description string          `json:"description"`
-	Price       decimal.Decimal `gorm:"type:decimal(15,2);not null" json:"price"`
-	Stock       int             `gorm:"not null;default:0" json:"stock"`
-	ImageURL    string          `json:"image_url"`
-	CreatedAt   time.Time       `json:"created_at"`
-	UpdatedAt   time.Time       `json:"updated_at"`
+	ID                uuid.UUID       `gorm:"type:uuid;primaryKey;default:gen_random_uuid()" json:"id"`
+	StoreID           uuid.UUID       `gorm:"type:uuid;not null;index" json:"store_id"`
+	CategoryID        uuid.UUID       `gorm:"type:uuid;not null;index" json:"category_id"`
+	Name              string          `gorm:"not null" json:"name"`
+	Description       string          `json:"description"`
+	Price             decimal.Decimal `gorm:"type:decimal(15,2);not null" json:"price"`
+	Stock             int             `gorm:"not null;default:0" json:"stock"`
+	ImageURL          string          `json:"image_url"`
+	ThumbnailURL      string          `json:"thumbnail_url"`
+	LowStockThreshold *int            `json:"low_stock_threshold"`
+	AllowBackorder    bool            `gorm:"not null;default:false" json:"allow_backorder"`
+	// Unavailable marks a deleted product kept only because carts still
+	// held it; listings, lookups and checkout treat it as gone. The row is
+	// never removed.
+	Unavailable bool      `gorm:"not null;default:false" json:"unavailable"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
 
-	Store    Store    `gorm:"foreignKey:StoreID" json:"-"`
-	Category Category `gorm:"foreignKey:CategoryID" json:"-"`
+	Store      Store              `gorm:"foreignKey:StoreID" json:"-"`
+	Category   Category           `gorm:"foreignKey:CategoryID" json:"-"`
+	Attributes []ProductAttribute `gorm:"foreignKey:ProductID" json:"attributes,omitempty"`
 }
 
 type CreateProductRequest struct {
-	CategoryID  string `json:"category_id"`
-	Name        string `json:"name"`
-	Description string `json:"description"`
-	Price       string `json:"price"`
-	Stock       int    `json:"stock"`
+	CategoryID        string `json:"category_id" validate:"required,uuid"`
+	Name              string `json:"name" validate:"required"`
+	Description       string `json:"description"`
+	Price             string `json:"price" validate:"required"`
+	Stock             int    `json:"stock" validate:"min=0"`
+	LowStockThreshold *int   `json:"low_stock_threshold" validate:"omitempty,min=0"`
+	AllowBackorder    bool   `json:"allow_backorder"`
 }
 
 type UpdateProductRequest struct {
-	CategoryID  string `json:"category_id"`
-	Name        string `json:"name"`
-	Description string `json:"description"`
-	Price       string `json:"price"`
-	Stock       *int   `json:"stock"`
+	CategoryID        string `json:"category_id" validate:"omitempty,uuid"`
+	Name              string `json:"name"`
+	Description       string `json:"description"`
+	Price             string `json:"price"`
+	Stock             *int   `json:"stock" validate:"omitempty,min=0"`
+	LowStockThreshold *int   `json:"low_stock_threshold" validate:"omitempty,min=0"`
+	AllowBackorder    *bool  `json:"allow_backorder"`
 }
 
 type ProductFilter struct {
@@ -49,32 +61,73 @@ type ProductFilter struct {
 	SortOrder  string
 	Page       int
 	PerPage    int
+	// Cursor switches the listing to keyset pagination when set. It takes
+	// precedence over Page, and no total count is computed.
+	Cursor string
+	// IncludeSubcategories widens CategoryID to the category and all of its
+	// descendants instead of an exact match.
+	IncludeSubcategories bool
+	// Attributes keeps only products having every key with exactly the
+	// given value.
+	Attributes map[string]string
+	// ExcludeOwnerID, when set, drops the products of the store owned by
+	// that user; the service resolves it into ExcludeStoreID.
+	ExcludeOwnerID uuid.UUID `json:"-"`
+	ExcludeStoreID string
+	// ExcludeID drops a single product, e.g. the one related products are
+	// listed for.
+	ExcludeID string
+	// InStockFirst sorts out-of-stock products after in-stock ones ahead of
+	// SortBy. Cursor pagination ignores it.
+	InStockFirst bool
+	// IncludeInactiveStores keeps the products of deactivated stores and
+	// banned sellers, which public listings hide, for the owner's own view.
+	IncludeInactiveStores bool
+	// SkipCache bypasses the listing cache, for personalized requests.
+	SkipCache bool `json:"-"`
 }
 
 type ProductResponse struct {
-	ID          uuid.UUID       `json:"id"`
-	StoreID     uuid.UUID       `json:"store_id"`
-	CategoryID  uuid.UUID       `json:"category_id"`
-	Name        string          `json:"name"`
-	Description string          `json:"description"`
-	Price       decimal.Decimal `json:"price"`
-	Stock       int             `json:"stock"`
-	ImageURL    string          `json:"image_url"`
-	CreatedAt   time.Time       `json:"created_at"`
-	UpdatedAt   time.Time       `json:"updated_at"`
+	ID                uuid.UUID         `json:"id"`
+	StoreID           uuid.UUID         `json:"store_id"`
+	CategoryID        uuid.UUID         `json:"category_id"`
+	Name              string            `json:"name"`
+	Description       string            `json:"description"`
+	Price             decimal.Decimal   `json:"price"`
+	Stock             int               `json:"stock"`
+	ImageURL          string            `json:"image_url"`
+	ThumbnailURL      string            `json:"thumbnail_url"`
+	LowStockThreshold *int              `json:"low_stock_threshold"`
+	AllowBackorder    bool              `json:"allow_backorder"`
+	Attributes        map[string]string `json:"attributes"`
+	CreatedAt         time.Time         `json:"created_at"`
+	UpdatedAt         time.Time         `json:"updated_at"`
+}
+
+// AttributeMap returns the product's attributes keyed by name.
+func (p *Product) AttributeMap() map[string]string {
+	attrs := make(map[string]string, len(p.Attributes))
+	for _, a := range p.Attributes {
+		attrs[a.Key] = a.Value
+	}
+	return attrs
 }
 
 func (p *Product) ToResponse() ProductResponse {
 	return ProductResponse{
-		ID:          p.ID,
-		StoreID:     p.StoreID,
-		CategoryID:  p.CategoryID,
-		Name:        p.Name,
-		Description: p.Description,
-		Price:       p.Price,
-		Stock:       p.Stock,
-		ImageURL:    p.ImageURL,
-		CreatedAt:   p.CreatedAt,
-		UpdatedAt:   p.UpdatedAt,
+		ID:                p.ID,
+		StoreID:           p.StoreID,
+		CategoryID:        p.CategoryID,
+		Name:              p.Name,
+		Description:       p.Description,
+		Price:             p.Price,
+		Stock:             p.Stock,
+		ImageURL:          p.ImageURL,
+		ThumbnailURL:      p.ThumbnailURL,
+		LowStockThreshold: p.LowStockThreshold,
+		AllowBackorder:    p.AllowBackorder,
+		Attributes:        p.AttributeMap(),
+		CreatedAt:         p.CreatedAt,
+		UpdatedAt:         p.UpdatedAt,
 	}
 }
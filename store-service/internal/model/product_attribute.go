@@ -0,0 +1,27 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	MaxAttributeKeyLength   = 50
+	MaxAttributeValueLength = 255
+)
+
+// ProductAttribute is one structured spec of a product, such as color=red.
+// Keys are unique per product.
+type ProductAttribute struct {
+	ID        uuid.UUID `gorm:"type:uuid;primaryKey;default:gen_random_uuid()" json:"id"`
+	ProductID uuid.UUID `gorm:"type:uuid;not null;index" json:"product_id"`
+	Key       string    `gorm:"not null" json:"key"`
+	Value     string    `gorm:"not null" json:"value"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// SetProductAttributesRequest replaces all attributes of a product.
+type SetProductAttributesRequest struct {
+	Attributes map[string]string `json:"attributes"`
+}
@@ -0,0 +1,26 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ProductImage is one image of a product. Images are shown in SortOrder and
+// exactly one of a product's images is primary; the product's ImageURL and
+// ThumbnailURL mirror the primary image.
+type ProductImage struct {
+	ID           uuid.UUID `gorm:"type:uuid;primaryKey;default:gen_random_uuid()" json:"id"`
+	ProductID    uuid.UUID `gorm:"type:uuid;not null;index" json:"product_id"`
+	URL          string    `gorm:"not null" json:"url"`
+	ThumbnailURL string    `json:"thumbnail_url"`
+	SortOrder    int       `gorm:"not null;default:0" json:"sort_order"`
+	IsPrimary    bool      `gorm:"not null;default:false" json:"is_primary"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+// ReorderProductImagesRequest lists every image of a product in its new
+// order; the first one becomes the primary image.
+type ReorderProductImagesRequest struct {
+	ImageIDs []string `json:"image_ids"`
+}
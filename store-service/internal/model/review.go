@@ -6,17 +6,25 @@ import (
 	"github.com/google/uuid"
 )
 
+// Review is one buyer's rating of a product. PurchaseSeq numbers the buyer's
+// reviews of that product; it stays 1 unless repeat purchases may be
+// reviewed again.
 type Review struct {
-	ID        uuid.UUID `gorm:"type:uuid;primaryKey;default:gen_random_uuid()" json:"id"`
-	UserID    uuid.UUID `gorm:"type:uuid;not null" json:"user_id"`
-	ProductID uuid.UUID `gorm:"type:uuid;not null" json:"product_id"`
-	Rating    int       `gorm:"not null" json:"rating"`
-	Comment   string    `json:"comment"`
-	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
-
-	User    User    `gorm:"foreignKey:UserID" json:"-"`
-	Product Product `gorm:"foreignKey:ProductID" json:"-"`
+	ID          uuid.UUID `gorm:"type:uuid;primaryKey;default:gen_random_uuid()" json:"id"`
+	UserID      uuid.UUID `gorm:"type:uuid;not null" json:"user_id"`
+	ProductID   uuid.UUID `gorm:"type:uuid;not null" json:"product_id"`
+	Rating      int       `gorm:"not null" json:"rating"`
+	Comment     string    `json:"comment"`
+	PurchaseSeq int       `gorm:"not null;default:1" json:"purchase_seq"`
+	// HelpfulCount is the number of helpful votes, filled in only by
+	// queries that select it.
+	HelpfulCount int64     `gorm:"->;-:migration" json:"helpful_count"`
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+
+	User    User         `gorm:"foreignKey:UserID" json:"-"`
+	Product Product      `gorm:"foreignKey:ProductID" json:"-"`
+	Reply   *ReviewReply `gorm:"foreignKey:ReviewID" json:"-"`
 }
 
 type CreateReviewRequest struct {
@@ -24,23 +32,47 @@ type CreateReviewRequest struct {
 	Comment string `json:"comment"`
 }
 
+type UpdateReviewRequest struct {
+	Rating  int    `json:"rating"`
+	Comment string `json:"comment"`
+}
+
+// ReviewFilter narrows and orders a product's reviews. Rating, when set,
+// keeps only reviews with that many stars.
+type ReviewFilter struct {
+	Rating    int
+	SortBy    string
+	SortOrder string
+	Page      int
+	PerPage   int
+}
+
 type ReviewResponse struct {
-	ID        uuid.UUID `json:"id"`
-	UserID    uuid.UUID `json:"user_id"`
-	UserName  string    `json:"user_name"`
-	ProductID uuid.UUID `json:"product_id"`
-	Rating    int       `json:"rating"`
-	Comment   string    `json:"comment"`
-	CreatedAt time.Time `json:"created_at"`
+	ID           uuid.UUID            `json:"id"`
+	UserID       uuid.UUID            `json:"user_id"`
+	UserName     string               `json:"user_name"`
+	ProductID    uuid.UUID            `json:"product_id"`
+	Rating       int                  `json:"rating"`
+	Comment      string               `json:"comment"`
+	HelpfulCount int64                `json:"helpful_count"`
+	Reply        *ReviewReplyResponse `json:"reply,omitempty"`
+	CreatedAt    time.Time            `json:"created_at"`
 }
 
 func (r *Review) ToResponse() ReviewResponse {
+	var reply *ReviewReplyResponse
+	if r.Reply != nil {
+		resp := r.Reply.ToResponse()
+		reply = &resp
+	}
 	return ReviewResponse{
-		ID:        r.ID,
-		UserID:    r.UserID,
-		ProductID: r.ProductID,
-		Rating:    r.Rating,
-		Comment:   r.Comment,
-		CreatedAt: r.CreatedAt,
+		ID:           r.ID,
+		UserID:       r.UserID,
+		ProductID:    r.ProductID,
+		Rating:       r.Rating,
+		Comment:      r.Comment,
+		HelpfulCount: r.HelpfulCount,
+		Reply:        reply,
+		CreatedAt:    r.CreatedAt,
 	}
 }
@@ -0,0 +1,37 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ReviewReply is the reviewed product's store answering a review. A review
+// has at most one reply; replying again replaces it.
+type ReviewReply struct {
+	ReviewID  uuid.UUID `gorm:"type:uuid;primaryKey" json:"review_id"`
+	StoreID   uuid.UUID `gorm:"type:uuid;not null" json:"store_id"`
+	Comment   string    `gorm:"not null" json:"comment"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+type ReplyReviewRequest struct {
+	Comment string `json:"comment"`
+}
+
+type ReviewReplyResponse struct {
+	StoreID   uuid.UUID `json:"store_id"`
+	Comment   string    `json:"comment"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+func (r *ReviewReply) ToResponse() ReviewReplyResponse {
+	return ReviewReplyResponse{
+		StoreID:   r.StoreID,
+		Comment:   r.Comment,
+		CreatedAt: r.CreatedAt,
+		UpdatedAt: r.UpdatedAt,
+	}
+}
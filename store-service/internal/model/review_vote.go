@@ -0,0 +1,20 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ReviewVote is one user marking a review as helpful. A user has at most one
+// vote per review.
+type ReviewVote struct {
+	ReviewID  uuid.UUID `gorm:"type:uuid;primaryKey" json:"review_id"`
+	UserID    uuid.UUID `gorm:"type:uuid;primaryKey" json:"user_id"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type HelpfulVoteResponse struct {
+	ReviewID     uuid.UUID `json:"review_id"`
+	HelpfulCount int64     `json:"helpful_count"`
+}
@@ -0,0 +1,26 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// StockMovement records one change to a product's stock. Delta is signed,
+// StockAfter is the stock the change left, and Reason is one of the
+// constant.StockReason* values. ActorID is nil for guest checkouts and
+// system changes; OrderID is set for changes made by an order.
+type StockMovement struct {
+	ID         uuid.UUID  `gorm:"type:uuid;primaryKey;default:gen_random_uuid()" json:"id"`
+	ProductID  uuid.UUID  `gorm:"type:uuid;not null;index" json:"product_id"`
+	Reason     string     `gorm:"not null" json:"reason"`
+	Delta      int        `gorm:"not null" json:"delta"`
+	StockAfter int        `gorm:"not null" json:"stock_after"`
+	ActorID    *uuid.UUID `gorm:"type:uuid" json:"actor_id"`
+	OrderID    *uuid.UUID `gorm:"type:uuid" json:"order_id"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+func (StockMovement) TableName() string {
+	return "stock_movements"
+}
@@ -0,0 +1,24 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// StockReservation holds the stock taken by an order awaiting payment. A
+// successful payment commits it; once it expires unpaid the reservation
+// reaper releases it, cancelling the order and putting the stock back.
+type StockReservation struct {
+	OrderID   uuid.UUID `gorm:"type:uuid;primaryKey" json:"order_id"`
+	Status    string    `gorm:"not null;default:active" json:"status"`
+	ExpiresAt time.Time `gorm:"not null" json:"expires_at"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+const (
+	ReservationStatusActive    = "active"
+	ReservationStatusCommitted = "committed"
+	ReservationStatusReleased  = "released"
+)
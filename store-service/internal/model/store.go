@@ -4,21 +4,32 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
 )
 
 type Store struct {
-	ID          uuid.UUID `gorm:"type:uuid;primaryKey;default:gen_random_uuid()" json:"id"`
-	UserID      uuid.UUID `gorm:"type:uuid;uniqueIndex;not null" json:"user_id"`
-	Name        string    `gorm:"not null" json:"name"`
-	Description string    `json:"description"`
-	LogoURL     string    `json:"logo_url"`
-	CreatedAt   time.Time `json:"created_at"`
-	UpdatedAt   time.Time `json:"updated_at"`
+	ID           uuid.UUID `gorm:"type:uuid;primaryKey;default:gen_random_uuid()" json:"id"`
+	UserID       uuid.UUID `gorm:"type:uuid;uniqueIndex;not null" json:"user_id"`
+	Name         string    `gorm:"not null" json:"name"`
+	Description  string    `json:"description"`
+	LogoURL      string    `json:"logo_url"`
+	ThumbnailURL string    `json:"thumbnail_url"`
+	// Active is cleared by an admin to hide the store's products from
+	// public listings.
+	Active    bool      `gorm:"not null;default:true" json:"active"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
 
 	User     User      `gorm:"foreignKey:UserID" json:"-"`
 	Products []Product `gorm:"foreignKey:StoreID" json:"-"`
 }
 
+// StoreLogo is the pair of uploaded files behind a store's logo.
+type StoreLogo struct {
+	URL          string
+	ThumbnailURL string
+}
+
 type CreateStoreRequest struct {
 	Name        string `json:"name"`
 	Description string `json:"description"`
@@ -29,24 +40,54 @@ type UpdateStoreRequest struct {
 	Description string `json:"description"`
 }
 
+type UpdateStoreStatusRequest struct {
+	Active *bool `json:"active"`
+}
+
 type StoreResponse struct {
-	ID          uuid.UUID `json:"id"`
-	UserID      uuid.UUID `json:"user_id"`
-	Name        string    `json:"name"`
-	Description string    `json:"description"`
-	LogoURL     string    `json:"logo_url"`
-	CreatedAt   time.Time `json:"created_at"`
-	UpdatedAt   time.Time `json:"updated_at"`
+	ID           uuid.UUID `json:"id"`
+	UserID       uuid.UUID `json:"user_id"`
+	Name         string    `json:"name"`
+	Description  string    `json:"description"`
+	LogoURL      string    `json:"logo_url"`
+	ThumbnailURL string    `json:"thumbnail_url"`
+	Active       bool      `json:"active"`
+	// AvgProcessingTime is the store's average seconds from payment to the
+	// seller starting to process an order. It is only filled on the store
+	// detail and is null while the store has no processed orders.
+	AvgProcessingTime *int64    `json:"avg_processing_time"`
+	CreatedAt         time.Time `json:"created_at"`
+	UpdatedAt         time.Time `json:"updated_at"`
 }
 
 func (s *Store) ToResponse() StoreResponse {
 	return StoreResponse{
-		ID:          s.ID,
-		UserID:      s.UserID,
-		Name:        s.Name,
-		Description: s.Description,
-		LogoURL:     s.LogoURL,
-		CreatedAt:   s.CreatedAt,
-		UpdatedAt:   s.UpdatedAt,
+		ID:           s.ID,
+		UserID:       s.UserID,
+		Name:         s.Name,
+		Description:  s.Description,
+		LogoURL:      s.LogoURL,
+		ThumbnailURL: s.ThumbnailURL,
+		Active:       s.Active,
+		CreatedAt:    s.CreatedAt,
+		UpdatedAt:    s.UpdatedAt,
 	}
 }
+
+type SellerStatsResponse struct {
+	TotalProducts      int64           `json:"total_products"`
+	TotalOrders        int64           `json:"total_orders"`
+	Revenue            decimal.Decimal `json:"revenue"`
+	PendingFulfillment int64           `json:"pending_fulfillment"`
+}
+
+// SellerCommissionResponse reports a seller's completed sales for a period
+// and how they split between the platform and the seller.
+type SellerCommissionResponse struct {
+	From       string          `json:"from"`
+	To         string          `json:"to"`
+	GrossSales decimal.Decimal `json:"gross_sales"`
+	FeePercent decimal.Decimal `json:"fee_percent"`
+	Commission decimal.Decimal `json:"commission"`
+	NetPayout  decimal.Decimal `json:"net_payout"`
+}
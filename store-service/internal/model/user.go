@@ -1,34 +1,79 @@
 package model
 
 import (
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
 )
 
 type User struct {
-	ID        uuid.UUID `gorm:"type:uuid;primaryKey;default:gen_random_uuid()" json:"id"`
-	Email     string    `gorm:"uniqueIndex;not null" json:"email"`
-	Password  string    `gorm:"not null" json:"-"`
-	Name      string    `gorm:"not null" json:"name"`
-	Role      string    `gorm:"not null;default:buyer" json:"role"`
-	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
+	ID       uuid.UUID `gorm:"type:uuid;primaryKey;default:gen_random_uuid()" json:"id"`
+	Email    string    `gorm:"uniqueIndex;not null" json:"email"`
+	Password string    `gorm:"not null" json:"-"`
+	Name     string    `gorm:"not null" json:"name"`
+	Role     string    `gorm:"not null;default:buyer" json:"role"`
+	// Active is cleared by an admin to ban the user; their tokens stop
+	// working and they cannot log in.
+	Active bool `gorm:"not null;default:true" json:"active"`
+	// PasswordChangedAt is set by a password change; refresh tokens issued
+	// before it are rejected.
+	PasswordChangedAt *time.Time `json:"-"`
+	CreatedAt         time.Time  `json:"created_at"`
+	UpdatedAt         time.Time  `json:"updated_at"`
+}
+
+// NormalizeEmail is the form emails are stored and looked up in, so the same
+// address registers and logs in as one account whatever its case.
+func NormalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
 }
 
 type RegisterRequest struct {
-	Email    string `json:"email"`
-	Password string `json:"password"`
-	Name     string `json:"name"`
+	Email    string `json:"email" validate:"required,email_address"`
+	Password string `json:"password" validate:"required"`
+	Name     string `json:"name" validate:"required"`
 }
 
 type LoginRequest struct {
-	Email    string `json:"email"`
-	Password string `json:"password"`
+	Email    string `json:"email" validate:"required"`
+	Password string `json:"password" validate:"required"`
+}
+
+type ChangePasswordRequest struct {
+	CurrentPassword string `json:"current_password" validate:"required"`
+	NewPassword     string `json:"new_password" validate:"required"`
+}
+
+type ForgotPasswordRequest struct {
+	Email string `json:"email" validate:"required"`
+}
+
+type ResetPasswordRequest struct {
+	Email       string `json:"email" validate:"required"`
+	Token       string `json:"token" validate:"required"`
+	NewPassword string `json:"new_password" validate:"required"`
 }
 
 type RefreshRequest struct {
-	RefreshToken string `json:"refresh_token"`
+	RefreshToken string `json:"refresh_token" validate:"required"`
+}
+
+// UserFilter narrows the admin user listing. Email matches any part of the
+// address, case-insensitively.
+type UserFilter struct {
+	Role    string
+	Email   string
+	Page    int
+	PerPage int
+}
+
+type UpdateUserRoleRequest struct {
+	Role string `json:"role"`
+}
+
+type UpdateUserStatusRequest struct {
+	Active *bool `json:"active"`
 }
 
 type UserResponse struct {
@@ -36,6 +81,7 @@ type UserResponse struct {
 	Email     string    `json:"email"`
 	Name      string    `json:"name"`
 	Role      string    `json:"role"`
+	Active    bool      `json:"active"`
 	CreatedAt time.Time `json:"created_at"`
 	UpdatedAt time.Time `json:"updated_at"`
 }
@@ -46,6 +92,7 @@ func (u *User) ToResponse() UserResponse {
 		Email:     u.Email,
 		Name:      u.Name,
 		Role:      u.Role,
+		Active:    u.Active,
 		CreatedAt: u.CreatedAt,
 		UpdatedAt: u.UpdatedAt,
 	}
@@ -3,10 +3,13 @@ package nsq
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"time"
 
+	"github.com/1tsndre/mini-go-project/pkg/events"
 	"github.com/1tsndre/mini-go-project/pkg/logger"
 	"github.com/1tsndre/mini-go-project/store-service/internal/constant"
+	"github.com/1tsndre/mini-go-project/store-service/internal/repository"
 	"github.com/1tsndre/mini-go-project/store-service/internal/service"
 	"github.com/google/uuid"
 	"github.com/nsqio/go-nsq"
@@ -14,22 +17,45 @@ import (
 
 type PaymentResultConsumer struct {
 	orderService    service.OrderService
+	processed       repository.ProcessedEventRepository
+	producer        service.Publisher
+	maxAttempts     uint16
 	successConsumer *nsq.Consumer
 	failedConsumer  *nsq.Consumer
 }
 
-func NewPaymentResultConsumer(orderService service.OrderService) *PaymentResultConsumer {
-	return &PaymentResultConsumer{orderService: orderService}
+// deadLetter is what gets published to the DLQ topic: the original message
+// body plus enough context to replay or investigate it.
+type deadLetter struct {
+	Topic    string `json:"topic"`
+	Body     string `json:"body"`
+	Attempts uint16 `json:"attempts"`
+	Error    string `json:"error"`
+}
+
+// NewPaymentResultConsumer creates the consumer. A message is retried until
+// it has been attempted maxAttempts times, then dead-lettered via producer.
+// Events already recorded in processed are acknowledged without handling.
+func NewPaymentResultConsumer(orderService service.OrderService, processed repository.ProcessedEventRepository, producer service.Publisher, maxAttempts uint16) *PaymentResultConsumer {
+	return &PaymentResultConsumer{
+		orderService: orderService,
+		processed:    processed,
+		producer:     producer,
+		maxAttempts:  maxAttempts,
+	}
 }
 
 func (c *PaymentResultConsumer) Start(lookupdAddr string) error {
+	// Attempts are bounded by the handler so exhausted messages reach the
+	// DLQ; go-nsq's own cap would silently drop them instead.
 	successCfg := nsq.NewConfig()
+	successCfg.MaxAttempts = 0
 	successConsumer, err := nsq.NewConsumer(constant.TopicPaymentSuccess, constant.ChannelStoreService, successCfg)
 	if err != nil {
 		return err
 	}
 	successConsumer.AddHandler(nsq.HandlerFunc(func(message *nsq.Message) error {
-		return c.handlePaymentResult(message, true)
+		return c.handleMessage(message, constant.TopicPaymentSuccess)
 	}))
 	if err := successConsumer.ConnectToNSQLookupd(lookupdAddr); err != nil {
 		return err
@@ -37,13 +63,14 @@ func (c *PaymentResultConsumer) Start(lookupdAddr string) error {
 	c.successConsumer = successConsumer
 
 	failedCfg := nsq.NewConfig()
+	failedCfg.MaxAttempts = 0
 	failedConsumer, err := nsq.NewConsumer(constant.TopicPaymentFailed, constant.ChannelStoreService, failedCfg)
 	if err != nil {
 		successConsumer.Stop()
 		return err
 	}
 	failedConsumer.AddHandler(nsq.HandlerFunc(func(message *nsq.Message) error {
-		return c.handlePaymentResult(message, false)
+		return c.handleMessage(message, constant.TopicPaymentFailed)
 	}))
 	if err := failedConsumer.ConnectToNSQLookupd(lookupdAddr); err != nil {
 		successConsumer.Stop()
@@ -56,38 +83,130 @@ func (c *PaymentResultConsumer) Start(lookupdAddr string) error {
 	return nil
 }
 
-func (c *PaymentResultConsumer) Stop() {
-	ctx := context.Background()
-	if c.successConsumer != nil {
-		c.successConsumer.Stop()
+// Stop stops both consumers and waits until their in-flight messages are
+// handled, or until ctx is done.
+func (c *PaymentResultConsumer) Stop(ctx context.Context) {
+	consumers := []*nsq.Consumer{c.successConsumer, c.failedConsumer}
+	for _, consumer := range consumers {
+		if consumer != nil {
+			consumer.Stop()
+		}
 	}
-	if c.failedConsumer != nil {
-		c.failedConsumer.Stop()
+	for _, consumer := range consumers {
+		if consumer != nil && !waitStopped(ctx, consumer) {
+			logger.Warn(ctx, "NSQ payment result consumers did not stop in time")
+			return
+		}
 	}
 	logger.Info(ctx, "NSQ payment result consumers stopped")
 }
 
-func (c *PaymentResultConsumer) handlePaymentResult(message *nsq.Message, success bool) error {
-	var payload struct {
-		OrderID string `json:"order_id"`
+// waitStopped waits for consumer, already told to stop, to finish its
+// in-flight messages. It reports false if ctx is done first.
+func waitStopped(ctx context.Context, consumer *nsq.Consumer) bool {
+	select {
+	case <-consumer.StopChan:
+		return true
+	case <-ctx.Done():
+		return false
 	}
-	if err := json.Unmarshal(message.Body, &payload); err != nil {
-		ctx := context.Background()
-		logger.Error(ctx, "failed to unmarshal payment result, skipping", err)
+}
+
+// permanentError marks a message that will never succeed, so it skips the
+// remaining retries and goes straight to the DLQ.
+type permanentError struct{ msg string }
+
+func (e permanentError) Error() string { return e.msg }
+
+// handleMessage processes a payment result and decides its fate. Transient
+// failures are returned to NSQ for requeue until maxAttempts is reached;
+// permanent failures and exhausted messages are dead-lettered and finished.
+func (c *PaymentResultConsumer) handleMessage(message *nsq.Message, topic string) error {
+	err := c.handlePaymentResult(message, topic == constant.TopicPaymentSuccess)
+	if err == nil {
 		return nil
 	}
 
+	_, permanent := err.(permanentError)
+	if !permanent && message.Attempts < c.maxAttempts {
+		return err
+	}
+
+	ctx := context.Background()
+	logger.Error(ctx, "payment result failed, moving to dead-letter queue", err, map[string]interface{}{
+		"topic":     topic,
+		"attempts":  message.Attempts,
+		"permanent": permanent,
+	})
+
+	body, _ := json.Marshal(deadLetter{
+		Topic:    topic,
+		Body:     string(message.Body),
+		Attempts: message.Attempts,
+		Error:    err.Error(),
+	})
+	if pubErr := c.producer.Publish(constant.TopicPaymentResultDLQ, body); pubErr != nil {
+		// Requeue rather than lose the message; it will be dead-lettered
+		// again on the next attempt.
+		logger.Error(ctx, "failed to publish payment result to dead-letter queue", pubErr, map[string]interface{}{
+			"topic": topic,
+		})
+		return pubErr
+	}
+	return nil
+}
+
+func (c *PaymentResultConsumer) handlePaymentResult(message *nsq.Message, success bool) error {
+	var payload events.PaymentResult
+	env, err := events.Unmarshal(message.Body, &payload)
+	if err != nil {
+		return permanentError{msg: "invalid payment result payload: " + err.Error()}
+	}
+
 	orderID, err := uuid.Parse(payload.OrderID)
 	if err != nil {
-		ctx := context.Background()
-		logger.Error(ctx, "invalid order_id in payment result, skipping", err, map[string]interface{}{
+		return permanentError{msg: "invalid order_id in payment result: " + payload.OrderID}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	// Legacy results have no event id; the order status guard alone keeps
+	// them from being applied twice.
+	var done bool
+	if env.ID != "" {
+		done, err = c.processed.Processed(ctx, constant.ChannelStoreService, env.ID)
+	}
+	if err != nil {
+		// ProcessPaymentResult ignores results for orders that are no
+		// longer pending, so handling a possible duplicate is safe.
+		logger.Warn(ctx, "failed to check payment result for duplicates, processing anyway", map[string]interface{}{
+			"event_id": env.ID,
+			"error":    err.Error(),
+		})
+	} else if done {
+		logger.Info(ctx, "duplicate payment result skipped", map[string]interface{}{
+			"event_id": env.ID,
 			"order_id": payload.OrderID,
 		})
 		return nil
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
-	defer cancel()
+	if err := c.orderService.ProcessPaymentResult(ctx, orderID, success); err != nil {
+		if errors.Is(err, service.ErrNotFound) {
+			return permanentError{msg: err.Error()}
+		}
+		return err
+	}
 
-	return c.orderService.ProcessPaymentResult(ctx, orderID, success)
+	if env.ID == "" {
+		return nil
+	}
+	if err := c.processed.MarkProcessed(ctx, constant.ChannelStoreService, env.ID); err != nil {
+		logger.Warn(ctx, "failed to mark payment result processed", map[string]interface{}{
+			"event_id": env.ID,
+			"error":    err.Error(),
+		})
+	}
+	return nil
 }
@@ -0,0 +1,267 @@
+package nsq
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/1tsndre/mini-go-project/pkg/events"
+	"github.com/1tsndre/mini-go-project/store-service/internal/constant"
+	"github.com/1tsndre/mini-go-project/store-service/internal/service"
+	"github.com/google/uuid"
+	"github.com/nsqio/go-nsq"
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeOrderService struct {
+	service.OrderService
+	err   error
+	calls int
+}
+
+func (f *fakeOrderService) ProcessPaymentResult(context.Context, uuid.UUID, bool) error {
+	f.calls++
+	return f.err
+}
+
+func (f *fakeOrderService) CancelUnpaidOrder(context.Context, uuid.UUID) error {
+	f.calls++
+	return f.err
+}
+
+type fakePublisher struct {
+	err       error
+	published map[string][][]byte
+}
+
+func (f *fakePublisher) Publish(topic string, body []byte) error {
+	if f.err != nil {
+		return f.err
+	}
+	if f.published == nil {
+		f.published = make(map[string][][]byte)
+	}
+	f.published[topic] = append(f.published[topic], body)
+	return nil
+}
+
+// memoryProcessedEvents is an in-process repository.ProcessedEventRepository.
+type memoryProcessedEvents struct {
+	err  error
+	done map[string]bool
+}
+
+func (m *memoryProcessedEvents) Processed(_ context.Context, consumer, eventID string) (bool, error) {
+	if m.err != nil {
+		return false, m.err
+	}
+	return m.done[consumer+":"+eventID], nil
+}
+
+func (m *memoryProcessedEvents) MarkProcessed(_ context.Context, consumer, eventID string) error {
+	if m.err != nil {
+		return m.err
+	}
+	if m.done == nil {
+		m.done = make(map[string]bool)
+	}
+	m.done[consumer+":"+eventID] = true
+	return nil
+}
+
+func newFakeMessage(body string, attempts uint16) *nsq.Message {
+	msg := nsq.NewMessage(nsq.MessageID{}, []byte(body))
+	msg.Attempts = attempts
+	return msg
+}
+
+func mustMarshal(t *testing.T, event events.Event) []byte {
+	t.Helper()
+	data, err := events.Marshal(event)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+func TestPaymentResultConsumer_HandleMessage(t *testing.T) {
+	validBody := string(mustMarshal(t, events.PaymentResult{OrderID: uuid.NewString(), Success: true}))
+
+	tests := []struct {
+		name          string
+		body          string
+		attempts      uint16
+		serviceErr    error
+		publishErr    error
+		wantErr       bool
+		wantDLQ       bool
+		wantProcessed bool
+	}{
+		{
+			name:          "success finishes the message",
+			body:          validBody,
+			attempts:      1,
+			wantProcessed: true,
+		},
+		{
+			name:          "transient error below threshold is requeued",
+			body:          validBody,
+			attempts:      2,
+			serviceErr:    errors.New("failed to fetch order"),
+			wantErr:       true,
+			wantProcessed: true,
+		},
+		{
+			name:          "transient error at threshold is dead-lettered",
+			body:          validBody,
+			attempts:      3,
+			serviceErr:    errors.New("failed to fetch order"),
+			wantDLQ:       true,
+			wantProcessed: true,
+		},
+		{
+			name:          "missing order is dead-lettered on first attempt",
+			body:          validBody,
+			attempts:      1,
+			serviceErr:    fmt.Errorf("loading order: %w", service.ErrNotFound),
+			wantDLQ:       true,
+			wantProcessed: true,
+		},
+		{
+			name:     "malformed payload is dead-lettered without processing",
+			body:     `not json`,
+			attempts: 1,
+			wantDLQ:  true,
+		},
+		{
+			name:     "invalid order id is dead-lettered without processing",
+			body:     string(mustMarshal(t, events.PaymentResult{OrderID: "abc"})),
+			attempts: 1,
+			wantDLQ:  true,
+		},
+		{
+			name:          "legacy bare payload is processed",
+			body:          `{"order_id":"` + uuid.NewString() + `","success":true}`,
+			attempts:      1,
+			wantProcessed: true,
+		},
+		{
+			name:     "unsupported version is dead-lettered without processing",
+			body:     `{"event_type":"payment.result","version":99,"payload":{"order_id":"` + uuid.NewString() + `"}}`,
+			attempts: 1,
+			wantDLQ:  true,
+		},
+		{
+			name:          "dead-letter publish failure requeues",
+			body:          validBody,
+			attempts:      3,
+			serviceErr:    errors.New("failed to fetch order"),
+			publishErr:    errors.New("nsqd unavailable"),
+			wantErr:       true,
+			wantProcessed: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := &fakeOrderService{err: tt.serviceErr}
+			pub := &fakePublisher{err: tt.publishErr}
+			c := NewPaymentResultConsumer(svc, &memoryProcessedEvents{}, pub, 3)
+
+			err := c.handleMessage(newFakeMessage(tt.body, tt.attempts), constant.TopicPaymentSuccess)
+
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, tt.wantProcessed, svc.calls == 1)
+
+			dlq := pub.published[constant.TopicPaymentResultDLQ]
+			if !tt.wantDLQ {
+				assert.Empty(t, dlq)
+				return
+			}
+			if assert.Len(t, dlq, 1) {
+				var letter deadLetter
+				assert.NoError(t, json.Unmarshal(dlq[0], &letter))
+				assert.Equal(t, constant.TopicPaymentSuccess, letter.Topic)
+				assert.Equal(t, tt.body, letter.Body)
+				assert.Equal(t, tt.attempts, letter.Attempts)
+				assert.NotEmpty(t, letter.Error)
+			}
+		})
+	}
+}
+
+func TestPaymentResultConsumer_HandleMessage_Duplicate(t *testing.T) {
+	body := string(mustMarshal(t, events.PaymentResult{OrderID: uuid.NewString(), Success: true}))
+
+	tests := []struct {
+		name      string
+		processed *memoryProcessedEvents
+		wantCalls int
+	}{
+		{
+			name:      "same event id is processed once",
+			processed: &memoryProcessedEvents{},
+			wantCalls: 1,
+		},
+		{
+			name:      "dedupe store down processes both",
+			processed: &memoryProcessedEvents{err: errors.New("redis unavailable")},
+			wantCalls: 2,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := &fakeOrderService{}
+			c := NewPaymentResultConsumer(svc, tt.processed, &fakePublisher{}, 3)
+
+			assert.NoError(t, c.handleMessage(newFakeMessage(body, 1), constant.TopicPaymentSuccess))
+			assert.NoError(t, c.handleMessage(newFakeMessage(body, 1), constant.TopicPaymentSuccess))
+
+			assert.Equal(t, tt.wantCalls, svc.calls)
+		})
+	}
+}
+
+func TestPaymentResultConsumer_HandleMessage_RetryAfterFailure(t *testing.T) {
+	body := string(mustMarshal(t, events.PaymentResult{OrderID: uuid.NewString(), Success: true}))
+	svc := &fakeOrderService{err: errors.New("failed to fetch order")}
+	c := NewPaymentResultConsumer(svc, &memoryProcessedEvents{}, &fakePublisher{}, 3)
+
+	assert.Error(t, c.handleMessage(newFakeMessage(body, 1), constant.TopicPaymentSuccess))
+
+	svc.err = nil
+	assert.NoError(t, c.handleMessage(newFakeMessage(body, 2), constant.TopicPaymentSuccess))
+	assert.Equal(t, 2, svc.calls, "a failed attempt must not mark the event processed")
+}
+
+func TestPaymentResultConsumer_Stop_WaitsForConsumers(t *testing.T) {
+	success, err := nsq.NewConsumer(constant.TopicPaymentSuccess, constant.ChannelStoreService, nsq.NewConfig())
+	assert.NoError(t, err)
+	failed, err := nsq.NewConsumer(constant.TopicPaymentFailed, constant.ChannelStoreService, nsq.NewConfig())
+	assert.NoError(t, err)
+	noop := nsq.HandlerFunc(func(*nsq.Message) error { return nil })
+	success.AddHandler(noop)
+	failed.AddHandler(noop)
+	c := &PaymentResultConsumer{successConsumer: success, failedConsumer: failed}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	c.Stop(ctx)
+
+	for _, consumer := range []*nsq.Consumer{success, failed} {
+		select {
+		case <-consumer.StopChan:
+		default:
+			t.Fatal("Stop returned before the consumer stopped")
+		}
+	}
+	assert.NoError(t, ctx.Err())
+}
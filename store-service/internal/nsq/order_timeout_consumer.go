@@ -0,0 +1,85 @@
+package nsq
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/1tsndre/mini-go-project/pkg/logger"
+	"github.com/1tsndre/mini-go-project/store-service/internal/constant"
+	"github.com/1tsndre/mini-go-project/store-service/internal/service"
+	"github.com/google/uuid"
+	"github.com/nsqio/go-nsq"
+)
+
+// OrderTimeoutConsumer cancels orders still unpaid when their deferred
+// order.payment_timeout message arrives.
+type OrderTimeoutConsumer struct {
+	orderService service.OrderService
+	consumer     *nsq.Consumer
+}
+
+func NewOrderTimeoutConsumer(orderService service.OrderService) *OrderTimeoutConsumer {
+	return &OrderTimeoutConsumer{orderService: orderService}
+}
+
+func (c *OrderTimeoutConsumer) Start(lookupdAddr string) error {
+	consumer, err := nsq.NewConsumer(constant.TopicOrderPaymentTimeout, constant.ChannelStoreService, nsq.NewConfig())
+	if err != nil {
+		return err
+	}
+	consumer.AddHandler(nsq.HandlerFunc(c.handleMessage))
+	if err := consumer.ConnectToNSQLookupd(lookupdAddr); err != nil {
+		return err
+	}
+	c.consumer = consumer
+
+	logger.Info(context.Background(), "NSQ order timeout consumer started")
+	return nil
+}
+
+// Stop stops the consumer and waits until its in-flight messages are
+// handled, or until ctx is done.
+func (c *OrderTimeoutConsumer) Stop(ctx context.Context) {
+	if c.consumer != nil {
+		c.consumer.Stop()
+		if !waitStopped(ctx, c.consumer) {
+			logger.Warn(ctx, "NSQ order timeout consumer did not stop in time")
+			return
+		}
+	}
+	logger.Info(ctx, "NSQ order timeout consumer stopped")
+}
+
+// handleMessage finishes messages that can never succeed, a malformed body
+// or an order that is gone, and requeues anything else that fails.
+func (c *OrderTimeoutConsumer) handleMessage(message *nsq.Message) error {
+	ctx := context.Background()
+
+	var payload struct {
+		OrderID string `json:"order_id"`
+	}
+	if err := json.Unmarshal(message.Body, &payload); err != nil {
+		logger.Error(ctx, "invalid order timeout payload", err)
+		return nil
+	}
+	orderID, err := uuid.Parse(payload.OrderID)
+	if err != nil {
+		logger.Error(ctx, "invalid order_id in order timeout", err, map[string]interface{}{
+			"order_id": payload.OrderID,
+		})
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	if err := c.orderService.CancelUnpaidOrder(ctx, orderID); err != nil {
+		if errors.Is(err, service.ErrNotFound) {
+			return nil
+		}
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,66 @@
+package nsq
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/1tsndre/mini-go-project/store-service/internal/service"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOrderTimeoutConsumer_HandleMessage(t *testing.T) {
+	validBody := `{"order_id":"` + uuid.NewString() + `"}`
+
+	tests := []struct {
+		name          string
+		body          string
+		serviceErr    error
+		wantErr       bool
+		wantProcessed bool
+	}{
+		{
+			name:          "handled order finishes the message",
+			body:          validBody,
+			wantProcessed: true,
+		},
+		{
+			name:          "transient error is requeued",
+			body:          validBody,
+			serviceErr:    errors.New("failed to fetch order"),
+			wantErr:       true,
+			wantProcessed: true,
+		},
+		{
+			name:          "missing order finishes the message",
+			body:          validBody,
+			serviceErr:    fmt.Errorf("loading order: %w", service.ErrNotFound),
+			wantProcessed: true,
+		},
+		{
+			name: "malformed payload finishes without processing",
+			body: `not json`,
+		},
+		{
+			name: "invalid order id finishes without processing",
+			body: `{"order_id":"abc"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := &fakeOrderService{err: tt.serviceErr}
+			c := NewOrderTimeoutConsumer(svc)
+
+			err := c.handleMessage(newFakeMessage(tt.body, 1))
+
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, tt.wantProcessed, svc.calls == 1)
+		})
+	}
+}
@@ -0,0 +1,155 @@
+package openapi
+
+import (
+	"net/http"
+
+	"github.com/1tsndre/mini-go-project/pkg/jwt"
+	"github.com/1tsndre/mini-go-project/store-service/internal/model"
+	"github.com/google/uuid"
+)
+
+type access int
+
+const (
+	public access = iota
+	// optional routes work anonymously but tailor the response to a
+	// bearer token when one is sent.
+	optional
+	authenticated
+)
+
+// route documents a single pattern registered on the router. request and
+// response are zero values of the body types; the schemas are reflected from
+// them so the spec follows the model structs.
+type route struct {
+	summary  string
+	access   access
+	request  any
+	upload   string
+	response any
+	status   int
+	list     bool
+	csv      bool
+	// query lists the filter and sort parameters the handler reads beyond
+	// the pagination ones list adds.
+	query []Parameter
+}
+
+func queryParam(name string, schema *Schema) Parameter {
+	return Parameter{Name: name, In: "query", Schema: schema}
+}
+
+// productFilters are the query parameters of every product listing, read
+// by the product handler's productFilterFromQuery.
+var productFilters = []Parameter{
+	queryParam("search", &Schema{Type: "string"}),
+	queryParam("category_id", &Schema{Type: "string", Format: "uuid"}),
+	queryParam("include_subcategories", &Schema{Type: "boolean"}),
+	queryParam("min_price", &Schema{Type: "string", Format: "decimal"}),
+	queryParam("max_price", &Schema{Type: "string", Format: "decimal"}),
+	queryParam("sort_by", &Schema{Type: "string", Enum: []string{"created_at", "price", "name", "rating"}}),
+	queryParam("sort_order", &Schema{Type: "string", Enum: []string{"asc", "desc"}}),
+	queryParam("cursor", &Schema{Type: "string"}),
+}
+
+// productSearchFilters adds the parameters only the public search reads.
+var productSearchFilters = append([]Parameter{
+	queryParam("store_id", &Schema{Type: "string", Format: "uuid"}),
+	queryParam("exclude_own", &Schema{Type: "boolean"}),
+}, productFilters...)
+
+// MessageResponse is the data of endpoints that only confirm an action.
+type MessageResponse struct {
+	Message string `json:"message"`
+}
+
+// HealthResponse is the data of the health and probe endpoints.
+type HealthResponse struct {
+	Status string            `json:"status"`
+	Checks map[string]string `json:"checks,omitempty"`
+}
+
+// routes is keyed by the exact pattern passed to the router. A pattern
+// registered there without an entry here is still emitted, but without a
+// summary or schemas, which the router test rejects.
+var routes = map[string]route{
+	"GET /health":  {summary: "Health check", response: HealthResponse{}},
+	"GET /healthz": {summary: "Liveness probe", response: HealthResponse{}},
+	"GET /readyz":  {summary: "Readiness probe with per-dependency status", response: HealthResponse{}},
+
+	"POST /api/v1/auth/register":        {summary: "Register a buyer account", request: model.RegisterRequest{}, response: model.UserResponse{}, status: http.StatusCreated},
+	"POST /api/v1/auth/login":           {summary: "Log in with email and password", request: model.LoginRequest{}, response: jwt.TokenPair{}},
+	"POST /api/v1/auth/forgot-password": {summary: "Request a password reset token", request: model.ForgotPasswordRequest{}, response: MessageResponse{}},
+	"POST /api/v1/auth/reset-password":  {summary: "Reset a password with a reset token", request: model.ResetPasswordRequest{}, response: MessageResponse{}},
+	"POST /api/v1/auth/refresh":         {summary: "Exchange a refresh token for a new token pair", request: model.RefreshRequest{}, response: jwt.TokenPair{}},
+	"POST /api/v1/auth/change-password": {summary: "Change the current user's password", access: authenticated, request: model.ChangePasswordRequest{}, response: jwt.TokenPair{}},
+
+	"POST /api/v1/stores":                  {summary: "Open a store and become a seller", access: authenticated, request: model.CreateStoreRequest{}, response: model.StoreResponse{}, status: http.StatusCreated},
+	"GET /api/v1/stores/{id}":              {summary: "Get a store", response: model.StoreResponse{}},
+	"PUT /api/v1/stores/{id}":              {summary: "Update your store", access: authenticated, request: model.UpdateStoreRequest{}, response: model.StoreResponse{}},
+	"POST /api/v1/stores/{id}/logo":        {summary: "Upload your store logo", access: authenticated, upload: "logo", response: model.StoreResponse{}},
+	"GET /api/v1/stores/{id}/products":     {summary: "List a store's products", response: []model.ProductResponse{}, list: true, query: productFilters},
+	"PUT /api/v1/admin/stores/{id}/status": {summary: "Activate or deactivate a store", access: authenticated, request: model.UpdateStoreStatusRequest{}, response: model.StoreResponse{}},
+
+	"POST /api/v1/categories":            {summary: "Create a category", access: authenticated, request: model.CreateCategoryRequest{}, response: model.CategoryResponse{}, status: http.StatusCreated},
+	"GET /api/v1/categories":             {summary: "List categories, as a tree or paginated", response: []model.CategoryResponse{}, list: true},
+	"PUT /api/v1/categories/{id}":        {summary: "Update a category", access: authenticated, request: model.UpdateCategoryRequest{}, response: model.CategoryResponse{}},
+	"DELETE /api/v1/categories/{id}":     {summary: "Delete a category", access: authenticated, response: MessageResponse{}},
+	"POST /api/v1/admin/categories/bulk": {summary: "Create many categories at once", access: authenticated, request: model.BulkCreateCategoriesRequest{}, response: model.BulkCreateCategoriesResponse{}},
+
+	"POST /api/v1/products":                          {summary: "Create a product", access: authenticated, request: model.CreateProductRequest{}, response: model.ProductResponse{}, status: http.StatusCreated},
+	"GET /api/v1/products":                           {summary: "Search and list products", access: optional, response: []model.ProductResponse{}, list: true, query: productSearchFilters},
+	"GET /api/v1/products/{id}":                      {summary: "Get a product", response: model.ProductResponse{}},
+	"PUT /api/v1/products/{id}":                      {summary: "Update your product", access: authenticated, request: model.UpdateProductRequest{}, response: model.ProductResponse{}},
+	"DELETE /api/v1/products/{id}":                   {summary: "Delete your product", access: authenticated, response: MessageResponse{}},
+	"GET /api/v1/products/{id}/attributes":           {summary: "Get a product's attributes", response: map[string]string{}},
+	"PUT /api/v1/products/{id}/attributes":           {summary: "Replace your product's attributes", access: authenticated, request: model.SetProductAttributesRequest{}, response: map[string]string{}},
+	"GET /api/v1/products/{id}/also-bought":          {summary: "Products often bought together with this one", response: []model.ProductResponse{}},
+	"GET /api/v1/products/{id}/related":              {summary: "Products related to this one", response: []model.ProductResponse{}},
+	"POST /api/v1/products/{id}/image":               {summary: "Replace your product's primary image", access: authenticated, upload: "image", response: model.ProductResponse{}},
+	"GET /api/v1/products/{id}/images":               {summary: "List a product's images", response: []model.ProductImage{}},
+	"POST /api/v1/products/{id}/images":              {summary: "Add an image to your product", access: authenticated, upload: "image", response: model.ProductImage{}, status: http.StatusCreated},
+	"PUT /api/v1/products/{id}/images/order":         {summary: "Reorder your product's images", access: authenticated, request: model.ReorderProductImagesRequest{}, response: []model.ProductImage{}},
+	"DELETE /api/v1/products/{id}/images/{image_id}": {summary: "Delete an image from your product", access: authenticated, response: MessageResponse{}},
+	"GET /api/v1/seller/products":                    {summary: "List your store's products, including hidden ones", access: authenticated, response: []model.ProductResponse{}, list: true, query: productFilters},
+	"GET /api/v1/seller/products/export":             {summary: "Export your store's products as CSV", access: authenticated, csv: true},
+	"GET /api/v1/seller/products/{id}/stock-history": {summary: "List stock movements for your product", access: authenticated, response: []model.StockMovement{}, list: true},
+	"GET /api/v1/seller/stats":                       {summary: "Sales statistics for your store", access: authenticated, response: model.SellerStatsResponse{}},
+	"GET /api/v1/seller/commission":                  {summary: "Platform commission for your store over a period", access: authenticated, response: model.SellerCommissionResponse{}},
+
+	"POST /api/v1/products/{id}/reviews":  {summary: "Review a purchased product", access: authenticated, request: model.CreateReviewRequest{}, response: model.ReviewResponse{}, status: http.StatusCreated},
+	"PUT /api/v1/products/{id}/reviews":   {summary: "Update your review of a product", access: authenticated, request: model.UpdateReviewRequest{}, response: model.ReviewResponse{}},
+	"GET /api/v1/products/{id}/reviews":   {summary: "List a product's reviews", response: []model.ReviewResponse{}, list: true},
+	"DELETE /api/v1/reviews/{id}":         {summary: "Delete a review", access: authenticated, response: MessageResponse{}},
+	"POST /api/v1/reviews/{id}/reply":     {summary: "Reply to a review of your product", access: authenticated, request: model.ReplyReviewRequest{}, response: model.ReviewReplyResponse{}},
+	"POST /api/v1/reviews/{id}/helpful":   {summary: "Mark a review as helpful", access: authenticated, response: model.HelpfulVoteResponse{}},
+	"DELETE /api/v1/reviews/{id}/helpful": {summary: "Remove your helpful vote", access: authenticated, response: model.HelpfulVoteResponse{}},
+
+	"GET /api/v1/cart":                       {summary: "Get your cart", access: authenticated, response: model.CartResponse{}},
+	"POST /api/v1/cart/items":                {summary: "Add an item to your cart", access: authenticated, request: model.AddCartItemRequest{}, response: model.CartResponse{}},
+	"PUT /api/v1/cart/items/{product_id}":    {summary: "Change the quantity of a cart item", access: authenticated, request: model.UpdateCartItemRequest{}, response: model.CartResponse{}},
+	"DELETE /api/v1/cart/items/{product_id}": {summary: "Remove an item from your cart", access: authenticated, response: model.CartResponse{}},
+
+	"POST /api/v1/orders":                   {summary: "Check out your cart", access: authenticated, request: model.CheckoutRequest{}, response: model.OrderResponse{}, status: http.StatusCreated},
+	"GET /api/v1/orders":                    {summary: "List your orders", access: authenticated, response: []model.OrderResponse{}, list: true},
+	"POST /api/v1/orders/status-batch":      {summary: "Get the status of several of your orders", access: authenticated, request: model.OrderStatusBatchRequest{}, response: map[uuid.UUID]string{}},
+	"GET /api/v1/orders/{id}":               {summary: "Get one of your orders", access: authenticated, response: model.OrderResponse{}},
+	"GET /api/v1/orders/{id}/timeline":      {summary: "Status timeline of one of your orders", access: authenticated, response: []model.OrderTimelineEvent{}},
+	"GET /api/v1/orders/{id}/payment":       {summary: "Payment record of one of your orders", access: authenticated, response: model.PaymentResponse{}},
+	"PUT /api/v1/orders/{id}/cancel":        {summary: "Cancel one of your orders", access: authenticated, response: MessageResponse{}},
+	"PUT /api/v1/orders/{id}/refund":        {summary: "Refund one of your orders", access: authenticated, response: MessageResponse{}},
+	"PUT /api/v1/orders/{id}/status":        {summary: "Advance the status of an order for your store", access: authenticated, request: model.UpdateOrderStatusRequest{}, response: MessageResponse{}},
+	"GET /api/v1/seller/orders":             {summary: "List orders containing your products", access: authenticated, response: []model.OrderResponse{}, list: true},
+	"POST /api/v1/guest/orders":             {summary: "Check out as a guest", request: model.GuestCheckoutRequest{}, response: model.OrderResponse{}, status: http.StatusCreated},
+	"GET /api/v1/guest/orders/{id}":         {summary: "Look up a guest order with its lookup token", response: model.OrderResponse{}},
+	"GET /api/v1/admin/orders":              {summary: "List all orders", access: authenticated, response: []model.OrderResponse{}, list: true},
+	"PUT /api/v1/admin/orders/{id}/release": {summary: "Release an order held for review", access: authenticated, response: MessageResponse{}},
+
+	"GET /api/v1/admin/users":             {summary: "List users", access: authenticated, response: []model.UserResponse{}, list: true},
+	"GET /api/v1/admin/users/{id}":        {summary: "Get a user", access: authenticated, response: model.UserResponse{}},
+	"PUT /api/v1/admin/users/{id}/role":   {summary: "Change a user's role", access: authenticated, request: model.UpdateUserRoleRequest{}, response: model.UserResponse{}},
+	"PUT /api/v1/admin/users/{id}/status": {summary: "Activate or deactivate a user", access: authenticated, request: model.UpdateUserStatusRequest{}, response: model.UserResponse{}},
+
+	"GET /api/v1/announcements":        {summary: "List active announcements for your audience", access: optional, response: []model.Announcement{}},
+	"POST /api/v1/admin/announcements": {summary: "Publish an announcement", access: authenticated, request: model.CreateAnnouncementRequest{}, response: model.Announcement{}, status: http.StatusCreated},
+}
@@ -0,0 +1,72 @@
+package openapi
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+)
+
+type schemaBase struct {
+	ID uuid.UUID `json:"id"`
+}
+
+type schemaNode struct {
+	schemaBase
+	Price    decimal.Decimal `json:"price"`
+	At       *time.Time      `json:"at,omitempty"`
+	Hidden   string          `json:"-"`
+	Untagged int64
+	Labels   map[string]string `json:"labels"`
+	Children []schemaNode      `json:"children"`
+}
+
+func TestSchemas_Of(t *testing.T) {
+	s := schemas{}
+
+	ref := s.of(reflect.TypeFor[*schemaNode]())
+	if ref.Ref != "#/components/schemas/schemaNode" || ref.Nullable {
+		t.Fatalf("expected a plain $ref to schemaNode, got %+v", ref)
+	}
+
+	node := s["schemaNode"]
+	if node == nil {
+		t.Fatal("expected schemaNode to be registered as a component")
+	}
+	if _, ok := s["schemaBase"]; ok {
+		t.Error("embedded structs should be flattened, not registered")
+	}
+
+	tests := []struct {
+		name string
+		want Schema
+	}{
+		{"id", Schema{Type: "string", Format: "uuid"}},
+		{"price", Schema{Type: "string", Format: "decimal"}},
+		{"at", Schema{Type: "string", Format: "date-time", Nullable: true}},
+		{"Untagged", Schema{Type: "integer", Format: "int64"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := node.Properties[tt.name]
+			if got == nil {
+				t.Fatalf("property %q missing", tt.name)
+			}
+			if !reflect.DeepEqual(*got, tt.want) {
+				t.Errorf("expected %+v, got %+v", tt.want, *got)
+			}
+		})
+	}
+
+	if _, ok := node.Properties["Hidden"]; ok {
+		t.Error(`json:"-" fields should be skipped`)
+	}
+	if labels := node.Properties["labels"]; labels.AdditionalProperties == nil || labels.AdditionalProperties.Type != "string" {
+		t.Errorf("expected a string map for labels, got %+v", labels)
+	}
+	if children := node.Properties["children"]; children.Items == nil || children.Items.Ref != ref.Ref {
+		t.Errorf("expected children to reference schemaNode, got %+v", children)
+	}
+}
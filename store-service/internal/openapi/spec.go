@@ -0,0 +1,208 @@
+// Package openapi builds the OpenAPI 3 document for the store API from the
+// patterns registered on the router and the model structs they exchange.
+package openapi
+
+import (
+	"net/http"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/1tsndre/mini-go-project/pkg/response"
+)
+
+const Version = "3.0.3"
+
+type Document struct {
+	OpenAPI    string              `json:"openapi"`
+	Info       Info                `json:"info"`
+	Paths      map[string]PathItem `json:"paths"`
+	Components Components          `json:"components"`
+}
+
+type Info struct {
+	Title   string `json:"title"`
+	Version string `json:"version"`
+}
+
+// PathItem maps a lower-case HTTP method to its operation.
+type PathItem map[string]*Operation
+
+type Operation struct {
+	Tags        []string              `json:"tags,omitempty"`
+	Summary     string                `json:"summary,omitempty"`
+	Security    []map[string][]string `json:"security,omitempty"`
+	Parameters  []Parameter           `json:"parameters,omitempty"`
+	RequestBody *RequestBody          `json:"requestBody,omitempty"`
+	Responses   map[string]*Response  `json:"responses"`
+}
+
+type Parameter struct {
+	Name     string  `json:"name"`
+	In       string  `json:"in"`
+	Required bool    `json:"required,omitempty"`
+	Schema   *Schema `json:"schema"`
+}
+
+type RequestBody struct {
+	Required bool                 `json:"required"`
+	Content  map[string]MediaType `json:"content"`
+}
+
+type MediaType struct {
+	Schema *Schema `json:"schema"`
+}
+
+type Response struct {
+	Ref         string               `json:"$ref,omitempty"`
+	Description string               `json:"description,omitempty"`
+	Content     map[string]MediaType `json:"content,omitempty"`
+}
+
+type Components struct {
+	Schemas         map[string]*Schema        `json:"schemas"`
+	Responses       map[string]*Response      `json:"responses"`
+	SecuritySchemes map[string]SecurityScheme `json:"securitySchemes"`
+}
+
+type SecurityScheme struct {
+	Type         string `json:"type"`
+	Scheme       string `json:"scheme"`
+	BearerFormat string `json:"bearerFormat,omitempty"`
+}
+
+const (
+	contentTypeJSON = "application/json"
+	bearerAuth      = "bearerAuth"
+	errorResponse   = "Error"
+)
+
+var pathParam = regexp.MustCompile(`\{(\w+)\}`)
+
+// Build describes every "METHOD /path" pattern in patterns. Patterns without
+// a method, such as static file trees, are skipped.
+func Build(patterns []string) *Document {
+	s := schemas{}
+	doc := &Document{
+		OpenAPI: Version,
+		Info:    Info{Title: "Store API", Version: "1.0"},
+		Paths:   map[string]PathItem{},
+		Components: Components{
+			Schemas: s,
+			Responses: map[string]*Response{
+				errorResponse: {
+					Description: "Error envelope; errors lists one entry per problem, with field set for validation errors",
+					Content:     jsonContent(envelope(s, "errors", reflect.TypeFor[[]response.Error]())),
+				},
+			},
+			SecuritySchemes: map[string]SecurityScheme{
+				bearerAuth: {Type: "http", Scheme: "bearer", BearerFormat: "JWT"},
+			},
+		},
+	}
+
+	for _, pattern := range patterns {
+		method, path, ok := strings.Cut(pattern, " ")
+		if !ok {
+			continue
+		}
+		item, ok := doc.Paths[path]
+		if !ok {
+			item = PathItem{}
+			doc.Paths[path] = item
+		}
+		item[strings.ToLower(method)] = operation(s, path, routes[pattern])
+	}
+	return doc
+}
+
+func operation(s schemas, path string, rt route) *Operation {
+	op := &Operation{
+		Tags:    []string{tag(path)},
+		Summary: rt.summary,
+		Responses: map[string]*Response{
+			"default": {Ref: "#/components/responses/" + errorResponse},
+		},
+	}
+
+	switch rt.access {
+	case optional:
+		op.Security = []map[string][]string{{}, {bearerAuth: {}}}
+	case authenticated:
+		op.Security = []map[string][]string{{bearerAuth: {}}}
+	}
+
+	for _, m := range pathParam.FindAllStringSubmatch(path, -1) {
+		op.Parameters = append(op.Parameters, Parameter{
+			Name: m[1], In: "path", Required: true, Schema: &Schema{Type: "string"},
+		})
+	}
+	if rt.list {
+		op.Parameters = append(op.Parameters,
+			Parameter{Name: "page", In: "query", Schema: &Schema{Type: "integer"}},
+			Parameter{Name: "per_page", In: "query", Schema: &Schema{Type: "integer"}},
+		)
+	}
+	op.Parameters = append(op.Parameters, rt.query...)
+
+	switch {
+	case rt.request != nil:
+		op.RequestBody = &RequestBody{
+			Required: true,
+			Content:  jsonContent(s.of(reflect.TypeOf(rt.request))),
+		}
+	case rt.upload != "":
+		op.RequestBody = &RequestBody{
+			Required: true,
+			Content: map[string]MediaType{
+				"multipart/form-data": {Schema: &Schema{
+					Type:       "object",
+					Properties: map[string]*Schema{rt.upload: {Type: "string", Format: "binary"}},
+				}},
+			},
+		}
+	}
+
+	status := rt.status
+	if status == 0 {
+		status = http.StatusOK
+	}
+	success := &Response{Description: http.StatusText(status)}
+	switch {
+	case rt.csv:
+		success.Content = map[string]MediaType{"text/csv": {Schema: &Schema{Type: "string"}}}
+	case rt.response != nil:
+		success.Content = jsonContent(envelope(s, "data", reflect.TypeOf(rt.response)))
+	}
+	op.Responses[strconv.Itoa(status)] = success
+
+	return op
+}
+
+// envelope wraps the schema of t in the response.Response shape under key,
+// which is "data" for successes and "errors" for failures.
+func envelope(s schemas, key string, t reflect.Type) *Schema {
+	return &Schema{
+		Type: "object",
+		Properties: map[string]*Schema{
+			key:    s.of(t),
+			"meta": s.of(reflect.TypeFor[response.Meta]()),
+		},
+	}
+}
+
+func jsonContent(schema *Schema) map[string]MediaType {
+	return map[string]MediaType{contentTypeJSON: {Schema: schema}}
+}
+
+// tag groups operations by the first path segment after the version, e.g.
+// "products" for /api/v1/products/{id}; probes fall under "health".
+func tag(path string) string {
+	rest, ok := strings.CutPrefix(path, "/api/v1/")
+	if !ok {
+		return "health"
+	}
+	segment, _, _ := strings.Cut(rest, "/")
+	return segment
+}
@@ -0,0 +1,65 @@
+// Package password holds the strength rules new passwords must meet.
+package password
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Policy is the set of rules a new password must satisfy. The zero Policy
+// accepts any password.
+type Policy struct {
+	// MinLength is counted in characters, not bytes.
+	MinLength     int
+	RequireLetter bool
+	RequireDigit  bool
+	// RequireSymbol asks for a punctuation or symbol character.
+	RequireSymbol bool
+}
+
+// PolicyError lists every rule a password broke, each phrased to follow
+// the field name, e.g. "must contain a digit".
+type PolicyError struct {
+	Violations []string
+}
+
+func (e *PolicyError) Error() string {
+	return "password " + strings.Join(e.Violations, ", ")
+}
+
+// Validate returns a *PolicyError naming every rule password breaks, or nil
+// when it satisfies them all.
+func (p Policy) Validate(password string) error {
+	var hasLetter, hasDigit, hasSymbol bool
+	for _, r := range password {
+		switch {
+		case unicode.IsLetter(r):
+			hasLetter = true
+		case unicode.IsDigit(r):
+			hasDigit = true
+		case unicode.IsPunct(r) || unicode.IsSymbol(r):
+			hasSymbol = true
+		}
+	}
+
+	var violations []string
+	if utf8.RuneCountInString(password) < p.MinLength {
+		violations = append(violations, fmt.Sprintf("must be at least %d characters", p.MinLength))
+	}
+	if p.RequireLetter && !hasLetter {
+		violations = append(violations, "must contain a letter")
+	}
+	if p.RequireDigit && !hasDigit {
+		violations = append(violations, "must contain a digit")
+	}
+	if p.RequireSymbol && !hasSymbol {
+		violations = append(violations, "must contain a symbol")
+	}
+
+	if len(violations) > 0 {
+		return &PolicyError{Violations: violations}
+	}
+	return nil
+}
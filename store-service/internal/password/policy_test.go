@@ -0,0 +1,86 @@
+package password
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPolicy_Validate(t *testing.T) {
+	tests := []struct {
+		name           string
+		policy         Policy
+		password       string
+		wantViolations []string
+	}{
+		{name: "zero policy accepts anything", policy: Policy{}, password: ""},
+		{name: "min length met", policy: Policy{MinLength: 6}, password: "abcdef"},
+		{
+			name:           "min length not met",
+			policy:         Policy{MinLength: 6},
+			password:       "abcde",
+			wantViolations: []string{"must be at least 6 characters"},
+		},
+		{
+			name:           "length counts characters, not bytes",
+			policy:         Policy{MinLength: 6},
+			password:       "äöü",
+			wantViolations: []string{"must be at least 6 characters"},
+		},
+		{name: "letter required and present", policy: Policy{RequireLetter: true}, password: "1234a"},
+		{
+			name:           "letter required and missing",
+			policy:         Policy{RequireLetter: true},
+			password:       "123456",
+			wantViolations: []string{"must contain a letter"},
+		},
+		{name: "letter not required", policy: Policy{}, password: "123456"},
+		{name: "digit required and present", policy: Policy{RequireDigit: true}, password: "abc1"},
+		{
+			name:           "digit required and missing",
+			policy:         Policy{RequireDigit: true},
+			password:       "abcdef",
+			wantViolations: []string{"must contain a digit"},
+		},
+		{name: "digit not required", policy: Policy{}, password: "abcdef"},
+		{name: "symbol required and present", policy: Policy{RequireSymbol: true}, password: "abc!"},
+		{
+			name:           "symbol required and missing",
+			policy:         Policy{RequireSymbol: true},
+			password:       "abc123",
+			wantViolations: []string{"must contain a symbol"},
+		},
+		{name: "symbol not required", policy: Policy{}, password: "abc123"},
+		{
+			name:     "every broken rule is reported",
+			policy:   Policy{MinLength: 8, RequireLetter: true, RequireDigit: true, RequireSymbol: true},
+			password: "abc",
+			wantViolations: []string{
+				"must be at least 8 characters",
+				"must contain a digit",
+				"must contain a symbol",
+			},
+		},
+		{
+			name:     "all rules met",
+			policy:   Policy{MinLength: 8, RequireLetter: true, RequireDigit: true, RequireSymbol: true},
+			password: "s3cure-pass",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.policy.Validate(tt.password)
+
+			if tt.wantViolations == nil {
+				assert.NoError(t, err)
+				return
+			}
+			var policyErr *PolicyError
+			if assert.True(t, errors.As(err, &policyErr)) {
+				assert.Equal(t, tt.wantViolations, policyErr.Violations)
+			}
+		})
+	}
+}
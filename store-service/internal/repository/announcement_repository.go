@@ -0,0 +1,38 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/1tsndre/mini-go-project/store-service/internal/model"
+	"github.com/1tsndre/mini-go-project/store-service/internal/repository/databases"
+)
+
+type AnnouncementRepository interface {
+	Create(ctx context.Context, announcement *model.Announcement) error
+	FindActive(ctx context.Context, audiences []string, at time.Time) ([]model.Announcement, error)
+}
+
+type announcementRepository struct {
+	db databases.Database
+}
+
+func NewAnnouncementRepository(db databases.Database) AnnouncementRepository {
+	return &announcementRepository{db: db}
+}
+
+func (r *announcementRepository) Create(ctx context.Context, announcement *model.Announcement) error {
+	return databases.FromContext(ctx, r.db).Create(announcement).Error
+}
+
+// FindActive returns the announcements for any of audiences whose window
+// contains at, newest first.
+func (r *announcementRepository) FindActive(ctx context.Context, audiences []string, at time.Time) ([]model.Announcement, error) {
+	announcements := []model.Announcement{}
+	err := databases.FromContext(ctx, r.db).
+		Where("audience IN ?", audiences).
+		Where("starts_at <= ? AND (ends_at IS NULL OR ends_at > ?)", at, at).
+		Order("starts_at DESC, id").
+		Find(&announcements).Error
+	return announcements, err
+}
@@ -0,0 +1,27 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAnnouncementRepository_FindActive(t *testing.T) {
+	db := newDryRunDB(t)
+	repo := NewAnnouncementRepository(db)
+	at := time.Date(2026, 6, 1, 9, 0, 0, 0, time.UTC)
+
+	announcements, err := repo.FindActive(context.Background(), []string{"all", "sellers"}, at)
+
+	assert.NoError(t, err)
+	assert.NotNil(t, announcements)
+	// Announcements that have not started yet or have already ended are
+	// filtered out; an open-ended one stays active.
+	assert.Equal(t,
+		`SELECT * FROM "announcements" WHERE audience IN ('all','sellers') `+
+			`AND (starts_at <= '2026-06-01 09:00:00' AND (ends_at IS NULL OR ends_at > '2026-06-01 09:00:00')) `+
+			`ORDER BY starts_at DESC, id`,
+		db.recorder.Last())
+}
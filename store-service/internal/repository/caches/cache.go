@@ -7,7 +7,21 @@ import (
 
 type Cache interface {
 	Get(ctx context.Context, key string) ([]byte, error)
+	// DeleteIfEqual deletes key only while it still holds value, checked
+	// and deleted in one step, and reports whether it did. Concurrent
+	// callers with the same value never both succeed.
+	DeleteIfEqual(ctx context.Context, key string, value any) (bool, error)
 	Set(ctx context.Context, key string, value any, ttl time.Duration) error
 	Delete(ctx context.Context, key string) error
+	// Invalidate deletes key and then announces it to every instance's
+	// Subscribe. Use it only for keys instances may also keep in process
+	// memory; a failed announcement is logged, not returned.
+	Invalidate(ctx context.Context, key string) error
 	Exists(ctx context.Context, key string) (bool, error)
+	// Incr atomically increments the integer at key and (re)sets its TTL.
+	Incr(ctx context.Context, key string, ttl time.Duration) (int64, error)
+	Decr(ctx context.Context, key string) (int64, error)
+	// Subscribe calls fn with each key invalidated by any instance until
+	// ctx is done. It is the hook for evicting copies kept in process memory.
+	Subscribe(ctx context.Context, fn func(key string)) error
 }
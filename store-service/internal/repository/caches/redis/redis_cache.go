@@ -5,6 +5,8 @@ import (
 	"encoding/json"
 	"time"
 
+	"github.com/1tsndre/mini-go-project/pkg/logger"
+	"github.com/1tsndre/mini-go-project/store-service/internal/constant"
 	"github.com/1tsndre/mini-go-project/store-service/internal/repository/caches"
 	"github.com/redis/go-redis/v9"
 )
@@ -21,6 +23,27 @@ func (r *redisCache) Get(ctx context.Context, key string) ([]byte, error) {
 	return r.client.Get(ctx, key).Bytes()
 }
 
+// deleteIfEqualScript deletes KEYS[1] when it holds ARGV[1] and returns the
+// number of keys deleted.
+var deleteIfEqualScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("DEL", KEYS[1])
+end
+return 0
+`)
+
+func (r *redisCache) DeleteIfEqual(ctx context.Context, key string, value any) (bool, error) {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return false, err
+	}
+	deleted, err := deleteIfEqualScript.Run(ctx, r.client, []string{key}, data).Int64()
+	if err != nil {
+		return false, err
+	}
+	return deleted > 0, nil
+}
+
 func (r *redisCache) Set(ctx context.Context, key string, value any, ttl time.Duration) error {
 	data, err := json.Marshal(value)
 	if err != nil {
@@ -33,6 +56,20 @@ func (r *redisCache) Delete(ctx context.Context, key string) error {
 	return r.client.Del(ctx, key).Err()
 }
 
+// Invalidate reports only a failed delete: once the key is gone from Redis
+// the caller's write stands, and other instances' copies expire on their own.
+func (r *redisCache) Invalidate(ctx context.Context, key string) error {
+	if err := r.Delete(ctx, key); err != nil {
+		return err
+	}
+	if err := r.client.Publish(ctx, constant.ChannelCacheInvalidation, key).Err(); err != nil {
+		logger.Error(ctx, "failed to announce cache invalidation", err, map[string]interface{}{
+			"key": key,
+		})
+	}
+	return nil
+}
+
 func (r *redisCache) Exists(ctx context.Context, key string) (bool, error) {
 	result, err := r.client.Exists(ctx, key).Result()
 	if err != nil {
@@ -40,3 +77,51 @@ func (r *redisCache) Exists(ctx context.Context, key string) (bool, error) {
 	}
 	return result > 0, nil
 }
+
+func (r *redisCache) Incr(ctx context.Context, key string, ttl time.Duration) (int64, error) {
+	pipe := r.client.TxPipeline()
+	incr := pipe.Incr(ctx, key)
+	pipe.Expire(ctx, key, ttl)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return 0, err
+	}
+	return incr.Val(), nil
+}
+
+func (r *redisCache) Decr(ctx context.Context, key string) (int64, error) {
+	return r.client.Decr(ctx, key).Result()
+}
+
+// Subscribe returns once ctx is done, or with an error if the subscription
+// cannot be set up.
+func (r *redisCache) Subscribe(ctx context.Context, fn func(key string)) error {
+	sub := r.client.Subscribe(ctx, constant.ChannelCacheInvalidation)
+	defer sub.Close()
+
+	// Wait for the subscription to be confirmed so no invalidation published
+	// after Subscribe starts is missed.
+	if _, err := sub.Receive(ctx); err != nil {
+		if ctx.Err() != nil {
+			return nil
+		}
+		return err
+	}
+	dispatchInvalidations(ctx, sub.Channel(), fn)
+	return nil
+}
+
+// dispatchInvalidations calls fn with the key carried by each message until
+// ctx is done or msgs is closed.
+func dispatchInvalidations(ctx context.Context, msgs <-chan *redis.Message, fn func(key string)) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case msg, ok := <-msgs:
+			if !ok {
+				return
+			}
+			fn(msg.Payload)
+		}
+	}
+}
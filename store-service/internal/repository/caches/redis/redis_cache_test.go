@@ -0,0 +1,93 @@
+package redis
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDispatchInvalidations(t *testing.T) {
+	msgs := make(chan *redis.Message, 2)
+	msgs <- &redis.Message{Payload: "product:1"}
+	msgs <- &redis.Message{Payload: "store:2"}
+	close(msgs)
+
+	var evicted []string
+	dispatchInvalidations(context.Background(), msgs, func(key string) {
+		evicted = append(evicted, key)
+	})
+
+	assert.Equal(t, []string{"product:1", "store:2"}, evicted)
+}
+
+func TestDispatchInvalidations_StopsWithContext(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	done := make(chan struct{})
+	go func() {
+		dispatchInvalidations(ctx, make(chan *redis.Message), func(string) {})
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("dispatch did not stop after the context was cancelled")
+	}
+}
+
+// TestRedisCache_InvalidateNotifiesSubscribers needs a real Redis and is
+// skipped unless REDIS_TEST_ADDR is set.
+func TestRedisCache_InvalidateNotifiesSubscribers(t *testing.T) {
+	addr := os.Getenv("REDIS_TEST_ADDR")
+	if addr == "" {
+		t.Skip("REDIS_TEST_ADDR not set")
+	}
+	client := redis.NewClient(&redis.Options{Addr: addr})
+	t.Cleanup(func() { client.Close() })
+
+	// Two caches on the same Redis stand in for two instances.
+	writer := NewRedisCache(client)
+	reader := NewRedisCache(client)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	evicted := make(chan string, 1)
+	subscribed := make(chan error, 1)
+	go func() {
+		subscribed <- reader.Subscribe(ctx, func(key string) { evicted <- key })
+	}()
+
+	key := "test:invalidation:" + time.Now().Format(time.RFC3339Nano)
+	assert.NoError(t, writer.Set(ctx, key, "value", time.Minute))
+	// Publishing before the subscription is confirmed would be lost.
+	assert.Eventually(t, func() bool {
+		n, err := client.PubSubNumSub(ctx, "cache_invalidation").Result()
+		return err == nil && n["cache_invalidation"] > 0
+	}, time.Second, 10*time.Millisecond)
+
+	// A plain Delete is not announced.
+	other := key + ":other"
+	assert.NoError(t, writer.Set(ctx, other, "value", time.Minute))
+	assert.NoError(t, writer.Delete(ctx, other))
+
+	assert.NoError(t, writer.Invalidate(ctx, key))
+
+	select {
+	case got := <-evicted:
+		assert.Equal(t, key, got)
+	case <-time.After(time.Second):
+		t.Fatal("subscriber was not notified of the deleted key")
+	}
+	exists, err := reader.Exists(ctx, key)
+	assert.NoError(t, err)
+	assert.False(t, exists)
+
+	cancel()
+	assert.NoError(t, <-subscribed)
+}
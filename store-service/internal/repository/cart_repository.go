@@ -3,7 +3,9 @@ package repository
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"time"
 
 	"github.com/1tsndre/mini-go-project/store-service/internal/constant"
 	"github.com/1tsndre/mini-go-project/store-service/internal/model"
@@ -12,21 +14,31 @@ import (
 	"github.com/google/uuid"
 	"github.com/shopspring/decimal"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
+// ErrStaleCart is returned by SaveCartIfVersion when the cart was written by
+// someone else after it was read.
+var ErrStaleCart = errors.New("cart was modified concurrently")
+
 type CartRepository interface {
 	GetCart(ctx context.Context, userID uuid.UUID) (*model.Cart, error)
 	SaveCart(ctx context.Context, cart *model.Cart) error
+	SaveCartIfVersion(ctx context.Context, cart *model.Cart) error
 	DeleteCart(ctx context.Context, userID uuid.UUID) error
+	DeleteItemsOlderThan(ctx context.Context, cutoff time.Time) (int64, error)
+	DeleteItemsByProduct(ctx context.Context, productID uuid.UUID) (int64, error)
+	ProductInCarts(ctx context.Context, productID uuid.UUID) (bool, error)
 }
 
 type cartRepository struct {
-	db    databases.Database
-	cache caches.Cache
+	db       databases.Database
+	cache    caches.Cache
+	cacheTTL time.Duration
 }
 
-func NewCartRepository(db databases.Database, cache caches.Cache) CartRepository {
-	return &cartRepository{db: db, cache: cache}
+func NewCartRepository(db databases.Database, cache caches.Cache, cacheTTL time.Duration) CartRepository {
+	return &cartRepository{db: db, cache: cache, cacheTTL: cacheTTL}
 }
 
 func (r *cartRepository) GetCart(ctx context.Context, userID uuid.UUID) (*model.Cart, error) {
@@ -49,7 +61,7 @@ func (r *cartRepository) GetCart(ctx context.Context, userID uuid.UUID) (*model.
 	}
 
 	var rows []cartRow
-	err = r.db.DB().WithContext(ctx).
+	err = databases.FromContext(ctx, r.db).
 		Table("cart_items").
 		Select("cart_items.product_id, cart_items.quantity, products.name, products.price, products.image_url").
 		Joins("JOIN products ON products.id = cart_items.product_id").
@@ -59,9 +71,20 @@ func (r *cartRepository) GetCart(ctx context.Context, userID uuid.UUID) (*model.
 		return nil, err
 	}
 
+	var version model.CartVersion
+	err = databases.FromContext(ctx, r.db).
+		Where("user_id = ?", userID).
+		Limit(1).
+		Find(&version).Error
+	if err != nil {
+		return nil, err
+	}
+
 	cart := &model.Cart{
-		UserID: userID,
-		Items:  make([]model.CartItem, 0, len(rows)),
+		UserID:    userID,
+		Items:     make([]model.CartItem, 0, len(rows)),
+		Version:   version.Version,
+		UpdatedAt: version.UpdatedAt,
 	}
 
 	for _, row := range rows {
@@ -74,7 +97,7 @@ func (r *cartRepository) GetCart(ctx context.Context, userID uuid.UUID) (*model.
 		})
 	}
 
-	r.cache.Set(ctx, cacheKey, cart, constant.TTLCart)
+	r.cache.Set(ctx, cacheKey, cart, r.cacheTTL)
 
 	return cart, nil
 }
@@ -82,39 +105,176 @@ func (r *cartRepository) GetCart(ctx context.Context, userID uuid.UUID) (*model.
 func (r *cartRepository) SaveCart(ctx context.Context, cart *model.Cart) error {
 	cacheKey := fmt.Sprintf(constant.KeyCart, cart.UserID.String())
 
-	if err := r.db.DB().WithContext(ctx).Transaction(func(tx *gorm.DB) error {
-		if err := tx.Where("user_id = ?", cart.UserID).Delete(&model.CartItemDB{}).Error; err != nil {
+	if err := databases.FromContext(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		if err := bumpCartVersion(tx, cart.UserID, cart.UpdatedAt); err != nil {
 			return err
 		}
+		return replaceCartItems(tx, cart)
+	}); err != nil {
+		return err
+	}
 
-		if len(cart.Items) == 0 {
-			return nil
-		}
+	cart.Version++
+	r.cache.Set(ctx, cacheKey, cart, r.cacheTTL)
+	return nil
+}
+
+// SaveCartIfVersion saves the cart only if its version still matches the one
+// stored in PostgreSQL, returning ErrStaleCart otherwise. On success the
+// cart's Version is advanced to the newly stored value.
+func (r *cartRepository) SaveCartIfVersion(ctx context.Context, cart *model.Cart) error {
+	cacheKey := fmt.Sprintf(constant.KeyCart, cart.UserID.String())
 
-		dbItems := make([]model.CartItemDB, 0, len(cart.Items))
-		for _, item := range cart.Items {
-			dbItems = append(dbItems, model.CartItemDB{
-				UserID:    cart.UserID,
-				ProductID: item.ProductID,
-				Quantity:  item.Quantity,
+	if err := databases.FromContext(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&model.CartVersion{}).
+			Where("user_id = ? AND version = ?", cart.UserID, cart.Version).
+			Updates(map[string]interface{}{
+				"version":    gorm.Expr("version + 1"),
+				"updated_at": cart.UpdatedAt,
 			})
+		if result.Error != nil {
+			return result.Error
 		}
 
-		return tx.Create(&dbItems).Error
+		if result.RowsAffected == 0 {
+			if cart.Version != 0 {
+				return ErrStaleCart
+			}
+			// First save of this cart: the version row does not exist yet,
+			// and only one concurrent writer may create it.
+			result = tx.Clauses(clause.OnConflict{DoNothing: true}).
+				Create(&model.CartVersion{UserID: cart.UserID, Version: 1, UpdatedAt: cart.UpdatedAt})
+			if result.Error != nil {
+				return result.Error
+			}
+			if result.RowsAffected == 0 {
+				return ErrStaleCart
+			}
+		}
+
+		return replaceCartItems(tx, cart)
 	}); err != nil {
+		if errors.Is(err, ErrStaleCart) {
+			r.cache.Delete(ctx, cacheKey)
+		}
 		return err
 	}
 
-	r.cache.Set(ctx, cacheKey, cart, constant.TTLCart)
+	cart.Version++
+	r.cache.Set(ctx, cacheKey, cart, r.cacheTTL)
 	return nil
 }
 
+// bumpCartVersion advances the cart's version and records at as its last
+// modification, which GetCart reports as the cart's UpdatedAt.
+func bumpCartVersion(tx *gorm.DB, userID uuid.UUID, at time.Time) error {
+	return tx.Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "user_id"}},
+		DoUpdates: clause.Assignments(map[string]interface{}{
+			"version":    gorm.Expr("cart_versions.version + 1"),
+			"updated_at": at,
+		}),
+	}).Create(&model.CartVersion{UserID: userID, Version: 1, UpdatedAt: at}).Error
+}
+
+func replaceCartItems(tx *gorm.DB, cart *model.Cart) error {
+	if err := tx.Where("user_id = ?", cart.UserID).Delete(&model.CartItemDB{}).Error; err != nil {
+		return err
+	}
+
+	if len(cart.Items) == 0 {
+		return nil
+	}
+
+	dbItems := make([]model.CartItemDB, 0, len(cart.Items))
+	for _, item := range cart.Items {
+		dbItems = append(dbItems, model.CartItemDB{
+			UserID:    cart.UserID,
+			ProductID: item.ProductID,
+			Quantity:  item.Quantity,
+		})
+	}
+
+	return tx.Create(&dbItems).Error
+}
+
 func (r *cartRepository) DeleteCart(ctx context.Context, userID uuid.UUID) error {
 	cacheKey := fmt.Sprintf(constant.KeyCart, userID.String())
 
-	if err := r.db.DB().WithContext(ctx).Where("user_id = ?", userID).Delete(&model.CartItemDB{}).Error; err != nil {
+	// Bumping the version makes any save based on the pre-delete cart stale.
+	if err := databases.FromContext(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("user_id = ?", userID).Delete(&model.CartItemDB{}).Error; err != nil {
+			return err
+		}
+		return bumpCartVersion(tx, userID, time.Now().UTC().Truncate(time.Microsecond))
+	}); err != nil {
 		return err
 	}
 	r.cache.Delete(ctx, cacheKey)
 	return nil
 }
+
+// DeleteItemsOlderThan removes the PostgreSQL backup rows of carts that have
+// not been saved since cutoff. SaveCart rewrites every row of a cart, so
+// updated_at reflects the cart's last modification.
+func (r *cartRepository) DeleteItemsOlderThan(ctx context.Context, cutoff time.Time) (int64, error) {
+	result := databases.FromContext(ctx, r.db).
+		Where("updated_at < ?", cutoff).
+		Delete(&model.CartItemDB{})
+	return result.RowsAffected, result.Error
+}
+
+// DeleteItemsByProduct removes productID from every cart that holds it and
+// returns how many carts changed. Their versions are bumped, so saves based
+// on the old contents are stale, and their cached copies are dropped.
+func (r *cartRepository) DeleteItemsByProduct(ctx context.Context, productID uuid.UUID) (int64, error) {
+	var owners []uuid.UUID
+	if err := databases.FromContext(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		var removed []model.CartItemDB
+		if err := tx.Clauses(clause.Returning{Columns: []clause.Column{{Name: "user_id"}}}).
+			Where("product_id = ?", productID).
+			Delete(&removed).Error; err != nil {
+			return err
+		}
+
+		owners = cartOwners(removed)
+		now := time.Now().UTC().Truncate(time.Microsecond)
+		for _, userID := range owners {
+			if err := bumpCartVersion(tx, userID, now); err != nil {
+				return err
+			}
+		}
+		return nil
+	}); err != nil {
+		return 0, err
+	}
+
+	for _, userID := range owners {
+		r.cache.Delete(ctx, fmt.Sprintf(constant.KeyCart, userID.String()))
+	}
+	return int64(len(owners)), nil
+}
+
+// cartOwners returns the distinct users the items belong to.
+func cartOwners(items []model.CartItemDB) []uuid.UUID {
+	seen := make(map[uuid.UUID]bool, len(items))
+	owners := make([]uuid.UUID, 0, len(items))
+	for _, item := range items {
+		if !seen[item.UserID] {
+			seen[item.UserID] = true
+			owners = append(owners, item.UserID)
+		}
+	}
+	return owners
+}
+
+// ProductInCarts reports whether any cart holds productID.
+func (r *cartRepository) ProductInCarts(ctx context.Context, productID uuid.UUID) (bool, error) {
+	var count int64
+	err := databases.FromContext(ctx, r.db).
+		Model(&model.CartItemDB{}).
+		Where("product_id = ?", productID).
+		Limit(1).
+		Count(&count).Error
+	return count > 0, err
+}
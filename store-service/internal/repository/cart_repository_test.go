@@ -0,0 +1,86 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/1tsndre/mini-go-project/store-service/internal/constant"
+	"github.com/1tsndre/mini-go-project/store-service/internal/model"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCartRepository_DeleteItemsOlderThan(t *testing.T) {
+	db := newDryRunDB(t)
+	repo := NewCartRepository(db, nil, time.Hour)
+
+	cutoff := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	_, err := repo.DeleteItemsOlderThan(context.Background(), cutoff)
+
+	assert.NoError(t, err)
+	assert.Equal(t,
+		`DELETE FROM "cart_items" WHERE updated_at < '2026-01-02 03:04:05'`,
+		db.recorder.Last(),
+	)
+}
+
+func TestCartRepository_ProductInCarts(t *testing.T) {
+	db := newDryRunDB(t)
+	repo := NewCartRepository(db, nil, time.Hour)
+
+	productID := uuid.MustParse("3d4e5f60-7182-4c9d-8e0f-1a2b3c4d5e6f")
+	_, err := repo.ProductInCarts(context.Background(), productID)
+
+	assert.NoError(t, err)
+	assert.Equal(t,
+		`SELECT count(*) FROM "cart_items" WHERE product_id = '3d4e5f60-7182-4c9d-8e0f-1a2b3c4d5e6f' LIMIT 1`,
+		db.recorder.Last(),
+	)
+}
+
+func TestCartRepository_DeleteItemsByProduct(t *testing.T) {
+	db := newDryRunDB(t)
+	repo := NewCartRepository(db, newMemoryCache(), time.Hour)
+
+	productID := uuid.MustParse("3d4e5f60-7182-4c9d-8e0f-1a2b3c4d5e6f")
+	carts, err := repo.DeleteItemsByProduct(db.txContext(context.Background()), productID)
+
+	assert.NoError(t, err)
+	assert.Zero(t, carts)
+	stmts := db.recorder.Statements()
+	if assert.Len(t, stmts, 2) {
+		assert.Equal(t,
+			`DELETE FROM "cart_items" WHERE product_id = '3d4e5f60-7182-4c9d-8e0f-1a2b3c4d5e6f' RETURNING "user_id"`,
+			stmts[1],
+		)
+	}
+}
+
+func TestCartRepository_SaveCartIfVersion(t *testing.T) {
+	db := newDryRunDB(t)
+	cache := newMemoryCache()
+	repo := NewCartRepository(db, cache, time.Hour)
+
+	userID := uuid.MustParse("4e5f6071-8293-4dae-9f01-2b3c4d5e6f70")
+	cart := &model.Cart{UserID: userID, Version: 3, UpdatedAt: time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)}
+	cacheKey := fmt.Sprintf(constant.KeyCart, userID.String())
+	cache.Set(context.Background(), cacheKey, cart, time.Hour)
+
+	err := repo.SaveCartIfVersion(db.txContext(context.Background()), cart)
+
+	// The dry run updates no rows, which is what a concurrent write looks like.
+	assert.ErrorIs(t, err, ErrStaleCart)
+	stmts := db.recorder.Statements()
+	if assert.Len(t, stmts, 3, "a stale cart must not touch its items") {
+		assert.Equal(t,
+			`UPDATE "cart_versions" SET "updated_at"='2026-01-02 03:04:05',"version"=version + 1 `+
+				`WHERE user_id = '4e5f6071-8293-4dae-9f01-2b3c4d5e6f70' AND version = 3`,
+			stmts[1],
+		)
+	}
+	assert.Equal(t, int64(3), cart.Version)
+	_, err = cache.Get(context.Background(), cacheKey)
+	assert.Error(t, err, "a stale cart must be dropped from the cache")
+}
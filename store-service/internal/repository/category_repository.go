@@ -2,18 +2,50 @@ package repository
 
 import (
 	"context"
+	"errors"
+	"fmt"
 
 	"github.com/1tsndre/mini-go-project/store-service/internal/model"
 	"github.com/1tsndre/mini-go-project/store-service/internal/repository/databases"
 	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
+// ErrCategoryCycle is returned by Update when the new parent is the
+// category itself or one of its descendants.
+var ErrCategoryCycle = errors.New("a category cannot be its own ancestor")
+
+// ErrCategoryTooDeep is returned by Update when the new parent's ancestry is
+// deeper than maxCategoryDepth.
+var ErrCategoryTooDeep = errors.New("category hierarchy is too deep")
+
+// maxCategoryDepth bounds the ancestor walk of the cycle check, so corrupt
+// data cannot make it loop forever.
+const maxCategoryDepth = 100
+
+// CategoryInUseError is returned by Delete when categories or products are
+// still filed under the category.
+type CategoryInUseError struct {
+	Subcategories int64
+	Products      int64
+}
+
+func (e *CategoryInUseError) Error() string {
+	if e.Subcategories > 0 {
+		return fmt.Sprintf("category has %d subcategories; move or delete them first", e.Subcategories)
+	}
+	return fmt.Sprintf("category has %d products; move them to another category first", e.Products)
+}
+
 type CategoryRepository interface {
 	Create(ctx context.Context, category *model.Category) error
 	FindAll(ctx context.Context) ([]model.Category, error)
+	FindAllPaginated(ctx context.Context, page, perPage int) ([]model.Category, int64, error)
 	FindByID(ctx context.Context, id uuid.UUID) (*model.Category, error)
 	Update(ctx context.Context, category *model.Category) error
 	Delete(ctx context.Context, id uuid.UUID) error
+	CreateMany(ctx context.Context, categories []model.Category) ([]string, error)
 }
 
 type categoryRepository struct {
@@ -25,28 +57,151 @@ func NewCategoryRepository(db databases.Database) CategoryRepository {
 }
 
 func (r *categoryRepository) Create(ctx context.Context, category *model.Category) error {
-	return r.db.DB().WithContext(ctx).Create(category).Error
+	return databases.FromContext(ctx, r.db).Create(category).Error
 }
 
 func (r *categoryRepository) FindAll(ctx context.Context) ([]model.Category, error) {
 	var categories []model.Category
-	err := r.db.DB().WithContext(ctx).Order("name ASC").Find(&categories).Error
+	err := databases.FromContext(ctx, r.db).Order("name ASC, id").Find(&categories).Error
 	return categories, err
 }
 
+// FindAllPaginated returns one page of categories in FindAll's order, with
+// the total number of categories.
+func (r *categoryRepository) FindAllPaginated(ctx context.Context, page, perPage int) ([]model.Category, int64, error) {
+	var total int64
+	if err := databases.FromContext(ctx, r.db).Model(&model.Category{}).Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var categories []model.Category
+	err := databases.FromContext(ctx, r.db).
+		Order("name ASC, id").
+		Offset((page - 1) * perPage).
+		Limit(perPage).
+		Find(&categories).Error
+	return categories, total, err
+}
+
 func (r *categoryRepository) FindByID(ctx context.Context, id uuid.UUID) (*model.Category, error) {
 	var category model.Category
-	err := r.db.DB().WithContext(ctx).First(&category, "id = ?", id).Error
+	err := databases.FromContext(ctx, r.db).First(&category, "id = ?", id).Error
 	if err != nil {
 		return nil, err
 	}
 	return &category, nil
 }
 
+// Update saves category. When it has a parent, the category and every
+// ancestor of the parent are locked first and checked for a cycle, so two
+// concurrent moves cannot each pass the check and together loop the
+// hierarchy.
 func (r *categoryRepository) Update(ctx context.Context, category *model.Category) error {
-	return r.db.DB().WithContext(ctx).Save(category).Error
+	if category.ParentID == nil {
+		return databases.FromContext(ctx, r.db).Save(category).Error
+	}
+	return databases.FromContext(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		if _, err := lockCategory(tx, category.ID); err != nil {
+			return err
+		}
+		current := category.ParentID
+		for depth := 0; current != nil; depth++ {
+			if *current == category.ID {
+				return ErrCategoryCycle
+			}
+			if depth >= maxCategoryDepth {
+				return ErrCategoryTooDeep
+			}
+			ancestor, err := lockCategory(tx, *current)
+			if err != nil {
+				return err
+			}
+			current = ancestor.ParentID
+		}
+		return tx.Save(category).Error
+	})
 }
 
+// Delete removes a category that nothing is filed under. The category is
+// locked while checked, so a concurrent move or product cannot land in it
+// between the check and the delete.
 func (r *categoryRepository) Delete(ctx context.Context, id uuid.UUID) error {
-	return r.db.DB().WithContext(ctx).Delete(&model.Category{}, "id = ?", id).Error
+	return databases.FromContext(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		if _, err := lockCategory(tx, id); err != nil {
+			return err
+		}
+
+		var inUse CategoryInUseError
+		if err := tx.Model(&model.Category{}).Where("parent_id = ?", id).Count(&inUse.Subcategories).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&model.Product{}).Where("category_id = ?", id).Count(&inUse.Products).Error; err != nil {
+			return err
+		}
+		if inUse.Subcategories > 0 || inUse.Products > 0 {
+			return &inUse
+		}
+		return tx.Delete(&model.Category{}, "id = ?", id).Error
+	})
+}
+
+// lockCategory loads a category's parent and locks its row until tx ends.
+func lockCategory(tx *gorm.DB, id uuid.UUID) (*model.Category, error) {
+	var category model.Category
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Select("id", "parent_id").
+		First(&category, "id = ?", id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &category, nil
+}
+
+// CreateMany inserts categories in one transaction. Parents must come before
+// their children. A category whose name was taken since the caller checked
+// is left out and its name returned; children listed after it are filed
+// under the category that holds the name instead.
+func (r *categoryRepository) CreateMany(ctx context.Context, categories []model.Category) ([]string, error) {
+	if len(categories) == 0 {
+		return nil, nil
+	}
+
+	var taken []string
+	err := databases.FromContext(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		taken = nil
+		// holders maps the ID planned for a left-out category to the ID of
+		// the existing category with its name.
+		holders := make(map[uuid.UUID]uuid.UUID)
+		for i := range categories {
+			category := &categories[i]
+			if category.ParentID != nil {
+				if holderID, ok := holders[*category.ParentID]; ok {
+					category.ParentID = &holderID
+				}
+			}
+
+			result := tx.Clauses(clause.OnConflict{
+				Columns:   []clause.Column{{Name: "name"}},
+				DoNothing: true,
+			}).Create(category)
+			if result.Error != nil {
+				return result.Error
+			}
+			if result.RowsAffected > 0 {
+				continue
+			}
+
+			var holder model.Category
+			if err := tx.Select("id").First(&holder, "name = ?", category.Name).Error; err != nil {
+				return err
+			}
+			holders[category.ID] = holder.ID
+			taken = append(taken, category.Name)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return taken, nil
 }
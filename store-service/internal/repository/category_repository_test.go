@@ -0,0 +1,202 @@
+package repository
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/1tsndre/mini-go-project/store-service/internal/model"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+func TestCategoryRepository_CreateMany(t *testing.T) {
+	parentID := uuid.MustParse("1b2c3d4e-5f60-4a7b-8c9d-0e1f2a3b4c5d")
+	childID := uuid.MustParse("2c3d4e5f-6071-4b8c-9d0e-1f2a3b4c5d6e")
+	holderID := uuid.MustParse("3d4e5f60-7182-4c9d-8e0f-2a3b4c5d6e7f")
+
+	// insertsAll makes every dry-run INSERT report a row, except for names
+	// already held by another category.
+	insertsAll := func(db *testDB, held ...string) {
+		assert.NoError(t, db.db.Callback().Create().After("gorm:create").Register("test:rows", func(tx *gorm.DB) {
+			if c, ok := tx.Statement.Dest.(*model.Category); ok && !slices.Contains(held, c.Name) {
+				tx.RowsAffected = 1
+			}
+		}))
+		assert.NoError(t, db.db.Callback().Query().After("gorm:query").Register("test:holder", func(tx *gorm.DB) {
+			if c, ok := tx.Statement.Dest.(*model.Category); ok {
+				c.ID = holderID
+				tx.RowsAffected = 1
+			}
+		}))
+	}
+
+	t.Run("inserts each category in one transaction", func(t *testing.T) {
+		db := newDryRunDB(t)
+		insertsAll(db)
+		repo := NewCategoryRepository(db)
+
+		taken, err := repo.CreateMany(db.txContext(context.Background()), []model.Category{
+			{ID: parentID, Name: "Electronics"},
+			{ID: childID, Name: "Phones", ParentID: &parentID},
+		})
+
+		assert.NoError(t, err)
+		assert.Empty(t, taken)
+		stmts := db.recorder.Statements()
+		if assert.Len(t, stmts, 3) {
+			assert.Contains(t, stmts[0], "SAVEPOINT")
+			assert.Contains(t, stmts[1], `INSERT INTO "categories"`)
+			assert.Contains(t, stmts[1], `'Electronics'`)
+			assert.Contains(t, stmts[1], `ON CONFLICT ("name") DO NOTHING`)
+			assert.Contains(t, stmts[2], `'Phones','1b2c3d4e-5f60-4a7b-8c9d-0e1f2a3b4c5d'`)
+		}
+	})
+
+	t.Run("a name taken meanwhile is reported and adopts its children", func(t *testing.T) {
+		db := newDryRunDB(t)
+		insertsAll(db, "Electronics")
+		repo := NewCategoryRepository(db)
+		categories := []model.Category{
+			{ID: parentID, Name: "Electronics"},
+			{ID: childID, Name: "Phones", ParentID: &parentID},
+		}
+
+		taken, err := repo.CreateMany(db.txContext(context.Background()), categories)
+
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"Electronics"}, taken)
+		assert.Equal(t, holderID, *categories[1].ParentID)
+		assert.Contains(t, db.recorder.Last(), `'Phones','3d4e5f60-7182-4c9d-8e0f-2a3b4c5d6e7f'`)
+	})
+
+	t.Run("empty batch does not touch the database", func(t *testing.T) {
+		db := newDryRunDB(t)
+		repo := NewCategoryRepository(db)
+
+		taken, err := repo.CreateMany(context.Background(), nil)
+		assert.NoError(t, err)
+		assert.Empty(t, taken)
+		assert.Empty(t, db.recorder.Statements())
+	})
+}
+
+func TestCategoryRepository_FindAllPaginated(t *testing.T) {
+	db := newDryRunDB(t)
+	repo := NewCategoryRepository(db)
+
+	_, _, err := repo.FindAllPaginated(context.Background(), 3, 20)
+
+	assert.NoError(t, err)
+	stmts := db.recorder.Statements()
+	if assert.Len(t, stmts, 2) {
+		assert.Contains(t, stmts[0], `SELECT count(*) FROM "categories"`)
+		assert.Contains(t, stmts[1], `ORDER BY name ASC, id LIMIT 20 OFFSET 40`)
+	}
+}
+
+func TestCategoryRepository_Update_LocksAncestors(t *testing.T) {
+	// root -> child -> grandchild, and other at the top level.
+	rootID := uuid.MustParse("1b2c3d4e-5f60-4a7b-8c9d-0e1f2a3b4c5d")
+	childID := uuid.MustParse("2c3d4e5f-6071-4b8c-9d0e-1f2a3b4c5d6e")
+	grandchildID := uuid.MustParse("3d4e5f60-7182-4c9d-8e0f-2a3b4c5d6e7f")
+	otherID := uuid.MustParse("4e5f6071-8293-4d0e-9f1a-3b4c5d6e7f80")
+	parents := map[uuid.UUID]*uuid.UUID{rootID: nil, childID: &rootID, grandchildID: &childID, otherID: nil}
+
+	tests := []struct {
+		name      string
+		id        uuid.UUID
+		parentID  uuid.UUID
+		wantErr   error
+		wantLocks int
+	}{
+		{name: "under its grandchild", id: rootID, parentID: grandchildID, wantErr: ErrCategoryCycle, wantLocks: 3},
+		{name: "under itself", id: rootID, parentID: rootID, wantErr: ErrCategoryCycle, wantLocks: 1},
+		{name: "to another branch", id: grandchildID, parentID: otherID, wantLocks: 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := newDryRunDB(t)
+			assert.NoError(t, db.db.Callback().Query().After("gorm:query").Register("test:parents", func(tx *gorm.DB) {
+				c, ok := tx.Statement.Dest.(*model.Category)
+				if !ok {
+					return
+				}
+				c.ID = tx.Statement.Vars[0].(uuid.UUID)
+				c.ParentID = parents[c.ID]
+				tx.RowsAffected = 1
+			}))
+			repo := NewCategoryRepository(db)
+
+			err := repo.Update(db.txContext(context.Background()), &model.Category{ID: tt.id, Name: "Moved", ParentID: &tt.parentID})
+
+			stmts := db.recorder.Statements()
+			var locks, updates int
+			for _, stmt := range stmts {
+				if strings.HasSuffix(stmt, "FOR UPDATE") {
+					locks++
+				}
+				if strings.HasPrefix(stmt, `UPDATE "categories"`) {
+					updates++
+				}
+			}
+			assert.Equal(t, tt.wantLocks, locks)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				assert.Zero(t, updates)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, 1, updates)
+		})
+	}
+}
+
+func TestCategoryRepository_Delete(t *testing.T) {
+	id := uuid.MustParse("1b2c3d4e-5f60-4a7b-8c9d-0e1f2a3b4c5d")
+
+	tests := []struct {
+		name       string
+		counts     []int64
+		wantErr    error
+		wantDelete bool
+	}{
+		{name: "empty category", counts: []int64{0, 0}, wantDelete: true},
+		{name: "has subcategories", counts: []int64{1, 0}, wantErr: &CategoryInUseError{Subcategories: 1}},
+		{name: "has products", counts: []int64{0, 3}, wantErr: &CategoryInUseError{Products: 3}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := newDryRunDB(t)
+			counts := tt.counts
+			assert.NoError(t, db.db.Callback().Query().After("gorm:query").Register("test:rows", func(tx *gorm.DB) {
+				switch dest := tx.Statement.Dest.(type) {
+				case *model.Category:
+					dest.ID = id
+					tx.RowsAffected = 1
+				case *int64:
+					*dest, counts = counts[0], counts[1:]
+					tx.RowsAffected = 1
+				}
+			}))
+			repo := NewCategoryRepository(db)
+
+			err := repo.Delete(db.txContext(context.Background()), id)
+
+			stmts := db.recorder.Statements()
+			if assert.GreaterOrEqual(t, len(stmts), 4) {
+				assert.Equal(t, `SELECT "id","parent_id" FROM "categories" WHERE id = '1b2c3d4e-5f60-4a7b-8c9d-0e1f2a3b4c5d' ORDER BY "categories"."id" LIMIT 1 FOR UPDATE`, stmts[1])
+			}
+			deleted := false
+			for _, stmt := range stmts {
+				deleted = deleted || strings.HasPrefix(stmt, `DELETE FROM "categories"`)
+			}
+			assert.Equal(t, tt.wantDelete, deleted)
+			assert.Equal(t, tt.wantErr, err)
+		})
+	}
+}
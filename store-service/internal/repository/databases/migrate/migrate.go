@@ -0,0 +1,176 @@
+// Package migrate applies the versioned SQL files in the migrations
+// directory. Applied versions are tracked in the same schema_migrations
+// table the golang-migrate CLI uses, so databases migrated by either one can
+// be moved forward by the other.
+package migrate
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"regexp"
+	"sort"
+	"strconv"
+
+	"github.com/1tsndre/mini-go-project/pkg/logger"
+	"github.com/1tsndre/mini-go-project/store-service/internal/repository/databases"
+	"gorm.io/gorm"
+)
+
+// lockID is the PostgreSQL advisory lock held while migrating, so instances
+// starting together apply each migration once.
+const lockID = 7340958233
+
+var upFilePattern = regexp.MustCompile(`^(\d+)_(.+)\.up\.sql$`)
+
+// ErrDirty is returned when a previous migration run stopped part way. The
+// schema has to be repaired and the version reset by hand before migrating
+// again.
+var ErrDirty = errors.New("database schema is dirty")
+
+// Migration is one versioned schema change.
+type Migration struct {
+	Version uint
+	Name    string
+	SQL     string
+}
+
+// Load reads the *.up.sql files in files, ordered by version. Down files are
+// ignored: rolling back stays a manual step.
+func Load(files fs.FS) ([]Migration, error) {
+	entries, err := fs.ReadDir(files, ".")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read migrations: %w", err)
+	}
+
+	var migrations []Migration
+	seen := make(map[uint]string)
+	for _, entry := range entries {
+		match := upFilePattern.FindStringSubmatch(entry.Name())
+		if entry.IsDir() || match == nil {
+			continue
+		}
+		version, err := strconv.ParseUint(match[1], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid migration version in %s: %w", entry.Name(), err)
+		}
+		if other, ok := seen[uint(version)]; ok {
+			return nil, fmt.Errorf("migrations %s and %s share version %d", other, entry.Name(), version)
+		}
+		seen[uint(version)] = entry.Name()
+
+		sql, err := fs.ReadFile(files, entry.Name())
+		if err != nil {
+			return nil, fmt.Errorf("failed to read migration %s: %w", entry.Name(), err)
+		}
+		migrations = append(migrations, Migration{Version: uint(version), Name: match[2], SQL: string(sql)})
+	}
+
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+	return migrations, nil
+}
+
+// versionStore records which migration a database is at and applies new
+// ones.
+type versionStore interface {
+	Version(ctx context.Context) (version uint, dirty bool, err error)
+	Apply(ctx context.Context, m Migration) error
+}
+
+type Runner struct {
+	db         databases.Database
+	migrations []Migration
+}
+
+func NewRunner(db databases.Database, migrations []Migration) *Runner {
+	return &Runner{db: db, migrations: migrations}
+}
+
+// Up applies every migration newer than the database's version, oldest
+// first, and returns the versions it applied. A database already up to date
+// is left untouched.
+func (r *Runner) Up(ctx context.Context) ([]uint, error) {
+	var applied []uint
+	// The advisory lock belongs to a session, so everything runs on one
+	// connection.
+	err := r.db.DB().WithContext(ctx).Connection(func(conn *gorm.DB) error {
+		if err := conn.Exec("SELECT pg_advisory_lock(?)", lockID).Error; err != nil {
+			return fmt.Errorf("failed to acquire migration lock: %w", err)
+		}
+		defer conn.Exec("SELECT pg_advisory_unlock(?)", lockID)
+
+		var err error
+		applied, err = r.up(ctx, &postgresStore{db: conn})
+		return err
+	})
+	return applied, err
+}
+
+func (r *Runner) up(ctx context.Context, store versionStore) ([]uint, error) {
+	current, dirty, err := store.Version(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read schema version: %w", err)
+	}
+	if dirty {
+		return nil, fmt.Errorf("%w at version %d", ErrDirty, current)
+	}
+
+	var applied []uint
+	for _, m := range r.migrations {
+		if m.Version <= current {
+			continue
+		}
+		if err := store.Apply(ctx, m); err != nil {
+			return applied, fmt.Errorf("migration %d_%s failed: %w", m.Version, m.Name, err)
+		}
+		logger.Info(ctx, "applied database migration", map[string]interface{}{
+			"version": m.Version,
+			"name":    m.Name,
+		})
+		applied = append(applied, m.Version)
+	}
+	return applied, nil
+}
+
+// postgresStore keeps the version as the single row of schema_migrations,
+// the layout golang-migrate reads and writes.
+type postgresStore struct {
+	db *gorm.DB
+}
+
+func (s *postgresStore) Version(ctx context.Context) (uint, bool, error) {
+	db := s.db.WithContext(ctx)
+	err := db.Exec("CREATE TABLE IF NOT EXISTS schema_migrations (version bigint NOT NULL PRIMARY KEY, dirty boolean NOT NULL)").Error
+	if err != nil {
+		return 0, false, err
+	}
+
+	var rows []struct {
+		Version uint
+		Dirty   bool
+	}
+	if err := db.Raw("SELECT version, dirty FROM schema_migrations LIMIT 1").Scan(&rows).Error; err != nil {
+		return 0, false, err
+	}
+	if len(rows) == 0 {
+		return 0, false, nil
+	}
+	return rows[0].Version, rows[0].Dirty, nil
+}
+
+// Apply runs the migration and moves the version in one transaction, so a
+// failed migration leaves both the schema and the version as they were.
+func (s *postgresStore) Apply(ctx context.Context, m Migration) error {
+	return s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec(m.SQL).Error; err != nil {
+			return err
+		}
+		if err := tx.Exec("DELETE FROM schema_migrations").Error; err != nil {
+			return err
+		}
+		return tx.Exec("INSERT INTO schema_migrations (version, dirty) VALUES (?, false)", m.Version).Error
+	})
+}
@@ -0,0 +1,116 @@
+package migrate
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeStore tracks the version like schema_migrations does and records
+// every migration it runs.
+type fakeStore struct {
+	version uint
+	dirty   bool
+	ran     []uint
+	failAt  uint
+}
+
+func (s *fakeStore) Version(context.Context) (uint, bool, error) {
+	return s.version, s.dirty, nil
+}
+
+func (s *fakeStore) Apply(_ context.Context, m Migration) error {
+	if m.Version == s.failAt {
+		return errors.New("syntax error")
+	}
+	s.ran = append(s.ran, m.Version)
+	s.version = m.Version
+	return nil
+}
+
+func TestLoad(t *testing.T) {
+	files := fstest.MapFS{
+		"000002_add_orders.up.sql":   {Data: []byte("CREATE TABLE orders ();")},
+		"000002_add_orders.down.sql": {Data: []byte("DROP TABLE orders;")},
+		"000001_init.up.sql":         {Data: []byte("CREATE TABLE users ();")},
+		"000001_init.down.sql":       {Data: []byte("DROP TABLE users;")},
+		"README.md":                  {Data: []byte("notes")},
+	}
+
+	migrations, err := Load(files)
+
+	assert.NoError(t, err)
+	assert.Equal(t, []Migration{
+		{Version: 1, Name: "init", SQL: "CREATE TABLE users ();"},
+		{Version: 2, Name: "add_orders", SQL: "CREATE TABLE orders ();"},
+	}, migrations)
+}
+
+func TestLoad_DuplicateVersion(t *testing.T) {
+	files := fstest.MapFS{
+		"000003_a.up.sql": {Data: []byte("SELECT 1;")},
+		"3_b.up.sql":      {Data: []byte("SELECT 1;")},
+	}
+
+	_, err := Load(files)
+
+	assert.ErrorContains(t, err, "share version 3")
+}
+
+func TestRunner_Up(t *testing.T) {
+	migrations := []Migration{
+		{Version: 1, Name: "init"},
+		{Version: 2, Name: "add_orders"},
+		{Version: 3, Name: "add_reviews"},
+	}
+
+	t.Run("applies pending migrations in order", func(t *testing.T) {
+		store := &fakeStore{version: 1}
+		runner := NewRunner(nil, migrations)
+
+		applied, err := runner.up(context.Background(), store)
+
+		assert.NoError(t, err)
+		assert.Equal(t, []uint{2, 3}, applied)
+		assert.Equal(t, []uint{2, 3}, store.ran)
+	})
+
+	t.Run("second run is a no-op", func(t *testing.T) {
+		store := &fakeStore{}
+		runner := NewRunner(nil, migrations)
+
+		_, err := runner.up(context.Background(), store)
+		assert.NoError(t, err)
+
+		applied, err := runner.up(context.Background(), store)
+
+		assert.NoError(t, err)
+		assert.Empty(t, applied)
+		assert.Equal(t, []uint{1, 2, 3}, store.ran, "no migration should run twice")
+		assert.Equal(t, uint(3), store.version)
+	})
+
+	t.Run("stops at the first failure", func(t *testing.T) {
+		store := &fakeStore{failAt: 2}
+		runner := NewRunner(nil, migrations)
+
+		applied, err := runner.up(context.Background(), store)
+
+		assert.ErrorContains(t, err, "migration 2_add_orders failed")
+		assert.Equal(t, []uint{1}, applied)
+		assert.Equal(t, uint(1), store.version)
+	})
+
+	t.Run("refuses a dirty database", func(t *testing.T) {
+		store := &fakeStore{version: 2, dirty: true}
+		runner := NewRunner(nil, migrations)
+
+		_, err := runner.up(context.Background(), store)
+
+		assert.ErrorIs(t, err, ErrDirty)
+		assert.Empty(t, store.ran)
+	})
+}
@@ -0,0 +1,30 @@
+package databases
+
+import (
+	"context"
+
+	"gorm.io/gorm"
+)
+
+type txKey struct{}
+
+// WithTx returns a copy of ctx carrying tx. Repositories reached with the
+// returned context run their queries inside tx instead of on the pool.
+func WithTx(ctx context.Context, tx *gorm.DB) context.Context {
+	return context.WithValue(ctx, txKey{}, tx)
+}
+
+// InTx reports whether ctx carries a transaction bound by WithTx.
+func InTx(ctx context.Context) bool {
+	_, ok := ctx.Value(txKey{}).(*gorm.DB)
+	return ok
+}
+
+// FromContext returns the transaction bound to ctx by WithTx, or db's
+// connection when there is none. The result is already scoped to ctx.
+func FromContext(ctx context.Context, db Database) *gorm.DB {
+	if tx, ok := ctx.Value(txKey{}).(*gorm.DB); ok {
+		return tx.WithContext(ctx)
+	}
+	return db.DB().WithContext(ctx)
+}
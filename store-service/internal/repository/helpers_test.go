@@ -0,0 +1,201 @@
+package repository
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/1tsndre/mini-go-project/store-service/internal/repository/databases"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// testDB is a GORM database in dry-run mode: statements are built and
+// recorded but never sent to PostgreSQL, so repository queries can be
+// asserted without a running database.
+type testDB struct {
+	db       *gorm.DB
+	recorder *sqlRecorder
+}
+
+func (t *testDB) DB() *gorm.DB {
+	return t.db
+}
+
+// txContext returns ctx bound to a dry-run transaction, so repository code
+// that opens its own transaction nests a savepoint in it instead of trying
+// to reach PostgreSQL for a BEGIN.
+func (t *testDB) txContext(ctx context.Context) context.Context {
+	tx := t.db.Session(&gorm.Session{})
+	tx.Statement.ConnPool = dryRunTx{ConnPool: tx.Statement.ConnPool}
+	return databases.WithTx(ctx, tx)
+}
+
+// dryRunTx passes a connection pool off as an open transaction.
+type dryRunTx struct {
+	gorm.ConnPool
+}
+
+func (dryRunTx) Commit() error   { return nil }
+func (dryRunTx) Rollback() error { return nil }
+
+func newDryRunDB(t *testing.T) *testDB {
+	t.Helper()
+
+	recorder := &sqlRecorder{}
+	db, err := gorm.Open(postgres.New(postgres.Config{
+		DSN: "host=localhost user=test dbname=test sslmode=disable",
+	}), &gorm.Config{
+		DryRun:                 true,
+		SkipDefaultTransaction: true,
+		DisableAutomaticPing:   true,
+		Logger:                 recorder,
+	})
+	if err != nil {
+		t.Fatalf("failed to open dry-run db: %v", err)
+	}
+
+	return &testDB{db: db, recorder: recorder}
+}
+
+// sqlRecorder is a GORM logger that keeps every traced statement with its
+// bind variables inlined.
+type sqlRecorder struct {
+	mu         sync.Mutex
+	statements []string
+}
+
+func (r *sqlRecorder) LogMode(logger.LogLevel) logger.Interface      { return r }
+func (r *sqlRecorder) Info(context.Context, string, ...interface{})  {}
+func (r *sqlRecorder) Warn(context.Context, string, ...interface{})  {}
+func (r *sqlRecorder) Error(context.Context, string, ...interface{}) {}
+func (r *sqlRecorder) Trace(_ context.Context, _ time.Time, fc func() (string, int64), _ error) {
+	sql, _ := fc()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.statements = append(r.statements, sql)
+}
+
+func (r *sqlRecorder) Statements() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]string(nil), r.statements...)
+}
+
+func (r *sqlRecorder) Last() string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if len(r.statements) == 0 {
+		return ""
+	}
+	return r.statements[len(r.statements)-1]
+}
+
+// memoryCache is an in-process caches.Cache that records TTLs but never
+// expires anything.
+type memoryCache struct {
+	mu    sync.Mutex
+	items map[string][]byte
+	ttls  map[string]time.Duration
+}
+
+func newMemoryCache() *memoryCache {
+	return &memoryCache{items: make(map[string][]byte), ttls: make(map[string]time.Duration)}
+}
+
+func (c *memoryCache) Get(_ context.Context, key string) ([]byte, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	data, ok := c.items[key]
+	if !ok {
+		return nil, errors.New("cache miss")
+	}
+	return data, nil
+}
+
+func (c *memoryCache) DeleteIfEqual(_ context.Context, key string, value any) (bool, error) {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return false, err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if current, ok := c.items[key]; !ok || !bytes.Equal(current, data) {
+		return false, nil
+	}
+	delete(c.items, key)
+	return true, nil
+}
+
+func (c *memoryCache) Set(_ context.Context, key string, value any, ttl time.Duration) error {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.items[key] = data
+	c.ttls[key] = ttl
+	return nil
+}
+
+// ttl returns the TTL key was last set with.
+func (c *memoryCache) ttl(key string) time.Duration {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.ttls[key]
+}
+
+func (c *memoryCache) Delete(_ context.Context, key string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.items, key)
+	return nil
+}
+
+func (c *memoryCache) Invalidate(ctx context.Context, key string) error {
+	return c.Delete(ctx, key)
+}
+
+func (c *memoryCache) Exists(_ context.Context, key string) (bool, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	_, ok := c.items[key]
+	return ok, nil
+}
+
+func (c *memoryCache) Incr(_ context.Context, key string, _ time.Duration) (int64, error) {
+	return c.add(key, 1)
+}
+
+func (c *memoryCache) Decr(_ context.Context, key string) (int64, error) {
+	return c.add(key, -1)
+}
+
+func (c *memoryCache) Subscribe(ctx context.Context, _ func(key string)) error {
+	<-ctx.Done()
+	return nil
+}
+
+func (c *memoryCache) add(key string, delta int64) (int64, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	var n int64
+	if data, ok := c.items[key]; ok {
+		if err := json.Unmarshal(data, &n); err != nil {
+			return 0, err
+		}
+	}
+	n += delta
+	data, err := json.Marshal(n)
+	if err != nil {
+		return 0, err
+	}
+	c.items[key] = data
+	return n, nil
+}
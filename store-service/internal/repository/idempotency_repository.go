@@ -0,0 +1,46 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/1tsndre/mini-go-project/store-service/internal/constant"
+	"github.com/1tsndre/mini-go-project/store-service/internal/model"
+	"github.com/1tsndre/mini-go-project/store-service/internal/repository/caches"
+	"github.com/google/uuid"
+)
+
+// IdempotencyRepository stores checkout idempotency records in the cache.
+// Keys are scoped per user, so two users may pick the same key.
+type IdempotencyRepository interface {
+	Get(ctx context.Context, userID uuid.UUID, key string) (*model.IdempotencyRecord, error)
+	Save(ctx context.Context, userID uuid.UUID, key string, record model.IdempotencyRecord) error
+}
+
+type idempotencyRepository struct {
+	cache caches.Cache
+	ttl   time.Duration
+}
+
+func NewIdempotencyRepository(cache caches.Cache, ttl time.Duration) IdempotencyRepository {
+	return &idempotencyRepository{cache: cache, ttl: ttl}
+}
+
+func (r *idempotencyRepository) Get(ctx context.Context, userID uuid.UUID, key string) (*model.IdempotencyRecord, error) {
+	data, err := r.cache.Get(ctx, fmt.Sprintf(constant.KeyCheckoutIdempotency, userID.String(), key))
+	if err != nil {
+		return nil, err
+	}
+
+	var record model.IdempotencyRecord
+	if err := json.Unmarshal(data, &record); err != nil {
+		return nil, err
+	}
+	return &record, nil
+}
+
+func (r *idempotencyRepository) Save(ctx context.Context, userID uuid.UUID, key string, record model.IdempotencyRecord) error {
+	return r.cache.Set(ctx, fmt.Sprintf(constant.KeyCheckoutIdempotency, userID.String(), key), record, r.ttl)
+}
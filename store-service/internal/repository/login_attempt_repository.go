@@ -0,0 +1,47 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/1tsndre/mini-go-project/store-service/internal/constant"
+	"github.com/1tsndre/mini-go-project/store-service/internal/repository/caches"
+)
+
+// LoginAttemptRepository tracks failed logins per email in the cache. The
+// failure count expires window after the latest failure, and a lock lasts
+// for cooldown.
+type LoginAttemptRepository interface {
+	RecordFailure(ctx context.Context, email string) (int64, error)
+	Reset(ctx context.Context, email string) error
+	Lock(ctx context.Context, email string) error
+	IsLocked(ctx context.Context, email string) (bool, error)
+}
+
+type loginAttemptRepository struct {
+	cache    caches.Cache
+	window   time.Duration
+	cooldown time.Duration
+}
+
+func NewLoginAttemptRepository(cache caches.Cache, window, cooldown time.Duration) LoginAttemptRepository {
+	return &loginAttemptRepository{cache: cache, window: window, cooldown: cooldown}
+}
+
+// RecordFailure counts a failed login and returns the failures so far.
+func (r *loginAttemptRepository) RecordFailure(ctx context.Context, email string) (int64, error) {
+	return r.cache.Incr(ctx, fmt.Sprintf(constant.KeyLoginFailures, email), r.window)
+}
+
+func (r *loginAttemptRepository) Reset(ctx context.Context, email string) error {
+	return r.cache.Delete(ctx, fmt.Sprintf(constant.KeyLoginFailures, email))
+}
+
+func (r *loginAttemptRepository) Lock(ctx context.Context, email string) error {
+	return r.cache.Set(ctx, fmt.Sprintf(constant.KeyLoginLock, email), 1, r.cooldown)
+}
+
+func (r *loginAttemptRepository) IsLocked(ctx context.Context, email string) (bool, error) {
+	return r.cache.Exists(ctx, fmt.Sprintf(constant.KeyLoginLock, email))
+}
@@ -2,21 +2,39 @@ package repository
 
 import (
 	"context"
+	"time"
 
+	"github.com/1tsndre/mini-go-project/store-service/internal/constant"
 	"github.com/1tsndre/mini-go-project/store-service/internal/model"
 	"github.com/1tsndre/mini-go-project/store-service/internal/repository/databases"
 	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
 )
 
 type OrderRepository interface {
 	Create(ctx context.Context, order *model.Order) error
+	Delete(ctx context.Context, id uuid.UUID) error
 	FindByID(ctx context.Context, id uuid.UUID) (*model.Order, error)
 	FindByUserID(ctx context.Context, userID uuid.UUID, page, perPage int) ([]model.Order, int64, error)
 	FindByStoreID(ctx context.Context, storeID uuid.UUID, page, perPage int) ([]model.Order, int64, error)
+	FindAll(ctx context.Context, filter model.OrderFilter) ([]model.Order, int64, error)
+	FindStatusesByUser(ctx context.Context, userID uuid.UUID, ids []uuid.UUID) ([]model.Order, error)
 	UpdateStatus(ctx context.Context, id uuid.UUID, status string) error
+	UpdateStatusFrom(ctx context.Context, id uuid.UUID, from, to string) (bool, error)
+	AddStatusHistory(ctx context.Context, entry *model.OrderStatusHistory) error
+	FindStatusHistory(ctx context.Context, orderID uuid.UUID) ([]model.OrderStatusHistory, error)
 	CreatePayment(ctx context.Context, payment *model.Payment) error
 	UpdatePayment(ctx context.Context, payment *model.Payment) error
 	FindPaymentByOrderID(ctx context.Context, orderID uuid.UUID) (*model.Payment, error)
+	CreateReservation(ctx context.Context, reservation *model.StockReservation) error
+	FindReservation(ctx context.Context, orderID uuid.UUID) (*model.StockReservation, error)
+	UpdateReservationStatus(ctx context.Context, orderID uuid.UUID, from, to string) (bool, error)
+	FindExpiredReservations(ctx context.Context, now time.Time, limit int) ([]model.StockReservation, error)
+	FindStalePending(ctx context.Context, before time.Time, after *model.Order, limit int) ([]model.Order, error)
+	CountByStore(ctx context.Context, storeID uuid.UUID, statuses []string) (int64, error)
+	StoreRevenue(ctx context.Context, storeID uuid.UUID, statuses []string) (decimal.Decimal, error)
+	StoreRevenueBetween(ctx context.Context, storeID uuid.UUID, statuses []string, from, to time.Time) (decimal.Decimal, error)
 }
 
 type orderRepository struct {
@@ -28,12 +46,26 @@ func NewOrderRepository(db databases.Database) OrderRepository {
 }
 
 func (r *orderRepository) Create(ctx context.Context, order *model.Order) error {
-	return r.db.DB().WithContext(ctx).Create(order).Error
+	return databases.FromContext(ctx, r.db).Create(order).Error
+}
+
+// Delete removes an order together with its items. It is only meant for
+// undoing a checkout that could not be completed.
+func (r *orderRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	return databases.FromContext(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Delete(&model.OrderItem{}, "order_id = ?", id).Error; err != nil {
+			return err
+		}
+		if err := tx.Delete(&model.StockReservation{}, "order_id = ?", id).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&model.Order{}, "id = ?", id).Error
+	})
 }
 
 func (r *orderRepository) FindByID(ctx context.Context, id uuid.UUID) (*model.Order, error) {
 	var order model.Order
-	err := r.db.DB().WithContext(ctx).
+	err := databases.FromContext(ctx, r.db).
 		Preload("OrderItems").
 		Preload("Payment").
 		First(&order, "id = ?", id).Error
@@ -47,7 +79,7 @@ func (r *orderRepository) FindByUserID(ctx context.Context, userID uuid.UUID, pa
 	var orders []model.Order
 	var total int64
 
-	query := r.db.DB().WithContext(ctx).Model(&model.Order{}).Where("user_id = ?", userID)
+	query := databases.FromContext(ctx, r.db).Model(&model.Order{}).Where("user_id = ?", userID)
 
 	if err := query.Count(&total).Error; err != nil {
 		return nil, 0, err
@@ -65,11 +97,47 @@ func (r *orderRepository) FindByUserID(ctx context.Context, userID uuid.UUID, pa
 	return orders, total, err
 }
 
+// FindAll lists orders across all users, newest first, applying only the
+// filters that are set.
+func (r *orderRepository) FindAll(ctx context.Context, filter model.OrderFilter) ([]model.Order, int64, error) {
+	var orders []model.Order
+	var total int64
+
+	query := databases.FromContext(ctx, r.db).Model(&model.Order{})
+	if filter.Status != "" {
+		query = query.Where("status = ?", filter.Status)
+	}
+	if filter.UserID != uuid.Nil {
+		query = query.Where("user_id = ?", filter.UserID)
+	}
+	if !filter.CreatedFrom.IsZero() {
+		query = query.Where("created_at >= ?", filter.CreatedFrom)
+	}
+	if !filter.CreatedTo.IsZero() {
+		query = query.Where("created_at < ?", filter.CreatedTo)
+	}
+
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	offset := (filter.Page - 1) * filter.PerPage
+	err := query.
+		Preload("OrderItems").
+		Preload("Payment").
+		Order("created_at DESC").
+		Offset(offset).
+		Limit(filter.PerPage).
+		Find(&orders).Error
+
+	return orders, total, err
+}
+
 func (r *orderRepository) FindByStoreID(ctx context.Context, storeID uuid.UUID, page, perPage int) ([]model.Order, int64, error) {
 	var orders []model.Order
 	var total int64
 
-	query := r.db.DB().WithContext(ctx).Model(&model.Order{}).
+	query := databases.FromContext(ctx, r.db).Model(&model.Order{}).
 		Joins("JOIN order_items ON order_items.order_id = orders.id").
 		Joins("JOIN products ON products.id = order_items.product_id").
 		Where("products.store_id = ?", storeID).
@@ -80,11 +148,16 @@ func (r *orderRepository) FindByStoreID(ctx context.Context, storeID uuid.UUID,
 	}
 
 	offset := (page - 1) * perPage
-	err := r.db.DB().WithContext(ctx).
+	err := databases.FromContext(ctx, r.db).
 		Preload("OrderItems").
+		// The product's store tells the seller's items apart in orders
+		// that span several stores.
+		Preload("OrderItems.Product", func(db *gorm.DB) *gorm.DB {
+			return db.Select("id", "store_id")
+		}).
 		Preload("Payment").
 		Where("id IN (?)",
-			r.db.DB().WithContext(ctx).Model(&model.Order{}).
+			databases.FromContext(ctx, r.db).Model(&model.Order{}).
 				Select("DISTINCT orders.id").
 				Joins("JOIN order_items ON order_items.order_id = orders.id").
 				Joins("JOIN products ON products.id = order_items.product_id").
@@ -98,27 +171,174 @@ func (r *orderRepository) FindByStoreID(ctx context.Context, storeID uuid.UUID,
 	return orders, total, err
 }
 
+// FindStatusesByUser loads only the id and status of the given orders, skipping
+// any that do not belong to userID.
+func (r *orderRepository) FindStatusesByUser(ctx context.Context, userID uuid.UUID, ids []uuid.UUID) ([]model.Order, error) {
+	var orders []model.Order
+	err := databases.FromContext(ctx, r.db).
+		Select("id", "status").
+		Where("user_id = ? AND id IN ?", userID, ids).
+		Find(&orders).Error
+	return orders, err
+}
+
 func (r *orderRepository) UpdateStatus(ctx context.Context, id uuid.UUID, status string) error {
-	return r.db.DB().WithContext(ctx).
+	return databases.FromContext(ctx, r.db).
 		Model(&model.Order{}).
 		Where("id = ?", id).
 		Update("status", status).Error
 }
 
+// UpdateStatusFrom moves order id to status to only if it is still in
+// status from, reporting whether it was. Concurrent transitions out of the
+// same status therefore have exactly one winner.
+func (r *orderRepository) UpdateStatusFrom(ctx context.Context, id uuid.UUID, from, to string) (bool, error) {
+	result := databases.FromContext(ctx, r.db).
+		Model(&model.Order{}).
+		Where("id = ? AND status = ?", id, from).
+		Update("status", to)
+	return result.RowsAffected > 0, result.Error
+}
+
+func (r *orderRepository) AddStatusHistory(ctx context.Context, entry *model.OrderStatusHistory) error {
+	return databases.FromContext(ctx, r.db).Create(entry).Error
+}
+
+// FindStatusHistory returns an order's status changes, oldest first.
+func (r *orderRepository) FindStatusHistory(ctx context.Context, orderID uuid.UUID) ([]model.OrderStatusHistory, error) {
+	var history []model.OrderStatusHistory
+	err := databases.FromContext(ctx, r.db).
+		Where("order_id = ?", orderID).
+		Order("created_at ASC").
+		Find(&history).Error
+	return history, err
+}
+
 func (r *orderRepository) CreatePayment(ctx context.Context, payment *model.Payment) error {
-	return r.db.DB().WithContext(ctx).Create(payment).Error
+	return databases.FromContext(ctx, r.db).Create(payment).Error
 }
 
 func (r *orderRepository) UpdatePayment(ctx context.Context, payment *model.Payment) error {
-	return r.db.DB().WithContext(ctx).Save(payment).Error
+	return databases.FromContext(ctx, r.db).Save(payment).Error
 }
 
 func (r *orderRepository) FindPaymentByOrderID(ctx context.Context, orderID uuid.UUID) (*model.Payment, error) {
 	var payment model.Payment
-	err := r.db.DB().WithContext(ctx).First(&payment, "order_id = ?", orderID).Error
+	err := databases.FromContext(ctx, r.db).First(&payment, "order_id = ?", orderID).Error
 	if err != nil {
 		return nil, err
 	}
 	return &payment, nil
 }
 
+func (r *orderRepository) CreateReservation(ctx context.Context, reservation *model.StockReservation) error {
+	return databases.FromContext(ctx, r.db).Create(reservation).Error
+}
+
+func (r *orderRepository) FindReservation(ctx context.Context, orderID uuid.UUID) (*model.StockReservation, error) {
+	var reservation model.StockReservation
+	err := databases.FromContext(ctx, r.db).First(&reservation, "order_id = ?", orderID).Error
+	if err != nil {
+		return nil, err
+	}
+	return &reservation, nil
+}
+
+// UpdateReservationStatus moves an order's reservation from one status to
+// another and reports whether it did. Only one of a payment and the reaper
+// racing for the same active reservation gets true.
+func (r *orderRepository) UpdateReservationStatus(ctx context.Context, orderID uuid.UUID, from, to string) (bool, error) {
+	result := databases.FromContext(ctx, r.db).
+		Model(&model.StockReservation{}).
+		Where("order_id = ? AND status = ?", orderID, from).
+		Update("status", to)
+	return result.RowsAffected > 0, result.Error
+}
+
+// FindExpiredReservations returns active reservations past their expiry
+// whose orders are still pending, oldest expiry first.
+func (r *orderRepository) FindExpiredReservations(ctx context.Context, now time.Time, limit int) ([]model.StockReservation, error) {
+	var reservations []model.StockReservation
+	err := databases.FromContext(ctx, r.db).
+		Joins("JOIN orders ON orders.id = stock_reservations.order_id").
+		Where("stock_reservations.status = ? AND stock_reservations.expires_at < ? AND orders.status = ?",
+			model.ReservationStatusActive, now, constant.OrderStatusPending).
+		Order("stock_reservations.expires_at ASC").
+		Limit(limit).
+		Find(&reservations).Error
+	return reservations, err
+}
+
+// FindStalePending returns orders that have been pending since before
+// before, longest waiting first. Pass the last order of the previous page as
+// after to continue past it, or nil for the first page.
+func (r *orderRepository) FindStalePending(ctx context.Context, before time.Time, after *model.Order, limit int) ([]model.Order, error) {
+	query := databases.FromContext(ctx, r.db).
+		Where("status = ? AND updated_at < ?", constant.OrderStatusPending, before)
+	if after != nil {
+		query = query.Where("(updated_at, id) > (?, ?)", after.UpdatedAt, after.ID)
+	}
+
+	var orders []model.Order
+	err := query.
+		Order("updated_at ASC, id ASC").
+		Limit(limit).
+		Find(&orders).Error
+	return orders, err
+}
+
+// storeOrderItems scopes a query to the order items that belong to storeID,
+// joined with their orders.
+func storeOrderItems(db *gorm.DB, storeID uuid.UUID) *gorm.DB {
+	return db.Table("order_items").
+		Joins("JOIN orders ON orders.id = order_items.order_id").
+		Joins("JOIN products ON products.id = order_items.product_id").
+		Where("products.store_id = ?", storeID)
+}
+
+// CountByStore counts the distinct orders containing items from storeID. An
+// empty statuses slice counts orders in any status.
+func (r *orderRepository) CountByStore(ctx context.Context, storeID uuid.UUID, statuses []string) (int64, error) {
+	var count int64
+	query := storeOrderItems(databases.FromContext(ctx, r.db), storeID)
+	if len(statuses) > 0 {
+		query = query.Where("orders.status IN ?", statuses)
+	}
+	err := query.Distinct("orders.id").Count(&count).Error
+	return count, err
+}
+
+// StoreRevenue sums price * quantity of storeID's order items in orders with
+// one of the given statuses. Only the store's own items are counted, since an
+// order may contain products from several stores.
+func (r *orderRepository) StoreRevenue(ctx context.Context, storeID uuid.UUID, statuses []string) (decimal.Decimal, error) {
+	return sumRevenue(storeOrderItems(databases.FromContext(ctx, r.db), storeID).
+		Where("orders.status IN ?", statuses))
+}
+
+// StoreRevenueBetween is StoreRevenue restricted to orders that reached
+// their current status in [from, to), going by the status history: for
+// completed orders, when they were completed. Later edits to an order do
+// not move it to another period.
+func (r *orderRepository) StoreRevenueBetween(ctx context.Context, storeID uuid.UUID, statuses []string, from, to time.Time) (decimal.Decimal, error) {
+	return sumRevenue(storeOrderItems(databases.FromContext(ctx, r.db), storeID).
+		Where("orders.status IN ?", statuses).
+		Where("EXISTS (SELECT 1 FROM order_status_history h WHERE h.order_id = orders.id "+
+			"AND h.to_status = orders.status AND h.created_at >= ? AND h.created_at < ?)", from, to))
+}
+
+func sumRevenue(query *gorm.DB) (decimal.Decimal, error) {
+	var result struct {
+		Revenue decimal.NullDecimal
+	}
+	err := query.
+		Select("SUM(order_items.price * order_items.quantity) AS revenue").
+		Find(&result).Error
+	if err != nil {
+		return decimal.Zero, err
+	}
+	if !result.Revenue.Valid {
+		return decimal.Zero, nil
+	}
+	return result.Revenue.Decimal, nil
+}
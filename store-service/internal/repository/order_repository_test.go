@@ -0,0 +1,216 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/1tsndre/mini-go-project/store-service/internal/constant"
+	"github.com/1tsndre/mini-go-project/store-service/internal/model"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOrderRepository_StoreRevenue(t *testing.T) {
+	db := newDryRunDB(t)
+	repo := NewOrderRepository(db)
+	storeID := uuid.MustParse("8a3c2a52-6f1e-4c1b-9d7a-2f3b6f0c9e11")
+
+	revenue, err := repo.StoreRevenue(context.Background(), storeID, []string{constant.OrderStatusPaid, constant.OrderStatusCompleted})
+
+	assert.NoError(t, err)
+	assert.True(t, revenue.IsZero())
+	assert.Equal(t,
+		`SELECT SUM(order_items.price * order_items.quantity) AS revenue FROM "order_items" `+
+			`JOIN orders ON orders.id = order_items.order_id `+
+			`JOIN products ON products.id = order_items.product_id `+
+			`WHERE products.store_id = '8a3c2a52-6f1e-4c1b-9d7a-2f3b6f0c9e11' AND orders.status IN ('paid','completed')`,
+		db.recorder.Last(),
+	)
+}
+
+func TestOrderRepository_StoreRevenueBetween(t *testing.T) {
+	db := newDryRunDB(t)
+	repo := NewOrderRepository(db)
+	storeID := uuid.MustParse("8a3c2a52-6f1e-4c1b-9d7a-2f3b6f0c9e11")
+	from := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2026, 2, 1, 0, 0, 0, 0, time.UTC)
+
+	_, err := repo.StoreRevenueBetween(context.Background(), storeID, []string{constant.OrderStatusCompleted}, from, to)
+
+	assert.NoError(t, err)
+	assert.Equal(t,
+		`SELECT SUM(order_items.price * order_items.quantity) AS revenue FROM "order_items" `+
+			`JOIN orders ON orders.id = order_items.order_id `+
+			`JOIN products ON products.id = order_items.product_id `+
+			`WHERE products.store_id = '8a3c2a52-6f1e-4c1b-9d7a-2f3b6f0c9e11' AND orders.status IN ('completed') `+
+			`AND (EXISTS (SELECT 1 FROM order_status_history h WHERE h.order_id = orders.id `+
+			`AND h.to_status = orders.status AND h.created_at >= '2026-01-01 00:00:00' AND h.created_at < '2026-02-01 00:00:00'))`,
+		db.recorder.Last(),
+	)
+}
+
+func TestOrderRepository_CountByStore(t *testing.T) {
+	storeID := uuid.MustParse("8a3c2a52-6f1e-4c1b-9d7a-2f3b6f0c9e11")
+	base := `SELECT COUNT(DISTINCT("orders"."id")) FROM "order_items" ` +
+		`JOIN orders ON orders.id = order_items.order_id ` +
+		`JOIN products ON products.id = order_items.product_id ` +
+		`WHERE products.store_id = '8a3c2a52-6f1e-4c1b-9d7a-2f3b6f0c9e11'`
+
+	tests := []struct {
+		name     string
+		statuses []string
+		wantSQL  string
+	}{
+		{name: "all statuses", wantSQL: base},
+		{name: "filtered statuses", statuses: []string{constant.OrderStatusPaid, constant.OrderStatusProcessing}, wantSQL: base + ` AND orders.status IN ('paid','processing')`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := newDryRunDB(t)
+			repo := NewOrderRepository(db)
+
+			_, err := repo.CountByStore(context.Background(), storeID, tt.statuses)
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.wantSQL, db.recorder.Last())
+		})
+	}
+}
+
+func TestOrderRepository_FindStatusesByUser(t *testing.T) {
+	db := newDryRunDB(t)
+	repo := NewOrderRepository(db)
+	userID := uuid.MustParse("0f8b6c1e-3d2a-4b5c-8e9f-1a2b3c4d5e6f")
+	orderID := uuid.MustParse("9c1d2e3f-4a5b-4c6d-8e7f-0a1b2c3d4e5f")
+
+	_, err := repo.FindStatusesByUser(context.Background(), userID, []uuid.UUID{orderID})
+
+	assert.NoError(t, err)
+	assert.Equal(t,
+		`SELECT "id","status" FROM "orders" WHERE user_id = '0f8b6c1e-3d2a-4b5c-8e9f-1a2b3c4d5e6f' `+
+			`AND id IN ('9c1d2e3f-4a5b-4c6d-8e7f-0a1b2c3d4e5f')`,
+		db.recorder.Last(),
+	)
+}
+
+func TestOrderRepository_FindAll(t *testing.T) {
+	userID := uuid.MustParse("8a3c2a52-6f1e-4c1b-9d7a-2f3b6f0c9e11")
+	from := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2026, 2, 1, 0, 0, 0, 0, time.UTC)
+	base := `SELECT count(*) FROM "orders"`
+
+	tests := []struct {
+		name      string
+		filter    model.OrderFilter
+		wantCount string
+	}{
+		{
+			name:      "no filters",
+			filter:    model.OrderFilter{},
+			wantCount: base,
+		},
+		{
+			name:      "status",
+			filter:    model.OrderFilter{Status: constant.OrderStatusPaid},
+			wantCount: base + ` WHERE status = 'paid'`,
+		},
+		{
+			name:      "user",
+			filter:    model.OrderFilter{UserID: userID},
+			wantCount: base + ` WHERE user_id = '8a3c2a52-6f1e-4c1b-9d7a-2f3b6f0c9e11'`,
+		},
+		{
+			name:      "from only",
+			filter:    model.OrderFilter{CreatedFrom: from},
+			wantCount: base + ` WHERE created_at >= '2026-01-01 00:00:00'`,
+		},
+		{
+			name:      "to only",
+			filter:    model.OrderFilter{CreatedTo: to},
+			wantCount: base + ` WHERE created_at < '2026-02-01 00:00:00'`,
+		},
+		{
+			name:      "date range",
+			filter:    model.OrderFilter{CreatedFrom: from, CreatedTo: to},
+			wantCount: base + ` WHERE created_at >= '2026-01-01 00:00:00' AND created_at < '2026-02-01 00:00:00'`,
+		},
+		{
+			name:   "all filters",
+			filter: model.OrderFilter{Status: constant.OrderStatusCompleted, UserID: userID, CreatedFrom: from, CreatedTo: to},
+			wantCount: base + ` WHERE status = 'completed' AND user_id = '8a3c2a52-6f1e-4c1b-9d7a-2f3b6f0c9e11' ` +
+				`AND created_at >= '2026-01-01 00:00:00' AND created_at < '2026-02-01 00:00:00'`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := newDryRunDB(t)
+			repo := NewOrderRepository(db)
+			tt.filter.Page, tt.filter.PerPage = 2, 10
+
+			_, _, err := repo.FindAll(context.Background(), tt.filter)
+
+			assert.NoError(t, err)
+			statements := db.recorder.Statements()
+			if assert.NotEmpty(t, statements) {
+				assert.Equal(t, tt.wantCount, statements[0])
+			}
+		})
+	}
+}
+
+func TestOrderRepository_FindStatusHistory(t *testing.T) {
+	db := newDryRunDB(t)
+	repo := NewOrderRepository(db)
+	orderID := uuid.MustParse("8a3c2a52-6f1e-4c1b-9d7a-2f3b6f0c9e11")
+
+	_, err := repo.FindStatusHistory(context.Background(), orderID)
+
+	assert.NoError(t, err)
+	assert.Equal(t,
+		`SELECT * FROM "order_status_history" WHERE order_id = '8a3c2a52-6f1e-4c1b-9d7a-2f3b6f0c9e11' ORDER BY created_at ASC`,
+		db.recorder.Last(),
+	)
+}
+
+func TestOrderRepository_UpdateStatusFrom(t *testing.T) {
+	db := newDryRunDB(t)
+	repo := NewOrderRepository(db)
+	orderID := uuid.MustParse("8a3c2a52-6f1e-4c1b-9d7a-2f3b6f0c9e11")
+
+	_, err := repo.UpdateStatusFrom(context.Background(), orderID, constant.OrderStatusPending, constant.OrderStatusPaid)
+
+	assert.NoError(t, err)
+	assert.Contains(t, db.recorder.Last(),
+		`UPDATE "orders" SET "status"='paid',"updated_at"=`)
+	assert.Contains(t, db.recorder.Last(),
+		`WHERE id = '8a3c2a52-6f1e-4c1b-9d7a-2f3b6f0c9e11' AND status = 'pending'`)
+}
+
+func TestOrderRepository_FindStalePending(t *testing.T) {
+	db := newDryRunDB(t)
+	repo := NewOrderRepository(db)
+	before := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	_, err := repo.FindStalePending(context.Background(), before, nil, 50)
+
+	assert.NoError(t, err)
+	assert.Equal(t,
+		`SELECT * FROM "orders" WHERE status = 'pending' AND updated_at < '2026-01-01 12:00:00' ORDER BY updated_at ASC, id ASC LIMIT 50`,
+		db.recorder.Last(),
+	)
+
+	after := &model.Order{
+		ID:        uuid.MustParse("8a3c2a52-6f1e-4c1b-9d7a-2f3b6f0c9e11"),
+		UpdatedAt: time.Date(2026, 1, 1, 11, 0, 0, 0, time.UTC),
+	}
+	_, err = repo.FindStalePending(context.Background(), before, after, 50)
+
+	assert.NoError(t, err)
+	assert.Equal(t,
+		`SELECT * FROM "orders" WHERE (status = 'pending' AND updated_at < '2026-01-01 12:00:00') AND (updated_at, id) > ('2026-01-01 11:00:00', '8a3c2a52-6f1e-4c1b-9d7a-2f3b6f0c9e11') ORDER BY updated_at ASC, id ASC LIMIT 50`,
+		db.recorder.Last(),
+	)
+}
@@ -0,0 +1,55 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/1tsndre/mini-go-project/store-service/internal/model"
+	"github.com/1tsndre/mini-go-project/store-service/internal/repository/databases"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type OutboxRepository interface {
+	Create(ctx context.Context, event *model.OutboxEvent) error
+	FindPending(ctx context.Context, limit int) ([]model.OutboxEvent, error)
+	MarkPublished(ctx context.Context, id uuid.UUID) error
+	IncrementAttempts(ctx context.Context, id uuid.UUID) error
+}
+
+type outboxRepository struct {
+	db databases.Database
+}
+
+func NewOutboxRepository(db databases.Database) OutboxRepository {
+	return &outboxRepository{db: db}
+}
+
+func (r *outboxRepository) Create(ctx context.Context, event *model.OutboxEvent) error {
+	return databases.FromContext(ctx, r.db).Create(event).Error
+}
+
+// FindPending returns the oldest unpublished events first.
+func (r *outboxRepository) FindPending(ctx context.Context, limit int) ([]model.OutboxEvent, error) {
+	var events []model.OutboxEvent
+	err := databases.FromContext(ctx, r.db).
+		Where("published_at IS NULL").
+		Order("created_at ASC").
+		Limit(limit).
+		Find(&events).Error
+	return events, err
+}
+
+func (r *outboxRepository) MarkPublished(ctx context.Context, id uuid.UUID) error {
+	return databases.FromContext(ctx, r.db).
+		Model(&model.OutboxEvent{}).
+		Where("id = ?", id).
+		Update("published_at", time.Now()).Error
+}
+
+func (r *outboxRepository) IncrementAttempts(ctx context.Context, id uuid.UUID) error {
+	return databases.FromContext(ctx, r.db).
+		Model(&model.OutboxEvent{}).
+		Where("id = ?", id).
+		Update("attempts", gorm.Expr("attempts + 1")).Error
+}
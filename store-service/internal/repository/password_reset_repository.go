@@ -0,0 +1,56 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/1tsndre/mini-go-project/store-service/internal/constant"
+	"github.com/1tsndre/mini-go-project/store-service/internal/repository/caches"
+	"github.com/google/uuid"
+)
+
+// PasswordResetRepository keeps the hash of each user's outstanding password
+// reset token in the cache. A user has at most one: saving a new token
+// replaces the previous one, and entries expire after the configured TTL.
+type PasswordResetRepository interface {
+	Save(ctx context.Context, userID uuid.UUID, tokenHash string) error
+	// Get returns the user's token hash without removing it, so a wrong
+	// guess leaves the outstanding token usable.
+	Get(ctx context.Context, userID uuid.UUID) (string, error)
+	// Consume removes the user's token hash if it is still tokenHash and
+	// reports whether it did, so two resets racing with the same token
+	// cannot both succeed.
+	Consume(ctx context.Context, userID uuid.UUID, tokenHash string) (bool, error)
+}
+
+type passwordResetRepository struct {
+	cache caches.Cache
+	ttl   time.Duration
+}
+
+func NewPasswordResetRepository(cache caches.Cache, ttl time.Duration) PasswordResetRepository {
+	return &passwordResetRepository{cache: cache, ttl: ttl}
+}
+
+func (r *passwordResetRepository) Save(ctx context.Context, userID uuid.UUID, tokenHash string) error {
+	return r.cache.Set(ctx, fmt.Sprintf(constant.KeyPasswordReset, userID.String()), tokenHash, r.ttl)
+}
+
+func (r *passwordResetRepository) Get(ctx context.Context, userID uuid.UUID) (string, error) {
+	data, err := r.cache.Get(ctx, fmt.Sprintf(constant.KeyPasswordReset, userID.String()))
+	if err != nil {
+		return "", err
+	}
+
+	var tokenHash string
+	if err := json.Unmarshal(data, &tokenHash); err != nil {
+		return "", err
+	}
+	return tokenHash, nil
+}
+
+func (r *passwordResetRepository) Consume(ctx context.Context, userID uuid.UUID, tokenHash string) (bool, error) {
+	return r.cache.DeleteIfEqual(ctx, fmt.Sprintf(constant.KeyPasswordReset, userID.String()), tokenHash)
+}
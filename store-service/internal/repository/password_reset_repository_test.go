@@ -0,0 +1,37 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPasswordResetRepository_Consume(t *testing.T) {
+	ctx := context.Background()
+	repo := NewPasswordResetRepository(newMemoryCache(), time.Hour)
+	userID := uuid.New()
+
+	assert.NoError(t, repo.Save(ctx, userID, "hash"))
+
+	consumed, err := repo.Consume(ctx, userID, "other")
+	assert.NoError(t, err)
+	assert.False(t, consumed, "a different hash must not remove the token")
+
+	hash, err := repo.Get(ctx, userID)
+	assert.NoError(t, err)
+	assert.Equal(t, "hash", hash)
+
+	consumed, err = repo.Consume(ctx, userID, "hash")
+	assert.NoError(t, err)
+	assert.True(t, consumed)
+
+	consumed, err = repo.Consume(ctx, userID, "hash")
+	assert.NoError(t, err)
+	assert.False(t, consumed, "a token can only be consumed once")
+
+	_, err = repo.Get(ctx, userID)
+	assert.Error(t, err)
+}
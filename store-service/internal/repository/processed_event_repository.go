@@ -0,0 +1,39 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/1tsndre/mini-go-project/store-service/internal/constant"
+	"github.com/1tsndre/mini-go-project/store-service/internal/repository/caches"
+)
+
+// ProcessedEventRepository remembers which NSQ event ids a consumer has
+// handled, so a redelivered event can be acknowledged without handling it
+// again. Marks expire after ttl; NSQ redelivers well within that.
+type ProcessedEventRepository interface {
+	// Processed reports whether consumer has already handled eventID.
+	Processed(ctx context.Context, consumer, eventID string) (bool, error)
+	// MarkProcessed records that consumer has handled eventID. Call it only
+	// once handling has succeeded, so a crash midway leaves the event to be
+	// redelivered.
+	MarkProcessed(ctx context.Context, consumer, eventID string) error
+}
+
+type processedEventRepository struct {
+	cache caches.Cache
+	ttl   time.Duration
+}
+
+func NewProcessedEventRepository(cache caches.Cache, ttl time.Duration) ProcessedEventRepository {
+	return &processedEventRepository{cache: cache, ttl: ttl}
+}
+
+func (r *processedEventRepository) Processed(ctx context.Context, consumer, eventID string) (bool, error) {
+	return r.cache.Exists(ctx, fmt.Sprintf(constant.KeyProcessedEvent, consumer, eventID))
+}
+
+func (r *processedEventRepository) MarkProcessed(ctx context.Context, consumer, eventID string) error {
+	return r.cache.Set(ctx, fmt.Sprintf(constant.KeyProcessedEvent, consumer, eventID), true, r.ttl)
+}
@@ -0,0 +1,28 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProcessedEventRepository_MarkProcessed(t *testing.T) {
+	ctx := context.Background()
+	repo := NewProcessedEventRepository(newMemoryCache(), time.Hour)
+
+	done, err := repo.Processed(ctx, "store-service", "evt-1")
+	assert.NoError(t, err)
+	assert.False(t, done)
+
+	assert.NoError(t, repo.MarkProcessed(ctx, "store-service", "evt-1"))
+
+	done, err = repo.Processed(ctx, "store-service", "evt-1")
+	assert.NoError(t, err)
+	assert.True(t, done)
+
+	done, err = repo.Processed(ctx, "audit-service", "evt-1")
+	assert.NoError(t, err)
+	assert.False(t, done, "marks are per consumer")
+}
@@ -2,76 +2,344 @@ package repository
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
 
+	"github.com/1tsndre/mini-go-project/pkg/money"
 	"github.com/1tsndre/mini-go-project/store-service/internal/constant"
 	"github.com/1tsndre/mini-go-project/store-service/internal/model"
+	"github.com/1tsndre/mini-go-project/store-service/internal/pagination"
 	"github.com/1tsndre/mini-go-project/store-service/internal/repository/caches"
 	"github.com/1tsndre/mini-go-project/store-service/internal/repository/databases"
 	"github.com/google/uuid"
-	"github.com/shopspring/decimal"
+	"golang.org/x/sync/singleflight"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 var allowedProductSortFields = map[string]bool{
 	"price":      true,
 	"name":       true,
 	"created_at": true,
+	"rating":     true,
 }
 
+// productRatingSQL is a product's average review rating, with unreviewed
+// products counting as 0.
+const productRatingSQL = "(SELECT COALESCE(AVG(reviews.rating), 0) FROM reviews WHERE reviews.product_id = products.id)"
+
 type ProductRepository interface {
-	Create(ctx context.Context, product *model.Product) error
+	Create(ctx context.Context, product *model.Product, movements ...model.StockMovement) error
 	FindAll(ctx context.Context, filter model.ProductFilter) ([]model.Product, int64, error)
 	FindByID(ctx context.Context, id uuid.UUID) (*model.Product, error)
-	Update(ctx context.Context, product *model.Product) error
+	Update(ctx context.Context, product *model.Product, movements ...model.StockMovement) error
 	Delete(ctx context.Context, id uuid.UUID) error
-	UpdateStock(ctx context.Context, id uuid.UUID, quantity int) error
+	UpdateStock(ctx context.Context, product *model.Product, quantity int, movements ...model.StockMovement) error
+	FindByStoreIDInBatches(ctx context.Context, storeID uuid.UUID, batchSize int, fn func([]model.Product) error) error
+	CountImagesByStore(ctx context.Context, storeID uuid.UUID) (int64, error)
+	CountByStore(ctx context.Context, storeID uuid.UUID) (int64, error)
+	SetAttributes(ctx context.Context, product *model.Product, attrs map[string]string) error
+	FindAlsoBought(ctx context.Context, productID uuid.UUID, limit int) ([]model.Product, error)
+	FindImages(ctx context.Context, productID uuid.UUID) ([]model.ProductImage, error)
+	AddImage(ctx context.Context, product *model.Product, image *model.ProductImage) error
+	UpdateImage(ctx context.Context, product *model.Product, image *model.ProductImage) error
+	DeleteImage(ctx context.Context, product *model.Product, image *model.ProductImage, promoted *model.ProductImage) error
+	SaveImageOrder(ctx context.Context, product *model.Product, images []model.ProductImage) error
+	InvalidateStoreListings(ctx context.Context, storeID uuid.UUID)
+	InvalidateCategoryListings(ctx context.Context, categoryIDs ...uuid.UUID)
 }
 
 type productRepository struct {
-	db    databases.Database
-	cache caches.Cache
+	db                 databases.Database
+	cache              caches.Cache
+	cacheTTL           time.Duration
+	listCacheTTL       time.Duration
+	alsoBoughtCacheTTL time.Duration
+	// loads collapses concurrent FindByID cache misses for the same product
+	// into a single database read.
+	loads singleflight.Group
+}
+
+// NewProductRepository creates a ProductRepository. cacheTTL is how long
+// FindByID results are cached, listCacheTTL how long FindAll results are and
+// alsoBoughtCacheTTL how long FindAlsoBought results are; zero disables the
+// respective caching.
+func NewProductRepository(db databases.Database, cache caches.Cache, cacheTTL, listCacheTTL, alsoBoughtCacheTTL time.Duration) ProductRepository {
+	return &productRepository{db: db, cache: cache, cacheTTL: cacheTTL, listCacheTTL: listCacheTTL, alsoBoughtCacheTTL: alsoBoughtCacheTTL}
+}
+
+// Create inserts product. Stock movements given with it are recorded in the
+// stock ledger in the same transaction, as are those given to Update and
+// UpdateStock.
+func (r *productRepository) Create(ctx context.Context, product *model.Product, movements ...model.StockMovement) error {
+	err := r.withMovements(ctx, product, movements, func(tx *gorm.DB) error {
+		return tx.Create(product).Error
+	})
+	if err != nil {
+		return err
+	}
+	r.invalidateLists(ctx, product.StoreID, product.CategoryID)
+	return nil
 }
 
-func NewProductRepository(db databases.Database, cache caches.Cache) ProductRepository {
-	return &productRepository{db: db, cache: cache}
+// withMovements runs write and records movements of product's stock in the
+// same transaction, so the ledger never disagrees with the stock it
+// explains. Without movements write runs on its own.
+func (r *productRepository) withMovements(ctx context.Context, product *model.Product, movements []model.StockMovement, write func(tx *gorm.DB) error) error {
+	db := databases.FromContext(ctx, r.db)
+	if len(movements) == 0 {
+		return write(db)
+	}
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := write(tx); err != nil {
+			return err
+		}
+		// A new product only has its ID once inserted.
+		for i := range movements {
+			movements[i].ProductID = product.ID
+		}
+		return tx.Create(&movements).Error
+	})
 }
 
-func (r *productRepository) Create(ctx context.Context, product *model.Product) error {
-	return r.db.DB().WithContext(ctx).Create(product).Error
+// productListPage is the cached form of one FindAll result.
+type productListPage struct {
+	Products []model.Product `json:"products"`
+	Total    int64           `json:"total"`
 }
 
 func (r *productRepository) FindAll(ctx context.Context, filter model.ProductFilter) ([]model.Product, int64, error) {
+	if r.listCacheTTL <= 0 || filter.SkipCache {
+		return r.findAll(ctx, filter)
+	}
+
+	cacheKey, err := r.listCacheKey(ctx, filter)
+	if err != nil {
+		return r.findAll(ctx, filter)
+	}
+
+	cached, err := r.cache.Get(ctx, cacheKey)
+	if err == nil {
+		var page productListPage
+		if json.Unmarshal(cached, &page) == nil {
+			return page.Products, page.Total, nil
+		}
+	}
+
+	products, total, err := r.findAll(ctx, filter)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	r.cache.Set(ctx, cacheKey, productListPage{Products: products, Total: total}, r.listCacheTTL)
+
+	return products, total, nil
+}
+
+// listCacheKey hashes the filter together with the generation of every
+// scope the listing depends on: its store and category when filtered by
+// them, the whole catalog otherwise. Bumping a generation orphans every
+// cached page built on it, which then ages out on its own TTL.
+func (r *productRepository) listCacheKey(ctx context.Context, filter model.ProductFilter) (string, error) {
+	var scopes []string
+	if filter.StoreID != "" {
+		scopes = append(scopes, "store:"+filter.StoreID)
+	}
+	if filter.CategoryID != "" && !filter.IncludeSubcategories {
+		scopes = append(scopes, "category:"+filter.CategoryID)
+	}
+	// A subtree listing spans categories that are not known up front, so it
+	// hangs off the catalog generation, which every catalog change bumps.
+	if len(scopes) == 0 || filter.IncludeSubcategories {
+		scopes = append(scopes, "all")
+	}
+
+	gens := make([]string, len(scopes))
+	for i, scope := range scopes {
+		gen, err := r.cache.Get(ctx, fmt.Sprintf(constant.KeyProductListGen, scope))
+		if err == nil {
+			gens[i] = string(gen)
+		}
+	}
+
+	body, err := json.Marshal(struct {
+		Filter model.ProductFilter
+		Gens   []string
+	}{filter, gens})
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(body)
+	return fmt.Sprintf(constant.KeyProductList, hex.EncodeToString(sum[:])), nil
+}
+
+// invalidateLists starts a new generation for the catalog and for the given
+// store and categories, so listings that could include a changed product are
+// recomputed on their next read.
+func (r *productRepository) invalidateLists(ctx context.Context, storeID uuid.UUID, categoryIDs ...uuid.UUID) {
+	scopes := []string{"all", "store:" + storeID.String()}
+	for _, id := range categoryIDs {
+		scopes = append(scopes, "category:"+id.String())
+	}
+	r.bumpGenerations(ctx, r.listCacheTTL, scopes...)
+}
+
+// bumpGenerations moves the given KeyProductListGen scopes to a new
+// generation. The keys expire after ttl, the lifetime of the results built
+// on them: by then no result cached under the generation before is left to
+// be served again.
+func (r *productRepository) bumpGenerations(ctx context.Context, ttl time.Duration, scopes ...string) {
+	if ttl <= 0 {
+		return
+	}
+
+	gen := uuid.NewString()
+	for _, scope := range scopes {
+		r.cache.Set(ctx, fmt.Sprintf(constant.KeyProductListGen, scope), gen, ttl)
+	}
+}
+
+// InvalidateStoreListings drops cached listings that could include the
+// store's products, for changes made outside this repository such as the
+// store being deactivated.
+func (r *productRepository) InvalidateStoreListings(ctx context.Context, storeID uuid.UUID) {
+	r.invalidateLists(ctx, storeID)
+	// Also-bought rankings are cached per product, so any of them may have
+	// left out the store's products while it was inactive.
+	r.bumpGenerations(ctx, r.alsoBoughtCacheTTL, alsoBoughtScope)
+}
+
+// InvalidateCategoryListings drops cached listings of the given categories
+// and of every subtree, for changes to the category hierarchy such as a
+// category moving between parents. Subtree listings hang off the catalog
+// generation, so bumping it also covers the ancestors above each parent.
+func (r *productRepository) InvalidateCategoryListings(ctx context.Context, categoryIDs ...uuid.UUID) {
+	scopes := []string{"all"}
+	for _, id := range categoryIDs {
+		scopes = append(scopes, "category:"+id.String())
+	}
+	r.bumpGenerations(ctx, r.listCacheTTL, scopes...)
+}
+
+// alsoBoughtScope is the KeyProductListGen scope of also-bought results.
+const alsoBoughtScope = "also_bought"
+
+// listScopes returns the store and category a product currently belongs to,
+// for invalidating listings before it is changed or removed.
+func (r *productRepository) listScopes(ctx context.Context, id uuid.UUID) (uuid.UUID, uuid.UUID, bool) {
+	if r.listCacheTTL <= 0 {
+		return uuid.Nil, uuid.Nil, false
+	}
+
+	var product model.Product
+	err := databases.FromContext(ctx, r.db).
+		Select("store_id", "category_id").
+		First(&product, "id = ?", id).Error
+	if err != nil {
+		return uuid.Nil, uuid.Nil, false
+	}
+	return product.StoreID, product.CategoryID, true
+}
+
+// categorySubtreeSQL selects a category and every category below it. UNION
+// rather than UNION ALL stops the walk should a cycle ever slip past the
+// service checks.
+const categorySubtreeSQL = `WITH RECURSIVE subtree AS (SELECT id FROM categories WHERE id = ? ` +
+	`UNION SELECT c.id FROM categories c JOIN subtree ON c.parent_id = subtree.id) SELECT id FROM subtree`
+
+// activeStoreSQL keeps products whose store is active and whose seller is
+// not banned.
+const activeStoreSQL = "EXISTS (SELECT 1 FROM stores s JOIN users u ON u.id = s.user_id " +
+	"WHERE s.id = products.store_id AND s.active AND u.active)"
+
+func (r *productRepository) findAll(ctx context.Context, filter model.ProductFilter) ([]model.Product, int64, error) {
 	var products []model.Product
 	var total int64
 
-	query := r.db.DB().WithContext(ctx).Model(&model.Product{})
+	query := databases.FromContext(ctx, r.db).Model(&model.Product{}).Where("NOT products.unavailable")
+
+	if !filter.IncludeInactiveStores {
+		query = query.Where(activeStoreSQL)
+	}
 
 	if filter.CategoryID != "" {
-		query = query.Where("category_id = ?", filter.CategoryID)
+		if filter.IncludeSubcategories {
+			query = query.Where("category_id IN ("+categorySubtreeSQL+")", filter.CategoryID)
+		} else {
+			query = query.Where("category_id = ?", filter.CategoryID)
+		}
 	}
 	if filter.StoreID != "" {
 		query = query.Where("store_id = ?", filter.StoreID)
 	}
-	if filter.Search != "" {
-		search := "%" + filter.Search + "%"
+	if filter.ExcludeStoreID != "" {
+		query = query.Where("store_id <> ?", filter.ExcludeStoreID)
+	}
+	if filter.ExcludeID != "" {
+		query = query.Where("id <> ?", filter.ExcludeID)
+	}
+	fullText := useFullTextSearch(filter.Search)
+	if fullText {
+		query = query.Where(productSearchMatchSQL, filter.Search)
+	} else if strings.TrimSpace(filter.Search) != "" {
+		search := containsPattern(filter.Search)
 		query = query.Where("name ILIKE ? OR description ILIKE ?", search, search)
 	}
 	if filter.MinPrice != "" {
-		if minPrice, err := decimal.NewFromString(filter.MinPrice); err == nil {
+		if minPrice, err := money.Parse(filter.MinPrice, money.NonNegative); err == nil {
 			query = query.Where("price >= ?", minPrice)
 		}
 	}
 	if filter.MaxPrice != "" {
-		if maxPrice, err := decimal.NewFromString(filter.MaxPrice); err == nil {
+		if maxPrice, err := money.Parse(filter.MaxPrice, money.NonNegative); err == nil {
 			query = query.Where("price <= ?", maxPrice)
 		}
 	}
+	keys := make([]string, 0, len(filter.Attributes))
+	for key := range filter.Attributes {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		query = query.Where("EXISTS (SELECT 1 FROM product_attributes pa WHERE pa.product_id = products.id AND pa.key = ? AND pa.value = ?)",
+			key, filter.Attributes[key])
+	}
+	query = query.Preload("Attributes")
+
+	if filter.Cursor != "" {
+		products, err := r.findAfterCursor(query, filter)
+		return products, 0, err
+	}
 
 	if err := query.Count(&total).Error; err != nil {
 		return nil, 0, err
 	}
 
+	if fullText && filter.SortBy == "" {
+		query = query.Order(productSearchOrder(filter))
+	} else {
+		query = query.Order(productListOrder(filter))
+	}
+
+	offset := (filter.Page - 1) * filter.PerPage
+	err := query.
+		Offset(offset).
+		Limit(filter.PerPage).
+		Find(&products).Error
+
+	return products, total, err
+}
+
+// productListOrder is the ORDER BY of a page-based listing. With
+// InStockFirst, out-of-stock products sink below every in-stock one while
+// the chosen sort still applies within each group.
+func productListOrder(filter model.ProductFilter) string {
 	sortBy := "created_at"
 	if filter.SortBy != "" {
 		if allowedProductSortFields[filter.SortBy] {
@@ -83,16 +351,80 @@ func (r *productRepository) FindAll(ctx context.Context, filter model.ProductFil
 		sortOrder = "ASC"
 	}
 
-	offset := (filter.Page - 1) * filter.PerPage
-	err := query.Order(fmt.Sprintf("%s %s", sortBy, sortOrder)).
-		Offset(offset).
+	order := fmt.Sprintf("%s %s", sortBy, sortOrder)
+	if sortBy == "rating" {
+		// Many products share an average, so ties go to the newest.
+		order = fmt.Sprintf("%s %s, created_at DESC", productRatingSQL, sortOrder)
+	}
+	if filter.InStockFirst {
+		order = "(stock > 0) DESC, " + order
+	}
+	return order
+}
+
+// productSearchMatchSQL matches the search_vector column, generated from
+// name and description with the same 'english' configuration, so words are
+// compared by stem ("running" finds "run").
+const productSearchMatchSQL = "search_vector @@ plainto_tsquery('english', ?)"
+
+// useFullTextSearch reports whether search goes through the full-text index.
+// Very short terms keep the ILIKE substring match.
+func useFullTextSearch(search string) bool {
+	return utf8.RuneCountInString(strings.TrimSpace(search)) >= constant.MinFullTextSearchLength
+}
+
+// likeEscaper escapes the LIKE wildcards, and backslash, PostgreSQL's
+// default LIKE escape character, itself.
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// containsPattern is the ILIKE pattern matching term, trimmed, anywhere in
+// a value. Wildcards in term match themselves, so "50%" does not match
+// every value starting with "50".
+func containsPattern(term string) string {
+	return "%" + likeEscaper.Replace(strings.TrimSpace(term)) + "%"
+}
+
+// productSearchOrder ranks full-text matches by relevance, name hits above
+// description hits, when no explicit sort was chosen. Equal ranks keep the
+// newest-first default.
+func productSearchOrder(filter model.ProductFilter) clause.OrderBy {
+	sql := "ts_rank(search_vector, plainto_tsquery('english', ?)) DESC, created_at DESC"
+	if filter.InStockFirst {
+		sql = "(stock > 0) DESC, " + sql
+	}
+	return clause.OrderBy{Expression: clause.Expr{SQL: sql, Vars: []interface{}{filter.Search}, WithoutParentheses: true}}
+}
+
+// findAfterCursor pages through query by (created_at, id) instead of an
+// offset, so rows inserted ahead of the cursor never shift later pages.
+// SortBy is ignored in this mode and no total is counted.
+func (r *productRepository) findAfterCursor(query *gorm.DB, filter model.ProductFilter) ([]model.Product, error) {
+	cursor, err := pagination.DecodeCursor(filter.Cursor)
+	if err != nil {
+		return nil, err
+	}
+
+	op, sortOrder := "<", "DESC"
+	if filter.SortOrder == "asc" {
+		op, sortOrder = ">", "ASC"
+	}
+
+	var products []model.Product
+	err = query.
+		Where(fmt.Sprintf("(created_at, id) %s (?, ?)", op), cursor.CreatedAt, cursor.ID).
+		Order(fmt.Sprintf("created_at %s, id %s", sortOrder, sortOrder)).
 		Limit(filter.PerPage).
 		Find(&products).Error
-
-	return products, total, err
+	return products, err
 }
 
 func (r *productRepository) FindByID(ctx context.Context, id uuid.UUID) (*model.Product, error) {
+	// A transaction may see its own uncommitted writes, which must neither
+	// be cached nor shared with callers outside it.
+	if r.cacheTTL <= 0 || databases.InTx(ctx) {
+		return r.findByID(ctx, id)
+	}
+
 	cacheKey := fmt.Sprintf(constant.KeyProduct, id.String())
 
 	cached, err := r.cache.Get(ctx, cacheKey)
@@ -103,43 +435,365 @@ func (r *productRepository) FindByID(ctx context.Context, id uuid.UUID) (*model.
 		}
 	}
 
+	// Callers waiting on the same load share its result, so each gets its
+	// own copy to modify. The load must not fail for all of them because the
+	// caller that started it went away, so it ignores that caller's
+	// cancellation and each caller stops waiting on its own.
+	loadCtx := context.WithoutCancel(ctx)
+	loading := r.loads.DoChan(id.String(), func() (interface{}, error) {
+		product, err := r.findByID(loadCtx, id)
+		if err != nil {
+			return nil, err
+		}
+		r.cache.Set(loadCtx, cacheKey, product, r.cacheTTL)
+		return product, nil
+	})
+	var loaded singleflight.Result
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case loaded = <-loading:
+	}
+	if loaded.Err != nil {
+		return nil, loaded.Err
+	}
+	product := *loaded.Val.(*model.Product)
+	product.Attributes = append([]model.ProductAttribute(nil), product.Attributes...)
+	return &product, nil
+}
+
+func (r *productRepository) findByID(ctx context.Context, id uuid.UUID) (*model.Product, error) {
 	var product model.Product
-	err = r.db.DB().WithContext(ctx).First(&product, "id = ?", id).Error
+	err := databases.FromContext(ctx, r.db).Preload("Attributes").First(&product, "id = ?", id).Error
 	if err != nil {
 		return nil, err
 	}
-
-	r.cache.Set(ctx, cacheKey, product, constant.TTLProduct)
-
 	return &product, nil
 }
 
-func (r *productRepository) Update(ctx context.Context, product *model.Product) error {
-	if err := r.db.DB().WithContext(ctx).Save(product).Error; err != nil {
+func (r *productRepository) Update(ctx context.Context, product *model.Product, movements ...model.StockMovement) error {
+	_, oldCategoryID, found := r.listScopes(ctx, product.ID)
+
+	// Attributes are only written through SetAttributes.
+	err := r.withMovements(ctx, product, movements, func(tx *gorm.DB) error {
+		return tx.Omit("Attributes").Save(product).Error
+	})
+	if err != nil {
 		return err
 	}
 	cacheKey := fmt.Sprintf(constant.KeyProduct, product.ID.String())
-	r.cache.Delete(ctx, cacheKey)
+	r.cache.Invalidate(ctx, cacheKey)
+
+	categories := []uuid.UUID{product.CategoryID}
+	if found && oldCategoryID != product.CategoryID {
+		categories = append(categories, oldCategoryID)
+	}
+	r.invalidateLists(ctx, product.StoreID, categories...)
 	return nil
 }
 
 func (r *productRepository) Delete(ctx context.Context, id uuid.UUID) error {
-	if err := r.db.DB().WithContext(ctx).Delete(&model.Product{}, "id = ?", id).Error; err != nil {
+	storeID, categoryID, found := r.listScopes(ctx, id)
+
+	if err := databases.FromContext(ctx, r.db).Delete(&model.Product{}, "id = ?", id).Error; err != nil {
 		return err
 	}
 	cacheKey := fmt.Sprintf(constant.KeyProduct, id.String())
-	r.cache.Delete(ctx, cacheKey)
+	r.cache.Invalidate(ctx, cacheKey)
+	if found {
+		r.invalidateLists(ctx, storeID, categoryID)
+	}
 	return nil
 }
 
-func (r *productRepository) UpdateStock(ctx context.Context, id uuid.UUID, quantity int) error {
-	if err := r.db.DB().WithContext(ctx).
-		Model(&model.Product{}).
-		Where("id = ?", id).
-		Update("stock", quantity).Error; err != nil {
+// SetAttributes replaces all attributes of product with attrs.
+func (r *productRepository) SetAttributes(ctx context.Context, product *model.Product, attrs map[string]string) error {
+	rows := make([]model.ProductAttribute, 0, len(attrs))
+	for key, value := range attrs {
+		rows = append(rows, model.ProductAttribute{ProductID: product.ID, Key: key, Value: value})
+	}
+	sort.Slice(rows, func(i, j int) bool { return rows[i].Key < rows[j].Key })
+
+	err := databases.FromContext(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Delete(&model.ProductAttribute{}, "product_id = ?", product.ID).Error; err != nil {
+			return err
+		}
+		if len(rows) == 0 {
+			return nil
+		}
+		return tx.Create(&rows).Error
+	})
+	if err != nil {
 		return err
 	}
-	cacheKey := fmt.Sprintf(constant.KeyProduct, id.String())
-	r.cache.Delete(ctx, cacheKey)
+
+	product.Attributes = rows
+	r.cache.Invalidate(ctx, fmt.Sprintf(constant.KeyProduct, product.ID.String()))
+	r.invalidateLists(ctx, product.StoreID, product.CategoryID)
+	return nil
+}
+
+// FindImages returns the product's images in display order.
+func (r *productRepository) FindImages(ctx context.Context, productID uuid.UUID) ([]model.ProductImage, error) {
+	images := []model.ProductImage{}
+	err := databases.FromContext(ctx, r.db).
+		Where("product_id = ?", productID).
+		Order("sort_order, created_at").
+		Find(&images).Error
+	return images, err
+}
+
+// AddImage stores a new image of product. A primary image also becomes the
+// product's ImageURL.
+func (r *productRepository) AddImage(ctx context.Context, product *model.Product, image *model.ProductImage) error {
+	image.ProductID = product.ID
+	err := databases.FromContext(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(image).Error; err != nil {
+			return err
+		}
+		if !image.IsPrimary {
+			return nil
+		}
+		return syncPrimaryImage(tx, product, image)
+	})
+	if err != nil {
+		return err
+	}
+	r.invalidateProduct(ctx, product)
 	return nil
 }
+
+// UpdateImage replaces the files of an existing image, keeping its position.
+func (r *productRepository) UpdateImage(ctx context.Context, product *model.Product, image *model.ProductImage) error {
+	err := databases.FromContext(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		err := tx.Model(&model.ProductImage{}).
+			Where("id = ? AND product_id = ?", image.ID, product.ID).
+			Updates(map[string]interface{}{
+				"url":           image.URL,
+				"thumbnail_url": image.ThumbnailURL,
+			}).Error
+		if err != nil {
+			return err
+		}
+		if !image.IsPrimary {
+			return nil
+		}
+		return syncPrimaryImage(tx, product, image)
+	})
+	if err != nil {
+		return err
+	}
+	r.invalidateProduct(ctx, product)
+	return nil
+}
+
+// DeleteImage removes image from product. When the primary image is
+// deleted, promoted (if any) becomes the new primary; with nothing left to
+// promote the product's ImageURL is cleared.
+func (r *productRepository) DeleteImage(ctx context.Context, product *model.Product, image *model.ProductImage, promoted *model.ProductImage) error {
+	err := databases.FromContext(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Delete(&model.ProductImage{}, "id = ? AND product_id = ?", image.ID, product.ID).Error; err != nil {
+			return err
+		}
+		if !image.IsPrimary {
+			return nil
+		}
+		if promoted != nil {
+			if err := tx.Model(&model.ProductImage{}).Where("id = ?", promoted.ID).Update("is_primary", true).Error; err != nil {
+				return err
+			}
+			promoted.IsPrimary = true
+		}
+		return syncPrimaryImage(tx, product, promoted)
+	})
+	if err != nil {
+		return err
+	}
+	r.invalidateProduct(ctx, product)
+	return nil
+}
+
+// SaveImageOrder stores the SortOrder and IsPrimary of every image of
+// product and points the product's ImageURL at the primary one.
+func (r *productRepository) SaveImageOrder(ctx context.Context, product *model.Product, images []model.ProductImage) error {
+	var primary *model.ProductImage
+	err := databases.FromContext(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		// Demote first: at most one primary image per product is allowed at
+		// any moment.
+		if err := tx.Model(&model.ProductImage{}).Where("product_id = ?", product.ID).Update("is_primary", false).Error; err != nil {
+			return err
+		}
+		for i := range images {
+			err := tx.Model(&model.ProductImage{}).
+				Where("id = ? AND product_id = ?", images[i].ID, product.ID).
+				Updates(map[string]interface{}{
+					"sort_order": images[i].SortOrder,
+					"is_primary": images[i].IsPrimary,
+				}).Error
+			if err != nil {
+				return err
+			}
+			if images[i].IsPrimary {
+				primary = &images[i]
+			}
+		}
+		return syncPrimaryImage(tx, product, primary)
+	})
+	if err != nil {
+		return err
+	}
+	r.invalidateProduct(ctx, product)
+	return nil
+}
+
+// syncPrimaryImage mirrors primary into the product's ImageURL and
+// ThumbnailURL, which older clients still read; nil clears them.
+func syncPrimaryImage(tx *gorm.DB, product *model.Product, primary *model.ProductImage) error {
+	imageURL, thumbnailURL := "", ""
+	if primary != nil {
+		imageURL, thumbnailURL = primary.URL, primary.ThumbnailURL
+	}
+	err := tx.Model(&model.Product{}).
+		Where("id = ?", product.ID).
+		Updates(map[string]interface{}{
+			"image_url":     imageURL,
+			"thumbnail_url": thumbnailURL,
+		}).Error
+	if err != nil {
+		return err
+	}
+	product.ImageURL, product.ThumbnailURL = imageURL, thumbnailURL
+	return nil
+}
+
+func (r *productRepository) invalidateProduct(ctx context.Context, product *model.Product) {
+	r.cache.Invalidate(ctx, fmt.Sprintf(constant.KeyProduct, product.ID.String()))
+	r.invalidateLists(ctx, product.StoreID, product.CategoryID)
+}
+
+// UpdateStock sets product's stock to quantity; product itself is left
+// as loaded. Since every checkout comes through here, only the listings of
+// product's store and category are recomputed at once. Catalog-wide ones
+// pick up the new stock when their cached pages expire.
+func (r *productRepository) UpdateStock(ctx context.Context, product *model.Product, quantity int, movements ...model.StockMovement) error {
+	err := r.withMovements(ctx, product, movements, func(tx *gorm.DB) error {
+		return tx.Model(&model.Product{}).
+			Where("id = ?", product.ID).
+			Update("stock", quantity).Error
+	})
+	if err != nil {
+		return err
+	}
+	cacheKey := fmt.Sprintf(constant.KeyProduct, product.ID.String())
+	r.cache.Invalidate(ctx, cacheKey)
+	r.bumpGenerations(ctx, r.listCacheTTL, "store:"+product.StoreID.String(), "category:"+product.CategoryID.String())
+	return nil
+}
+
+func (r *productRepository) FindByStoreIDInBatches(ctx context.Context, storeID uuid.UUID, batchSize int, fn func([]model.Product) error) error {
+	var batch []model.Product
+	return databases.FromContext(ctx, r.db).
+		Preload("Category").
+		Where("store_id = ?", storeID).
+		FindInBatches(&batch, batchSize, func(_ *gorm.DB, _ int) error {
+			return fn(batch)
+		}).Error
+}
+
+func (r *productRepository) CountImagesByStore(ctx context.Context, storeID uuid.UUID) (int64, error) {
+	var count int64
+	err := databases.FromContext(ctx, r.db).
+		Model(&model.ProductImage{}).
+		Joins("JOIN products ON products.id = product_images.product_id").
+		Where("products.store_id = ?", storeID).
+		Count(&count).Error
+	return count, err
+}
+
+func (r *productRepository) CountByStore(ctx context.Context, storeID uuid.UUID) (int64, error) {
+	var count int64
+	err := databases.FromContext(ctx, r.db).
+		Model(&model.Product{}).
+		Where("store_id = ?", storeID).
+		Count(&count).Error
+	return count, err
+}
+
+// FindAlsoBought returns up to limit other products that were bought in the
+// same orders as productID, most co-purchased first. Only orders that were
+// actually paid for count, and only products of active stores are returned.
+// The ranking is cached as ids and not invalidated on new orders; it ages
+// out after alsoBoughtCacheTTL. The products themselves are always loaded
+// fresh, so stock, prices and deletions show at once.
+func (r *productRepository) FindAlsoBought(ctx context.Context, productID uuid.UUID, limit int) ([]model.Product, error) {
+	ids, err := r.alsoBoughtIDs(ctx, productID, limit)
+	if err != nil {
+		return nil, err
+	}
+	if len(ids) == 0 {
+		return []model.Product{}, nil
+	}
+
+	var found []model.Product
+	err = databases.FromContext(ctx, r.db).
+		Where("id IN ?", ids).
+		Where("NOT products.unavailable").
+		Where(activeStoreSQL).
+		Find(&found).Error
+	if err != nil {
+		return nil, err
+	}
+
+	byID := make(map[uuid.UUID]model.Product, len(found))
+	for _, p := range found {
+		byID[p.ID] = p
+	}
+	products := make([]model.Product, 0, len(found))
+	for _, id := range ids {
+		if p, ok := byID[id]; ok {
+			products = append(products, p)
+		}
+	}
+	return products, nil
+}
+
+// alsoBoughtIDs ranks the products co-purchased with productID, from the
+// cache when it holds a ranking for the current store generation.
+func (r *productRepository) alsoBoughtIDs(ctx context.Context, productID uuid.UUID, limit int) ([]uuid.UUID, error) {
+	var cacheKey string
+	if r.alsoBoughtCacheTTL > 0 {
+		var gen string
+		if cached, err := r.cache.Get(ctx, fmt.Sprintf(constant.KeyProductListGen, alsoBoughtScope)); err == nil {
+			gen = string(cached)
+		}
+		cacheKey = fmt.Sprintf(constant.KeyProductAlsoBought, productID.String(), limit, gen)
+		cached, err := r.cache.Get(ctx, cacheKey)
+		if err == nil {
+			var ids []uuid.UUID
+			if json.Unmarshal(cached, &ids) == nil {
+				return ids, nil
+			}
+		}
+	}
+
+	var ids []uuid.UUID
+	err := databases.FromContext(ctx, r.db).
+		Table("order_items AS self").
+		Joins("JOIN order_items AS other ON other.order_id = self.order_id AND other.product_id <> self.product_id").
+		Joins("JOIN orders ON orders.id = self.order_id").
+		Joins("JOIN products ON products.id = other.product_id").
+		Where("self.product_id = ? AND orders.status IN ?", productID, constant.RevenueStatuses).
+		Where(activeStoreSQL).
+		Group("other.product_id").
+		Order("COUNT(DISTINCT other.order_id) DESC, other.product_id").
+		Limit(limit).
+		Pluck("other.product_id", &ids).Error
+	if err != nil {
+		return nil, err
+	}
+
+	if r.alsoBoughtCacheTTL > 0 {
+		r.cache.Set(ctx, cacheKey, ids, r.alsoBoughtCacheTTL)
+	}
+	return ids, nil
+}
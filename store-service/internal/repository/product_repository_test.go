@@ -0,0 +1,711 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/1tsndre/mini-go-project/store-service/internal/constant"
+	"github.com/1tsndre/mini-go-project/store-service/internal/model"
+	"github.com/1tsndre/mini-go-project/store-service/internal/pagination"
+	"github.com/1tsndre/mini-go-project/store-service/internal/repository/databases"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+// activeStoreWhere opens the WHERE clause of every public listing, which
+// hides the products of inactive stores.
+const activeStoreWhere = `WHERE NOT products.unavailable AND (EXISTS (SELECT 1 FROM stores s JOIN users u ON u.id = s.user_id ` +
+	`WHERE s.id = products.store_id AND s.active AND u.active)) AND `
+
+func TestProductRepository_FindAll_Cursor(t *testing.T) {
+	createdAt := time.Date(2024, 3, 1, 10, 30, 0, 0, time.UTC)
+	id := uuid.MustParse("5f0c7d3e-2b1a-4c9e-8f7d-6a5b4c3d2e1f")
+	cursor := pagination.EncodeCursor(createdAt, id)
+
+	tests := []struct {
+		name    string
+		filter  model.ProductFilter
+		wantSQL string
+	}{
+		{
+			name:   "newest first",
+			filter: model.ProductFilter{Cursor: cursor, PerPage: 10},
+			wantSQL: `SELECT * FROM "products" ` +
+				activeStoreWhere + `(created_at, id) < ('2024-03-01 10:30:00', '5f0c7d3e-2b1a-4c9e-8f7d-6a5b4c3d2e1f') ` +
+				`ORDER BY created_at DESC, id DESC LIMIT 10`,
+		},
+		{
+			name:   "oldest first with filters",
+			filter: model.ProductFilter{Cursor: cursor, PerPage: 5, SortOrder: "asc", SortBy: "price", CategoryID: "c1"},
+			wantSQL: `SELECT * FROM "products" ` +
+				activeStoreWhere + `category_id = 'c1' AND (created_at, id) > ('2024-03-01 10:30:00', '5f0c7d3e-2b1a-4c9e-8f7d-6a5b4c3d2e1f') ` +
+				`ORDER BY created_at ASC, id ASC LIMIT 5`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := newDryRunDB(t)
+			repo := NewProductRepository(db, nil, 0, 0, 0)
+
+			_, total, err := repo.FindAll(context.Background(), tt.filter)
+
+			assert.NoError(t, err)
+			assert.Zero(t, total)
+			// A single keyset query, no COUNT and no OFFSET: rows inserted
+			// before the cursor position cannot shift the next page.
+			assert.Len(t, db.recorder.Statements(), 1)
+			assert.Equal(t, tt.wantSQL, db.recorder.Last())
+			assert.False(t, strings.Contains(db.recorder.Last(), "OFFSET"))
+		})
+	}
+}
+
+func TestProductRepository_FindByID_ConcurrentMisses(t *testing.T) {
+	db := newDryRunDB(t)
+	release := make(chan struct{})
+	var loads atomic.Int32
+	db.DB().Callback().Query().Before("gorm:query").Register("test:slow_load", func(tx *gorm.DB) {
+		if tx.Statement.Table == "products" {
+			loads.Add(1)
+			<-release
+		}
+	})
+	cache := &countingCache{memoryCache: newMemoryCache()}
+	repo := NewProductRepository(db, cache, time.Minute, 0, 0)
+	id := uuid.MustParse("4a5b6c7d-8e9f-4a0b-9c1d-2e3f4a5b6c7d")
+
+	const callers = 10
+	var wg sync.WaitGroup
+	for i := 0; i < callers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := repo.FindByID(context.Background(), id)
+			assert.NoError(t, err)
+		}()
+	}
+	// Let every caller miss the cache before the first load finishes.
+	assert.Eventually(t, func() bool { return cache.misses.Load() == callers }, time.Second, time.Millisecond)
+	time.Sleep(10 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	assert.Equal(t, int32(1), loads.Load(), "concurrent misses should share one database load")
+}
+
+func TestProductRepository_FindByID_CallerCancelled(t *testing.T) {
+	db := newDryRunDB(t)
+	release := make(chan struct{})
+	var loads atomic.Int32
+	db.DB().Callback().Query().Before("gorm:query").Register("test:slow_load", func(tx *gorm.DB) {
+		if tx.Statement.Table == "products" {
+			loads.Add(1)
+			<-release
+		}
+	})
+	cache := &countingCache{memoryCache: newMemoryCache()}
+	repo := NewProductRepository(db, cache, time.Minute, 0, 0)
+	id := uuid.MustParse("4a5b6c7d-8e9f-4a0b-9c1d-2e3f4a5b6c7d")
+
+	// The first caller starts the load and gives up while it is running.
+	ctx, cancel := context.WithCancel(context.Background())
+	cancelled := make(chan error, 1)
+	go func() {
+		_, err := repo.FindByID(ctx, id)
+		cancelled <- err
+	}()
+	assert.Eventually(t, func() bool { return loads.Load() == 1 }, time.Second, time.Millisecond)
+
+	waiting := make(chan error, 1)
+	go func() {
+		_, err := repo.FindByID(context.Background(), id)
+		waiting <- err
+	}()
+	assert.Eventually(t, func() bool { return cache.misses.Load() == 2 }, time.Second, time.Millisecond)
+	time.Sleep(10 * time.Millisecond)
+
+	cancel()
+	assert.ErrorIs(t, <-cancelled, context.Canceled)
+
+	// The caller still waiting gets the shared load's result, not the
+	// first caller's cancellation.
+	close(release)
+	assert.NoError(t, <-waiting)
+	assert.Equal(t, int32(1), loads.Load())
+}
+
+func TestProductRepository_FindByID_InTransaction(t *testing.T) {
+	db := newDryRunDB(t)
+	cache := &countingCache{memoryCache: newMemoryCache()}
+	repo := NewProductRepository(db, cache, time.Minute, 0, 0)
+	id := uuid.MustParse("4a5b6c7d-8e9f-4a0b-9c1d-2e3f4a5b6c7d")
+
+	ctx := databases.WithTx(context.Background(), db.DB())
+	_, err := repo.FindByID(ctx, id)
+
+	assert.NoError(t, err)
+	assert.Zero(t, cache.misses.Load(), "a transaction reads past the cache")
+	assert.Empty(t, cache.items, "a transaction's view of the row is not cached")
+}
+
+// countingCache counts Get misses.
+type countingCache struct {
+	*memoryCache
+	misses atomic.Int32
+}
+
+func (c *countingCache) Get(ctx context.Context, key string) ([]byte, error) {
+	data, err := c.memoryCache.Get(ctx, key)
+	if err != nil {
+		c.misses.Add(1)
+	}
+	return data, err
+}
+
+func TestProductRepository_FindAll_InvalidCursor(t *testing.T) {
+	db := newDryRunDB(t)
+	repo := NewProductRepository(db, nil, 0, 0, 0)
+
+	_, _, err := repo.FindAll(context.Background(), model.ProductFilter{Cursor: "not-a-cursor", PerPage: 10})
+
+	assert.ErrorIs(t, err, pagination.ErrInvalidCursor)
+	assert.Empty(t, db.recorder.Statements())
+}
+
+func TestProductRepository_FindAll_ListCache(t *testing.T) {
+	storeID := uuid.MustParse("8a3c2a52-6f1e-4c1b-9d7a-2f3b6f0c9e11")
+	categoryID := uuid.MustParse("1b2c3d4e-5f60-4a7b-8c9d-0e1f2a3b4c5d")
+	otherStoreID := uuid.MustParse("0c9e118a-3c2a-4526-9e1e-4c1b9d7a2f3b")
+	filter := model.ProductFilter{StoreID: storeID.String(), Page: 1, PerPage: 10}
+
+	tests := []struct {
+		name       string
+		between    func(repo ProductRepository)
+		filter     model.ProductFilter
+		wantCached bool
+	}{
+		{
+			name:       "identical query hits the cache",
+			filter:     filter,
+			wantCached: true,
+		},
+		{
+			name: "update in the listed store invalidates",
+			between: func(repo ProductRepository) {
+				repo.Update(context.Background(), &model.Product{ID: uuid.New(), StoreID: storeID, CategoryID: categoryID})
+			},
+			filter: filter,
+		},
+		{
+			name: "update in another store keeps the cache",
+			between: func(repo ProductRepository) {
+				repo.Update(context.Background(), &model.Product{ID: uuid.New(), StoreID: otherStoreID, CategoryID: categoryID})
+			},
+			filter:     filter,
+			wantCached: true,
+		},
+		{
+			name: "create in the listed category invalidates",
+			between: func(repo ProductRepository) {
+				repo.Create(context.Background(), &model.Product{StoreID: otherStoreID, CategoryID: categoryID})
+			},
+			filter: model.ProductFilter{CategoryID: categoryID.String(), Page: 1, PerPage: 10},
+		},
+		{
+			name: "stock update in the listed store invalidates",
+			between: func(repo ProductRepository) {
+				repo.UpdateStock(context.Background(), &model.Product{ID: uuid.New(), StoreID: storeID, CategoryID: categoryID}, 3)
+			},
+			filter: filter,
+		},
+		{
+			name: "stock update keeps the catalog cache",
+			between: func(repo ProductRepository) {
+				repo.UpdateStock(context.Background(), &model.Product{ID: uuid.New(), StoreID: storeID, CategoryID: categoryID}, 3)
+			},
+			filter:     model.ProductFilter{Page: 1, PerPage: 10},
+			wantCached: true,
+		},
+		{
+			name: "update keeps nothing in the catalog cache",
+			between: func(repo ProductRepository) {
+				repo.Update(context.Background(), &model.Product{ID: uuid.New(), StoreID: otherStoreID, CategoryID: categoryID})
+			},
+			filter: model.ProductFilter{Page: 1, PerPage: 10},
+		},
+		{
+			name:   "personalized query bypasses the cache",
+			filter: model.ProductFilter{StoreID: storeID.String(), Page: 1, PerPage: 10, SkipCache: true},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := newDryRunDB(t)
+			repo := NewProductRepository(db, newMemoryCache(), 0, time.Minute, 0)
+
+			_, _, err := repo.FindAll(context.Background(), tt.filter)
+			assert.NoError(t, err)
+			if tt.between != nil {
+				tt.between(repo)
+			}
+
+			before := len(db.recorder.Statements())
+			_, _, err = repo.FindAll(context.Background(), tt.filter)
+			assert.NoError(t, err)
+
+			queried := len(db.recorder.Statements()) > before
+			assert.Equal(t, tt.wantCached, !queried)
+		})
+	}
+}
+
+func TestProductRepository_UpdateStock(t *testing.T) {
+	db := newDryRunDB(t)
+	cache := newMemoryCache()
+	repo := NewProductRepository(db, cache, 0, time.Minute, 0)
+	product := &model.Product{
+		ID:         uuid.MustParse("3f9b8c1e-2d4a-4e6b-9c7d-1a2b3c4d5e6f"),
+		StoreID:    uuid.MustParse("8a3c2a52-6f1e-4c1b-9d7a-2f3b6f0c9e11"),
+		CategoryID: uuid.MustParse("1b2c3d4e-5f60-4a7b-8c9d-0e1f2a3b4c5d"),
+		Stock:      5,
+	}
+
+	err := repo.UpdateStock(context.Background(), product, 3)
+
+	assert.NoError(t, err)
+	stmts := db.recorder.Statements()
+	if assert.Len(t, stmts, 1, "the loaded product supplies the listings to invalidate") {
+		assert.Regexp(t, `^UPDATE "products" SET "stock"=3,"updated_at"='[^']+' WHERE id = '3f9b8c1e-2d4a-4e6b-9c7d-1a2b3c4d5e6f'$`, stmts[0])
+	}
+	assert.Equal(t, 5, product.Stock)
+	for _, scope := range []string{"store:" + product.StoreID.String(), "category:" + product.CategoryID.String()} {
+		assert.Equal(t, time.Minute, cache.ttl(fmt.Sprintf(constant.KeyProductListGen, scope)), scope)
+	}
+	_, err = cache.Get(context.Background(), fmt.Sprintf(constant.KeyProductListGen, "all"))
+	assert.Error(t, err, "stock updates leave the catalog generation alone")
+}
+
+func TestProductRepository_UpdateStock_WithMovement(t *testing.T) {
+	db := newDryRunDB(t)
+	repo := NewProductRepository(db, newMemoryCache(), 0, 0, 0)
+	product := &model.Product{ID: uuid.MustParse("3f9b8c1e-2d4a-4e6b-9c7d-1a2b3c4d5e6f"), Stock: 5}
+
+	err := repo.UpdateStock(db.txContext(context.Background()), product, 3, model.StockMovement{
+		Reason:     constant.StockReasonCheckout,
+		Delta:      -2,
+		StockAfter: 3,
+	})
+
+	assert.NoError(t, err)
+	stmts := db.recorder.Statements()
+	if assert.Len(t, stmts, 3, "the ledger entry is written in the stock update's transaction") {
+		assert.Contains(t, stmts[0], "SAVEPOINT")
+		assert.Contains(t, stmts[1], `UPDATE "products" SET "stock"=3`)
+		assert.Contains(t, stmts[2], `INSERT INTO "stock_movements"`)
+		assert.Contains(t, stmts[2], `'3f9b8c1e-2d4a-4e6b-9c7d-1a2b3c4d5e6f','checkout',-2,3`)
+	}
+}
+
+func TestProductRepository_FindAll_AttributeFilter(t *testing.T) {
+	db := newDryRunDB(t)
+	repo := NewProductRepository(db, nil, 0, 0, 0)
+
+	_, _, err := repo.FindAll(context.Background(), model.ProductFilter{
+		Page:       1,
+		PerPage:    10,
+		Attributes: map[string]string{"size": "M", "color": "red"},
+	})
+
+	assert.NoError(t, err)
+	stmts := db.recorder.Statements()
+	if assert.NotEmpty(t, stmts) {
+		// Keys are applied in sorted order so equal filters share a cache key
+		// and produce identical SQL.
+		assert.Contains(t, stmts[0],
+			activeStoreWhere+`(EXISTS (SELECT 1 FROM product_attributes pa WHERE pa.product_id = products.id AND pa.key = 'color' AND pa.value = 'red')) AND `+
+				`(EXISTS (SELECT 1 FROM product_attributes pa WHERE pa.product_id = products.id AND pa.key = 'size' AND pa.value = 'M'))`)
+	}
+}
+
+func TestProductRepository_FindAll_ExcludeStore(t *testing.T) {
+	db := newDryRunDB(t)
+	repo := NewProductRepository(db, nil, 0, 0, 0)
+
+	_, _, err := repo.FindAll(context.Background(), model.ProductFilter{
+		Page:           1,
+		PerPage:        10,
+		ExcludeStoreID: "8a3c2a52-6f1e-4c1b-9d7a-2f3b6f0c9e11",
+	})
+
+	assert.NoError(t, err)
+	stmts := db.recorder.Statements()
+	if assert.NotEmpty(t, stmts) {
+		assert.Equal(t, `SELECT count(*) FROM "products" `+activeStoreWhere+`store_id <> '8a3c2a52-6f1e-4c1b-9d7a-2f3b6f0c9e11'`, stmts[0])
+	}
+}
+
+func TestProductRepository_FindAll_ExcludeID(t *testing.T) {
+	db := newDryRunDB(t)
+	repo := NewProductRepository(db, nil, 0, 0, 0)
+
+	_, _, err := repo.FindAll(context.Background(), model.ProductFilter{
+		Page:       1,
+		PerPage:    10,
+		CategoryID: "1b2c3d4e-5f60-4a7b-8c9d-0e1f2a3b4c5d",
+		ExcludeID:  "3f9b8c1e-2d4a-4e6b-9c7d-1a2b3c4d5e6f",
+	})
+
+	assert.NoError(t, err)
+	stmts := db.recorder.Statements()
+	if assert.NotEmpty(t, stmts) {
+		assert.Equal(t, `SELECT count(*) FROM "products" `+activeStoreWhere+`category_id = '1b2c3d4e-5f60-4a7b-8c9d-0e1f2a3b4c5d' `+
+			`AND id <> '3f9b8c1e-2d4a-4e6b-9c7d-1a2b3c4d5e6f'`, stmts[0])
+	}
+}
+
+func TestProductRepository_FindAll_InactiveStores(t *testing.T) {
+	storeID := "8a3c2a52-6f1e-4c1b-9d7a-2f3b6f0c9e11"
+
+	tests := []struct {
+		name    string
+		filter  model.ProductFilter
+		wantSQL string
+	}{
+		{
+			name:    "public listing hides inactive stores",
+			filter:  model.ProductFilter{StoreID: storeID, Page: 1, PerPage: 10},
+			wantSQL: `SELECT count(*) FROM "products" ` + activeStoreWhere + `store_id = '8a3c2a52-6f1e-4c1b-9d7a-2f3b6f0c9e11'`,
+		},
+		{
+			name:    "seller view keeps them",
+			filter:  model.ProductFilter{StoreID: storeID, IncludeInactiveStores: true, Page: 1, PerPage: 10},
+			wantSQL: `SELECT count(*) FROM "products" WHERE NOT products.unavailable AND store_id = '8a3c2a52-6f1e-4c1b-9d7a-2f3b6f0c9e11'`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := newDryRunDB(t)
+			repo := NewProductRepository(db, nil, 0, 0, 0)
+
+			_, _, err := repo.FindAll(context.Background(), tt.filter)
+
+			assert.NoError(t, err)
+			stmts := db.recorder.Statements()
+			if assert.NotEmpty(t, stmts) {
+				assert.Equal(t, tt.wantSQL, stmts[0])
+			}
+		})
+	}
+
+	t.Run("deactivating the store invalidates its cached listings", func(t *testing.T) {
+		db := newDryRunDB(t)
+		repo := NewProductRepository(db, newMemoryCache(), 0, time.Minute, 0)
+		filter := model.ProductFilter{Page: 1, PerPage: 10}
+
+		_, _, err := repo.FindAll(context.Background(), filter)
+		assert.NoError(t, err)
+
+		repo.InvalidateStoreListings(context.Background(), uuid.MustParse(storeID))
+
+		before := len(db.recorder.Statements())
+		_, _, err = repo.FindAll(context.Background(), filter)
+		assert.NoError(t, err)
+		assert.Greater(t, len(db.recorder.Statements()), before, "listing should be recomputed")
+	})
+
+	t.Run("moving a category invalidates cached subtree listings", func(t *testing.T) {
+		db := newDryRunDB(t)
+		repo := NewProductRepository(db, newMemoryCache(), 0, time.Minute, 0)
+		filter := model.ProductFilter{CategoryID: uuid.NewString(), IncludeSubcategories: true, Page: 1, PerPage: 10}
+
+		_, _, err := repo.FindAll(context.Background(), filter)
+		assert.NoError(t, err)
+
+		repo.InvalidateCategoryListings(context.Background(), uuid.New(), uuid.New())
+
+		before := len(db.recorder.Statements())
+		_, _, err = repo.FindAll(context.Background(), filter)
+		assert.NoError(t, err)
+		assert.Greater(t, len(db.recorder.Statements()), before, "listing should be recomputed")
+	})
+}
+
+func TestProductRepository_FindAlsoBought(t *testing.T) {
+	db := newDryRunDB(t)
+	repo := NewProductRepository(db, newMemoryCache(), 0, 0, time.Minute)
+	productID := uuid.MustParse("3f9b8c1e-2d4a-4e6b-9c7d-1a2b3c4d5e6f")
+
+	products, err := repo.FindAlsoBought(context.Background(), productID, 5)
+
+	assert.NoError(t, err)
+	assert.NotNil(t, products, "no co-purchases should be an empty list, not nil")
+	assert.Equal(t,
+		`SELECT "other"."product_id" FROM order_items AS self `+
+			`JOIN order_items AS other ON other.order_id = self.order_id AND other.product_id <> self.product_id `+
+			`JOIN orders ON orders.id = self.order_id `+
+			`JOIN products ON products.id = other.product_id `+
+			`WHERE (self.product_id = '3f9b8c1e-2d4a-4e6b-9c7d-1a2b3c4d5e6f' AND orders.status IN ('paid','processing','shipping','shipped','completed')) `+
+			`AND (EXISTS (SELECT 1 FROM stores s JOIN users u ON u.id = s.user_id `+
+			`WHERE s.id = products.store_id AND s.active AND u.active)) `+
+			`GROUP BY "other"."product_id" ORDER BY COUNT(DISTINCT other.order_id) DESC, other.product_id LIMIT 5`,
+		db.recorder.Last())
+
+	before := len(db.recorder.Statements())
+	_, err = repo.FindAlsoBought(context.Background(), productID, 5)
+	assert.NoError(t, err)
+	assert.Len(t, db.recorder.Statements(), before, "second ranking should be served from the cache")
+
+	repo.InvalidateStoreListings(context.Background(), uuid.New())
+	_, err = repo.FindAlsoBought(context.Background(), productID, 5)
+	assert.NoError(t, err)
+	assert.Len(t, db.recorder.Statements(), before+1, "a store change should drop cached rankings")
+}
+
+func TestProductRepository_FindAlsoBought_LoadsRankedProductsFresh(t *testing.T) {
+	db := newDryRunDB(t)
+	cache := newMemoryCache()
+	repo := NewProductRepository(db, cache, 0, 0, time.Minute)
+	productID := uuid.MustParse("3f9b8c1e-2d4a-4e6b-9c7d-1a2b3c4d5e6f")
+	ranked := []uuid.UUID{
+		uuid.MustParse("5a6b7c8d-9e0f-4a1b-8c2d-3e4f5a6b7c8d"),
+		uuid.MustParse("6b7c8d9e-0f1a-4b2c-9d3e-4f5a6b7c8d9e"),
+	}
+	cache.Set(context.Background(), fmt.Sprintf(constant.KeyProductAlsoBought, productID.String(), 5, ""), ranked, 0)
+
+	for range 2 {
+		_, err := repo.FindAlsoBought(context.Background(), productID, 5)
+		assert.NoError(t, err)
+	}
+
+	stmts := db.recorder.Statements()
+	wantSQL := `SELECT * FROM "products" WHERE id IN ('5a6b7c8d-9e0f-4a1b-8c2d-3e4f5a6b7c8d','6b7c8d9e-0f1a-4b2c-9d3e-4f5a6b7c8d9e') ` +
+		`AND NOT products.unavailable AND (EXISTS (SELECT 1 FROM stores s JOIN users u ON u.id = s.user_id ` +
+		`WHERE s.id = products.store_id AND s.active AND u.active))`
+	if assert.Len(t, stmts, 2, "rows are loaded on every call, only the ranking is cached") {
+		assert.Equal(t, wantSQL, stmts[0])
+		assert.Equal(t, wantSQL, stmts[1])
+	}
+}
+
+func TestProductRepository_FindAll_IncludeSubcategories(t *testing.T) {
+	parentID := uuid.MustParse("1b2c3d4e-5f60-4a7b-8c9d-0e1f2a3b4c5d")
+	childID := uuid.MustParse("2c3d4e5f-6071-4b8c-9d0e-1f2a3b4c5d6e")
+
+	tests := []struct {
+		name    string
+		filter  model.ProductFilter
+		wantSQL string
+	}{
+		{
+			name:    "exact category by default",
+			filter:  model.ProductFilter{CategoryID: parentID.String(), Page: 1, PerPage: 10},
+			wantSQL: `SELECT count(*) FROM "products" ` + activeStoreWhere + `category_id = '1b2c3d4e-5f60-4a7b-8c9d-0e1f2a3b4c5d'`,
+		},
+		{
+			name:   "parent expands to its descendants",
+			filter: model.ProductFilter{CategoryID: parentID.String(), IncludeSubcategories: true, Page: 1, PerPage: 10},
+			wantSQL: `SELECT count(*) FROM "products" ` + activeStoreWhere + `category_id IN (WITH RECURSIVE subtree AS ` +
+				`(SELECT id FROM categories WHERE id = '1b2c3d4e-5f60-4a7b-8c9d-0e1f2a3b4c5d' ` +
+				`UNION SELECT c.id FROM categories c JOIN subtree ON c.parent_id = subtree.id) SELECT id FROM subtree)`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := newDryRunDB(t)
+			repo := NewProductRepository(db, nil, 0, 0, 0)
+
+			_, _, err := repo.FindAll(context.Background(), tt.filter)
+
+			assert.NoError(t, err)
+			stmts := db.recorder.Statements()
+			if assert.NotEmpty(t, stmts) {
+				assert.Equal(t, tt.wantSQL, stmts[0])
+			}
+		})
+	}
+
+	t.Run("product added to a child invalidates the parent subtree listing", func(t *testing.T) {
+		db := newDryRunDB(t)
+		repo := NewProductRepository(db, newMemoryCache(), 0, time.Minute, 0)
+		subtree := model.ProductFilter{CategoryID: parentID.String(), IncludeSubcategories: true, Page: 1, PerPage: 10}
+		exact := model.ProductFilter{CategoryID: parentID.String(), Page: 1, PerPage: 10}
+
+		_, _, err := repo.FindAll(context.Background(), subtree)
+		assert.NoError(t, err)
+		_, _, err = repo.FindAll(context.Background(), exact)
+		assert.NoError(t, err)
+
+		repo.Create(context.Background(), &model.Product{StoreID: uuid.New(), CategoryID: childID})
+
+		before := len(db.recorder.Statements())
+		_, _, err = repo.FindAll(context.Background(), exact)
+		assert.NoError(t, err)
+		assert.Len(t, db.recorder.Statements(), before, "exact parent listing does not include the child")
+
+		_, _, err = repo.FindAll(context.Background(), subtree)
+		assert.NoError(t, err)
+		assert.Greater(t, len(db.recorder.Statements()), before, "subtree listing should be recomputed")
+	})
+}
+
+func TestProductRepository_FindAll_Search(t *testing.T) {
+	tests := []struct {
+		name    string
+		search  string
+		wantSQL string
+	}{
+		{
+			name:    "short term falls back to ILIKE",
+			search:  "tv",
+			wantSQL: `SELECT count(*) FROM "products" ` + activeStoreWhere + `(name ILIKE '%tv%' OR description ILIKE '%tv%')`,
+		},
+		{
+			name:    "surrounding spaces do not count towards the length",
+			search:  " tv ",
+			wantSQL: `SELECT count(*) FROM "products" ` + activeStoreWhere + `(name ILIKE '%tv%' OR description ILIKE '%tv%')`,
+		},
+		{
+			name:    "wildcards match literally",
+			search:  "%_",
+			wantSQL: `SELECT count(*) FROM "products" ` + activeStoreWhere + `(name ILIKE '%\%\_%' OR description ILIKE '%\%\_%')`,
+		},
+		{
+			name:    "blank term does not filter",
+			search:  "  ",
+			wantSQL: `SELECT count(*) FROM "products" ` + strings.TrimSuffix(activeStoreWhere, " AND "),
+		},
+		{
+			name:    "longer term uses the full-text index",
+			search:  "running",
+			wantSQL: `SELECT count(*) FROM "products" ` + activeStoreWhere + `search_vector @@ plainto_tsquery('english', 'running')`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := newDryRunDB(t)
+			repo := NewProductRepository(db, nil, 0, 0, 0)
+
+			_, _, err := repo.FindAll(context.Background(), model.ProductFilter{Search: tt.search, Page: 1, PerPage: 10})
+
+			assert.NoError(t, err)
+			stmts := db.recorder.Statements()
+			if assert.NotEmpty(t, stmts) {
+				assert.Equal(t, tt.wantSQL, stmts[0])
+			}
+		})
+	}
+}
+
+func TestProductSearchOrder(t *testing.T) {
+	tests := []struct {
+		name   string
+		filter model.ProductFilter
+		want   string
+	}{
+		{
+			name:   "relevance then newest",
+			filter: model.ProductFilter{Search: "running"},
+			want:   `ORDER BY ts_rank(search_vector, plainto_tsquery('english', 'running')) DESC, created_at DESC`,
+		},
+		{
+			name:   "in stock first ahead of relevance",
+			filter: model.ProductFilter{Search: "running", InStockFirst: true},
+			want:   `ORDER BY (stock > 0) DESC, ts_rank(search_vector, plainto_tsquery('english', 'running')) DESC, created_at DESC`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := newDryRunDB(t)
+
+			db.DB().Order(productSearchOrder(tt.filter)).Find(&[]model.Product{})
+
+			assert.Equal(t, `SELECT * FROM "products" `+tt.want, db.recorder.Last())
+		})
+	}
+}
+
+// TestProductSearch_Stemming runs the search expression against a real
+// PostgreSQL, since stemming happens in the database. It is skipped unless
+// POSTGRES_TEST_DSN is set.
+func TestProductSearch_Stemming(t *testing.T) {
+	dsn := os.Getenv("POSTGRES_TEST_DSN")
+	if dsn == "" {
+		t.Skip("POSTGRES_TEST_DSN not set")
+	}
+	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{})
+	if !assert.NoError(t, err) {
+		return
+	}
+	// The same expression as the generated search_vector column.
+	const vector = "setweight(to_tsvector('english', coalesce(?, '')), 'A') || setweight(to_tsvector('english', coalesce(?, '')), 'B')"
+
+	tests := []struct {
+		name        string
+		productName string
+		description string
+		search      string
+		want        bool
+	}{
+		{name: "running matches run", productName: "Trail run shoe", search: "running", want: true},
+		{name: "plural matches singular", productName: "Leather boot", search: "boots", want: true},
+		{name: "description is searched", productName: "Pack", description: "for long hikes", search: "hiking", want: true},
+		{name: "unrelated word", productName: "Trail run shoe", search: "jacket", want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var matched bool
+			err := db.Raw("SELECT ("+vector+") @@ plainto_tsquery('english', ?)",
+				tt.productName, tt.description, tt.search).Scan(&matched).Error
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, matched)
+		})
+	}
+}
+
+func TestProductListOrder(t *testing.T) {
+	tests := []struct {
+		name   string
+		filter model.ProductFilter
+		want   string
+	}{
+		{name: "default sort", filter: model.ProductFilter{}, want: "created_at DESC"},
+		{name: "unknown field falls back", filter: model.ProductFilter{SortBy: "stock; DROP", SortOrder: "asc"}, want: "created_at ASC"},
+		{name: "chosen sort alone when off", filter: model.ProductFilter{SortBy: "price", SortOrder: "asc"}, want: "price ASC"},
+		{
+			// Out-of-stock rows sort last; among in-stock rows the price
+			// order still applies.
+			name:   "in stock ranked ahead of the chosen sort",
+			filter: model.ProductFilter{SortBy: "price", SortOrder: "asc", InStockFirst: true},
+			want:   "(stock > 0) DESC, price ASC",
+		},
+		{name: "in stock first with default sort", filter: model.ProductFilter{InStockFirst: true}, want: "(stock > 0) DESC, created_at DESC"},
+		{
+			// Equal averages fall back to the newest product.
+			name:   "rating then recency",
+			filter: model.ProductFilter{SortBy: "rating", SortOrder: "desc"},
+			want:   "(SELECT COALESCE(AVG(reviews.rating), 0) FROM reviews WHERE reviews.product_id = products.id) DESC, created_at DESC",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, productListOrder(tt.filter))
+		})
+	}
+}
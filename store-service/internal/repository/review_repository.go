@@ -2,18 +2,41 @@ package repository
 
 import (
 	"context"
+	"fmt"
 
 	"github.com/1tsndre/mini-go-project/store-service/internal/constant"
 	"github.com/1tsndre/mini-go-project/store-service/internal/model"
 	"github.com/1tsndre/mini-go-project/store-service/internal/repository/databases"
 	"github.com/google/uuid"
+	"gorm.io/gorm/clause"
 )
 
+var allowedReviewSortFields = map[string]bool{
+	"created_at": true,
+	"rating":     true,
+	"helpful":    true,
+}
+
+// reviewHelpfulSQL is a review's number of helpful votes.
+const reviewHelpfulSQL = "(SELECT COUNT(*) FROM review_votes WHERE review_votes.review_id = reviews.id)"
+
 type ReviewRepository interface {
 	Create(ctx context.Context, review *model.Review) error
-	FindByProductID(ctx context.Context, productID uuid.UUID, page, perPage int) ([]model.Review, int64, error)
+	Update(ctx context.Context, review *model.Review) error
+	Delete(ctx context.Context, id uuid.UUID) error
+	FindByID(ctx context.Context, id uuid.UUID) (*model.Review, error)
+	FindByUserAndProduct(ctx context.Context, userID, productID uuid.UUID) (*model.Review, error)
+	FindByProductID(ctx context.Context, productID uuid.UUID, filter model.ReviewFilter) ([]model.Review, int64, error)
 	HasUserReviewed(ctx context.Context, userID, productID uuid.UUID) (bool, error)
 	HasUserPurchased(ctx context.Context, userID, productID uuid.UUID) (bool, error)
+	CountUserPurchases(ctx context.Context, userID, productID uuid.UUID) (int64, error)
+	LatestPurchaseSeq(ctx context.Context, userID, productID uuid.UUID) (int, error)
+	FindProductOwnerID(ctx context.Context, productID uuid.UUID) (uuid.UUID, error)
+	FindProductStore(ctx context.Context, productID uuid.UUID) (*model.Store, error)
+	SaveReply(ctx context.Context, reply *model.ReviewReply) error
+	AddVote(ctx context.Context, reviewID, userID uuid.UUID) error
+	RemoveVote(ctx context.Context, reviewID, userID uuid.UUID) error
+	CountVotes(ctx context.Context, reviewID uuid.UUID) (int64, error)
 }
 
 type reviewRepository struct {
@@ -25,33 +48,89 @@ func NewReviewRepository(db databases.Database) ReviewRepository {
 }
 
 func (r *reviewRepository) Create(ctx context.Context, review *model.Review) error {
-	return r.db.DB().WithContext(ctx).Create(review).Error
+	return databases.FromContext(ctx, r.db).Create(review).Error
+}
+
+func (r *reviewRepository) Update(ctx context.Context, review *model.Review) error {
+	return databases.FromContext(ctx, r.db).Save(review).Error
+}
+
+func (r *reviewRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	return databases.FromContext(ctx, r.db).Delete(&model.Review{}, "id = ?", id).Error
+}
+
+func (r *reviewRepository) FindByID(ctx context.Context, id uuid.UUID) (*model.Review, error) {
+	var review model.Review
+	err := databases.FromContext(ctx, r.db).First(&review, "id = ?", id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &review, nil
 }
 
-func (r *reviewRepository) FindByProductID(ctx context.Context, productID uuid.UUID, page, perPage int) ([]model.Review, int64, error) {
+// FindByUserAndProduct returns the user's latest review of the product.
+func (r *reviewRepository) FindByUserAndProduct(ctx context.Context, userID, productID uuid.UUID) (*model.Review, error) {
+	var review model.Review
+	err := databases.FromContext(ctx, r.db).
+		Order("purchase_seq DESC").
+		First(&review, "user_id = ? AND product_id = ?", userID, productID).Error
+	if err != nil {
+		return nil, err
+	}
+	return &review, nil
+}
+
+// FindByProductID returns one page of a product's reviews in reviewOrder.
+func (r *reviewRepository) FindByProductID(ctx context.Context, productID uuid.UUID, filter model.ReviewFilter) ([]model.Review, int64, error) {
 	var reviews []model.Review
 	var total int64
 
-	query := r.db.DB().WithContext(ctx).Model(&model.Review{}).Where("product_id = ?", productID)
+	query := databases.FromContext(ctx, r.db).Model(&model.Review{}).Where("product_id = ?", productID)
+	if filter.Rating > 0 {
+		query = query.Where("rating = ?", filter.Rating)
+	}
 
 	if err := query.Count(&total).Error; err != nil {
 		return nil, 0, err
 	}
 
-	offset := (page - 1) * perPage
+	offset := (filter.Page - 1) * filter.PerPage
 	err := query.
+		Select("reviews.*, " + reviewHelpfulSQL + " AS helpful_count").
 		Preload("User").
-		Order("created_at DESC").
+		Preload("Reply").
+		Order(reviewOrder(filter)).
 		Offset(offset).
-		Limit(perPage).
+		Limit(filter.PerPage).
 		Find(&reviews).Error
 
 	return reviews, total, err
 }
 
+// reviewOrder orders reviews by filter's sort field, newest first when it
+// is missing or not allowed. Ties are broken by recency.
+func reviewOrder(filter model.ReviewFilter) string {
+	sortBy := "created_at"
+	if allowedReviewSortFields[filter.SortBy] {
+		sortBy = filter.SortBy
+	}
+	sortOrder := "DESC"
+	if filter.SortOrder == "asc" {
+		sortOrder = "ASC"
+	}
+	if sortBy == "helpful" {
+		sortBy = "helpful_count"
+	}
+	order := fmt.Sprintf("%s %s", sortBy, sortOrder)
+	if sortBy != "created_at" {
+		order += ", created_at DESC"
+	}
+	return order
+}
+
 func (r *reviewRepository) HasUserReviewed(ctx context.Context, userID, productID uuid.UUID) (bool, error) {
 	var count int64
-	err := r.db.DB().WithContext(ctx).
+	err := databases.FromContext(ctx, r.db).
 		Model(&model.Review{}).
 		Where("user_id = ? AND product_id = ?", userID, productID).
 		Count(&count).Error
@@ -60,7 +139,7 @@ func (r *reviewRepository) HasUserReviewed(ctx context.Context, userID, productI
 
 func (r *reviewRepository) HasUserPurchased(ctx context.Context, userID, productID uuid.UUID) (bool, error) {
 	var count int64
-	err := r.db.DB().WithContext(ctx).
+	err := databases.FromContext(ctx, r.db).
 		Model(&model.OrderItem{}).
 		Joins("JOIN orders ON orders.id = order_items.order_id").
 		Where("orders.user_id = ? AND order_items.product_id = ? AND orders.status IN (?, ?)",
@@ -68,3 +147,86 @@ func (r *reviewRepository) HasUserPurchased(ctx context.Context, userID, product
 		Count(&count).Error
 	return count > 0, err
 }
+
+// CountUserPurchases counts the distinct shipped or completed orders in which
+// the user bought the product.
+func (r *reviewRepository) CountUserPurchases(ctx context.Context, userID, productID uuid.UUID) (int64, error) {
+	var count int64
+	err := databases.FromContext(ctx, r.db).
+		Model(&model.OrderItem{}).
+		Joins("JOIN orders ON orders.id = order_items.order_id").
+		Where("orders.user_id = ? AND order_items.product_id = ? AND orders.status IN (?, ?)",
+			userID, productID, constant.OrderStatusShipped, constant.OrderStatusCompleted).
+		Distinct("orders.id").
+		Count(&count).Error
+	return count, err
+}
+
+// LatestPurchaseSeq returns the highest PurchaseSeq among the user's reviews
+// of the product, or 0 if there are none.
+func (r *reviewRepository) LatestPurchaseSeq(ctx context.Context, userID, productID uuid.UUID) (int, error) {
+	var seq int
+	err := databases.FromContext(ctx, r.db).
+		Model(&model.Review{}).
+		Where("user_id = ? AND product_id = ?", userID, productID).
+		Select("COALESCE(MAX(purchase_seq), 0)").
+		Scan(&seq).Error
+	return seq, err
+}
+
+// FindProductOwnerID returns the user who owns the store selling the product.
+func (r *reviewRepository) FindProductOwnerID(ctx context.Context, productID uuid.UUID) (uuid.UUID, error) {
+	var store model.Store
+	err := databases.FromContext(ctx, r.db).
+		Select("stores.user_id").
+		Joins("JOIN products ON products.store_id = stores.id").
+		Where("products.id = ?", productID).
+		First(&store).Error
+	return store.UserID, err
+}
+
+// FindProductStore returns the store selling productID.
+func (r *reviewRepository) FindProductStore(ctx context.Context, productID uuid.UUID) (*model.Store, error) {
+	var store model.Store
+	err := databases.FromContext(ctx, r.db).
+		Select("stores.*").
+		Joins("JOIN products ON products.store_id = stores.id").
+		Where("products.id = ?", productID).
+		First(&store).Error
+	if err != nil {
+		return nil, err
+	}
+	return &store, nil
+}
+
+// SaveReply stores reply, replacing the review's previous reply if it has
+// one. A replaced reply keeps its original created_at, which is read back
+// into reply.
+func (r *reviewRepository) SaveReply(ctx context.Context, reply *model.ReviewReply) error {
+	return databases.FromContext(ctx, r.db).
+		Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "review_id"}},
+			DoUpdates: clause.AssignmentColumns([]string{"store_id", "comment", "updated_at"}),
+		}, clause.Returning{Columns: []clause.Column{{Name: "created_at"}}}).
+		Create(reply).Error
+}
+
+// AddVote records userID's helpful vote on reviewID. Voting again is a
+// no-op.
+func (r *reviewRepository) AddVote(ctx context.Context, reviewID, userID uuid.UUID) error {
+	return databases.FromContext(ctx, r.db).
+		Clauses(clause.OnConflict{DoNothing: true}).
+		Create(&model.ReviewVote{ReviewID: reviewID, UserID: userID}).Error
+}
+
+// RemoveVote withdraws userID's helpful vote on reviewID, if there is one.
+func (r *reviewRepository) RemoveVote(ctx context.Context, reviewID, userID uuid.UUID) error {
+	return databases.FromContext(ctx, r.db).
+		Delete(&model.ReviewVote{}, "review_id = ? AND user_id = ?", reviewID, userID).Error
+}
+
+func (r *reviewRepository) CountVotes(ctx context.Context, reviewID uuid.UUID) (int64, error) {
+	var count int64
+	err := databases.FromContext(ctx, r.db).Model(&model.ReviewVote{}).Where("review_id = ?", reviewID).Count(&count).Error
+	return count, err
+}
@@ -0,0 +1,93 @@
+package repository
+
+import (
+	"context"
+	"testing"
+
+	"github.com/1tsndre/mini-go-project/store-service/internal/model"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReviewRepository_CountUserPurchases(t *testing.T) {
+	db := newDryRunDB(t)
+	repo := NewReviewRepository(db)
+	userID := uuid.MustParse("8a3c2a52-6f1e-4c1b-9d7a-2f3b6f0c9e11")
+	productID := uuid.MustParse("5f0c7d3e-2b1a-4c9e-8f7d-6a5b4c3d2e1f")
+
+	_, err := repo.CountUserPurchases(context.Background(), userID, productID)
+
+	assert.NoError(t, err)
+	// Several lines of the same order are still one purchase.
+	assert.Equal(t, `SELECT COUNT(DISTINCT("orders"."id")) FROM "order_items" `+
+		`JOIN orders ON orders.id = order_items.order_id `+
+		`WHERE orders.user_id = '8a3c2a52-6f1e-4c1b-9d7a-2f3b6f0c9e11' AND order_items.product_id = '5f0c7d3e-2b1a-4c9e-8f7d-6a5b4c3d2e1f' `+
+		`AND orders.status IN ('shipped', 'completed')`, db.recorder.Last())
+}
+
+func TestReviewRepository_FindByProductID_Rating(t *testing.T) {
+	db := newDryRunDB(t)
+	repo := NewReviewRepository(db)
+	productID := uuid.MustParse("5f0c7d3e-2b1a-4c9e-8f7d-6a5b4c3d2e1f")
+
+	_, _, err := repo.FindByProductID(context.Background(), productID, model.ReviewFilter{Rating: 5, Page: 1, PerPage: 10})
+
+	assert.NoError(t, err)
+	statements := db.recorder.Statements()
+	if assert.NotEmpty(t, statements) {
+		assert.Equal(t,
+			`SELECT count(*) FROM "reviews" WHERE product_id = '5f0c7d3e-2b1a-4c9e-8f7d-6a5b4c3d2e1f' AND rating = 5`,
+			statements[0],
+		)
+	}
+}
+
+func TestReviewOrder(t *testing.T) {
+	tests := []struct {
+		name   string
+		filter model.ReviewFilter
+		want   string
+	}{
+		{name: "default is newest first", want: "created_at DESC"},
+		{name: "oldest first", filter: model.ReviewFilter{SortOrder: "asc"}, want: "created_at ASC"},
+		{name: "highest rated first", filter: model.ReviewFilter{SortBy: "rating"}, want: "rating DESC, created_at DESC"},
+		{name: "lowest rated first", filter: model.ReviewFilter{SortBy: "rating", SortOrder: "asc"}, want: "rating ASC, created_at DESC"},
+		{name: "most helpful first", filter: model.ReviewFilter{SortBy: "helpful"}, want: "helpful_count DESC, created_at DESC"},
+		{name: "unknown field falls back", filter: model.ReviewFilter{SortBy: "comment; DROP TABLE reviews"}, want: "created_at DESC"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, reviewOrder(tt.filter))
+		})
+	}
+}
+
+func TestReviewRepository_AddVote(t *testing.T) {
+	db := newDryRunDB(t)
+	repo := NewReviewRepository(db)
+	reviewID := uuid.MustParse("7b1e2c3d-4f5a-4b6c-8d7e-9f0a1b2c3d4e")
+	userID := uuid.MustParse("8a3c2a52-6f1e-4c1b-9d7a-2f3b6f0c9e11")
+
+	err := repo.AddVote(context.Background(), reviewID, userID)
+
+	assert.NoError(t, err)
+	assert.Contains(t, db.recorder.Last(), `INSERT INTO "review_votes"`)
+	assert.Contains(t, db.recorder.Last(), `ON CONFLICT DO NOTHING`)
+}
+
+func TestReviewRepository_SaveReply(t *testing.T) {
+	db := newDryRunDB(t)
+	repo := NewReviewRepository(db)
+
+	err := repo.SaveReply(context.Background(), &model.ReviewReply{
+		ReviewID: uuid.MustParse("7b1e2c3d-4f5a-4b6c-8d7e-9f0a1b2c3d4e"),
+		StoreID:  uuid.MustParse("8a3c2a52-6f1e-4c1b-9d7a-2f3b6f0c9e11"),
+		Comment:  "Thanks!",
+	})
+
+	assert.NoError(t, err)
+	assert.Contains(t, db.recorder.Last(),
+		`ON CONFLICT ("review_id") DO UPDATE SET "store_id"="excluded"."store_id","comment"="excluded"."comment","updated_at"="excluded"."updated_at" RETURNING "created_at"`,
+		"a replaced reply keeps its created_at")
+}
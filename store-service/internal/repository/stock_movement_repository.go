@@ -0,0 +1,42 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/1tsndre/mini-go-project/store-service/internal/model"
+	"github.com/1tsndre/mini-go-project/store-service/internal/repository/databases"
+	"github.com/google/uuid"
+)
+
+type StockMovementRepository interface {
+	FindByProductID(ctx context.Context, productID uuid.UUID, page, perPage int) ([]model.StockMovement, int64, error)
+}
+
+type stockMovementRepository struct {
+	db databases.Database
+}
+
+func NewStockMovementRepository(db databases.Database) StockMovementRepository {
+	return &stockMovementRepository{db: db}
+}
+
+// FindByProductID returns a product's movements newest first.
+func (r *stockMovementRepository) FindByProductID(ctx context.Context, productID uuid.UUID, page, perPage int) ([]model.StockMovement, int64, error) {
+	var movements []model.StockMovement
+	var total int64
+
+	query := databases.FromContext(ctx, r.db).Model(&model.StockMovement{}).Where("product_id = ?", productID)
+
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	offset := (page - 1) * perPage
+	err := query.
+		Order("created_at DESC").
+		Offset(offset).
+		Limit(perPage).
+		Find(&movements).Error
+
+	return movements, total, err
+}
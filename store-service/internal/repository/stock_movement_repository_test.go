@@ -0,0 +1,26 @@
+package repository
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStockMovementRepository_FindByProductID(t *testing.T) {
+	db := newDryRunDB(t)
+	repo := NewStockMovementRepository(db)
+
+	productID := uuid.MustParse("3d4e5f60-7182-4c9d-8e0f-1a2b3c4d5e6f")
+	_, _, err := repo.FindByProductID(context.Background(), productID, 2, 10)
+
+	assert.NoError(t, err)
+	statements := db.recorder.Statements()
+	if assert.NotEmpty(t, statements) {
+		assert.Equal(t,
+			`SELECT count(*) FROM "stock_movements" WHERE product_id = '3d4e5f60-7182-4c9d-8e0f-1a2b3c4d5e6f'`,
+			statements[0],
+		)
+	}
+}
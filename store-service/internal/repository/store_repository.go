@@ -2,10 +2,17 @@ package repository
 
 import (
 	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
 
+	"github.com/1tsndre/mini-go-project/store-service/internal/constant"
 	"github.com/1tsndre/mini-go-project/store-service/internal/model"
+	"github.com/1tsndre/mini-go-project/store-service/internal/repository/caches"
 	"github.com/1tsndre/mini-go-project/store-service/internal/repository/databases"
 	"github.com/google/uuid"
+	"gorm.io/gorm"
 )
 
 type StoreRepository interface {
@@ -14,42 +21,154 @@ type StoreRepository interface {
 	FindByUserID(ctx context.Context, userID uuid.UUID) (*model.Store, error)
 	Update(ctx context.Context, store *model.Store) error
 	Delete(ctx context.Context, id uuid.UUID) error
+	SetActive(ctx context.Context, id uuid.UUID, active bool) error
+	AvgProcessingTime(ctx context.Context, storeID uuid.UUID, since time.Time) (*int64, error)
 }
 
 type storeRepository struct {
-	db databases.Database
+	db    databases.Database
+	cache caches.Cache
 }
 
-func NewStoreRepository(db databases.Database) StoreRepository {
-	return &storeRepository{db: db}
+// NewStoreRepository creates a StoreRepository that caches stores by id and
+// the owner to store mapping, since sellers' store is looked up on nearly
+// every action they take.
+func NewStoreRepository(db databases.Database, cache caches.Cache) StoreRepository {
+	return &storeRepository{db: db, cache: cache}
 }
 
 func (r *storeRepository) Create(ctx context.Context, store *model.Store) error {
-	return r.db.DB().WithContext(ctx).Create(store).Error
+	return databases.FromContext(ctx, r.db).Create(store).Error
 }
 
 func (r *storeRepository) FindByID(ctx context.Context, id uuid.UUID) (*model.Store, error) {
+	cacheKey := fmt.Sprintf(constant.KeyStore, id.String())
+
+	cached, err := r.cache.Get(ctx, cacheKey)
+	if err == nil {
+		var store model.Store
+		if json.Unmarshal(cached, &store) == nil {
+			return &store, nil
+		}
+	}
+
 	var store model.Store
-	err := r.db.DB().WithContext(ctx).First(&store, "id = ?", id).Error
+	err = databases.FromContext(ctx, r.db).First(&store, "id = ?", id).Error
 	if err != nil {
 		return nil, err
 	}
+
+	r.cache.Set(ctx, cacheKey, store, constant.TTLStore)
+
 	return &store, nil
 }
 
+// FindByUserID resolves the owner to a store id through the cache and then
+// loads the store with FindByID. A cached mapping whose store is gone or
+// changed hands falls through to the database.
 func (r *storeRepository) FindByUserID(ctx context.Context, userID uuid.UUID) (*model.Store, error) {
+	ownerKey := fmt.Sprintf(constant.KeyStoreOwner, userID.String())
+
+	cached, err := r.cache.Get(ctx, ownerKey)
+	if err == nil {
+		var storeID uuid.UUID
+		if json.Unmarshal(cached, &storeID) == nil {
+			if store, err := r.FindByID(ctx, storeID); err == nil && store.UserID == userID {
+				return store, nil
+			}
+		}
+	}
+
 	var store model.Store
-	err := r.db.DB().WithContext(ctx).First(&store, "user_id = ?", userID).Error
+	err = databases.FromContext(ctx, r.db).First(&store, "user_id = ?", userID).Error
 	if err != nil {
 		return nil, err
 	}
+
+	r.cache.Set(ctx, ownerKey, store.ID, constant.TTLStore)
+	r.cache.Set(ctx, fmt.Sprintf(constant.KeyStore, store.ID.String()), store, constant.TTLStore)
+
 	return &store, nil
 }
 
 func (r *storeRepository) Update(ctx context.Context, store *model.Store) error {
-	return r.db.DB().WithContext(ctx).Save(store).Error
+	if err := databases.FromContext(ctx, r.db).Save(store).Error; err != nil {
+		return err
+	}
+	r.invalidate(ctx, store.ID)
+	return nil
 }
 
+// Delete leaves the owner mapping in place: FindByUserID no longer finds
+// the store behind it and falls back to the database.
 func (r *storeRepository) Delete(ctx context.Context, id uuid.UUID) error {
-	return r.db.DB().WithContext(ctx).Delete(&model.Store{}, "id = ?", id).Error
+	if err := databases.FromContext(ctx, r.db).Delete(&model.Store{}, "id = ?", id).Error; err != nil {
+		return err
+	}
+	r.invalidate(ctx, id)
+	return nil
+}
+
+func (r *storeRepository) SetActive(ctx context.Context, id uuid.UUID, active bool) error {
+	result := databases.FromContext(ctx, r.db).Model(&model.Store{}).Where("id = ?", id).Update("active", active)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	r.invalidate(ctx, id)
+	return nil
+}
+
+// AvgProcessingTime averages, in whole seconds, how long storeID's orders
+// paid since the given time waited before first moving to processing. It
+// returns nil when no such order has been processed yet. The result is
+// cached next to the store for TTLStore, so the window may lag by as much.
+func (r *storeRepository) AvgProcessingTime(ctx context.Context, storeID uuid.UUID, since time.Time) (*int64, error) {
+	cacheKey := fmt.Sprintf(constant.KeyStoreProcessingTime, storeID.String())
+
+	cached, err := r.cache.Get(ctx, cacheKey)
+	if err == nil {
+		var avg *int64
+		if json.Unmarshal(cached, &avg) == nil {
+			return avg, nil
+		}
+	}
+
+	storeOrders := databases.FromContext(ctx, r.db).Table("order_items").
+		Select("order_items.order_id").
+		Joins("JOIN products ON products.id = order_items.product_id").
+		Where("products.store_id = ?", storeID)
+
+	perOrder := databases.FromContext(ctx, r.db).Table("order_status_history AS paid").
+		Select("MIN(paid.created_at) AS paid_at, MIN(processed.created_at) AS processed_at").
+		Joins("JOIN order_status_history AS processed ON processed.order_id = paid.order_id AND processed.from_status = ? AND processed.to_status = ?",
+			constant.OrderStatusPaid, constant.OrderStatusProcessing).
+		Where("paid.to_status = ? AND paid.created_at >= ?", constant.OrderStatusPaid, since).
+		Where("paid.order_id IN (?)", storeOrders).
+		Group("paid.order_id")
+
+	var result struct {
+		Avg sql.NullInt64
+	}
+	err = databases.FromContext(ctx, r.db).Table("(?) AS times", perOrder).
+		Select("CAST(ROUND(AVG(EXTRACT(EPOCH FROM times.processed_at - times.paid_at))) AS BIGINT) AS avg").
+		Find(&result).Error
+	if err != nil {
+		return nil, err
+	}
+
+	var avg *int64
+	if result.Avg.Valid {
+		avg = &result.Avg.Int64
+	}
+	r.cache.Set(ctx, cacheKey, avg, constant.TTLStore)
+
+	return avg, nil
+}
+
+func (r *storeRepository) invalidate(ctx context.Context, id uuid.UUID) {
+	r.cache.Delete(ctx, fmt.Sprintf(constant.KeyStore, id.String()))
+	r.cache.Delete(ctx, fmt.Sprintf(constant.KeyStoreProcessingTime, id.String()))
 }
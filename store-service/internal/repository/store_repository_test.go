@@ -0,0 +1,136 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/1tsndre/mini-go-project/store-service/internal/constant"
+	"github.com/1tsndre/mini-go-project/store-service/internal/model"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStoreRepository_Cache(t *testing.T) {
+	store := model.Store{
+		ID:     uuid.MustParse("6e7f8091-a2b3-4c4d-9e5f-60718293a4b5"),
+		UserID: uuid.MustParse("7f8091a2-b3c4-4d5e-8f60-718293a4b5c6"),
+		Name:   "Corner Shop",
+	}
+	seed := func(cache *memoryCache) {
+		cache.Set(context.Background(), fmt.Sprintf(constant.KeyStore, store.ID.String()), store, 0)
+		cache.Set(context.Background(), fmt.Sprintf(constant.KeyStoreOwner, store.UserID.String()), store.ID, 0)
+	}
+
+	t.Run("FindByID hit skips the database", func(t *testing.T) {
+		db := newDryRunDB(t)
+		cache := newMemoryCache()
+		seed(cache)
+		repo := NewStoreRepository(db, cache)
+
+		got, err := repo.FindByID(context.Background(), store.ID)
+
+		assert.NoError(t, err)
+		assert.Equal(t, "Corner Shop", got.Name)
+		assert.Empty(t, db.recorder.Statements())
+	})
+
+	t.Run("FindByUserID hit skips the database", func(t *testing.T) {
+		db := newDryRunDB(t)
+		cache := newMemoryCache()
+		seed(cache)
+		repo := NewStoreRepository(db, cache)
+
+		got, err := repo.FindByUserID(context.Background(), store.UserID)
+
+		assert.NoError(t, err)
+		assert.Equal(t, store.ID, got.ID)
+		assert.Empty(t, db.recorder.Statements())
+	})
+
+	t.Run("miss queries the database", func(t *testing.T) {
+		db := newDryRunDB(t)
+		repo := NewStoreRepository(db, newMemoryCache())
+
+		repo.FindByUserID(context.Background(), store.UserID)
+
+		assert.Equal(t,
+			`SELECT * FROM "stores" WHERE user_id = '7f8091a2-b3c4-4d5e-8f60-718293a4b5c6' ORDER BY "stores"."id" LIMIT 1`,
+			db.recorder.Last())
+	})
+
+	t.Run("Update busts the cached store", func(t *testing.T) {
+		db := newDryRunDB(t)
+		cache := newMemoryCache()
+		seed(cache)
+		repo := NewStoreRepository(db, cache)
+
+		updated := store
+		updated.Name = "Renamed Shop"
+		assert.NoError(t, repo.Update(context.Background(), &updated))
+
+		before := len(db.recorder.Statements())
+		repo.FindByID(context.Background(), store.ID)
+		assert.Greater(t, len(db.recorder.Statements()), before, "updated store should be read from the database")
+
+		before = len(db.recorder.Statements())
+		repo.FindByUserID(context.Background(), store.UserID)
+		assert.Greater(t, len(db.recorder.Statements()), before, "owner lookup should not serve the stale store")
+	})
+
+	t.Run("mapping to a deleted store falls back to the database", func(t *testing.T) {
+		db := newDryRunDB(t)
+		cache := newMemoryCache()
+		seed(cache)
+		repo := NewStoreRepository(db, cache)
+
+		assert.NoError(t, repo.Delete(context.Background(), store.ID))
+
+		repo.FindByUserID(context.Background(), store.UserID)
+		assert.Contains(t, db.recorder.Last(), `WHERE user_id = '7f8091a2-b3c4-4d5e-8f60-718293a4b5c6'`)
+	})
+}
+
+func TestStoreRepository_AvgProcessingTime(t *testing.T) {
+	storeID := uuid.MustParse("8a3c2a52-6f1e-4c1b-9d7a-2f3b6f0c9e11")
+	since := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	t.Run("averages in the database", func(t *testing.T) {
+		db := newDryRunDB(t)
+		repo := NewStoreRepository(db, newMemoryCache())
+
+		avg, err := repo.AvgProcessingTime(context.Background(), storeID, since)
+
+		assert.NoError(t, err)
+		assert.Nil(t, avg)
+		assert.Equal(t,
+			`SELECT CAST(ROUND(AVG(EXTRACT(EPOCH FROM times.processed_at - times.paid_at))) AS BIGINT) AS avg FROM `+
+				`(SELECT MIN(paid.created_at) AS paid_at, MIN(processed.created_at) AS processed_at FROM order_status_history AS paid `+
+				`JOIN order_status_history AS processed ON processed.order_id = paid.order_id AND processed.from_status = 'paid' AND processed.to_status = 'processing' `+
+				`WHERE (paid.to_status = 'paid' AND paid.created_at >= '2026-01-01 00:00:00') `+
+				`AND paid.order_id IN (SELECT order_items.order_id FROM "order_items" JOIN products ON products.id = order_items.product_id `+
+				`WHERE products.store_id = '8a3c2a52-6f1e-4c1b-9d7a-2f3b6f0c9e11') `+
+				`GROUP BY "paid"."order_id") AS times`,
+			db.recorder.Last(),
+		)
+	})
+
+	t.Run("cached with the store", func(t *testing.T) {
+		db := newDryRunDB(t)
+		cache := newMemoryCache()
+		cache.Set(context.Background(), fmt.Sprintf(constant.KeyStoreProcessingTime, storeID.String()), int64(8400), 0)
+		repo := NewStoreRepository(db, cache)
+
+		avg, err := repo.AvgProcessingTime(context.Background(), storeID, since)
+
+		assert.NoError(t, err)
+		assert.Equal(t, int64(8400), *avg)
+		assert.Empty(t, db.recorder.Statements())
+
+		assert.NoError(t, repo.Update(context.Background(), &model.Store{ID: storeID, Name: "Renamed Shop"}))
+		before := len(db.recorder.Statements())
+		repo.AvgProcessingTime(context.Background(), storeID, since)
+		assert.Greater(t, len(db.recorder.Statements()), before, "store changes should drop the cached average")
+	})
+}
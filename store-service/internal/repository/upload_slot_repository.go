@@ -0,0 +1,57 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/1tsndre/mini-go-project/store-service/internal/constant"
+	"github.com/1tsndre/mini-go-project/store-service/internal/repository/caches"
+	"github.com/google/uuid"
+)
+
+// UploadSlotRepository counts each user's in-flight uploads in the cache so
+// the limit holds across instances. The counter expires after
+// constant.TTLUploadSlots, so slots leaked by a crashed instance free up.
+type UploadSlotRepository interface {
+	// Acquire takes a slot and reports whether the user is still within
+	// limit. A rejected attempt does not hold a slot.
+	Acquire(ctx context.Context, userID uuid.UUID, limit int) (bool, error)
+	Release(ctx context.Context, userID uuid.UUID) error
+}
+
+type uploadSlotRepository struct {
+	cache caches.Cache
+}
+
+func NewUploadSlotRepository(cache caches.Cache) UploadSlotRepository {
+	return &uploadSlotRepository{cache: cache}
+}
+
+func (r *uploadSlotRepository) Acquire(ctx context.Context, userID uuid.UUID, limit int) (bool, error) {
+	key := fmt.Sprintf(constant.KeyUploadSlots, userID.String())
+	n, err := r.cache.Incr(ctx, key, constant.TTLUploadSlots)
+	if err != nil {
+		return false, err
+	}
+	if n > int64(limit) {
+		if _, err := r.cache.Decr(ctx, key); err != nil {
+			return false, err
+		}
+		return false, nil
+	}
+	return true, nil
+}
+
+func (r *uploadSlotRepository) Release(ctx context.Context, userID uuid.UUID) error {
+	key := fmt.Sprintf(constant.KeyUploadSlots, userID.String())
+	n, err := r.cache.Decr(ctx, key)
+	if err != nil {
+		return err
+	}
+	// The counter may have expired mid-upload; don't let it go negative
+	// and hand out extra slots.
+	if n <= 0 {
+		return r.cache.Delete(ctx, key)
+	}
+	return nil
+}
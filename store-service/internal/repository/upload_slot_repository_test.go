@@ -0,0 +1,48 @@
+package repository
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUploadSlotRepository_Acquire(t *testing.T) {
+	ctx := context.Background()
+	repo := NewUploadSlotRepository(newMemoryCache())
+	userID := uuid.New()
+	const limit = 2
+
+	for i := 0; i < limit; i++ {
+		ok, err := repo.Acquire(ctx, userID, limit)
+		assert.NoError(t, err)
+		assert.True(t, ok)
+	}
+
+	ok, err := repo.Acquire(ctx, userID, limit)
+	assert.NoError(t, err)
+	assert.False(t, ok, "upload beyond the limit should be rejected")
+
+	ok, err = repo.Acquire(ctx, uuid.New(), limit)
+	assert.NoError(t, err)
+	assert.True(t, ok, "limit is per user")
+
+	assert.NoError(t, repo.Release(ctx, userID))
+	ok, err = repo.Acquire(ctx, userID, limit)
+	assert.NoError(t, err)
+	assert.True(t, ok, "released slot should be reusable")
+}
+
+func TestUploadSlotRepository_ReleaseAfterExpiry(t *testing.T) {
+	ctx := context.Background()
+	cache := newMemoryCache()
+	repo := NewUploadSlotRepository(cache)
+	userID := uuid.New()
+
+	assert.NoError(t, repo.Release(ctx, userID))
+
+	exists, err := cache.Exists(ctx, "upload_slots:"+userID.String())
+	assert.NoError(t, err)
+	assert.False(t, exists)
+}
@@ -2,34 +2,69 @@ package repository
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
 
+	"github.com/1tsndre/mini-go-project/store-service/internal/constant"
 	"github.com/1tsndre/mini-go-project/store-service/internal/model"
+	"github.com/1tsndre/mini-go-project/store-service/internal/repository/caches"
 	"github.com/1tsndre/mini-go-project/store-service/internal/repository/databases"
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgconn"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
+// ErrEmailExists is returned by Create when another user already holds the
+// email. The unique constraint on users.email is the source of truth, so this
+// also covers registrations that race past a FindByEmail check.
+var ErrEmailExists = errors.New("email already exists")
+
+// ErrLastAdmin is returned by ChangeRole when it would leave no admin.
+var ErrLastAdmin = errors.New("cannot remove the last admin")
+
+// pgUniqueViolation is the PostgreSQL SQLSTATE for unique_violation.
+const pgUniqueViolation = "23505"
+
 type UserRepository interface {
 	Create(ctx context.Context, user *model.User) error
 	FindByID(ctx context.Context, id uuid.UUID) (*model.User, error)
 	FindByEmail(ctx context.Context, email string) (*model.User, error)
+	FindAll(ctx context.Context, filter model.UserFilter) ([]model.User, int64, error)
 	UpdateRole(ctx context.Context, id uuid.UUID, role string) error
+	ChangeRole(ctx context.Context, id uuid.UUID, role string) error
+	SetActive(ctx context.Context, id uuid.UUID, active bool) error
+	IsActive(ctx context.Context, id uuid.UUID) (bool, error)
+	UpdatePassword(ctx context.Context, id uuid.UUID, hashedPassword string, changedAt time.Time) error
 }
 
 type userRepository struct {
-	db databases.Database
+	db    databases.Database
+	cache caches.Cache
 }
 
-func NewUserRepository(db databases.Database) UserRepository {
-	return &userRepository{db: db}
+// NewUserRepository creates a UserRepository. The cache holds each user's
+// active flag, which the auth middleware checks on every request.
+func NewUserRepository(db databases.Database, cache caches.Cache) UserRepository {
+	return &userRepository{db: db, cache: cache}
 }
 
 func (r *userRepository) Create(ctx context.Context, user *model.User) error {
-	return r.db.DB().WithContext(ctx).Create(user).Error
+	err := databases.FromContext(ctx, r.db).Create(user).Error
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == pgUniqueViolation {
+		return ErrEmailExists
+	}
+	return err
 }
 
 func (r *userRepository) FindByID(ctx context.Context, id uuid.UUID) (*model.User, error) {
 	var user model.User
-	err := r.db.DB().WithContext(ctx).First(&user, "id = ?", id).Error
+	err := databases.FromContext(ctx, r.db).First(&user, "id = ?", id).Error
 	if err != nil {
 		return nil, err
 	}
@@ -38,7 +73,7 @@ func (r *userRepository) FindByID(ctx context.Context, id uuid.UUID) (*model.Use
 
 func (r *userRepository) FindByEmail(ctx context.Context, email string) (*model.User, error) {
 	var user model.User
-	err := r.db.DB().WithContext(ctx).First(&user, "email = ?", email).Error
+	err := databases.FromContext(ctx, r.db).First(&user, "email = ?", email).Error
 	if err != nil {
 		return nil, err
 	}
@@ -46,5 +81,105 @@ func (r *userRepository) FindByEmail(ctx context.Context, email string) (*model.
 }
 
 func (r *userRepository) UpdateRole(ctx context.Context, id uuid.UUID, role string) error {
-	return r.db.DB().WithContext(ctx).Model(&model.User{}).Where("id = ?", id).Update("role", role).Error
+	return databases.FromContext(ctx, r.db).Model(&model.User{}).Where("id = ?", id).Update("role", role).Error
+}
+
+// FindAll returns one page of users, newest first.
+func (r *userRepository) FindAll(ctx context.Context, filter model.UserFilter) ([]model.User, int64, error) {
+	var users []model.User
+	var total int64
+
+	query := databases.FromContext(ctx, r.db).Model(&model.User{})
+	if filter.Role != "" {
+		query = query.Where("role = ?", filter.Role)
+	}
+	if strings.TrimSpace(filter.Email) != "" {
+		query = query.Where("email ILIKE ?", containsPattern(filter.Email))
+	}
+
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	offset := (filter.Page - 1) * filter.PerPage
+	err := query.
+		Order("created_at DESC").
+		Offset(offset).
+		Limit(filter.PerPage).
+		Find(&users).Error
+
+	return users, total, err
+}
+
+// ChangeRole sets a user's role unless that would demote the only admin.
+// Every admin row is locked first, so two admins demoting each other at once
+// cannot both succeed.
+func (r *userRepository) ChangeRole(ctx context.Context, id uuid.UUID, role string) error {
+	return databases.FromContext(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		var admins []uuid.UUID
+		err := tx.Model(&model.User{}).
+			Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("role = ?", constant.RoleAdmin).
+			Pluck("id", &admins).Error
+		if err != nil {
+			return err
+		}
+		if role != constant.RoleAdmin && len(admins) <= 1 && slices.Contains(admins, id) {
+			return ErrLastAdmin
+		}
+
+		result := tx.Model(&model.User{}).Where("id = ?", id).Update("role", role)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		return nil
+	})
+}
+
+// SetActive bans or reinstates a user and drops the cached flag, so the
+// change applies to their next request.
+func (r *userRepository) SetActive(ctx context.Context, id uuid.UUID, active bool) error {
+	result := databases.FromContext(ctx, r.db).Model(&model.User{}).Where("id = ?", id).Update("active", active)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	r.cache.Delete(ctx, fmt.Sprintf(constant.KeyUserActive, id.String()))
+	return nil
+}
+
+// IsActive reports whether the user may still act, reading through a short
+// lived cache.
+func (r *userRepository) IsActive(ctx context.Context, id uuid.UUID) (bool, error) {
+	cacheKey := fmt.Sprintf(constant.KeyUserActive, id.String())
+
+	cached, err := r.cache.Get(ctx, cacheKey)
+	if err == nil {
+		var active bool
+		if json.Unmarshal(cached, &active) == nil {
+			return active, nil
+		}
+	}
+
+	var user model.User
+	err = databases.FromContext(ctx, r.db).Select("active").First(&user, "id = ?", id).Error
+	if err != nil {
+		return false, err
+	}
+
+	r.cache.Set(ctx, cacheKey, user.Active, constant.TTLUserActive)
+
+	return user.Active, nil
+}
+
+func (r *userRepository) UpdatePassword(ctx context.Context, id uuid.UUID, hashedPassword string, changedAt time.Time) error {
+	return databases.FromContext(ctx, r.db).Model(&model.User{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"password":            hashedPassword,
+		"password_changed_at": changedAt,
+	}).Error
 }
@@ -0,0 +1,171 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/1tsndre/mini-go-project/store-service/internal/constant"
+	"github.com/1tsndre/mini-go-project/store-service/internal/model"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+func TestUserRepository_Create_UniqueViolation(t *testing.T) {
+	tests := []struct {
+		name    string
+		dbErr   error
+		wantErr error
+	}{
+		{
+			name:    "duplicate email",
+			dbErr:   &pgconn.PgError{Code: "23505", ConstraintName: "users_email_key"},
+			wantErr: ErrEmailExists,
+		},
+		{
+			name:  "other database errors pass through",
+			dbErr: &pgconn.PgError{Code: "53300"},
+		},
+		{
+			name: "success",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := newDryRunDB(t)
+			// Simulate PostgreSQL rejecting the INSERT.
+			err := db.db.Callback().Create().After("gorm:create").Register("test:db_error", func(tx *gorm.DB) {
+				if tt.dbErr != nil {
+					_ = tx.AddError(tt.dbErr)
+				}
+			})
+			assert.NoError(t, err)
+			repo := NewUserRepository(db, newMemoryCache())
+
+			err = repo.Create(context.Background(), &model.User{Email: "a@example.com"})
+
+			switch {
+			case tt.wantErr != nil:
+				assert.ErrorIs(t, err, tt.wantErr)
+			case tt.dbErr != nil:
+				assert.ErrorIs(t, err, tt.dbErr)
+				assert.NotErrorIs(t, err, ErrEmailExists)
+			default:
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestUserRepository_ChangeRole_LastAdmin(t *testing.T) {
+	adminID := uuid.MustParse("5e6f7081-92a3-4b4c-8d5e-6f708192a3b4")
+	otherAdminID := uuid.MustParse("6f708192-a3b4-4c5d-9e6f-708192a3b4c5")
+
+	tests := []struct {
+		name    string
+		admins  []uuid.UUID
+		role    string
+		wantErr error
+	}{
+		{name: "demoting the only admin is refused", admins: []uuid.UUID{adminID}, role: constant.RoleBuyer, wantErr: ErrLastAdmin},
+		{name: "demoting one of two admins goes ahead", admins: []uuid.UUID{adminID, otherAdminID}, role: constant.RoleBuyer},
+		{name: "keeping the only admin an admin goes ahead", admins: []uuid.UUID{adminID}, role: constant.RoleAdmin},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := newDryRunDB(t)
+			// Answer the admin lookup the way PostgreSQL would.
+			err := db.db.Callback().Query().After("gorm:query").Register("test:admins", func(tx *gorm.DB) {
+				if ids, ok := tx.Statement.Dest.(*[]uuid.UUID); ok {
+					*ids = tt.admins
+				}
+			})
+			assert.NoError(t, err)
+			repo := NewUserRepository(db, newMemoryCache())
+
+			err = repo.ChangeRole(db.txContext(context.Background()), adminID, tt.role)
+
+			stmts := db.recorder.Statements()
+			if assert.GreaterOrEqual(t, len(stmts), 2) {
+				assert.Equal(t, `SELECT "id" FROM "users" WHERE role = 'admin' FOR UPDATE`, stmts[1])
+			}
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				for _, stmt := range stmts {
+					assert.NotContains(t, stmt, "UPDATE \"users\" SET", "a refused change must not write the role")
+				}
+				return
+			}
+			// The dry run updates no rows, which reads as an unknown user.
+			assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+			if assert.Len(t, stmts, 4) {
+				assert.Contains(t, stmts[2], `UPDATE "users" SET "role"='`+tt.role+`'`)
+			}
+		})
+	}
+}
+
+func TestUserRepository_FindAll(t *testing.T) {
+	tests := []struct {
+		name      string
+		filter    model.UserFilter
+		wantCount string
+	}{
+		{
+			name:      "no filter",
+			filter:    model.UserFilter{Page: 1, PerPage: 20},
+			wantCount: `SELECT count(*) FROM "users"`,
+		},
+		{
+			name:      "role and email",
+			filter:    model.UserFilter{Role: "seller", Email: "shop", Page: 2, PerPage: 10},
+			wantCount: `SELECT count(*) FROM "users" WHERE role = 'seller' AND email ILIKE '%shop%'`,
+		},
+		{
+			name:      "email is trimmed and taken literally",
+			filter:    model.UserFilter{Email: " a_b%@x ", Page: 1, PerPage: 20},
+			wantCount: `SELECT count(*) FROM "users" WHERE email ILIKE '%a\_b\%@x%'`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := newDryRunDB(t)
+			repo := NewUserRepository(db, newMemoryCache())
+
+			_, _, err := repo.FindAll(context.Background(), tt.filter)
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.wantCount, db.recorder.Statements()[0])
+		})
+	}
+}
+
+func TestUserRepository_IsActive_Cache(t *testing.T) {
+	db := newDryRunDB(t)
+	cache := newMemoryCache()
+	repo := NewUserRepository(db, cache)
+	userID := uuid.MustParse("8a3c2a52-6f1e-4c1b-9d7a-2f3b6f0c9e11")
+	cacheKey := fmt.Sprintf(constant.KeyUserActive, userID.String())
+
+	assert.NoError(t, cache.Set(context.Background(), cacheKey, false, constant.TTLUserActive))
+
+	active, err := repo.IsActive(context.Background(), userID)
+
+	assert.NoError(t, err)
+	assert.False(t, active)
+	assert.Empty(t, db.recorder.Statements(), "a cached flag must not hit the database")
+
+	assert.NoError(t, cache.Delete(context.Background(), cacheKey))
+	_, err = repo.IsActive(context.Background(), userID)
+
+	assert.NoError(t, err)
+	assert.Equal(t,
+		`SELECT "active" FROM "users" WHERE id = '8a3c2a52-6f1e-4c1b-9d7a-2f3b6f0c9e11' ORDER BY "users"."id" LIMIT 1`,
+		db.recorder.Last(),
+	)
+}
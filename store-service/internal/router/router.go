@@ -2,6 +2,7 @@ package router
 
 import (
 	"net/http"
+	"net/netip"
 	"time"
 
 	"github.com/1tsndre/mini-go-project/pkg/jwt"
@@ -10,44 +11,84 @@ import (
 	"github.com/1tsndre/mini-go-project/store-service/internal/constant"
 	"github.com/1tsndre/mini-go-project/store-service/internal/handler"
 	"github.com/1tsndre/mini-go-project/store-service/internal/middleware"
+	"github.com/1tsndre/mini-go-project/store-service/internal/openapi"
 	"github.com/redis/go-redis/v9"
 )
 
 type Handlers struct {
-	Auth     *handler.AuthHandler
-	Store    *handler.StoreHandler
-	Category *handler.CategoryHandler
-	Product  *handler.ProductHandler
-	Cart     *handler.CartHandler
-	Order    *handler.OrderHandler
-	Review   *handler.ReviewHandler
+	Auth         *handler.AuthHandler
+	Store        *handler.StoreHandler
+	Category     *handler.CategoryHandler
+	Product      *handler.ProductHandler
+	Cart         *handler.CartHandler
+	Order        *handler.OrderHandler
+	Review       *handler.ReviewHandler
+	Health       *handler.HealthHandler
+	Announcement *handler.AnnouncementHandler
+	User         *handler.UserHandler
 }
 
 func NewRouter(
 	handlers Handlers,
 	jwtManager *jwt.JWTManager,
+	users middleware.UserStatus,
 	redisClient *redis.Client,
 	uploadDir string,
 	requestTimeout time.Duration,
+	maxBodyBytes int64,
+	maxUploadBytes int64,
+	trustedProxies []netip.Prefix,
 	rateCfg config.RateConfig,
+	logCfg config.LogConfig,
+	corsCfg config.CORSConfig,
+	compressCfg config.CompressConfig,
 ) http.Handler {
+	handler, _ := newRouter(handlers, jwtManager, users, redisClient, uploadDir, requestTimeout, maxBodyBytes, maxUploadBytes,
+		trustedProxies, rateCfg, logCfg, corsCfg, compressCfg)
+	return handler
+}
+
+// newRouter is NewRouter that also returns the route table, so tests can
+// check the registered routes against the OpenAPI document.
+func newRouter(
+	handlers Handlers,
+	jwtManager *jwt.JWTManager,
+	users middleware.UserStatus,
+	redisClient *redis.Client,
+	uploadDir string,
+	requestTimeout time.Duration,
+	maxBodyBytes int64,
+	maxUploadBytes int64,
+	trustedProxies []netip.Prefix,
+	rateCfg config.RateConfig,
+	logCfg config.LogConfig,
+	corsCfg config.CORSConfig,
+	compressCfg config.CompressConfig,
+) (http.Handler, *routeTable) {
 	mux := http.NewServeMux()
+	routes := &routeTable{mux: mux, access: map[string]string{}}
 
 	rateLimiter := middleware.NewRateLimiter(redisClient)
-	authMw := middleware.Auth(jwtManager)
+	authMw := withAccess(middleware.Auth(jwtManager, users), accessAuthenticated)
+	optionalAuthMw := withAccess(middleware.OptionalAuth(jwtManager, users), accessOptional)
 	sellerMw := middleware.RequireRole(constant.RoleSeller)
 	buyerMw := middleware.RequireRole(constant.RoleBuyer)
 	adminMw := middleware.RequireRole(constant.RoleAdmin)
-	loginRate := rateLimiter.Limit(rateCfg.Login, time.Minute, constant.RateLimitKeyLogin)
-	publicRate := rateLimiter.Limit(rateCfg.Public, time.Minute, constant.RateLimitKeyPublic)
-	authRate := rateLimiter.Limit(rateCfg.Auth, time.Minute, constant.RateLimitKeyAuth)
+	loginRate := rateLimiter.Limit(rateCfg.Login, time.Minute, constant.RateLimitKeyLogin, rateCfg.FailOpen)
+	loginAttemptRate := rateLimiter.LimitComposite(rateCfg.Login, rateCfg.LoginIP, time.Minute, constant.RateLimitKeyLogin, constant.RateLimitKeyLoginIP, rateCfg.FailOpen)
+	publicRate := rateLimiter.Limit(rateCfg.Public, time.Minute, constant.RateLimitKeyPublic, true)
+	authRate := rateLimiter.Limit(rateCfg.Auth, time.Minute, constant.RateLimitKeyAuth, true)
 
 	// Health check
-	mux.HandleFunc("GET /health", func(w http.ResponseWriter, r *http.Request) {
+	routes.HandleFunc("GET /health", func(w http.ResponseWriter, r *http.Request) {
 		meta := middleware.BuildMeta(r)
 		response.Success(w, http.StatusOK, map[string]string{"status": "ok"}, meta)
 	})
 
+	// Orchestrator probes: no auth, no rate limiting
+	routes.HandleFunc("GET /healthz", handlers.Health.Liveness)
+	routes.HandleFunc("GET /readyz", handlers.Health.Readiness)
+
 	// 404 catch-all for routes not matched by any other pattern
 	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
 		meta := middleware.BuildMeta(r)
@@ -58,58 +99,179 @@ func NewRouter(
 
 	// Static files
 	mux.Handle("GET /uploads/", http.StripPrefix("/uploads/", http.FileServer(http.Dir(uploadDir))))
-	mux.Handle("GET /docs/", http.StripPrefix("/docs/", http.FileServer(http.Dir("./docs"))))
 
 	// Auth routes
-	mux.Handle("POST /api/v1/auth/register", middleware.Chain(http.HandlerFunc(handlers.Auth.Register), loginRate, publicRate))
-	mux.Handle("POST /api/v1/auth/login", middleware.Chain(http.HandlerFunc(handlers.Auth.Login), loginRate, publicRate))
-	mux.Handle("POST /api/v1/auth/refresh", middleware.Chain(http.HandlerFunc(handlers.Auth.Refresh), authRate))
+	routes.Handle("POST /api/v1/auth/register", middleware.Chain(http.HandlerFunc(handlers.Auth.Register), loginRate, publicRate))
+	routes.Handle("POST /api/v1/auth/login", middleware.Chain(http.HandlerFunc(handlers.Auth.Login), loginAttemptRate, publicRate))
+	routes.Handle("POST /api/v1/auth/forgot-password", middleware.Chain(http.HandlerFunc(handlers.Auth.ForgotPassword), loginRate, publicRate))
+	routes.Handle("POST /api/v1/auth/reset-password", middleware.Chain(http.HandlerFunc(handlers.Auth.ResetPassword), loginRate, publicRate))
+	routes.Handle("POST /api/v1/auth/refresh", middleware.Chain(http.HandlerFunc(handlers.Auth.Refresh), authRate))
+	routes.Handle("POST /api/v1/auth/change-password", middleware.Chain(http.HandlerFunc(handlers.Auth.ChangePassword), authMw, authRate))
 
 	// Store routes
-	mux.Handle("POST /api/v1/stores", middleware.Chain(http.HandlerFunc(handlers.Store.CreateStore), authMw, buyerMw, authRate))
-	mux.Handle("GET /api/v1/stores/{id}", middleware.Chain(http.HandlerFunc(handlers.Store.GetStore), publicRate))
-	mux.Handle("PUT /api/v1/stores/{id}", middleware.Chain(http.HandlerFunc(handlers.Store.UpdateStore), authMw, sellerMw, authRate))
-	mux.Handle("POST /api/v1/stores/{id}/logo", middleware.Chain(http.HandlerFunc(handlers.Store.UploadLogo), authMw, sellerMw, authRate))
+	routes.Handle("POST /api/v1/stores", middleware.Chain(http.HandlerFunc(handlers.Store.CreateStore), authMw, buyerMw, authRate))
+	routes.Handle("GET /api/v1/stores/{id}", middleware.Chain(http.HandlerFunc(handlers.Store.GetStore), publicRate))
+	routes.Handle("PUT /api/v1/stores/{id}", middleware.Chain(http.HandlerFunc(handlers.Store.UpdateStore), authMw, sellerMw, authRate))
+	routes.Handle("POST /api/v1/stores/{id}/logo", middleware.Chain(http.HandlerFunc(handlers.Store.UploadLogo), authMw, sellerMw, authRate))
+	routes.Handle("GET /api/v1/stores/{id}/products", middleware.Chain(http.HandlerFunc(handlers.Product.GetStoreProducts), publicRate))
 
 	// Category routes
-	mux.Handle("POST /api/v1/categories", middleware.Chain(http.HandlerFunc(handlers.Category.CreateCategory), authMw, adminMw, authRate))
-	mux.Handle("GET /api/v1/categories", middleware.Chain(http.HandlerFunc(handlers.Category.GetCategories), publicRate))
-	mux.Handle("PUT /api/v1/categories/{id}", middleware.Chain(http.HandlerFunc(handlers.Category.UpdateCategory), authMw, adminMw, authRate))
-	mux.Handle("DELETE /api/v1/categories/{id}", middleware.Chain(http.HandlerFunc(handlers.Category.DeleteCategory), authMw, adminMw, authRate))
+	routes.Handle("POST /api/v1/categories", middleware.Chain(http.HandlerFunc(handlers.Category.CreateCategory), authMw, adminMw, authRate))
+	routes.Handle("GET /api/v1/categories", middleware.Chain(http.HandlerFunc(handlers.Category.GetCategories), publicRate))
+	routes.Handle("PUT /api/v1/categories/{id}", middleware.Chain(http.HandlerFunc(handlers.Category.UpdateCategory), authMw, adminMw, authRate))
+	routes.Handle("DELETE /api/v1/categories/{id}", middleware.Chain(http.HandlerFunc(handlers.Category.DeleteCategory), authMw, adminMw, authRate))
+	routes.Handle("POST /api/v1/admin/categories/bulk", middleware.Chain(http.HandlerFunc(handlers.Category.CreateCategories), authMw, adminMw, authRate))
 
 	// Product routes
-	mux.Handle("POST /api/v1/products", middleware.Chain(http.HandlerFunc(handlers.Product.CreateProduct), authMw, sellerMw, authRate))
-	mux.Handle("GET /api/v1/products", middleware.Chain(http.HandlerFunc(handlers.Product.GetProducts), publicRate))
-	mux.Handle("GET /api/v1/products/{id}", middleware.Chain(http.HandlerFunc(handlers.Product.GetProduct), publicRate))
-	mux.Handle("PUT /api/v1/products/{id}", middleware.Chain(http.HandlerFunc(handlers.Product.UpdateProduct), authMw, sellerMw, authRate))
-	mux.Handle("DELETE /api/v1/products/{id}", middleware.Chain(http.HandlerFunc(handlers.Product.DeleteProduct), authMw, sellerMw, authRate))
-	mux.Handle("POST /api/v1/products/{id}/image", middleware.Chain(http.HandlerFunc(handlers.Product.UploadImage), authMw, sellerMw, authRate))
+	routes.Handle("POST /api/v1/products", middleware.Chain(http.HandlerFunc(handlers.Product.CreateProduct), authMw, sellerMw, authRate))
+	routes.Handle("GET /api/v1/products", middleware.Chain(http.HandlerFunc(handlers.Product.GetProducts), optionalAuthMw, publicRate))
+	routes.Handle("GET /api/v1/products/{id}", middleware.Chain(http.HandlerFunc(handlers.Product.GetProduct), publicRate))
+	routes.Handle("PUT /api/v1/products/{id}", middleware.Chain(http.HandlerFunc(handlers.Product.UpdateProduct), authMw, sellerMw, authRate))
+	routes.Handle("DELETE /api/v1/products/{id}", middleware.Chain(http.HandlerFunc(handlers.Product.DeleteProduct), authMw, sellerMw, authRate))
+	routes.Handle("GET /api/v1/products/{id}/attributes", middleware.Chain(http.HandlerFunc(handlers.Product.GetProductAttributes), publicRate))
+	routes.Handle("GET /api/v1/products/{id}/also-bought", middleware.Chain(http.HandlerFunc(handlers.Product.GetAlsoBought), publicRate))
+	routes.Handle("GET /api/v1/products/{id}/related", middleware.Chain(http.HandlerFunc(handlers.Product.GetRelatedProducts), publicRate))
+	routes.Handle("PUT /api/v1/products/{id}/attributes", middleware.Chain(http.HandlerFunc(handlers.Product.SetProductAttributes), authMw, sellerMw, authRate))
+	routes.Handle("POST /api/v1/products/{id}/image", middleware.Chain(http.HandlerFunc(handlers.Product.UploadImage), authMw, sellerMw, authRate))
+	routes.Handle("GET /api/v1/products/{id}/images", middleware.Chain(http.HandlerFunc(handlers.Product.ListProductImages), publicRate))
+	routes.Handle("POST /api/v1/products/{id}/images", middleware.Chain(http.HandlerFunc(handlers.Product.AddProductImage), authMw, sellerMw, authRate))
+	routes.Handle("PUT /api/v1/products/{id}/images/order", middleware.Chain(http.HandlerFunc(handlers.Product.ReorderProductImages), authMw, sellerMw, authRate))
+	routes.Handle("DELETE /api/v1/products/{id}/images/{image_id}", middleware.Chain(http.HandlerFunc(handlers.Product.DeleteProductImage), authMw, sellerMw, authRate))
 
 	// Review routes
-	mux.Handle("POST /api/v1/products/{id}/reviews", middleware.Chain(http.HandlerFunc(handlers.Review.CreateReview), authMw, buyerMw, authRate))
-	mux.Handle("GET /api/v1/products/{id}/reviews", middleware.Chain(http.HandlerFunc(handlers.Review.GetProductReviews), publicRate))
+	routes.Handle("POST /api/v1/products/{id}/reviews", middleware.Chain(http.HandlerFunc(handlers.Review.CreateReview), authMw, buyerMw, authRate))
+	routes.Handle("PUT /api/v1/products/{id}/reviews", middleware.Chain(http.HandlerFunc(handlers.Review.UpdateReview), authMw, buyerMw, authRate))
+	routes.Handle("DELETE /api/v1/reviews/{id}", middleware.Chain(http.HandlerFunc(handlers.Review.DeleteReview), authMw, authRate))
+	routes.Handle("POST /api/v1/reviews/{id}/reply", middleware.Chain(http.HandlerFunc(handlers.Review.ReplyToReview), authMw, sellerMw, authRate))
+	routes.Handle("POST /api/v1/reviews/{id}/helpful", middleware.Chain(http.HandlerFunc(handlers.Review.AddHelpfulVote), authMw, authRate))
+	routes.Handle("DELETE /api/v1/reviews/{id}/helpful", middleware.Chain(http.HandlerFunc(handlers.Review.RemoveHelpfulVote), authMw, authRate))
+	routes.Handle("GET /api/v1/products/{id}/reviews", middleware.Chain(http.HandlerFunc(handlers.Review.GetProductReviews), publicRate))
 
 	// Cart routes
-	mux.Handle("GET /api/v1/cart", middleware.Chain(http.HandlerFunc(handlers.Cart.GetCart), authMw, buyerMw, authRate))
-	mux.Handle("POST /api/v1/cart/items", middleware.Chain(http.HandlerFunc(handlers.Cart.AddItem), authMw, buyerMw, authRate))
-	mux.Handle("PUT /api/v1/cart/items/{product_id}", middleware.Chain(http.HandlerFunc(handlers.Cart.UpdateItem), authMw, buyerMw, authRate))
-	mux.Handle("DELETE /api/v1/cart/items/{product_id}", middleware.Chain(http.HandlerFunc(handlers.Cart.RemoveItem), authMw, buyerMw, authRate))
+	routes.Handle("GET /api/v1/cart", middleware.Chain(http.HandlerFunc(handlers.Cart.GetCart), authMw, buyerMw, authRate))
+	routes.Handle("POST /api/v1/cart/items", middleware.Chain(http.HandlerFunc(handlers.Cart.AddItem), authMw, buyerMw, authRate))
+	routes.Handle("PUT /api/v1/cart/items/{product_id}", middleware.Chain(http.HandlerFunc(handlers.Cart.UpdateItem), authMw, buyerMw, authRate))
+	routes.Handle("DELETE /api/v1/cart/items/{product_id}", middleware.Chain(http.HandlerFunc(handlers.Cart.RemoveItem), authMw, buyerMw, authRate))
 
 	// Order routes (buyer)
-	mux.Handle("POST /api/v1/orders", middleware.Chain(http.HandlerFunc(handlers.Order.Checkout), authMw, buyerMw, authRate))
-	mux.Handle("GET /api/v1/orders", middleware.Chain(http.HandlerFunc(handlers.Order.GetOrders), authMw, buyerMw, authRate))
-	mux.Handle("GET /api/v1/orders/{id}", middleware.Chain(http.HandlerFunc(handlers.Order.GetOrder), authMw, buyerMw, authRate))
-	mux.Handle("PUT /api/v1/orders/{id}/cancel", middleware.Chain(http.HandlerFunc(handlers.Order.CancelOrder), authMw, buyerMw, authRate))
+	routes.Handle("POST /api/v1/orders", middleware.Chain(http.HandlerFunc(handlers.Order.Checkout), authMw, buyerMw, authRate))
+	routes.Handle("GET /api/v1/orders", middleware.Chain(http.HandlerFunc(handlers.Order.GetOrders), authMw, buyerMw, authRate))
+	routes.Handle("POST /api/v1/orders/status-batch", middleware.Chain(http.HandlerFunc(handlers.Order.GetOrderStatuses), authMw, buyerMw, authRate))
+	routes.Handle("GET /api/v1/orders/{id}", middleware.Chain(http.HandlerFunc(handlers.Order.GetOrder), authMw, buyerMw, authRate))
+	routes.Handle("GET /api/v1/orders/{id}/timeline", middleware.Chain(http.HandlerFunc(handlers.Order.GetOrderTimeline), authMw, buyerMw, authRate))
+	routes.Handle("GET /api/v1/orders/{id}/payment", middleware.Chain(http.HandlerFunc(handlers.Order.GetOrderPayment), authMw, buyerMw, authRate))
+	routes.Handle("PUT /api/v1/orders/{id}/cancel", middleware.Chain(http.HandlerFunc(handlers.Order.CancelOrder), authMw, buyerMw, authRate))
+	routes.Handle("PUT /api/v1/orders/{id}/refund", middleware.Chain(http.HandlerFunc(handlers.Order.RefundOrder), authMw, buyerMw, authRate))
+
+	// Seller catalog routes
+	routes.Handle("GET /api/v1/seller/products", middleware.Chain(http.HandlerFunc(handlers.Product.GetSellerProducts), authMw, sellerMw, authRate))
+	routes.Handle("GET /api/v1/seller/products/export", middleware.Chain(http.HandlerFunc(handlers.Product.ExportProducts), authMw, sellerMw, authRate))
+	routes.Handle("GET /api/v1/seller/products/{id}/stock-history", middleware.Chain(http.HandlerFunc(handlers.Product.GetStockHistory), authMw, sellerMw, authRate))
+	routes.Handle("GET /api/v1/seller/stats", middleware.Chain(http.HandlerFunc(handlers.Store.GetSellerStats), authMw, sellerMw, authRate))
+	routes.Handle("GET /api/v1/seller/commission", middleware.Chain(http.HandlerFunc(handlers.Store.GetSellerCommission), authMw, sellerMw, authRate))
+
+	// Order routes (guest)
+	routes.Handle("POST /api/v1/guest/orders", middleware.Chain(http.HandlerFunc(handlers.Order.GuestCheckout), publicRate))
+	routes.Handle("GET /api/v1/guest/orders/{id}", middleware.Chain(http.HandlerFunc(handlers.Order.GetGuestOrder), publicRate))
 
 	// Order routes (seller)
-	mux.Handle("GET /api/v1/seller/orders", middleware.Chain(http.HandlerFunc(handlers.Order.GetSellerOrders), authMw, sellerMw, authRate))
-	mux.Handle("PUT /api/v1/orders/{id}/status", middleware.Chain(http.HandlerFunc(handlers.Order.UpdateOrderStatus), authMw, sellerMw, authRate))
+	routes.Handle("GET /api/v1/seller/orders", middleware.Chain(http.HandlerFunc(handlers.Order.GetSellerOrders), authMw, sellerMw, authRate))
+	routes.Handle("PUT /api/v1/orders/{id}/status", middleware.Chain(http.HandlerFunc(handlers.Order.UpdateOrderStatus), authMw, sellerMw, authRate))
+
+	// Order routes (admin)
+	routes.Handle("GET /api/v1/admin/orders", middleware.Chain(http.HandlerFunc(handlers.Order.GetAllOrders), authMw, adminMw, authRate))
+	routes.Handle("PUT /api/v1/admin/orders/{id}/release", middleware.Chain(http.HandlerFunc(handlers.Order.ReleaseOrder), authMw, adminMw, authRate))
+
+	// Store routes (admin)
+	routes.Handle("PUT /api/v1/admin/stores/{id}/status", middleware.Chain(http.HandlerFunc(handlers.Store.UpdateStoreStatus), authMw, adminMw, authRate))
+
+	// User routes (admin)
+	routes.Handle("GET /api/v1/admin/users", middleware.Chain(http.HandlerFunc(handlers.User.GetUsers), authMw, adminMw, authRate))
+	routes.Handle("GET /api/v1/admin/users/{id}", middleware.Chain(http.HandlerFunc(handlers.User.GetUser), authMw, adminMw, authRate))
+	routes.Handle("PUT /api/v1/admin/users/{id}/role", middleware.Chain(http.HandlerFunc(handlers.User.UpdateUserRole), authMw, adminMw, authRate))
+	routes.Handle("PUT /api/v1/admin/users/{id}/status", middleware.Chain(http.HandlerFunc(handlers.User.UpdateUserStatus), authMw, adminMw, authRate))
+
+	// Announcement routes
+	routes.Handle("GET /api/v1/announcements", middleware.Chain(http.HandlerFunc(handlers.Announcement.GetAnnouncements), optionalAuthMw, publicRate))
+	routes.Handle("POST /api/v1/admin/announcements", middleware.Chain(http.HandlerFunc(handlers.Announcement.CreateAnnouncement), authMw, adminMw, authRate))
 
-	return middleware.Chain(mux,
+	// API documentation, built from the routes registered above
+	mux.Handle("GET /docs/{$}", openapi.UI())
+	mux.Handle("GET /docs/openapi.json", openapi.Handler(openapi.Build(routes.patterns)))
+
+	global := []func(http.Handler) http.Handler{
 		middleware.Recovery,
 		middleware.Timeout(requestTimeout),
+		middleware.ClientIP(trustedProxies),
 		middleware.Logging,
 		middleware.RequestID,
-		middleware.MethodNotAllowed,
-	)
+		// Uploads get the file size plus the non-upload cap for the form
+		// fields and multipart framing around it.
+		middleware.MaxBodyBytes(maxBodyBytes, maxUploadBytes+maxBodyBytes),
+	}
+	if compressCfg.Enabled {
+		// Ahead of BodyLogging so bodies are logged uncompressed.
+		global = append(global, middleware.Compress(compressCfg.MinBytes))
+	}
+	if logCfg.RequestBody {
+		global = append(global, middleware.BodyLogging(logCfg.BodyMaxBytes))
+	}
+	if len(corsCfg.AllowedOrigins) > 0 {
+		// Must run before MethodNotAllowed, which would reject preflight
+		// OPTIONS requests with 405.
+		global = append(global, middleware.CORS(middleware.CORSConfig{
+			AllowedOrigins:   corsCfg.AllowedOrigins,
+			AllowedMethods:   corsCfg.AllowedMethods,
+			AllowedHeaders:   corsCfg.AllowedHeaders,
+			AllowCredentials: corsCfg.AllowCredentials,
+			MaxAge:           corsCfg.MaxAge,
+		}))
+	}
+	global = append(global, middleware.MethodNotAllowed)
+
+	return middleware.Chain(mux, global...), routes
+}
+
+const (
+	accessPublic        = "public"
+	accessOptional      = "optional"
+	accessAuthenticated = "authenticated"
+)
+
+// accessHandler is a handler wrapped by an auth middleware, labelled with
+// the access that middleware enforces.
+type accessHandler struct {
+	http.Handler
+	access string
+}
+
+func withAccess(mw func(http.Handler) http.Handler, access string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return accessHandler{Handler: mw(next), access: access}
+	}
+}
+
+// routeTable registers handlers on mux and remembers their patterns so the
+// OpenAPI document is derived from the same table that serves requests. It
+// also records the access of each route, read from its outermost
+// middleware, so tests can hold the document to it.
+type routeTable struct {
+	mux      *http.ServeMux
+	patterns []string
+	access   map[string]string
+}
+
+func (t *routeTable) Handle(pattern string, handler http.Handler) {
+	t.mux.Handle(pattern, handler)
+	t.patterns = append(t.patterns, pattern)
+
+	access := accessPublic
+	if h, ok := handler.(accessHandler); ok {
+		access = h.access
+	}
+	t.access[pattern] = access
+}
+
+func (t *routeTable) HandleFunc(pattern string, handler http.HandlerFunc) {
+	t.Handle(pattern, handler)
 }
@@ -0,0 +1,183 @@
+package router
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/1tsndre/mini-go-project/store-service/internal/config"
+)
+
+var refPattern = regexp.MustCompile(`"\$ref":"#/components/(\w+)/(\w+)"`)
+
+func newTestRouter() (http.Handler, *routeTable) {
+	return newRouter(Handlers{}, nil, nil, nil, "", time.Second, 1<<20, 5<<20, nil,
+		config.RateConfig{}, config.LogConfig{}, config.CORSConfig{}, config.CompressConfig{})
+}
+
+// operation is the part of an OpenAPI operation the router tests check.
+type operation struct {
+	Summary    string                `json:"summary"`
+	Security   []map[string][]string `json:"security"`
+	Parameters []struct {
+		Name string `json:"name"`
+		In   string `json:"in"`
+	} `json:"parameters"`
+	Responses map[string]json.RawMessage `json:"responses"`
+}
+
+func fetchPaths(t *testing.T, router http.Handler) map[string]map[string]operation {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/docs/openapi.json", nil))
+
+	var doc struct {
+		Paths map[string]map[string]operation `json:"paths"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &doc); err != nil {
+		t.Fatalf("document is not valid JSON: %v", err)
+	}
+	return doc.Paths
+}
+
+func TestRouter_OpenAPIDocument(t *testing.T) {
+	router, _ := newTestRouter()
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/docs/openapi.json", nil))
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", rec.Code)
+	}
+	if ct := rec.Header().Get("Content-Type"); ct != "application/json" {
+		t.Errorf("expected application/json, got %q", ct)
+	}
+
+	var doc struct {
+		OpenAPI string `json:"openapi"`
+		Info    struct {
+			Title   string `json:"title"`
+			Version string `json:"version"`
+		} `json:"info"`
+		Paths      map[string]map[string]json.RawMessage `json:"paths"`
+		Components struct {
+			Schemas   map[string]json.RawMessage `json:"schemas"`
+			Responses map[string]json.RawMessage `json:"responses"`
+		} `json:"components"`
+	}
+	body := rec.Body.Bytes()
+	if err := json.Unmarshal(body, &doc); err != nil {
+		t.Fatalf("document is not valid JSON: %v", err)
+	}
+
+	if !strings.HasPrefix(doc.OpenAPI, "3.") {
+		t.Errorf("expected an OpenAPI 3 document, got version %q", doc.OpenAPI)
+	}
+	if doc.Info.Title == "" || doc.Info.Version == "" {
+		t.Error("info.title and info.version are required")
+	}
+
+	for path, item := range doc.Paths {
+		for method, raw := range item {
+			var op struct {
+				Summary   string                     `json:"summary"`
+				Responses map[string]json.RawMessage `json:"responses"`
+			}
+			if err := json.Unmarshal(raw, &op); err != nil {
+				t.Fatalf("%s %s: %v", method, path, err)
+			}
+			// An empty summary means the route was registered without an
+			// entry in the openapi route table.
+			if op.Summary == "" {
+				t.Errorf("%s %s is not documented", strings.ToUpper(method), path)
+			}
+			if len(op.Responses) == 0 {
+				t.Errorf("%s %s has no responses", strings.ToUpper(method), path)
+			}
+		}
+	}
+	if _, ok := doc.Paths["/api/v1/products/{id}"]["get"]; !ok {
+		t.Error("expected GET /api/v1/products/{id} to be documented")
+	}
+	if _, ok := doc.Paths["/docs/openapi.json"]; ok {
+		t.Error("the docs routes should not describe themselves")
+	}
+
+	// Every $ref must resolve inside the document.
+	for _, ref := range refPattern.FindAllStringSubmatch(string(body), -1) {
+		kind, name := ref[1], ref[2]
+		var found bool
+		switch kind {
+		case "schemas":
+			_, found = doc.Components.Schemas[name]
+		case "responses":
+			_, found = doc.Components.Responses[name]
+		}
+		if !found {
+			t.Errorf("unresolved reference #/components/%s/%s", kind, name)
+		}
+	}
+}
+
+// TestRouter_OpenAPIAccess holds the documented security of every route to
+// the auth middleware it is actually registered with.
+func TestRouter_OpenAPIAccess(t *testing.T) {
+	router, routes := newTestRouter()
+	paths := fetchPaths(t, router)
+
+	for pattern, access := range routes.access {
+		method, path, _ := strings.Cut(pattern, " ")
+		op, ok := paths[path][strings.ToLower(method)]
+		if !ok {
+			t.Errorf("%s is not in the document", pattern)
+			continue
+		}
+
+		documented := accessPublic
+		switch len(op.Security) {
+		case 1:
+			documented = accessAuthenticated
+		case 2:
+			documented = accessOptional
+		}
+		if documented != access {
+			t.Errorf("%s is documented as %s but registered as %s", pattern, documented, access)
+		}
+	}
+}
+
+func TestRouter_OpenAPIProductFilters(t *testing.T) {
+	router, _ := newTestRouter()
+	op := fetchPaths(t, router)["/api/v1/products"]["get"]
+
+	query := map[string]bool{}
+	for _, p := range op.Parameters {
+		if p.In == "query" {
+			query[p.Name] = true
+		}
+	}
+	for _, name := range []string{"search", "category_id", "store_id", "min_price", "max_price", "sort_by", "sort_order", "page", "per_page"} {
+		if !query[name] {
+			t.Errorf("expected query parameter %q on GET /api/v1/products", name)
+		}
+	}
+}
+
+func TestRouter_SwaggerUI(t *testing.T) {
+	router, _ := newTestRouter()
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/docs/", nil))
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", rec.Code)
+	}
+	if !strings.Contains(rec.Body.String(), `url: "openapi.json"`) {
+		t.Error("expected the UI to load openapi.json")
+	}
+	if strings.Contains(rec.Body.String(), "swagger-ui-dist@5/") {
+		t.Error("expected the Swagger UI assets to be pinned to an exact version")
+	}
+}
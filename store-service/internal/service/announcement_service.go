@@ -0,0 +1,96 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/1tsndre/mini-go-project/pkg/logger"
+	"github.com/1tsndre/mini-go-project/store-service/internal/constant"
+	"github.com/1tsndre/mini-go-project/store-service/internal/model"
+	"github.com/1tsndre/mini-go-project/store-service/internal/repository"
+	"github.com/google/uuid"
+)
+
+type AnnouncementService interface {
+	CreateAnnouncement(ctx context.Context, adminID uuid.UUID, req model.CreateAnnouncementRequest) (*model.Announcement, error)
+	GetActiveAnnouncements(ctx context.Context, role string) ([]model.Announcement, error)
+}
+
+type announcementService struct {
+	repo repository.AnnouncementRepository
+}
+
+func NewAnnouncementService(repo repository.AnnouncementRepository) AnnouncementService {
+	return &announcementService{repo: repo}
+}
+
+func (s *announcementService) CreateAnnouncement(ctx context.Context, adminID uuid.UUID, req model.CreateAnnouncementRequest) (*model.Announcement, error) {
+	title := strings.TrimSpace(req.Title)
+	if title == "" || len(title) > model.MaxAnnouncementTitleLength {
+		return nil, fmt.Errorf("title must be 1-%d characters", model.MaxAnnouncementTitleLength)
+	}
+	message := strings.TrimSpace(req.Message)
+	if message == "" {
+		return nil, errors.New("message is required")
+	}
+
+	audience := req.Audience
+	if audience == "" {
+		audience = model.AnnouncementAudienceAll
+	}
+	switch audience {
+	case model.AnnouncementAudienceAll, model.AnnouncementAudienceBuyers, model.AnnouncementAudienceSellers:
+	default:
+		return nil, errors.New("audience must be one of all, buyers, sellers")
+	}
+
+	startsAt := time.Now()
+	if req.StartsAt != nil {
+		startsAt = *req.StartsAt
+	}
+	if req.EndsAt != nil && !req.EndsAt.After(startsAt) {
+		return nil, errors.New("ends_at must be after starts_at")
+	}
+
+	announcement := &model.Announcement{
+		Title:     title,
+		Message:   message,
+		Audience:  audience,
+		StartsAt:  startsAt,
+		EndsAt:    req.EndsAt,
+		CreatedBy: adminID,
+	}
+	if err := s.repo.Create(ctx, announcement); err != nil {
+		logger.Error(ctx, "failed to create announcement", err)
+		return nil, errors.New("failed to create announcement")
+	}
+	return announcement, nil
+}
+
+// GetActiveAnnouncements returns the announcements currently shown to a user
+// of role; an empty role is an anonymous visitor, who only sees those meant
+// for everyone. Admins see every audience.
+func (s *announcementService) GetActiveAnnouncements(ctx context.Context, role string) ([]model.Announcement, error) {
+	announcements, err := s.repo.FindActive(ctx, announcementAudiences(role), time.Now())
+	if err != nil {
+		logger.Error(ctx, "failed to fetch announcements", err)
+		return nil, errors.New("failed to fetch announcements")
+	}
+	return announcements, nil
+}
+
+func announcementAudiences(role string) []string {
+	switch role {
+	case constant.RoleBuyer:
+		return []string{model.AnnouncementAudienceAll, model.AnnouncementAudienceBuyers}
+	case constant.RoleSeller:
+		return []string{model.AnnouncementAudienceAll, model.AnnouncementAudienceSellers}
+	case constant.RoleAdmin:
+		return []string{model.AnnouncementAudienceAll, model.AnnouncementAudienceBuyers, model.AnnouncementAudienceSellers}
+	default:
+		return []string{model.AnnouncementAudienceAll}
+	}
+}
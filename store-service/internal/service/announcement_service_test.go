@@ -0,0 +1,120 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/1tsndre/mini-go-project/store-service/internal/constant"
+	"github.com/1tsndre/mini-go-project/store-service/internal/mocks"
+	"github.com/1tsndre/mini-go-project/store-service/internal/model"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+)
+
+func TestAnnouncementService_GetActiveAnnouncements_AudienceScoping(t *testing.T) {
+	tests := []struct {
+		name          string
+		role          string
+		wantAudiences []string
+	}{
+		{name: "anonymous", role: "", wantAudiences: []string{"all"}},
+		{name: "buyer", role: constant.RoleBuyer, wantAudiences: []string{"all", "buyers"}},
+		{name: "seller", role: constant.RoleSeller, wantAudiences: []string{"all", "sellers"}},
+		{name: "admin", role: constant.RoleAdmin, wantAudiences: []string{"all", "buyers", "sellers"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			before := time.Now()
+			repo := mocks.NewMockAnnouncementRepository(ctrl)
+			repo.EXPECT().FindActive(gomock.Any(), tt.wantAudiences, gomock.Any()).DoAndReturn(
+				func(_ context.Context, _ []string, at time.Time) ([]model.Announcement, error) {
+					// The active window is checked against the current time.
+					assert.False(t, at.Before(before))
+					return []model.Announcement{}, nil
+				})
+
+			svc := NewAnnouncementService(repo)
+			announcements, err := svc.GetActiveAnnouncements(context.Background(), tt.role)
+
+			assert.NoError(t, err)
+			assert.NotNil(t, announcements)
+		})
+	}
+}
+
+func TestAnnouncementService_CreateAnnouncement(t *testing.T) {
+	adminID := uuid.New()
+	start := time.Date(2026, 6, 1, 0, 0, 0, 0, time.UTC)
+	end := start.Add(24 * time.Hour)
+
+	tests := []struct {
+		name         string
+		req          model.CreateAnnouncementRequest
+		repoErr      error
+		wantAudience string
+		wantErr      string
+	}{
+		{
+			name:         "defaults to everyone",
+			req:          model.CreateAnnouncementRequest{Title: "Maintenance", Message: "Down at 2am"},
+			wantAudience: model.AnnouncementAudienceAll,
+		},
+		{
+			name:         "sellers only with window",
+			req:          model.CreateAnnouncementRequest{Title: "Fees", Message: "New fees", Audience: "sellers", StartsAt: &start, EndsAt: &end},
+			wantAudience: model.AnnouncementAudienceSellers,
+		},
+		{
+			name:    "unknown audience",
+			req:     model.CreateAnnouncementRequest{Title: "Hi", Message: "Hi", Audience: "admins"},
+			wantErr: "audience must be one of",
+		},
+		{
+			name:    "window ends before it starts",
+			req:     model.CreateAnnouncementRequest{Title: "Hi", Message: "Hi", StartsAt: &end, EndsAt: &start},
+			wantErr: "ends_at must be after starts_at",
+		},
+		{
+			name:    "missing title",
+			req:     model.CreateAnnouncementRequest{Title: "  ", Message: "Hi"},
+			wantErr: "title must be",
+		},
+		{
+			name:    "repository error",
+			req:     model.CreateAnnouncementRequest{Title: "Hi", Message: "Hi"},
+			repoErr: errors.New("db error"),
+			wantErr: "failed to create announcement",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			repo := mocks.NewMockAnnouncementRepository(ctrl)
+			if tt.wantAudience != "" || tt.repoErr != nil {
+				repo.EXPECT().Create(gomock.Any(), gomock.Any()).Return(tt.repoErr)
+			}
+
+			svc := NewAnnouncementService(repo)
+			announcement, err := svc.CreateAnnouncement(context.Background(), adminID, tt.req)
+
+			if tt.wantErr != "" {
+				assert.ErrorContains(t, err, tt.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.wantAudience, announcement.Audience)
+			assert.Equal(t, adminID, announcement.CreatedBy)
+			assert.False(t, announcement.StartsAt.IsZero())
+		})
+	}
+}
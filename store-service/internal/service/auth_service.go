@@ -2,13 +2,17 @@ package service
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
+	"time"
 
 	"github.com/1tsndre/mini-go-project/pkg/jwt"
 	"github.com/1tsndre/mini-go-project/pkg/logger"
 	"github.com/1tsndre/mini-go-project/store-service/internal/constant"
 	"github.com/1tsndre/mini-go-project/store-service/internal/model"
+	"github.com/1tsndre/mini-go-project/store-service/internal/password"
 	"github.com/1tsndre/mini-go-project/store-service/internal/repository"
+	"github.com/google/uuid"
 	"golang.org/x/crypto/bcrypt"
 )
 
@@ -16,27 +20,85 @@ type AuthService interface {
 	Register(ctx context.Context, req model.RegisterRequest) (*model.UserResponse, error)
 	Login(ctx context.Context, req model.LoginRequest) (*jwt.TokenPair, error)
 	RefreshToken(ctx context.Context, req model.RefreshRequest) (*jwt.TokenPair, error)
+	ChangePassword(ctx context.Context, userID uuid.UUID, req model.ChangePasswordRequest) (*jwt.TokenPair, error)
+	ForgotPassword(ctx context.Context, req model.ForgotPasswordRequest) error
+	ResetPassword(ctx context.Context, req model.ResetPasswordRequest) error
+}
+
+// ErrAccountLocked is returned by Login while an email is locked out after
+// repeated failures. It is returned for unknown emails too, so the lock
+// does not reveal which accounts exist.
+var ErrAccountLocked = errors.New("account temporarily locked, try again later")
+
+// ErrAccountDisabled is returned by Login and RefreshToken for a user an
+// admin has deactivated. Login only returns it after a correct password.
+var ErrAccountDisabled = errors.New("account is deactivated")
+
+// AuthConfig holds the tunable account rules for AuthService.
+type AuthConfig struct {
+	// SessionLifetime bounds how long after login a session can be
+	// refreshed; zero means no bound.
+	SessionLifetime time.Duration
+	// BcryptCost is the cost new password hashes are made with; zero uses
+	// bcrypt.DefaultCost.
+	BcryptCost int
+	// MaxLoginFailures locks an email out after that many failed logins in
+	// a row; zero disables the lockout.
+	MaxLoginFailures int
+	// PasswordPolicy is checked on every password a user sets.
+	PasswordPolicy password.Policy
 }
 
 type authService struct {
-	userRepo   repository.UserRepository
-	jwtManager *jwt.JWTManager
+	userRepo          repository.UserRepository
+	passwordResetRepo repository.PasswordResetRepository
+	jwtManager        *jwt.JWTManager
+	nsqProducer       Publisher
+	// loginAttempts tracks failed logins for the lockout; it is only used
+	// when cfg.MaxLoginFailures is set.
+	loginAttempts repository.LoginAttemptRepository
+	cfg           AuthConfig
 }
 
-func NewAuthService(userRepo repository.UserRepository, jwtManager *jwt.JWTManager) AuthService {
+func NewAuthService(
+	userRepo repository.UserRepository,
+	passwordResetRepo repository.PasswordResetRepository,
+	jwtManager *jwt.JWTManager,
+	producer Publisher,
+	loginAttempts repository.LoginAttemptRepository,
+	cfg AuthConfig,
+) AuthService {
 	return &authService{
-		userRepo:   userRepo,
-		jwtManager: jwtManager,
+		userRepo:          userRepo,
+		passwordResetRepo: passwordResetRepo,
+		jwtManager:        jwtManager,
+		nsqProducer:       producer,
+		loginAttempts:     loginAttempts,
+		cfg:               cfg,
+	}
+}
+
+// hashPassword hashes password at the configured cost.
+func (s *authService) hashPassword(password string) ([]byte, error) {
+	cost := s.cfg.BcryptCost
+	if cost == 0 {
+		cost = bcrypt.DefaultCost
 	}
+	return bcrypt.GenerateFromPassword([]byte(password), cost)
 }
 
 func (s *authService) Register(ctx context.Context, req model.RegisterRequest) (*model.UserResponse, error) {
+	if err := s.cfg.PasswordPolicy.Validate(req.Password); err != nil {
+		return nil, err
+	}
+
+	req.Email = model.NormalizeEmail(req.Email)
 	existing, _ := s.userRepo.FindByEmail(ctx, req.Email)
 	if existing != nil {
 		return nil, errors.New("email already registered")
 	}
 
-	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(req.Password), bcrypt.DefaultCost)
+	hashedPassword, err := s.hashPassword(req.Password)
 	if err != nil {
 		logger.Error(ctx, "failed to hash password", err)
 		return nil, errors.New("internal server error")
@@ -47,9 +109,13 @@ func (s *authService) Register(ctx context.Context, req model.RegisterRequest) (
 		Password: string(hashedPassword),
 		Name:     req.Name,
 		Role:     constant.RoleBuyer,
+		Active:   true,
 	}
 
 	if err := s.userRepo.Create(ctx, user); err != nil {
+		if errors.Is(err, repository.ErrEmailExists) {
+			return nil, errors.New("email already registered")
+		}
 		logger.Error(ctx, "failed to create user", err)
 		return nil, errors.New("failed to create user")
 	}
@@ -63,16 +129,42 @@ func (s *authService) Register(ctx context.Context, req model.RegisterRequest) (
 	return &resp, nil
 }
 
+// Login checks the credentials and issues a token pair. While the email is
+// locked out it is refused before the password is even checked.
 func (s *authService) Login(ctx context.Context, req model.LoginRequest) (*jwt.TokenPair, error) {
-	user, err := s.userRepo.FindByEmail(ctx, req.Email)
+	email := model.NormalizeEmail(req.Email)
+	if s.isLockedOut(ctx, email) {
+		logger.Warn(ctx, "login refused", map[string]interface{}{
+			"reason": "locked",
+		})
+		return nil, ErrAccountLocked
+	}
+
+	user, err := s.userRepo.FindByEmail(ctx, email)
 	if err != nil {
+		s.recordLoginFailure(ctx, email)
 		return nil, errors.New("invalid email or password")
 	}
 
 	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(req.Password)); err != nil {
+		s.recordLoginFailure(ctx, email)
 		return nil, errors.New("invalid email or password")
 	}
 
+	if !user.Active {
+		logger.Warn(ctx, "login refused", map[string]interface{}{
+			"reason":  "deactivated",
+			"user_id": user.ID.String(),
+		})
+		return nil, ErrAccountDisabled
+	}
+
+	if s.cfg.MaxLoginFailures > 0 {
+		if err := s.loginAttempts.Reset(ctx, email); err != nil {
+			logger.Error(ctx, "failed to reset login failures", err)
+		}
+	}
+
 	tokenPair, err := s.jwtManager.GenerateTokenPair(user.ID.String(), user.Email, user.Role)
 	if err != nil {
 		logger.Error(ctx, "failed to generate token pair", err)
@@ -86,13 +178,74 @@ func (s *authService) Login(ctx context.Context, req model.LoginRequest) (*jwt.T
 	return tokenPair, nil
 }
 
+// isLockedOut reports whether email is locked out. The lockout fails open:
+// when the cache is unreachable, logins fall back to the rate limiter.
+func (s *authService) isLockedOut(ctx context.Context, email string) bool {
+	if s.cfg.MaxLoginFailures <= 0 {
+		return false
+	}
+	locked, err := s.loginAttempts.IsLocked(ctx, email)
+	if err != nil {
+		logger.Error(ctx, "failed to check login lockout", err)
+		return false
+	}
+	return locked
+}
+
+// recordLoginFailure counts a failed login for email and locks it out once
+// cfg.MaxLoginFailures is reached. The count starts over when the lock ends.
+func (s *authService) recordLoginFailure(ctx context.Context, email string) {
+	if s.cfg.MaxLoginFailures <= 0 {
+		return
+	}
+	failures, err := s.loginAttempts.RecordFailure(ctx, email)
+	if err != nil {
+		logger.Error(ctx, "failed to record login failure", err)
+		return
+	}
+	if failures < int64(s.cfg.MaxLoginFailures) {
+		return
+	}
+	if err := s.loginAttempts.Lock(ctx, email); err != nil {
+		logger.Error(ctx, "failed to lock out account", err)
+		return
+	}
+	if err := s.loginAttempts.Reset(ctx, email); err != nil {
+		logger.Error(ctx, "failed to reset login failures", err)
+	}
+	logger.Warn(ctx, "login locked out after repeated failures", map[string]interface{}{
+		"failures": failures,
+	})
+}
+
 func (s *authService) RefreshToken(ctx context.Context, req model.RefreshRequest) (*jwt.TokenPair, error) {
 	claims, err := s.jwtManager.ValidateToken(req.RefreshToken)
 	if err != nil {
 		return nil, errors.New("invalid refresh token")
 	}
+	if s.cfg.SessionLifetime > 0 && time.Since(claims.AuthenticatedAt()) > s.cfg.SessionLifetime {
+		return nil, errors.New("session expired, please log in again")
+	}
 
-	tokenPair, err := s.jwtManager.GenerateTokenPair(claims.UserID, claims.Email, claims.Role)
+	userID, err := uuid.Parse(claims.UserID)
+	if err != nil {
+		return nil, errors.New("invalid refresh token")
+	}
+	user, err := s.userRepo.FindByID(ctx, userID)
+	if err != nil {
+		return nil, errors.New("invalid refresh token")
+	}
+	if !user.Active {
+		return nil, ErrAccountDisabled
+	}
+	// JWT timestamps have second precision, so compare against the second of
+	// the change: the pair issued by ChangePassword itself stays valid.
+	if user.PasswordChangedAt != nil && claims.IssuedAt != nil &&
+		claims.IssuedAt.Time.Before(user.PasswordChangedAt.Truncate(time.Second)) {
+		return nil, errors.New("refresh token revoked by password change")
+	}
+
+	tokenPair, err := s.jwtManager.RefreshTokenPair(claims)
 	if err != nil {
 		logger.Error(ctx, "failed to generate token pair", err)
 		return nil, errors.New("internal server error")
@@ -100,3 +253,137 @@ func (s *authService) RefreshToken(ctx context.Context, req model.RefreshRequest
 
 	return tokenPair, nil
 }
+
+// ChangePassword replaces the user's password after verifying the current
+// one. Refresh tokens issued before the change stop working, so a fresh token
+// pair is returned for the caller's session.
+func (s *authService) ChangePassword(ctx context.Context, userID uuid.UUID, req model.ChangePasswordRequest) (*jwt.TokenPair, error) {
+	if err := s.cfg.PasswordPolicy.Validate(req.NewPassword); err != nil {
+		return nil, err
+	}
+
+	user, err := s.userRepo.FindByID(ctx, userID)
+	if err != nil {
+		return nil, errors.New("user not found")
+	}
+
+	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(req.CurrentPassword)); err != nil {
+		return nil, errors.New("current password is incorrect")
+	}
+
+	hashedPassword, err := s.hashPassword(req.NewPassword)
+	if err != nil {
+		logger.Error(ctx, "failed to hash password", err)
+		return nil, errors.New("internal server error")
+	}
+
+	if err := s.userRepo.UpdatePassword(ctx, user.ID, string(hashedPassword), time.Now()); err != nil {
+		logger.Error(ctx, "failed to update password", err, map[string]interface{}{
+			"user_id": user.ID.String(),
+		})
+		return nil, errors.New("failed to change password")
+	}
+
+	tokenPair, err := s.jwtManager.GenerateTokenPair(user.ID.String(), user.Email, user.Role)
+	if err != nil {
+		logger.Error(ctx, "failed to generate token pair", err)
+		return nil, errors.New("internal server error")
+	}
+
+	logger.Info(ctx, "password changed", map[string]interface{}{
+		"user_id": user.ID.String(),
+	})
+
+	return tokenPair, nil
+}
+
+// ForgotPassword issues a password reset token for the account with the given
+// email and publishes it for delivery. Unknown emails succeed silently so the
+// endpoint cannot be used to discover accounts.
+func (s *authService) ForgotPassword(ctx context.Context, req model.ForgotPasswordRequest) error {
+	user, err := s.userRepo.FindByEmail(ctx, model.NormalizeEmail(req.Email))
+	if err != nil {
+		return nil
+	}
+
+	token, tokenHash, err := newSecretToken()
+	if err != nil {
+		logger.Error(ctx, "failed to generate password reset token", err)
+		return errors.New("failed to request password reset")
+	}
+
+	if err := s.passwordResetRepo.Save(ctx, user.ID, tokenHash); err != nil {
+		logger.Error(ctx, "failed to save password reset token", err, map[string]interface{}{
+			"user_id": user.ID.String(),
+		})
+		return errors.New("failed to request password reset")
+	}
+
+	payload, err := json.Marshal(map[string]interface{}{
+		"user_id": user.ID.String(),
+		"email":   user.Email,
+		"token":   token,
+	})
+	if err != nil {
+		return errors.New("failed to request password reset")
+	}
+	if err := s.nsqProducer.Publish(constant.TopicPasswordResetRequested, payload); err != nil {
+		logger.Error(ctx, "failed to publish password.reset.requested", err, map[string]interface{}{
+			"user_id": user.ID.String(),
+		})
+		return errors.New("failed to request password reset")
+	}
+
+	logger.Info(ctx, "password reset requested", map[string]interface{}{
+		"user_id": user.ID.String(),
+	})
+	return nil
+}
+
+// ResetPassword sets a new password for the holder of a valid reset token.
+// The token is checked before it is removed, so a wrong guess leaves the
+// outstanding token usable, and removing it only succeeds once.
+func (s *authService) ResetPassword(ctx context.Context, req model.ResetPasswordRequest) error {
+	if err := s.cfg.PasswordPolicy.Validate(req.NewPassword); err != nil {
+		return err
+	}
+
+	user, err := s.userRepo.FindByEmail(ctx, model.NormalizeEmail(req.Email))
+	if err != nil {
+		return errors.New("invalid or expired reset token")
+	}
+
+	tokenHash, err := s.passwordResetRepo.Get(ctx, user.ID)
+	if err != nil || !secretTokenMatches(tokenHash, req.Token) {
+		return errors.New("invalid or expired reset token")
+	}
+
+	consumed, err := s.passwordResetRepo.Consume(ctx, user.ID, tokenHash)
+	if err != nil {
+		logger.Error(ctx, "failed to consume password reset token", err, map[string]interface{}{
+			"user_id": user.ID.String(),
+		})
+		return errors.New("failed to reset password")
+	}
+	if !consumed {
+		return errors.New("invalid or expired reset token")
+	}
+
+	hashedPassword, err := s.hashPassword(req.NewPassword)
+	if err != nil {
+		logger.Error(ctx, "failed to hash password", err)
+		return errors.New("internal server error")
+	}
+
+	if err := s.userRepo.UpdatePassword(ctx, user.ID, string(hashedPassword), time.Now()); err != nil {
+		logger.Error(ctx, "failed to update password", err, map[string]interface{}{
+			"user_id": user.ID.String(),
+		})
+		return errors.New("failed to reset password")
+	}
+
+	logger.Info(ctx, "password reset", map[string]interface{}{
+		"user_id": user.ID.String(),
+	})
+	return nil
+}
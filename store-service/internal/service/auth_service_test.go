@@ -2,12 +2,17 @@ package service
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"testing"
 
 	"github.com/1tsndre/mini-go-project/pkg/jwt"
+	"github.com/1tsndre/mini-go-project/store-service/internal/constant"
 	"github.com/1tsndre/mini-go-project/store-service/internal/mocks"
 	"github.com/1tsndre/mini-go-project/store-service/internal/model"
+	"github.com/1tsndre/mini-go-project/store-service/internal/password"
+	"github.com/1tsndre/mini-go-project/store-service/internal/repository"
+	gojwt "github.com/golang-jwt/jwt/v5"
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
 	"go.uber.org/mock/gomock"
@@ -19,6 +24,9 @@ func newTestJWTManager() *jwt.JWTManager {
 	return jwt.NewJWTManager("test-secret", 15*time.Minute, 168*time.Hour)
 }
 
+// testPasswordPolicy is the default policy: at least 6 characters.
+var testPasswordPolicy = password.Policy{MinLength: 6}
+
 func TestAuthService_Register(t *testing.T) {
 	tests := []struct {
 		name        string
@@ -70,6 +78,20 @@ func TestAuthService_Register(t *testing.T) {
 			wantErr:     true,
 			errContains: "failed to create user",
 		},
+		{
+			name: "concurrent registration hits unique constraint",
+			req: model.RegisterRequest{
+				Email:    "race@example.com",
+				Password: "password123",
+				Name:     "Test User",
+			},
+			mockSetup: func(repo *mocks.MockUserRepository) {
+				repo.EXPECT().FindByEmail(gomock.Any(), "race@example.com").Return(nil, errors.New("not found"))
+				repo.EXPECT().Create(gomock.Any(), gomock.Any()).Return(repository.ErrEmailExists)
+			},
+			wantErr:     true,
+			errContains: "email already registered",
+		},
 	}
 
 	for _, tt := range tests {
@@ -80,7 +102,7 @@ func TestAuthService_Register(t *testing.T) {
 			repo := mocks.NewMockUserRepository(ctrl)
 			tt.mockSetup(repo)
 
-			svc := NewAuthService(repo, newTestJWTManager())
+			svc := NewAuthService(repo, nil, newTestJWTManager(), nil, nil, AuthConfig{PasswordPolicy: testPasswordPolicy})
 			resp, err := svc.Register(context.Background(), tt.req)
 
 			if tt.wantErr {
@@ -120,10 +142,28 @@ func TestAuthService_Login(t *testing.T) {
 					Email:    "test@example.com",
 					Password: string(hashedPassword),
 					Role:     "buyer",
+					Active:   true,
 				}, nil)
 			},
 			wantErr: false,
 		},
+		{
+			name: "deactivated user",
+			req: model.LoginRequest{
+				Email:    "test@example.com",
+				Password: "password123",
+			},
+			mockSetup: func(repo *mocks.MockUserRepository) {
+				repo.EXPECT().FindByEmail(gomock.Any(), "test@example.com").Return(&model.User{
+					ID:       uuid.New(),
+					Email:    "test@example.com",
+					Password: string(hashedPassword),
+					Role:     "buyer",
+				}, nil)
+			},
+			wantErr:     true,
+			errContains: "account is deactivated",
+		},
 		{
 			name: "invalid email",
 			req: model.LoginRequest{
@@ -163,7 +203,7 @@ func TestAuthService_Login(t *testing.T) {
 			repo := mocks.NewMockUserRepository(ctrl)
 			tt.mockSetup(repo)
 
-			svc := NewAuthService(repo, newTestJWTManager())
+			svc := NewAuthService(repo, nil, newTestJWTManager(), nil, nil, AuthConfig{PasswordPolicy: testPasswordPolicy})
 			tokenPair, err := svc.Login(context.Background(), tt.req)
 
 			if tt.wantErr {
@@ -179,3 +219,501 @@ func TestAuthService_Login(t *testing.T) {
 		})
 	}
 }
+
+func TestAuthService_EmailNormalization(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	// The repository matches emails exactly, as the users table does.
+	users := map[string]*model.User{}
+	repo := mocks.NewMockUserRepository(ctrl)
+	repo.EXPECT().FindByEmail(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(_ context.Context, email string) (*model.User, error) {
+			if u, ok := users[email]; ok {
+				return u, nil
+			}
+			return nil, errors.New("not found")
+		}).AnyTimes()
+	repo.EXPECT().Create(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(_ context.Context, u *model.User) error {
+			if _, ok := users[u.Email]; ok {
+				return repository.ErrEmailExists
+			}
+			u.ID = uuid.New()
+			users[u.Email] = u
+			return nil
+		}).AnyTimes()
+
+	svc := NewAuthService(repo, nil, newTestJWTManager(), nil, nil, AuthConfig{PasswordPolicy: testPasswordPolicy})
+
+	registered, err := svc.Register(context.Background(), model.RegisterRequest{
+		Email: "  Jane.Doe@Example.COM ", Password: "password123", Name: "Jane",
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "jane.doe@example.com", registered.Email)
+
+	for _, email := range []string{"jane.doe@example.com", "JANE.DOE@EXAMPLE.COM", " Jane.Doe@example.com"} {
+		tokenPair, err := svc.Login(context.Background(), model.LoginRequest{Email: email, Password: "password123"})
+		if assert.NoError(t, err, email) {
+			claims, err := newTestJWTManager().ValidateToken(tokenPair.AccessToken)
+			assert.NoError(t, err)
+			assert.Equal(t, registered.ID.String(), claims.UserID)
+		}
+	}
+
+	_, err = svc.Register(context.Background(), model.RegisterRequest{
+		Email: "jane.doe@example.com", Password: "password123", Name: "Jane again",
+	})
+	assert.ErrorContains(t, err, "email already registered")
+	assert.Len(t, users, 1)
+}
+
+func TestAuthService_ChangePassword(t *testing.T) {
+	userID := uuid.New()
+	hashedPassword, _ := bcrypt.GenerateFromPassword([]byte("oldpass123"), bcrypt.DefaultCost)
+	user := &model.User{ID: userID, Email: "test@example.com", Password: string(hashedPassword), Role: "buyer"}
+
+	tests := []struct {
+		name        string
+		req         model.ChangePasswordRequest
+		mockSetup   func(repo *mocks.MockUserRepository)
+		errContains string
+	}{
+		{
+			name: "success",
+			req:  model.ChangePasswordRequest{CurrentPassword: "oldpass123", NewPassword: "newpass456"},
+			mockSetup: func(repo *mocks.MockUserRepository) {
+				repo.EXPECT().FindByID(gomock.Any(), userID).Return(user, nil)
+				repo.EXPECT().UpdatePassword(gomock.Any(), userID, gomock.Any(), gomock.Any()).
+					DoAndReturn(func(_ context.Context, _ uuid.UUID, hash string, _ time.Time) error {
+						assert.NoError(t, bcrypt.CompareHashAndPassword([]byte(hash), []byte("newpass456")))
+						return nil
+					})
+			},
+		},
+		{
+			name: "wrong current password",
+			req:  model.ChangePasswordRequest{CurrentPassword: "wrong", NewPassword: "newpass456"},
+			mockSetup: func(repo *mocks.MockUserRepository) {
+				repo.EXPECT().FindByID(gomock.Any(), userID).Return(user, nil)
+			},
+			errContains: "current password is incorrect",
+		},
+		{
+			name:        "new password too short",
+			req:         model.ChangePasswordRequest{CurrentPassword: "oldpass123", NewPassword: "abc"},
+			mockSetup:   func(repo *mocks.MockUserRepository) {},
+			errContains: "at least 6 characters",
+		},
+		{
+			name: "update fails",
+			req:  model.ChangePasswordRequest{CurrentPassword: "oldpass123", NewPassword: "newpass456"},
+			mockSetup: func(repo *mocks.MockUserRepository) {
+				repo.EXPECT().FindByID(gomock.Any(), userID).Return(user, nil)
+				repo.EXPECT().UpdatePassword(gomock.Any(), userID, gomock.Any(), gomock.Any()).Return(errors.New("db error"))
+			},
+			errContains: "failed to change password",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			repo := mocks.NewMockUserRepository(ctrl)
+			tt.mockSetup(repo)
+
+			svc := NewAuthService(repo, nil, newTestJWTManager(), nil, nil, AuthConfig{PasswordPolicy: testPasswordPolicy})
+			tokenPair, err := svc.ChangePassword(context.Background(), userID, tt.req)
+
+			if tt.errContains != "" {
+				assert.Error(t, err)
+				assert.Contains(t, err.Error(), tt.errContains)
+				assert.Nil(t, tokenPair)
+				return
+			}
+			assert.NoError(t, err)
+			assert.NotEmpty(t, tokenPair.RefreshToken)
+		})
+	}
+}
+
+func TestAuthService_RefreshToken_RevokedByPasswordChange(t *testing.T) {
+	userID := uuid.New()
+	jwtManager := newTestJWTManager()
+	tokenPair, err := jwtManager.GenerateTokenPair(userID.String(), "test@example.com", "buyer")
+	assert.NoError(t, err)
+
+	tests := []struct {
+		name      string
+		changedAt *time.Time
+		wantErr   bool
+	}{
+		{name: "password never changed"},
+		{name: "changed before token was issued", changedAt: ptrTime(time.Now().Add(-time.Hour))},
+		{name: "changed after token was issued", changedAt: ptrTime(time.Now().Add(time.Hour)), wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			repo := mocks.NewMockUserRepository(ctrl)
+			repo.EXPECT().FindByID(gomock.Any(), userID).Return(&model.User{ID: userID, Active: true, PasswordChangedAt: tt.changedAt}, nil)
+
+			svc := NewAuthService(repo, nil, jwtManager, nil, nil, AuthConfig{PasswordPolicy: testPasswordPolicy})
+			refreshed, err := svc.RefreshToken(context.Background(), model.RefreshRequest{RefreshToken: tokenPair.RefreshToken})
+
+			if tt.wantErr {
+				assert.EqualError(t, err, "refresh token revoked by password change")
+				return
+			}
+			assert.NoError(t, err)
+			assert.NotNil(t, refreshed)
+		})
+	}
+}
+
+func TestAuthService_RefreshToken_SessionLifetime(t *testing.T) {
+	userID := uuid.New()
+	jwtManager := newTestJWTManager()
+
+	// signRefresh mints a refresh token as the manager would have some time
+	// ago; a nil authTime mimics tokens issued before auth_time existed.
+	signRefresh := func(authTime *time.Time, issuedAt time.Time) string {
+		claims := &jwt.Claims{
+			UserID: userID.String(),
+			Email:  "test@example.com",
+			Role:   "buyer",
+			RegisteredClaims: gojwt.RegisteredClaims{
+				IssuedAt:  gojwt.NewNumericDate(issuedAt),
+				ExpiresAt: gojwt.NewNumericDate(time.Now().Add(time.Hour)),
+			},
+		}
+		if authTime != nil {
+			claims.AuthTime = gojwt.NewNumericDate(*authTime)
+		}
+		token, err := gojwt.NewWithClaims(gojwt.SigningMethodHS256, claims).SignedString([]byte("test-secret"))
+		assert.NoError(t, err)
+		return token
+	}
+
+	loggedIn := time.Now().Add(-2 * time.Hour)
+	tests := []struct {
+		name     string
+		token    string
+		lifetime time.Duration
+		wantErr  bool
+	}{
+		{name: "no lifetime", token: signRefresh(&loggedIn, time.Now()), lifetime: 0},
+		{name: "within lifetime", token: signRefresh(&loggedIn, time.Now()), lifetime: 3 * time.Hour},
+		// Rotated a minute ago, but signed in two hours ago.
+		{name: "past lifetime", token: signRefresh(&loggedIn, time.Now().Add(-time.Minute)), lifetime: time.Hour, wantErr: true},
+		{name: "legacy token past lifetime", token: signRefresh(nil, loggedIn), lifetime: time.Hour, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			repo := mocks.NewMockUserRepository(ctrl)
+			if !tt.wantErr {
+				repo.EXPECT().FindByID(gomock.Any(), userID).Return(&model.User{ID: userID, Active: true}, nil)
+			}
+
+			svc := NewAuthService(repo, nil, jwtManager, nil, nil, AuthConfig{SessionLifetime: tt.lifetime, PasswordPolicy: testPasswordPolicy})
+			refreshed, err := svc.RefreshToken(context.Background(), model.RefreshRequest{RefreshToken: tt.token})
+
+			if tt.wantErr {
+				assert.EqualError(t, err, "session expired, please log in again")
+				return
+			}
+			assert.NoError(t, err)
+
+			// Rotation keeps the original sign-in time.
+			claims, err := jwtManager.ValidateToken(refreshed.RefreshToken)
+			assert.NoError(t, err)
+			assert.Equal(t, loggedIn.Unix(), claims.AuthenticatedAt().Unix())
+		})
+	}
+}
+
+func ptrTime(t time.Time) *time.Time {
+	return &t
+}
+
+// memoryResetRepo is an in-memory PasswordResetRepository; expire drops a
+// user's token as the cache TTL would.
+type memoryResetRepo struct {
+	hashes map[uuid.UUID]string
+}
+
+func (r *memoryResetRepo) Save(_ context.Context, userID uuid.UUID, tokenHash string) error {
+	r.hashes[userID] = tokenHash
+	return nil
+}
+
+func (r *memoryResetRepo) Get(_ context.Context, userID uuid.UUID) (string, error) {
+	hash, ok := r.hashes[userID]
+	if !ok {
+		return "", errors.New("cache miss")
+	}
+	return hash, nil
+}
+
+func (r *memoryResetRepo) Consume(_ context.Context, userID uuid.UUID, tokenHash string) (bool, error) {
+	if hash, ok := r.hashes[userID]; !ok || hash != tokenHash {
+		return false, nil
+	}
+	delete(r.hashes, userID)
+	return true, nil
+}
+
+func (r *memoryResetRepo) expire(userID uuid.UUID) {
+	delete(r.hashes, userID)
+}
+
+func TestAuthService_PasswordReset(t *testing.T) {
+	user := &model.User{ID: uuid.New(), Email: "test@example.com", Role: "buyer"}
+
+	// issueToken runs ForgotPassword and returns the token from the published event.
+	issueToken := func(t *testing.T, svc AuthService, pub *fakePublisher) string {
+		t.Helper()
+		assert.NoError(t, svc.ForgotPassword(context.Background(), model.ForgotPasswordRequest{Email: user.Email}))
+		msgs := pub.topic(constant.TopicPasswordResetRequested)
+		if !assert.Len(t, msgs, 1) {
+			t.FailNow()
+		}
+		var event struct {
+			UserID string `json:"user_id"`
+			Email  string `json:"email"`
+			Token  string `json:"token"`
+		}
+		assert.NoError(t, json.Unmarshal(msgs[0].body, &event))
+		assert.Equal(t, user.ID.String(), event.UserID)
+		assert.Equal(t, user.Email, event.Email)
+		return event.Token
+	}
+
+	t.Run("token is issued hashed and published", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		repo := mocks.NewMockUserRepository(ctrl)
+		repo.EXPECT().FindByEmail(gomock.Any(), user.Email).Return(user, nil)
+		resets := &memoryResetRepo{hashes: map[uuid.UUID]string{}}
+		pub := &fakePublisher{}
+
+		svc := NewAuthService(repo, resets, newTestJWTManager(), pub, nil, AuthConfig{PasswordPolicy: testPasswordPolicy})
+		token := issueToken(t, svc, pub)
+
+		assert.NotEmpty(t, token)
+		assert.Equal(t, hashSecretToken(token), resets.hashes[user.ID])
+		assert.NotEqual(t, token, resets.hashes[user.ID])
+	})
+
+	t.Run("unknown email succeeds without issuing a token", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		repo := mocks.NewMockUserRepository(ctrl)
+		repo.EXPECT().FindByEmail(gomock.Any(), "nobody@example.com").Return(nil, errors.New("not found"))
+		resets := &memoryResetRepo{hashes: map[uuid.UUID]string{}}
+		pub := &fakePublisher{}
+
+		svc := NewAuthService(repo, resets, newTestJWTManager(), pub, nil, AuthConfig{PasswordPolicy: testPasswordPolicy})
+		err := svc.ForgotPassword(context.Background(), model.ForgotPasswordRequest{Email: "nobody@example.com"})
+
+		assert.NoError(t, err)
+		assert.Empty(t, pub.messages)
+		assert.Empty(t, resets.hashes)
+	})
+
+	t.Run("reset succeeds once", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		repo := mocks.NewMockUserRepository(ctrl)
+		repo.EXPECT().FindByEmail(gomock.Any(), user.Email).Return(user, nil).Times(3)
+		repo.EXPECT().UpdatePassword(gomock.Any(), user.ID, gomock.Any(), gomock.Any()).
+			DoAndReturn(func(_ context.Context, _ uuid.UUID, hash string, _ time.Time) error {
+				assert.NoError(t, bcrypt.CompareHashAndPassword([]byte(hash), []byte("newpass456")))
+				return nil
+			})
+		resets := &memoryResetRepo{hashes: map[uuid.UUID]string{}}
+		pub := &fakePublisher{}
+
+		svc := NewAuthService(repo, resets, newTestJWTManager(), pub, nil, AuthConfig{PasswordPolicy: testPasswordPolicy})
+		token := issueToken(t, svc, pub)
+		req := model.ResetPasswordRequest{Email: user.Email, Token: token, NewPassword: "newpass456"}
+
+		assert.NoError(t, svc.ResetPassword(context.Background(), req))
+		assert.EqualError(t, svc.ResetPassword(context.Background(), req), "invalid or expired reset token")
+	})
+
+	t.Run("expired token is rejected", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		repo := mocks.NewMockUserRepository(ctrl)
+		repo.EXPECT().FindByEmail(gomock.Any(), user.Email).Return(user, nil).Times(2)
+		resets := &memoryResetRepo{hashes: map[uuid.UUID]string{}}
+		pub := &fakePublisher{}
+
+		svc := NewAuthService(repo, resets, newTestJWTManager(), pub, nil, AuthConfig{PasswordPolicy: testPasswordPolicy})
+		token := issueToken(t, svc, pub)
+
+		err := svc.ResetPassword(context.Background(), model.ResetPasswordRequest{Email: user.Email, Token: token, NewPassword: "abc"})
+		assert.ErrorContains(t, err, "at least 6 characters")
+
+		resets.expire(user.ID)
+		err = svc.ResetPassword(context.Background(), model.ResetPasswordRequest{Email: user.Email, Token: token, NewPassword: "newpass456"})
+		assert.EqualError(t, err, "invalid or expired reset token")
+	})
+
+	t.Run("wrong token leaves the outstanding one usable", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		repo := mocks.NewMockUserRepository(ctrl)
+		repo.EXPECT().FindByEmail(gomock.Any(), user.Email).Return(user, nil).Times(3)
+		repo.EXPECT().UpdatePassword(gomock.Any(), user.ID, gomock.Any(), gomock.Any()).Return(nil)
+		resets := &memoryResetRepo{hashes: map[uuid.UUID]string{}}
+		pub := &fakePublisher{}
+
+		svc := NewAuthService(repo, resets, newTestJWTManager(), pub, nil, AuthConfig{PasswordPolicy: testPasswordPolicy})
+		token := issueToken(t, svc, pub)
+
+		err := svc.ResetPassword(context.Background(), model.ResetPasswordRequest{Email: user.Email, Token: "wrong", NewPassword: "newpass456"})
+		assert.EqualError(t, err, "invalid or expired reset token")
+
+		err = svc.ResetPassword(context.Background(), model.ResetPasswordRequest{Email: user.Email, Token: token, NewPassword: "newpass456"})
+		assert.NoError(t, err)
+		assert.Empty(t, resets.hashes)
+	})
+}
+
+func TestAuthService_Register_BcryptCost(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	repo := mocks.NewMockUserRepository(ctrl)
+	repo.EXPECT().FindByEmail(gomock.Any(), "cost@example.com").Return(nil, errors.New("not found"))
+	repo.EXPECT().Create(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, user *model.User) error {
+		cost, err := bcrypt.Cost([]byte(user.Password))
+		assert.NoError(t, err)
+		assert.Equal(t, bcrypt.MinCost, cost)
+		return nil
+	})
+
+	svc := NewAuthService(repo, nil, newTestJWTManager(), nil, nil, AuthConfig{BcryptCost: bcrypt.MinCost, PasswordPolicy: testPasswordPolicy})
+	_, err := svc.Register(context.Background(), model.RegisterRequest{
+		Email:    "cost@example.com",
+		Password: "password123",
+		Name:     "Test User",
+	})
+
+	assert.NoError(t, err)
+}
+
+// fakeLoginAttempts keeps failure counts and locks in memory, without
+// expiry.
+type fakeLoginAttempts struct {
+	failures map[string]int64
+	locked   map[string]bool
+}
+
+func newFakeLoginAttempts() *fakeLoginAttempts {
+	return &fakeLoginAttempts{failures: map[string]int64{}, locked: map[string]bool{}}
+}
+
+func (f *fakeLoginAttempts) RecordFailure(_ context.Context, email string) (int64, error) {
+	f.failures[email]++
+	return f.failures[email], nil
+}
+
+func (f *fakeLoginAttempts) Reset(_ context.Context, email string) error {
+	delete(f.failures, email)
+	return nil
+}
+
+func (f *fakeLoginAttempts) Lock(_ context.Context, email string) error {
+	f.locked[email] = true
+	return nil
+}
+
+func (f *fakeLoginAttempts) IsLocked(_ context.Context, email string) (bool, error) {
+	return f.locked[email], nil
+}
+
+func TestAuthService_Login_Lockout(t *testing.T) {
+	hashedPassword, _ := bcrypt.GenerateFromPassword([]byte("password123"), bcrypt.MinCost)
+	user := &model.User{ID: uuid.New(), Email: "test@example.com", Password: string(hashedPassword), Role: "buyer", Active: true}
+	wrong := model.LoginRequest{Email: "test@example.com", Password: "wrong"}
+	right := model.LoginRequest{Email: "Test@Example.com", Password: "password123"}
+
+	t.Run("locks after N failures even for the right password", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		repo := mocks.NewMockUserRepository(ctrl)
+		repo.EXPECT().FindByEmail(gomock.Any(), "test@example.com").Return(user, nil).Times(3)
+
+		attempts := newFakeLoginAttempts()
+		svc := NewAuthService(repo, nil, newTestJWTManager(), nil, attempts, AuthConfig{MaxLoginFailures: 3, PasswordPolicy: testPasswordPolicy})
+
+		for i := 0; i < 3; i++ {
+			_, err := svc.Login(context.Background(), wrong)
+			assert.ErrorContains(t, err, "invalid email or password")
+		}
+
+		_, err := svc.Login(context.Background(), right)
+		assert.ErrorIs(t, err, ErrAccountLocked)
+	})
+
+	t.Run("unknown emails are locked too", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		repo := mocks.NewMockUserRepository(ctrl)
+		repo.EXPECT().FindByEmail(gomock.Any(), "ghost@example.com").Return(nil, errors.New("not found")).Times(2)
+
+		svc := NewAuthService(repo, nil, newTestJWTManager(), nil, newFakeLoginAttempts(), AuthConfig{MaxLoginFailures: 2, PasswordPolicy: testPasswordPolicy})
+		ghost := model.LoginRequest{Email: "ghost@example.com", Password: "guess"}
+		for i := 0; i < 2; i++ {
+			_, err := svc.Login(context.Background(), ghost)
+			assert.ErrorContains(t, err, "invalid email or password")
+		}
+
+		_, err := svc.Login(context.Background(), ghost)
+		assert.ErrorIs(t, err, ErrAccountLocked)
+	})
+
+	t.Run("successful login resets the count", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		repo := mocks.NewMockUserRepository(ctrl)
+		repo.EXPECT().FindByEmail(gomock.Any(), "test@example.com").Return(user, nil).Times(5)
+
+		attempts := newFakeLoginAttempts()
+		svc := NewAuthService(repo, nil, newTestJWTManager(), nil, attempts, AuthConfig{MaxLoginFailures: 3, PasswordPolicy: testPasswordPolicy})
+
+		for i := 0; i < 2; i++ {
+			_, err := svc.Login(context.Background(), wrong)
+			assert.Error(t, err)
+		}
+		_, err := svc.Login(context.Background(), right)
+		assert.NoError(t, err)
+		assert.Zero(t, attempts.failures["test@example.com"])
+
+		for i := 0; i < 2; i++ {
+			_, err := svc.Login(context.Background(), wrong)
+			assert.Error(t, err)
+		}
+		assert.False(t, attempts.locked["test@example.com"], "failures before the successful login must not count")
+	})
+}
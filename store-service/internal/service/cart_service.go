@@ -6,6 +6,8 @@ import (
 	"fmt"
 	"time"
 
+	"github.com/1tsndre/mini-go-project/pkg/money"
+	"github.com/1tsndre/mini-go-project/store-service/internal/clock"
 	"github.com/1tsndre/mini-go-project/store-service/internal/constant"
 	"github.com/1tsndre/mini-go-project/store-service/internal/model"
 	"github.com/1tsndre/mini-go-project/store-service/internal/repository"
@@ -18,20 +20,43 @@ type CartService interface {
 	GetCart(ctx context.Context, userID uuid.UUID) (*model.CartResponse, error)
 	AddItem(ctx context.Context, userID uuid.UUID, req model.AddCartItemRequest) (*model.CartResponse, error)
 	UpdateItem(ctx context.Context, userID uuid.UUID, productID uuid.UUID, req model.UpdateCartItemRequest) (*model.CartResponse, error)
-	RemoveItem(ctx context.Context, userID uuid.UUID, productID uuid.UUID) (*model.CartResponse, error)
+	RemoveItem(ctx context.Context, userID uuid.UUID, productID uuid.UUID, req model.RemoveCartItemRequest) (*model.CartResponse, error)
+}
+
+// CartConfig holds the tunable cart rules for CartService.
+type CartConfig struct {
+	// OptimisticLocking rejects saves of a cart that changed after it was
+	// read. It only applies when redsync is not configured, since the
+	// distributed cart lock already serializes writers.
+	OptimisticLocking bool
+	// SyncConflicts rejects edits made against a stale copy of the cart,
+	// e.g. on another device: when the client sends the updated_at it last
+	// read and the cart was saved after that, the edit fails so the client
+	// can refresh. Edits without updated_at stay last-write-wins.
+	SyncConflicts bool
+	// Rounding brings line subtotals to the currency scale; the cart total
+	// is their sum, matching what checkout charges.
+	Rounding money.Rounding
+	// Clock stamps saved carts. Nil means the system clock.
+	Clock clock.Clock
 }
 
 type cartService struct {
 	cartRepo    repository.CartRepository
 	productRepo repository.ProductRepository
 	redsync     *redsync.Redsync
+	cfg         CartConfig
 }
 
-func NewCartService(cartRepo repository.CartRepository, productRepo repository.ProductRepository, rs *redsync.Redsync) CartService {
+func NewCartService(cartRepo repository.CartRepository, productRepo repository.ProductRepository, rs *redsync.Redsync, cfg CartConfig) CartService {
+	if cfg.Clock == nil {
+		cfg.Clock = clock.Real{}
+	}
 	return &cartService{
 		cartRepo:    cartRepo,
 		productRepo: productRepo,
 		redsync:     rs,
+		cfg:         cfg,
 	}
 }
 
@@ -46,6 +71,42 @@ func (s *cartService) lockCart(userID uuid.UUID) (func(), error) {
 	return func() { mutex.Unlock() }, nil
 }
 
+// saveCart persists the cart. Without the distributed lock, concurrent writers
+// are detected through the cart version instead when optimistic locking is on.
+func (s *cartService) saveCart(ctx context.Context, cart *model.Cart) error {
+	// PostgreSQL keeps microseconds; truncating here makes the cached and
+	// stored timestamps identical, so clients can echo either one back.
+	cart.UpdatedAt = s.cfg.Clock.Now().UTC().Truncate(time.Microsecond)
+
+	if s.redsync != nil || !s.cfg.OptimisticLocking {
+		if err := s.cartRepo.SaveCart(ctx, cart); err != nil {
+			return errors.New("failed to save cart")
+		}
+		return nil
+	}
+
+	if err := s.cartRepo.SaveCartIfVersion(ctx, cart); err != nil {
+		if errors.Is(err, repository.ErrStaleCart) {
+			return errors.New("cart was modified by another request, please retry")
+		}
+		return errors.New("failed to save cart")
+	}
+	return nil
+}
+
+// checkBase rejects an edit based on a copy of the cart older than the
+// stored one, when sync conflict detection is on and the client said which
+// copy it edited.
+func (s *cartService) checkBase(cart *model.Cart, base *time.Time) error {
+	if !s.cfg.SyncConflicts || base == nil {
+		return nil
+	}
+	if cart.UpdatedAt.After(base.Truncate(time.Microsecond)) {
+		return errors.New("cart was modified by another request since it was read, please refresh")
+	}
+	return nil
+}
+
 func (s *cartService) GetCart(ctx context.Context, userID uuid.UUID) (*model.CartResponse, error) {
 	cart, err := s.cartRepo.GetCart(ctx, userID)
 	if err != nil {
@@ -65,11 +126,11 @@ func (s *cartService) AddItem(ctx context.Context, userID uuid.UUID, req model.A
 	}
 
 	product, err := s.productRepo.FindByID(ctx, productID)
-	if err != nil {
+	if err != nil || product.Unavailable {
 		return nil, errors.New("product not found")
 	}
 
-	if product.Stock < req.Quantity {
+	if product.Stock < req.Quantity && !product.AllowBackorder {
 		return nil, errors.New("insufficient stock")
 	}
 
@@ -86,6 +147,9 @@ func (s *cartService) AddItem(ctx context.Context, userID uuid.UUID, req model.A
 			Items:  []model.CartItem{},
 		}
 	}
+	if err := s.checkBase(cart, req.UpdatedAt); err != nil {
+		return nil, err
+	}
 
 	found := false
 	for i, item := range cart.Items {
@@ -106,9 +170,8 @@ func (s *cartService) AddItem(ctx context.Context, userID uuid.UUID, req model.A
 		})
 	}
 
-	cart.UpdatedAt = time.Now()
-	if err := s.cartRepo.SaveCart(ctx, cart); err != nil {
-		return nil, errors.New("failed to save cart")
+	if err := s.saveCart(ctx, cart); err != nil {
+		return nil, err
 	}
 
 	return s.toCartResponse(cart), nil
@@ -129,29 +192,42 @@ func (s *cartService) UpdateItem(ctx context.Context, userID uuid.UUID, productI
 	if err != nil {
 		return nil, errors.New("failed to load cart")
 	}
+	if err := s.checkBase(cart, req.UpdatedAt); err != nil {
+		return nil, err
+	}
 
-	found := false
+	index := -1
 	for i, item := range cart.Items {
 		if item.ProductID == productID {
-			cart.Items[i].Quantity = req.Quantity
-			found = true
+			index = i
 			break
 		}
 	}
 
-	if !found {
+	if index < 0 {
 		return nil, errors.New("item not found in cart")
 	}
 
-	cart.UpdatedAt = time.Now()
-	if err := s.cartRepo.SaveCart(ctx, cart); err != nil {
-		return nil, errors.New("failed to save cart")
+	// Stock may have dropped since the item was added; catch it here rather
+	// than at checkout.
+	product, err := s.productRepo.FindByID(ctx, productID)
+	if err != nil {
+		return nil, errors.New("product not found")
+	}
+	if product.Stock < req.Quantity && !product.AllowBackorder {
+		return nil, fmt.Errorf("insufficient stock: only %d available", product.Stock)
+	}
+
+	cart.Items[index].Quantity = req.Quantity
+
+	if err := s.saveCart(ctx, cart); err != nil {
+		return nil, err
 	}
 
 	return s.toCartResponse(cart), nil
 }
 
-func (s *cartService) RemoveItem(ctx context.Context, userID uuid.UUID, productID uuid.UUID) (*model.CartResponse, error) {
+func (s *cartService) RemoveItem(ctx context.Context, userID uuid.UUID, productID uuid.UUID, req model.RemoveCartItemRequest) (*model.CartResponse, error) {
 	unlock, err := s.lockCart(userID)
 	if err != nil {
 		return nil, err
@@ -162,6 +238,9 @@ func (s *cartService) RemoveItem(ctx context.Context, userID uuid.UUID, productI
 	if err != nil {
 		return nil, errors.New("failed to load cart")
 	}
+	if err := s.checkBase(cart, req.UpdatedAt); err != nil {
+		return nil, err
+	}
 
 	found := false
 	for i, item := range cart.Items {
@@ -176,9 +255,8 @@ func (s *cartService) RemoveItem(ctx context.Context, userID uuid.UUID, productI
 		return nil, errors.New("item not found in cart")
 	}
 
-	cart.UpdatedAt = time.Now()
-	if err := s.cartRepo.SaveCart(ctx, cart); err != nil {
-		return nil, errors.New("failed to save cart")
+	if err := s.saveCart(ctx, cart); err != nil {
+		return nil, err
 	}
 
 	return s.toCartResponse(cart), nil
@@ -189,7 +267,7 @@ func (s *cartService) toCartResponse(cart *model.Cart) *model.CartResponse {
 	items := make([]model.CartItemResponse, 0, len(cart.Items))
 
 	for _, item := range cart.Items {
-		subtotal := item.Price.Mul(decimal.NewFromInt(int64(item.Quantity)))
+		subtotal := s.cfg.Rounding.LineTotal(item.Price, item.Quantity)
 		total = total.Add(subtotal)
 		items = append(items, model.CartItemResponse{
 			ProductID: item.ProductID,
@@ -3,10 +3,15 @@ package service
 import (
 	"context"
 	"errors"
+	"sync"
 	"testing"
+	"time"
 
+	"github.com/1tsndre/mini-go-project/pkg/money"
+	"github.com/1tsndre/mini-go-project/store-service/internal/clock"
 	"github.com/1tsndre/mini-go-project/store-service/internal/mocks"
 	"github.com/1tsndre/mini-go-project/store-service/internal/model"
+	"github.com/1tsndre/mini-go-project/store-service/internal/repository"
 	"github.com/google/uuid"
 	"github.com/shopspring/decimal"
 	"github.com/stretchr/testify/assert"
@@ -61,7 +66,7 @@ func TestCartService_GetCart(t *testing.T) {
 			productRepo := mocks.NewMockProductRepository(ctrl)
 			tt.mockSetup(cartRepo, productRepo)
 
-			svc := NewCartService(cartRepo, productRepo, nil)
+			svc := NewCartService(cartRepo, productRepo, nil, CartConfig{})
 			resp, err := svc.GetCart(context.Background(), userID)
 
 			if tt.wantErr {
@@ -145,6 +150,19 @@ func TestCartService_AddItem(t *testing.T) {
 			wantErr:     true,
 			errContains: "product not found",
 		},
+		{
+			name: "unavailable product",
+			req:  model.AddCartItemRequest{ProductID: productID.String(), Quantity: 1},
+			mockSetup: func(_ *mocks.MockCartRepository, productRepo *mocks.MockProductRepository) {
+				productRepo.EXPECT().FindByID(gomock.Any(), productID).Return(&model.Product{
+					ID:          productID,
+					Stock:       3,
+					Unavailable: true,
+				}, nil)
+			},
+			wantErr:     true,
+			errContains: "product not found",
+		},
 		{
 			name: "insufficient stock",
 			req:  model.AddCartItemRequest{ProductID: productID.String(), Quantity: 5},
@@ -157,6 +175,20 @@ func TestCartService_AddItem(t *testing.T) {
 			wantErr:     true,
 			errContains: "insufficient stock",
 		},
+		{
+			name: "backorder beyond stock",
+			req:  model.AddCartItemRequest{ProductID: productID.String(), Quantity: 5},
+			mockSetup: func(cartRepo *mocks.MockCartRepository, productRepo *mocks.MockProductRepository) {
+				productRepo.EXPECT().FindByID(gomock.Any(), productID).Return(&model.Product{
+					ID:             productID,
+					Price:          decimal.NewFromFloat(10000),
+					Stock:          3,
+					AllowBackorder: true,
+				}, nil)
+				cartRepo.EXPECT().GetCart(gomock.Any(), userID).Return(&model.Cart{UserID: userID}, nil)
+				cartRepo.EXPECT().SaveCart(gomock.Any(), gomock.Any()).Return(nil)
+			},
+		},
 		{
 			name: "save cart fails",
 			req:  model.AddCartItemRequest{ProductID: productID.String(), Quantity: 1},
@@ -182,7 +214,7 @@ func TestCartService_AddItem(t *testing.T) {
 			productRepo := mocks.NewMockProductRepository(ctrl)
 			tt.mockSetup(cartRepo, productRepo)
 
-			svc := NewCartService(cartRepo, productRepo, nil)
+			svc := NewCartService(cartRepo, productRepo, nil, CartConfig{})
 			resp, err := svc.AddItem(context.Background(), userID, tt.req)
 
 			if tt.wantErr {
@@ -216,11 +248,39 @@ func TestCartService_UpdateItem(t *testing.T) {
 			name:      "success",
 			productID: productID,
 			req:       model.UpdateCartItemRequest{Quantity: 3},
-			mockSetup: func(cartRepo *mocks.MockCartRepository, _ *mocks.MockProductRepository) {
+			mockSetup: func(cartRepo *mocks.MockCartRepository, productRepo *mocks.MockProductRepository) {
+				cartRepo.EXPECT().GetCart(gomock.Any(), userID).Return(&model.Cart{
+					UserID: userID,
+					Items:  []model.CartItem{{ProductID: productID, Quantity: 1}},
+				}, nil)
+				productRepo.EXPECT().FindByID(gomock.Any(), productID).Return(&model.Product{ID: productID, Stock: 3}, nil)
+				cartRepo.EXPECT().SaveCart(gomock.Any(), gomock.Any()).Return(nil)
+			},
+		},
+		{
+			name:      "beyond available stock",
+			productID: productID,
+			req:       model.UpdateCartItemRequest{Quantity: 4},
+			mockSetup: func(cartRepo *mocks.MockCartRepository, productRepo *mocks.MockProductRepository) {
+				cartRepo.EXPECT().GetCart(gomock.Any(), userID).Return(&model.Cart{
+					UserID: userID,
+					Items:  []model.CartItem{{ProductID: productID, Quantity: 1}},
+				}, nil)
+				productRepo.EXPECT().FindByID(gomock.Any(), productID).Return(&model.Product{ID: productID, Stock: 3}, nil)
+			},
+			wantErr:     true,
+			errContains: "insufficient stock: only 3 available",
+		},
+		{
+			name:      "beyond stock with backorders allowed",
+			productID: productID,
+			req:       model.UpdateCartItemRequest{Quantity: 4},
+			mockSetup: func(cartRepo *mocks.MockCartRepository, productRepo *mocks.MockProductRepository) {
 				cartRepo.EXPECT().GetCart(gomock.Any(), userID).Return(&model.Cart{
 					UserID: userID,
 					Items:  []model.CartItem{{ProductID: productID, Quantity: 1}},
 				}, nil)
+				productRepo.EXPECT().FindByID(gomock.Any(), productID).Return(&model.Product{ID: productID, Stock: 3, AllowBackorder: true}, nil)
 				cartRepo.EXPECT().SaveCart(gomock.Any(), gomock.Any()).Return(nil)
 			},
 		},
@@ -259,11 +319,12 @@ func TestCartService_UpdateItem(t *testing.T) {
 			name:      "save fails",
 			productID: productID,
 			req:       model.UpdateCartItemRequest{Quantity: 2},
-			mockSetup: func(cartRepo *mocks.MockCartRepository, _ *mocks.MockProductRepository) {
+			mockSetup: func(cartRepo *mocks.MockCartRepository, productRepo *mocks.MockProductRepository) {
 				cartRepo.EXPECT().GetCart(gomock.Any(), userID).Return(&model.Cart{
 					UserID: userID,
 					Items:  []model.CartItem{{ProductID: productID, Quantity: 1}},
 				}, nil)
+				productRepo.EXPECT().FindByID(gomock.Any(), productID).Return(&model.Product{ID: productID, Stock: 10}, nil)
 				cartRepo.EXPECT().SaveCart(gomock.Any(), gomock.Any()).Return(errors.New("redis error"))
 			},
 			wantErr:     true,
@@ -280,7 +341,7 @@ func TestCartService_UpdateItem(t *testing.T) {
 			productRepo := mocks.NewMockProductRepository(ctrl)
 			tt.mockSetup(cartRepo, productRepo)
 
-			svc := NewCartService(cartRepo, productRepo, nil)
+			svc := NewCartService(cartRepo, productRepo, nil, CartConfig{})
 			resp, err := svc.UpdateItem(context.Background(), userID, tt.productID, tt.req)
 
 			if tt.wantErr {
@@ -365,8 +426,8 @@ func TestCartService_RemoveItem(t *testing.T) {
 			productRepo := mocks.NewMockProductRepository(ctrl)
 			tt.mockSetup(cartRepo, productRepo)
 
-			svc := NewCartService(cartRepo, productRepo, nil)
-			resp, err := svc.RemoveItem(context.Background(), userID, tt.productID)
+			svc := NewCartService(cartRepo, productRepo, nil, CartConfig{})
+			resp, err := svc.RemoveItem(context.Background(), userID, tt.productID, model.RemoveCartItemRequest{})
 
 			if tt.wantErr {
 				assert.Error(t, err)
@@ -381,4 +442,205 @@ func TestCartService_RemoveItem(t *testing.T) {
 			assert.Empty(t, resp.Items)
 		})
 	}
-}
\ No newline at end of file
+}
+
+// versionedCartRepo is an in-memory cart store with the same version check as
+// the PostgreSQL SaveCartIfVersion. GetCart blocks until every expected reader
+// has loaded the cart, so concurrent writers are guaranteed to share a base.
+type versionedCartRepo struct {
+	repository.CartRepository
+
+	mu      sync.Mutex
+	cart    model.Cart
+	readers sync.WaitGroup
+}
+
+func (r *versionedCartRepo) GetCart(_ context.Context, userID uuid.UUID) (*model.Cart, error) {
+	r.mu.Lock()
+	cart := r.cart
+	cart.Items = append([]model.CartItem(nil), r.cart.Items...)
+	r.mu.Unlock()
+
+	r.readers.Done()
+	r.readers.Wait()
+	return &cart, nil
+}
+
+func (r *versionedCartRepo) SaveCartIfVersion(_ context.Context, cart *model.Cart) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if cart.Version != r.cart.Version {
+		return repository.ErrStaleCart
+	}
+	cart.Version++
+	r.cart = *cart
+	r.cart.Items = append([]model.CartItem(nil), cart.Items...)
+	return nil
+}
+
+func TestCartService_AddItem_OptimisticLockingRejectsStaleWrite(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	userID := uuid.New()
+	productID := uuid.New()
+
+	productRepo := mocks.NewMockProductRepository(ctrl)
+	productRepo.EXPECT().FindByID(gomock.Any(), productID).Return(&model.Product{
+		ID:    productID,
+		Name:  "Test Product",
+		Price: decimal.NewFromInt(10000),
+		Stock: 10,
+	}, nil).Times(2)
+
+	cartRepo := &versionedCartRepo{cart: model.Cart{UserID: userID, Version: 4}}
+	cartRepo.readers.Add(2)
+
+	svc := NewCartService(cartRepo, productRepo, nil, CartConfig{OptimisticLocking: true})
+
+	var wg sync.WaitGroup
+	errs := make([]error, 2)
+	for i := range errs {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			_, errs[i] = svc.AddItem(context.Background(), userID, model.AddCartItemRequest{
+				ProductID: productID.String(),
+				Quantity:  i + 1,
+			})
+		}(i)
+	}
+	wg.Wait()
+
+	var succeeded, conflicted int
+	for _, err := range errs {
+		switch {
+		case err == nil:
+			succeeded++
+		case assert.Contains(t, err.Error(), "modified by another request"):
+			conflicted++
+		}
+	}
+	assert.Equal(t, 1, succeeded)
+	assert.Equal(t, 1, conflicted)
+
+	// Only the winning write is stored; the stale one did not clobber it.
+	assert.Equal(t, int64(5), cartRepo.cart.Version)
+	assert.Len(t, cartRepo.cart.Items, 1)
+}
+
+func TestCartService_UpdateItem_SyncConflicts(t *testing.T) {
+	userID := uuid.New()
+	productID := uuid.New()
+	savedAt := time.Date(2026, 5, 4, 12, 0, 0, 123456000, time.UTC)
+	before := savedAt.Add(-time.Minute)
+
+	tests := []struct {
+		name          string
+		syncConflicts bool
+		base          *time.Time
+		wantConflict  bool
+	}{
+		{name: "stale base is rejected", syncConflicts: true, base: &before, wantConflict: true},
+		{name: "current base is accepted", syncConflicts: true, base: &savedAt},
+		{name: "base with extra precision is accepted", syncConflicts: true, base: ptrTime(savedAt.Add(789 * time.Nanosecond))},
+		{name: "no base is last write wins", syncConflicts: true},
+		{name: "disabled ignores stale base", base: &before},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			cartRepo := mocks.NewMockCartRepository(ctrl)
+			cartRepo.EXPECT().GetCart(gomock.Any(), userID).Return(&model.Cart{
+				UserID:    userID,
+				Items:     []model.CartItem{{ProductID: productID, Quantity: 1}},
+				UpdatedAt: savedAt,
+			}, nil)
+			if !tt.wantConflict {
+				cartRepo.EXPECT().SaveCart(gomock.Any(), gomock.Any()).Return(nil)
+			}
+
+			productRepo := mocks.NewMockProductRepository(ctrl)
+			if !tt.wantConflict {
+				productRepo.EXPECT().FindByID(gomock.Any(), productID).Return(&model.Product{ID: productID, Stock: 10}, nil)
+			}
+
+			svc := NewCartService(cartRepo, productRepo, nil, CartConfig{SyncConflicts: tt.syncConflicts})
+			resp, err := svc.UpdateItem(context.Background(), userID, productID, model.UpdateCartItemRequest{
+				Quantity:  5,
+				UpdatedAt: tt.base,
+			})
+
+			if tt.wantConflict {
+				// The handler maps this message to 409 so the client refreshes.
+				assert.ErrorContains(t, err, "modified by another request")
+				assert.Nil(t, resp)
+				return
+			}
+			assert.NoError(t, err)
+			assert.True(t, resp.UpdatedAt.After(savedAt))
+		})
+	}
+}
+
+func TestCartService_GetCart_Rounding(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	userID := uuid.New()
+	cartRepo := mocks.NewMockCartRepository(ctrl)
+	cartRepo.EXPECT().GetCart(gomock.Any(), userID).Return(&model.Cart{
+		UserID: userID,
+		Items: []model.CartItem{
+			{ProductID: uuid.New(), Price: decimal.RequireFromString("0.335"), Quantity: 1},
+			{ProductID: uuid.New(), Price: decimal.RequireFromString("0.335"), Quantity: 1},
+			{ProductID: uuid.New(), Price: decimal.RequireFromString("0.335"), Quantity: 1},
+		},
+	}, nil).Times(2)
+
+	halfUp := NewCartService(cartRepo, mocks.NewMockProductRepository(ctrl), nil, CartConfig{})
+	resp, err := halfUp.GetCart(context.Background(), userID)
+	assert.NoError(t, err)
+	assert.Equal(t, "0.34", resp.Items[0].Subtotal.StringFixed(money.Scale))
+	// The total is the sum of the rounded lines, not the rounded exact sum.
+	assert.Equal(t, "1.02", resp.Total.StringFixed(money.Scale))
+
+	down := NewCartService(cartRepo, mocks.NewMockProductRepository(ctrl), nil, CartConfig{Rounding: money.RoundDown})
+	resp, err = down.GetCart(context.Background(), userID)
+	assert.NoError(t, err)
+	assert.Equal(t, "0.33", resp.Items[0].Subtotal.StringFixed(money.Scale))
+	assert.Equal(t, "0.99", resp.Total.StringFixed(money.Scale))
+}
+
+func TestCartService_UpdateItem_StampsClockTime(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	userID := uuid.New()
+	productID := uuid.New()
+	jakarta := time.FixedZone("WIB", 7*60*60)
+	now := time.Date(2026, 5, 4, 19, 30, 0, 123456789, jakarta)
+	want := time.Date(2026, 5, 4, 12, 30, 0, 123456000, time.UTC)
+
+	cartRepo := mocks.NewMockCartRepository(ctrl)
+	cartRepo.EXPECT().GetCart(gomock.Any(), userID).Return(&model.Cart{
+		UserID: userID,
+		Items:  []model.CartItem{{ProductID: productID, Quantity: 1}},
+	}, nil)
+	cartRepo.EXPECT().SaveCart(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, cart *model.Cart) error {
+		assert.Equal(t, want, cart.UpdatedAt)
+		return nil
+	})
+	productRepo := mocks.NewMockProductRepository(ctrl)
+	productRepo.EXPECT().FindByID(gomock.Any(), productID).Return(&model.Product{ID: productID, Stock: 10}, nil)
+
+	svc := NewCartService(cartRepo, productRepo, nil, CartConfig{Clock: clock.NewFake(now)})
+	resp, err := svc.UpdateItem(context.Background(), userID, productID, model.UpdateCartItemRequest{Quantity: 2})
+
+	assert.NoError(t, err)
+	assert.Equal(t, want, resp.UpdatedAt)
+}
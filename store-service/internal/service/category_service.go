@@ -3,27 +3,35 @@ package service
 import (
 	"context"
 	"errors"
+	"fmt"
+	"slices"
 	"strings"
 
 	"github.com/1tsndre/mini-go-project/pkg/logger"
 	"github.com/1tsndre/mini-go-project/store-service/internal/model"
+	"github.com/1tsndre/mini-go-project/store-service/internal/pagination"
 	"github.com/1tsndre/mini-go-project/store-service/internal/repository"
 	"github.com/google/uuid"
+	"gorm.io/gorm"
 )
 
 type CategoryService interface {
 	CreateCategory(ctx context.Context, req model.CreateCategoryRequest) (*model.CategoryResponse, error)
+	CreateMany(ctx context.Context, req model.BulkCreateCategoriesRequest) (*model.BulkCreateCategoriesResponse, error)
 	GetAllCategories(ctx context.Context) ([]model.CategoryResponse, error)
+	GetCategoriesPage(ctx context.Context, page, perPage int) ([]model.CategoryResponse, int64, error)
+	GetCategoryTree(ctx context.Context) ([]model.CategoryResponse, error)
 	UpdateCategory(ctx context.Context, id uuid.UUID, req model.UpdateCategoryRequest) (*model.CategoryResponse, error)
 	DeleteCategory(ctx context.Context, id uuid.UUID) error
 }
 
 type categoryService struct {
-	repo repository.CategoryRepository
+	repo        repository.CategoryRepository
+	productRepo repository.ProductRepository
 }
 
-func NewCategoryService(repo repository.CategoryRepository) CategoryService {
-	return &categoryService{repo: repo}
+func NewCategoryService(repo repository.CategoryRepository, productRepo repository.ProductRepository) CategoryService {
+	return &categoryService{repo: repo, productRepo: productRepo}
 }
 
 func (s *categoryService) CreateCategory(ctx context.Context, req model.CreateCategoryRequest) (*model.CategoryResponse, error) {
@@ -31,6 +39,14 @@ func (s *categoryService) CreateCategory(ctx context.Context, req model.CreateCa
 		Name: req.Name,
 	}
 
+	if req.ParentID != "" {
+		parentID, err := s.findParent(ctx, req.ParentID)
+		if err != nil {
+			return nil, err
+		}
+		category.ParentID = &parentID
+	}
+
 	if err := s.repo.Create(ctx, category); err != nil {
 		logger.Error(ctx, "failed to create category", err)
 		if strings.Contains(err.Error(), "unique") || strings.Contains(err.Error(), "duplicate") {
@@ -43,6 +59,67 @@ func (s *categoryService) CreateCategory(ctx context.Context, req model.CreateCa
 	return &resp, nil
 }
 
+// CreateMany creates every category of the request that does not exist yet,
+// in one transaction. Names already taken, or repeated within the request, are
+// skipped and reported rather than failing the import; a parent that cannot
+// be resolved rejects the whole request.
+func (s *categoryService) CreateMany(ctx context.Context, req model.BulkCreateCategoriesRequest) (*model.BulkCreateCategoriesResponse, error) {
+	existing, err := s.repo.FindAll(ctx)
+	if err != nil {
+		logger.Error(ctx, "failed to fetch categories", err)
+		return nil, errors.New("failed to create categories")
+	}
+
+	byName := make(map[string]uuid.UUID, len(existing)+len(req.Categories))
+	for _, c := range existing {
+		byName[c.Name] = c.ID
+	}
+
+	resp := &model.BulkCreateCategoriesResponse{
+		Created: []model.CategoryResponse{},
+		Skipped: []model.SkippedCategory{},
+	}
+	var categories []model.Category
+	for _, item := range req.Categories {
+		name := strings.TrimSpace(item.Name)
+		if _, ok := byName[name]; ok {
+			resp.Skipped = append(resp.Skipped, model.SkippedCategory{Name: name, Reason: "already exists"})
+			continue
+		}
+
+		// IDs are assigned here so later entries can name this one as
+		// their parent before anything is inserted.
+		category := model.Category{ID: uuid.New(), Name: name}
+		if parent := strings.TrimSpace(item.Parent); parent != "" {
+			parentID, ok := byName[parent]
+			if !ok {
+				return nil, fmt.Errorf("parent category %q of %q not found", parent, name)
+			}
+			category.ParentID = &parentID
+		}
+		byName[name] = category.ID
+		categories = append(categories, category)
+	}
+
+	// Another request may have created some of the names since they were
+	// checked; those come back as taken and are reported like any other
+	// duplicate.
+	taken, err := s.repo.CreateMany(ctx, categories)
+	if err != nil {
+		logger.Error(ctx, "failed to create categories", err)
+		return nil, errors.New("failed to create categories")
+	}
+
+	for _, c := range categories {
+		if slices.Contains(taken, c.Name) {
+			resp.Skipped = append(resp.Skipped, model.SkippedCategory{Name: c.Name, Reason: "already exists"})
+			continue
+		}
+		resp.Created = append(resp.Created, c.ToResponse())
+	}
+	return resp, nil
+}
+
 func (s *categoryService) GetAllCategories(ctx context.Context) ([]model.CategoryResponse, error) {
 	categories, err := s.repo.FindAll(ctx)
 	if err != nil {
@@ -57,6 +134,46 @@ func (s *categoryService) GetAllCategories(ctx context.Context) ([]model.Categor
 	return responses, nil
 }
 
+// GetCategoriesPage returns one page of the flat category listing and the
+// total number of categories.
+func (s *categoryService) GetCategoriesPage(ctx context.Context, page, perPage int) ([]model.CategoryResponse, int64, error) {
+	page, perPage = pagination.Normalize(page, perPage)
+
+	categories, total, err := s.repo.FindAllPaginated(ctx, page, perPage)
+	if err != nil {
+		logger.Error(ctx, "failed to fetch categories", err)
+		return nil, 0, errors.New("failed to fetch categories")
+	}
+
+	responses := make([]model.CategoryResponse, 0, len(categories))
+	for _, c := range categories {
+		responses = append(responses, c.ToResponse())
+	}
+	return responses, total, nil
+}
+
+// GetCategoryTree returns all categories nested under their parents.
+func (s *categoryService) GetCategoryTree(ctx context.Context) ([]model.CategoryResponse, error) {
+	categories, err := s.repo.FindAll(ctx)
+	if err != nil {
+		logger.Error(ctx, "failed to fetch categories", err)
+		return nil, errors.New("failed to fetch categories")
+	}
+	return buildCategoryTree(categories), nil
+}
+
+// findParent parses and loads a requested parent category.
+func (s *categoryService) findParent(ctx context.Context, raw string) (uuid.UUID, error) {
+	parentID, err := uuid.Parse(raw)
+	if err != nil {
+		return uuid.Nil, errors.New("invalid parent_id")
+	}
+	if _, err := s.repo.FindByID(ctx, parentID); err != nil {
+		return uuid.Nil, errors.New("parent category not found")
+	}
+	return parentID, nil
+}
+
 func (s *categoryService) UpdateCategory(ctx context.Context, id uuid.UUID, req model.UpdateCategoryRequest) (*model.CategoryResponse, error) {
 	category, err := s.repo.FindByID(ctx, id)
 	if err != nil {
@@ -67,24 +184,100 @@ func (s *categoryService) UpdateCategory(ctx context.Context, id uuid.UUID, req
 		category.Name = req.Name
 	}
 
+	oldParentID := category.ParentID
+	if req.ParentID != nil {
+		if *req.ParentID == "" {
+			category.ParentID = nil
+		} else {
+			parentID, err := s.findParent(ctx, *req.ParentID)
+			if err != nil {
+				return nil, err
+			}
+			category.ParentID = &parentID
+		}
+	}
+
+	// The repository checks the new parent for a cycle under lock.
 	if err := s.repo.Update(ctx, category); err != nil {
+		if errors.Is(err, repository.ErrCategoryCycle) || errors.Is(err, repository.ErrCategoryTooDeep) {
+			return nil, err
+		}
 		logger.Error(ctx, "failed to update category", err)
 		return nil, errors.New("failed to update category")
 	}
 
+	// A move changes which products both the old and the new parent's
+	// subtrees hold, so their cached listings are stale.
+	if !sameParent(oldParentID, category.ParentID) {
+		var parents []uuid.UUID
+		for _, parentID := range []*uuid.UUID{oldParentID, category.ParentID} {
+			if parentID != nil {
+				parents = append(parents, *parentID)
+			}
+		}
+		s.productRepo.InvalidateCategoryListings(ctx, parents...)
+	}
+
 	resp := category.ToResponse()
 	return &resp, nil
 }
 
+// sameParent reports whether two parent references point at the same
+// category, with nil meaning top level.
+func sameParent(a, b *uuid.UUID) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
+
 func (s *categoryService) DeleteCategory(ctx context.Context, id uuid.UUID) error {
-	_, err := s.repo.FindByID(ctx, id)
-	if err != nil {
+	err := s.repo.Delete(ctx, id)
+	if err == nil {
+		return nil
+	}
+
+	var inUse *repository.CategoryInUseError
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
 		return errors.New("category not found")
+	case errors.As(err, &inUse):
+		return inUse
+	}
+	logger.Error(ctx, "failed to delete category", err)
+	return errors.New("failed to delete category")
+}
+
+// buildCategoryTree nests categories under their parents, keeping the input
+// order among siblings. Categories whose parent is missing from the input
+// are returned at the top level.
+func buildCategoryTree(categories []model.Category) []model.CategoryResponse {
+	present := make(map[uuid.UUID]bool, len(categories))
+	children := make(map[uuid.UUID][]model.Category, len(categories))
+	for _, c := range categories {
+		present[c.ID] = true
+	}
+
+	var roots []model.Category
+	for _, c := range categories {
+		if c.ParentID != nil && present[*c.ParentID] {
+			children[*c.ParentID] = append(children[*c.ParentID], c)
+		} else {
+			roots = append(roots, c)
+		}
 	}
 
-	if err := s.repo.Delete(ctx, id); err != nil {
-		logger.Error(ctx, "failed to delete category", err)
-		return errors.New("failed to delete category")
+	var build func(nodes []model.Category) []model.CategoryResponse
+	build = func(nodes []model.Category) []model.CategoryResponse {
+		out := make([]model.CategoryResponse, 0, len(nodes))
+		for _, c := range nodes {
+			resp := c.ToResponse()
+			if kids := children[c.ID]; len(kids) > 0 {
+				resp.Children = build(kids)
+			}
+			out = append(out, resp)
+		}
+		return out
 	}
-	return nil
+	return build(roots)
 }
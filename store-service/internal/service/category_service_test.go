@@ -7,9 +7,11 @@ import (
 
 	"github.com/1tsndre/mini-go-project/store-service/internal/mocks"
 	"github.com/1tsndre/mini-go-project/store-service/internal/model"
+	"github.com/1tsndre/mini-go-project/store-service/internal/repository"
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
 	"go.uber.org/mock/gomock"
+	"gorm.io/gorm"
 )
 
 func TestCategoryService_CreateCategory(t *testing.T) {
@@ -47,7 +49,7 @@ func TestCategoryService_CreateCategory(t *testing.T) {
 			repo := mocks.NewMockCategoryRepository(ctrl)
 			tt.mockSetup(repo)
 
-			svc := NewCategoryService(repo)
+			svc := NewCategoryService(repo, nil)
 			resp, err := svc.CreateCategory(context.Background(), tt.req)
 
 			if tt.wantErr {
@@ -105,7 +107,7 @@ func TestCategoryService_GetAllCategories(t *testing.T) {
 			repo := mocks.NewMockCategoryRepository(ctrl)
 			tt.mockSetup(repo)
 
-			svc := NewCategoryService(repo)
+			svc := NewCategoryService(repo, nil)
 			resp, err := svc.GetAllCategories(context.Background())
 
 			if tt.wantErr {
@@ -132,16 +134,33 @@ func TestCategoryService_DeleteCategory(t *testing.T) {
 			name: "success",
 			id:   catID,
 			mockSetup: func(repo *mocks.MockCategoryRepository) {
-				repo.EXPECT().FindByID(gomock.Any(), catID).Return(&model.Category{ID: catID, Name: "Electronics"}, nil)
 				repo.EXPECT().Delete(gomock.Any(), catID).Return(nil)
 			},
 			wantErr: false,
 		},
+		{
+			name: "has children",
+			id:   catID,
+			mockSetup: func(repo *mocks.MockCategoryRepository) {
+				repo.EXPECT().Delete(gomock.Any(), catID).Return(&repository.CategoryInUseError{Subcategories: 1})
+			},
+			wantErr:     true,
+			errContains: "category has 1 subcategories",
+		},
+		{
+			name: "has products",
+			id:   catID,
+			mockSetup: func(repo *mocks.MockCategoryRepository) {
+				repo.EXPECT().Delete(gomock.Any(), catID).Return(&repository.CategoryInUseError{Products: 3})
+			},
+			wantErr:     true,
+			errContains: "category has 3 products",
+		},
 		{
 			name: "not found",
 			id:   catID,
 			mockSetup: func(repo *mocks.MockCategoryRepository) {
-				repo.EXPECT().FindByID(gomock.Any(), catID).Return(nil, errors.New("not found"))
+				repo.EXPECT().Delete(gomock.Any(), catID).Return(gorm.ErrRecordNotFound)
 			},
 			wantErr:     true,
 			errContains: "category not found",
@@ -156,7 +175,7 @@ func TestCategoryService_DeleteCategory(t *testing.T) {
 			repo := mocks.NewMockCategoryRepository(ctrl)
 			tt.mockSetup(repo)
 
-			svc := NewCategoryService(repo)
+			svc := NewCategoryService(repo, nil)
 			err := svc.DeleteCategory(context.Background(), tt.id)
 
 			if tt.wantErr {
@@ -168,3 +187,224 @@ func TestCategoryService_DeleteCategory(t *testing.T) {
 		})
 	}
 }
+
+func TestCategoryService_UpdateCategory_Parent(t *testing.T) {
+	// root -> child -> grandchild
+	rootID, childID, grandchildID := uuid.New(), uuid.New(), uuid.New()
+	categories := map[uuid.UUID]*model.Category{
+		rootID:       {ID: rootID, Name: "Electronics"},
+		childID:      {ID: childID, Name: "Phones", ParentID: &rootID},
+		grandchildID: {ID: grandchildID, Name: "Cases", ParentID: &childID},
+	}
+	otherID := uuid.New()
+	categories[otherID] = &model.Category{ID: otherID, Name: "Books"}
+
+	str := func(id uuid.UUID) *string {
+		s := id.String()
+		return &s
+	}
+	empty := ""
+
+	tests := []struct {
+		name         string
+		id           uuid.UUID
+		parentID     *string
+		wantParentID *uuid.UUID
+		// updateErr is what the repository's locked cycle check reports.
+		updateErr error
+		// wantInvalidated are the parents whose listings the move drops.
+		wantInvalidated []uuid.UUID
+		errContains     string
+	}{
+		{name: "own parent", id: rootID, parentID: str(rootID), updateErr: repository.ErrCategoryCycle, errContains: "own ancestor"},
+		{name: "under its grandchild", id: rootID, parentID: str(grandchildID), updateErr: repository.ErrCategoryCycle, errContains: "own ancestor"},
+		{name: "too deep", id: childID, parentID: str(grandchildID), updateErr: repository.ErrCategoryTooDeep, errContains: "too deep"},
+		{name: "move to another branch", id: childID, parentID: str(otherID), wantParentID: &otherID, wantInvalidated: []uuid.UUID{rootID, otherID}},
+		{name: "move to top level", id: grandchildID, parentID: &empty, wantInvalidated: []uuid.UUID{childID}},
+		{name: "same parent", id: childID, parentID: str(rootID), wantParentID: &rootID},
+		{name: "missing parent", id: childID, parentID: str(uuid.New()), errContains: "parent category not found"},
+		{name: "invalid parent", id: childID, parentID: func() *string { s := "nope"; return &s }(), errContains: "invalid parent_id"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			repo := mocks.NewMockCategoryRepository(ctrl)
+			repo.EXPECT().FindByID(gomock.Any(), gomock.Any()).DoAndReturn(
+				func(_ context.Context, id uuid.UUID) (*model.Category, error) {
+					c, ok := categories[id]
+					if !ok {
+						return nil, errors.New("record not found")
+					}
+					copied := *c
+					return &copied, nil
+				}).AnyTimes()
+			if tt.errContains == "" || tt.updateErr != nil {
+				repo.EXPECT().Update(gomock.Any(), gomock.Any()).Return(tt.updateErr)
+			}
+			prodRepo := mocks.NewMockProductRepository(ctrl)
+			if tt.wantInvalidated != nil {
+				var ids []any
+				for _, id := range tt.wantInvalidated {
+					ids = append(ids, id)
+				}
+				prodRepo.EXPECT().InvalidateCategoryListings(gomock.Any(), ids...)
+			}
+
+			svc := NewCategoryService(repo, prodRepo)
+			resp, err := svc.UpdateCategory(context.Background(), tt.id, model.UpdateCategoryRequest{ParentID: tt.parentID})
+
+			if tt.errContains != "" {
+				assert.ErrorContains(t, err, tt.errContains)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.wantParentID, resp.ParentID)
+		})
+	}
+}
+
+func TestCategoryService_GetCategoryTree(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	rootID, childID := uuid.New(), uuid.New()
+	orphanParent := uuid.New()
+
+	repo := mocks.NewMockCategoryRepository(ctrl)
+	repo.EXPECT().FindAll(gomock.Any()).Return([]model.Category{
+		{ID: uuid.New(), Name: "Cases", ParentID: &childID},
+		{ID: rootID, Name: "Electronics"},
+		{ID: uuid.New(), Name: "Orphan", ParentID: &orphanParent},
+		{ID: childID, Name: "Phones", ParentID: &rootID},
+	}, nil)
+
+	tree, err := NewCategoryService(repo, nil).GetCategoryTree(context.Background())
+
+	assert.NoError(t, err)
+
+	if assert.Len(t, tree, 2) {
+		assert.Equal(t, "Electronics", tree[0].Name)
+		assert.Equal(t, "Orphan", tree[1].Name)
+		if assert.Len(t, tree[0].Children, 1) {
+			assert.Equal(t, "Phones", tree[0].Children[0].Name)
+			if assert.Len(t, tree[0].Children[0].Children, 1) {
+				assert.Equal(t, "Cases", tree[0].Children[0].Children[0].Name)
+			}
+		}
+	}
+}
+
+func TestCategoryService_CreateMany(t *testing.T) {
+	electronicsID := uuid.New()
+	existing := []model.Category{{ID: electronicsID, Name: "Electronics"}}
+
+	tests := []struct {
+		name        string
+		req         model.BulkCreateCategoriesRequest
+		taken       []string
+		createErr   error
+		wantCreated []string
+		wantSkipped []string
+		errContains string
+	}{
+		{
+			name: "mixed new and duplicate names",
+			req: model.BulkCreateCategoriesRequest{Categories: []model.BulkCategory{
+				{Name: "Electronics"},
+				{Name: "Phones", Parent: "Electronics"},
+				{Name: "Android", Parent: "Phones"},
+				{Name: " Phones "},
+				{Name: "Books"},
+			}},
+			wantCreated: []string{"Phones", "Android", "Books"},
+			wantSkipped: []string{"Electronics", "Phones"},
+		},
+		{
+			name: "only duplicates inserts nothing",
+			req: model.BulkCreateCategoriesRequest{Categories: []model.BulkCategory{
+				{Name: "Electronics"},
+			}},
+			wantCreated: []string{},
+			wantSkipped: []string{"Electronics"},
+		},
+		{
+			name: "unknown parent rejects the batch",
+			req: model.BulkCreateCategoriesRequest{Categories: []model.BulkCategory{
+				{Name: "Books"},
+				{Name: "Novels", Parent: "Literature"},
+			}},
+			errContains: `parent category "Literature" of "Novels" not found`,
+		},
+		{
+			name: "concurrent insert of the same name",
+			req: model.BulkCreateCategoriesRequest{Categories: []model.BulkCategory{
+				{Name: "Books"},
+				{Name: "Novels", Parent: "Books"},
+			}},
+			taken:       []string{"Books"},
+			wantCreated: []string{"Novels"},
+			wantSkipped: []string{"Books"},
+		},
+		{
+			name: "insert failure",
+			req: model.BulkCreateCategoriesRequest{Categories: []model.BulkCategory{
+				{Name: "Books"},
+			}},
+			createErr:   errors.New("connection reset"),
+			errContains: "failed to create categories",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			repo := mocks.NewMockCategoryRepository(ctrl)
+			repo.EXPECT().FindAll(gomock.Any()).Return(existing, nil)
+			var inserted []model.Category
+			if tt.errContains == "" || tt.createErr != nil {
+				repo.EXPECT().CreateMany(gomock.Any(), gomock.Any()).DoAndReturn(
+					func(_ context.Context, categories []model.Category) ([]string, error) {
+						inserted = categories
+						return tt.taken, tt.createErr
+					})
+			}
+
+			svc := NewCategoryService(repo, nil)
+			resp, err := svc.CreateMany(context.Background(), tt.req)
+
+			if tt.errContains != "" {
+				assert.Error(t, err)
+				assert.Contains(t, err.Error(), tt.errContains)
+				assert.Nil(t, resp)
+				return
+			}
+			assert.NoError(t, err)
+
+			created := make([]string, 0, len(resp.Created))
+			for _, c := range resp.Created {
+				created = append(created, c.Name)
+			}
+			skipped := make([]string, 0, len(resp.Skipped))
+			for _, c := range resp.Skipped {
+				skipped = append(skipped, c.Name)
+				assert.Equal(t, "already exists", c.Reason)
+			}
+			assert.Equal(t, tt.wantCreated, created)
+			assert.Equal(t, tt.wantSkipped, skipped)
+			assert.Len(t, inserted, len(tt.wantCreated)+len(tt.taken))
+
+			// Parents resolve by name, to existing categories and to ones
+			// created earlier in the same batch.
+			if len(inserted) == 3 {
+				assert.Equal(t, electronicsID, *inserted[0].ParentID)
+				assert.Equal(t, inserted[0].ID, *inserted[1].ParentID)
+				assert.Nil(t, inserted[2].ParentID)
+			}
+		})
+	}
+}
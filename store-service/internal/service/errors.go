@@ -0,0 +1,65 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Error kinds. Services return errors that match one of these under
+// errors.Is, so handlers choose the HTTP status by kind rather than by
+// matching message text.
+var (
+	ErrNotFound   = errors.New("not found")
+	ErrForbidden  = errors.New("forbidden")
+	ErrConflict   = errors.New("conflict")
+	ErrValidation = errors.New("validation failed")
+	ErrInternal   = errors.New("internal error")
+)
+
+// Failures that handlers answer with a more specific error code than their
+// kind's. Each still matches its kind, except ErrPaymentUnavailable, which
+// is an outage rather than a problem with the request.
+var (
+	ErrInvalidStatus        = fmt.Errorf("invalid status: %w", ErrValidation)
+	ErrPriceChanged         = fmt.Errorf("price changed: %w", ErrConflict)
+	ErrIdempotencyKeyReused = fmt.Errorf("idempotency key reused: %w", ErrConflict)
+	ErrPaymentUnavailable   = errors.New("payment service unavailable")
+)
+
+// kindError is an error whose message is meant for the client, tagged with
+// the kind it belongs to and, optionally, the request field that caused it.
+type kindError struct {
+	kind  error
+	err   error
+	field string
+}
+
+func (e *kindError) Error() string {
+	return e.err.Error()
+}
+
+func (e *kindError) Unwrap() []error {
+	return []error{e.kind, e.err}
+}
+
+// newError formats a message like fmt.Errorf, %w included, and tags it with
+// kind. The message is returned to clients unchanged.
+func newError(kind error, format string, args ...any) error {
+	return &kindError{kind: kind, err: fmt.Errorf(format, args...)}
+}
+
+// newFieldError is newError for a failure caused by one request field,
+// which handlers report against that field.
+func newFieldError(kind error, field, format string, args ...any) error {
+	return &kindError{kind: kind, err: fmt.Errorf(format, args...), field: field}
+}
+
+// ErrorField returns the request field err was reported against, or "" if
+// it was not tied to one.
+func ErrorField(err error) string {
+	var ke *kindError
+	if errors.As(err, &ke) {
+		return ke.field
+	}
+	return ""
+}
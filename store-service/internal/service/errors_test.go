@@ -0,0 +1,38 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewError(t *testing.T) {
+	cause := errors.New("bad digit")
+	err := newError(ErrValidation, "invalid price: %w", cause)
+
+	assert.Equal(t, "invalid price: bad digit", err.Error())
+	assert.ErrorIs(t, err, ErrValidation)
+	assert.ErrorIs(t, err, cause)
+	assert.NotErrorIs(t, err, ErrNotFound)
+
+	// The specific failures still belong to their kind.
+	priceChanged := newError(ErrPriceChanged, "price changed, please review your cart")
+	assert.ErrorIs(t, priceChanged, ErrPriceChanged)
+	assert.ErrorIs(t, priceChanged, ErrConflict)
+	assert.Equal(t, "price changed, please review your cart", priceChanged.Error())
+}
+
+func TestNewFieldError(t *testing.T) {
+	err := newFieldError(ErrValidation, "attributes", "duplicate attribute key %q", "color")
+
+	assert.Equal(t, `duplicate attribute key "color"`, err.Error())
+	assert.ErrorIs(t, err, ErrValidation)
+	assert.Equal(t, "attributes", ErrorField(err))
+	assert.Equal(t, "attributes", ErrorField(fmt.Errorf("saving: %w", err)))
+	assert.Empty(t, ErrorField(newError(ErrValidation, "invalid price")))
+
+	// A status rejection is still a validation failure.
+	assert.ErrorIs(t, newError(ErrInvalidStatus, "cannot cancel order"), ErrValidation)
+}
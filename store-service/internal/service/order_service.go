@@ -2,40 +2,145 @@ package service
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"slices"
 	"sort"
 	"time"
 
+	"github.com/1tsndre/mini-go-project/pkg/events"
 	"github.com/1tsndre/mini-go-project/pkg/logger"
+	"github.com/1tsndre/mini-go-project/pkg/money"
+	"github.com/1tsndre/mini-go-project/store-service/internal/clock"
 	"github.com/1tsndre/mini-go-project/store-service/internal/constant"
 	"github.com/1tsndre/mini-go-project/store-service/internal/model"
 	"github.com/1tsndre/mini-go-project/store-service/internal/pagination"
 	"github.com/1tsndre/mini-go-project/store-service/internal/repository"
 	"github.com/go-redsync/redsync/v4"
 	"github.com/google/uuid"
-	"github.com/nsqio/go-nsq"
 	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
 )
 
 type OrderService interface {
-	Checkout(ctx context.Context, userID uuid.UUID, shippingAddress string) (*model.OrderResponse, error)
+	Checkout(ctx context.Context, userID uuid.UUID, req model.CheckoutRequest) (*model.OrderResponse, error)
+	GuestCheckout(ctx context.Context, req model.GuestCheckoutRequest) (*model.OrderResponse, error)
+	GetGuestOrder(ctx context.Context, id uuid.UUID, token string) (*model.OrderResponse, error)
+	CheckoutIdempotent(ctx context.Context, userID uuid.UUID, key string, req model.CheckoutRequest) (*model.OrderResponse, error)
 	GetOrders(ctx context.Context, userID uuid.UUID, page, perPage int) ([]model.OrderResponse, int64, error)
 	GetOrderByID(ctx context.Context, userID uuid.UUID, id uuid.UUID) (*model.OrderResponse, error)
+	GetOrderTimeline(ctx context.Context, userID uuid.UUID, id uuid.UUID) ([]model.OrderTimelineEvent, error)
+	GetOrderPayment(ctx context.Context, userID uuid.UUID, id uuid.UUID) (*model.PaymentResponse, error)
+	GetOrderStatuses(ctx context.Context, userID uuid.UUID, ids []uuid.UUID) (map[uuid.UUID]string, error)
 	CancelOrder(ctx context.Context, userID uuid.UUID, id uuid.UUID) error
+	RefundOrder(ctx context.Context, userID uuid.UUID, id uuid.UUID) error
 	UpdateOrderStatus(ctx context.Context, sellerID uuid.UUID, id uuid.UUID, status string) error
 	GetSellerOrders(ctx context.Context, userID uuid.UUID, page, perPage int) ([]model.OrderResponse, int64, error)
+	GetAllOrders(ctx context.Context, filter model.OrderFilter) ([]model.OrderResponse, int64, error)
 	ProcessPaymentResult(ctx context.Context, orderID uuid.UUID, success bool) error
+	ReleaseOrder(ctx context.Context, id uuid.UUID) error
+	ExpireReservations(ctx context.Context) (int, error)
+	CancelUnpaidOrder(ctx context.Context, id uuid.UUID) error
 }
 
+// Publisher publishes a message to an NSQ topic. *nsq.Producer satisfies it.
+type Publisher interface {
+	Publish(topic string, body []byte) error
+}
+
+// DeferredPublisher publishes a message that is delivered after delay.
+// *nsq.Producer satisfies it.
+type DeferredPublisher interface {
+	DeferredPublish(topic string, delay time.Duration, body []byte) error
+}
+
+// OrderConfig holds the tunable checkout rules for OrderService.
+type OrderConfig struct {
+	// HoldThreshold routes orders whose total exceeds it to on_hold for
+	// manual review instead of triggering payment. Zero disables the rule.
+	HoldThreshold decimal.Decimal
+	// LowStockThreshold is the default stock level below which a
+	// product.low_stock event is published, for products that do not set
+	// their own threshold.
+	LowStockThreshold int
+	// PaymentUnavailablePolicy is constant.PaymentPolicyFail to undo the
+	// checkout when order.created cannot be published, or
+	// constant.PaymentPolicyOutbox to keep the order and queue the message
+	// for the outbox relay.
+	PaymentUnavailablePolicy string
+	// RefundWindow is how long after completion (or payment, for orders not
+	// completed yet) a buyer may still request a refund.
+	RefundWindow time.Duration
+	// PaidCancelWindow limits how long after payment a buyer may still
+	// cancel a paid or processing order. Unpaid orders can always be
+	// cancelled. Zero disables the limit.
+	PaidCancelWindow time.Duration
+	// GuestCheckoutEnabled allows GuestCheckout; checkout otherwise requires
+	// an account.
+	GuestCheckoutEnabled bool
+	// BlockSelfPurchase rejects checkouts containing products from the
+	// buyer's own store. When off, such products are sold like any other.
+	BlockSelfPurchase bool
+	// FeePercent is the platform commission shown in seller payouts.
+	FeePercent decimal.Decimal
+	// Rounding brings line subtotals, shipping and tax to the currency scale
+	// before they are summed into the charged total.
+	Rounding money.Rounding
+	// PaymentsDisabled takes orders without triggering payment: order.created
+	// is never published, so orders stay pending until ReservationTTL or
+	// PaymentTimeout cancels them. Otherwise a Publisher is required; see
+	// Validate.
+	PaymentsDisabled bool
+	// ExpectedTotalTolerance is how far the items total may drift from a
+	// checkout's expected_total before the checkout is refused.
+	ExpectedTotalTolerance decimal.Decimal
+	// ReservationTTL is how long an order awaiting payment holds its stock.
+	// Once it passes unpaid, ExpireReservations cancels the order and puts
+	// the stock back. Zero disables reservations.
+	ReservationTTL time.Duration
+	// PaymentTimeout is how long after payment is triggered an unpaid order
+	// is cancelled, via an order.payment_timeout message published deferred
+	// at that point. Zero disables the check.
+	PaymentTimeout time.Duration
+	// Clock tells the time for payment, refund and reservation deadlines.
+	// Nil means the system clock.
+	Clock clock.Clock
+}
+
+// ErrPublisherRequired is returned by OrderConfig.Validate when payments are
+// enabled but there is no publisher to trigger them.
+var ErrPublisherRequired = errors.New("an NSQ publisher is required unless payments are disabled")
+
+// Validate checks the config against the publisher the service will get, so
+// a missing producer stops startup instead of silently leaving every order
+// unpaid.
+func (c OrderConfig) Validate(producer Publisher) error {
+	if !c.PaymentsDisabled && producer == nil {
+		return ErrPublisherRequired
+	}
+	return nil
+}
+
+// reservationExpiryBatchSize caps how many expired reservations one
+// ExpireReservations call handles.
+const reservationExpiryBatchSize = 100
+
 type orderService struct {
-	orderRepo   repository.OrderRepository
-	cartRepo    repository.CartRepository
-	productRepo repository.ProductRepository
-	storeRepo   repository.StoreRepository
-	redsync     *redsync.Redsync
-	nsqProducer *nsq.Producer
+	orderRepo       repository.OrderRepository
+	cartRepo        repository.CartRepository
+	productRepo     repository.ProductRepository
+	storeRepo       repository.StoreRepository
+	idempotencyRepo repository.IdempotencyRepository
+	outboxRepo      repository.OutboxRepository
+	stockRepo       repository.StockMovementRepository
+	redsync         *redsync.Redsync
+	nsqProducer     Publisher
+	shipping        ShippingCalculator
+	tax             TaxCalculator
+	cfg             OrderConfig
 }
 
 func NewOrderService(
@@ -43,158 +148,626 @@ func NewOrderService(
 	cartRepo repository.CartRepository,
 	productRepo repository.ProductRepository,
 	storeRepo repository.StoreRepository,
+	idempotencyRepo repository.IdempotencyRepository,
+	outboxRepo repository.OutboxRepository,
+	stockRepo repository.StockMovementRepository,
 	rs *redsync.Redsync,
-	producer *nsq.Producer,
+	producer Publisher,
+	shipping ShippingCalculator,
+	tax TaxCalculator,
+	cfg OrderConfig,
 ) OrderService {
+	if cfg.Clock == nil {
+		cfg.Clock = clock.Real{}
+	}
 	return &orderService{
-		orderRepo:   orderRepo,
-		cartRepo:    cartRepo,
-		productRepo: productRepo,
-		storeRepo:   storeRepo,
-		redsync:     rs,
-		nsqProducer: producer,
+		orderRepo:       orderRepo,
+		cartRepo:        cartRepo,
+		productRepo:     productRepo,
+		storeRepo:       storeRepo,
+		idempotencyRepo: idempotencyRepo,
+		outboxRepo:      outboxRepo,
+		stockRepo:       stockRepo,
+		redsync:         rs,
+		nsqProducer:     producer,
+		shipping:        shipping,
+		tax:             tax,
+		cfg:             cfg,
+	}
+}
+
+// itemSnapshot is a checkout line validated against the product as it was
+// read, with the stock level the sale will leave behind.
+type itemSnapshot struct {
+	product   *model.Product
+	orderItem model.OrderItem
+	newStock  int
+}
+
+// newReservation is the stock reservation for an order starting to await
+// payment now, or nil when reservations are disabled.
+func (s *orderService) newReservation() *model.StockReservation {
+	if s.cfg.ReservationTTL <= 0 {
+		return nil
+	}
+	return &model.StockReservation{
+		Status:    model.ReservationStatusActive,
+		ExpiresAt: s.cfg.Clock.Now().Add(s.cfg.ReservationTTL),
+	}
+}
+
+func (s *orderService) requiresHold(total decimal.Decimal) bool {
+	return s.cfg.HoldThreshold.IsPositive() && total.GreaterThan(s.cfg.HoldThreshold)
+}
+
+// recordStatusChange appends an order status change to its history. The
+// change itself has already been saved, so a failure here is logged rather
+// than failing the caller.
+func (s *orderService) recordStatusChange(ctx context.Context, orderID uuid.UUID, from, to string, changedBy *uuid.UUID) {
+	entry := &model.OrderStatusHistory{
+		OrderID:    orderID,
+		FromStatus: from,
+		ToStatus:   to,
+		ChangedBy:  changedBy,
+	}
+	if err := s.orderRepo.AddStatusHistory(ctx, entry); err != nil {
+		logger.Error(ctx, "failed to record order status history", err, map[string]interface{}{
+			"order_id": orderID.String(),
+			"to":       to,
+		})
 	}
 }
 
-func (s *orderService) Checkout(ctx context.Context, userID uuid.UUID, shippingAddress string) (*model.OrderResponse, error) {
+// lockStock acquires the distributed stock lock for a product. Like the cart
+// lock, it is a no-op when redsync is not configured (e.g. in tests).
+func (s *orderService) lockStock(productID uuid.UUID) (func(), error) {
+	if s.redsync == nil {
+		return func() {}, nil
+	}
+	lockKey := fmt.Sprintf(constant.KeyStockLock, productID.String())
+	mutex := s.redsync.NewMutex(lockKey, redsync.WithExpiry(10*time.Second))
+	if err := mutex.Lock(); err != nil {
+		return nil, err
+	}
+	return func() { mutex.Unlock() }, nil
+}
+
+// Checkout places an order for the buyer's cart at current prices. Cart
+// items keep the price they were added at, so a buyer can send the total
+// they saw as req.ExpectedTotal to be stopped if prices changed since.
+func (s *orderService) Checkout(ctx context.Context, userID uuid.UUID, req model.CheckoutRequest) (*model.OrderResponse, error) {
+	var expectedTotal *decimal.Decimal
+	if req.ExpectedTotal != "" {
+		total, err := money.Parse(req.ExpectedTotal, money.NonNegative)
+		if err != nil {
+			return nil, newError(ErrValidation, "invalid expected_total: %w", err)
+		}
+		expectedTotal = &total
+	}
+
 	cart, err := s.cartRepo.GetCart(ctx, userID)
 	if err != nil {
-		return nil, errors.New("cart not found")
+		return nil, newError(ErrNotFound, "cart not found")
 	}
 	if len(cart.Items) == 0 {
-		return nil, errors.New("cart is empty")
+		return nil, newError(ErrValidation, "cart is empty")
+	}
+
+	order := &model.Order{
+		UserID:          userID,
+		ShippingAddress: req.ShippingAddress,
+	}
+	resp, err := s.placeOrder(ctx, order, cart.Items, expectedTotal)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.cartRepo.DeleteCart(ctx, userID); err != nil {
+		logger.Error(ctx, "failed to clear cart after checkout", err, map[string]interface{}{
+			"user_id": userID.String(),
+		})
+	}
+
+	return resp, nil
+}
+
+// GuestCheckout places an order for a buyer without an account. The items come
+// with the request instead of from a cart, and the order is linked to a new
+// guest record. The returned response carries the lookup token the guest needs
+// for GetGuestOrder; only its hash is stored.
+func (s *orderService) GuestCheckout(ctx context.Context, req model.GuestCheckoutRequest) (*model.OrderResponse, error) {
+	if !s.cfg.GuestCheckoutEnabled {
+		return nil, newError(ErrForbidden, "guest checkout is disabled")
+	}
+
+	quantities := make(map[uuid.UUID]int, len(req.Items))
+	for _, item := range req.Items {
+		productID, err := uuid.Parse(item.ProductID)
+		if err != nil {
+			return nil, newError(ErrValidation, "invalid product id %q", item.ProductID)
+		}
+		if item.Quantity <= 0 {
+			return nil, newError(ErrValidation, "quantity must be positive")
+		}
+		quantities[productID] += item.Quantity
+	}
+	if len(quantities) == 0 {
+		return nil, newError(ErrValidation, "no items to check out")
+	}
+
+	items := make([]model.CartItem, 0, len(quantities))
+	for productID, quantity := range quantities {
+		items = append(items, model.CartItem{ProductID: productID, Quantity: quantity})
+	}
+
+	token, tokenHash, err := newSecretToken()
+	if err != nil {
+		logger.Error(ctx, "failed to generate order lookup token", err)
+		return nil, newError(ErrInternal, "failed to create order")
+	}
+
+	order := &model.Order{
+		Guest: &model.Guest{
+			Email: req.Email,
+			Name:  req.Name,
+			Phone: req.Phone,
+		},
+		ShippingAddress: req.ShippingAddress,
+		LookupTokenHash: tokenHash,
+	}
+	resp, err := s.placeOrder(ctx, order, items, nil)
+	if err != nil {
+		return nil, err
 	}
 
-	sort.Slice(cart.Items, func(i, j int) bool {
-		return cart.Items[i].ProductID.String() < cart.Items[j].ProductID.String()
+	resp.LookupToken = token
+	return resp, nil
+}
+
+// placeOrder validates items against current stock, reserves it and creates
+// order, which the caller has filled with the buyer and shipping address.
+// With expectedTotal set, the items must still add up to it at current
+// prices. Payment is triggered unless the order goes on hold.
+func (s *orderService) placeOrder(ctx context.Context, order *model.Order, items []model.CartItem, expectedTotal *decimal.Decimal) (*model.OrderResponse, error) {
+	sort.Slice(items, func(i, j int) bool {
+		return items[i].ProductID.String() < items[j].ProductID.String()
 	})
 
-	var mutexes []*redsync.Mutex
-	for _, item := range cart.Items {
-		lockKey := fmt.Sprintf(constant.KeyStockLock, item.ProductID.String())
-		mutex := s.redsync.NewMutex(lockKey, redsync.WithExpiry(10*time.Second))
-		if err := mutex.Lock(); err != nil {
-			for _, m := range mutexes {
-				m.Unlock()
+	var unlocks []func()
+	for _, item := range items {
+		unlock, err := s.lockStock(item.ProductID)
+		if err != nil {
+			for _, u := range unlocks {
+				u()
 			}
 			logger.Error(ctx, "failed to acquire stock lock", err, map[string]interface{}{
 				"product_id": item.ProductID.String(),
 			})
-			return nil, errors.New("failed to process checkout, please try again")
+			return nil, newError(ErrInternal, "failed to process checkout, please try again")
 		}
-		mutexes = append(mutexes, mutex)
+		unlocks = append(unlocks, unlock)
 	}
 	defer func() {
-		for _, m := range mutexes {
-			m.Unlock()
+		for _, u := range unlocks {
+			u()
 		}
 	}()
 
-	// Phase 1: validate all items and capture snapshots (no DB writes yet)
-	type itemSnapshot struct {
-		product   *model.Product
-		orderItem model.OrderItem
-		newStock  int
+	// A user has at most one store; guests and buyers have none.
+	var ownStoreID uuid.UUID
+	if s.cfg.BlockSelfPurchase && order.UserID != uuid.Nil {
+		if store, err := s.storeRepo.FindByUserID(ctx, order.UserID); err == nil {
+			ownStoreID = store.ID
+		}
 	}
-	snapshots := make([]itemSnapshot, 0, len(cart.Items))
+
+	// Phase 1: validate all items and capture snapshots (no DB writes yet)
+	snapshots := make([]itemSnapshot, 0, len(items))
 	totalAmount := decimal.NewFromInt(0)
 
-	for _, item := range cart.Items {
+	for _, item := range items {
 		product, err := s.productRepo.FindByID(ctx, item.ProductID)
 		if err != nil {
-			return nil, fmt.Errorf("product %s not found", item.ProductID)
+			return nil, newError(ErrNotFound, "product %s not found", item.ProductID)
+		}
+
+		if product.Unavailable {
+			return nil, newError(ErrValidation, "product %s is no longer available", product.Name)
+		}
+
+		if ownStoreID != uuid.Nil && product.StoreID == ownStoreID {
+			return nil, newError(ErrForbidden, "sellers cannot order their own products (%s)", product.Name)
 		}
 
-		if product.Stock < item.Quantity {
-			return nil, fmt.Errorf("insufficient stock for product %s", product.Name)
+		backordered := product.Stock < item.Quantity
+		if backordered && !product.AllowBackorder {
+			return nil, newError(ErrValidation, "insufficient stock for product %s", product.Name)
 		}
 
-		subtotal := product.Price.Mul(decimal.NewFromInt(int64(item.Quantity)))
+		subtotal := s.cfg.Rounding.LineTotal(product.Price, item.Quantity)
 		totalAmount = totalAmount.Add(subtotal)
 
 		snapshots = append(snapshots, itemSnapshot{
 			product: product,
 			orderItem: model.OrderItem{
-				ProductID: item.ProductID,
-				Quantity:  item.Quantity,
-				Price:     product.Price,
+				ProductID:   item.ProductID,
+				Quantity:    item.Quantity,
+				Price:       product.Price,
+				Backordered: backordered,
 			},
+			// Backorders take stock negative; the deficit is what the seller
+			// owes once restocked, and cancellation restores it exactly.
 			newStock: product.Stock - item.Quantity,
 		})
 	}
 
+	if expectedTotal != nil && totalAmount.Sub(*expectedTotal).Abs().GreaterThan(s.cfg.ExpectedTotalTolerance) {
+		return nil, newError(ErrPriceChanged, "price changed, please review your cart: the total is now %s", totalAmount.StringFixed(money.Scale))
+	}
+
+	shippingCost, err := s.shipping.Calculate(ctx, order.ShippingAddress)
+	if err != nil {
+		logger.Error(ctx, "failed to calculate shipping cost", err)
+		return nil, newError(ErrInternal, "failed to calculate shipping cost")
+	}
+	shippingCost = s.cfg.Rounding.Round(shippingCost)
+
+	// Tax is charged on the items only, not on shipping. Without a
+	// calculator nothing is taxed.
+	taxAmount := decimal.Zero
+	if s.tax != nil {
+		taxAmount, err = s.tax.Calculate(ctx, order.ShippingAddress, totalAmount)
+		if err != nil {
+			logger.Error(ctx, "failed to calculate tax", err)
+			return nil, newError(ErrInternal, "failed to calculate tax")
+		}
+		taxAmount = s.cfg.Rounding.Round(taxAmount)
+	}
+	totalAmount = totalAmount.Add(shippingCost).Add(taxAmount)
+
+	// The order's ID is fixed up front so the stock ledger entries written
+	// with each stock update below can name it.
+	order.ID = uuid.New()
+	var placedBy *uuid.UUID
+	if order.UserID != uuid.Nil {
+		placedBy = &order.UserID
+	}
+
 	// Phase 2: apply stock updates; rollback already-applied on partial failure
 	var orderItems []model.OrderItem
 	for i, snap := range snapshots {
-		if err := s.productRepo.UpdateStock(ctx, snap.product.ID, snap.newStock); err != nil {
+		if err := s.productRepo.UpdateStock(ctx, snap.product, snap.newStock, s.checkoutMovement(order, snap, placedBy)...); err != nil {
 			for j := 0; j < i; j++ {
-				if rbErr := s.productRepo.UpdateStock(ctx, snapshots[j].product.ID, snapshots[j].product.Stock); rbErr != nil {
+				if rbErr := s.productRepo.UpdateStock(ctx, snapshots[j].product, snapshots[j].product.Stock, s.checkoutUndoneMovement(order, snapshots[j])...); rbErr != nil {
 					logger.Error(ctx, "failed to rollback stock update", rbErr, map[string]interface{}{
 						"product_id": snapshots[j].product.ID.String(),
 					})
 				}
 			}
 			logger.Error(ctx, "failed to update stock", err)
-			return nil, errors.New("failed to process checkout")
+			return nil, newError(ErrInternal, "failed to process checkout")
 		}
 		orderItems = append(orderItems, snap.orderItem)
 	}
 
-	order := &model.Order{
-		UserID:          userID,
-		Status:          constant.OrderStatusPending,
-		TotalAmount:     totalAmount,
-		ShippingAddress: shippingAddress,
-		OrderItems:      orderItems,
+	status := constant.OrderStatusPending
+	switch {
+	case totalAmount.IsZero():
+		// Nothing to charge, so there is no payment step to wait for: the
+		// order is paid on creation with a zero-amount payment record.
+		status = constant.OrderStatusPaid
+		paidAt := s.cfg.Clock.Now()
+		order.Payment = &model.Payment{
+			Method: model.PaymentMethodFree,
+			Status: model.PaymentStatusSuccess,
+			Amount: totalAmount,
+			PaidAt: &paidAt,
+		}
+	case s.requiresHold(totalAmount):
+		status = constant.OrderStatusOnHold
+	}
+	if status == constant.OrderStatusPending {
+		order.Reservation = s.newReservation()
 	}
 
+	order.Status = status
+	order.TotalAmount = totalAmount
+	order.ShippingCost = shippingCost
+	order.TaxAmount = taxAmount
+	order.OrderItems = orderItems
+
 	if err := s.orderRepo.Create(ctx, order); err != nil {
 		// Rollback all stock updates
 		for _, snap := range snapshots {
-			if rbErr := s.productRepo.UpdateStock(ctx, snap.product.ID, snap.product.Stock); rbErr != nil {
+			if rbErr := s.productRepo.UpdateStock(ctx, snap.product, snap.product.Stock, s.checkoutUndoneMovement(order, snap)...); rbErr != nil {
 				logger.Error(ctx, "failed to rollback stock after order creation failure", rbErr, map[string]interface{}{
 					"product_id": snap.product.ID.String(),
 				})
 			}
 		}
 		logger.Error(ctx, "failed to create order", err)
-		return nil, errors.New("failed to create order")
+		return nil, newError(ErrInternal, "failed to create order")
 	}
+	s.recordStatusChange(ctx, order.ID, "", order.Status, placedBy)
 
-	if err := s.cartRepo.DeleteCart(ctx, userID); err != nil {
-		logger.Error(ctx, "failed to clear cart after checkout", err, map[string]interface{}{
-			"user_id": userID.String(),
+	paymentPending := false
+	switch {
+	case order.Status == constant.OrderStatusPaid:
+		logger.Info(ctx, "zero-total order marked paid without payment", map[string]interface{}{
+			"order_id": order.ID.String(),
 		})
-	}
-
-	if s.nsqProducer != nil {
-		msg, err := json.Marshal(map[string]interface{}{
+	case order.Status == constant.OrderStatusOnHold:
+		logger.Info(ctx, "order placed on hold for manual review", map[string]interface{}{
 			"order_id":     order.ID.String(),
-			"user_id":      userID.String(),
 			"total_amount": totalAmount.String(),
 		})
-		if err != nil {
-			logger.Error(ctx, "failed to marshal order.created payload", err)
-		} else if err := s.nsqProducer.Publish(constant.TopicOrderCreated, msg); err != nil {
-			logger.Error(ctx, "failed to publish order.created", err)
+	default:
+		if err := s.publishOrderCreated(ctx, order); err != nil {
+			logger.Error(ctx, "failed to publish order.created", err, map[string]interface{}{
+				"order_id": order.ID.String(),
+				"policy":   s.cfg.PaymentUnavailablePolicy,
+			})
+			if s.cfg.PaymentUnavailablePolicy != constant.PaymentPolicyOutbox || !s.enqueueOrderCreated(ctx, order) {
+				s.undoCheckout(ctx, order, snapshots)
+				return nil, newError(ErrPaymentUnavailable, "payment service unavailable, please try again later")
+			}
+			paymentPending = true
 		}
+		s.schedulePaymentTimeout(ctx, order)
+	}
+
+	for _, snap := range snapshots {
+		s.publishLowStockIfCrossed(ctx, snap.product, snap.newStock)
 	}
 
 	logger.Info(ctx, "order created", map[string]interface{}{
-		"order_id":     order.ID.String(),
-		"total_amount": totalAmount.String(),
+		"order_id":        order.ID.String(),
+		"total_amount":    totalAmount.String(),
+		"payment_pending": paymentPending,
+		"guest":           order.GuestID != nil,
 	})
 
+	logOrderEvent(ctx, constant.EventOrderCreated, order)
+	if order.Status == constant.OrderStatusPaid {
+		logOrderEvent(ctx, constant.EventOrderPaid, order)
+	}
+
 	resp := order.ToResponse()
+	resp.PaymentPending = paymentPending
 	return &resp, nil
 }
 
+// logOrderEvent logs a business event for order. Every order event has the
+// same fields so analytics can join them on order_id; status is the status
+// the event moved the order to.
+func logOrderEvent(ctx context.Context, name string, order *model.Order) {
+	fields := map[string]interface{}{
+		"order_id":      order.ID.String(),
+		"status":        order.Status,
+		"total_amount":  order.TotalAmount.String(),
+		"shipping_cost": order.ShippingCost.String(),
+		"tax_amount":    order.TaxAmount.String(),
+		"item_count":    len(order.OrderItems),
+		"guest":         order.GuestID != nil,
+	}
+	if order.UserID != uuid.Nil {
+		fields["buyer_id"] = order.UserID.String()
+	}
+	logger.Event(ctx, name, fields)
+}
+
+func orderCreatedPayload(order *model.Order) ([]byte, error) {
+	return events.Marshal(events.OrderCreated{
+		OrderID:     order.ID.String(),
+		UserID:      order.UserID.String(),
+		TotalAmount: order.TotalAmount.String(),
+	})
+}
+
+// publishOrderCreated triggers payment for order. It is a no-op when
+// payments are disabled, and also, loudly, when no producer is configured.
+func (s *orderService) publishOrderCreated(ctx context.Context, order *model.Order) error {
+	if s.cfg.PaymentsDisabled {
+		logger.Info(ctx, "order taken with payments disabled", map[string]interface{}{
+			"order_id": order.ID.String(),
+			"event":    "payment_disabled",
+		})
+		return nil
+	}
+	if s.nsqProducer == nil {
+		// A misconfiguration rather than a mode: Validate should have
+		// stopped startup. The order stays pending with no payment.
+		logger.Error(ctx, "order.created not published, payment will not be triggered", ErrPublisherRequired, map[string]interface{}{
+			"order_id": order.ID.String(),
+			"event":    "payment_publish_skipped",
+		})
+		return nil
+	}
+	msg, err := orderCreatedPayload(order)
+	if err != nil {
+		return err
+	}
+	return s.nsqProducer.Publish(constant.TopicOrderCreated, msg)
+}
+
+// schedulePaymentTimeout publishes order's order.payment_timeout message
+// deferred by PaymentTimeout. Failing to schedule it only leaves the order
+// pending, so it is logged rather than failing the checkout.
+func (s *orderService) schedulePaymentTimeout(ctx context.Context, order *model.Order) {
+	if s.cfg.PaymentTimeout <= 0 || s.nsqProducer == nil {
+		return
+	}
+	producer, ok := s.nsqProducer.(DeferredPublisher)
+	if !ok {
+		return
+	}
+	msg, err := json.Marshal(map[string]interface{}{
+		"order_id": order.ID.String(),
+	})
+	if err != nil {
+		return
+	}
+	if err := producer.DeferredPublish(constant.TopicOrderPaymentTimeout, s.cfg.PaymentTimeout, msg); err != nil {
+		logger.Error(ctx, "failed to schedule order payment timeout", err, map[string]interface{}{
+			"order_id": order.ID.String(),
+		})
+	}
+}
+
+// enqueueOrderCreated stores order.created in the outbox for the relay to
+// publish later, reporting whether it was stored.
+func (s *orderService) enqueueOrderCreated(ctx context.Context, order *model.Order) bool {
+	if s.outboxRepo == nil {
+		return false
+	}
+	msg, err := orderCreatedPayload(order)
+	if err != nil {
+		logger.Error(ctx, "failed to marshal order.created payload", err)
+		return false
+	}
+	event := &model.OutboxEvent{Topic: constant.TopicOrderCreated, Payload: msg}
+	if err := s.outboxRepo.Create(ctx, event); err != nil {
+		logger.Error(ctx, "failed to enqueue order.created in outbox", err, map[string]interface{}{
+			"order_id": order.ID.String(),
+		})
+		return false
+	}
+	return true
+}
+
+// undoCheckout deletes an order whose payment could not be triggered and
+// puts its stock back. The cart is still intact at this point.
+func (s *orderService) undoCheckout(ctx context.Context, order *model.Order, snapshots []itemSnapshot) {
+	if err := s.orderRepo.Delete(ctx, order.ID); err != nil {
+		logger.Error(ctx, "failed to delete order after payment failure", err, map[string]interface{}{
+			"order_id": order.ID.String(),
+		})
+	}
+	for _, snap := range snapshots {
+		if err := s.productRepo.UpdateStock(ctx, snap.product, snap.product.Stock, s.checkoutUndoneMovement(order, snap)...); err != nil {
+			logger.Error(ctx, "failed to rollback stock after payment failure", err, map[string]interface{}{
+				"product_id": snap.product.ID.String(),
+			})
+		}
+	}
+}
+
+// checkoutMovement is the stock ledger entry for the stock order takes for
+// snap's item.
+func (s *orderService) checkoutMovement(order *model.Order, snap itemSnapshot, placedBy *uuid.UUID) []model.StockMovement {
+	return ledgerEntries(s.stockRepo, model.StockMovement{
+		Reason:     constant.StockReasonCheckout,
+		Delta:      -snap.orderItem.Quantity,
+		StockAfter: snap.newStock,
+		ActorID:    placedBy,
+		OrderID:    &order.ID,
+	})
+}
+
+// checkoutUndoneMovement is the stock ledger entry for putting back the
+// stock taken for snap's item when order's checkout fails.
+func (s *orderService) checkoutUndoneMovement(order *model.Order, snap itemSnapshot) []model.StockMovement {
+	return ledgerEntries(s.stockRepo, model.StockMovement{
+		Reason:     constant.StockReasonCheckoutUndone,
+		Delta:      snap.orderItem.Quantity,
+		StockAfter: snap.product.Stock,
+		OrderID:    &order.ID,
+	})
+}
+
+// publishLowStockIfCrossed publishes product.low_stock when a sale takes the
+// product's stock from at or above its threshold to below it. Sales that start
+// already below the threshold do not publish again, so each crossing produces
+// a single event.
+func (s *orderService) publishLowStockIfCrossed(ctx context.Context, product *model.Product, newStock int) {
+	threshold := s.cfg.LowStockThreshold
+	if product.LowStockThreshold != nil {
+		threshold = *product.LowStockThreshold
+	}
+	if s.nsqProducer == nil || threshold <= 0 || product.Stock < threshold || newStock >= threshold {
+		return
+	}
+
+	msg, err := json.Marshal(map[string]interface{}{
+		"product_id": product.ID.String(),
+		"store_id":   product.StoreID.String(),
+		"stock":      newStock,
+	})
+	if err != nil {
+		logger.Error(ctx, "failed to marshal product.low_stock payload", err)
+	} else if err := s.nsqProducer.Publish(constant.TopicProductLowStock, msg); err != nil {
+		logger.Error(ctx, "failed to publish product.low_stock", err, map[string]interface{}{
+			"product_id": product.ID.String(),
+		})
+	}
+}
+
+// CheckoutIdempotent runs Checkout at most once per user and key. A repeat
+// with the same request body replays the original order; a repeat with a
+// different body is rejected instead of silently returning that order.
+func (s *orderService) CheckoutIdempotent(ctx context.Context, userID uuid.UUID, key string, req model.CheckoutRequest) (*model.OrderResponse, error) {
+	hash, err := checkoutRequestHash(req)
+	if err != nil {
+		return nil, newError(ErrInternal, "failed to hash checkout request")
+	}
+
+	unlock, err := s.lockIdempotencyKey(userID, key)
+	if err != nil {
+		return nil, newError(ErrConflict, "a request with this idempotency key is already in progress")
+	}
+	defer unlock()
+
+	if record, err := s.idempotencyRepo.Get(ctx, userID, key); err == nil {
+		if record.RequestHash != hash {
+			return nil, newError(ErrIdempotencyKeyReused, "idempotency key reused with different parameters")
+		}
+		return s.GetOrderByID(ctx, userID, record.OrderID)
+	}
+
+	resp, err := s.Checkout(ctx, userID, req)
+	if err != nil {
+		return nil, err
+	}
+
+	record := model.IdempotencyRecord{RequestHash: hash, OrderID: resp.ID}
+	if err := s.idempotencyRepo.Save(ctx, userID, key, record); err != nil {
+		logger.Error(ctx, "failed to save idempotency record", err, map[string]interface{}{
+			"order_id": resp.ID.String(),
+		})
+	}
+
+	return resp, nil
+}
+
+// lockIdempotencyKey serializes concurrent requests carrying the same key so
+// only one of them can reach Checkout. It is a no-op without redsync.
+func (s *orderService) lockIdempotencyKey(userID uuid.UUID, key string) (func(), error) {
+	if s.redsync == nil {
+		return func() {}, nil
+	}
+	lockKey := fmt.Sprintf(constant.KeyCheckoutIdempotencyLock, userID.String(), key)
+	mutex := s.redsync.NewMutex(lockKey, redsync.WithExpiry(30*time.Second), redsync.WithTries(1))
+	if err := mutex.Lock(); err != nil {
+		return nil, err
+	}
+	return func() { mutex.Unlock() }, nil
+}
+
+func checkoutRequestHash(req model.CheckoutRequest) (string, error) {
+	body, err := json.Marshal(req)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(body)
+	return hex.EncodeToString(sum[:]), nil
+}
+
 func (s *orderService) GetOrders(ctx context.Context, userID uuid.UUID, page, perPage int) ([]model.OrderResponse, int64, error) {
 	page, perPage = pagination.Normalize(page, perPage)
 
 	orders, total, err := s.orderRepo.FindByUserID(ctx, userID, page, perPage)
 	if err != nil {
-		return nil, 0, errors.New("failed to fetch orders")
+		return nil, 0, newError(ErrInternal, "failed to fetch orders")
 	}
 
 	var responses []model.OrderResponse
@@ -208,71 +781,335 @@ func (s *orderService) GetOrders(ctx context.Context, userID uuid.UUID, page, pe
 func (s *orderService) GetOrderByID(ctx context.Context, userID uuid.UUID, id uuid.UUID) (*model.OrderResponse, error) {
 	order, err := s.orderRepo.FindByID(ctx, id)
 	if err != nil {
-		return nil, errors.New("order not found")
+		return nil, newError(ErrNotFound, "order not found")
 	}
 
 	if order.UserID != userID {
-		return nil, errors.New("forbidden")
+		return nil, newError(ErrForbidden, "forbidden")
+	}
+
+	return s.orderDetail(ctx, order)
+}
+
+// GetOrderTimeline returns the buyer's order status changes, oldest first.
+func (s *orderService) GetOrderTimeline(ctx context.Context, userID uuid.UUID, id uuid.UUID) ([]model.OrderTimelineEvent, error) {
+	order, err := s.orderRepo.FindByID(ctx, id)
+	if err != nil {
+		return nil, newError(ErrNotFound, "order not found")
+	}
+
+	if order.UserID != userID {
+		return nil, newError(ErrForbidden, "forbidden")
+	}
+
+	history, err := s.orderRepo.FindStatusHistory(ctx, order.ID)
+	if err != nil {
+		logger.Error(ctx, "failed to fetch order status history", err, map[string]interface{}{
+			"order_id": order.ID.String(),
+		})
+		return nil, newError(ErrInternal, "failed to fetch order timeline")
+	}
+
+	timeline := make([]model.OrderTimelineEvent, 0, len(history))
+	for _, h := range history {
+		timeline = append(timeline, h.TimelineEvent(order.UserID))
+	}
+	return timeline, nil
+}
+
+// orderDetail builds the order detail response, which unlike listings
+// includes the status history.
+func (s *orderService) orderDetail(ctx context.Context, order *model.Order) (*model.OrderResponse, error) {
+	history, err := s.orderRepo.FindStatusHistory(ctx, order.ID)
+	if err != nil {
+		logger.Error(ctx, "failed to fetch order status history", err, map[string]interface{}{
+			"order_id": order.ID.String(),
+		})
+		return nil, newError(ErrInternal, "failed to fetch order")
 	}
 
 	resp := order.ToResponse()
+	for _, h := range history {
+		resp.StatusHistory = append(resp.StatusHistory, h.ToResponse())
+	}
+	return &resp, nil
+}
+
+// GetGuestOrder returns a guest order to the holder of its lookup token. A
+// wrong token is reported as not found so order ids cannot be probed.
+func (s *orderService) GetGuestOrder(ctx context.Context, id uuid.UUID, token string) (*model.OrderResponse, error) {
+	order, err := s.orderRepo.FindByID(ctx, id)
+	if err != nil {
+		return nil, newError(ErrNotFound, "order not found")
+	}
+
+	if order.GuestID == nil || !secretTokenMatches(order.LookupTokenHash, token) {
+		return nil, newError(ErrNotFound, "order not found")
+	}
+
+	return s.orderDetail(ctx, order)
+}
+
+// GetOrderPayment returns the payment record of one of the buyer's orders.
+// Orders that never reached payment, such as those still on hold, have none.
+func (s *orderService) GetOrderPayment(ctx context.Context, userID uuid.UUID, id uuid.UUID) (*model.PaymentResponse, error) {
+	order, err := s.orderRepo.FindByID(ctx, id)
+	if err != nil {
+		return nil, newError(ErrNotFound, "order not found")
+	}
+
+	if order.UserID != userID {
+		return nil, newError(ErrForbidden, "forbidden")
+	}
+
+	payment, err := s.orderRepo.FindPaymentByOrderID(ctx, order.ID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, newError(ErrNotFound, "no payment has been recorded for this order yet")
+	}
+	if err != nil {
+		logger.Error(ctx, "failed to fetch order payment", err, map[string]interface{}{
+			"order_id": order.ID.String(),
+		})
+		return nil, newError(ErrInternal, "failed to fetch payment")
+	}
+
+	resp := payment.ToResponse()
 	return &resp, nil
 }
 
+// GetOrderStatuses returns the status of each of the caller's orders among ids.
+// Ids of other users' orders, or of orders that do not exist, are left out.
+func (s *orderService) GetOrderStatuses(ctx context.Context, userID uuid.UUID, ids []uuid.UUID) (map[uuid.UUID]string, error) {
+	statuses := make(map[uuid.UUID]string, len(ids))
+	if len(ids) == 0 {
+		return statuses, nil
+	}
+
+	orders, err := s.orderRepo.FindStatusesByUser(ctx, userID, ids)
+	if err != nil {
+		logger.Error(ctx, "failed to fetch order statuses", err)
+		return nil, newError(ErrInternal, "failed to fetch order statuses")
+	}
+
+	for _, o := range orders {
+		statuses[o.ID] = o.Status
+	}
+	return statuses, nil
+}
+
 func (s *orderService) CancelOrder(ctx context.Context, userID uuid.UUID, id uuid.UUID) error {
 	order, err := s.orderRepo.FindByID(ctx, id)
 	if err != nil {
-		return errors.New("order not found")
+		return newError(ErrNotFound, "order not found")
 	}
 
 	if order.UserID != userID {
-		return errors.New("forbidden")
+		return newError(ErrForbidden, "forbidden")
 	}
 
 	if !constant.CancellableStatuses[order.Status] {
-		return fmt.Errorf("cannot cancel order with status %s", order.Status)
+		return newError(ErrInvalidStatus, "cannot cancel order with status %s", order.Status)
 	}
 
+	if s.paidCancelWindowExpired(order) {
+		return newError(ErrInvalidStatus, "cancellation window for paid orders has expired")
+	}
+
+	// The reaper must not expire the reservation of an order being
+	// cancelled here and restore its stock a second time.
+	if order.Status == constant.OrderStatusPending && s.cfg.ReservationTTL > 0 {
+		released, err := s.orderRepo.UpdateReservationStatus(ctx, id, model.ReservationStatusActive, model.ReservationStatusReleased)
+		if err != nil {
+			logger.Error(ctx, "failed to release stock reservation", err, map[string]interface{}{
+				"order_id": id.String(),
+			})
+			return newError(ErrInternal, "failed to cancel order")
+		}
+		if !released {
+			// Either the reaper expired the order or a payment committed the
+			// reservation first. Orders placed before reservations were
+			// enabled have none and can still be cancelled.
+			reservation, err := s.orderRepo.FindReservation(ctx, id)
+			switch {
+			case errors.Is(err, gorm.ErrRecordNotFound):
+			case err != nil:
+				logger.Error(ctx, "failed to fetch stock reservation", err, map[string]interface{}{
+					"order_id": id.String(),
+				})
+				return newError(ErrInternal, "failed to cancel order")
+			case reservation.Status == model.ReservationStatusReleased:
+				return newError(ErrConflict, "order has already expired")
+			default:
+				return newError(ErrConflict, "order has already been paid")
+			}
+		}
+	}
+
+	// Only the request that moves the order out of the status it was read
+	// in restores the stock.
+	updated, err := s.orderRepo.UpdateStatusFrom(ctx, id, order.Status, constant.OrderStatusCancelled)
+	if err != nil {
+		return newError(ErrInternal, "failed to cancel order")
+	}
+	if !updated {
+		return newError(ErrConflict, "order status changed, please retry")
+	}
+
+	s.restoreStock(ctx, order, constant.StockReasonCancel, &userID)
+
+	s.recordStatusChange(ctx, id, order.Status, constant.OrderStatusCancelled, &userID)
+	order.Status = constant.OrderStatusCancelled
+
+	logger.Info(ctx, "order cancelled", map[string]interface{}{
+		"order_id": id.String(),
+	})
+	logOrderEvent(ctx, constant.EventOrderCancelled, order)
+
+	return nil
+}
+
+// restoreStock puts back the stock taken by order's items and records it in
+// the stock ledger. Items whose stock cannot be locked or updated are logged
+// and skipped.
+func (s *orderService) restoreStock(ctx context.Context, order *model.Order, reason string, actorID *uuid.UUID) {
 	for _, item := range order.OrderItems {
-		lockKey := fmt.Sprintf(constant.KeyStockLock, item.ProductID.String())
-		mutex := s.redsync.NewMutex(lockKey, redsync.WithExpiry(10*time.Second))
-		if err := mutex.Lock(); err != nil {
+		unlock, err := s.lockStock(item.ProductID)
+		if err != nil {
 			logger.Error(ctx, "failed to acquire lock for stock restore", err)
 			continue
 		}
 		product, err := s.productRepo.FindByID(ctx, item.ProductID)
 		if err != nil {
-			mutex.Unlock()
+			unlock()
 			continue
 		}
-		if err := s.productRepo.UpdateStock(ctx, item.ProductID, product.Stock+item.Quantity); err != nil {
-			logger.Error(ctx, "failed to restore stock for cancelled order", err, map[string]interface{}{
+		restored := product.Stock + item.Quantity
+		movements := ledgerEntries(s.stockRepo, model.StockMovement{
+			Reason:     reason,
+			Delta:      item.Quantity,
+			StockAfter: restored,
+			ActorID:    actorID,
+			OrderID:    &order.ID,
+		})
+		if err := s.productRepo.UpdateStock(ctx, product, restored, movements...); err != nil {
+			logger.Error(ctx, "failed to restore stock", err, map[string]interface{}{
 				"product_id": item.ProductID.String(),
+				"order_id":   order.ID.String(),
 			})
 		}
-		mutex.Unlock()
+		unlock()
+	}
+}
+
+// RefundOrder refunds a paid order that is past cancellation. The window is
+// measured from completion for completed orders, whose last update is the
+// completion, and from payment otherwise. Stock is not restored since the
+// goods have already left the seller.
+func (s *orderService) RefundOrder(ctx context.Context, userID uuid.UUID, id uuid.UUID) error {
+	order, err := s.orderRepo.FindByID(ctx, id)
+	if err != nil {
+		return newError(ErrNotFound, "order not found")
 	}
 
-	if err := s.orderRepo.UpdateStatus(ctx, id, constant.OrderStatusCancelled); err != nil {
-		return errors.New("failed to cancel order")
+	if order.UserID != userID {
+		return newError(ErrForbidden, "forbidden")
 	}
 
-	logger.Info(ctx, "order cancelled", map[string]interface{}{
+	if !constant.RefundableStatuses[order.Status] {
+		return newError(ErrInvalidStatus, "cannot refund order with status %s", order.Status)
+	}
+
+	windowStart, err := s.refundWindowStart(ctx, order)
+	if err != nil {
+		logger.Error(ctx, "failed to load order status history", err, map[string]interface{}{
+			"order_id": id.String(),
+		})
+		return newError(ErrInternal, "failed to refund order")
+	}
+	if s.cfg.Clock.Now().After(windowStart.Add(s.cfg.RefundWindow)) {
+		return newError(ErrInvalidStatus, "refund window has expired")
+	}
+
+	// Only the request that moves the order out of the status it was read
+	// in refunds it, so concurrent requests cannot refund it twice.
+	updated, err := s.orderRepo.UpdateStatusFrom(ctx, id, order.Status, constant.OrderStatusRefunded)
+	if err != nil {
+		logger.Error(ctx, "failed to update order status to refunded", err, map[string]interface{}{
+			"order_id": id.String(),
+		})
+		return newError(ErrInternal, "failed to refund order")
+	}
+	if !updated {
+		return newError(ErrConflict, "order status changed, please retry")
+	}
+
+	if order.Payment != nil {
+		order.Payment.Status = model.PaymentStatusRefunded
+		if err := s.orderRepo.UpdatePayment(ctx, order.Payment); err != nil {
+			logger.Error(ctx, "order refunded but payment status not updated", err, map[string]interface{}{
+				"order_id": id.String(),
+			})
+			return newError(ErrInternal, "failed to refund order")
+		}
+	}
+	s.recordStatusChange(ctx, id, order.Status, constant.OrderStatusRefunded, &userID)
+
+	logger.Info(ctx, "order refunded", map[string]interface{}{
 		"order_id": id.String(),
 	})
 
 	return nil
 }
 
+func (s *orderService) paidCancelWindowExpired(order *model.Order) bool {
+	if s.cfg.PaidCancelWindow <= 0 {
+		return false
+	}
+	if order.Status != constant.OrderStatusPaid && order.Status != constant.OrderStatusProcessing {
+		return false
+	}
+	paidAt := order.UpdatedAt
+	if order.Payment != nil && order.Payment.PaidAt != nil {
+		paidAt = *order.Payment.PaidAt
+	}
+	return s.cfg.Clock.Now().After(paidAt.Add(s.cfg.PaidCancelWindow))
+}
+
+// refundWindowStart is when a completed order was completed, or when an
+// order still on its way was paid, as recorded in its status history. The
+// last update stands in for completion, and the payment time for payment,
+// when history was not recorded.
+func (s *orderService) refundWindowStart(ctx context.Context, order *model.Order) (time.Time, error) {
+	history, err := s.orderRepo.FindStatusHistory(ctx, order.ID)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	from := constant.OrderStatusPaid
+	if order.Status == constant.OrderStatusCompleted {
+		from = constant.OrderStatusCompleted
+	}
+	for i := len(history) - 1; i >= 0; i-- {
+		if history[i].ToStatus == from {
+			return history[i].CreatedAt, nil
+		}
+	}
+
+	if from == constant.OrderStatusPaid && order.Payment != nil && order.Payment.PaidAt != nil {
+		return *order.Payment.PaidAt, nil
+	}
+	return order.UpdatedAt, nil
+}
+
 func (s *orderService) UpdateOrderStatus(ctx context.Context, sellerID uuid.UUID, id uuid.UUID, status string) error {
 	order, err := s.orderRepo.FindByID(ctx, id)
 	if err != nil {
-		return errors.New("order not found")
+		return newError(ErrNotFound, "order not found")
 	}
 
 	allowed, ok := constant.OrderStatusTransitions[order.Status]
 	if !ok {
-		return fmt.Errorf("cannot transition from status %s", order.Status)
+		return newError(ErrInvalidStatus, "cannot transition from status %s", order.Status)
 	}
 
 	valid := false
@@ -284,12 +1121,12 @@ func (s *orderService) UpdateOrderStatus(ctx context.Context, sellerID uuid.UUID
 	}
 
 	if !valid {
-		return fmt.Errorf("invalid status transition from %s to %s", order.Status, status)
+		return newError(ErrInvalidStatus, "invalid status transition from %s to %s", order.Status, status)
 	}
 
 	store, err := s.storeRepo.FindByUserID(ctx, sellerID)
 	if err != nil {
-		return errors.New("store not found")
+		return newError(ErrNotFound, "store not found")
 	}
 
 	hasItem := false
@@ -304,12 +1141,21 @@ func (s *orderService) UpdateOrderStatus(ctx context.Context, sellerID uuid.UUID
 		}
 	}
 	if !hasItem {
-		return errors.New("forbidden: no items from your store in this order")
+		return newError(ErrForbidden, "forbidden: no items from your store in this order")
 	}
 
-	if err := s.orderRepo.UpdateStatus(ctx, id, status); err != nil {
+	updated, err := s.orderRepo.UpdateStatusFrom(ctx, id, order.Status, status)
+	if err != nil {
 		logger.Error(ctx, "failed to update order status", err)
-		return errors.New("failed to update order status")
+		return newError(ErrInternal, "failed to update order status")
+	}
+	if !updated {
+		return newError(ErrConflict, "order status changed, please retry")
+	}
+	s.recordStatusChange(ctx, id, order.Status, status, &sellerID)
+	if status == constant.OrderStatusShipped {
+		order.Status = status
+		logOrderEvent(ctx, constant.EventOrderShipped, order)
 	}
 	return nil
 }
@@ -319,12 +1165,52 @@ func (s *orderService) GetSellerOrders(ctx context.Context, userID uuid.UUID, pa
 
 	store, err := s.storeRepo.FindByUserID(ctx, userID)
 	if err != nil {
-		return nil, 0, errors.New("store not found")
+		return nil, 0, newError(ErrNotFound, "store not found")
 	}
 
 	orders, total, err := s.orderRepo.FindByStoreID(ctx, store.ID, page, perPage)
 	if err != nil {
-		return nil, 0, errors.New("failed to fetch orders")
+		return nil, 0, newError(ErrInternal, "failed to fetch orders")
+	}
+
+	var responses []model.OrderResponse
+	for _, o := range orders {
+		resp := o.ToResponse()
+		if slices.Contains(constant.RevenueStatuses, o.Status) {
+			resp.Payout = s.sellerPayout(&o, store.ID)
+		}
+		responses = append(responses, resp)
+	}
+
+	return responses, total, nil
+}
+
+// sellerPayout sums the order items sold by storeID, which needs the items'
+// products loaded, and splits that between the platform and the seller.
+func (s *orderService) sellerPayout(order *model.Order, storeID uuid.UUID) *model.SellerPayoutResponse {
+	gross := decimal.Zero
+	for _, item := range order.OrderItems {
+		if item.Product.StoreID == storeID {
+			gross = gross.Add(s.cfg.Rounding.LineTotal(item.Price, item.Quantity))
+		}
+	}
+	fee, net := splitPlatformFee(gross, s.cfg.FeePercent)
+	return &model.SellerPayoutResponse{
+		Gross:      gross,
+		FeePercent: s.cfg.FeePercent,
+		Fee:        fee,
+		Net:        net,
+	}
+}
+
+// GetAllOrders lists orders of every user for admins.
+func (s *orderService) GetAllOrders(ctx context.Context, filter model.OrderFilter) ([]model.OrderResponse, int64, error) {
+	filter.Page, filter.PerPage = pagination.Normalize(filter.Page, filter.PerPage)
+
+	orders, total, err := s.orderRepo.FindAll(ctx, filter)
+	if err != nil {
+		logger.Error(ctx, "failed to list all orders", err)
+		return nil, 0, newError(ErrInternal, "failed to fetch orders")
 	}
 
 	var responses []model.OrderResponse
@@ -337,14 +1223,49 @@ func (s *orderService) GetSellerOrders(ctx context.Context, userID uuid.UUID, pa
 
 func (s *orderService) ProcessPaymentResult(ctx context.Context, orderID uuid.UUID, success bool) error {
 	order, err := s.orderRepo.FindByID(ctx, orderID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return newError(ErrNotFound, "order not found")
+	}
 	if err != nil {
-		return errors.New("order not found")
+		logger.Error(ctx, "failed to fetch order for payment result", err, map[string]interface{}{
+			"order_id": orderID.String(),
+		})
+		return newError(ErrInternal, "failed to fetch order")
+	}
+
+	// Results can arrive more than once: redelivered by NSQ, or found again
+	// by the reconciler. Once the order has left pending there is nothing
+	// left to apply, except recording a late success on a cancelled order
+	// for refund below.
+	if order.Status != constant.OrderStatusPending && (!success || order.Status != constant.OrderStatusCancelled) {
+		logger.Info(ctx, "payment result ignored, order is no longer pending", map[string]interface{}{
+			"order_id": orderID.String(),
+			"status":   order.Status,
+			"success":  success,
+		})
+		return nil
 	}
 
 	payment, _ := s.orderRepo.FindPaymentByOrderID(ctx, orderID)
 
 	if success {
-		now := time.Now()
+		if order.Status == constant.OrderStatusCancelled {
+			// The order was cancelled before the payment landed; it stays
+			// cancelled and the payment is only recorded for refund.
+			return s.recordRefundablePayment(ctx, orderID, payment)
+		}
+
+		payable, err := s.commitReservation(ctx, orderID)
+		if err != nil {
+			return err
+		}
+		if !payable {
+			// The reservation expired and its stock may be sold again, so
+			// the order cannot be marked paid.
+			return s.recordRefundablePayment(ctx, orderID, payment)
+		}
+
+		now := s.cfg.Clock.Now()
 		if payment != nil {
 			payment.Status = model.PaymentStatusSuccess
 			payment.PaidAt = &now
@@ -355,16 +1276,28 @@ func (s *orderService) ProcessPaymentResult(ctx context.Context, orderID uuid.UU
 				return err
 			}
 		}
-		if err := s.orderRepo.UpdateStatus(ctx, orderID, constant.OrderStatusPaid); err != nil {
+		updated, err := s.orderRepo.UpdateStatusFrom(ctx, orderID, constant.OrderStatusPending, constant.OrderStatusPaid)
+		if err != nil {
 			logger.Error(ctx, "failed to update order status to paid", err, map[string]interface{}{
 				"order_id": order.ID.String(),
 			})
 			return err
 		}
+		if !updated {
+			// Cancelled between the read above and now; the payment has
+			// been recorded and must be refunded.
+			logger.Error(ctx, "payment received for order that left pending, refund required", nil, map[string]interface{}{
+				"order_id": orderID.String(),
+			})
+			return nil
+		}
+		s.recordStatusChange(ctx, orderID, order.Status, constant.OrderStatusPaid, nil)
+		order.Status = constant.OrderStatusPaid
 
 		logger.Info(ctx, "payment success", map[string]interface{}{
 			"order_id": order.ID.String(),
 		})
+		logOrderEvent(ctx, constant.EventOrderPaid, order)
 	} else {
 		if payment != nil {
 			payment.Status = model.PaymentStatusFailed
@@ -383,3 +1316,221 @@ func (s *orderService) ProcessPaymentResult(ctx context.Context, orderID uuid.UU
 
 	return nil
 }
+
+// ReleaseOrder moves an order out of manual review into pending and triggers
+// payment for it.
+func (s *orderService) ReleaseOrder(ctx context.Context, id uuid.UUID) error {
+	order, err := s.orderRepo.FindByID(ctx, id)
+	if err != nil {
+		return newError(ErrNotFound, "order not found")
+	}
+
+	if order.Status != constant.OrderStatusOnHold {
+		return newError(ErrInvalidStatus, "cannot release order with status %s", order.Status)
+	}
+
+	// Only the release that moves the order off hold goes on to reserve stock
+	// and request payment.
+	updated, err := s.orderRepo.UpdateStatusFrom(ctx, id, constant.OrderStatusOnHold, constant.OrderStatusPending)
+	if err != nil {
+		logger.Error(ctx, "failed to release order", err)
+		return newError(ErrInternal, "failed to release order")
+	}
+	if !updated {
+		return newError(ErrConflict, "order status changed, please retry")
+	}
+	s.recordStatusChange(ctx, id, order.Status, constant.OrderStatusPending, nil)
+	order.Status = constant.OrderStatusPending
+
+	// Held orders keep their stock without expiry; the reservation starts
+	// once the order awaits payment.
+	if reservation := s.newReservation(); reservation != nil {
+		reservation.OrderID = id
+		if err := s.orderRepo.CreateReservation(ctx, reservation); err != nil {
+			logger.Error(ctx, "failed to reserve stock for released order", err, map[string]interface{}{
+				"order_id": id.String(),
+			})
+		}
+	}
+
+	// The release has already been recorded, so a publish failure is always
+	// queued for retry regardless of the checkout policy.
+	if err := s.publishOrderCreated(ctx, order); err != nil {
+		logger.Error(ctx, "failed to publish order.created", err, map[string]interface{}{
+			"order_id": id.String(),
+		})
+		s.enqueueOrderCreated(ctx, order)
+	}
+	s.schedulePaymentTimeout(ctx, order)
+
+	logger.Info(ctx, "order released from hold", map[string]interface{}{
+		"order_id": id.String(),
+	})
+
+	return nil
+}
+
+// commitReservation commits order's stock reservation for a successful
+// payment and reports whether the order may be marked paid. It is not payable
+// if the reservation had already expired. Orders without a reservation are
+// always payable.
+func (s *orderService) commitReservation(ctx context.Context, orderID uuid.UUID) (bool, error) {
+	if s.cfg.ReservationTTL <= 0 {
+		return true, nil
+	}
+	committed, err := s.orderRepo.UpdateReservationStatus(ctx, orderID, model.ReservationStatusActive, model.ReservationStatusCommitted)
+	if err != nil {
+		logger.Error(ctx, "failed to commit stock reservation", err, map[string]interface{}{
+			"order_id": orderID.String(),
+		})
+		return false, err
+	}
+	if committed {
+		return true, nil
+	}
+	reservation, err := s.orderRepo.FindReservation(ctx, orderID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return true, nil
+	}
+	if err != nil {
+		logger.Error(ctx, "failed to fetch stock reservation", err, map[string]interface{}{
+			"order_id": orderID.String(),
+		})
+		return false, err
+	}
+	return reservation.Status != model.ReservationStatusReleased, nil
+}
+
+// recordRefundablePayment records a successful payment for an order that
+// cannot be marked paid, leaving the order status untouched.
+func (s *orderService) recordRefundablePayment(ctx context.Context, orderID uuid.UUID, payment *model.Payment) error {
+	if payment != nil {
+		now := s.cfg.Clock.Now()
+		payment.Status = model.PaymentStatusSuccess
+		payment.PaidAt = &now
+		if err := s.orderRepo.UpdatePayment(ctx, payment); err != nil {
+			logger.Error(ctx, "failed to record payment of unpayable order", err, map[string]interface{}{
+				"order_id": orderID.String(),
+			})
+			return err
+		}
+	}
+	logger.Error(ctx, "payment received for order that cannot be paid, refund required", nil, map[string]interface{}{
+		"order_id": orderID.String(),
+	})
+	return nil
+}
+
+// ExpireReservations cancels pending orders whose stock reservation has
+// expired and puts their stock back, returning how many it cancelled. An
+// order paid while this runs keeps its stock: the payment and the expiry
+// race for the reservation and only one of them wins.
+func (s *orderService) ExpireReservations(ctx context.Context) (int, error) {
+	reservations, err := s.orderRepo.FindExpiredReservations(ctx, s.cfg.Clock.Now(), reservationExpiryBatchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	expired := 0
+	for _, reservation := range reservations {
+		released, err := s.orderRepo.UpdateReservationStatus(ctx, reservation.OrderID, model.ReservationStatusActive, model.ReservationStatusReleased)
+		if err != nil {
+			logger.Error(ctx, "failed to release expired stock reservation", err, map[string]interface{}{
+				"order_id": reservation.OrderID.String(),
+			})
+			continue
+		}
+		if !released {
+			continue
+		}
+
+		order, err := s.orderRepo.FindByID(ctx, reservation.OrderID)
+		if err != nil {
+			logger.Error(ctx, "failed to load order of expired reservation", err, map[string]interface{}{
+				"order_id": reservation.OrderID.String(),
+			})
+			continue
+		}
+
+		// Only the run that moves the order out of the status it was read in
+		// restores the stock.
+		updated, err := s.orderRepo.UpdateStatusFrom(ctx, order.ID, order.Status, constant.OrderStatusCancelled)
+		if err != nil {
+			logger.Error(ctx, "failed to cancel order of expired reservation", err, map[string]interface{}{
+				"order_id": order.ID.String(),
+			})
+			continue
+		}
+		if !updated {
+			continue
+		}
+		s.restoreStock(ctx, order, constant.StockReasonReservationExpired, nil)
+		s.recordStatusChange(ctx, order.ID, order.Status, constant.OrderStatusCancelled, nil)
+		order.Status = constant.OrderStatusCancelled
+		expired++
+
+		logger.Info(ctx, "order cancelled after stock reservation expired", map[string]interface{}{
+			"order_id":   order.ID.String(),
+			"expired_at": reservation.ExpiresAt.Format(time.RFC3339),
+		})
+		logOrderEvent(ctx, constant.EventOrderCancelled, order)
+	}
+	return expired, nil
+}
+
+// CancelUnpaidOrder cancels order id and puts its stock back if it is still
+// awaiting payment. It is a no-op for any other status, so a timeout check
+// arriving after the payment, or delivered twice, changes nothing.
+func (s *orderService) CancelUnpaidOrder(ctx context.Context, id uuid.UUID) error {
+	order, err := s.orderRepo.FindByID(ctx, id)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return newError(ErrNotFound, "order not found")
+	}
+	if err != nil {
+		logger.Error(ctx, "failed to fetch order for payment timeout", err, map[string]interface{}{
+			"order_id": id.String(),
+		})
+		return newError(ErrInternal, "failed to fetch order")
+	}
+	if order.Status != constant.OrderStatusPending {
+		return nil
+	}
+
+	// As in CancelOrder, the reservation decides between this and a payment
+	// or the reaper arriving at the same time.
+	if s.cfg.ReservationTTL > 0 {
+		released, err := s.orderRepo.UpdateReservationStatus(ctx, id, model.ReservationStatusActive, model.ReservationStatusReleased)
+		if err != nil {
+			logger.Error(ctx, "failed to release stock reservation", err, map[string]interface{}{
+				"order_id": id.String(),
+			})
+			return newError(ErrInternal, "failed to cancel order")
+		}
+		if !released {
+			if _, err := s.orderRepo.FindReservation(ctx, id); err == nil {
+				return nil
+			}
+		}
+	}
+
+	// Without a reservation to decide, the status itself does: a payment or
+	// another cancel that got there first leaves nothing to do.
+	updated, err := s.orderRepo.UpdateStatusFrom(ctx, id, constant.OrderStatusPending, constant.OrderStatusCancelled)
+	if err != nil {
+		return newError(ErrInternal, "failed to cancel order")
+	}
+	if !updated {
+		return nil
+	}
+
+	s.restoreStock(ctx, order, constant.StockReasonPaymentTimeout, nil)
+
+	s.recordStatusChange(ctx, id, order.Status, constant.OrderStatusCancelled, nil)
+	order.Status = constant.OrderStatusCancelled
+
+	logger.Info(ctx, "unpaid order cancelled after payment timeout", map[string]interface{}{
+		"order_id": id.String(),
+	})
+	logOrderEvent(ctx, constant.EventOrderCancelled, order)
+	return nil
+}
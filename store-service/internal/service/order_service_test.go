@@ -1,10 +1,18 @@
 package service
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
 	"errors"
+	"fmt"
+	"io"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/1tsndre/mini-go-project/pkg/logger"
+	"github.com/1tsndre/mini-go-project/store-service/internal/clock"
 	"github.com/1tsndre/mini-go-project/store-service/internal/constant"
 	"github.com/1tsndre/mini-go-project/store-service/internal/mocks"
 	"github.com/1tsndre/mini-go-project/store-service/internal/model"
@@ -12,17 +20,20 @@ import (
 	"github.com/shopspring/decimal"
 	"github.com/stretchr/testify/assert"
 	"go.uber.org/mock/gomock"
+	"gorm.io/gorm"
 )
 
 // newTestOrderService creates an OrderService with nil redsync and nsq producer,
 // suitable for testing methods that do not exercise distributed locking or messaging.
+// Shipping is free so totals equal item subtotals unless a test overrides it.
 func newTestOrderService(
 	orderRepo *mocks.MockOrderRepository,
 	cartRepo *mocks.MockCartRepository,
 	productRepo *mocks.MockProductRepository,
 	storeRepo *mocks.MockStoreRepository,
 ) OrderService {
-	return NewOrderService(orderRepo, cartRepo, productRepo, storeRepo, nil, nil)
+	return NewOrderService(orderRepo, cartRepo, productRepo, storeRepo, nil, nil, nil, nil, nil,
+		NewFlatRateShippingCalculator(nil, decimal.Zero), nil, OrderConfig{PaymentsDisabled: true})
 }
 
 func TestOrderService_Checkout(t *testing.T) {
@@ -64,7 +75,7 @@ func TestOrderService_Checkout(t *testing.T) {
 			tt.mockSetup(orderRepo, cartRepo, productRepo, storeRepo)
 
 			svc := newTestOrderService(orderRepo, cartRepo, productRepo, storeRepo)
-			resp, err := svc.Checkout(context.Background(), userID, "Jl. Test No. 1, Jakarta")
+			resp, err := svc.Checkout(context.Background(), userID, model.CheckoutRequest{ShippingAddress: "Jl. Test No. 1, Jakarta"})
 
 			assert.Error(t, err)
 			assert.Contains(t, err.Error(), tt.errContains)
@@ -73,6 +84,47 @@ func TestOrderService_Checkout(t *testing.T) {
 	}
 }
 
+func TestOrderService_Checkout_IncludesShipping(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	userID := uuid.New()
+	productA := &model.Product{ID: uuid.New(), Name: "A", Price: decimal.NewFromInt(20000), Stock: 5}
+	productB := &model.Product{ID: uuid.New(), Name: "B", Price: decimal.NewFromInt(7500), Stock: 3}
+
+	orderRepo := mocks.NewMockOrderRepository(ctrl)
+	cartRepo := mocks.NewMockCartRepository(ctrl)
+	productRepo := mocks.NewMockProductRepository(ctrl)
+	storeRepo := mocks.NewMockStoreRepository(ctrl)
+
+	cartRepo.EXPECT().GetCart(gomock.Any(), userID).Return(&model.Cart{
+		UserID: userID,
+		Items: []model.CartItem{
+			{ProductID: productA.ID, Quantity: 2},
+			{ProductID: productB.ID, Quantity: 1},
+		},
+	}, nil)
+	productRepo.EXPECT().FindByID(gomock.Any(), productA.ID).Return(productA, nil)
+	productRepo.EXPECT().FindByID(gomock.Any(), productB.ID).Return(productB, nil)
+	productRepo.EXPECT().UpdateStock(gomock.Any(), productA, 3).Return(nil)
+	productRepo.EXPECT().UpdateStock(gomock.Any(), productB, 2).Return(nil)
+	orderRepo.EXPECT().AddStatusHistory(gomock.Any(), gomock.Any()).Return(nil)
+	orderRepo.EXPECT().Create(gomock.Any(), gomock.Any()).Return(nil)
+	cartRepo.EXPECT().DeleteCart(gomock.Any(), userID).Return(nil)
+
+	shipping := NewFlatRateShippingCalculator(map[string]decimal.Decimal{
+		"jakarta": decimal.NewFromInt(10000),
+	}, decimal.NewFromInt(25000))
+	svc := NewOrderService(orderRepo, cartRepo, productRepo, storeRepo, nil, nil, nil, nil, nil, shipping, nil, OrderConfig{})
+
+	resp, err := svc.Checkout(context.Background(), userID, model.CheckoutRequest{ShippingAddress: "Jl. Test No. 1, Jakarta"})
+
+	assert.NoError(t, err)
+	assert.True(t, decimal.NewFromInt(10000).Equal(resp.ShippingCost))
+	// 2 x 20000 + 1 x 7500 + 10000 shipping
+	assert.True(t, decimal.NewFromInt(57500).Equal(resp.TotalAmount))
+}
+
 func TestOrderService_GetOrders(t *testing.T) {
 	userID := uuid.New()
 
@@ -165,6 +217,9 @@ func TestOrderService_GetOrderByID(t *testing.T) {
 					UserID: ownerID,
 					Status: constant.OrderStatusPending,
 				}, nil)
+				orderRepo.EXPECT().FindStatusHistory(gomock.Any(), orderID).Return([]model.OrderStatusHistory{
+					{OrderID: orderID, ToStatus: constant.OrderStatusPending, ChangedBy: &ownerID},
+				}, nil)
 			},
 		},
 		{
@@ -213,6 +268,10 @@ func TestOrderService_GetOrderByID(t *testing.T) {
 			assert.NoError(t, err)
 			assert.NotNil(t, resp)
 			assert.Equal(t, orderID, resp.ID)
+			if assert.Len(t, resp.StatusHistory, 1) {
+				assert.Equal(t, constant.OrderStatusPending, resp.StatusHistory[0].ToStatus)
+				assert.Empty(t, resp.StatusHistory[0].FromStatus)
+			}
 		})
 	}
 }
@@ -240,7 +299,8 @@ func TestOrderService_CancelOrder(t *testing.T) {
 					Status:     constant.OrderStatusPending,
 					OrderItems: []model.OrderItem{},
 				}, nil)
-				orderRepo.EXPECT().UpdateStatus(gomock.Any(), orderID, constant.OrderStatusCancelled).Return(nil)
+				orderRepo.EXPECT().AddStatusHistory(gomock.Any(), gomock.Any()).Return(nil)
+				orderRepo.EXPECT().UpdateStatusFrom(gomock.Any(), orderID, constant.OrderStatusPending, constant.OrderStatusCancelled).Return(true, nil)
 			},
 		},
 		{
@@ -330,7 +390,8 @@ func TestOrderService_UpdateOrderStatus(t *testing.T) {
 				}, nil)
 				storeRepo.EXPECT().FindByUserID(gomock.Any(), sellerID).Return(&model.Store{ID: storeID}, nil)
 				productRepo.EXPECT().FindByID(gomock.Any(), productID).Return(&model.Product{ID: productID, StoreID: storeID}, nil)
-				orderRepo.EXPECT().UpdateStatus(gomock.Any(), orderID, constant.OrderStatusProcessing).Return(nil)
+				orderRepo.EXPECT().AddStatusHistory(gomock.Any(), gomock.Any()).Return(nil)
+				orderRepo.EXPECT().UpdateStatusFrom(gomock.Any(), orderID, constant.OrderStatusPaid, constant.OrderStatusProcessing).Return(true, nil)
 			},
 		},
 		{
@@ -345,8 +406,26 @@ func TestOrderService_UpdateOrderStatus(t *testing.T) {
 				}, nil)
 				storeRepo.EXPECT().FindByUserID(gomock.Any(), sellerID).Return(&model.Store{ID: storeID}, nil)
 				productRepo.EXPECT().FindByID(gomock.Any(), productID).Return(&model.Product{ID: productID, StoreID: storeID}, nil)
-				orderRepo.EXPECT().UpdateStatus(gomock.Any(), orderID, constant.OrderStatusShipping).Return(nil)
+				orderRepo.EXPECT().AddStatusHistory(gomock.Any(), gomock.Any()).Return(nil)
+				orderRepo.EXPECT().UpdateStatusFrom(gomock.Any(), orderID, constant.OrderStatusProcessing, constant.OrderStatusShipping).Return(true, nil)
+			},
+		},
+		{
+			name:      "status changed since it was read",
+			sellerID:  sellerID,
+			newStatus: constant.OrderStatusProcessing,
+			mockSetup: func(orderRepo *mocks.MockOrderRepository, _ *mocks.MockCartRepository, productRepo *mocks.MockProductRepository, storeRepo *mocks.MockStoreRepository) {
+				orderRepo.EXPECT().FindByID(gomock.Any(), orderID).Return(&model.Order{
+					ID:         orderID,
+					Status:     constant.OrderStatusPaid,
+					OrderItems: []model.OrderItem{{ProductID: productID, Quantity: 1}},
+				}, nil)
+				storeRepo.EXPECT().FindByUserID(gomock.Any(), sellerID).Return(&model.Store{ID: storeID}, nil)
+				productRepo.EXPECT().FindByID(gomock.Any(), productID).Return(&model.Product{ID: productID, StoreID: storeID}, nil)
+				orderRepo.EXPECT().UpdateStatusFrom(gomock.Any(), orderID, constant.OrderStatusPaid, constant.OrderStatusProcessing).Return(false, nil)
 			},
+			wantErr:     true,
+			errContains: "order status changed",
 		},
 		{
 			name:      "order not found",
@@ -522,6 +601,89 @@ func TestOrderService_GetSellerOrders(t *testing.T) {
 	}
 }
 
+func TestOrderService_GetSellerOrders_Payout(t *testing.T) {
+	userID := uuid.New()
+	storeID := uuid.New()
+	otherStoreID := uuid.New()
+
+	// Two of the seller's items plus one from another store in the same
+	// order: 2 x 12,500.50 + 1 x 3,333.33 = 28,334.33 gross.
+	items := []model.OrderItem{
+		{ProductID: uuid.New(), Quantity: 2, Price: decimal.RequireFromString("12500.50"), Product: model.Product{StoreID: storeID}},
+		{ProductID: uuid.New(), Quantity: 1, Price: decimal.RequireFromString("3333.33"), Product: model.Product{StoreID: storeID}},
+		{ProductID: uuid.New(), Quantity: 5, Price: decimal.RequireFromString("999.99"), Product: model.Product{StoreID: otherStoreID}},
+	}
+
+	tests := []struct {
+		name       string
+		status     string
+		feePercent string
+		wantPayout *model.SellerPayoutResponse
+	}{
+		{
+			name:       "paid order",
+			status:     constant.OrderStatusPaid,
+			feePercent: "7.5",
+			// 7.5% of 28,334.33 is 2,125.07475, rounded to cents.
+			wantPayout: &model.SellerPayoutResponse{
+				Gross:      decimal.RequireFromString("28334.33"),
+				FeePercent: decimal.RequireFromString("7.5"),
+				Fee:        decimal.RequireFromString("2125.07"),
+				Net:        decimal.RequireFromString("26209.26"),
+			},
+		},
+		{
+			name:       "completed order without a fee",
+			status:     constant.OrderStatusCompleted,
+			feePercent: "0",
+			wantPayout: &model.SellerPayoutResponse{
+				Gross:      decimal.RequireFromString("28334.33"),
+				FeePercent: decimal.Zero,
+				Fee:        decimal.Zero,
+				Net:        decimal.RequireFromString("28334.33"),
+			},
+		},
+		{name: "pending order does not count", status: constant.OrderStatusPending, feePercent: "7.5"},
+		{name: "cancelled order does not count", status: constant.OrderStatusCancelled, feePercent: "7.5"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			orderRepo := mocks.NewMockOrderRepository(ctrl)
+			storeRepo := mocks.NewMockStoreRepository(ctrl)
+			storeRepo.EXPECT().FindByUserID(gomock.Any(), userID).Return(&model.Store{ID: storeID, UserID: userID}, nil)
+			orderRepo.EXPECT().FindByStoreID(gomock.Any(), storeID, 1, 10).Return([]model.Order{
+				{ID: uuid.New(), Status: tt.status, OrderItems: items},
+			}, int64(1), nil)
+
+			svc := NewOrderService(orderRepo, mocks.NewMockCartRepository(ctrl), mocks.NewMockProductRepository(ctrl), storeRepo, nil, nil, nil, nil, nil,
+				NewFlatRateShippingCalculator(nil, decimal.Zero), nil, OrderConfig{PaymentsDisabled: true, FeePercent: decimal.RequireFromString(tt.feePercent)})
+
+			orders, _, err := svc.GetSellerOrders(context.Background(), userID, 1, 10)
+
+			assert.NoError(t, err)
+			if !assert.Len(t, orders, 1) {
+				return
+			}
+			payout := orders[0].Payout
+			if tt.wantPayout == nil {
+				assert.Nil(t, payout)
+				return
+			}
+			if assert.NotNil(t, payout) {
+				assert.True(t, tt.wantPayout.Gross.Equal(payout.Gross), "gross %s", payout.Gross)
+				assert.True(t, tt.wantPayout.FeePercent.Equal(payout.FeePercent), "fee percent %s", payout.FeePercent)
+				assert.True(t, tt.wantPayout.Fee.Equal(payout.Fee), "fee %s", payout.Fee)
+				assert.True(t, tt.wantPayout.Net.Equal(payout.Net), "net %s", payout.Net)
+				assert.True(t, payout.Fee.Add(payout.Net).Equal(payout.Gross))
+			}
+		})
+	}
+}
+
 func TestOrderService_ProcessPaymentResult(t *testing.T) {
 	orderID := uuid.New()
 
@@ -536,29 +698,40 @@ func TestOrderService_ProcessPaymentResult(t *testing.T) {
 			name:    "order not found",
 			success: true,
 			mockSetup: func(orderRepo *mocks.MockOrderRepository, _ *mocks.MockCartRepository, _ *mocks.MockProductRepository, _ *mocks.MockStoreRepository) {
-				orderRepo.EXPECT().FindByID(gomock.Any(), orderID).Return(nil, errors.New("not found"))
+				orderRepo.EXPECT().FindByID(gomock.Any(), orderID).Return(nil, gorm.ErrRecordNotFound)
 			},
 			wantErr:     true,
 			errContains: "order not found",
 		},
+		{
+			name:    "order lookup fails",
+			success: true,
+			mockSetup: func(orderRepo *mocks.MockOrderRepository, _ *mocks.MockCartRepository, _ *mocks.MockProductRepository, _ *mocks.MockStoreRepository) {
+				orderRepo.EXPECT().FindByID(gomock.Any(), orderID).Return(nil, errors.New("connection refused"))
+			},
+			wantErr:     true,
+			errContains: "failed to fetch order",
+		},
 		{
 			name:    "payment success - with payment record",
 			success: true,
 			mockSetup: func(orderRepo *mocks.MockOrderRepository, _ *mocks.MockCartRepository, _ *mocks.MockProductRepository, _ *mocks.MockStoreRepository) {
 				payment := &model.Payment{ID: uuid.New(), OrderID: orderID}
-				orderRepo.EXPECT().FindByID(gomock.Any(), orderID).Return(&model.Order{ID: orderID}, nil)
+				orderRepo.EXPECT().FindByID(gomock.Any(), orderID).Return(&model.Order{ID: orderID, Status: constant.OrderStatusPending}, nil)
 				orderRepo.EXPECT().FindPaymentByOrderID(gomock.Any(), orderID).Return(payment, nil)
 				orderRepo.EXPECT().UpdatePayment(gomock.Any(), gomock.Any()).Return(nil)
-				orderRepo.EXPECT().UpdateStatus(gomock.Any(), orderID, constant.OrderStatusPaid).Return(nil)
+				orderRepo.EXPECT().AddStatusHistory(gomock.Any(), gomock.Any()).Return(nil)
+				orderRepo.EXPECT().UpdateStatusFrom(gomock.Any(), orderID, constant.OrderStatusPending, constant.OrderStatusPaid).Return(true, nil)
 			},
 		},
 		{
 			name:    "payment success - no payment record",
 			success: true,
 			mockSetup: func(orderRepo *mocks.MockOrderRepository, _ *mocks.MockCartRepository, _ *mocks.MockProductRepository, _ *mocks.MockStoreRepository) {
-				orderRepo.EXPECT().FindByID(gomock.Any(), orderID).Return(&model.Order{ID: orderID}, nil)
+				orderRepo.EXPECT().FindByID(gomock.Any(), orderID).Return(&model.Order{ID: orderID, Status: constant.OrderStatusPending}, nil)
 				orderRepo.EXPECT().FindPaymentByOrderID(gomock.Any(), orderID).Return(nil, errors.New("not found"))
-				orderRepo.EXPECT().UpdateStatus(gomock.Any(), orderID, constant.OrderStatusPaid).Return(nil)
+				orderRepo.EXPECT().AddStatusHistory(gomock.Any(), gomock.Any()).Return(nil)
+				orderRepo.EXPECT().UpdateStatusFrom(gomock.Any(), orderID, constant.OrderStatusPending, constant.OrderStatusPaid).Return(true, nil)
 			},
 		},
 		{
@@ -566,7 +739,7 @@ func TestOrderService_ProcessPaymentResult(t *testing.T) {
 			success: false,
 			mockSetup: func(orderRepo *mocks.MockOrderRepository, _ *mocks.MockCartRepository, _ *mocks.MockProductRepository, _ *mocks.MockStoreRepository) {
 				payment := &model.Payment{ID: uuid.New(), OrderID: orderID}
-				orderRepo.EXPECT().FindByID(gomock.Any(), orderID).Return(&model.Order{ID: orderID}, nil)
+				orderRepo.EXPECT().FindByID(gomock.Any(), orderID).Return(&model.Order{ID: orderID, Status: constant.OrderStatusPending}, nil)
 				orderRepo.EXPECT().FindPaymentByOrderID(gomock.Any(), orderID).Return(payment, nil)
 				orderRepo.EXPECT().UpdatePayment(gomock.Any(), gomock.Any()).Return(nil)
 			},
@@ -575,10 +748,24 @@ func TestOrderService_ProcessPaymentResult(t *testing.T) {
 			name:    "payment failed - no payment record",
 			success: false,
 			mockSetup: func(orderRepo *mocks.MockOrderRepository, _ *mocks.MockCartRepository, _ *mocks.MockProductRepository, _ *mocks.MockStoreRepository) {
-				orderRepo.EXPECT().FindByID(gomock.Any(), orderID).Return(&model.Order{ID: orderID}, nil)
+				orderRepo.EXPECT().FindByID(gomock.Any(), orderID).Return(&model.Order{ID: orderID, Status: constant.OrderStatusPending}, nil)
 				orderRepo.EXPECT().FindPaymentByOrderID(gomock.Any(), orderID).Return(nil, errors.New("not found"))
 			},
 		},
+		{
+			name:    "duplicate success on a paid order changes nothing",
+			success: true,
+			mockSetup: func(orderRepo *mocks.MockOrderRepository, _ *mocks.MockCartRepository, _ *mocks.MockProductRepository, _ *mocks.MockStoreRepository) {
+				orderRepo.EXPECT().FindByID(gomock.Any(), orderID).Return(&model.Order{ID: orderID, Status: constant.OrderStatusPaid}, nil)
+			},
+		},
+		{
+			name:    "stale failure on a shipped order changes nothing",
+			success: false,
+			mockSetup: func(orderRepo *mocks.MockOrderRepository, _ *mocks.MockCartRepository, _ *mocks.MockProductRepository, _ *mocks.MockStoreRepository) {
+				orderRepo.EXPECT().FindByID(gomock.Any(), orderID).Return(&model.Order{ID: orderID, Status: constant.OrderStatusShipped}, nil)
+			},
+		},
 	}
 
 	for _, tt := range tests {
@@ -603,4 +790,1821 @@ func TestOrderService_ProcessPaymentResult(t *testing.T) {
 			assert.NoError(t, err)
 		})
 	}
-}
\ No newline at end of file
+}
+
+func TestOrderService_Checkout_HoldThreshold(t *testing.T) {
+	userID := uuid.New()
+	product := &model.Product{ID: uuid.New(), Name: "TV", Price: decimal.NewFromInt(6000000), Stock: 2}
+
+	tests := []struct {
+		name       string
+		quantity   int
+		wantStatus string
+	}{
+		{name: "above threshold goes on hold", quantity: 2, wantStatus: constant.OrderStatusOnHold},
+		{name: "at threshold stays pending", quantity: 1, wantStatus: constant.OrderStatusPending},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			orderRepo := mocks.NewMockOrderRepository(ctrl)
+			cartRepo := mocks.NewMockCartRepository(ctrl)
+			productRepo := mocks.NewMockProductRepository(ctrl)
+			storeRepo := mocks.NewMockStoreRepository(ctrl)
+
+			cartRepo.EXPECT().GetCart(gomock.Any(), userID).Return(&model.Cart{
+				UserID: userID,
+				Items:  []model.CartItem{{ProductID: product.ID, Quantity: tt.quantity}},
+			}, nil)
+			productRepo.EXPECT().FindByID(gomock.Any(), product.ID).Return(product, nil)
+			productRepo.EXPECT().UpdateStock(gomock.Any(), product, product.Stock-tt.quantity).Return(nil)
+			orderRepo.EXPECT().AddStatusHistory(gomock.Any(), gomock.Any()).Return(nil)
+			orderRepo.EXPECT().Create(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, order *model.Order) error {
+				assert.Equal(t, tt.wantStatus, order.Status)
+				return nil
+			})
+			cartRepo.EXPECT().DeleteCart(gomock.Any(), userID).Return(nil)
+
+			svc := NewOrderService(orderRepo, cartRepo, productRepo, storeRepo, nil, nil, nil, nil, nil,
+				NewFlatRateShippingCalculator(nil, decimal.Zero), nil,
+				OrderConfig{HoldThreshold: decimal.NewFromInt(6000000)})
+
+			resp, err := svc.Checkout(context.Background(), userID, model.CheckoutRequest{ShippingAddress: "Jl. Test No. 1, Jakarta"})
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.wantStatus, resp.Status)
+		})
+	}
+}
+
+func TestOrderService_ReleaseOrder(t *testing.T) {
+	orderID := uuid.New()
+
+	tests := []struct {
+		name        string
+		mockSetup   func(orderRepo *mocks.MockOrderRepository)
+		wantErr     bool
+		errContains string
+	}{
+		{
+			name: "success - on hold to pending",
+			mockSetup: func(orderRepo *mocks.MockOrderRepository) {
+				orderRepo.EXPECT().FindByID(gomock.Any(), orderID).Return(&model.Order{
+					ID:     orderID,
+					Status: constant.OrderStatusOnHold,
+				}, nil)
+				orderRepo.EXPECT().AddStatusHistory(gomock.Any(), gomock.Any()).Return(nil)
+				orderRepo.EXPECT().UpdateStatusFrom(gomock.Any(), orderID, constant.OrderStatusOnHold, constant.OrderStatusPending).Return(true, nil)
+			},
+		},
+		{
+			name: "concurrent release loses",
+			mockSetup: func(orderRepo *mocks.MockOrderRepository) {
+				orderRepo.EXPECT().FindByID(gomock.Any(), orderID).Return(&model.Order{
+					ID:     orderID,
+					Status: constant.OrderStatusOnHold,
+				}, nil)
+				orderRepo.EXPECT().UpdateStatusFrom(gomock.Any(), orderID, constant.OrderStatusOnHold, constant.OrderStatusPending).Return(false, nil)
+			},
+			wantErr:     true,
+			errContains: "order status changed",
+		},
+		{
+			name: "order not on hold",
+			mockSetup: func(orderRepo *mocks.MockOrderRepository) {
+				orderRepo.EXPECT().FindByID(gomock.Any(), orderID).Return(&model.Order{
+					ID:     orderID,
+					Status: constant.OrderStatusPending,
+				}, nil)
+			},
+			wantErr:     true,
+			errContains: "cannot release order with status",
+		},
+		{
+			name: "order not found",
+			mockSetup: func(orderRepo *mocks.MockOrderRepository) {
+				orderRepo.EXPECT().FindByID(gomock.Any(), orderID).Return(nil, errors.New("not found"))
+			},
+			wantErr:     true,
+			errContains: "order not found",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			orderRepo := mocks.NewMockOrderRepository(ctrl)
+			tt.mockSetup(orderRepo)
+
+			svc := newTestOrderService(orderRepo, mocks.NewMockCartRepository(ctrl), mocks.NewMockProductRepository(ctrl), mocks.NewMockStoreRepository(ctrl))
+			err := svc.ReleaseOrder(context.Background(), orderID)
+
+			if tt.wantErr {
+				assert.Error(t, err)
+				assert.Contains(t, err.Error(), tt.errContains)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
+
+type publishedMessage struct {
+	topic string
+	body  []byte
+	delay time.Duration
+}
+
+type fakePublisher struct {
+	messages []publishedMessage
+	err      error
+}
+
+func (p *fakePublisher) Publish(topic string, body []byte) error {
+	if p.err != nil {
+		return p.err
+	}
+	p.messages = append(p.messages, publishedMessage{topic: topic, body: body})
+	return nil
+}
+
+func (p *fakePublisher) DeferredPublish(topic string, delay time.Duration, body []byte) error {
+	if p.err != nil {
+		return p.err
+	}
+	p.messages = append(p.messages, publishedMessage{topic: topic, body: body, delay: delay})
+	return nil
+}
+
+func (p *fakePublisher) topic(topic string) []publishedMessage {
+	var matched []publishedMessage
+	for _, m := range p.messages {
+		if m.topic == topic {
+			matched = append(matched, m)
+		}
+	}
+	return matched
+}
+
+func TestOrderService_Checkout_LowStockAlert(t *testing.T) {
+	userID := uuid.New()
+	storeID := uuid.New()
+	productID := uuid.New()
+
+	tests := []struct {
+		name      string
+		stock     int
+		quantity  int
+		wantAlert bool
+	}{
+		{name: "crossing below threshold", stock: 6, quantity: 2, wantAlert: true},
+		{name: "already below threshold", stock: 4, quantity: 1, wantAlert: false},
+		{name: "still at threshold", stock: 7, quantity: 2, wantAlert: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			orderRepo := mocks.NewMockOrderRepository(ctrl)
+			cartRepo := mocks.NewMockCartRepository(ctrl)
+			productRepo := mocks.NewMockProductRepository(ctrl)
+			storeRepo := mocks.NewMockStoreRepository(ctrl)
+
+			product := &model.Product{ID: productID, StoreID: storeID, Name: "A", Price: decimal.NewFromInt(1000), Stock: tt.stock}
+			cartRepo.EXPECT().GetCart(gomock.Any(), userID).Return(&model.Cart{
+				UserID: userID,
+				Items:  []model.CartItem{{ProductID: productID, Quantity: tt.quantity}},
+			}, nil)
+			productRepo.EXPECT().FindByID(gomock.Any(), productID).Return(product, nil)
+			productRepo.EXPECT().UpdateStock(gomock.Any(), product, tt.stock-tt.quantity).Return(nil)
+			orderRepo.EXPECT().AddStatusHistory(gomock.Any(), gomock.Any()).Return(nil)
+			orderRepo.EXPECT().Create(gomock.Any(), gomock.Any()).Return(nil)
+			cartRepo.EXPECT().DeleteCart(gomock.Any(), userID).Return(nil)
+
+			publisher := &fakePublisher{}
+			svc := NewOrderService(orderRepo, cartRepo, productRepo, storeRepo, nil, nil, nil, nil, publisher,
+				NewFlatRateShippingCalculator(nil, decimal.Zero), nil, OrderConfig{LowStockThreshold: 5})
+
+			_, err := svc.Checkout(context.Background(), userID, model.CheckoutRequest{ShippingAddress: "Jl. Test No. 1, Jakarta"})
+			assert.NoError(t, err)
+
+			alerts := publisher.topic(constant.TopicProductLowStock)
+			if !tt.wantAlert {
+				assert.Empty(t, alerts)
+				return
+			}
+			assert.Len(t, alerts, 1)
+
+			var payload map[string]interface{}
+			assert.NoError(t, json.Unmarshal(alerts[0].body, &payload))
+			assert.Equal(t, productID.String(), payload["product_id"])
+			assert.Equal(t, storeID.String(), payload["store_id"])
+			assert.Equal(t, float64(tt.stock-tt.quantity), payload["stock"])
+		})
+	}
+}
+
+func TestOrderService_GetOrderStatuses(t *testing.T) {
+	userID := uuid.New()
+	ownPending := uuid.New()
+	ownPaid := uuid.New()
+	foreign := uuid.New()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	orderRepo := mocks.NewMockOrderRepository(ctrl)
+	cartRepo := mocks.NewMockCartRepository(ctrl)
+	productRepo := mocks.NewMockProductRepository(ctrl)
+	storeRepo := mocks.NewMockStoreRepository(ctrl)
+
+	ids := []uuid.UUID{ownPending, foreign, ownPaid}
+	// The repository filters by owner, so the foreign order is not returned.
+	orderRepo.EXPECT().FindStatusesByUser(gomock.Any(), userID, ids).Return([]model.Order{
+		{ID: ownPending, Status: constant.OrderStatusPending},
+		{ID: ownPaid, Status: constant.OrderStatusPaid},
+	}, nil)
+
+	svc := newTestOrderService(orderRepo, cartRepo, productRepo, storeRepo)
+	statuses, err := svc.GetOrderStatuses(context.Background(), userID, ids)
+
+	assert.NoError(t, err)
+	assert.Equal(t, map[uuid.UUID]string{
+		ownPending: constant.OrderStatusPending,
+		ownPaid:    constant.OrderStatusPaid,
+	}, statuses)
+	assert.NotContains(t, statuses, foreign)
+}
+
+func TestOrderService_Checkout_Backorder(t *testing.T) {
+	userID := uuid.New()
+	productID := uuid.New()
+
+	tests := []struct {
+		name            string
+		allowBackorder  bool
+		wantErr         string
+		wantBackordered bool
+	}{
+		{name: "backorder enabled", allowBackorder: true, wantBackordered: true},
+		{name: "backorder disabled", allowBackorder: false, wantErr: "insufficient stock"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			orderRepo := mocks.NewMockOrderRepository(ctrl)
+			cartRepo := mocks.NewMockCartRepository(ctrl)
+			productRepo := mocks.NewMockProductRepository(ctrl)
+			storeRepo := mocks.NewMockStoreRepository(ctrl)
+
+			cartRepo.EXPECT().GetCart(gomock.Any(), userID).Return(&model.Cart{
+				UserID: userID,
+				Items:  []model.CartItem{{ProductID: productID, Quantity: 5}},
+			}, nil)
+			product := &model.Product{
+				ID:             productID,
+				Name:           "Preorder Widget",
+				Price:          decimal.NewFromInt(1000),
+				Stock:          2,
+				AllowBackorder: tt.allowBackorder,
+			}
+			productRepo.EXPECT().FindByID(gomock.Any(), productID).Return(product, nil)
+			if tt.wantErr == "" {
+				productRepo.EXPECT().UpdateStock(gomock.Any(), product, -3).Return(nil)
+				orderRepo.EXPECT().AddStatusHistory(gomock.Any(), gomock.Any()).Return(nil)
+				orderRepo.EXPECT().Create(gomock.Any(), gomock.Any()).Return(nil)
+				cartRepo.EXPECT().DeleteCart(gomock.Any(), userID).Return(nil)
+			}
+
+			svc := newTestOrderService(orderRepo, cartRepo, productRepo, storeRepo)
+			resp, err := svc.Checkout(context.Background(), userID, model.CheckoutRequest{ShippingAddress: "Jl. Test No. 1, Jakarta"})
+
+			if tt.wantErr != "" {
+				assert.Error(t, err)
+				assert.Contains(t, err.Error(), tt.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Len(t, resp.Items, 1)
+			assert.Equal(t, tt.wantBackordered, resp.Items[0].Backordered)
+			assert.Equal(t, 5, resp.Items[0].Quantity)
+		})
+	}
+}
+
+func TestOrderService_Checkout_UnavailableProduct(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	userID := uuid.New()
+	product := &model.Product{ID: uuid.New(), Name: "Gone", Price: decimal.NewFromInt(1000), Unavailable: true, AllowBackorder: true}
+	orderRepo := mocks.NewMockOrderRepository(ctrl)
+	cartRepo := mocks.NewMockCartRepository(ctrl)
+	productRepo := mocks.NewMockProductRepository(ctrl)
+	storeRepo := mocks.NewMockStoreRepository(ctrl)
+
+	cartRepo.EXPECT().GetCart(gomock.Any(), userID).Return(&model.Cart{
+		UserID: userID,
+		Items:  []model.CartItem{{ProductID: product.ID, Quantity: 1}},
+	}, nil)
+	productRepo.EXPECT().FindByID(gomock.Any(), product.ID).Return(product, nil)
+
+	svc := newTestOrderService(orderRepo, cartRepo, productRepo, storeRepo)
+	_, err := svc.Checkout(context.Background(), userID, model.CheckoutRequest{ShippingAddress: "Jl. Test No. 1, Jakarta"})
+
+	assert.ErrorIs(t, err, ErrValidation)
+	assert.ErrorContains(t, err, "product Gone is no longer available")
+}
+
+func TestOrderService_CheckoutIdempotent(t *testing.T) {
+	userID := uuid.New()
+	productID := uuid.New()
+	orderID := uuid.New()
+	key := "checkout-7f3a"
+	req := model.CheckoutRequest{ShippingAddress: "Jl. Test No. 1, Jakarta"}
+	reqHash, err := checkoutRequestHash(req)
+	assert.NoError(t, err)
+
+	tests := []struct {
+		name      string
+		req       model.CheckoutRequest
+		mockSetup func(orderRepo *mocks.MockOrderRepository, cartRepo *mocks.MockCartRepository, productRepo *mocks.MockProductRepository, idemRepo *mocks.MockIdempotencyRepository)
+		wantErr   string
+	}{
+		{
+			name: "first use places the order and records the key",
+			req:  req,
+			mockSetup: func(orderRepo *mocks.MockOrderRepository, cartRepo *mocks.MockCartRepository, productRepo *mocks.MockProductRepository, idemRepo *mocks.MockIdempotencyRepository) {
+				idemRepo.EXPECT().Get(gomock.Any(), userID, key).Return(nil, errors.New("redis: nil"))
+				cartRepo.EXPECT().GetCart(gomock.Any(), userID).Return(&model.Cart{
+					UserID: userID,
+					Items:  []model.CartItem{{ProductID: productID, Quantity: 1}},
+				}, nil)
+				product := &model.Product{ID: productID, Name: "Widget", Price: decimal.NewFromInt(1000), Stock: 10}
+				productRepo.EXPECT().FindByID(gomock.Any(), productID).Return(product, nil)
+				productRepo.EXPECT().UpdateStock(gomock.Any(), product, 9).Return(nil)
+				orderRepo.EXPECT().AddStatusHistory(gomock.Any(), gomock.Any()).Return(nil)
+				orderRepo.EXPECT().Create(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, order *model.Order) error {
+					order.ID = orderID
+					return nil
+				})
+				cartRepo.EXPECT().DeleteCart(gomock.Any(), userID).Return(nil)
+				idemRepo.EXPECT().Save(gomock.Any(), userID, key, model.IdempotencyRecord{
+					RequestHash: reqHash,
+					OrderID:     orderID,
+				}).Return(nil)
+			},
+		},
+		{
+			name: "same key and body replays the original order",
+			req:  req,
+			mockSetup: func(orderRepo *mocks.MockOrderRepository, _ *mocks.MockCartRepository, _ *mocks.MockProductRepository, idemRepo *mocks.MockIdempotencyRepository) {
+				idemRepo.EXPECT().Get(gomock.Any(), userID, key).Return(&model.IdempotencyRecord{
+					RequestHash: reqHash,
+					OrderID:     orderID,
+				}, nil)
+				orderRepo.EXPECT().FindByID(gomock.Any(), orderID).Return(&model.Order{
+					ID:     orderID,
+					UserID: userID,
+					Status: constant.OrderStatusPending,
+				}, nil)
+				orderRepo.EXPECT().FindStatusHistory(gomock.Any(), orderID).Return(nil, nil)
+			},
+		},
+		{
+			name: "same key with a different body is rejected",
+			req:  model.CheckoutRequest{ShippingAddress: "Jl. Lain No. 2, Bandung"},
+			mockSetup: func(_ *mocks.MockOrderRepository, _ *mocks.MockCartRepository, _ *mocks.MockProductRepository, idemRepo *mocks.MockIdempotencyRepository) {
+				idemRepo.EXPECT().Get(gomock.Any(), userID, key).Return(&model.IdempotencyRecord{
+					RequestHash: reqHash,
+					OrderID:     orderID,
+				}, nil)
+			},
+			wantErr: "idempotency key reused with different parameters",
+		},
+		{
+			name: "failed checkout is not recorded",
+			req:  req,
+			mockSetup: func(_ *mocks.MockOrderRepository, cartRepo *mocks.MockCartRepository, _ *mocks.MockProductRepository, idemRepo *mocks.MockIdempotencyRepository) {
+				idemRepo.EXPECT().Get(gomock.Any(), userID, key).Return(nil, errors.New("redis: nil"))
+				cartRepo.EXPECT().GetCart(gomock.Any(), userID).Return(&model.Cart{UserID: userID}, nil)
+			},
+			wantErr: "cart is empty",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			orderRepo := mocks.NewMockOrderRepository(ctrl)
+			cartRepo := mocks.NewMockCartRepository(ctrl)
+			productRepo := mocks.NewMockProductRepository(ctrl)
+			storeRepo := mocks.NewMockStoreRepository(ctrl)
+			idemRepo := mocks.NewMockIdempotencyRepository(ctrl)
+			tt.mockSetup(orderRepo, cartRepo, productRepo, idemRepo)
+
+			svc := NewOrderService(orderRepo, cartRepo, productRepo, storeRepo, idemRepo, nil, nil, nil, nil,
+				NewFlatRateShippingCalculator(nil, decimal.Zero), nil, OrderConfig{})
+			resp, err := svc.CheckoutIdempotent(context.Background(), userID, key, tt.req)
+
+			if tt.wantErr != "" {
+				assert.Error(t, err)
+				assert.Contains(t, err.Error(), tt.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, orderID, resp.ID)
+		})
+	}
+}
+
+func TestOrderService_Checkout_PaymentUnavailable(t *testing.T) {
+	userID := uuid.New()
+	productID := uuid.New()
+	orderID := uuid.New()
+
+	tests := []struct {
+		name        string
+		policy      string
+		outboxErr   error
+		wantErr     string
+		wantPending bool
+	}{
+		{name: "fail policy undoes the checkout", policy: constant.PaymentPolicyFail, wantErr: "payment service unavailable"},
+		{name: "outbox policy accepts the order", policy: constant.PaymentPolicyOutbox, wantPending: true},
+		{name: "outbox write failure falls back to undo", policy: constant.PaymentPolicyOutbox, outboxErr: errors.New("db error"), wantErr: "payment service unavailable"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			orderRepo := mocks.NewMockOrderRepository(ctrl)
+			cartRepo := mocks.NewMockCartRepository(ctrl)
+			productRepo := mocks.NewMockProductRepository(ctrl)
+			storeRepo := mocks.NewMockStoreRepository(ctrl)
+			outboxRepo := mocks.NewMockOutboxRepository(ctrl)
+
+			cartRepo.EXPECT().GetCart(gomock.Any(), userID).Return(&model.Cart{
+				UserID: userID,
+				Items:  []model.CartItem{{ProductID: productID, Quantity: 2}},
+			}, nil)
+			product := &model.Product{ID: productID, Name: "Widget", Price: decimal.NewFromInt(1000), Stock: 10}
+			productRepo.EXPECT().FindByID(gomock.Any(), productID).Return(product, nil)
+			productRepo.EXPECT().UpdateStock(gomock.Any(), product, 8).Return(nil)
+			orderRepo.EXPECT().AddStatusHistory(gomock.Any(), gomock.Any()).Return(nil)
+			orderRepo.EXPECT().Create(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, order *model.Order) error {
+				order.ID = orderID
+				return nil
+			})
+			if tt.policy == constant.PaymentPolicyOutbox {
+				outboxRepo.EXPECT().Create(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, event *model.OutboxEvent) error {
+					assert.Equal(t, constant.TopicOrderCreated, event.Topic)
+					assert.Contains(t, string(event.Payload), orderID.String())
+					return tt.outboxErr
+				})
+			}
+			if tt.wantErr != "" {
+				// Order removed and stock restored; the cart is left alone.
+				orderRepo.EXPECT().Delete(gomock.Any(), orderID).Return(nil)
+				productRepo.EXPECT().UpdateStock(gomock.Any(), product, 10).Return(nil)
+			} else {
+				cartRepo.EXPECT().DeleteCart(gomock.Any(), userID).Return(nil)
+			}
+
+			publisher := &fakePublisher{err: errors.New("nsqd unreachable")}
+			svc := NewOrderService(orderRepo, cartRepo, productRepo, storeRepo, nil, outboxRepo, nil, nil, publisher,
+				NewFlatRateShippingCalculator(nil, decimal.Zero), nil, OrderConfig{PaymentUnavailablePolicy: tt.policy})
+
+			resp, err := svc.Checkout(context.Background(), userID, model.CheckoutRequest{ShippingAddress: "Jl. Test No. 1, Jakarta"})
+
+			if tt.wantErr != "" {
+				assert.Error(t, err)
+				assert.Contains(t, err.Error(), tt.wantErr)
+				assert.Nil(t, resp)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, orderID, resp.ID)
+			assert.Equal(t, tt.wantPending, resp.PaymentPending)
+			assert.Equal(t, constant.OrderStatusPending, resp.Status)
+		})
+	}
+}
+
+func TestOrderService_CancelOrder_PaidCancelWindow(t *testing.T) {
+	userID := uuid.New()
+	orderID := uuid.New()
+	window := 30 * time.Minute
+	justInside := time.Now().Add(-window + time.Minute)
+	justOutside := time.Now().Add(-window - time.Minute)
+
+	tests := []struct {
+		name        string
+		window      time.Duration
+		order       *model.Order
+		errContains string
+	}{
+		{
+			name:   "paid order within the window",
+			window: window,
+			order: &model.Order{ID: orderID, UserID: userID, Status: constant.OrderStatusPaid,
+				Payment: &model.Payment{OrderID: orderID, PaidAt: &justInside}},
+		},
+		{
+			name:   "paid order past the window",
+			window: window,
+			order: &model.Order{ID: orderID, UserID: userID, Status: constant.OrderStatusPaid,
+				Payment: &model.Payment{OrderID: orderID, PaidAt: &justOutside}},
+			errContains: "cancellation window for paid orders has expired",
+		},
+		{
+			name:   "processing order past the window",
+			window: window,
+			order: &model.Order{ID: orderID, UserID: userID, Status: constant.OrderStatusProcessing,
+				Payment: &model.Payment{OrderID: orderID, PaidAt: &justOutside}},
+			errContains: "cancellation window for paid orders has expired",
+		},
+		{
+			name:   "pending order is not time-bounded",
+			window: window,
+			order:  &model.Order{ID: orderID, UserID: userID, Status: constant.OrderStatusPending, CreatedAt: justOutside, UpdatedAt: justOutside},
+		},
+		{
+			name: "no window configured",
+			order: &model.Order{ID: orderID, UserID: userID, Status: constant.OrderStatusPaid,
+				Payment: &model.Payment{OrderID: orderID, PaidAt: &justOutside}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			orderRepo := mocks.NewMockOrderRepository(ctrl)
+			orderRepo.EXPECT().FindByID(gomock.Any(), orderID).Return(tt.order, nil)
+			if tt.errContains == "" {
+				orderRepo.EXPECT().AddStatusHistory(gomock.Any(), gomock.Any()).Return(nil)
+				orderRepo.EXPECT().UpdateStatusFrom(gomock.Any(), orderID, tt.order.Status, constant.OrderStatusCancelled).Return(true, nil)
+			}
+
+			svc := NewOrderService(orderRepo, nil, nil, nil, nil, nil, nil, nil, nil,
+				NewFlatRateShippingCalculator(nil, decimal.Zero), nil, OrderConfig{PaidCancelWindow: tt.window})
+			err := svc.CancelOrder(context.Background(), userID, orderID)
+
+			if tt.errContains != "" {
+				assert.Error(t, err)
+				assert.Contains(t, err.Error(), tt.errContains)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestOrderService_CancelOrder_Races(t *testing.T) {
+	userID := uuid.New()
+	orderID := uuid.New()
+
+	tests := []struct {
+		name        string
+		mockSetup   func(orderRepo *mocks.MockOrderRepository)
+		errContains string
+	}{
+		{
+			name: "reservation already expired",
+			mockSetup: func(orderRepo *mocks.MockOrderRepository) {
+				orderRepo.EXPECT().UpdateReservationStatus(gomock.Any(), orderID, model.ReservationStatusActive, model.ReservationStatusReleased).Return(false, nil)
+				orderRepo.EXPECT().FindReservation(gomock.Any(), orderID).Return(&model.StockReservation{OrderID: orderID, Status: model.ReservationStatusReleased}, nil)
+			},
+			errContains: "order has already expired",
+		},
+		{
+			name: "reservation committed by a payment",
+			mockSetup: func(orderRepo *mocks.MockOrderRepository) {
+				orderRepo.EXPECT().UpdateReservationStatus(gomock.Any(), orderID, model.ReservationStatusActive, model.ReservationStatusReleased).Return(false, nil)
+				orderRepo.EXPECT().FindReservation(gomock.Any(), orderID).Return(&model.StockReservation{OrderID: orderID, Status: model.ReservationStatusCommitted}, nil)
+			},
+			errContains: "order has already been paid",
+		},
+		{
+			name: "status changed after it was read",
+			mockSetup: func(orderRepo *mocks.MockOrderRepository) {
+				orderRepo.EXPECT().UpdateReservationStatus(gomock.Any(), orderID, model.ReservationStatusActive, model.ReservationStatusReleased).Return(true, nil)
+				orderRepo.EXPECT().UpdateStatusFrom(gomock.Any(), orderID, constant.OrderStatusPending, constant.OrderStatusCancelled).Return(false, nil)
+			},
+			errContains: "order status changed",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			// No product repository expectations: the stock must not be
+			// restored when the cancellation loses the race.
+			orderRepo := mocks.NewMockOrderRepository(ctrl)
+			productRepo := mocks.NewMockProductRepository(ctrl)
+			orderRepo.EXPECT().FindByID(gomock.Any(), orderID).Return(&model.Order{
+				ID:         orderID,
+				UserID:     userID,
+				Status:     constant.OrderStatusPending,
+				OrderItems: []model.OrderItem{{ProductID: uuid.New(), Quantity: 1}},
+			}, nil)
+			tt.mockSetup(orderRepo)
+
+			svc := NewOrderService(orderRepo, nil, productRepo, nil, nil, nil, nil, nil, nil,
+				NewFlatRateShippingCalculator(nil, decimal.Zero), nil, OrderConfig{PaymentsDisabled: true, ReservationTTL: 30 * time.Minute})
+			err := svc.CancelOrder(context.Background(), userID, orderID)
+
+			assert.ErrorIs(t, err, ErrConflict)
+			assert.Contains(t, err.Error(), tt.errContains)
+		})
+	}
+}
+
+func TestOrderService_UpdateOrderStatus_RecordsHistory(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	orderID := uuid.New()
+	sellerID := uuid.New()
+	storeID := uuid.New()
+	productID := uuid.New()
+
+	orderRepo := mocks.NewMockOrderRepository(ctrl)
+	productRepo := mocks.NewMockProductRepository(ctrl)
+	storeRepo := mocks.NewMockStoreRepository(ctrl)
+
+	orderRepo.EXPECT().FindByID(gomock.Any(), orderID).Return(&model.Order{
+		ID:         orderID,
+		Status:     constant.OrderStatusPaid,
+		OrderItems: []model.OrderItem{{ProductID: productID, Quantity: 1}},
+	}, nil)
+	storeRepo.EXPECT().FindByUserID(gomock.Any(), sellerID).Return(&model.Store{ID: storeID}, nil)
+	productRepo.EXPECT().FindByID(gomock.Any(), productID).Return(&model.Product{ID: productID, StoreID: storeID}, nil)
+	orderRepo.EXPECT().UpdateStatusFrom(gomock.Any(), orderID, constant.OrderStatusPaid, constant.OrderStatusProcessing).Return(true, nil)
+
+	var recorded []*model.OrderStatusHistory
+	orderRepo.EXPECT().AddStatusHistory(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(_ context.Context, entry *model.OrderStatusHistory) error {
+			recorded = append(recorded, entry)
+			return nil
+		}).Times(1)
+
+	svc := newTestOrderService(orderRepo, nil, productRepo, storeRepo)
+	err := svc.UpdateOrderStatus(context.Background(), sellerID, orderID, constant.OrderStatusProcessing)
+
+	assert.NoError(t, err)
+	if assert.Len(t, recorded, 1) {
+		assert.Equal(t, orderID, recorded[0].OrderID)
+		assert.Equal(t, constant.OrderStatusPaid, recorded[0].FromStatus)
+		assert.Equal(t, constant.OrderStatusProcessing, recorded[0].ToStatus)
+		assert.Equal(t, &sellerID, recorded[0].ChangedBy)
+	}
+}
+
+func TestOrderService_RefundOrder(t *testing.T) {
+	userID := uuid.New()
+	orderID := uuid.New()
+	window := 7 * 24 * time.Hour
+	// A small margin keeps the boundary cases stable while the test runs.
+	justInside := time.Now().Add(-window + time.Minute)
+	justOutside := time.Now().Add(-window - time.Minute)
+
+	tests := []struct {
+		name        string
+		order       *model.Order
+		history     []model.OrderStatusHistory
+		claimed     bool
+		wantRefund  bool
+		errContains string
+	}{
+		{
+			name:       "completed just inside the window",
+			order:      &model.Order{ID: orderID, UserID: userID, Status: constant.OrderStatusCompleted, UpdatedAt: justInside},
+			wantRefund: true,
+		},
+		{
+			name:        "completed just past the window",
+			order:       &model.Order{ID: orderID, UserID: userID, Status: constant.OrderStatusCompleted, UpdatedAt: justOutside},
+			errContains: "refund window has expired",
+		},
+		{
+			name: "completed order measured from completion, not payment",
+			order: &model.Order{ID: orderID, UserID: userID, Status: constant.OrderStatusCompleted, UpdatedAt: justInside,
+				Payment: &model.Payment{OrderID: orderID, PaidAt: &justOutside}},
+			wantRefund: true,
+		},
+		{
+			name:        "completed order measured from its history, not its last update",
+			order:       &model.Order{ID: orderID, UserID: userID, Status: constant.OrderStatusCompleted, UpdatedAt: time.Now()},
+			history:     []model.OrderStatusHistory{{ToStatus: constant.OrderStatusCompleted, CreatedAt: justOutside}},
+			errContains: "refund window has expired",
+		},
+		{
+			name: "shipped order measured from the paid entry in its history",
+			order: &model.Order{ID: orderID, UserID: userID, Status: constant.OrderStatusShipped, UpdatedAt: time.Now(),
+				Payment: &model.Payment{OrderID: orderID, PaidAt: &justOutside}},
+			history: []model.OrderStatusHistory{
+				{ToStatus: constant.OrderStatusPending, CreatedAt: justOutside},
+				{ToStatus: constant.OrderStatusPaid, CreatedAt: justInside},
+			},
+			wantRefund: true,
+		},
+		{
+			name:        "order refunded by a concurrent request",
+			order:       &model.Order{ID: orderID, UserID: userID, Status: constant.OrderStatusCompleted, UpdatedAt: justInside},
+			claimed:     true,
+			errContains: "order status changed, please retry",
+		},
+		{
+			name: "shipped order paid just inside the window",
+			order: &model.Order{ID: orderID, UserID: userID, Status: constant.OrderStatusShipped, UpdatedAt: time.Now(),
+				Payment: &model.Payment{OrderID: orderID, PaidAt: &justInside}},
+			wantRefund: true,
+		},
+		{
+			name: "shipped order paid just past the window",
+			order: &model.Order{ID: orderID, UserID: userID, Status: constant.OrderStatusShipped, UpdatedAt: time.Now(),
+				Payment: &model.Payment{OrderID: orderID, PaidAt: &justOutside}},
+			errContains: "refund window has expired",
+		},
+		{
+			name:        "cancellable order is not refundable",
+			order:       &model.Order{ID: orderID, UserID: userID, Status: constant.OrderStatusPaid, UpdatedAt: time.Now()},
+			errContains: "cannot refund order with status paid",
+		},
+		{
+			name:        "someone else's order",
+			order:       &model.Order{ID: orderID, UserID: uuid.New(), Status: constant.OrderStatusCompleted, UpdatedAt: time.Now()},
+			errContains: "forbidden",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			orderRepo := mocks.NewMockOrderRepository(ctrl)
+			orderRepo.EXPECT().FindByID(gomock.Any(), orderID).Return(tt.order, nil)
+			if tt.order.UserID == userID && constant.RefundableStatuses[tt.order.Status] {
+				orderRepo.EXPECT().FindStatusHistory(gomock.Any(), orderID).Return(tt.history, nil)
+			}
+			if tt.claimed {
+				orderRepo.EXPECT().UpdateStatusFrom(gomock.Any(), orderID, tt.order.Status, constant.OrderStatusRefunded).Return(false, nil)
+			}
+			if tt.wantRefund {
+				if tt.order.Payment != nil {
+					orderRepo.EXPECT().UpdatePayment(gomock.Any(), gomock.Any()).
+						DoAndReturn(func(_ context.Context, p *model.Payment) error {
+							assert.Equal(t, model.PaymentStatusRefunded, p.Status)
+							return nil
+						})
+				}
+				orderRepo.EXPECT().AddStatusHistory(gomock.Any(), gomock.Any()).Return(nil)
+				orderRepo.EXPECT().UpdateStatusFrom(gomock.Any(), orderID, tt.order.Status, constant.OrderStatusRefunded).Return(true, nil)
+			}
+
+			svc := NewOrderService(orderRepo, nil, nil, nil, nil, nil, nil, nil, nil,
+				NewFlatRateShippingCalculator(nil, decimal.Zero), nil, OrderConfig{RefundWindow: window})
+			err := svc.RefundOrder(context.Background(), userID, orderID)
+
+			if tt.errContains != "" {
+				assert.Error(t, err)
+				assert.Contains(t, err.Error(), tt.errContains)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestOrderService_GuestCheckout(t *testing.T) {
+	product := &model.Product{ID: uuid.New(), Name: "Mug", Price: decimal.NewFromInt(15000), Stock: 10}
+	req := model.GuestCheckoutRequest{
+		Email:           "guest@example.com",
+		Name:            "Guest",
+		ShippingAddress: "Jl. Test No. 1, Jakarta",
+		Items: []model.GuestCheckoutItem{
+			{ProductID: product.ID.String(), Quantity: 1},
+			{ProductID: product.ID.String(), Quantity: 2},
+		},
+	}
+
+	t.Run("creates a guest order with a lookup token", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		orderRepo := mocks.NewMockOrderRepository(ctrl)
+		productRepo := mocks.NewMockProductRepository(ctrl)
+
+		var created *model.Order
+		productRepo.EXPECT().FindByID(gomock.Any(), product.ID).Return(product, nil)
+		productRepo.EXPECT().UpdateStock(gomock.Any(), product, 7).Return(nil)
+		orderRepo.EXPECT().Create(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, order *model.Order) error {
+			guestID := uuid.New()
+			order.ID = uuid.New()
+			order.GuestID = &guestID
+			created = order
+			return nil
+		})
+		orderRepo.EXPECT().AddStatusHistory(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, entry *model.OrderStatusHistory) error {
+			assert.Nil(t, entry.ChangedBy)
+			return nil
+		})
+
+		// No cart repository: a guest checkout never touches carts.
+		svc := NewOrderService(orderRepo, nil, productRepo, nil, nil, nil, nil, nil, nil,
+			NewFlatRateShippingCalculator(nil, decimal.Zero), nil, OrderConfig{GuestCheckoutEnabled: true})
+
+		resp, err := svc.GuestCheckout(context.Background(), req)
+
+		assert.NoError(t, err)
+		assert.Equal(t, uuid.Nil, created.UserID)
+		assert.Equal(t, "guest@example.com", created.Guest.Email)
+		if assert.Len(t, created.OrderItems, 1) {
+			assert.Equal(t, 3, created.OrderItems[0].Quantity)
+		}
+		assert.NotEmpty(t, resp.LookupToken)
+		assert.Equal(t, hashSecretToken(resp.LookupToken), created.LookupTokenHash)
+		assert.NotEqual(t, resp.LookupToken, created.LookupTokenHash)
+		assert.Equal(t, created.GuestID, resp.GuestID)
+	})
+
+	t.Run("disabled by default", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		svc := newTestOrderService(mocks.NewMockOrderRepository(ctrl), mocks.NewMockCartRepository(ctrl),
+			mocks.NewMockProductRepository(ctrl), mocks.NewMockStoreRepository(ctrl))
+
+		resp, err := svc.GuestCheckout(context.Background(), req)
+
+		assert.EqualError(t, err, "guest checkout is disabled")
+		assert.Nil(t, resp)
+	})
+}
+
+func TestOrderService_GetGuestOrder(t *testing.T) {
+	guestID := uuid.New()
+	orderID := uuid.New()
+	token, tokenHash, err := newSecretToken()
+	assert.NoError(t, err)
+
+	guestOrder := &model.Order{ID: orderID, GuestID: &guestID, LookupTokenHash: tokenHash, Status: constant.OrderStatusPending}
+
+	tests := []struct {
+		name      string
+		order     *model.Order
+		token     string
+		wantFound bool
+	}{
+		{name: "valid token", order: guestOrder, token: token, wantFound: true},
+		{name: "wrong token", order: guestOrder, token: "not-the-token"},
+		{name: "account order", order: &model.Order{ID: orderID, UserID: uuid.New()}, token: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			orderRepo := mocks.NewMockOrderRepository(ctrl)
+			orderRepo.EXPECT().FindByID(gomock.Any(), orderID).Return(tt.order, nil)
+			if tt.wantFound {
+				orderRepo.EXPECT().FindStatusHistory(gomock.Any(), orderID).Return(nil, nil)
+			}
+
+			svc := newTestOrderService(orderRepo, nil, nil, nil)
+			resp, err := svc.GetGuestOrder(context.Background(), orderID, tt.token)
+
+			if !tt.wantFound {
+				assert.EqualError(t, err, "order not found")
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, orderID, resp.ID)
+			assert.Empty(t, resp.LookupToken)
+		})
+	}
+}
+
+func TestOrderService_Checkout_ZeroTotal(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	userID := uuid.New()
+	product := &model.Product{ID: uuid.New(), Name: "Free sample", Price: decimal.Zero, Stock: 5}
+
+	orderRepo := mocks.NewMockOrderRepository(ctrl)
+	cartRepo := mocks.NewMockCartRepository(ctrl)
+	productRepo := mocks.NewMockProductRepository(ctrl)
+	storeRepo := mocks.NewMockStoreRepository(ctrl)
+
+	cartRepo.EXPECT().GetCart(gomock.Any(), userID).Return(&model.Cart{
+		UserID: userID,
+		Items:  []model.CartItem{{ProductID: product.ID, Quantity: 1}},
+	}, nil)
+	productRepo.EXPECT().FindByID(gomock.Any(), product.ID).Return(product, nil)
+	productRepo.EXPECT().UpdateStock(gomock.Any(), product, 4).Return(nil)
+	orderRepo.EXPECT().Create(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, order *model.Order) error {
+		assert.Equal(t, constant.OrderStatusPaid, order.Status)
+		if assert.NotNil(t, order.Payment) {
+			assert.Equal(t, model.PaymentMethodFree, order.Payment.Method)
+			assert.Equal(t, model.PaymentStatusSuccess, order.Payment.Status)
+			assert.True(t, order.Payment.Amount.IsZero())
+			assert.NotNil(t, order.Payment.PaidAt)
+		}
+		return nil
+	})
+	orderRepo.EXPECT().AddStatusHistory(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, entry *model.OrderStatusHistory) error {
+		assert.Equal(t, constant.OrderStatusPaid, entry.ToStatus)
+		return nil
+	})
+	cartRepo.EXPECT().DeleteCart(gomock.Any(), userID).Return(nil)
+
+	publisher := &fakePublisher{}
+	svc := NewOrderService(orderRepo, cartRepo, productRepo, storeRepo, nil, nil, nil, nil, publisher,
+		NewFlatRateShippingCalculator(nil, decimal.Zero), nil, OrderConfig{})
+
+	resp, err := svc.Checkout(context.Background(), userID, model.CheckoutRequest{ShippingAddress: "Jl. Test No. 1, Jakarta"})
+
+	assert.NoError(t, err)
+	assert.Equal(t, constant.OrderStatusPaid, resp.Status)
+	assert.False(t, resp.PaymentPending)
+	assert.Empty(t, publisher.topic(constant.TopicOrderCreated), "zero-total order must not trigger payment")
+}
+
+func TestOrderService_Checkout_SelfPurchase(t *testing.T) {
+	sellerID := uuid.New()
+	storeID := uuid.New()
+	product := &model.Product{ID: uuid.New(), StoreID: storeID, Name: "Own widget", Price: decimal.NewFromInt(1000), Stock: 3}
+
+	tests := []struct {
+		name      string
+		block     bool
+		sellerHas bool
+		quantity  int
+		wantErr   string
+	}{
+		{name: "allowed by default and stock is decremented", quantity: 2},
+		{name: "allowed by default but still bound by stock", quantity: 4, wantErr: "insufficient stock"},
+		{name: "blocked when the rule is on", block: true, sellerHas: true, quantity: 1, wantErr: "sellers cannot order their own products"},
+		{name: "rule on, buyer without a store", block: true, quantity: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			orderRepo := mocks.NewMockOrderRepository(ctrl)
+			cartRepo := mocks.NewMockCartRepository(ctrl)
+			productRepo := mocks.NewMockProductRepository(ctrl)
+			storeRepo := mocks.NewMockStoreRepository(ctrl)
+
+			cartRepo.EXPECT().GetCart(gomock.Any(), sellerID).Return(&model.Cart{
+				UserID: sellerID,
+				Items:  []model.CartItem{{ProductID: product.ID, Quantity: tt.quantity}},
+			}, nil)
+			productRepo.EXPECT().FindByID(gomock.Any(), product.ID).Return(product, nil)
+			if tt.block {
+				if tt.sellerHas {
+					storeRepo.EXPECT().FindByUserID(gomock.Any(), sellerID).Return(&model.Store{ID: storeID, UserID: sellerID}, nil)
+				} else {
+					storeRepo.EXPECT().FindByUserID(gomock.Any(), sellerID).Return(nil, gorm.ErrRecordNotFound)
+				}
+			}
+			if tt.wantErr == "" {
+				productRepo.EXPECT().UpdateStock(gomock.Any(), product, product.Stock-tt.quantity).Return(nil)
+				orderRepo.EXPECT().Create(gomock.Any(), gomock.Any()).Return(nil)
+				orderRepo.EXPECT().AddStatusHistory(gomock.Any(), gomock.Any()).Return(nil)
+				cartRepo.EXPECT().DeleteCart(gomock.Any(), sellerID).Return(nil)
+			}
+
+			svc := NewOrderService(orderRepo, cartRepo, productRepo, storeRepo, nil, nil, nil, nil, nil,
+				NewFlatRateShippingCalculator(nil, decimal.Zero), nil, OrderConfig{BlockSelfPurchase: tt.block})
+
+			resp, err := svc.Checkout(context.Background(), sellerID, model.CheckoutRequest{ShippingAddress: "Jl. Test No. 1, Jakarta"})
+
+			if tt.wantErr != "" {
+				assert.Error(t, err)
+				assert.Contains(t, err.Error(), tt.wantErr)
+				assert.Nil(t, resp)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, constant.OrderStatusPending, resp.Status)
+		})
+	}
+}
+
+func TestOrderConfig_Validate(t *testing.T) {
+	tests := []struct {
+		name     string
+		cfg      OrderConfig
+		producer Publisher
+		wantErr  error
+	}{
+		{name: "payments enabled with a publisher", producer: &fakePublisher{}},
+		{name: "payments enabled without a publisher", wantErr: ErrPublisherRequired},
+		{name: "payments explicitly disabled", cfg: OrderConfig{PaymentsDisabled: true}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.ErrorIs(t, tt.cfg.Validate(tt.producer), tt.wantErr)
+		})
+	}
+}
+
+func TestOrderService_Checkout_PaymentsDisabled(t *testing.T) {
+	userID := uuid.New()
+	product := &model.Product{ID: uuid.New(), StoreID: uuid.New(), Name: "Widget", Price: decimal.NewFromInt(1000), Stock: 5}
+
+	tests := []struct {
+		name        string
+		disabled    bool
+		wantSkipLog bool
+		wantEvent   string
+	}{
+		{name: "explicitly disabled is counted", disabled: true, wantEvent: "payment_disabled"},
+		{name: "missing producer is reported", wantSkipLog: true, wantEvent: "payment_publish_skipped"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			logger.SetOutput(&buf)
+			t.Cleanup(func() { logger.SetOutput(io.Discard) })
+
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			orderRepo := mocks.NewMockOrderRepository(ctrl)
+			cartRepo := mocks.NewMockCartRepository(ctrl)
+			productRepo := mocks.NewMockProductRepository(ctrl)
+			storeRepo := mocks.NewMockStoreRepository(ctrl)
+
+			cartRepo.EXPECT().GetCart(gomock.Any(), userID).Return(&model.Cart{
+				UserID: userID,
+				Items:  []model.CartItem{{ProductID: product.ID, Quantity: 1}},
+			}, nil)
+			productRepo.EXPECT().FindByID(gomock.Any(), product.ID).Return(product, nil)
+			productRepo.EXPECT().UpdateStock(gomock.Any(), product, product.Stock-1).Return(nil)
+			orderRepo.EXPECT().Create(gomock.Any(), gomock.Any()).Return(nil)
+			orderRepo.EXPECT().AddStatusHistory(gomock.Any(), gomock.Any()).Return(nil)
+			cartRepo.EXPECT().DeleteCart(gomock.Any(), userID).Return(nil)
+
+			svc := NewOrderService(orderRepo, cartRepo, productRepo, storeRepo, nil, nil, nil, nil, nil,
+				NewFlatRateShippingCalculator(nil, decimal.Zero), nil, OrderConfig{PaymentsDisabled: tt.disabled})
+
+			resp, err := svc.Checkout(context.Background(), userID, model.CheckoutRequest{ShippingAddress: "Jl. Test No. 1, Jakarta"})
+
+			assert.NoError(t, err)
+			assert.Equal(t, constant.OrderStatusPending, resp.Status)
+			assert.Equal(t, tt.wantSkipLog, strings.Contains(buf.String(), "payment_publish_skipped"))
+			assert.Contains(t, buf.String(), `"event":"`+tt.wantEvent+`"`)
+		})
+	}
+}
+
+func TestOrderService_GetOrderTimeline(t *testing.T) {
+	buyerID := uuid.New()
+	sellerID := uuid.New()
+	orderID := uuid.New()
+	start := time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC)
+
+	history := []model.OrderStatusHistory{
+		{OrderID: orderID, ToStatus: constant.OrderStatusPending, ChangedBy: &buyerID, CreatedAt: start},
+		{OrderID: orderID, FromStatus: constant.OrderStatusPending, ToStatus: constant.OrderStatusPaid, CreatedAt: start.Add(time.Minute)},
+		{OrderID: orderID, FromStatus: constant.OrderStatusPaid, ToStatus: constant.OrderStatusProcessing, ChangedBy: &sellerID, CreatedAt: start.Add(time.Hour)},
+		{OrderID: orderID, FromStatus: constant.OrderStatusProcessing, ToStatus: constant.OrderStatusCancelled, ChangedBy: &buyerID, CreatedAt: start.Add(2 * time.Hour)},
+	}
+
+	tests := []struct {
+		name      string
+		userID    uuid.UUID
+		mockSetup func(orderRepo *mocks.MockOrderRepository)
+		want      []model.OrderTimelineEvent
+		wantErr   string
+	}{
+		{
+			name:   "status changes in order with their actors",
+			userID: buyerID,
+			mockSetup: func(orderRepo *mocks.MockOrderRepository) {
+				orderRepo.EXPECT().FindByID(gomock.Any(), orderID).Return(&model.Order{ID: orderID, UserID: buyerID}, nil)
+				orderRepo.EXPECT().FindStatusHistory(gomock.Any(), orderID).Return(history, nil)
+			},
+			want: []model.OrderTimelineEvent{
+				{Status: constant.OrderStatusPending, Actor: model.TimelineActorBuyer, ActorID: &buyerID, At: start},
+				{Status: constant.OrderStatusPaid, FromStatus: constant.OrderStatusPending, Actor: model.TimelineActorSystem, At: start.Add(time.Minute)},
+				{Status: constant.OrderStatusProcessing, FromStatus: constant.OrderStatusPaid, Actor: model.TimelineActorSeller, At: start.Add(time.Hour)},
+				{Status: constant.OrderStatusCancelled, FromStatus: constant.OrderStatusProcessing, Actor: model.TimelineActorBuyer, ActorID: &buyerID, At: start.Add(2 * time.Hour)},
+			},
+		},
+		{
+			name:   "another user's order",
+			userID: uuid.New(),
+			mockSetup: func(orderRepo *mocks.MockOrderRepository) {
+				orderRepo.EXPECT().FindByID(gomock.Any(), orderID).Return(&model.Order{ID: orderID, UserID: buyerID}, nil)
+			},
+			wantErr: "forbidden",
+		},
+		{
+			name:   "unknown order",
+			userID: buyerID,
+			mockSetup: func(orderRepo *mocks.MockOrderRepository) {
+				orderRepo.EXPECT().FindByID(gomock.Any(), orderID).Return(nil, gorm.ErrRecordNotFound)
+			},
+			wantErr: "order not found",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			orderRepo := mocks.NewMockOrderRepository(ctrl)
+			tt.mockSetup(orderRepo)
+
+			svc := newTestOrderService(orderRepo, mocks.NewMockCartRepository(ctrl), mocks.NewMockProductRepository(ctrl), mocks.NewMockStoreRepository(ctrl))
+			got, err := svc.GetOrderTimeline(context.Background(), tt.userID, orderID)
+
+			if tt.wantErr != "" {
+				assert.EqualError(t, err, tt.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestOrderService_ProcessPaymentResult_LogsPaidEvent(t *testing.T) {
+	orderID := uuid.New()
+	buyerID := uuid.New()
+
+	for _, success := range []bool{true, false} {
+		t.Run(fmt.Sprintf("success=%v", success), func(t *testing.T) {
+			var buf bytes.Buffer
+			logger.SetOutput(&buf)
+			t.Cleanup(func() { logger.SetOutput(io.Discard) })
+
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			orderRepo := mocks.NewMockOrderRepository(ctrl)
+			orderRepo.EXPECT().FindByID(gomock.Any(), orderID).Return(&model.Order{
+				ID:           orderID,
+				UserID:       buyerID,
+				Status:       constant.OrderStatusPending,
+				TotalAmount:  decimal.NewFromInt(150000),
+				ShippingCost: decimal.NewFromInt(10000),
+				OrderItems:   []model.OrderItem{{ID: uuid.New()}, {ID: uuid.New()}},
+			}, nil)
+			orderRepo.EXPECT().FindPaymentByOrderID(gomock.Any(), orderID).Return(nil, errors.New("not found"))
+			if success {
+				orderRepo.EXPECT().UpdateStatusFrom(gomock.Any(), orderID, constant.OrderStatusPending, constant.OrderStatusPaid).Return(true, nil)
+				orderRepo.EXPECT().AddStatusHistory(gomock.Any(), gomock.Any()).Return(nil)
+			}
+
+			svc := newTestOrderService(orderRepo, nil, nil, nil)
+			ctx := logger.WithRequestID(context.Background(), "req-1")
+			assert.NoError(t, svc.ProcessPaymentResult(ctx, orderID, success))
+
+			var events []map[string]interface{}
+			for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+				var entry map[string]interface{}
+				if assert.NoError(t, json.Unmarshal([]byte(line), &entry)) && entry["category"] == "business" {
+					events = append(events, entry)
+				}
+			}
+
+			if !success {
+				assert.Empty(t, events)
+				return
+			}
+			if assert.Len(t, events, 1) {
+				event := events[0]
+				assert.Equal(t, constant.EventOrderPaid, event["event"])
+				assert.Equal(t, orderID.String(), event["order_id"])
+				assert.Equal(t, buyerID.String(), event["buyer_id"])
+				assert.Equal(t, constant.OrderStatusPaid, event["status"])
+				assert.Equal(t, "150000", event["total_amount"])
+				assert.Equal(t, "10000", event["shipping_cost"])
+				assert.Equal(t, float64(2), event["item_count"])
+				assert.Equal(t, false, event["guest"])
+				assert.Equal(t, "req-1", event["request_id"])
+				assert.NotContains(t, event, "function", "business events carry no caller noise")
+			}
+		})
+	}
+}
+
+func TestOrderService_Checkout_ExpectedTotal(t *testing.T) {
+	userID := uuid.New()
+
+	tests := []struct {
+		name          string
+		expectedTotal string
+		tolerance     decimal.Decimal
+		wantErr       string
+	}{
+		{name: "omitted", expectedTotal: ""},
+		// Two items now at 20000, added to the cart at 19000. Shipping is
+		// not part of the expected total.
+		{name: "matching", expectedTotal: "40000"},
+		{name: "price changed", expectedTotal: "38000", wantErr: "price changed, please review your cart: the total is now 40000.00"},
+		{name: "within tolerance", expectedTotal: "39999.99", tolerance: decimal.RequireFromString("0.01")},
+		{name: "malformed", expectedTotal: "forty", wantErr: "invalid expected_total"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			product := &model.Product{ID: uuid.New(), Name: "A", Price: decimal.NewFromInt(20000), Stock: 5}
+			orderRepo := mocks.NewMockOrderRepository(ctrl)
+			cartRepo := mocks.NewMockCartRepository(ctrl)
+			productRepo := mocks.NewMockProductRepository(ctrl)
+
+			if tt.wantErr != "invalid expected_total" {
+				cartRepo.EXPECT().GetCart(gomock.Any(), userID).Return(&model.Cart{
+					UserID: userID,
+					Items:  []model.CartItem{{ProductID: product.ID, Price: decimal.NewFromInt(19000), Quantity: 2}},
+				}, nil)
+				productRepo.EXPECT().FindByID(gomock.Any(), product.ID).Return(product, nil)
+			}
+			if tt.wantErr == "" {
+				productRepo.EXPECT().UpdateStock(gomock.Any(), product, 3).Return(nil)
+				orderRepo.EXPECT().Create(gomock.Any(), gomock.Any()).Return(nil)
+				orderRepo.EXPECT().AddStatusHistory(gomock.Any(), gomock.Any()).Return(nil)
+				cartRepo.EXPECT().DeleteCart(gomock.Any(), userID).Return(nil)
+			}
+
+			shipping := NewFlatRateShippingCalculator(nil, decimal.NewFromInt(10000))
+			svc := NewOrderService(orderRepo, cartRepo, productRepo, nil, nil, nil, nil, nil, nil, shipping, nil,
+				OrderConfig{PaymentsDisabled: true, ExpectedTotalTolerance: tt.tolerance})
+
+			resp, err := svc.Checkout(context.Background(), userID, model.CheckoutRequest{
+				ShippingAddress: "Jl. Test No. 1, Jakarta",
+				ExpectedTotal:   tt.expectedTotal,
+			})
+
+			if tt.wantErr != "" {
+				assert.ErrorContains(t, err, tt.wantErr)
+				assert.Nil(t, resp)
+				return
+			}
+			assert.NoError(t, err)
+			assert.True(t, decimal.NewFromInt(50000).Equal(resp.TotalAmount))
+		})
+	}
+}
+
+func TestOrderService_StockLedger(t *testing.T) {
+	userID := uuid.New()
+
+	t.Run("checkout records each item taken", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		productA := &model.Product{ID: uuid.New(), Name: "A", Price: decimal.NewFromInt(20000), Stock: 5}
+		productB := &model.Product{ID: uuid.New(), Name: "B", Price: decimal.NewFromInt(7500), Stock: 1}
+		orderRepo := mocks.NewMockOrderRepository(ctrl)
+		cartRepo := mocks.NewMockCartRepository(ctrl)
+		productRepo := mocks.NewMockProductRepository(ctrl)
+		stockRepo := mocks.NewMockStockMovementRepository(ctrl)
+
+		cartRepo.EXPECT().GetCart(gomock.Any(), userID).Return(&model.Cart{
+			UserID: userID,
+			Items: []model.CartItem{
+				{ProductID: productA.ID, Quantity: 2},
+				{ProductID: productB.ID, Quantity: 1},
+			},
+		}, nil)
+		productRepo.EXPECT().FindByID(gomock.Any(), productA.ID).Return(productA, nil)
+		productRepo.EXPECT().FindByID(gomock.Any(), productB.ID).Return(productB, nil)
+		// Each item's entry is written with its stock update.
+		var recorded []model.StockMovement
+		record := func(_ context.Context, _ *model.Product, _ int, movements ...model.StockMovement) error {
+			recorded = append(recorded, movements...)
+			return nil
+		}
+		productRepo.EXPECT().UpdateStock(gomock.Any(), productA, 3, gomock.Any()).DoAndReturn(record)
+		productRepo.EXPECT().UpdateStock(gomock.Any(), productB, 0, gomock.Any()).DoAndReturn(record)
+		orderRepo.EXPECT().Create(gomock.Any(), gomock.Any()).Return(nil)
+		orderRepo.EXPECT().AddStatusHistory(gomock.Any(), gomock.Any()).Return(nil)
+		cartRepo.EXPECT().DeleteCart(gomock.Any(), userID).Return(nil)
+
+		svc := NewOrderService(orderRepo, cartRepo, productRepo, nil, nil, nil, stockRepo, nil, nil,
+			NewFlatRateShippingCalculator(nil, decimal.Zero), nil, OrderConfig{PaymentsDisabled: true})
+		resp, err := svc.Checkout(context.Background(), userID, model.CheckoutRequest{ShippingAddress: "Jl. Test No. 1, Jakarta"})
+		assert.NoError(t, err)
+
+		// Items are checked out in lock order, so match entries by product.
+		want := [][2]int{{-2, 3}, {-1, 0}}
+		if productB.ID.String() < productA.ID.String() {
+			want[0], want[1] = want[1], want[0]
+		}
+		assert.Len(t, recorded, len(want))
+		for i, m := range recorded {
+			assert.Equal(t, constant.StockReasonCheckout, m.Reason)
+			assert.Equal(t, want[i], [2]int{m.Delta, m.StockAfter})
+			assert.Equal(t, &userID, m.ActorID)
+			assert.Equal(t, &resp.ID, m.OrderID)
+		}
+	})
+
+	t.Run("cancel records each item restored", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		orderID := uuid.New()
+		product := &model.Product{ID: uuid.New(), Name: "A", Stock: 3}
+		orderRepo := mocks.NewMockOrderRepository(ctrl)
+		productRepo := mocks.NewMockProductRepository(ctrl)
+		stockRepo := mocks.NewMockStockMovementRepository(ctrl)
+
+		orderRepo.EXPECT().FindByID(gomock.Any(), orderID).Return(&model.Order{
+			ID:         orderID,
+			UserID:     userID,
+			Status:     constant.OrderStatusPending,
+			OrderItems: []model.OrderItem{{ProductID: product.ID, Quantity: 2}},
+		}, nil)
+		productRepo.EXPECT().FindByID(gomock.Any(), product.ID).Return(product, nil)
+		productRepo.EXPECT().UpdateStock(gomock.Any(), product, 5, model.StockMovement{
+			Reason:     constant.StockReasonCancel,
+			Delta:      2,
+			StockAfter: 5,
+			ActorID:    &userID,
+			OrderID:    &orderID,
+		}).Return(nil)
+		orderRepo.EXPECT().UpdateStatusFrom(gomock.Any(), orderID, constant.OrderStatusPending, constant.OrderStatusCancelled).Return(true, nil)
+		orderRepo.EXPECT().AddStatusHistory(gomock.Any(), gomock.Any()).Return(nil)
+
+		svc := NewOrderService(orderRepo, nil, productRepo, nil, nil, nil, stockRepo, nil, nil,
+			NewFlatRateShippingCalculator(nil, decimal.Zero), nil, OrderConfig{PaymentsDisabled: true})
+		assert.NoError(t, svc.CancelOrder(context.Background(), userID, orderID))
+	})
+}
+
+func TestOrderService_Checkout_Tax(t *testing.T) {
+	userID := uuid.New()
+
+	tests := []struct {
+		name      string
+		address   string
+		wantTax   string
+		wantTotal string
+	}{
+		// 2 x 19999.99 = 39999.98 taxed at 11% is 4399.9978, rounded to
+		// 4400.00, plus 10000 shipping, which is not taxed.
+		{name: "taxed region", address: "Jl. Test No. 1, Jakarta", wantTax: "4400.00", wantTotal: "54399.98"},
+		{name: "zero-tax region", address: "Jl. Dago 10, Bandung", wantTax: "0.00", wantTotal: "49999.98"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			product := &model.Product{ID: uuid.New(), Name: "A", Price: decimal.RequireFromString("19999.99"), Stock: 5}
+			orderRepo := mocks.NewMockOrderRepository(ctrl)
+			cartRepo := mocks.NewMockCartRepository(ctrl)
+			productRepo := mocks.NewMockProductRepository(ctrl)
+
+			cartRepo.EXPECT().GetCart(gomock.Any(), userID).Return(&model.Cart{
+				UserID: userID,
+				Items:  []model.CartItem{{ProductID: product.ID, Quantity: 2}},
+			}, nil)
+			productRepo.EXPECT().FindByID(gomock.Any(), product.ID).Return(product, nil)
+			productRepo.EXPECT().UpdateStock(gomock.Any(), product, 3).Return(nil)
+			orderRepo.EXPECT().Create(gomock.Any(), gomock.Any()).Return(nil)
+			orderRepo.EXPECT().AddStatusHistory(gomock.Any(), gomock.Any()).Return(nil)
+			cartRepo.EXPECT().DeleteCart(gomock.Any(), userID).Return(nil)
+
+			shipping := NewFlatRateShippingCalculator(nil, decimal.NewFromInt(10000))
+			tax := NewRegionalTaxCalculator(map[string]decimal.Decimal{"jakarta": decimal.NewFromInt(11)})
+			svc := NewOrderService(orderRepo, cartRepo, productRepo, nil, nil, nil, nil, nil, nil, shipping, tax,
+				OrderConfig{PaymentsDisabled: true})
+
+			resp, err := svc.Checkout(context.Background(), userID, model.CheckoutRequest{ShippingAddress: tt.address})
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.wantTax, resp.TaxAmount.StringFixed(2))
+			assert.Equal(t, tt.wantTotal, resp.TotalAmount.StringFixed(2))
+		})
+	}
+}
+
+func TestOrderService_ExpireReservations(t *testing.T) {
+	orderID := uuid.New()
+	buyerID := uuid.New()
+	product := &model.Product{ID: uuid.New(), Stock: 3}
+	expiredAt := time.Now().Add(-time.Minute)
+	reservation := model.StockReservation{OrderID: orderID, Status: model.ReservationStatusActive, ExpiresAt: expiredAt}
+
+	t.Run("restores stock and cancels the order", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		orderRepo := mocks.NewMockOrderRepository(ctrl)
+		productRepo := mocks.NewMockProductRepository(ctrl)
+		stockRepo := mocks.NewMockStockMovementRepository(ctrl)
+		orderRepo.EXPECT().FindExpiredReservations(gomock.Any(), gomock.Any(), gomock.Any()).Return([]model.StockReservation{reservation}, nil)
+		orderRepo.EXPECT().UpdateReservationStatus(gomock.Any(), orderID, model.ReservationStatusActive, model.ReservationStatusReleased).Return(true, nil)
+		orderRepo.EXPECT().FindByID(gomock.Any(), orderID).Return(&model.Order{
+			ID:         orderID,
+			UserID:     buyerID,
+			Status:     constant.OrderStatusPending,
+			OrderItems: []model.OrderItem{{ProductID: product.ID, Quantity: 2}},
+		}, nil)
+		productRepo.EXPECT().FindByID(gomock.Any(), product.ID).Return(product, nil)
+		productRepo.EXPECT().UpdateStock(gomock.Any(), product, 5, model.StockMovement{
+			Reason:     constant.StockReasonReservationExpired,
+			Delta:      2,
+			StockAfter: 5,
+			OrderID:    &orderID,
+		}).Return(nil)
+		orderRepo.EXPECT().UpdateStatusFrom(gomock.Any(), orderID, constant.OrderStatusPending, constant.OrderStatusCancelled).Return(true, nil)
+		orderRepo.EXPECT().AddStatusHistory(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, history *model.OrderStatusHistory) error {
+			assert.Equal(t, constant.OrderStatusPending, history.FromStatus)
+			assert.Equal(t, constant.OrderStatusCancelled, history.ToStatus)
+			assert.Nil(t, history.ChangedBy)
+			return nil
+		})
+
+		svc := NewOrderService(orderRepo, nil, productRepo, nil, nil, nil, stockRepo, nil, nil,
+			NewFlatRateShippingCalculator(nil, decimal.Zero), nil, OrderConfig{PaymentsDisabled: true, ReservationTTL: 30 * time.Minute})
+		expired, err := svc.ExpireReservations(context.Background())
+
+		assert.NoError(t, err)
+		assert.Equal(t, 1, expired)
+	})
+
+	t.Run("skips an order whose status changed", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		orderRepo := mocks.NewMockOrderRepository(ctrl)
+		orderRepo.EXPECT().FindExpiredReservations(gomock.Any(), gomock.Any(), gomock.Any()).Return([]model.StockReservation{reservation}, nil)
+		orderRepo.EXPECT().UpdateReservationStatus(gomock.Any(), orderID, model.ReservationStatusActive, model.ReservationStatusReleased).Return(true, nil)
+		orderRepo.EXPECT().FindByID(gomock.Any(), orderID).Return(&model.Order{
+			ID:         orderID,
+			UserID:     buyerID,
+			Status:     constant.OrderStatusPending,
+			OrderItems: []model.OrderItem{{ProductID: product.ID, Quantity: 2}},
+		}, nil)
+		orderRepo.EXPECT().UpdateStatusFrom(gomock.Any(), orderID, constant.OrderStatusPending, constant.OrderStatusCancelled).Return(false, nil)
+
+		// productRepo is a strict mock with no expectations: any stock
+		// restore fails the test.
+		productRepo := mocks.NewMockProductRepository(ctrl)
+		svc := NewOrderService(orderRepo, nil, productRepo, nil, nil, nil, nil, nil, nil,
+			NewFlatRateShippingCalculator(nil, decimal.Zero), nil, OrderConfig{PaymentsDisabled: true, ReservationTTL: 30 * time.Minute})
+		expired, err := svc.ExpireReservations(context.Background())
+
+		assert.NoError(t, err)
+		assert.Zero(t, expired)
+	})
+
+	t.Run("leaves a reservation taken by a payment", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		orderRepo := mocks.NewMockOrderRepository(ctrl)
+		orderRepo.EXPECT().FindExpiredReservations(gomock.Any(), gomock.Any(), gomock.Any()).Return([]model.StockReservation{reservation}, nil)
+		orderRepo.EXPECT().UpdateReservationStatus(gomock.Any(), orderID, model.ReservationStatusActive, model.ReservationStatusReleased).Return(false, nil)
+
+		svc := NewOrderService(orderRepo, nil, nil, nil, nil, nil, nil, nil, nil,
+			NewFlatRateShippingCalculator(nil, decimal.Zero), nil, OrderConfig{PaymentsDisabled: true, ReservationTTL: 30 * time.Minute})
+		expired, err := svc.ExpireReservations(context.Background())
+
+		assert.NoError(t, err)
+		assert.Zero(t, expired)
+	})
+}
+
+func TestOrderService_ProcessPaymentResult_Reservation(t *testing.T) {
+	orderID := uuid.New()
+
+	t.Run("payment commits the reservation", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		orderRepo := mocks.NewMockOrderRepository(ctrl)
+		orderRepo.EXPECT().FindByID(gomock.Any(), orderID).Return(&model.Order{ID: orderID, Status: constant.OrderStatusPending}, nil)
+		orderRepo.EXPECT().FindPaymentByOrderID(gomock.Any(), orderID).Return(nil, errors.New("not found"))
+		orderRepo.EXPECT().UpdateReservationStatus(gomock.Any(), orderID, model.ReservationStatusActive, model.ReservationStatusCommitted).Return(true, nil)
+		orderRepo.EXPECT().UpdateStatusFrom(gomock.Any(), orderID, constant.OrderStatusPending, constant.OrderStatusPaid).Return(true, nil)
+		orderRepo.EXPECT().AddStatusHistory(gomock.Any(), gomock.Any()).Return(nil)
+
+		svc := NewOrderService(orderRepo, nil, nil, nil, nil, nil, nil, nil, nil,
+			NewFlatRateShippingCalculator(nil, decimal.Zero), nil, OrderConfig{PaymentsDisabled: true, ReservationTTL: 30 * time.Minute})
+
+		assert.NoError(t, svc.ProcessPaymentResult(context.Background(), orderID, true))
+	})
+
+	t.Run("payment after expiry does not mark the order paid", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		payment := &model.Payment{ID: uuid.New(), OrderID: orderID}
+		orderRepo := mocks.NewMockOrderRepository(ctrl)
+		orderRepo.EXPECT().FindByID(gomock.Any(), orderID).Return(&model.Order{ID: orderID, Status: constant.OrderStatusPending}, nil)
+		orderRepo.EXPECT().FindPaymentByOrderID(gomock.Any(), orderID).Return(payment, nil)
+		orderRepo.EXPECT().UpdateReservationStatus(gomock.Any(), orderID, model.ReservationStatusActive, model.ReservationStatusCommitted).Return(false, nil)
+		orderRepo.EXPECT().FindReservation(gomock.Any(), orderID).Return(&model.StockReservation{OrderID: orderID, Status: model.ReservationStatusReleased}, nil)
+		orderRepo.EXPECT().UpdatePayment(gomock.Any(), payment).Return(nil)
+
+		svc := NewOrderService(orderRepo, nil, nil, nil, nil, nil, nil, nil, nil,
+			NewFlatRateShippingCalculator(nil, decimal.Zero), nil, OrderConfig{PaymentsDisabled: true, ReservationTTL: 30 * time.Minute})
+
+		assert.NoError(t, svc.ProcessPaymentResult(context.Background(), orderID, true))
+		assert.Equal(t, model.PaymentStatusSuccess, payment.Status)
+	})
+
+	t.Run("failing to commit the reservation does not mark the order paid", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		payment := &model.Payment{ID: uuid.New(), OrderID: orderID}
+		orderRepo := mocks.NewMockOrderRepository(ctrl)
+		orderRepo.EXPECT().FindByID(gomock.Any(), orderID).Return(&model.Order{ID: orderID, Status: constant.OrderStatusPending}, nil)
+		orderRepo.EXPECT().FindPaymentByOrderID(gomock.Any(), orderID).Return(payment, nil)
+		orderRepo.EXPECT().UpdateReservationStatus(gomock.Any(), orderID, model.ReservationStatusActive, model.ReservationStatusCommitted).Return(false, errors.New("connection refused"))
+
+		svc := NewOrderService(orderRepo, nil, nil, nil, nil, nil, nil, nil, nil,
+			NewFlatRateShippingCalculator(nil, decimal.Zero), nil, OrderConfig{PaymentsDisabled: true, ReservationTTL: 30 * time.Minute})
+
+		assert.Error(t, svc.ProcessPaymentResult(context.Background(), orderID, true))
+		assert.Empty(t, payment.Status)
+	})
+
+	t.Run("order cancelled while the payment was applied", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		payment := &model.Payment{ID: uuid.New(), OrderID: orderID}
+		orderRepo := mocks.NewMockOrderRepository(ctrl)
+		orderRepo.EXPECT().FindByID(gomock.Any(), orderID).Return(&model.Order{ID: orderID, Status: constant.OrderStatusPending}, nil)
+		orderRepo.EXPECT().FindPaymentByOrderID(gomock.Any(), orderID).Return(payment, nil)
+		orderRepo.EXPECT().UpdatePayment(gomock.Any(), payment).Return(nil)
+		orderRepo.EXPECT().UpdateStatusFrom(gomock.Any(), orderID, constant.OrderStatusPending, constant.OrderStatusPaid).Return(false, nil)
+
+		svc := NewOrderService(orderRepo, nil, nil, nil, nil, nil, nil, nil, nil,
+			NewFlatRateShippingCalculator(nil, decimal.Zero), nil, OrderConfig{PaymentsDisabled: true})
+
+		assert.NoError(t, svc.ProcessPaymentResult(context.Background(), orderID, true))
+		assert.Equal(t, model.PaymentStatusSuccess, payment.Status)
+	})
+}
+
+func TestOrderService_ProcessPaymentResult_CancelledOrder(t *testing.T) {
+	tests := []struct {
+		name string
+		ttl  time.Duration
+	}{
+		{"without reservations", 0},
+		// The reservation may already have been committed by an earlier
+		// delivery; the order must stay cancelled either way.
+		{"with a committed reservation", 30 * time.Minute},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			orderID := uuid.New()
+			payment := &model.Payment{ID: uuid.New(), OrderID: orderID}
+			orderRepo := mocks.NewMockOrderRepository(ctrl)
+			orderRepo.EXPECT().FindByID(gomock.Any(), orderID).Return(&model.Order{ID: orderID, Status: constant.OrderStatusCancelled}, nil)
+			orderRepo.EXPECT().FindPaymentByOrderID(gomock.Any(), orderID).Return(payment, nil)
+			orderRepo.EXPECT().UpdatePayment(gomock.Any(), payment).Return(nil)
+
+			svc := NewOrderService(orderRepo, nil, nil, nil, nil, nil, nil, nil, nil,
+				NewFlatRateShippingCalculator(nil, decimal.Zero), nil, OrderConfig{PaymentsDisabled: true, ReservationTTL: tt.ttl})
+
+			assert.NoError(t, svc.ProcessPaymentResult(context.Background(), orderID, true))
+			assert.Equal(t, model.PaymentStatusSuccess, payment.Status)
+		})
+	}
+}
+
+func TestOrderService_Checkout_SchedulesPaymentTimeout(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	userID := uuid.New()
+	product := &model.Product{ID: uuid.New(), Name: "Widget", Price: decimal.NewFromInt(1000), Stock: 5}
+
+	orderRepo := mocks.NewMockOrderRepository(ctrl)
+	cartRepo := mocks.NewMockCartRepository(ctrl)
+	productRepo := mocks.NewMockProductRepository(ctrl)
+
+	cartRepo.EXPECT().GetCart(gomock.Any(), userID).Return(&model.Cart{
+		UserID: userID,
+		Items:  []model.CartItem{{ProductID: product.ID, Quantity: 1}},
+	}, nil)
+	productRepo.EXPECT().FindByID(gomock.Any(), product.ID).Return(product, nil)
+	productRepo.EXPECT().UpdateStock(gomock.Any(), product, 4).Return(nil)
+	orderRepo.EXPECT().Create(gomock.Any(), gomock.Any()).Return(nil)
+	orderRepo.EXPECT().AddStatusHistory(gomock.Any(), gomock.Any()).Return(nil)
+	cartRepo.EXPECT().DeleteCart(gomock.Any(), userID).Return(nil)
+
+	publisher := &fakePublisher{}
+	svc := NewOrderService(orderRepo, cartRepo, productRepo, nil, nil, nil, nil, nil, publisher,
+		NewFlatRateShippingCalculator(nil, decimal.Zero), nil, OrderConfig{PaymentTimeout: time.Hour})
+
+	resp, err := svc.Checkout(context.Background(), userID, model.CheckoutRequest{ShippingAddress: "Jl. Test No. 1, Jakarta"})
+
+	assert.NoError(t, err)
+	timeouts := publisher.topic(constant.TopicOrderPaymentTimeout)
+	if assert.Len(t, timeouts, 1) {
+		assert.Equal(t, time.Hour, timeouts[0].delay)
+		assert.JSONEq(t, `{"order_id":"`+resp.ID.String()+`"}`, string(timeouts[0].body))
+	}
+}
+
+func TestOrderService_CancelUnpaidOrder(t *testing.T) {
+	orderID := uuid.New()
+
+	t.Run("still pending order is cancelled and its stock restored", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		product := &model.Product{ID: uuid.New(), Stock: 1}
+		orderRepo := mocks.NewMockOrderRepository(ctrl)
+		productRepo := mocks.NewMockProductRepository(ctrl)
+		orderRepo.EXPECT().FindByID(gomock.Any(), orderID).Return(&model.Order{
+			ID:         orderID,
+			Status:     constant.OrderStatusPending,
+			OrderItems: []model.OrderItem{{ProductID: product.ID, Quantity: 3}},
+		}, nil)
+		productRepo.EXPECT().FindByID(gomock.Any(), product.ID).Return(product, nil)
+		orderRepo.EXPECT().UpdateStatusFrom(gomock.Any(), orderID, constant.OrderStatusPending, constant.OrderStatusCancelled).Return(true, nil)
+		productRepo.EXPECT().UpdateStock(gomock.Any(), product, 4).Return(nil)
+		orderRepo.EXPECT().AddStatusHistory(gomock.Any(), gomock.Any()).Return(nil)
+
+		svc := NewOrderService(orderRepo, nil, productRepo, nil, nil, nil, nil, nil, nil,
+			NewFlatRateShippingCalculator(nil, decimal.Zero), nil, OrderConfig{PaymentsDisabled: true})
+
+		assert.NoError(t, svc.CancelUnpaidOrder(context.Background(), orderID))
+	})
+
+	t.Run("already paid order is left alone", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		orderRepo := mocks.NewMockOrderRepository(ctrl)
+		orderRepo.EXPECT().FindByID(gomock.Any(), orderID).Return(&model.Order{ID: orderID, Status: constant.OrderStatusPaid}, nil)
+
+		svc := NewOrderService(orderRepo, nil, nil, nil, nil, nil, nil, nil, nil,
+			NewFlatRateShippingCalculator(nil, decimal.Zero), nil, OrderConfig{PaymentsDisabled: true})
+
+		assert.NoError(t, svc.CancelUnpaidOrder(context.Background(), orderID))
+	})
+
+	t.Run("order paid between the read and the write", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		// No reservation guards the order, so only the conditional update
+		// stands between the timeout and the payment. Stock is untouched
+		// and no history is recorded.
+		orderRepo := mocks.NewMockOrderRepository(ctrl)
+		orderRepo.EXPECT().FindByID(gomock.Any(), orderID).Return(&model.Order{
+			ID:         orderID,
+			Status:     constant.OrderStatusPending,
+			OrderItems: []model.OrderItem{{ProductID: uuid.New(), Quantity: 3}},
+		}, nil)
+		orderRepo.EXPECT().UpdateStatusFrom(gomock.Any(), orderID, constant.OrderStatusPending, constant.OrderStatusCancelled).Return(false, nil)
+
+		svc := NewOrderService(orderRepo, nil, nil, nil, nil, nil, nil, nil, nil,
+			NewFlatRateShippingCalculator(nil, decimal.Zero), nil, OrderConfig{PaymentsDisabled: true})
+
+		assert.NoError(t, svc.CancelUnpaidOrder(context.Background(), orderID))
+	})
+
+	t.Run("payment committed the reservation first", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		orderRepo := mocks.NewMockOrderRepository(ctrl)
+		orderRepo.EXPECT().FindByID(gomock.Any(), orderID).Return(&model.Order{ID: orderID, Status: constant.OrderStatusPending}, nil)
+		orderRepo.EXPECT().UpdateReservationStatus(gomock.Any(), orderID, model.ReservationStatusActive, model.ReservationStatusReleased).Return(false, nil)
+		orderRepo.EXPECT().FindReservation(gomock.Any(), orderID).Return(&model.StockReservation{OrderID: orderID, Status: model.ReservationStatusCommitted}, nil)
+
+		svc := NewOrderService(orderRepo, nil, nil, nil, nil, nil, nil, nil, nil,
+			NewFlatRateShippingCalculator(nil, decimal.Zero), nil, OrderConfig{PaymentsDisabled: true, ReservationTTL: 30 * time.Minute})
+
+		assert.NoError(t, svc.CancelUnpaidOrder(context.Background(), orderID))
+	})
+}
+
+func TestOrderService_ProcessPaymentResult_PaidAt(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	orderID := uuid.New()
+	now := time.Date(2026, 3, 1, 10, 30, 15, 0, time.UTC)
+
+	orderRepo := mocks.NewMockOrderRepository(ctrl)
+	orderRepo.EXPECT().FindByID(gomock.Any(), orderID).Return(&model.Order{ID: orderID, Status: constant.OrderStatusPending}, nil)
+	orderRepo.EXPECT().FindPaymentByOrderID(gomock.Any(), orderID).Return(&model.Payment{OrderID: orderID}, nil)
+	orderRepo.EXPECT().UpdatePayment(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, p *model.Payment) error {
+		assert.Equal(t, model.PaymentStatusSuccess, p.Status)
+		if assert.NotNil(t, p.PaidAt) {
+			assert.Equal(t, now, *p.PaidAt)
+		}
+		return nil
+	})
+	orderRepo.EXPECT().UpdateStatusFrom(gomock.Any(), orderID, constant.OrderStatusPending, constant.OrderStatusPaid).Return(true, nil)
+	orderRepo.EXPECT().AddStatusHistory(gomock.Any(), gomock.Any()).Return(nil)
+
+	svc := NewOrderService(orderRepo, nil, nil, nil, nil, nil, nil, nil, nil,
+		NewFlatRateShippingCalculator(nil, decimal.Zero), nil, OrderConfig{Clock: clock.NewFake(now)})
+
+	assert.NoError(t, svc.ProcessPaymentResult(context.Background(), orderID, true))
+}
+
+func TestOrderService_RefundOrder_WindowBoundary(t *testing.T) {
+	userID := uuid.New()
+	orderID := uuid.New()
+	const window = 7 * 24 * time.Hour
+	completedAt := time.Date(2026, 3, 1, 10, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name       string
+		now        time.Time
+		wantRefund bool
+	}{
+		{name: "on the last instant of the window", now: completedAt.Add(window), wantRefund: true},
+		{name: "one nanosecond past the window", now: completedAt.Add(window + time.Nanosecond)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			orderRepo := mocks.NewMockOrderRepository(ctrl)
+			orderRepo.EXPECT().FindByID(gomock.Any(), orderID).Return(&model.Order{
+				ID: orderID, UserID: userID, Status: constant.OrderStatusCompleted, UpdatedAt: completedAt,
+			}, nil)
+			orderRepo.EXPECT().FindStatusHistory(gomock.Any(), orderID).Return(nil, nil)
+			if tt.wantRefund {
+				orderRepo.EXPECT().UpdateStatusFrom(gomock.Any(), orderID, constant.OrderStatusCompleted, constant.OrderStatusRefunded).Return(true, nil)
+				orderRepo.EXPECT().AddStatusHistory(gomock.Any(), gomock.Any()).Return(nil)
+			}
+
+			svc := NewOrderService(orderRepo, nil, nil, nil, nil, nil, nil, nil, nil,
+				NewFlatRateShippingCalculator(nil, decimal.Zero), nil, OrderConfig{RefundWindow: window, Clock: clock.NewFake(tt.now)})
+			err := svc.RefundOrder(context.Background(), userID, orderID)
+
+			if tt.wantRefund {
+				assert.NoError(t, err)
+				return
+			}
+			assert.ErrorContains(t, err, "refund window has expired")
+		})
+	}
+}
+
+func TestOrderService_ExpireReservations_UsesClock(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	now := time.Date(2026, 3, 1, 10, 30, 0, 0, time.UTC)
+	orderRepo := mocks.NewMockOrderRepository(ctrl)
+	orderRepo.EXPECT().FindExpiredReservations(gomock.Any(), now, reservationExpiryBatchSize).Return(nil, nil)
+
+	svc := NewOrderService(orderRepo, nil, nil, nil, nil, nil, nil, nil, nil,
+		NewFlatRateShippingCalculator(nil, decimal.Zero), nil, OrderConfig{ReservationTTL: time.Minute, Clock: clock.NewFake(now)})
+
+	expired, err := svc.ExpireReservations(context.Background())
+	assert.NoError(t, err)
+	assert.Zero(t, expired)
+}
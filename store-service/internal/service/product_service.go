@@ -2,9 +2,14 @@ package service
 
 import (
 	"context"
-	"errors"
+	"encoding/csv"
+	"io"
+	"strconv"
+	"strings"
 
 	"github.com/1tsndre/mini-go-project/pkg/logger"
+	"github.com/1tsndre/mini-go-project/pkg/money"
+	"github.com/1tsndre/mini-go-project/store-service/internal/constant"
 	"github.com/1tsndre/mini-go-project/store-service/internal/model"
 	"github.com/1tsndre/mini-go-project/store-service/internal/pagination"
 	"github.com/1tsndre/mini-go-project/store-service/internal/repository"
@@ -15,32 +20,86 @@ import (
 type ProductService interface {
 	CreateProduct(ctx context.Context, userID uuid.UUID, req model.CreateProductRequest) (*model.ProductResponse, error)
 	GetProducts(ctx context.Context, filter model.ProductFilter) ([]model.ProductResponse, int64, error)
+	GetStoreProducts(ctx context.Context, storeID uuid.UUID, filter model.ProductFilter) ([]model.ProductResponse, int64, error)
+	GetSellerProducts(ctx context.Context, userID uuid.UUID, filter model.ProductFilter) ([]model.ProductResponse, int64, error)
 	GetProductByID(ctx context.Context, id uuid.UUID) (*model.ProductResponse, error)
 	UpdateProduct(ctx context.Context, userID uuid.UUID, id uuid.UUID, req model.UpdateProductRequest) (*model.ProductResponse, error)
 	DeleteProduct(ctx context.Context, userID uuid.UUID, id uuid.UUID) error
-	UpdateImage(ctx context.Context, userID uuid.UUID, id uuid.UUID, imageURL string) (*model.ProductResponse, error)
+	UpdateImage(ctx context.Context, userID uuid.UUID, id uuid.UUID, imageURL, thumbnailURL string) (*model.ProductResponse, *model.ProductImage, error)
+	ExportProducts(ctx context.Context, userID uuid.UUID, w io.Writer) error
+	GetProductAttributes(ctx context.Context, id uuid.UUID) (map[string]string, error)
+	SetProductAttributes(ctx context.Context, userID uuid.UUID, id uuid.UUID, attrs map[string]string) (map[string]string, error)
+	GetAlsoBought(ctx context.Context, id uuid.UUID) ([]model.ProductResponse, error)
+	GetRelatedProducts(ctx context.Context, id uuid.UUID) ([]model.ProductResponse, error)
+	ListProductImages(ctx context.Context, id uuid.UUID) ([]model.ProductImage, error)
+	AddProductImage(ctx context.Context, userID uuid.UUID, id uuid.UUID, imageURL, thumbnailURL string) (*model.ProductImage, error)
+	DeleteProductImage(ctx context.Context, userID uuid.UUID, id uuid.UUID, imageID uuid.UUID) (*model.ProductImage, error)
+	ReorderProductImages(ctx context.Context, userID uuid.UUID, id uuid.UUID, imageIDs []string) ([]model.ProductImage, error)
+	GetStockHistory(ctx context.Context, userID uuid.UUID, id uuid.UUID, page, perPage int) ([]model.StockMovement, int64, error)
+}
+
+const productExportBatchSize = 500
+
+var productExportHeader = []string{"sku", "name", "price", "stock", "category"}
+
+// ProductConfig holds the tunable catalog rules for ProductService.
+type ProductConfig struct {
+	// MaxStoreImages caps the total number of product images a single store
+	// may hold, and MaxAttributes the number of attributes per product; zero
+	// disables either cap.
+	MaxStoreImages int
+	MaxAttributes  int
+	// AlsoBoughtLimit is how many products GetAlsoBought returns and
+	// RelatedLimit how many GetRelatedProducts does.
+	AlsoBoughtLimit int
+	RelatedLimit    int
+	// InStockFirst ranks out-of-stock products after in-stock ones in every
+	// listing.
+	InStockFirst bool
+	// DeleteCartPolicy is one of the constant.ProductDelete* policies for
+	// products still in carts; empty means remove them from the carts.
+	DeleteCartPolicy string
 }
 
 type productService struct {
 	productRepo repository.ProductRepository
 	storeRepo   repository.StoreRepository
+	cartRepo    repository.CartRepository
+	stockRepo   repository.StockMovementRepository
+	cfg         ProductConfig
 }
 
-func NewProductService(productRepo repository.ProductRepository, storeRepo repository.StoreRepository) ProductService {
+// NewProductService creates a ProductService. stockRepo records stock
+// changes in the stock ledger; nil disables it.
+func NewProductService(productRepo repository.ProductRepository, storeRepo repository.StoreRepository, cartRepo repository.CartRepository, stockRepo repository.StockMovementRepository, cfg ProductConfig) ProductService {
 	return &productService{
 		productRepo: productRepo,
 		storeRepo:   storeRepo,
+		cartRepo:    cartRepo,
+		stockRepo:   stockRepo,
+		cfg:         cfg,
 	}
 }
 
 func (s *productService) getStoreByOwner(ctx context.Context, userID uuid.UUID) (*model.Store, error) {
 	store, err := s.storeRepo.FindByUserID(ctx, userID)
 	if err != nil {
-		return nil, errors.New("store not found for this user")
+		return nil, newError(ErrNotFound, "store not found for this user")
 	}
 	return store, nil
 }
 
+// parsePrice reads a product price, which must be positive and in whole
+// cents. A value like "1.50" or "1.500" is accepted; "1.505" is not, since
+// it would have to be rounded to be charged.
+func parsePrice(raw string) (decimal.Decimal, error) {
+	price, err := money.Parse(raw, money.Positive)
+	if err != nil || !price.Equal(price.Truncate(money.Scale)) {
+		return decimal.Zero, newError(ErrValidation, "price must be a positive number with at most %d decimals", money.Scale)
+	}
+	return price, nil
+}
+
 func (s *productService) CreateProduct(ctx context.Context, userID uuid.UUID, req model.CreateProductRequest) (*model.ProductResponse, error) {
 	store, err := s.getStoreByOwner(ctx, userID)
 	if err != nil {
@@ -49,26 +108,42 @@ func (s *productService) CreateProduct(ctx context.Context, userID uuid.UUID, re
 
 	categoryID, err := uuid.Parse(req.CategoryID)
 	if err != nil {
-		return nil, errors.New("invalid category_id")
+		return nil, newError(ErrValidation, "invalid category_id")
 	}
 
-	price, err := decimal.NewFromString(req.Price)
+	price, err := parsePrice(req.Price)
 	if err != nil {
-		return nil, errors.New("invalid price")
+		return nil, err
+	}
+
+	if req.LowStockThreshold != nil && *req.LowStockThreshold < 0 {
+		return nil, newError(ErrValidation, "invalid low_stock_threshold")
 	}
 
 	product := &model.Product{
-		StoreID:     store.ID,
-		CategoryID:  categoryID,
-		Name:        req.Name,
-		Description: req.Description,
-		Price:       price,
-		Stock:       req.Stock,
+		StoreID:           store.ID,
+		CategoryID:        categoryID,
+		Name:              req.Name,
+		Description:       req.Description,
+		Price:             price,
+		Stock:             req.Stock,
+		LowStockThreshold: req.LowStockThreshold,
+		AllowBackorder:    req.AllowBackorder,
+	}
+
+	var movements []model.StockMovement
+	if product.Stock != 0 {
+		movements = ledgerEntries(s.stockRepo, model.StockMovement{
+			Reason:     constant.StockReasonCreated,
+			Delta:      product.Stock,
+			StockAfter: product.Stock,
+			ActorID:    &userID,
+		})
 	}
 
-	if err := s.productRepo.Create(ctx, product); err != nil {
+	if err := s.productRepo.Create(ctx, product, movements...); err != nil {
 		logger.Error(ctx, "failed to create product", err)
-		return nil, errors.New("failed to create product")
+		return nil, newError(ErrInternal, "failed to create product")
 	}
 
 	logger.Info(ctx, "product created", map[string]interface{}{
@@ -82,11 +157,19 @@ func (s *productService) CreateProduct(ctx context.Context, userID uuid.UUID, re
 
 func (s *productService) GetProducts(ctx context.Context, filter model.ProductFilter) ([]model.ProductResponse, int64, error) {
 	filter.Page, filter.PerPage = pagination.Normalize(filter.Page, filter.PerPage)
+	filter.InStockFirst = s.cfg.InStockFirst
+
+	// A seller without a store yet has nothing of their own to exclude.
+	if filter.ExcludeOwnerID != uuid.Nil {
+		if store, err := s.storeRepo.FindByUserID(ctx, filter.ExcludeOwnerID); err == nil {
+			filter.ExcludeStoreID = store.ID.String()
+		}
+	}
 
 	products, total, err := s.productRepo.FindAll(ctx, filter)
 	if err != nil {
 		logger.Error(ctx, "failed to fetch products", err)
-		return nil, 0, errors.New("failed to fetch products")
+		return nil, 0, newError(ErrInternal, "failed to fetch products")
 	}
 
 	var responses []model.ProductResponse
@@ -97,10 +180,36 @@ func (s *productService) GetProducts(ctx context.Context, filter model.ProductFi
 	return responses, total, nil
 }
 
+// GetStoreProducts lists a single store's catalog. The store must exist; the
+// filter's StoreID is always overridden with storeID.
+func (s *productService) GetStoreProducts(ctx context.Context, storeID uuid.UUID, filter model.ProductFilter) ([]model.ProductResponse, int64, error) {
+	if _, err := s.storeRepo.FindByID(ctx, storeID); err != nil {
+		return nil, 0, newError(ErrNotFound, "store not found")
+	}
+
+	filter.StoreID = storeID.String()
+	return s.GetProducts(ctx, filter)
+}
+
+// GetSellerProducts lists the caller's own catalog. Unlike the public
+// listings it still shows the products while the store is deactivated.
+func (s *productService) GetSellerProducts(ctx context.Context, userID uuid.UUID, filter model.ProductFilter) ([]model.ProductResponse, int64, error) {
+	store, err := s.getStoreByOwner(ctx, userID)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	filter.StoreID = store.ID.String()
+	filter.IncludeInactiveStores = true
+	return s.GetProducts(ctx, filter)
+}
+
 func (s *productService) GetProductByID(ctx context.Context, id uuid.UUID) (*model.ProductResponse, error) {
+	// An unavailable product is deleted as far as anyone but the carts
+	// holding it is concerned.
 	product, err := s.productRepo.FindByID(ctx, id)
-	if err != nil {
-		return nil, errors.New("product not found")
+	if err != nil || product.Unavailable {
+		return nil, newError(ErrNotFound, "product not found")
 	}
 
 	resp := product.ToResponse()
@@ -114,12 +223,12 @@ func (s *productService) UpdateProduct(ctx context.Context, userID uuid.UUID, id
 	}
 
 	product, err := s.productRepo.FindByID(ctx, id)
-	if err != nil {
-		return nil, errors.New("product not found")
+	if err != nil || product.Unavailable {
+		return nil, newError(ErrNotFound, "product not found")
 	}
 
 	if product.StoreID != store.ID {
-		return nil, errors.New("forbidden: not product owner")
+		return nil, newError(ErrForbidden, "forbidden: not product owner")
 	}
 
 	if req.Name != "" {
@@ -129,26 +238,36 @@ func (s *productService) UpdateProduct(ctx context.Context, userID uuid.UUID, id
 		product.Description = req.Description
 	}
 	if req.Price != "" {
-		price, err := decimal.NewFromString(req.Price)
+		price, err := parsePrice(req.Price)
 		if err != nil {
-			return nil, errors.New("invalid price")
+			return nil, err
 		}
 		product.Price = price
 	}
 	if req.CategoryID != "" {
 		categoryID, err := uuid.Parse(req.CategoryID)
 		if err != nil {
-			return nil, errors.New("invalid category_id")
+			return nil, newError(ErrValidation, "invalid category_id")
 		}
 		product.CategoryID = categoryID
 	}
+	previousStock := product.Stock
 	if req.Stock != nil {
 		product.Stock = *req.Stock
 	}
+	if req.LowStockThreshold != nil {
+		if *req.LowStockThreshold < 0 {
+			return nil, newError(ErrValidation, "invalid low_stock_threshold")
+		}
+		product.LowStockThreshold = req.LowStockThreshold
+	}
+	if req.AllowBackorder != nil {
+		product.AllowBackorder = *req.AllowBackorder
+	}
 
-	if err := s.productRepo.Update(ctx, product); err != nil {
+	if err := s.productRepo.Update(ctx, product, s.adjustment(userID, product, previousStock)...); err != nil {
 		logger.Error(ctx, "failed to update product", err)
-		return nil, errors.New("failed to update product")
+		return nil, newError(ErrInternal, "failed to update product")
 	}
 
 	resp := product.ToResponse()
@@ -163,41 +282,479 @@ func (s *productService) DeleteProduct(ctx context.Context, userID uuid.UUID, id
 
 	product, err := s.productRepo.FindByID(ctx, id)
 	if err != nil {
-		return errors.New("product not found")
+		return newError(ErrNotFound, "product not found")
 	}
 
 	if product.StoreID != store.ID {
-		return errors.New("forbidden: not product owner")
+		return newError(ErrForbidden, "forbidden: not product owner")
+	}
+
+	if s.cfg.DeleteCartPolicy == constant.ProductDeleteMarkUnavailable {
+		inCarts, err := s.cartRepo.ProductInCarts(ctx, id)
+		if err != nil {
+			logger.Error(ctx, "failed to check carts for deleted product", err, map[string]interface{}{
+				"product_id": id.String(),
+			})
+			return newError(ErrInternal, "failed to delete product")
+		}
+		if inCarts {
+			return s.markUnavailable(ctx, userID, product)
+		}
+	} else {
+		carts, err := s.cartRepo.DeleteItemsByProduct(ctx, id)
+		if err != nil {
+			logger.Error(ctx, "failed to remove deleted product from carts", err, map[string]interface{}{
+				"product_id": id.String(),
+			})
+			return newError(ErrInternal, "failed to delete product")
+		}
+		if carts > 0 {
+			logger.Info(ctx, "deleted product removed from carts", map[string]interface{}{
+				"product_id": id.String(),
+				"carts":      carts,
+			})
+		}
 	}
 
 	if err := s.productRepo.Delete(ctx, id); err != nil {
 		logger.Error(ctx, "failed to delete product", err)
-		return errors.New("failed to delete product")
+		return newError(ErrInternal, "failed to delete product")
+	}
+	return nil
+}
+
+// markUnavailable stands in for deleting a product that buyers still have
+// in their carts: it stays, out of stock and without backorders, so the
+// carts show it as unavailable and checkout refuses it, but drops out of
+// listings and search and can no longer be read, edited or added to a cart.
+// The row is kept for good; it is not removed once the last cart drops it.
+func (s *productService) markUnavailable(ctx context.Context, userID uuid.UUID, product *model.Product) error {
+	previousStock := product.Stock
+	product.Stock = 0
+	product.AllowBackorder = false
+	product.Unavailable = true
+	if err := s.productRepo.Update(ctx, product, s.adjustment(userID, product, previousStock)...); err != nil {
+		logger.Error(ctx, "failed to mark product unavailable", err, map[string]interface{}{
+			"product_id": product.ID.String(),
+		})
+		return newError(ErrInternal, "failed to delete product")
 	}
+
+	logger.Info(ctx, "product in carts marked unavailable instead of deleted", map[string]interface{}{
+		"product_id": product.ID.String(),
+	})
 	return nil
 }
 
-func (s *productService) UpdateImage(ctx context.Context, userID uuid.UUID, id uuid.UUID, imageURL string) (*model.ProductResponse, error) {
+// adjustment is the stock ledger entry for a seller's change of product's
+// stock from previousStock. Updates that leave the stock as it was are not
+// recorded.
+func (s *productService) adjustment(userID uuid.UUID, product *model.Product, previousStock int) []model.StockMovement {
+	if product.Stock == previousStock {
+		return nil
+	}
+	return ledgerEntries(s.stockRepo, model.StockMovement{
+		Reason:     constant.StockReasonAdjustment,
+		Delta:      product.Stock - previousStock,
+		StockAfter: product.Stock,
+		ActorID:    &userID,
+	})
+}
+
+// GetStockHistory lists the stock movements of one of the seller's
+// products, newest first. With the ledger disabled it is always empty.
+func (s *productService) GetStockHistory(ctx context.Context, userID uuid.UUID, id uuid.UUID, page, perPage int) ([]model.StockMovement, int64, error) {
 	store, err := s.getStoreByOwner(ctx, userID)
 	if err != nil {
-		return nil, err
+		return nil, 0, err
 	}
 
 	product, err := s.productRepo.FindByID(ctx, id)
 	if err != nil {
-		return nil, errors.New("product not found")
+		return nil, 0, newError(ErrNotFound, "product not found")
 	}
 
 	if product.StoreID != store.ID {
-		return nil, errors.New("forbidden: not product owner")
+		return nil, 0, newError(ErrForbidden, "forbidden: not product owner")
+	}
+
+	if s.stockRepo == nil {
+		return []model.StockMovement{}, 0, nil
 	}
 
-	product.ImageURL = imageURL
-	if err := s.productRepo.Update(ctx, product); err != nil {
+	page, perPage = pagination.Normalize(page, perPage)
+	movements, total, err := s.stockRepo.FindByProductID(ctx, id, page, perPage)
+	if err != nil {
+		logger.Error(ctx, "failed to get stock history", err, map[string]interface{}{
+			"product_id": id.String(),
+		})
+		return nil, 0, newError(ErrInternal, "failed to get stock history")
+	}
+	return movements, total, nil
+}
+
+// UpdateImage sets the product's primary image, replacing the current one
+// if there is any. The replaced image, with its old file paths, is returned
+// so the caller can remove the files once they are no longer referenced.
+func (s *productService) UpdateImage(ctx context.Context, userID uuid.UUID, id uuid.UUID, imageURL, thumbnailURL string) (*model.ProductResponse, *model.ProductImage, error) {
+	product, err := s.getOwnedProduct(ctx, userID, id)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	images, err := s.productRepo.FindImages(ctx, product.ID)
+	if err != nil {
+		logger.Error(ctx, "failed to list product images", err)
+		return nil, nil, newError(ErrInternal, "failed to update product image")
+	}
+
+	var primary, replaced *model.ProductImage
+	for i := range images {
+		if images[i].IsPrimary {
+			primary = &images[i]
+		}
+	}
+
+	if primary != nil {
+		// Replacing an existing image does not grow the store's image count.
+		old := *primary
+		replaced = &old
+		primary.URL, primary.ThumbnailURL = imageURL, thumbnailURL
+		err = s.productRepo.UpdateImage(ctx, product, primary)
+	} else {
+		if err := s.checkImageCap(ctx, product.StoreID); err != nil {
+			return nil, nil, err
+		}
+		err = s.productRepo.AddImage(ctx, product, &model.ProductImage{
+			URL:          imageURL,
+			ThumbnailURL: thumbnailURL,
+			SortOrder:    nextImageSortOrder(images),
+			IsPrimary:    true,
+		})
+	}
+	if err != nil {
 		logger.Error(ctx, "failed to update product image", err)
-		return nil, errors.New("failed to update product image")
+		return nil, nil, newError(ErrInternal, "failed to update product image")
 	}
 
 	resp := product.ToResponse()
-	return &resp, nil
+	return &resp, replaced, nil
+}
+
+func (s *productService) ListProductImages(ctx context.Context, id uuid.UUID) ([]model.ProductImage, error) {
+	if _, err := s.productRepo.FindByID(ctx, id); err != nil {
+		return nil, newError(ErrNotFound, "product not found")
+	}
+
+	images, err := s.productRepo.FindImages(ctx, id)
+	if err != nil {
+		logger.Error(ctx, "failed to list product images", err)
+		return nil, newError(ErrInternal, "failed to list product images")
+	}
+	return images, nil
+}
+
+// AddProductImage appends an image to the seller's product. The first image
+// a product gets becomes its primary image.
+func (s *productService) AddProductImage(ctx context.Context, userID uuid.UUID, id uuid.UUID, imageURL, thumbnailURL string) (*model.ProductImage, error) {
+	product, err := s.getOwnedProduct(ctx, userID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	images, err := s.productRepo.FindImages(ctx, product.ID)
+	if err != nil {
+		logger.Error(ctx, "failed to list product images", err)
+		return nil, newError(ErrInternal, "failed to add product image")
+	}
+
+	if err := s.checkImageCap(ctx, product.StoreID); err != nil {
+		return nil, err
+	}
+
+	image := &model.ProductImage{
+		URL:          imageURL,
+		ThumbnailURL: thumbnailURL,
+		SortOrder:    nextImageSortOrder(images),
+		IsPrimary:    len(images) == 0,
+	}
+	if err := s.productRepo.AddImage(ctx, product, image); err != nil {
+		logger.Error(ctx, "failed to add product image", err)
+		return nil, newError(ErrInternal, "failed to add product image")
+	}
+	return image, nil
+}
+
+// DeleteProductImage removes an image from the seller's product and returns
+// it so its files can be removed. Deleting the primary image promotes the
+// next image in display order.
+func (s *productService) DeleteProductImage(ctx context.Context, userID uuid.UUID, id uuid.UUID, imageID uuid.UUID) (*model.ProductImage, error) {
+	product, err := s.getOwnedProduct(ctx, userID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	images, err := s.productRepo.FindImages(ctx, product.ID)
+	if err != nil {
+		logger.Error(ctx, "failed to list product images", err)
+		return nil, newError(ErrInternal, "failed to delete product image")
+	}
+
+	var target, promoted *model.ProductImage
+	for i := range images {
+		if images[i].ID == imageID {
+			target = &images[i]
+		} else if promoted == nil {
+			promoted = &images[i]
+		}
+	}
+	if target == nil {
+		return nil, newError(ErrNotFound, "image not found")
+	}
+	if !target.IsPrimary {
+		promoted = nil
+	}
+
+	if err := s.productRepo.DeleteImage(ctx, product, target, promoted); err != nil {
+		logger.Error(ctx, "failed to delete product image", err)
+		return nil, newError(ErrInternal, "failed to delete product image")
+	}
+	return target, nil
+}
+
+// ReorderProductImages puts the seller's product images in the order of
+// imageIDs, which must name every image exactly once. The first image
+// becomes the primary one.
+func (s *productService) ReorderProductImages(ctx context.Context, userID uuid.UUID, id uuid.UUID, imageIDs []string) ([]model.ProductImage, error) {
+	product, err := s.getOwnedProduct(ctx, userID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	images, err := s.productRepo.FindImages(ctx, product.ID)
+	if err != nil {
+		logger.Error(ctx, "failed to list product images", err)
+		return nil, newError(ErrInternal, "failed to reorder product images")
+	}
+
+	byID := make(map[uuid.UUID]model.ProductImage, len(images))
+	for _, image := range images {
+		byID[image.ID] = image
+	}
+	if len(imageIDs) != len(images) {
+		return nil, newFieldError(ErrValidation, "image_ids", "image_ids must list every image of the product exactly once")
+	}
+
+	ordered := make([]model.ProductImage, 0, len(imageIDs))
+	for i, raw := range imageIDs {
+		imageID, err := uuid.Parse(raw)
+		if err != nil {
+			return nil, newFieldError(ErrValidation, "image_ids", "invalid image id %q", raw)
+		}
+		image, ok := byID[imageID]
+		if !ok {
+			return nil, newFieldError(ErrValidation, "image_ids", "image_ids must list every image of the product exactly once")
+		}
+		delete(byID, imageID)
+		image.SortOrder = i
+		image.IsPrimary = i == 0
+		ordered = append(ordered, image)
+	}
+
+	if err := s.productRepo.SaveImageOrder(ctx, product, ordered); err != nil {
+		logger.Error(ctx, "failed to reorder product images", err)
+		return nil, newError(ErrInternal, "failed to reorder product images")
+	}
+	return ordered, nil
+}
+
+// getOwnedProduct loads a product of the seller's own store.
+func (s *productService) getOwnedProduct(ctx context.Context, userID uuid.UUID, id uuid.UUID) (*model.Product, error) {
+	store, err := s.getStoreByOwner(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	product, err := s.productRepo.FindByID(ctx, id)
+	if err != nil {
+		return nil, newError(ErrNotFound, "product not found")
+	}
+
+	if product.StoreID != store.ID {
+		return nil, newError(ErrForbidden, "forbidden: not product owner")
+	}
+	return product, nil
+}
+
+func (s *productService) checkImageCap(ctx context.Context, storeID uuid.UUID) error {
+	if s.cfg.MaxStoreImages <= 0 {
+		return nil
+	}
+	count, err := s.productRepo.CountImagesByStore(ctx, storeID)
+	if err != nil {
+		logger.Error(ctx, "failed to count store images", err)
+		return newError(ErrInternal, "failed to update product image")
+	}
+	if count >= int64(s.cfg.MaxStoreImages) {
+		return newError(ErrForbidden, "image storage limit reached for your store")
+	}
+	return nil
+}
+
+func nextImageSortOrder(images []model.ProductImage) int {
+	next := 0
+	for _, image := range images {
+		if image.SortOrder >= next {
+			next = image.SortOrder + 1
+		}
+	}
+	return next
+}
+
+// ExportProducts streams the seller's catalog as CSV, loading products in
+// batches so large catalogs are never held in memory at once. Products have
+// no dedicated SKU column, so the product id is exported as the SKU.
+func (s *productService) ExportProducts(ctx context.Context, userID uuid.UUID, w io.Writer) error {
+	store, err := s.getStoreByOwner(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	cw := csv.NewWriter(w)
+	if err := cw.Write(productExportHeader); err != nil {
+		return newError(ErrInternal, "failed to write export")
+	}
+
+	err = s.productRepo.FindByStoreIDInBatches(ctx, store.ID, productExportBatchSize, func(products []model.Product) error {
+		for _, p := range products {
+			if err := cw.Write([]string{
+				p.ID.String(),
+				p.Name,
+				p.Price.StringFixed(2),
+				strconv.Itoa(p.Stock),
+				p.Category.Name,
+			}); err != nil {
+				return err
+			}
+		}
+		cw.Flush()
+		return cw.Error()
+	})
+	if err != nil {
+		logger.Error(ctx, "failed to export products", err, map[string]interface{}{
+			"store_id": store.ID.String(),
+		})
+		return newError(ErrInternal, "failed to export products")
+	}
+
+	cw.Flush()
+	return cw.Error()
+}
+
+func (s *productService) GetProductAttributes(ctx context.Context, id uuid.UUID) (map[string]string, error) {
+	product, err := s.productRepo.FindByID(ctx, id)
+	if err != nil {
+		return nil, newError(ErrNotFound, "product not found")
+	}
+	return product.AttributeMap(), nil
+}
+
+// SetProductAttributes replaces all attributes of the seller's product.
+// Keys are trimmed and must be unique after trimming.
+func (s *productService) SetProductAttributes(ctx context.Context, userID uuid.UUID, id uuid.UUID, attrs map[string]string) (map[string]string, error) {
+	if s.cfg.MaxAttributes > 0 && len(attrs) > s.cfg.MaxAttributes {
+		return nil, newFieldError(ErrValidation, "attributes", "a product can have at most %d attributes", s.cfg.MaxAttributes)
+	}
+
+	cleaned := make(map[string]string, len(attrs))
+	for key, value := range attrs {
+		key = strings.TrimSpace(key)
+		if key == "" || len(key) > model.MaxAttributeKeyLength {
+			return nil, newFieldError(ErrValidation, "attributes", "attribute keys must be 1-%d characters", model.MaxAttributeKeyLength)
+		}
+		if len(value) > model.MaxAttributeValueLength {
+			return nil, newFieldError(ErrValidation, "attributes", "attribute values must be at most %d characters", model.MaxAttributeValueLength)
+		}
+		if _, dup := cleaned[key]; dup {
+			return nil, newFieldError(ErrValidation, "attributes", "duplicate attribute key %q", key)
+		}
+		cleaned[key] = value
+	}
+
+	store, err := s.getStoreByOwner(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	product, err := s.productRepo.FindByID(ctx, id)
+	if err != nil {
+		return nil, newError(ErrNotFound, "product not found")
+	}
+
+	if product.StoreID != store.ID {
+		return nil, newError(ErrForbidden, "forbidden: not product owner")
+	}
+
+	if err := s.productRepo.SetAttributes(ctx, product, cleaned); err != nil {
+		logger.Error(ctx, "failed to set product attributes", err, map[string]interface{}{
+			"product_id": id.String(),
+		})
+		return nil, newError(ErrInternal, "failed to update product attributes")
+	}
+
+	return product.AttributeMap(), nil
+}
+
+// GetAlsoBought returns the products most often bought together with the
+// given one. A product nobody has bought alongside anything yields an empty
+// list rather than an error.
+func (s *productService) GetAlsoBought(ctx context.Context, id uuid.UUID) ([]model.ProductResponse, error) {
+	if _, err := s.productRepo.FindByID(ctx, id); err != nil {
+		return nil, newError(ErrNotFound, "product not found")
+	}
+
+	products, err := s.productRepo.FindAlsoBought(ctx, id, s.cfg.AlsoBoughtLimit)
+	if err != nil {
+		logger.Error(ctx, "failed to find also-bought products", err, map[string]interface{}{
+			"product_id": id.String(),
+		})
+		return nil, newError(ErrInternal, "failed to fetch recommendations")
+	}
+
+	responses := make([]model.ProductResponse, 0, len(products))
+	for _, p := range products {
+		responses = append(responses, p.ToResponse())
+	}
+	return responses, nil
+}
+
+// GetRelatedProducts returns other products from the same category, best
+// rated first and the newest among equal ratings.
+func (s *productService) GetRelatedProducts(ctx context.Context, id uuid.UUID) ([]model.ProductResponse, error) {
+	product, err := s.productRepo.FindByID(ctx, id)
+	if err != nil {
+		return nil, newError(ErrNotFound, "product not found")
+	}
+
+	products, _, err := s.productRepo.FindAll(ctx, model.ProductFilter{
+		CategoryID:   product.CategoryID.String(),
+		ExcludeID:    id.String(),
+		SortBy:       "rating",
+		SortOrder:    "desc",
+		Page:         1,
+		PerPage:      s.cfg.RelatedLimit,
+		InStockFirst: s.cfg.InStockFirst,
+	})
+	if err != nil {
+		logger.Error(ctx, "failed to find related products", err, map[string]interface{}{
+			"product_id": id.String(),
+		})
+		return nil, newError(ErrInternal, "failed to fetch related products")
+	}
+
+	responses := make([]model.ProductResponse, 0, len(products))
+	for _, p := range products {
+		responses = append(responses, p.ToResponse())
+	}
+	return responses, nil
 }
@@ -1,12 +1,15 @@
 package service
 
 import (
+	"bytes"
 	"context"
 	"errors"
 	"testing"
 
+	"github.com/1tsndre/mini-go-project/store-service/internal/constant"
 	"github.com/1tsndre/mini-go-project/store-service/internal/mocks"
 	"github.com/1tsndre/mini-go-project/store-service/internal/model"
+	"github.com/1tsndre/mini-go-project/store-service/internal/repository"
 	"github.com/google/uuid"
 	"github.com/shopspring/decimal"
 	"github.com/stretchr/testify/assert"
@@ -68,7 +71,21 @@ func TestProductService_CreateProduct(t *testing.T) {
 				storeRepo.EXPECT().FindByUserID(gomock.Any(), userID).Return(&model.Store{ID: storeID, UserID: userID}, nil)
 			},
 			wantErr:     true,
-			errContains: "invalid price",
+			errContains: "price must be a positive number with at most 2 decimals",
+		},
+		{
+			name:   "negative price",
+			userID: userID,
+			req: model.CreateProductRequest{
+				CategoryID: categoryID.String(),
+				Name:       "Laptop",
+				Price:      "-5",
+			},
+			mockSetup: func(prodRepo *mocks.MockProductRepository, storeRepo *mocks.MockStoreRepository) {
+				storeRepo.EXPECT().FindByUserID(gomock.Any(), userID).Return(&model.Store{ID: storeID, UserID: userID}, nil)
+			},
+			wantErr:     true,
+			errContains: "price must be a positive number with at most 2 decimals",
 		},
 		{
 			name:   "invalid category_id",
@@ -95,7 +112,7 @@ func TestProductService_CreateProduct(t *testing.T) {
 			storeRepo := mocks.NewMockStoreRepository(ctrl)
 			tt.mockSetup(prodRepo, storeRepo)
 
-			svc := NewProductService(prodRepo, storeRepo)
+			svc := NewProductService(prodRepo, storeRepo, nil, nil, ProductConfig{})
 			resp, err := svc.CreateProduct(context.Background(), tt.userID, tt.req)
 
 			if tt.wantErr {
@@ -110,6 +127,82 @@ func TestProductService_CreateProduct(t *testing.T) {
 	}
 }
 
+func TestProductService_Price(t *testing.T) {
+	userID := uuid.New()
+	storeID := uuid.New()
+	productID := uuid.New()
+	const priceErr = "price must be a positive number with at most 2 decimals"
+
+	tests := []struct {
+		name      string
+		price     string
+		wantPrice string
+		wantErr   bool
+	}{
+		{name: "negative", price: "-5", wantErr: true},
+		{name: "zero", price: "0", wantErr: true},
+		{name: "over-precise", price: "1.99999999", wantErr: true},
+		{name: "three decimals", price: "1.995", wantErr: true},
+		{name: "not a number", price: "abc", wantErr: true},
+		{name: "whole", price: "20", wantPrice: "20"},
+		{name: "two decimals", price: "19.99", wantPrice: "19.99"},
+		{name: "trailing zeros", price: "1.500", wantPrice: "1.5"},
+	}
+
+	for _, tt := range tests {
+		t.Run("create "+tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			prodRepo := mocks.NewMockProductRepository(ctrl)
+			storeRepo := mocks.NewMockStoreRepository(ctrl)
+			storeRepo.EXPECT().FindByUserID(gomock.Any(), userID).Return(&model.Store{ID: storeID, UserID: userID}, nil)
+			if !tt.wantErr {
+				prodRepo.EXPECT().Create(gomock.Any(), gomock.Any()).Return(nil)
+			}
+
+			svc := NewProductService(prodRepo, storeRepo, nil, nil, ProductConfig{})
+			resp, err := svc.CreateProduct(context.Background(), userID, model.CreateProductRequest{
+				CategoryID: uuid.NewString(),
+				Name:       "Laptop",
+				Price:      tt.price,
+			})
+
+			if tt.wantErr {
+				assert.EqualError(t, err, priceErr)
+				assert.ErrorIs(t, err, ErrValidation)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.wantPrice, resp.Price.String())
+		})
+
+		t.Run("update "+tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			prodRepo := mocks.NewMockProductRepository(ctrl)
+			storeRepo := mocks.NewMockStoreRepository(ctrl)
+			storeRepo.EXPECT().FindByUserID(gomock.Any(), userID).Return(&model.Store{ID: storeID, UserID: userID}, nil)
+			prodRepo.EXPECT().FindByID(gomock.Any(), productID).
+				Return(&model.Product{ID: productID, StoreID: storeID, Price: decimal.NewFromInt(10)}, nil)
+			if !tt.wantErr {
+				prodRepo.EXPECT().Update(gomock.Any(), gomock.Any()).Return(nil)
+			}
+
+			svc := NewProductService(prodRepo, storeRepo, nil, nil, ProductConfig{})
+			resp, err := svc.UpdateProduct(context.Background(), userID, productID, model.UpdateProductRequest{Price: tt.price})
+
+			if tt.wantErr {
+				assert.EqualError(t, err, priceErr)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.wantPrice, resp.Price.String())
+		})
+	}
+}
+
 func TestProductService_GetProducts(t *testing.T) {
 	tests := []struct {
 		name      string
@@ -161,7 +254,7 @@ func TestProductService_GetProducts(t *testing.T) {
 			storeRepo := mocks.NewMockStoreRepository(ctrl)
 			tt.mockSetup(prodRepo)
 
-			svc := NewProductService(prodRepo, storeRepo)
+			svc := NewProductService(prodRepo, storeRepo, nil, nil, ProductConfig{})
 			resp, total, err := svc.GetProducts(context.Background(), tt.filter)
 
 			if tt.wantErr {
@@ -185,7 +278,7 @@ func TestProductService_DeleteProduct(t *testing.T) {
 		name        string
 		userID      uuid.UUID
 		productID   uuid.UUID
-		mockSetup   func(prodRepo *mocks.MockProductRepository, storeRepo *mocks.MockStoreRepository)
+		mockSetup   func(prodRepo *mocks.MockProductRepository, storeRepo *mocks.MockStoreRepository, cartRepo *mocks.MockCartRepository)
 		wantErr     bool
 		errContains string
 	}{
@@ -193,9 +286,10 @@ func TestProductService_DeleteProduct(t *testing.T) {
 			name:      "success",
 			userID:    userID,
 			productID: productID,
-			mockSetup: func(prodRepo *mocks.MockProductRepository, storeRepo *mocks.MockStoreRepository) {
+			mockSetup: func(prodRepo *mocks.MockProductRepository, storeRepo *mocks.MockStoreRepository, cartRepo *mocks.MockCartRepository) {
 				storeRepo.EXPECT().FindByUserID(gomock.Any(), userID).Return(&model.Store{ID: storeID, UserID: userID}, nil)
 				prodRepo.EXPECT().FindByID(gomock.Any(), productID).Return(&model.Product{ID: productID, StoreID: storeID}, nil)
+				cartRepo.EXPECT().DeleteItemsByProduct(gomock.Any(), productID).Return(int64(0), nil)
 				prodRepo.EXPECT().Delete(gomock.Any(), productID).Return(nil)
 			},
 			wantErr: false,
@@ -204,7 +298,7 @@ func TestProductService_DeleteProduct(t *testing.T) {
 			name:      "not product owner",
 			userID:    userID,
 			productID: productID,
-			mockSetup: func(prodRepo *mocks.MockProductRepository, storeRepo *mocks.MockStoreRepository) {
+			mockSetup: func(prodRepo *mocks.MockProductRepository, storeRepo *mocks.MockStoreRepository, cartRepo *mocks.MockCartRepository) {
 				storeRepo.EXPECT().FindByUserID(gomock.Any(), userID).Return(&model.Store{ID: storeID, UserID: userID}, nil)
 				prodRepo.EXPECT().FindByID(gomock.Any(), productID).Return(&model.Product{ID: productID, StoreID: otherStoreID}, nil)
 			},
@@ -215,7 +309,7 @@ func TestProductService_DeleteProduct(t *testing.T) {
 			name:      "product not found",
 			userID:    userID,
 			productID: productID,
-			mockSetup: func(prodRepo *mocks.MockProductRepository, storeRepo *mocks.MockStoreRepository) {
+			mockSetup: func(prodRepo *mocks.MockProductRepository, storeRepo *mocks.MockStoreRepository, cartRepo *mocks.MockCartRepository) {
 				storeRepo.EXPECT().FindByUserID(gomock.Any(), userID).Return(&model.Store{ID: storeID, UserID: userID}, nil)
 				prodRepo.EXPECT().FindByID(gomock.Any(), productID).Return(nil, errors.New("not found"))
 			},
@@ -231,9 +325,10 @@ func TestProductService_DeleteProduct(t *testing.T) {
 
 			prodRepo := mocks.NewMockProductRepository(ctrl)
 			storeRepo := mocks.NewMockStoreRepository(ctrl)
-			tt.mockSetup(prodRepo, storeRepo)
+			cartRepo := mocks.NewMockCartRepository(ctrl)
+			tt.mockSetup(prodRepo, storeRepo, cartRepo)
 
-			svc := NewProductService(prodRepo, storeRepo)
+			svc := NewProductService(prodRepo, storeRepo, cartRepo, nil, ProductConfig{})
 			err := svc.DeleteProduct(context.Background(), tt.userID, tt.productID)
 
 			if tt.wantErr {
@@ -245,3 +340,742 @@ func TestProductService_DeleteProduct(t *testing.T) {
 		})
 	}
 }
+
+func TestProductService_ExportProducts(t *testing.T) {
+	userID := uuid.New()
+	storeID := uuid.New()
+	productID := uuid.New()
+
+	tests := []struct {
+		name        string
+		mockSetup   func(prodRepo *mocks.MockProductRepository, storeRepo *mocks.MockStoreRepository)
+		wantErr     bool
+		errContains string
+		wantCSV     string
+	}{
+		{
+			name: "success",
+			mockSetup: func(prodRepo *mocks.MockProductRepository, storeRepo *mocks.MockStoreRepository) {
+				storeRepo.EXPECT().FindByUserID(gomock.Any(), userID).Return(&model.Store{ID: storeID, UserID: userID}, nil)
+				prodRepo.EXPECT().FindByStoreIDInBatches(gomock.Any(), storeID, gomock.Any(), gomock.Any()).
+					DoAndReturn(func(_ context.Context, _ uuid.UUID, _ int, fn func([]model.Product) error) error {
+						return fn([]model.Product{{
+							ID:       productID,
+							StoreID:  storeID,
+							Name:     "Laptop, 14 inch",
+							Price:    decimal.NewFromFloat(15000000),
+							Stock:    7,
+							Category: model.Category{Name: "Electronics"},
+						}})
+					})
+			},
+			wantCSV: "sku,name,price,stock,category\n" +
+				productID.String() + ",\"Laptop, 14 inch\",15000000.00,7,Electronics\n",
+		},
+		{
+			name: "store not found",
+			mockSetup: func(_ *mocks.MockProductRepository, storeRepo *mocks.MockStoreRepository) {
+				storeRepo.EXPECT().FindByUserID(gomock.Any(), userID).Return(nil, errors.New("not found"))
+			},
+			wantErr:     true,
+			errContains: "store not found",
+		},
+		{
+			name: "db error",
+			mockSetup: func(prodRepo *mocks.MockProductRepository, storeRepo *mocks.MockStoreRepository) {
+				storeRepo.EXPECT().FindByUserID(gomock.Any(), userID).Return(&model.Store{ID: storeID, UserID: userID}, nil)
+				prodRepo.EXPECT().FindByStoreIDInBatches(gomock.Any(), storeID, gomock.Any(), gomock.Any()).Return(errors.New("db error"))
+			},
+			wantErr:     true,
+			errContains: "failed to export products",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			prodRepo := mocks.NewMockProductRepository(ctrl)
+			storeRepo := mocks.NewMockStoreRepository(ctrl)
+			tt.mockSetup(prodRepo, storeRepo)
+
+			svc := NewProductService(prodRepo, storeRepo, nil, nil, ProductConfig{})
+			var buf bytes.Buffer
+			err := svc.ExportProducts(context.Background(), userID, &buf)
+
+			if tt.wantErr {
+				assert.Error(t, err)
+				assert.Contains(t, err.Error(), tt.errContains)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.wantCSV, buf.String())
+		})
+	}
+}
+
+func TestProductService_UpdateImage_StoreImageCap(t *testing.T) {
+	userID := uuid.New()
+	storeID := uuid.New()
+	productID := uuid.New()
+
+	tests := []struct {
+		name        string
+		mockSetup   func(prodRepo *mocks.MockProductRepository)
+		wantErr     bool
+		errContains string
+	}{
+		{
+			name: "under cap",
+			mockSetup: func(prodRepo *mocks.MockProductRepository) {
+				prodRepo.EXPECT().FindImages(gomock.Any(), productID).Return([]model.ProductImage{}, nil)
+				prodRepo.EXPECT().CountImagesByStore(gomock.Any(), storeID).Return(int64(2), nil)
+				prodRepo.EXPECT().AddImage(gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(
+					func(_ context.Context, product *model.Product, image *model.ProductImage) error {
+						assert.True(t, image.IsPrimary)
+						product.ImageURL = image.URL
+						return nil
+					})
+			},
+		},
+		{
+			name: "cap reached",
+			mockSetup: func(prodRepo *mocks.MockProductRepository) {
+				prodRepo.EXPECT().FindImages(gomock.Any(), productID).Return([]model.ProductImage{}, nil)
+				prodRepo.EXPECT().CountImagesByStore(gomock.Any(), storeID).Return(int64(3), nil)
+			},
+			wantErr:     true,
+			errContains: "image storage limit reached for your store",
+		},
+		{
+			name: "replacing existing image ignores cap",
+			mockSetup: func(prodRepo *mocks.MockProductRepository) {
+				prodRepo.EXPECT().FindImages(gomock.Any(), productID).Return([]model.ProductImage{
+					{ID: uuid.New(), ProductID: productID, URL: "products/old.png", IsPrimary: true},
+				}, nil)
+				prodRepo.EXPECT().UpdateImage(gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(
+					func(_ context.Context, product *model.Product, image *model.ProductImage) error {
+						product.ImageURL = image.URL
+						return nil
+					})
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			prodRepo := mocks.NewMockProductRepository(ctrl)
+			storeRepo := mocks.NewMockStoreRepository(ctrl)
+			storeRepo.EXPECT().FindByUserID(gomock.Any(), userID).Return(&model.Store{ID: storeID, UserID: userID}, nil)
+			prodRepo.EXPECT().FindByID(gomock.Any(), productID).Return(&model.Product{ID: productID, StoreID: storeID}, nil)
+			tt.mockSetup(prodRepo)
+
+			svc := NewProductService(prodRepo, storeRepo, nil, nil, ProductConfig{MaxStoreImages: 3})
+			resp, _, err := svc.UpdateImage(context.Background(), userID, productID, "products/new.png", "products/new_thumb.png")
+
+			if tt.wantErr {
+				assert.Error(t, err)
+				assert.Contains(t, err.Error(), tt.errContains)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, "products/new.png", resp.ImageURL)
+		})
+	}
+}
+
+func TestProductService_AddProductImage(t *testing.T) {
+	userID := uuid.New()
+	storeID := uuid.New()
+	productID := uuid.New()
+
+	tests := []struct {
+		name          string
+		existing      []model.ProductImage
+		wantPrimary   bool
+		wantSortOrder int
+	}{
+		{name: "first image becomes primary", existing: []model.ProductImage{}, wantPrimary: true},
+		{
+			name: "later images are appended",
+			existing: []model.ProductImage{
+				{ID: uuid.New(), SortOrder: 0, IsPrimary: true},
+				{ID: uuid.New(), SortOrder: 4},
+			},
+			wantSortOrder: 5,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			prodRepo := mocks.NewMockProductRepository(ctrl)
+			storeRepo := mocks.NewMockStoreRepository(ctrl)
+			storeRepo.EXPECT().FindByUserID(gomock.Any(), userID).Return(&model.Store{ID: storeID, UserID: userID}, nil)
+			prodRepo.EXPECT().FindByID(gomock.Any(), productID).Return(&model.Product{ID: productID, StoreID: storeID}, nil)
+			prodRepo.EXPECT().FindImages(gomock.Any(), productID).Return(tt.existing, nil)
+			prodRepo.EXPECT().AddImage(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil)
+
+			svc := NewProductService(prodRepo, storeRepo, nil, nil, ProductConfig{})
+			image, err := svc.AddProductImage(context.Background(), userID, productID, "products/a.png", "products/a_thumb.png")
+
+			assert.NoError(t, err)
+			assert.Equal(t, "products/a.png", image.URL)
+			assert.Equal(t, "products/a_thumb.png", image.ThumbnailURL)
+			assert.Equal(t, tt.wantPrimary, image.IsPrimary)
+			assert.Equal(t, tt.wantSortOrder, image.SortOrder)
+		})
+	}
+}
+
+func TestProductService_ListProductImages(t *testing.T) {
+	productID := uuid.New()
+
+	t.Run("product not found", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		prodRepo := mocks.NewMockProductRepository(ctrl)
+		prodRepo.EXPECT().FindByID(gomock.Any(), productID).Return(nil, errors.New("record not found"))
+
+		svc := NewProductService(prodRepo, mocks.NewMockStoreRepository(ctrl), nil, nil, ProductConfig{})
+		_, err := svc.ListProductImages(context.Background(), productID)
+
+		assert.ErrorContains(t, err, "product not found")
+	})
+
+	t.Run("images in display order", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		images := []model.ProductImage{
+			{ID: uuid.New(), ProductID: productID, URL: "products/a.png", IsPrimary: true},
+			{ID: uuid.New(), ProductID: productID, URL: "products/b.png", SortOrder: 1},
+		}
+		prodRepo := mocks.NewMockProductRepository(ctrl)
+		prodRepo.EXPECT().FindByID(gomock.Any(), productID).Return(&model.Product{ID: productID}, nil)
+		prodRepo.EXPECT().FindImages(gomock.Any(), productID).Return(images, nil)
+
+		svc := NewProductService(prodRepo, mocks.NewMockStoreRepository(ctrl), nil, nil, ProductConfig{})
+		got, err := svc.ListProductImages(context.Background(), productID)
+
+		assert.NoError(t, err)
+		assert.Equal(t, images, got)
+	})
+}
+
+func TestProductService_DeleteProductImage(t *testing.T) {
+	userID := uuid.New()
+	storeID := uuid.New()
+	productID := uuid.New()
+	primary := model.ProductImage{ID: uuid.New(), URL: "products/a.png", SortOrder: 0, IsPrimary: true}
+	second := model.ProductImage{ID: uuid.New(), URL: "products/b.png", SortOrder: 1}
+	third := model.ProductImage{ID: uuid.New(), URL: "products/c.png", SortOrder: 2}
+
+	tests := []struct {
+		name         string
+		images       []model.ProductImage
+		imageID      uuid.UUID
+		wantPromoted *uuid.UUID
+		wantErr      string
+	}{
+		{
+			name:         "deleting the primary promotes the next image",
+			images:       []model.ProductImage{primary, second, third},
+			imageID:      primary.ID,
+			wantPromoted: &second.ID,
+		},
+		{
+			name:    "deleting another image promotes nothing",
+			images:  []model.ProductImage{primary, second, third},
+			imageID: second.ID,
+		},
+		{
+			name:    "deleting the only image leaves no primary",
+			images:  []model.ProductImage{primary},
+			imageID: primary.ID,
+		},
+		{
+			name:    "unknown image",
+			images:  []model.ProductImage{primary},
+			imageID: uuid.New(),
+			wantErr: "image not found",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			prodRepo := mocks.NewMockProductRepository(ctrl)
+			storeRepo := mocks.NewMockStoreRepository(ctrl)
+			storeRepo.EXPECT().FindByUserID(gomock.Any(), userID).Return(&model.Store{ID: storeID, UserID: userID}, nil)
+			prodRepo.EXPECT().FindByID(gomock.Any(), productID).Return(&model.Product{ID: productID, StoreID: storeID}, nil)
+			prodRepo.EXPECT().FindImages(gomock.Any(), productID).Return(append([]model.ProductImage(nil), tt.images...), nil)
+			if tt.wantErr == "" {
+				prodRepo.EXPECT().DeleteImage(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(
+					func(_ context.Context, _ *model.Product, image *model.ProductImage, promoted *model.ProductImage) error {
+						assert.Equal(t, tt.imageID, image.ID)
+						if tt.wantPromoted == nil {
+							assert.Nil(t, promoted)
+						} else if assert.NotNil(t, promoted) {
+							assert.Equal(t, *tt.wantPromoted, promoted.ID)
+						}
+						return nil
+					})
+			}
+
+			svc := NewProductService(prodRepo, storeRepo, nil, nil, ProductConfig{})
+			deleted, err := svc.DeleteProductImage(context.Background(), userID, productID, tt.imageID)
+
+			if tt.wantErr != "" {
+				assert.ErrorContains(t, err, tt.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.imageID, deleted.ID)
+		})
+	}
+}
+
+func TestProductService_ReorderProductImages(t *testing.T) {
+	userID := uuid.New()
+	storeID := uuid.New()
+	productID := uuid.New()
+	a := model.ProductImage{ID: uuid.New(), SortOrder: 0, IsPrimary: true}
+	b := model.ProductImage{ID: uuid.New(), SortOrder: 1}
+
+	tests := []struct {
+		name     string
+		imageIDs []string
+		wantErr  string
+	}{
+		{name: "new order moves primary", imageIDs: []string{b.ID.String(), a.ID.String()}},
+		{name: "missing image", imageIDs: []string{b.ID.String()}, wantErr: "exactly once"},
+		{name: "duplicate image", imageIDs: []string{b.ID.String(), b.ID.String()}, wantErr: "exactly once"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			prodRepo := mocks.NewMockProductRepository(ctrl)
+			storeRepo := mocks.NewMockStoreRepository(ctrl)
+			storeRepo.EXPECT().FindByUserID(gomock.Any(), userID).Return(&model.Store{ID: storeID, UserID: userID}, nil)
+			prodRepo.EXPECT().FindByID(gomock.Any(), productID).Return(&model.Product{ID: productID, StoreID: storeID}, nil)
+			prodRepo.EXPECT().FindImages(gomock.Any(), productID).Return([]model.ProductImage{a, b}, nil)
+			if tt.wantErr == "" {
+				prodRepo.EXPECT().SaveImageOrder(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil)
+			}
+
+			svc := NewProductService(prodRepo, storeRepo, nil, nil, ProductConfig{})
+			images, err := svc.ReorderProductImages(context.Background(), userID, productID, tt.imageIDs)
+
+			if tt.wantErr != "" {
+				assert.ErrorContains(t, err, tt.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+			if assert.Len(t, images, 2) {
+				assert.Equal(t, b.ID, images[0].ID)
+				assert.True(t, images[0].IsPrimary)
+				assert.Equal(t, 0, images[0].SortOrder)
+				assert.False(t, images[1].IsPrimary)
+				assert.Equal(t, 1, images[1].SortOrder)
+			}
+		})
+	}
+}
+
+func TestProductService_SetProductAttributes(t *testing.T) {
+	userID := uuid.New()
+	storeID := uuid.New()
+	productID := uuid.New()
+
+	tests := []struct {
+		name        string
+		attrs       map[string]string
+		mockSetup   func(prodRepo *mocks.MockProductRepository, storeRepo *mocks.MockStoreRepository)
+		want        map[string]string
+		wantErr     bool
+		errContains string
+	}{
+		{
+			name:  "success trims keys",
+			attrs: map[string]string{" color ": "red", "size": "M"},
+			mockSetup: func(prodRepo *mocks.MockProductRepository, storeRepo *mocks.MockStoreRepository) {
+				storeRepo.EXPECT().FindByUserID(gomock.Any(), userID).Return(&model.Store{ID: storeID, UserID: userID}, nil)
+				prodRepo.EXPECT().FindByID(gomock.Any(), productID).Return(&model.Product{ID: productID, StoreID: storeID}, nil)
+				prodRepo.EXPECT().SetAttributes(gomock.Any(), gomock.Any(), map[string]string{"color": "red", "size": "M"}).
+					DoAndReturn(func(_ context.Context, p *model.Product, attrs map[string]string) error {
+						p.Attributes = []model.ProductAttribute{
+							{ProductID: p.ID, Key: "color", Value: attrs["color"]},
+							{ProductID: p.ID, Key: "size", Value: attrs["size"]},
+						}
+						return nil
+					})
+			},
+			want: map[string]string{"color": "red", "size": "M"},
+		},
+		{
+			name:  "not product owner",
+			attrs: map[string]string{"color": "red"},
+			mockSetup: func(prodRepo *mocks.MockProductRepository, storeRepo *mocks.MockStoreRepository) {
+				storeRepo.EXPECT().FindByUserID(gomock.Any(), userID).Return(&model.Store{ID: storeID, UserID: userID}, nil)
+				prodRepo.EXPECT().FindByID(gomock.Any(), productID).Return(&model.Product{ID: productID, StoreID: uuid.New()}, nil)
+			},
+			wantErr:     true,
+			errContains: "forbidden",
+		},
+		{
+			name:        "too many attributes",
+			attrs:       map[string]string{"a": "1", "b": "2", "c": "3"},
+			mockSetup:   func(*mocks.MockProductRepository, *mocks.MockStoreRepository) {},
+			wantErr:     true,
+			errContains: "at most 2 attributes",
+		},
+		{
+			name:        "blank key",
+			attrs:       map[string]string{"  ": "red"},
+			mockSetup:   func(*mocks.MockProductRepository, *mocks.MockStoreRepository) {},
+			wantErr:     true,
+			errContains: "attribute keys",
+		},
+		{
+			name:  "repository failure",
+			attrs: map[string]string{"color": "red"},
+			mockSetup: func(prodRepo *mocks.MockProductRepository, storeRepo *mocks.MockStoreRepository) {
+				storeRepo.EXPECT().FindByUserID(gomock.Any(), userID).Return(&model.Store{ID: storeID, UserID: userID}, nil)
+				prodRepo.EXPECT().FindByID(gomock.Any(), productID).Return(&model.Product{ID: productID, StoreID: storeID}, nil)
+				prodRepo.EXPECT().SetAttributes(gomock.Any(), gomock.Any(), gomock.Any()).Return(errors.New("db down"))
+			},
+			wantErr:     true,
+			errContains: "failed to update product attributes",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			prodRepo := mocks.NewMockProductRepository(ctrl)
+			storeRepo := mocks.NewMockStoreRepository(ctrl)
+			tt.mockSetup(prodRepo, storeRepo)
+
+			svc := NewProductService(prodRepo, storeRepo, nil, nil, ProductConfig{MaxAttributes: 2})
+			got, err := svc.SetProductAttributes(context.Background(), userID, productID, tt.attrs)
+
+			if tt.wantErr {
+				assert.Error(t, err)
+				assert.Contains(t, err.Error(), tt.errContains)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestProductService_GetProducts_ExcludeOwn(t *testing.T) {
+	sellerID := uuid.New()
+	storeID := uuid.New()
+
+	tests := []struct {
+		name      string
+		mockSetup func(storeRepo *mocks.MockStoreRepository)
+		wantStore string
+	}{
+		{
+			name: "seller's own store is excluded",
+			mockSetup: func(storeRepo *mocks.MockStoreRepository) {
+				storeRepo.EXPECT().FindByUserID(gomock.Any(), sellerID).Return(&model.Store{ID: storeID, UserID: sellerID}, nil)
+			},
+			wantStore: storeID.String(),
+		},
+		{
+			name: "seller without a store excludes nothing",
+			mockSetup: func(storeRepo *mocks.MockStoreRepository) {
+				storeRepo.EXPECT().FindByUserID(gomock.Any(), sellerID).Return(nil, errors.New("not found"))
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			prodRepo := mocks.NewMockProductRepository(ctrl)
+			storeRepo := mocks.NewMockStoreRepository(ctrl)
+			tt.mockSetup(storeRepo)
+			prodRepo.EXPECT().FindAll(gomock.Any(), gomock.Any()).DoAndReturn(
+				func(_ context.Context, filter model.ProductFilter) ([]model.Product, int64, error) {
+					assert.Equal(t, tt.wantStore, filter.ExcludeStoreID)
+					return nil, 0, nil
+				})
+
+			svc := NewProductService(prodRepo, storeRepo, nil, nil, ProductConfig{})
+			_, _, err := svc.GetProducts(context.Background(), model.ProductFilter{ExcludeOwnerID: sellerID, SkipCache: true})
+
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestProductService_GetProducts_InStockFirst(t *testing.T) {
+	for _, inStockFirst := range []bool{false, true} {
+		ctrl := gomock.NewController(t)
+		prodRepo := mocks.NewMockProductRepository(ctrl)
+		prodRepo.EXPECT().FindAll(gomock.Any(), gomock.Any()).DoAndReturn(
+			func(_ context.Context, filter model.ProductFilter) ([]model.Product, int64, error) {
+				assert.Equal(t, inStockFirst, filter.InStockFirst)
+				return nil, 0, nil
+			})
+
+		svc := NewProductService(prodRepo, mocks.NewMockStoreRepository(ctrl), nil, nil, ProductConfig{InStockFirst: inStockFirst})
+		_, _, err := svc.GetProducts(context.Background(), model.ProductFilter{SortBy: "price"})
+
+		assert.NoError(t, err)
+		ctrl.Finish()
+	}
+}
+
+func TestProductService_InactiveStoreVisibility(t *testing.T) {
+	sellerID := uuid.New()
+	storeID := uuid.New()
+
+	t.Run("public listing hides inactive stores", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		prodRepo := mocks.NewMockProductRepository(ctrl)
+		prodRepo.EXPECT().FindAll(gomock.Any(), gomock.Any()).DoAndReturn(
+			func(_ context.Context, filter model.ProductFilter) ([]model.Product, int64, error) {
+				assert.False(t, filter.IncludeInactiveStores)
+				return nil, 0, nil
+			})
+
+		svc := NewProductService(prodRepo, mocks.NewMockStoreRepository(ctrl), nil, nil, ProductConfig{})
+		_, _, err := svc.GetProducts(context.Background(), model.ProductFilter{})
+
+		assert.NoError(t, err)
+	})
+
+	t.Run("seller view shows the own store even when inactive", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		prodRepo := mocks.NewMockProductRepository(ctrl)
+		storeRepo := mocks.NewMockStoreRepository(ctrl)
+		storeRepo.EXPECT().FindByUserID(gomock.Any(), sellerID).Return(&model.Store{ID: storeID, UserID: sellerID}, nil)
+		prodRepo.EXPECT().FindAll(gomock.Any(), gomock.Any()).DoAndReturn(
+			func(_ context.Context, filter model.ProductFilter) ([]model.Product, int64, error) {
+				assert.True(t, filter.IncludeInactiveStores)
+				assert.Equal(t, storeID.String(), filter.StoreID)
+				return []model.Product{{ID: uuid.New(), StoreID: storeID}}, 1, nil
+			})
+
+		svc := NewProductService(prodRepo, storeRepo, nil, nil, ProductConfig{})
+		products, total, err := svc.GetSellerProducts(context.Background(), sellerID, model.ProductFilter{StoreID: uuid.NewString()})
+
+		assert.NoError(t, err)
+		assert.Equal(t, int64(1), total)
+		assert.Len(t, products, 1)
+	})
+}
+
+func TestProductService_GetAlsoBought(t *testing.T) {
+	productID := uuid.New()
+
+	tests := []struct {
+		name      string
+		mockSetup func(prodRepo *mocks.MockProductRepository)
+		wantIDs   []uuid.UUID
+		wantErr   string
+	}{
+		{
+			name: "no co-purchases yields an empty list",
+			mockSetup: func(prodRepo *mocks.MockProductRepository) {
+				prodRepo.EXPECT().FindByID(gomock.Any(), productID).Return(&model.Product{ID: productID}, nil)
+				prodRepo.EXPECT().FindAlsoBought(gomock.Any(), productID, 5).Return([]model.Product{}, nil)
+			},
+			wantIDs: []uuid.UUID{},
+		},
+		{
+			name: "unknown product",
+			mockSetup: func(prodRepo *mocks.MockProductRepository) {
+				prodRepo.EXPECT().FindByID(gomock.Any(), productID).Return(nil, errors.New("record not found"))
+			},
+			wantErr: "product not found",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			prodRepo := mocks.NewMockProductRepository(ctrl)
+			tt.mockSetup(prodRepo)
+
+			svc := NewProductService(prodRepo, mocks.NewMockStoreRepository(ctrl), nil, nil, ProductConfig{AlsoBoughtLimit: 5})
+			got, err := svc.GetAlsoBought(context.Background(), productID)
+
+			if tt.wantErr != "" {
+				assert.EqualError(t, err, tt.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+			ids := []uuid.UUID{}
+			for _, p := range got {
+				ids = append(ids, p.ID)
+			}
+			assert.Equal(t, tt.wantIDs, ids)
+		})
+	}
+}
+
+// memoryCartRepo holds carts in memory and supports what product deletion
+// does to them.
+type memoryCartRepo struct {
+	repository.CartRepository
+	carts map[uuid.UUID]*model.Cart
+}
+
+func (r *memoryCartRepo) GetCart(_ context.Context, userID uuid.UUID) (*model.Cart, error) {
+	if cart, ok := r.carts[userID]; ok {
+		return cart, nil
+	}
+	return &model.Cart{UserID: userID}, nil
+}
+
+func (r *memoryCartRepo) DeleteItemsByProduct(_ context.Context, productID uuid.UUID) (int64, error) {
+	var changed int64
+	for _, cart := range r.carts {
+		kept := cart.Items[:0]
+		for _, item := range cart.Items {
+			if item.ProductID != productID {
+				kept = append(kept, item)
+			}
+		}
+		if len(kept) != len(cart.Items) {
+			cart.Items = kept
+			cart.Version++
+			changed++
+		}
+	}
+	return changed, nil
+}
+
+func (r *memoryCartRepo) ProductInCarts(_ context.Context, productID uuid.UUID) (bool, error) {
+	for _, cart := range r.carts {
+		for _, item := range cart.Items {
+			if item.ProductID == productID {
+				return true, nil
+			}
+		}
+	}
+	return false, nil
+}
+
+func TestProductService_DeleteProduct_InBuyerCart(t *testing.T) {
+	sellerID := uuid.New()
+	buyerID := uuid.New()
+	storeID := uuid.New()
+	product := &model.Product{ID: uuid.New(), StoreID: storeID, Stock: 5, AllowBackorder: true}
+	otherID := uuid.New()
+
+	newCarts := func() *memoryCartRepo {
+		return &memoryCartRepo{carts: map[uuid.UUID]*model.Cart{
+			buyerID: {UserID: buyerID, Items: []model.CartItem{
+				{ProductID: product.ID, Price: decimal.NewFromInt(100), Quantity: 1},
+				{ProductID: otherID, Price: decimal.NewFromInt(50), Quantity: 2},
+			}},
+		}}
+	}
+
+	t.Run("remove", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		prodRepo := mocks.NewMockProductRepository(ctrl)
+		storeRepo := mocks.NewMockStoreRepository(ctrl)
+		storeRepo.EXPECT().FindByUserID(gomock.Any(), sellerID).Return(&model.Store{ID: storeID, UserID: sellerID}, nil)
+		prodRepo.EXPECT().FindByID(gomock.Any(), product.ID).Return(product, nil)
+		prodRepo.EXPECT().Delete(gomock.Any(), product.ID).Return(nil)
+
+		carts := newCarts()
+		svc := NewProductService(prodRepo, storeRepo, carts, nil, ProductConfig{DeleteCartPolicy: constant.ProductDeleteRemoveFromCarts})
+		assert.NoError(t, svc.DeleteProduct(context.Background(), sellerID, product.ID))
+
+		cart, err := NewCartService(carts, prodRepo, nil, CartConfig{}).GetCart(context.Background(), buyerID)
+		assert.NoError(t, err)
+		if assert.Len(t, cart.Items, 1) {
+			assert.Equal(t, otherID, cart.Items[0].ProductID)
+		}
+		assert.True(t, decimal.NewFromInt(100).Equal(cart.Total))
+	})
+
+	t.Run("mark unavailable", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		prodRepo := mocks.NewMockProductRepository(ctrl)
+		storeRepo := mocks.NewMockStoreRepository(ctrl)
+		storeRepo.EXPECT().FindByUserID(gomock.Any(), sellerID).Return(&model.Store{ID: storeID, UserID: sellerID}, nil)
+		prodRepo.EXPECT().FindByID(gomock.Any(), product.ID).Return(product, nil)
+		prodRepo.EXPECT().Update(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, p *model.Product, _ ...model.StockMovement) error {
+			assert.Equal(t, 0, p.Stock)
+			assert.False(t, p.AllowBackorder)
+			assert.True(t, p.Unavailable)
+			return nil
+		})
+
+		carts := newCarts()
+		svc := NewProductService(prodRepo, storeRepo, carts, nil, ProductConfig{DeleteCartPolicy: constant.ProductDeleteMarkUnavailable})
+		assert.NoError(t, svc.DeleteProduct(context.Background(), sellerID, product.ID))
+		assert.Len(t, carts.carts[buyerID].Items, 2)
+	})
+}
+
+func TestProductService_UnavailableProduct(t *testing.T) {
+	sellerID := uuid.New()
+	storeID := uuid.New()
+	product := &model.Product{ID: uuid.New(), StoreID: storeID, Unavailable: true}
+	stock := 5
+
+	t.Run("get", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		prodRepo := mocks.NewMockProductRepository(ctrl)
+		prodRepo.EXPECT().FindByID(gomock.Any(), product.ID).Return(product, nil)
+
+		svc := NewProductService(prodRepo, nil, nil, nil, ProductConfig{DeleteCartPolicy: constant.ProductDeleteMarkUnavailable})
+		_, err := svc.GetProductByID(context.Background(), product.ID)
+		assert.ErrorIs(t, err, ErrNotFound)
+	})
+
+	t.Run("update cannot restock it", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		prodRepo := mocks.NewMockProductRepository(ctrl)
+		storeRepo := mocks.NewMockStoreRepository(ctrl)
+		storeRepo.EXPECT().FindByUserID(gomock.Any(), sellerID).Return(&model.Store{ID: storeID, UserID: sellerID}, nil)
+		prodRepo.EXPECT().FindByID(gomock.Any(), product.ID).Return(product, nil)
+
+		svc := NewProductService(prodRepo, storeRepo, nil, nil, ProductConfig{DeleteCartPolicy: constant.ProductDeleteMarkUnavailable})
+		_, err := svc.UpdateProduct(context.Background(), sellerID, product.ID, model.UpdateProductRequest{Stock: &stock})
+		assert.ErrorIs(t, err, ErrNotFound)
+	})
+}
@@ -3,25 +3,36 @@ package service
 import (
 	"context"
 	"errors"
+	"strings"
 
 	"github.com/1tsndre/mini-go-project/pkg/logger"
+	"github.com/1tsndre/mini-go-project/store-service/internal/constant"
 	"github.com/1tsndre/mini-go-project/store-service/internal/model"
 	"github.com/1tsndre/mini-go-project/store-service/internal/pagination"
 	"github.com/1tsndre/mini-go-project/store-service/internal/repository"
 	"github.com/google/uuid"
+	"gorm.io/gorm"
 )
 
 type ReviewService interface {
 	CreateReview(ctx context.Context, userID uuid.UUID, productID uuid.UUID, req model.CreateReviewRequest) (*model.ReviewResponse, error)
-	GetProductReviews(ctx context.Context, productID uuid.UUID, page, perPage int) ([]model.ReviewResponse, int64, error)
+	UpdateReview(ctx context.Context, userID uuid.UUID, productID uuid.UUID, req model.UpdateReviewRequest) (*model.ReviewResponse, error)
+	DeleteReview(ctx context.Context, callerID uuid.UUID, callerRole string, reviewID uuid.UUID) error
+	GetProductReviews(ctx context.Context, productID uuid.UUID, filter model.ReviewFilter) ([]model.ReviewResponse, int64, error)
+	AddHelpfulVote(ctx context.Context, userID, reviewID uuid.UUID) (*model.HelpfulVoteResponse, error)
+	RemoveHelpfulVote(ctx context.Context, userID, reviewID uuid.UUID) (*model.HelpfulVoteResponse, error)
+	ReplyToReview(ctx context.Context, sellerID, reviewID uuid.UUID, req model.ReplyReviewRequest) (*model.ReviewReplyResponse, error)
 }
 
 type reviewService struct {
 	repo repository.ReviewRepository
+	// allowRepeatPurchase lets a buyer add another review for each further
+	// purchase of a product instead of one review per product.
+	allowRepeatPurchase bool
 }
 
-func NewReviewService(repo repository.ReviewRepository) ReviewService {
-	return &reviewService{repo: repo}
+func NewReviewService(repo repository.ReviewRepository, allowRepeatPurchase bool) ReviewService {
+	return &reviewService{repo: repo, allowRepeatPurchase: allowRepeatPurchase}
 }
 
 func (s *reviewService) CreateReview(ctx context.Context, userID uuid.UUID, productID uuid.UUID, req model.CreateReviewRequest) (*model.ReviewResponse, error) {
@@ -37,19 +48,27 @@ func (s *reviewService) CreateReview(ctx context.Context, userID uuid.UUID, prod
 		return nil, errors.New("you must purchase this product before reviewing")
 	}
 
-	reviewed, err := s.repo.HasUserReviewed(ctx, userID, productID)
+	// Sellers can buy from their own store, so a purchase alone does not
+	// stop them from inflating their own ratings.
+	ownerID, err := s.repo.FindProductOwnerID(ctx, productID)
 	if err != nil {
-		return nil, errors.New("failed to check existing review")
+		return nil, errors.New("failed to verify product owner")
 	}
-	if reviewed {
-		return nil, errors.New("you have already reviewed this product")
+	if ownerID == userID {
+		return nil, errors.New("you cannot review your own product")
+	}
+
+	seq, err := s.nextPurchaseSeq(ctx, userID, productID)
+	if err != nil {
+		return nil, err
 	}
 
 	review := &model.Review{
-		UserID:    userID,
-		ProductID: productID,
-		Rating:    req.Rating,
-		Comment:   req.Comment,
+		UserID:      userID,
+		ProductID:   productID,
+		Rating:      req.Rating,
+		Comment:     req.Comment,
+		PurchaseSeq: seq,
 	}
 
 	if err := s.repo.Create(ctx, review); err != nil {
@@ -61,10 +80,97 @@ func (s *reviewService) CreateReview(ctx context.Context, userID uuid.UUID, prod
 	return &resp, nil
 }
 
-func (s *reviewService) GetProductReviews(ctx context.Context, productID uuid.UUID, page, perPage int) ([]model.ReviewResponse, int64, error) {
-	page, perPage = pagination.Normalize(page, perPage)
+// nextPurchaseSeq returns the PurchaseSeq for a new review by the user, or an
+// error if every purchase the user may review has been reviewed already.
+func (s *reviewService) nextPurchaseSeq(ctx context.Context, userID, productID uuid.UUID) (int, error) {
+	if !s.allowRepeatPurchase {
+		reviewed, err := s.repo.HasUserReviewed(ctx, userID, productID)
+		if err != nil {
+			return 0, errors.New("failed to check existing review")
+		}
+		if reviewed {
+			return 0, errors.New("you have already reviewed this product")
+		}
+		return 1, nil
+	}
+
+	latest, err := s.repo.LatestPurchaseSeq(ctx, userID, productID)
+	if err != nil {
+		return 0, errors.New("failed to check existing review")
+	}
+	purchases, err := s.repo.CountUserPurchases(ctx, userID, productID)
+	if err != nil {
+		return 0, errors.New("failed to verify purchase")
+	}
+	if int64(latest) >= purchases {
+		return 0, errors.New("you have already reviewed this product; purchase it again to add another review")
+	}
+	return latest + 1, nil
+}
+
+// UpdateReview changes the rating and comment of the caller's latest review
+// of a product. The purchase requirement still applies.
+func (s *reviewService) UpdateReview(ctx context.Context, userID uuid.UUID, productID uuid.UUID, req model.UpdateReviewRequest) (*model.ReviewResponse, error) {
+	if req.Rating < 1 || req.Rating > 5 {
+		return nil, errors.New("rating must be between 1 and 5")
+	}
+
+	purchased, err := s.repo.HasUserPurchased(ctx, userID, productID)
+	if err != nil {
+		return nil, errors.New("failed to verify purchase")
+	}
+	if !purchased {
+		return nil, errors.New("you must purchase this product before reviewing")
+	}
+
+	review, err := s.repo.FindByUserAndProduct(ctx, userID, productID)
+	if err != nil {
+		return nil, errors.New("review not found, create one first")
+	}
+	if review.UserID != userID {
+		return nil, errors.New("forbidden: not review owner")
+	}
+
+	review.Rating = req.Rating
+	review.Comment = req.Comment
+	if err := s.repo.Update(ctx, review); err != nil {
+		logger.Error(ctx, "failed to update review", err)
+		return nil, errors.New("failed to update review")
+	}
 
-	reviews, total, err := s.repo.FindByProductID(ctx, productID, page, perPage)
+	resp := review.ToResponse()
+	return &resp, nil
+}
+
+// DeleteReview removes a review. Only its author or an admin may delete it.
+// Product ratings are not cached anywhere yet, so there is nothing to
+// invalidate after the row is gone.
+func (s *reviewService) DeleteReview(ctx context.Context, callerID uuid.UUID, callerRole string, reviewID uuid.UUID) error {
+	review, err := s.repo.FindByID(ctx, reviewID)
+	if err != nil {
+		return errors.New("review not found")
+	}
+	if review.UserID != callerID && callerRole != constant.RoleAdmin {
+		return errors.New("forbidden: not review owner")
+	}
+
+	if err := s.repo.Delete(ctx, reviewID); err != nil {
+		logger.Error(ctx, "failed to delete review", err, map[string]interface{}{
+			"review_id": reviewID.String(),
+		})
+		return errors.New("failed to delete review")
+	}
+
+	return nil
+}
+
+func (s *reviewService) GetProductReviews(ctx context.Context, productID uuid.UUID, filter model.ReviewFilter) ([]model.ReviewResponse, int64, error) {
+	if filter.Rating < 0 || filter.Rating > 5 {
+		return nil, 0, errors.New("rating must be between 1 and 5")
+	}
+	filter.Page, filter.PerPage = pagination.Normalize(filter.Page, filter.PerPage)
+
+	reviews, total, err := s.repo.FindByProductID(ctx, productID, filter)
 	if err != nil {
 		return nil, 0, errors.New("failed to fetch reviews")
 	}
@@ -78,3 +184,85 @@ func (s *reviewService) GetProductReviews(ctx context.Context, productID uuid.UU
 
 	return responses, total, nil
 }
+
+// AddHelpfulVote marks a review as helpful for userID and returns its new
+// vote count. Voting twice counts once.
+func (s *reviewService) AddHelpfulVote(ctx context.Context, userID, reviewID uuid.UUID) (*model.HelpfulVoteResponse, error) {
+	if _, err := s.repo.FindByID(ctx, reviewID); err != nil {
+		return nil, errors.New("review not found")
+	}
+	if err := s.repo.AddVote(ctx, reviewID, userID); err != nil {
+		logger.Error(ctx, "failed to add helpful vote", err, map[string]interface{}{
+			"review_id": reviewID.String(),
+		})
+		return nil, errors.New("failed to vote on review")
+	}
+	return s.helpfulCount(ctx, reviewID)
+}
+
+// RemoveHelpfulVote withdraws userID's helpful vote, if any, and returns the
+// review's new vote count.
+func (s *reviewService) RemoveHelpfulVote(ctx context.Context, userID, reviewID uuid.UUID) (*model.HelpfulVoteResponse, error) {
+	if _, err := s.repo.FindByID(ctx, reviewID); err != nil {
+		return nil, errors.New("review not found")
+	}
+	if err := s.repo.RemoveVote(ctx, reviewID, userID); err != nil {
+		logger.Error(ctx, "failed to remove helpful vote", err, map[string]interface{}{
+			"review_id": reviewID.String(),
+		})
+		return nil, errors.New("failed to remove vote")
+	}
+	return s.helpfulCount(ctx, reviewID)
+}
+
+func (s *reviewService) helpfulCount(ctx context.Context, reviewID uuid.UUID) (*model.HelpfulVoteResponse, error) {
+	count, err := s.repo.CountVotes(ctx, reviewID)
+	if err != nil {
+		return nil, errors.New("failed to count votes")
+	}
+	return &model.HelpfulVoteResponse{ReviewID: reviewID, HelpfulCount: count}, nil
+}
+
+// ReplyToReview answers a review on behalf of the store selling the reviewed
+// product. Only that store's owner may reply, which is checked by walking
+// from the review to its product's store.
+func (s *reviewService) ReplyToReview(ctx context.Context, sellerID, reviewID uuid.UUID, req model.ReplyReviewRequest) (*model.ReviewReplyResponse, error) {
+	comment := strings.TrimSpace(req.Comment)
+	if comment == "" {
+		return nil, errors.New("comment is required")
+	}
+
+	review, err := s.repo.FindByID(ctx, reviewID)
+	if err != nil {
+		return nil, errors.New("review not found")
+	}
+
+	store, err := s.repo.FindProductStore(ctx, review.ProductID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, errors.New("forbidden: you can only reply to reviews of your own products")
+	}
+	if err != nil {
+		logger.Error(ctx, "failed to look up reviewed product's store", err, map[string]interface{}{
+			"review_id": reviewID.String(),
+		})
+		return nil, errors.New("failed to verify product ownership")
+	}
+	if store.UserID != sellerID {
+		return nil, errors.New("forbidden: you can only reply to reviews of your own products")
+	}
+
+	reply := &model.ReviewReply{
+		ReviewID: reviewID,
+		StoreID:  store.ID,
+		Comment:  comment,
+	}
+	if err := s.repo.SaveReply(ctx, reply); err != nil {
+		logger.Error(ctx, "failed to save review reply", err, map[string]interface{}{
+			"review_id": reviewID.String(),
+		})
+		return nil, errors.New("failed to reply to review")
+	}
+
+	resp := reply.ToResponse()
+	return &resp, nil
+}
@@ -5,16 +5,19 @@ import (
 	"errors"
 	"testing"
 
+	"github.com/1tsndre/mini-go-project/store-service/internal/constant"
 	"github.com/1tsndre/mini-go-project/store-service/internal/mocks"
 	"github.com/1tsndre/mini-go-project/store-service/internal/model"
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
 	"go.uber.org/mock/gomock"
+	"gorm.io/gorm"
 )
 
 func TestReviewService_CreateReview(t *testing.T) {
 	userID := uuid.New()
 	productID := uuid.New()
+	sellerID := uuid.New()
 
 	tests := []struct {
 		name        string
@@ -32,6 +35,7 @@ func TestReviewService_CreateReview(t *testing.T) {
 			req:       model.CreateReviewRequest{Rating: 5, Comment: "Great product"},
 			mockSetup: func(repo *mocks.MockReviewRepository) {
 				repo.EXPECT().HasUserPurchased(gomock.Any(), userID, productID).Return(true, nil)
+				repo.EXPECT().FindProductOwnerID(gomock.Any(), productID).Return(sellerID, nil)
 				repo.EXPECT().HasUserReviewed(gomock.Any(), userID, productID).Return(false, nil)
 				repo.EXPECT().Create(gomock.Any(), gomock.Any()).Return(nil)
 			},
@@ -66,6 +70,30 @@ func TestReviewService_CreateReview(t *testing.T) {
 			wantErr:     true,
 			errContains: "you must purchase this product",
 		},
+		{
+			name:      "store owner reviewing own product",
+			userID:    sellerID,
+			productID: productID,
+			req:       model.CreateReviewRequest{Rating: 5, Comment: "Best product ever"},
+			mockSetup: func(repo *mocks.MockReviewRepository) {
+				repo.EXPECT().HasUserPurchased(gomock.Any(), sellerID, productID).Return(true, nil)
+				repo.EXPECT().FindProductOwnerID(gomock.Any(), productID).Return(sellerID, nil)
+			},
+			wantErr:     true,
+			errContains: "you cannot review your own product",
+		},
+		{
+			name:      "owner lookup fails",
+			userID:    userID,
+			productID: productID,
+			req:       model.CreateReviewRequest{Rating: 4, Comment: "Good"},
+			mockSetup: func(repo *mocks.MockReviewRepository) {
+				repo.EXPECT().HasUserPurchased(gomock.Any(), userID, productID).Return(true, nil)
+				repo.EXPECT().FindProductOwnerID(gomock.Any(), productID).Return(uuid.Nil, errors.New("db error"))
+			},
+			wantErr:     true,
+			errContains: "failed to verify product owner",
+		},
 		{
 			name:      "user already reviewed",
 			userID:    userID,
@@ -73,6 +101,7 @@ func TestReviewService_CreateReview(t *testing.T) {
 			req:       model.CreateReviewRequest{Rating: 3, Comment: "Okay"},
 			mockSetup: func(repo *mocks.MockReviewRepository) {
 				repo.EXPECT().HasUserPurchased(gomock.Any(), userID, productID).Return(true, nil)
+				repo.EXPECT().FindProductOwnerID(gomock.Any(), productID).Return(sellerID, nil)
 				repo.EXPECT().HasUserReviewed(gomock.Any(), userID, productID).Return(true, nil)
 			},
 			wantErr:     true,
@@ -85,6 +114,7 @@ func TestReviewService_CreateReview(t *testing.T) {
 			req:       model.CreateReviewRequest{Rating: 5, Comment: "Great"},
 			mockSetup: func(repo *mocks.MockReviewRepository) {
 				repo.EXPECT().HasUserPurchased(gomock.Any(), userID, productID).Return(true, nil)
+				repo.EXPECT().FindProductOwnerID(gomock.Any(), productID).Return(sellerID, nil)
 				repo.EXPECT().HasUserReviewed(gomock.Any(), userID, productID).Return(false, nil)
 				repo.EXPECT().Create(gomock.Any(), gomock.Any()).Return(errors.New("db error"))
 			},
@@ -101,7 +131,7 @@ func TestReviewService_CreateReview(t *testing.T) {
 			repo := mocks.NewMockReviewRepository(ctrl)
 			tt.mockSetup(repo)
 
-			svc := NewReviewService(repo)
+			svc := NewReviewService(repo, false)
 			resp, err := svc.CreateReview(context.Background(), tt.userID, tt.productID, tt.req)
 
 			if tt.wantErr {
@@ -115,3 +145,408 @@ func TestReviewService_CreateReview(t *testing.T) {
 		})
 	}
 }
+
+func TestReviewService_UpdateReview(t *testing.T) {
+	userID := uuid.New()
+	productID := uuid.New()
+	reviewID := uuid.New()
+
+	tests := []struct {
+		name        string
+		req         model.UpdateReviewRequest
+		mockSetup   func(repo *mocks.MockReviewRepository)
+		wantErr     bool
+		errContains string
+	}{
+		{
+			name: "update rating from 2 to 5",
+			req:  model.UpdateReviewRequest{Rating: 5, Comment: "Better than I first thought"},
+			mockSetup: func(repo *mocks.MockReviewRepository) {
+				repo.EXPECT().HasUserPurchased(gomock.Any(), userID, productID).Return(true, nil)
+				repo.EXPECT().FindByUserAndProduct(gomock.Any(), userID, productID).Return(&model.Review{
+					ID:        reviewID,
+					UserID:    userID,
+					ProductID: productID,
+					Rating:    2,
+					Comment:   "Meh",
+				}, nil)
+				repo.EXPECT().Update(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, review *model.Review) error {
+					assert.Equal(t, reviewID, review.ID)
+					assert.Equal(t, 5, review.Rating)
+					assert.Equal(t, "Better than I first thought", review.Comment)
+					return nil
+				})
+			},
+		},
+		{
+			name:        "rating out of range",
+			req:         model.UpdateReviewRequest{Rating: 6},
+			mockSetup:   func(repo *mocks.MockReviewRepository) {},
+			wantErr:     true,
+			errContains: "rating must be between 1 and 5",
+		},
+		{
+			name: "user has not purchased product",
+			req:  model.UpdateReviewRequest{Rating: 5},
+			mockSetup: func(repo *mocks.MockReviewRepository) {
+				repo.EXPECT().HasUserPurchased(gomock.Any(), userID, productID).Return(false, nil)
+			},
+			wantErr:     true,
+			errContains: "must purchase",
+		},
+		{
+			name: "no existing review",
+			req:  model.UpdateReviewRequest{Rating: 5},
+			mockSetup: func(repo *mocks.MockReviewRepository) {
+				repo.EXPECT().HasUserPurchased(gomock.Any(), userID, productID).Return(true, nil)
+				repo.EXPECT().FindByUserAndProduct(gomock.Any(), userID, productID).Return(nil, errors.New("record not found"))
+			},
+			wantErr:     true,
+			errContains: "review not found",
+		},
+		{
+			name: "update fails",
+			req:  model.UpdateReviewRequest{Rating: 5},
+			mockSetup: func(repo *mocks.MockReviewRepository) {
+				repo.EXPECT().HasUserPurchased(gomock.Any(), userID, productID).Return(true, nil)
+				repo.EXPECT().FindByUserAndProduct(gomock.Any(), userID, productID).Return(&model.Review{
+					ID: reviewID, UserID: userID, ProductID: productID, Rating: 2,
+				}, nil)
+				repo.EXPECT().Update(gomock.Any(), gomock.Any()).Return(errors.New("db error"))
+			},
+			wantErr:     true,
+			errContains: "failed to update review",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			repo := mocks.NewMockReviewRepository(ctrl)
+			tt.mockSetup(repo)
+
+			svc := NewReviewService(repo, false)
+			resp, err := svc.UpdateReview(context.Background(), userID, productID, tt.req)
+
+			if tt.wantErr {
+				assert.Error(t, err)
+				assert.Contains(t, err.Error(), tt.errContains)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, 5, resp.Rating)
+			assert.Equal(t, reviewID, resp.ID)
+		})
+	}
+}
+
+func TestReviewService_DeleteReview(t *testing.T) {
+	authorID := uuid.New()
+	otherID := uuid.New()
+	reviewID := uuid.New()
+
+	review := func() *model.Review {
+		return &model.Review{ID: reviewID, UserID: authorID, ProductID: uuid.New(), Rating: 4}
+	}
+
+	tests := []struct {
+		name        string
+		callerID    uuid.UUID
+		callerRole  string
+		mockSetup   func(repo *mocks.MockReviewRepository)
+		wantErr     bool
+		errContains string
+	}{
+		{
+			name:       "author deletes own review",
+			callerID:   authorID,
+			callerRole: constant.RoleBuyer,
+			mockSetup: func(repo *mocks.MockReviewRepository) {
+				repo.EXPECT().FindByID(gomock.Any(), reviewID).Return(review(), nil)
+				repo.EXPECT().Delete(gomock.Any(), reviewID).Return(nil)
+			},
+		},
+		{
+			name:       "admin deletes someone else's review",
+			callerID:   otherID,
+			callerRole: constant.RoleAdmin,
+			mockSetup: func(repo *mocks.MockReviewRepository) {
+				repo.EXPECT().FindByID(gomock.Any(), reviewID).Return(review(), nil)
+				repo.EXPECT().Delete(gomock.Any(), reviewID).Return(nil)
+			},
+		},
+		{
+			name:       "other user is forbidden",
+			callerID:   otherID,
+			callerRole: constant.RoleBuyer,
+			mockSetup: func(repo *mocks.MockReviewRepository) {
+				repo.EXPECT().FindByID(gomock.Any(), reviewID).Return(review(), nil)
+			},
+			wantErr:     true,
+			errContains: "forbidden",
+		},
+		{
+			name:       "review not found",
+			callerID:   authorID,
+			callerRole: constant.RoleBuyer,
+			mockSetup: func(repo *mocks.MockReviewRepository) {
+				repo.EXPECT().FindByID(gomock.Any(), reviewID).Return(nil, errors.New("record not found"))
+			},
+			wantErr:     true,
+			errContains: "review not found",
+		},
+		{
+			name:       "delete fails",
+			callerID:   authorID,
+			callerRole: constant.RoleBuyer,
+			mockSetup: func(repo *mocks.MockReviewRepository) {
+				repo.EXPECT().FindByID(gomock.Any(), reviewID).Return(review(), nil)
+				repo.EXPECT().Delete(gomock.Any(), reviewID).Return(errors.New("db error"))
+			},
+			wantErr:     true,
+			errContains: "failed to delete review",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			repo := mocks.NewMockReviewRepository(ctrl)
+			tt.mockSetup(repo)
+
+			svc := NewReviewService(repo, false)
+			err := svc.DeleteReview(context.Background(), tt.callerID, tt.callerRole, reviewID)
+
+			if tt.wantErr {
+				assert.Error(t, err)
+				assert.Contains(t, err.Error(), tt.errContains)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestReviewService_CreateReview_RepeatPurchase(t *testing.T) {
+	userID := uuid.New()
+	productID := uuid.New()
+	sellerID := uuid.New()
+
+	tests := []struct {
+		name        string
+		latestSeq   int
+		purchases   int64
+		wantSeq     int
+		errContains string
+	}{
+		{name: "first review", latestSeq: 0, purchases: 1, wantSeq: 1},
+		{name: "second purchase allows a new review", latestSeq: 1, purchases: 2, wantSeq: 2},
+		{name: "every purchase already reviewed", latestSeq: 2, purchases: 2, errContains: "purchase it again"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			repo := mocks.NewMockReviewRepository(ctrl)
+			repo.EXPECT().HasUserPurchased(gomock.Any(), userID, productID).Return(true, nil)
+			repo.EXPECT().FindProductOwnerID(gomock.Any(), productID).Return(sellerID, nil)
+			repo.EXPECT().LatestPurchaseSeq(gomock.Any(), userID, productID).Return(tt.latestSeq, nil)
+			repo.EXPECT().CountUserPurchases(gomock.Any(), userID, productID).Return(tt.purchases, nil)
+			if tt.errContains == "" {
+				repo.EXPECT().Create(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, review *model.Review) error {
+					assert.Equal(t, tt.wantSeq, review.PurchaseSeq)
+					return nil
+				})
+			}
+
+			svc := NewReviewService(repo, true)
+			resp, err := svc.CreateReview(context.Background(), userID, productID, model.CreateReviewRequest{Rating: 4})
+
+			if tt.errContains != "" {
+				assert.Error(t, err)
+				assert.Contains(t, err.Error(), tt.errContains)
+				assert.Contains(t, err.Error(), "already reviewed")
+				return
+			}
+			assert.NoError(t, err)
+			assert.NotNil(t, resp)
+		})
+	}
+}
+
+func TestReviewService_GetProductReviews(t *testing.T) {
+	productID := uuid.New()
+
+	t.Run("filters by rating and sorts by rating", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		repo := mocks.NewMockReviewRepository(ctrl)
+		repo.EXPECT().FindByProductID(gomock.Any(), productID, model.ReviewFilter{
+			Rating:    5,
+			SortBy:    "rating",
+			SortOrder: "desc",
+			Page:      1,
+			PerPage:   10,
+		}).Return([]model.Review{
+			{ID: uuid.New(), ProductID: productID, Rating: 5, User: model.User{Name: "Ana"}},
+		}, int64(1), nil)
+
+		svc := NewReviewService(repo, false)
+		reviews, total, err := svc.GetProductReviews(context.Background(), productID, model.ReviewFilter{
+			Rating:    5,
+			SortBy:    "rating",
+			SortOrder: "desc",
+		})
+
+		assert.NoError(t, err)
+		assert.Equal(t, int64(1), total)
+		if assert.Len(t, reviews, 1) {
+			assert.Equal(t, 5, reviews[0].Rating)
+			assert.Equal(t, "Ana", reviews[0].UserName)
+		}
+	})
+
+	t.Run("rejects a rating out of range", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		svc := NewReviewService(mocks.NewMockReviewRepository(ctrl), false)
+		_, _, err := svc.GetProductReviews(context.Background(), productID, model.ReviewFilter{Rating: 6})
+
+		assert.ErrorContains(t, err, "rating must be between 1 and 5")
+	})
+}
+
+func TestReviewService_HelpfulVote(t *testing.T) {
+	userID := uuid.New()
+	reviewID := uuid.New()
+
+	t.Run("voting returns the new count", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		repo := mocks.NewMockReviewRepository(ctrl)
+		repo.EXPECT().FindByID(gomock.Any(), reviewID).Return(&model.Review{ID: reviewID}, nil)
+		repo.EXPECT().AddVote(gomock.Any(), reviewID, userID).Return(nil)
+		repo.EXPECT().CountVotes(gomock.Any(), reviewID).Return(int64(3), nil)
+
+		resp, err := NewReviewService(repo, false).AddHelpfulVote(context.Background(), userID, reviewID)
+
+		assert.NoError(t, err)
+		assert.Equal(t, &model.HelpfulVoteResponse{ReviewID: reviewID, HelpfulCount: 3}, resp)
+	})
+
+	t.Run("removing a vote returns the new count", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		repo := mocks.NewMockReviewRepository(ctrl)
+		repo.EXPECT().FindByID(gomock.Any(), reviewID).Return(&model.Review{ID: reviewID}, nil)
+		repo.EXPECT().RemoveVote(gomock.Any(), reviewID, userID).Return(nil)
+		repo.EXPECT().CountVotes(gomock.Any(), reviewID).Return(int64(0), nil)
+
+		resp, err := NewReviewService(repo, false).RemoveHelpfulVote(context.Background(), userID, reviewID)
+
+		assert.NoError(t, err)
+		assert.Equal(t, int64(0), resp.HelpfulCount)
+	})
+
+	t.Run("unknown review", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		repo := mocks.NewMockReviewRepository(ctrl)
+		repo.EXPECT().FindByID(gomock.Any(), reviewID).Return(nil, errors.New("record not found"))
+
+		_, err := NewReviewService(repo, false).AddHelpfulVote(context.Background(), userID, reviewID)
+
+		assert.ErrorContains(t, err, "review not found")
+	})
+}
+
+func TestReviewService_ReplyToReview(t *testing.T) {
+	sellerID := uuid.New()
+	reviewID := uuid.New()
+	productID := uuid.New()
+	storeID := uuid.New()
+
+	tests := []struct {
+		name        string
+		comment     string
+		mockSetup   func(repo *mocks.MockReviewRepository)
+		errContains string
+	}{
+		{
+			name:    "owner of the reviewed product replies",
+			comment: "  Thanks for the feedback!  ",
+			mockSetup: func(repo *mocks.MockReviewRepository) {
+				repo.EXPECT().FindByID(gomock.Any(), reviewID).Return(&model.Review{ID: reviewID, ProductID: productID}, nil)
+				repo.EXPECT().FindProductStore(gomock.Any(), productID).Return(&model.Store{ID: storeID, UserID: sellerID}, nil)
+				repo.EXPECT().SaveReply(gomock.Any(), &model.ReviewReply{
+					ReviewID: reviewID,
+					StoreID:  storeID,
+					Comment:  "Thanks for the feedback!",
+				}).Return(nil)
+			},
+		},
+		{
+			name:    "seller of another store is forbidden",
+			comment: "Buy ours instead",
+			mockSetup: func(repo *mocks.MockReviewRepository) {
+				repo.EXPECT().FindByID(gomock.Any(), reviewID).Return(&model.Review{ID: reviewID, ProductID: productID}, nil)
+				repo.EXPECT().FindProductStore(gomock.Any(), productID).Return(&model.Store{ID: storeID, UserID: uuid.New()}, nil)
+			},
+			errContains: "forbidden",
+		},
+		{
+			name:    "product without a store is forbidden",
+			comment: "Thanks",
+			mockSetup: func(repo *mocks.MockReviewRepository) {
+				repo.EXPECT().FindByID(gomock.Any(), reviewID).Return(&model.Review{ID: reviewID, ProductID: productID}, nil)
+				repo.EXPECT().FindProductStore(gomock.Any(), productID).Return(nil, gorm.ErrRecordNotFound)
+			},
+			errContains: "forbidden",
+		},
+		{
+			name:    "unknown review",
+			comment: "Thanks",
+			mockSetup: func(repo *mocks.MockReviewRepository) {
+				repo.EXPECT().FindByID(gomock.Any(), reviewID).Return(nil, gorm.ErrRecordNotFound)
+			},
+			errContains: "review not found",
+		},
+		{
+			name:        "blank comment",
+			comment:     "   ",
+			mockSetup:   func(_ *mocks.MockReviewRepository) {},
+			errContains: "comment is required",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			repo := mocks.NewMockReviewRepository(ctrl)
+			tt.mockSetup(repo)
+
+			resp, err := NewReviewService(repo, false).ReplyToReview(context.Background(), sellerID, reviewID, model.ReplyReviewRequest{Comment: tt.comment})
+
+			if tt.errContains != "" {
+				assert.ErrorContains(t, err, tt.errContains)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, storeID, resp.StoreID)
+			assert.Equal(t, "Thanks for the feedback!", resp.Comment)
+		})
+	}
+}
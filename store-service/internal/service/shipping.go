@@ -0,0 +1,60 @@
+package service
+
+import (
+	"context"
+	"strings"
+
+	"github.com/shopspring/decimal"
+)
+
+// ShippingCalculator prices delivery of an order to the given address.
+type ShippingCalculator interface {
+	Calculate(ctx context.Context, shippingAddress string) (decimal.Decimal, error)
+}
+
+type flatRateShippingCalculator struct {
+	rates       map[string]decimal.Decimal
+	defaultRate decimal.Decimal
+}
+
+// NewFlatRateShippingCalculator charges a fixed rate per region. Region keys
+// are matched case-insensitively against the comma-separated parts of the
+// address; addresses with no known region are charged defaultRate.
+func NewFlatRateShippingCalculator(rates map[string]decimal.Decimal, defaultRate decimal.Decimal) ShippingCalculator {
+	return &flatRateShippingCalculator{
+		rates:       normalizeRegionRates(rates),
+		defaultRate: defaultRate,
+	}
+}
+
+func (c *flatRateShippingCalculator) Calculate(_ context.Context, shippingAddress string) (decimal.Decimal, error) {
+	if rate, ok := matchRegion(c.rates, shippingAddress); ok {
+		return rate, nil
+	}
+	return c.defaultRate, nil
+}
+
+func normalizeRegionRates(rates map[string]decimal.Decimal) map[string]decimal.Decimal {
+	normalized := make(map[string]decimal.Decimal, len(rates))
+	for region, rate := range rates {
+		normalized[normalizeRegion(region)] = rate
+	}
+	return normalized
+}
+
+// matchRegion returns the rate of the region the address is in. It walks the
+// address parts from the end, since addresses are written from most to least
+// specific (street, district, city, province).
+func matchRegion(rates map[string]decimal.Decimal, address string) (decimal.Decimal, bool) {
+	parts := strings.Split(address, ",")
+	for i := len(parts) - 1; i >= 0; i-- {
+		if rate, ok := rates[normalizeRegion(parts[i])]; ok {
+			return rate, true
+		}
+	}
+	return decimal.Zero, false
+}
+
+func normalizeRegion(s string) string {
+	return strings.ToLower(strings.TrimSpace(s))
+}
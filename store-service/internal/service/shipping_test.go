@@ -0,0 +1,36 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFlatRateShippingCalculator_Calculate(t *testing.T) {
+	calc := NewFlatRateShippingCalculator(map[string]decimal.Decimal{
+		"Jakarta": decimal.NewFromInt(10000),
+		"bandung": decimal.NewFromInt(15000),
+	}, decimal.NewFromInt(25000))
+
+	tests := []struct {
+		name    string
+		address string
+		want    decimal.Decimal
+	}{
+		{name: "city as last part", address: "Jl. Sudirman No. 1, Jakarta", want: decimal.NewFromInt(10000)},
+		{name: "case and whitespace insensitive", address: "Jl. Dago 10,   BANDUNG  ", want: decimal.NewFromInt(15000)},
+		{name: "region before postal code", address: "Jl. Thamrin 5, Jakarta, 10230", want: decimal.NewFromInt(10000)},
+		{name: "unknown region falls back to default", address: "Jl. Malioboro 1, Yogyakarta", want: decimal.NewFromInt(25000)},
+		{name: "no separators falls back to default", address: "somewhere", want: decimal.NewFromInt(25000)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := calc.Calculate(context.Background(), tt.address)
+			assert.NoError(t, err)
+			assert.True(t, tt.want.Equal(got), "want %s, got %s", tt.want, got)
+		})
+	}
+}
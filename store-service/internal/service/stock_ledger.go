@@ -0,0 +1,16 @@
+package service
+
+import (
+	"github.com/1tsndre/mini-go-project/store-service/internal/model"
+	"github.com/1tsndre/mini-go-project/store-service/internal/repository"
+)
+
+// ledgerEntries returns movements for the product repository to record with
+// the stock change they describe, or none when the ledger is disabled, which
+// a nil repository means.
+func ledgerEntries(repo repository.StockMovementRepository, movements ...model.StockMovement) []model.StockMovement {
+	if repo == nil {
+		return nil
+	}
+	return movements
+}
@@ -3,30 +3,53 @@ package service
 import (
 	"context"
 	"errors"
+	"time"
 
 	"github.com/1tsndre/mini-go-project/pkg/logger"
 	"github.com/1tsndre/mini-go-project/store-service/internal/constant"
 	"github.com/1tsndre/mini-go-project/store-service/internal/model"
 	"github.com/1tsndre/mini-go-project/store-service/internal/repository"
 	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
 )
 
 type StoreService interface {
 	CreateStore(ctx context.Context, userID uuid.UUID, req model.CreateStoreRequest) (*model.StoreResponse, error)
 	GetStoreByID(ctx context.Context, id uuid.UUID) (*model.StoreResponse, error)
 	UpdateStore(ctx context.Context, userID uuid.UUID, id uuid.UUID, req model.UpdateStoreRequest) (*model.StoreResponse, error)
-	UpdateLogo(ctx context.Context, userID uuid.UUID, id uuid.UUID, logoURL string) (*model.StoreResponse, error)
+	UpdateLogo(ctx context.Context, userID uuid.UUID, id uuid.UUID, logoURL, thumbnailURL string) (*model.StoreResponse, *model.StoreLogo, error)
+	GetSellerStats(ctx context.Context, userID uuid.UUID) (*model.SellerStatsResponse, error)
+	GetSellerCommission(ctx context.Context, userID uuid.UUID, from, to time.Time) (*model.SellerCommissionResponse, error)
+	SetStoreActive(ctx context.Context, id uuid.UUID, active bool) (*model.StoreResponse, error)
 }
 
 type storeService struct {
-	storeRepo repository.StoreRepository
-	userRepo  repository.UserRepository
+	storeRepo   repository.StoreRepository
+	userRepo    repository.UserRepository
+	productRepo repository.ProductRepository
+	orderRepo   repository.OrderRepository
+	feePercent  decimal.Decimal
+	// processingWindow limits the orders averaged into a store's processing
+	// time to those paid within it; zero averages all of them.
+	processingWindow time.Duration
 }
 
-func NewStoreService(storeRepo repository.StoreRepository, userRepo repository.UserRepository) StoreService {
+func NewStoreService(
+	storeRepo repository.StoreRepository,
+	userRepo repository.UserRepository,
+	productRepo repository.ProductRepository,
+	orderRepo repository.OrderRepository,
+	feePercent decimal.Decimal,
+	processingWindow time.Duration,
+) StoreService {
 	return &storeService{
-		storeRepo: storeRepo,
-		userRepo:  userRepo,
+		storeRepo:        storeRepo,
+		userRepo:         userRepo,
+		productRepo:      productRepo,
+		orderRepo:        orderRepo,
+		feePercent:       feePercent,
+		processingWindow: processingWindow,
 	}
 }
 
@@ -73,9 +96,29 @@ func (s *storeService) GetStoreByID(ctx context.Context, id uuid.UUID) (*model.S
 	}
 
 	resp := store.ToResponse()
+	resp.AvgProcessingTime = s.avgProcessingTime(ctx, store.ID)
 	return &resp, nil
 }
 
+// avgProcessingTime averages, in whole seconds, how long storeID's paid
+// orders waited before the seller moved them to processing. It returns nil
+// when there is nothing to average; a failed lookup only drops the metric.
+func (s *storeService) avgProcessingTime(ctx context.Context, storeID uuid.UUID) *int64 {
+	var since time.Time
+	if s.processingWindow > 0 {
+		since = time.Now().Add(-s.processingWindow)
+	}
+
+	avg, err := s.storeRepo.AvgProcessingTime(ctx, storeID, since)
+	if err != nil {
+		logger.Error(ctx, "failed to load store processing time", err, map[string]interface{}{
+			"store_id": storeID.String(),
+		})
+		return nil
+	}
+	return avg
+}
+
 func (s *storeService) UpdateStore(ctx context.Context, userID uuid.UUID, id uuid.UUID, req model.UpdateStoreRequest) (*model.StoreResponse, error) {
 	store, err := s.storeRepo.FindByID(ctx, id)
 	if err != nil {
@@ -102,22 +145,128 @@ func (s *storeService) UpdateStore(ctx context.Context, userID uuid.UUID, id uui
 	return &resp, nil
 }
 
-func (s *storeService) UpdateLogo(ctx context.Context, userID uuid.UUID, id uuid.UUID, logoURL string) (*model.StoreResponse, error) {
+// UpdateLogo sets the store's logo. The logo it replaces, if any, is
+// returned so the caller can remove its files once they are no longer
+// referenced.
+func (s *storeService) UpdateLogo(ctx context.Context, userID uuid.UUID, id uuid.UUID, logoURL, thumbnailURL string) (*model.StoreResponse, *model.StoreLogo, error) {
 	store, err := s.storeRepo.FindByID(ctx, id)
 	if err != nil {
-		return nil, errors.New("store not found")
+		return nil, nil, errors.New("store not found")
 	}
 
 	if store.UserID != userID {
-		return nil, errors.New("forbidden: not store owner")
+		return nil, nil, errors.New("forbidden: not store owner")
+	}
+
+	var replaced *model.StoreLogo
+	if store.LogoURL != "" {
+		replaced = &model.StoreLogo{URL: store.LogoURL, ThumbnailURL: store.ThumbnailURL}
 	}
 
 	store.LogoURL = logoURL
+	store.ThumbnailURL = thumbnailURL
 	if err := s.storeRepo.Update(ctx, store); err != nil {
 		logger.Error(ctx, "failed to update store logo", err)
-		return nil, errors.New("failed to update store logo")
+		return nil, nil, errors.New("failed to update store logo")
 	}
 
 	resp := store.ToResponse()
-	return &resp, nil
+	return &resp, replaced, nil
+}
+
+// GetSellerStats summarizes the seller's store using aggregate queries, so
+// the cost does not grow with the number of orders loaded.
+func (s *storeService) GetSellerStats(ctx context.Context, userID uuid.UUID) (*model.SellerStatsResponse, error) {
+	store, err := s.storeRepo.FindByUserID(ctx, userID)
+	if err != nil {
+		return nil, errors.New("store not found")
+	}
+
+	totalProducts, err := s.productRepo.CountByStore(ctx, store.ID)
+	if err != nil {
+		logger.Error(ctx, "failed to count store products", err)
+		return nil, errors.New("failed to fetch seller stats")
+	}
+
+	totalOrders, err := s.orderRepo.CountByStore(ctx, store.ID, nil)
+	if err != nil {
+		logger.Error(ctx, "failed to count store orders", err)
+		return nil, errors.New("failed to fetch seller stats")
+	}
+
+	pending, err := s.orderRepo.CountByStore(ctx, store.ID, constant.PendingFulfillmentStatuses)
+	if err != nil {
+		logger.Error(ctx, "failed to count pending store orders", err)
+		return nil, errors.New("failed to fetch seller stats")
+	}
+
+	revenue, err := s.orderRepo.StoreRevenue(ctx, store.ID, constant.RevenueStatuses)
+	if err != nil {
+		logger.Error(ctx, "failed to sum store revenue", err)
+		return nil, errors.New("failed to fetch seller stats")
+	}
+
+	return &model.SellerStatsResponse{
+		TotalProducts:      totalProducts,
+		TotalOrders:        totalOrders,
+		Revenue:            revenue,
+		PendingFulfillment: pending,
+	}, nil
+}
+
+// GetSellerCommission sums the seller's completed orders in [from, to) and
+// applies the platform fee. Commission is rounded to cents and the seller
+// keeps the remainder, so gross always equals commission plus net.
+func (s *storeService) GetSellerCommission(ctx context.Context, userID uuid.UUID, from, to time.Time) (*model.SellerCommissionResponse, error) {
+	store, err := s.storeRepo.FindByUserID(ctx, userID)
+	if err != nil {
+		return nil, errors.New("store not found")
+	}
+
+	gross, err := s.orderRepo.StoreRevenueBetween(ctx, store.ID, []string{constant.OrderStatusCompleted}, from, to)
+	if err != nil {
+		logger.Error(ctx, "failed to sum store revenue for commission report", err, map[string]interface{}{
+			"store_id": store.ID.String(),
+		})
+		return nil, errors.New("failed to fetch commission report")
+	}
+
+	commission, net := splitPlatformFee(gross, s.feePercent)
+
+	return &model.SellerCommissionResponse{
+		GrossSales: gross,
+		FeePercent: s.feePercent,
+		Commission: commission,
+		NetPayout:  net,
+	}, nil
+}
+
+// splitPlatformFee takes feePercent of gross for the platform, rounded to
+// cents, and leaves the rest to the seller.
+func splitPlatformFee(gross, feePercent decimal.Decimal) (fee, net decimal.Decimal) {
+	fee = gross.Mul(feePercent).Div(decimal.NewFromInt(100)).Round(2)
+	return fee, gross.Sub(fee)
+}
+
+// SetStoreActive deactivates or reactivates a store. Public listings stop
+// showing the products of a deactivated store; its owner still sees them.
+func (s *storeService) SetStoreActive(ctx context.Context, id uuid.UUID, active bool) (*model.StoreResponse, error) {
+	err := s.storeRepo.SetActive(ctx, id, active)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, errors.New("store not found")
+	}
+	if err != nil {
+		logger.Error(ctx, "failed to update store status", err, map[string]interface{}{
+			"store_id": id.String(),
+		})
+		return nil, errors.New("failed to update store status")
+	}
+	s.productRepo.InvalidateStoreListings(ctx, id)
+
+	logger.Info(ctx, "store status updated", map[string]interface{}{
+		"store_id": id.String(),
+		"active":   active,
+	})
+
+	return s.GetStoreByID(ctx, id)
 }
@@ -4,13 +4,16 @@ import (
 	"context"
 	"errors"
 	"testing"
+	"time"
 
 	"github.com/1tsndre/mini-go-project/store-service/internal/constant"
 	"github.com/1tsndre/mini-go-project/store-service/internal/mocks"
 	"github.com/1tsndre/mini-go-project/store-service/internal/model"
 	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
 	"github.com/stretchr/testify/assert"
 	"go.uber.org/mock/gomock"
+	"gorm.io/gorm"
 )
 
 func TestStoreService_CreateStore(t *testing.T) {
@@ -77,7 +80,7 @@ func TestStoreService_CreateStore(t *testing.T) {
 			userRepo := mocks.NewMockUserRepository(ctrl)
 			tt.mockSetup(storeRepo, userRepo)
 
-			svc := NewStoreService(storeRepo, userRepo)
+			svc := NewStoreService(storeRepo, userRepo, nil, nil, decimal.Zero, 0)
 			resp, err := svc.CreateStore(context.Background(), userID, tt.req)
 
 			if tt.wantErr {
@@ -100,23 +103,24 @@ func TestStoreService_GetStoreByID(t *testing.T) {
 
 	tests := []struct {
 		name        string
-		mockSetup   func(storeRepo *mocks.MockStoreRepository, userRepo *mocks.MockUserRepository)
+		mockSetup   func(storeRepo *mocks.MockStoreRepository)
 		wantErr     bool
 		errContains string
 	}{
 		{
 			name: "success",
-			mockSetup: func(storeRepo *mocks.MockStoreRepository, _ *mocks.MockUserRepository) {
+			mockSetup: func(storeRepo *mocks.MockStoreRepository) {
 				storeRepo.EXPECT().FindByID(gomock.Any(), storeID).Return(&model.Store{
 					ID:     storeID,
 					UserID: userID,
 					Name:   "My Store",
 				}, nil)
+				storeRepo.EXPECT().AvgProcessingTime(gomock.Any(), storeID, gomock.Any()).Return(nil, nil)
 			},
 		},
 		{
 			name: "store not found",
-			mockSetup: func(storeRepo *mocks.MockStoreRepository, _ *mocks.MockUserRepository) {
+			mockSetup: func(storeRepo *mocks.MockStoreRepository) {
 				storeRepo.EXPECT().FindByID(gomock.Any(), storeID).Return(nil, errors.New("not found"))
 			},
 			wantErr:     true,
@@ -130,10 +134,9 @@ func TestStoreService_GetStoreByID(t *testing.T) {
 			defer ctrl.Finish()
 
 			storeRepo := mocks.NewMockStoreRepository(ctrl)
-			userRepo := mocks.NewMockUserRepository(ctrl)
-			tt.mockSetup(storeRepo, userRepo)
+			tt.mockSetup(storeRepo)
 
-			svc := NewStoreService(storeRepo, userRepo)
+			svc := NewStoreService(storeRepo, nil, nil, nil, decimal.Zero, 0)
 			resp, err := svc.GetStoreByID(context.Background(), storeID)
 
 			if tt.wantErr {
@@ -145,10 +148,54 @@ func TestStoreService_GetStoreByID(t *testing.T) {
 			assert.NoError(t, err)
 			assert.NotNil(t, resp)
 			assert.Equal(t, storeID, resp.ID)
+			assert.Nil(t, resp.AvgProcessingTime, "no processed orders yet")
 		})
 	}
 }
 
+func TestStoreService_GetStoreByID_AvgProcessingTime(t *testing.T) {
+	storeID := uuid.New()
+
+	tests := []struct {
+		name    string
+		avg     *int64
+		err     error
+		wantAvg *int64
+	}{
+		{name: "average from the repository", avg: ptrInt64(8400), wantAvg: ptrInt64(8400)},
+		{name: "no processed orders"},
+		{name: "lookup failure leaves the metric out", err: errors.New("db error")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			storeRepo := mocks.NewMockStoreRepository(ctrl)
+			storeRepo.EXPECT().FindByID(gomock.Any(), storeID).Return(&model.Store{ID: storeID}, nil)
+
+			window := 90 * 24 * time.Hour
+			before := time.Now()
+			storeRepo.EXPECT().AvgProcessingTime(gomock.Any(), storeID, gomock.Any()).DoAndReturn(
+				func(_ context.Context, _ uuid.UUID, since time.Time) (*int64, error) {
+					assert.WithinRange(t, since, before.Add(-window), time.Now().Add(-window))
+					return tt.avg, tt.err
+				})
+
+			svc := NewStoreService(storeRepo, nil, nil, nil, decimal.Zero, window)
+			resp, err := svc.GetStoreByID(context.Background(), storeID)
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.wantAvg, resp.AvgProcessingTime)
+		})
+	}
+}
+
+func ptrInt64(v int64) *int64 {
+	return &v
+}
+
 func TestStoreService_UpdateStore(t *testing.T) {
 	storeID := uuid.New()
 	ownerID := uuid.New()
@@ -223,7 +270,7 @@ func TestStoreService_UpdateStore(t *testing.T) {
 			userRepo := mocks.NewMockUserRepository(ctrl)
 			tt.mockSetup(storeRepo, userRepo)
 
-			svc := NewStoreService(storeRepo, userRepo)
+			svc := NewStoreService(storeRepo, userRepo, nil, nil, decimal.Zero, 0)
 			resp, err := svc.UpdateStore(context.Background(), tt.callerID, storeID, tt.req)
 
 			if tt.wantErr {
@@ -244,13 +291,15 @@ func TestStoreService_UpdateLogo(t *testing.T) {
 	ownerID := uuid.New()
 	otherUserID := uuid.New()
 	logoURL := "https://example.com/logo.png"
+	thumbnailURL := "https://example.com/logo_thumb.png"
 
 	tests := []struct {
-		name        string
-		callerID    uuid.UUID
-		mockSetup   func(storeRepo *mocks.MockStoreRepository, userRepo *mocks.MockUserRepository)
-		wantErr     bool
-		errContains string
+		name         string
+		callerID     uuid.UUID
+		mockSetup    func(storeRepo *mocks.MockStoreRepository, userRepo *mocks.MockUserRepository)
+		wantReplaced *model.StoreLogo
+		wantErr      bool
+		errContains  string
 	}{
 		{
 			name:     "success",
@@ -263,6 +312,20 @@ func TestStoreService_UpdateLogo(t *testing.T) {
 				storeRepo.EXPECT().Update(gomock.Any(), gomock.Any()).Return(nil)
 			},
 		},
+		{
+			name:     "replacing a logo returns the old files",
+			callerID: ownerID,
+			mockSetup: func(storeRepo *mocks.MockStoreRepository, _ *mocks.MockUserRepository) {
+				storeRepo.EXPECT().FindByID(gomock.Any(), storeID).Return(&model.Store{
+					ID:           storeID,
+					UserID:       ownerID,
+					LogoURL:      "stores/old.png",
+					ThumbnailURL: "stores/old_thumb.png",
+				}, nil)
+				storeRepo.EXPECT().Update(gomock.Any(), gomock.Any()).Return(nil)
+			},
+			wantReplaced: &model.StoreLogo{URL: "stores/old.png", ThumbnailURL: "stores/old_thumb.png"},
+		},
 		{
 			name:     "store not found",
 			callerID: ownerID,
@@ -308,8 +371,8 @@ func TestStoreService_UpdateLogo(t *testing.T) {
 			userRepo := mocks.NewMockUserRepository(ctrl)
 			tt.mockSetup(storeRepo, userRepo)
 
-			svc := NewStoreService(storeRepo, userRepo)
-			resp, err := svc.UpdateLogo(context.Background(), tt.callerID, storeID, logoURL)
+			svc := NewStoreService(storeRepo, userRepo, nil, nil, decimal.Zero, 0)
+			resp, replaced, err := svc.UpdateLogo(context.Background(), tt.callerID, storeID, logoURL, thumbnailURL)
 
 			if tt.wantErr {
 				assert.Error(t, err)
@@ -320,6 +383,254 @@ func TestStoreService_UpdateLogo(t *testing.T) {
 			assert.NoError(t, err)
 			assert.NotNil(t, resp)
 			assert.Equal(t, logoURL, resp.LogoURL)
+			assert.Equal(t, thumbnailURL, resp.ThumbnailURL)
+			assert.Equal(t, tt.wantReplaced, replaced)
+		})
+	}
+}
+
+func TestStoreService_GetSellerStats(t *testing.T) {
+	userID := uuid.New()
+	storeID := uuid.New()
+
+	// The store's share of each order, keyed by order status.
+	storeOrders := []struct {
+		status string
+		amount int64
+	}{
+		{constant.OrderStatusPending, 10000},
+		{constant.OrderStatusOnHold, 90000},
+		{constant.OrderStatusPaid, 20000},
+		{constant.OrderStatusProcessing, 30000},
+		{constant.OrderStatusShipped, 40000},
+		{constant.OrderStatusCompleted, 50000},
+		{constant.OrderStatusCancelled, 60000},
+	}
+	matching := func(statuses []string) (count int64, sum decimal.Decimal) {
+		sum = decimal.Zero
+		for _, o := range storeOrders {
+			for _, st := range statuses {
+				if o.status == st {
+					count++
+					sum = sum.Add(decimal.NewFromInt(o.amount))
+				}
+			}
+		}
+		return count, sum
+	}
+
+	tests := []struct {
+		name        string
+		mockSetup   func(storeRepo *mocks.MockStoreRepository, productRepo *mocks.MockProductRepository, orderRepo *mocks.MockOrderRepository)
+		wantErr     bool
+		errContains string
+		want        *model.SellerStatsResponse
+	}{
+		{
+			name: "mixed order statuses",
+			mockSetup: func(storeRepo *mocks.MockStoreRepository, productRepo *mocks.MockProductRepository, orderRepo *mocks.MockOrderRepository) {
+				storeRepo.EXPECT().FindByUserID(gomock.Any(), userID).Return(&model.Store{ID: storeID, UserID: userID}, nil)
+				productRepo.EXPECT().CountByStore(gomock.Any(), storeID).Return(int64(12), nil)
+				orderRepo.EXPECT().CountByStore(gomock.Any(), storeID, gomock.Any()).
+					DoAndReturn(func(_ context.Context, _ uuid.UUID, statuses []string) (int64, error) {
+						if len(statuses) == 0 {
+							return int64(len(storeOrders)), nil
+						}
+						count, _ := matching(statuses)
+						return count, nil
+					}).Times(2)
+				orderRepo.EXPECT().StoreRevenue(gomock.Any(), storeID, gomock.Any()).
+					DoAndReturn(func(_ context.Context, _ uuid.UUID, statuses []string) (decimal.Decimal, error) {
+						_, sum := matching(statuses)
+						return sum, nil
+					})
+			},
+			want: &model.SellerStatsResponse{
+				TotalProducts:      12,
+				TotalOrders:        7,
+				Revenue:            decimal.NewFromInt(140000),
+				PendingFulfillment: 2,
+			},
+		},
+		{
+			name: "store not found",
+			mockSetup: func(storeRepo *mocks.MockStoreRepository, _ *mocks.MockProductRepository, _ *mocks.MockOrderRepository) {
+				storeRepo.EXPECT().FindByUserID(gomock.Any(), userID).Return(nil, errors.New("not found"))
+			},
+			wantErr:     true,
+			errContains: "store not found",
+		},
+		{
+			name: "revenue query fails",
+			mockSetup: func(storeRepo *mocks.MockStoreRepository, productRepo *mocks.MockProductRepository, orderRepo *mocks.MockOrderRepository) {
+				storeRepo.EXPECT().FindByUserID(gomock.Any(), userID).Return(&model.Store{ID: storeID, UserID: userID}, nil)
+				productRepo.EXPECT().CountByStore(gomock.Any(), storeID).Return(int64(12), nil)
+				orderRepo.EXPECT().CountByStore(gomock.Any(), storeID, gomock.Any()).Return(int64(7), nil).Times(2)
+				orderRepo.EXPECT().StoreRevenue(gomock.Any(), storeID, gomock.Any()).Return(decimal.Zero, errors.New("db error"))
+			},
+			wantErr:     true,
+			errContains: "failed to fetch seller stats",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			storeRepo := mocks.NewMockStoreRepository(ctrl)
+			userRepo := mocks.NewMockUserRepository(ctrl)
+			productRepo := mocks.NewMockProductRepository(ctrl)
+			orderRepo := mocks.NewMockOrderRepository(ctrl)
+			tt.mockSetup(storeRepo, productRepo, orderRepo)
+
+			svc := NewStoreService(storeRepo, userRepo, productRepo, orderRepo, decimal.Zero, 0)
+			resp, err := svc.GetSellerStats(context.Background(), userID)
+
+			if tt.wantErr {
+				assert.Error(t, err)
+				assert.Contains(t, err.Error(), tt.errContains)
+				assert.Nil(t, resp)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want.TotalProducts, resp.TotalProducts)
+			assert.Equal(t, tt.want.TotalOrders, resp.TotalOrders)
+			assert.Equal(t, tt.want.PendingFulfillment, resp.PendingFulfillment)
+			assert.True(t, tt.want.Revenue.Equal(resp.Revenue), "revenue %s", resp.Revenue)
 		})
 	}
-}
\ No newline at end of file
+}
+
+func TestStoreService_GetSellerCommission(t *testing.T) {
+	userID := uuid.New()
+	storeID := uuid.New()
+	from := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2026, 2, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name           string
+		feePercent     string
+		completed      []string // line totals of completed orders in the period
+		wantGross      string
+		wantCommission string
+		wantNet        string
+		revenueErr     error
+		wantErr        bool
+		errContains    string
+	}{
+		{
+			name:           "commission applied to summed completed orders",
+			feePercent:     "10",
+			completed:      []string{"150000", "49999.99", "300000.01"},
+			wantGross:      "500000",
+			wantCommission: "50000",
+			wantNet:        "450000",
+		},
+		{
+			name:           "fractional commission rounds to cents and net keeps remainder",
+			feePercent:     "2.5",
+			completed:      []string{"10.01", "9.99", "0.33"},
+			wantGross:      "20.33",
+			wantCommission: "0.51",
+			wantNet:        "19.82",
+		},
+		{
+			name:           "no completed orders",
+			feePercent:     "10",
+			wantGross:      "0",
+			wantCommission: "0",
+			wantNet:        "0",
+		},
+		{
+			name:        "revenue query fails",
+			feePercent:  "10",
+			revenueErr:  errors.New("db error"),
+			wantErr:     true,
+			errContains: "failed to fetch commission report",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			storeRepo := mocks.NewMockStoreRepository(ctrl)
+			orderRepo := mocks.NewMockOrderRepository(ctrl)
+
+			gross := decimal.Zero
+			for _, amount := range tt.completed {
+				gross = gross.Add(decimal.RequireFromString(amount))
+			}
+			storeRepo.EXPECT().FindByUserID(gomock.Any(), userID).Return(&model.Store{ID: storeID, UserID: userID}, nil)
+			orderRepo.EXPECT().
+				StoreRevenueBetween(gomock.Any(), storeID, []string{constant.OrderStatusCompleted}, from, to).
+				Return(gross, tt.revenueErr)
+
+			svc := NewStoreService(storeRepo, nil, nil, orderRepo, decimal.RequireFromString(tt.feePercent), 0)
+			resp, err := svc.GetSellerCommission(context.Background(), userID, from, to)
+
+			if tt.wantErr {
+				assert.Error(t, err)
+				assert.Contains(t, err.Error(), tt.errContains)
+				assert.Nil(t, resp)
+				return
+			}
+			assert.NoError(t, err)
+			assert.True(t, decimal.RequireFromString(tt.wantGross).Equal(resp.GrossSales), "gross %s", resp.GrossSales)
+			assert.True(t, decimal.RequireFromString(tt.wantCommission).Equal(resp.Commission), "commission %s", resp.Commission)
+			assert.True(t, decimal.RequireFromString(tt.wantNet).Equal(resp.NetPayout), "net %s", resp.NetPayout)
+			assert.True(t, resp.GrossSales.Equal(resp.Commission.Add(resp.NetPayout)))
+		})
+	}
+}
+
+func TestStoreService_SetStoreActive(t *testing.T) {
+	storeID := uuid.New()
+
+	tests := []struct {
+		name        string
+		mockSetup   func(storeRepo *mocks.MockStoreRepository, prodRepo *mocks.MockProductRepository)
+		errContains string
+	}{
+		{
+			name: "deactivate invalidates listings",
+			mockSetup: func(storeRepo *mocks.MockStoreRepository, prodRepo *mocks.MockProductRepository) {
+				storeRepo.EXPECT().SetActive(gomock.Any(), storeID, false).Return(nil)
+				prodRepo.EXPECT().InvalidateStoreListings(gomock.Any(), storeID)
+				storeRepo.EXPECT().FindByID(gomock.Any(), storeID).Return(&model.Store{ID: storeID}, nil)
+				storeRepo.EXPECT().AvgProcessingTime(gomock.Any(), storeID, gomock.Any()).Return(nil, nil)
+			},
+		},
+		{
+			name: "store not found",
+			mockSetup: func(storeRepo *mocks.MockStoreRepository, _ *mocks.MockProductRepository) {
+				storeRepo.EXPECT().SetActive(gomock.Any(), storeID, false).Return(gorm.ErrRecordNotFound)
+			},
+			errContains: "store not found",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			storeRepo := mocks.NewMockStoreRepository(ctrl)
+			prodRepo := mocks.NewMockProductRepository(ctrl)
+			tt.mockSetup(storeRepo, prodRepo)
+
+			svc := NewStoreService(storeRepo, nil, prodRepo, nil, decimal.Zero, 0)
+			resp, err := svc.SetStoreActive(context.Background(), storeID, false)
+
+			if tt.errContains != "" {
+				assert.ErrorContains(t, err, tt.errContains)
+				assert.Nil(t, resp)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, storeID, resp.ID)
+		})
+	}
+}
@@ -0,0 +1,33 @@
+package service
+
+import (
+	"context"
+
+	"github.com/shopspring/decimal"
+)
+
+// TaxCalculator works out the tax owed on an order's items shipped to the
+// given address. subtotal excludes shipping.
+type TaxCalculator interface {
+	Calculate(ctx context.Context, shippingAddress string, subtotal decimal.Decimal) (decimal.Decimal, error)
+}
+
+type regionalTaxCalculator struct {
+	rates map[string]decimal.Decimal
+}
+
+// NewRegionalTaxCalculator taxes items at a percentage rate per region,
+// matched against the address like NewFlatRateShippingCalculator does.
+// Addresses with no configured region are not taxed. The result is not
+// rounded; the caller rounds it like any other amount on the order.
+func NewRegionalTaxCalculator(rates map[string]decimal.Decimal) TaxCalculator {
+	return &regionalTaxCalculator{rates: normalizeRegionRates(rates)}
+}
+
+func (c *regionalTaxCalculator) Calculate(_ context.Context, shippingAddress string, subtotal decimal.Decimal) (decimal.Decimal, error) {
+	rate, ok := matchRegion(c.rates, shippingAddress)
+	if !ok {
+		return decimal.Zero, nil
+	}
+	return subtotal.Mul(rate).Div(decimal.NewFromInt(100)), nil
+}
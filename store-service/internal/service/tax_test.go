@@ -0,0 +1,35 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRegionalTaxCalculator_Calculate(t *testing.T) {
+	calc := NewRegionalTaxCalculator(map[string]decimal.Decimal{
+		"Jakarta": decimal.NewFromInt(11),
+		"bandung": decimal.RequireFromString("7.5"),
+	})
+
+	tests := []struct {
+		name     string
+		address  string
+		subtotal string
+		want     string
+	}{
+		{name: "taxed region", address: "Jl. Sudirman No. 1, Jakarta", subtotal: "47500", want: "5225"},
+		{name: "fractional rate", address: "Jl. Dago 10, BANDUNG", subtotal: "19.99", want: "1.49925"},
+		{name: "unconfigured region is not taxed", address: "Jl. Malioboro 1, Yogyakarta", subtotal: "47500", want: "0"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := calc.Calculate(context.Background(), tt.address, decimal.RequireFromString(tt.subtotal))
+			assert.NoError(t, err)
+			assert.True(t, decimal.RequireFromString(tt.want).Equal(got), "want %s, got %s", tt.want, got)
+		})
+	}
+}
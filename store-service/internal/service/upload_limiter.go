@@ -0,0 +1,60 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"github.com/1tsndre/mini-go-project/pkg/logger"
+	"github.com/1tsndre/mini-go-project/store-service/internal/repository"
+	"github.com/google/uuid"
+)
+
+// UploadLimiter caps how many uploads a single user may have in flight.
+// Acquire returns a release func that the caller must invoke (typically via
+// defer, so it also runs on panic) once the upload has finished.
+type UploadLimiter interface {
+	Acquire(ctx context.Context, userID uuid.UUID) (release func(), err error)
+}
+
+type uploadLimiter struct {
+	slotRepo      repository.UploadSlotRepository
+	maxConcurrent int
+}
+
+// NewUploadLimiter returns a limiter allowing maxConcurrent uploads per
+// user; 0 disables the limit.
+func NewUploadLimiter(slotRepo repository.UploadSlotRepository, maxConcurrent int) UploadLimiter {
+	return &uploadLimiter{slotRepo: slotRepo, maxConcurrent: maxConcurrent}
+}
+
+func (l *uploadLimiter) Acquire(ctx context.Context, userID uuid.UUID) (func(), error) {
+	if l.maxConcurrent <= 0 {
+		return func() {}, nil
+	}
+
+	ok, err := l.slotRepo.Acquire(ctx, userID, l.maxConcurrent)
+	if err != nil {
+		// Like the rate limiter, fail open if the cache is unavailable.
+		logger.Error(ctx, "failed to acquire upload slot", err, map[string]interface{}{
+			"user_id": userID.String(),
+		})
+		return func() {}, nil
+	}
+	if !ok {
+		return nil, errors.New("too many concurrent uploads")
+	}
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			// The request context may already be cancelled by the time the
+			// upload unwinds; the slot must be returned regardless.
+			if err := l.slotRepo.Release(context.WithoutCancel(ctx), userID); err != nil {
+				logger.Error(ctx, "failed to release upload slot", err, map[string]interface{}{
+					"user_id": userID.String(),
+				})
+			}
+		})
+	}, nil
+}
@@ -0,0 +1,74 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/1tsndre/mini-go-project/store-service/internal/mocks"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+)
+
+func TestUploadLimiter_Acquire(t *testing.T) {
+	userID := uuid.New()
+
+	tests := []struct {
+		name          string
+		maxConcurrent int
+		mockSetup     func(repo *mocks.MockUploadSlotRepository)
+		wantErr       string
+	}{
+		{
+			name:          "within limit releases once",
+			maxConcurrent: 2,
+			mockSetup: func(repo *mocks.MockUploadSlotRepository) {
+				repo.EXPECT().Acquire(gomock.Any(), userID, 2).Return(true, nil)
+				repo.EXPECT().Release(gomock.Any(), userID).Return(nil).Times(1)
+			},
+		},
+		{
+			name:          "over limit is rejected",
+			maxConcurrent: 2,
+			mockSetup: func(repo *mocks.MockUploadSlotRepository) {
+				repo.EXPECT().Acquire(gomock.Any(), userID, 2).Return(false, nil)
+			},
+			wantErr: "too many concurrent uploads",
+		},
+		{
+			name:          "cache failure fails open",
+			maxConcurrent: 2,
+			mockSetup: func(repo *mocks.MockUploadSlotRepository) {
+				repo.EXPECT().Acquire(gomock.Any(), userID, 2).Return(false, errors.New("redis down"))
+			},
+		},
+		{
+			name:          "zero disables the limit",
+			maxConcurrent: 0,
+			mockSetup:     func(_ *mocks.MockUploadSlotRepository) {},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			repo := mocks.NewMockUploadSlotRepository(ctrl)
+			tt.mockSetup(repo)
+
+			limiter := NewUploadLimiter(repo, tt.maxConcurrent)
+			release, err := limiter.Acquire(context.Background(), userID)
+
+			if tt.wantErr != "" {
+				assert.EqualError(t, err, tt.wantErr)
+				assert.Nil(t, release)
+				return
+			}
+			assert.NoError(t, err)
+			release()
+			release()
+		})
+	}
+}
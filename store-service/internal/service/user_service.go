@@ -0,0 +1,124 @@
+package service
+
+import (
+	"context"
+	"errors"
+
+	"github.com/1tsndre/mini-go-project/pkg/logger"
+	"github.com/1tsndre/mini-go-project/store-service/internal/constant"
+	"github.com/1tsndre/mini-go-project/store-service/internal/model"
+	"github.com/1tsndre/mini-go-project/store-service/internal/pagination"
+	"github.com/1tsndre/mini-go-project/store-service/internal/repository"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// UserService is the admin view of user accounts.
+type UserService interface {
+	GetUsers(ctx context.Context, filter model.UserFilter) ([]model.UserResponse, int64, error)
+	GetUser(ctx context.Context, id uuid.UUID) (*model.UserResponse, error)
+	UpdateUserRole(ctx context.Context, id uuid.UUID, role string) (*model.UserResponse, error)
+	SetUserActive(ctx context.Context, adminID, id uuid.UUID, active bool) (*model.UserResponse, error)
+}
+
+type userService struct {
+	userRepo    repository.UserRepository
+	storeRepo   repository.StoreRepository
+	productRepo repository.ProductRepository
+}
+
+func NewUserService(userRepo repository.UserRepository, storeRepo repository.StoreRepository, productRepo repository.ProductRepository) UserService {
+	return &userService{userRepo: userRepo, storeRepo: storeRepo, productRepo: productRepo}
+}
+
+func (s *userService) GetUsers(ctx context.Context, filter model.UserFilter) ([]model.UserResponse, int64, error) {
+	filter.Page, filter.PerPage = pagination.Normalize(filter.Page, filter.PerPage)
+
+	users, total, err := s.userRepo.FindAll(ctx, filter)
+	if err != nil {
+		logger.Error(ctx, "failed to list users", err)
+		return nil, 0, errors.New("failed to fetch users")
+	}
+
+	var responses []model.UserResponse
+	for _, u := range users {
+		responses = append(responses, u.ToResponse())
+	}
+
+	return responses, total, nil
+}
+
+func (s *userService) GetUser(ctx context.Context, id uuid.UUID) (*model.UserResponse, error) {
+	user, err := s.userRepo.FindByID(ctx, id)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, errors.New("user not found")
+	}
+	if err != nil {
+		logger.Error(ctx, "failed to fetch user", err)
+		return nil, errors.New("failed to fetch user")
+	}
+
+	resp := user.ToResponse()
+	return &resp, nil
+}
+
+// UpdateUserRole changes a user's role. Tokens carry the role they were
+// issued with, so the change applies from the user's next login.
+func (s *userService) UpdateUserRole(ctx context.Context, id uuid.UUID, role string) (*model.UserResponse, error) {
+	if !constant.Roles[role] {
+		return nil, errors.New("role must be one of admin, seller, buyer")
+	}
+
+	err := s.userRepo.ChangeRole(ctx, id, role)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, errors.New("user not found")
+	}
+	if errors.Is(err, repository.ErrLastAdmin) {
+		return nil, errors.New("cannot demote the last admin")
+	}
+	if err != nil {
+		logger.Error(ctx, "failed to update user role", err, map[string]interface{}{
+			"user_id": id.String(),
+		})
+		return nil, errors.New("failed to update user role")
+	}
+
+	logger.Info(ctx, "user role updated", map[string]interface{}{
+		"user_id": id.String(),
+		"role":    role,
+	})
+
+	return s.GetUser(ctx, id)
+}
+
+// SetUserActive bans or reinstates a user. A banned user's tokens are
+// rejected from their next request on, and a banned seller's products drop
+// out of public listings. Admins cannot ban themselves, so there is always
+// someone left to undo a ban.
+func (s *userService) SetUserActive(ctx context.Context, adminID, id uuid.UUID, active bool) (*model.UserResponse, error) {
+	if !active && adminID == id {
+		return nil, errors.New("cannot deactivate your own account")
+	}
+
+	err := s.userRepo.SetActive(ctx, id, active)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, errors.New("user not found")
+	}
+	if err != nil {
+		logger.Error(ctx, "failed to update user status", err, map[string]interface{}{
+			"user_id": id.String(),
+		})
+		return nil, errors.New("failed to update user status")
+	}
+	if store, err := s.storeRepo.FindByUserID(ctx, id); err == nil {
+		s.productRepo.InvalidateStoreListings(ctx, store.ID)
+	}
+
+	logger.Info(ctx, "user status updated", map[string]interface{}{
+		"user_id":  id.String(),
+		"active":   active,
+		"admin_id": adminID.String(),
+	})
+
+	return s.GetUser(ctx, id)
+}
@@ -0,0 +1,187 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/1tsndre/mini-go-project/store-service/internal/constant"
+	"github.com/1tsndre/mini-go-project/store-service/internal/mocks"
+	"github.com/1tsndre/mini-go-project/store-service/internal/model"
+	"github.com/1tsndre/mini-go-project/store-service/internal/repository"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+	"gorm.io/gorm"
+)
+
+func TestUserService_UpdateUserRole(t *testing.T) {
+	userID := uuid.New()
+
+	tests := []struct {
+		name        string
+		role        string
+		mockSetup   func(userRepo *mocks.MockUserRepository)
+		wantErr     bool
+		errContains string
+	}{
+		{
+			name: "promote to seller",
+			role: constant.RoleSeller,
+			mockSetup: func(userRepo *mocks.MockUserRepository) {
+				userRepo.EXPECT().ChangeRole(gomock.Any(), userID, constant.RoleSeller).Return(nil)
+				userRepo.EXPECT().FindByID(gomock.Any(), userID).Return(&model.User{
+					ID:   userID,
+					Role: constant.RoleSeller,
+				}, nil)
+			},
+		},
+		{
+			name:        "unknown role",
+			role:        "owner",
+			mockSetup:   func(*mocks.MockUserRepository) {},
+			wantErr:     true,
+			errContains: "role must be one of",
+		},
+		{
+			name: "last admin cannot be demoted",
+			role: constant.RoleBuyer,
+			mockSetup: func(userRepo *mocks.MockUserRepository) {
+				userRepo.EXPECT().ChangeRole(gomock.Any(), userID, constant.RoleBuyer).Return(repository.ErrLastAdmin)
+			},
+			wantErr:     true,
+			errContains: "cannot demote the last admin",
+		},
+		{
+			name: "user not found",
+			role: constant.RoleAdmin,
+			mockSetup: func(userRepo *mocks.MockUserRepository) {
+				userRepo.EXPECT().ChangeRole(gomock.Any(), userID, constant.RoleAdmin).Return(gorm.ErrRecordNotFound)
+			},
+			wantErr:     true,
+			errContains: "user not found",
+		},
+		{
+			name: "update fails",
+			role: constant.RoleAdmin,
+			mockSetup: func(userRepo *mocks.MockUserRepository) {
+				userRepo.EXPECT().ChangeRole(gomock.Any(), userID, constant.RoleAdmin).Return(errors.New("db error"))
+			},
+			wantErr:     true,
+			errContains: "failed to update user role",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			userRepo := mocks.NewMockUserRepository(ctrl)
+			tt.mockSetup(userRepo)
+
+			svc := NewUserService(userRepo, nil, nil)
+			resp, err := svc.UpdateUserRole(context.Background(), userID, tt.role)
+
+			if tt.wantErr {
+				assert.Error(t, err)
+				assert.Contains(t, err.Error(), tt.errContains)
+				assert.Nil(t, resp)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.role, resp.Role)
+		})
+	}
+}
+
+func TestUserService_GetUsers(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	userRepo := mocks.NewMockUserRepository(ctrl)
+	userRepo.EXPECT().FindAll(gomock.Any(), model.UserFilter{Role: constant.RoleSeller, Page: 1, PerPage: 10}).
+		Return([]model.User{{ID: uuid.New(), Role: constant.RoleSeller}}, int64(1), nil)
+
+	svc := NewUserService(userRepo, nil, nil)
+	users, total, err := svc.GetUsers(context.Background(), model.UserFilter{Role: constant.RoleSeller})
+
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), total)
+	assert.Len(t, users, 1)
+}
+
+func TestUserService_SetUserActive(t *testing.T) {
+	adminID := uuid.New()
+	userID := uuid.New()
+	storeID := uuid.New()
+
+	tests := []struct {
+		name        string
+		id          uuid.UUID
+		active      bool
+		mockSetup   func(userRepo *mocks.MockUserRepository, storeRepo *mocks.MockStoreRepository, prodRepo *mocks.MockProductRepository)
+		errContains string
+	}{
+		{
+			name:   "deactivate a seller hides their listings",
+			id:     userID,
+			active: false,
+			mockSetup: func(userRepo *mocks.MockUserRepository, storeRepo *mocks.MockStoreRepository, prodRepo *mocks.MockProductRepository) {
+				userRepo.EXPECT().SetActive(gomock.Any(), userID, false).Return(nil)
+				storeRepo.EXPECT().FindByUserID(gomock.Any(), userID).Return(&model.Store{ID: storeID, UserID: userID}, nil)
+				prodRepo.EXPECT().InvalidateStoreListings(gomock.Any(), storeID)
+				userRepo.EXPECT().FindByID(gomock.Any(), userID).Return(&model.User{ID: userID}, nil)
+			},
+		},
+		{
+			name:   "reactivate a buyer",
+			id:     userID,
+			active: true,
+			mockSetup: func(userRepo *mocks.MockUserRepository, storeRepo *mocks.MockStoreRepository, _ *mocks.MockProductRepository) {
+				userRepo.EXPECT().SetActive(gomock.Any(), userID, true).Return(nil)
+				storeRepo.EXPECT().FindByUserID(gomock.Any(), userID).Return(nil, gorm.ErrRecordNotFound)
+				userRepo.EXPECT().FindByID(gomock.Any(), userID).Return(&model.User{ID: userID, Active: true}, nil)
+			},
+		},
+		{
+			name:        "admin cannot deactivate themselves",
+			id:          adminID,
+			active:      false,
+			mockSetup:   func(*mocks.MockUserRepository, *mocks.MockStoreRepository, *mocks.MockProductRepository) {},
+			errContains: "cannot deactivate your own account",
+		},
+		{
+			name:   "user not found",
+			id:     userID,
+			active: false,
+			mockSetup: func(userRepo *mocks.MockUserRepository, _ *mocks.MockStoreRepository, _ *mocks.MockProductRepository) {
+				userRepo.EXPECT().SetActive(gomock.Any(), userID, false).Return(gorm.ErrRecordNotFound)
+			},
+			errContains: "user not found",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			userRepo := mocks.NewMockUserRepository(ctrl)
+			storeRepo := mocks.NewMockStoreRepository(ctrl)
+			prodRepo := mocks.NewMockProductRepository(ctrl)
+			tt.mockSetup(userRepo, storeRepo, prodRepo)
+
+			svc := NewUserService(userRepo, storeRepo, prodRepo)
+			resp, err := svc.SetUserActive(context.Background(), adminID, tt.id, tt.active)
+
+			if tt.errContains != "" {
+				assert.ErrorContains(t, err, tt.errContains)
+				assert.Nil(t, resp)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.active, resp.Active)
+		})
+	}
+}
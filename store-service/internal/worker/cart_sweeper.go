@@ -0,0 +1,61 @@
+package worker
+
+import (
+	"context"
+	"time"
+
+	"github.com/1tsndre/mini-go-project/pkg/logger"
+	"github.com/1tsndre/mini-go-project/store-service/internal/repository"
+)
+
+// CartSweeper periodically deletes abandoned cart rows from the PostgreSQL
+// backup. Redis copies expire on their own via the cart cache TTL.
+type CartSweeper struct {
+	cartRepo repository.CartRepository
+	maxAge   time.Duration
+	interval time.Duration
+}
+
+func NewCartSweeper(cartRepo repository.CartRepository, maxAge, interval time.Duration) *CartSweeper {
+	return &CartSweeper{
+		cartRepo: cartRepo,
+		maxAge:   maxAge,
+		interval: interval,
+	}
+}
+
+// Run sweeps on every interval tick until ctx is cancelled.
+func (s *CartSweeper) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	logger.Info(ctx, "cart sweeper started", map[string]interface{}{
+		"max_age":  s.maxAge.String(),
+		"interval": s.interval.String(),
+	})
+
+	for {
+		select {
+		case <-ctx.Done():
+			logger.Info(context.Background(), "cart sweeper stopped")
+			return
+		case <-ticker.C:
+			s.sweep(ctx)
+		}
+	}
+}
+
+func (s *CartSweeper) sweep(ctx context.Context) {
+	cutoff := time.Now().Add(-s.maxAge)
+	deleted, err := s.cartRepo.DeleteItemsOlderThan(ctx, cutoff)
+	if err != nil {
+		logger.Error(ctx, "failed to sweep abandoned cart items", err)
+		return
+	}
+	if deleted > 0 {
+		logger.Info(ctx, "swept abandoned cart items", map[string]interface{}{
+			"deleted": deleted,
+			"cutoff":  cutoff.Format(time.RFC3339),
+		})
+	}
+}
@@ -0,0 +1,77 @@
+package worker
+
+import (
+	"context"
+	"time"
+
+	"github.com/1tsndre/mini-go-project/pkg/logger"
+	"github.com/1tsndre/mini-go-project/store-service/internal/repository"
+	"github.com/1tsndre/mini-go-project/store-service/internal/service"
+)
+
+// outboxRelayBatchSize caps how many pending events one tick publishes.
+const outboxRelayBatchSize = 100
+
+// OutboxRelay periodically publishes outbox events that could not be sent
+// to NSQ when they were produced.
+type OutboxRelay struct {
+	outboxRepo repository.OutboxRepository
+	producer   service.Publisher
+	interval   time.Duration
+}
+
+func NewOutboxRelay(outboxRepo repository.OutboxRepository, producer service.Publisher, interval time.Duration) *OutboxRelay {
+	return &OutboxRelay{
+		outboxRepo: outboxRepo,
+		producer:   producer,
+		interval:   interval,
+	}
+}
+
+// Run relays on every interval tick until ctx is cancelled.
+func (r *OutboxRelay) Run(ctx context.Context) {
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+
+	logger.Info(ctx, "outbox relay started", map[string]interface{}{
+		"interval": r.interval.String(),
+	})
+
+	for {
+		select {
+		case <-ctx.Done():
+			logger.Info(context.Background(), "outbox relay stopped")
+			return
+		case <-ticker.C:
+			r.relay(ctx)
+		}
+	}
+}
+
+func (r *OutboxRelay) relay(ctx context.Context) {
+	events, err := r.outboxRepo.FindPending(ctx, outboxRelayBatchSize)
+	if err != nil {
+		logger.Error(ctx, "failed to load pending outbox events", err)
+		return
+	}
+
+	for _, event := range events {
+		if err := r.producer.Publish(event.Topic, event.Payload); err != nil {
+			logger.Error(ctx, "failed to relay outbox event", err, map[string]interface{}{
+				"event_id": event.ID.String(),
+				"topic":    event.Topic,
+				"attempts": event.Attempts + 1,
+			})
+			if err := r.outboxRepo.IncrementAttempts(ctx, event.ID); err != nil {
+				logger.Error(ctx, "failed to record outbox attempt", err)
+			}
+			// NSQ is most likely still down; try the rest next tick.
+			return
+		}
+		if err := r.outboxRepo.MarkPublished(ctx, event.ID); err != nil {
+			logger.Error(ctx, "failed to mark outbox event published", err, map[string]interface{}{
+				"event_id": event.ID.String(),
+			})
+		}
+	}
+}
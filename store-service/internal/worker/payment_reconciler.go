@@ -0,0 +1,130 @@
+package worker
+
+import (
+	"context"
+	"time"
+
+	"github.com/1tsndre/mini-go-project/pkg/logger"
+	pb "github.com/1tsndre/mini-go-project/proto/payment"
+	"github.com/1tsndre/mini-go-project/store-service/internal/model"
+	"github.com/1tsndre/mini-go-project/store-service/internal/repository"
+	"github.com/1tsndre/mini-go-project/store-service/internal/service"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	// paymentReconcileBatchSize caps how many stale orders one tick checks.
+	paymentReconcileBatchSize = 100
+	// paymentStatusTimeout bounds a single status query to the payment
+	// service.
+	paymentStatusTimeout = 5 * time.Second
+)
+
+// PaymentReconciler periodically asks the payment service about orders that
+// have been pending for too long and applies the result, so an order whose
+// payment.success or payment.failed message was lost does not stay pending.
+type PaymentReconciler struct {
+	orderRepo    repository.OrderRepository
+	orderService service.OrderService
+	payments     pb.PaymentServiceClient
+	after        time.Duration
+	interval     time.Duration
+}
+
+func NewPaymentReconciler(orderRepo repository.OrderRepository, orderService service.OrderService, payments pb.PaymentServiceClient, after, interval time.Duration) *PaymentReconciler {
+	return &PaymentReconciler{
+		orderRepo:    orderRepo,
+		orderService: orderService,
+		payments:     payments,
+		after:        after,
+		interval:     interval,
+	}
+}
+
+// Run reconciles on every interval tick until ctx is cancelled.
+func (r *PaymentReconciler) Run(ctx context.Context) {
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+
+	logger.Info(ctx, "payment reconciler started", map[string]interface{}{
+		"after":    r.after.String(),
+		"interval": r.interval.String(),
+	})
+
+	for {
+		select {
+		case <-ctx.Done():
+			logger.Info(context.Background(), "payment reconciler stopped")
+			return
+		case <-ticker.C:
+			r.reconcile(ctx)
+		}
+	}
+}
+
+// reconcile checks every stale pending order, a page at a time, so orders
+// the payment service has no result for yet do not hide the ones behind them.
+func (r *PaymentReconciler) reconcile(ctx context.Context) {
+	before := time.Now().Add(-r.after)
+	var after *model.Order
+	for {
+		orders, err := r.orderRepo.FindStalePending(ctx, before, after, paymentReconcileBatchSize)
+		if err != nil {
+			logger.Error(ctx, "failed to load stale pending orders", err)
+			return
+		}
+		if !r.reconcileBatch(ctx, orders) || len(orders) < paymentReconcileBatchSize {
+			return
+		}
+		after = &orders[len(orders)-1]
+	}
+}
+
+// reconcileBatch applies the payment results of orders and reports whether
+// the pass should go on to the next page.
+func (r *PaymentReconciler) reconcileBatch(ctx context.Context, orders []model.Order) bool {
+	for _, order := range orders {
+		if ctx.Err() != nil {
+			return false
+		}
+
+		queryCtx, cancel := context.WithTimeout(ctx, paymentStatusTimeout)
+		resp, err := r.payments.GetPaymentStatus(queryCtx, &pb.GetPaymentStatusRequest{OrderId: order.ID.String()})
+		cancel()
+		if status.Code(err) == codes.NotFound {
+			// The payment has not been attempted yet, e.g. order.created
+			// is still queued in the outbox.
+			continue
+		}
+		if err != nil {
+			logger.Error(ctx, "failed to query payment status", err, map[string]interface{}{
+				"order_id": order.ID.String(),
+			})
+			// The payment service is most likely down; try the rest next tick.
+			return false
+		}
+
+		var success bool
+		switch resp.GetStatus() {
+		case "success":
+			success = true
+		case "failed":
+			success = false
+		default:
+			continue
+		}
+
+		if err := r.orderService.ProcessPaymentResult(ctx, order.ID, success); err != nil {
+			logger.Error(ctx, "failed to apply reconciled payment result", err, map[string]interface{}{
+				"order_id": order.ID.String(),
+			})
+			continue
+		}
+		logger.Info(ctx, "reconciled payment of stale pending order", map[string]interface{}{
+			"order_id": order.ID.String(),
+			"status":   resp.GetStatus(),
+		})
+	}
+	return true
+}
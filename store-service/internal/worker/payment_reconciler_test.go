@@ -0,0 +1,134 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	pb "github.com/1tsndre/mini-go-project/proto/payment"
+	"github.com/1tsndre/mini-go-project/store-service/internal/mocks"
+	"github.com/1tsndre/mini-go-project/store-service/internal/model"
+	"github.com/1tsndre/mini-go-project/store-service/internal/service"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+type paymentStatusClient struct {
+	pb.PaymentServiceClient
+	statuses map[string]string
+	err      error
+}
+
+func (c *paymentStatusClient) GetPaymentStatus(_ context.Context, req *pb.GetPaymentStatusRequest, _ ...grpc.CallOption) (*pb.GetPaymentStatusResponse, error) {
+	if c.err != nil {
+		return nil, c.err
+	}
+	s, ok := c.statuses[req.OrderId]
+	if !ok {
+		return nil, status.Error(codes.NotFound, "no payment found for order")
+	}
+	return &pb.GetPaymentStatusResponse{OrderId: req.OrderId, Status: s}, nil
+}
+
+type paymentResultRecorder struct {
+	service.OrderService
+	results map[uuid.UUID]bool
+}
+
+func (s *paymentResultRecorder) ProcessPaymentResult(_ context.Context, orderID uuid.UUID, success bool) error {
+	s.results[orderID] = success
+	return nil
+}
+
+func TestPaymentReconciler_Reconcile(t *testing.T) {
+	paidID := uuid.New()
+	declinedID := uuid.New()
+	unknownID := uuid.New()
+	stale := []model.Order{{ID: paidID}, {ID: declinedID}, {ID: unknownID}}
+
+	tests := []struct {
+		name        string
+		client      *paymentStatusClient
+		wantResults map[uuid.UUID]bool
+	}{
+		{
+			name: "applies results the callbacks never delivered",
+			client: &paymentStatusClient{statuses: map[string]string{
+				paidID.String():     "success",
+				declinedID.String(): "failed",
+			}},
+			wantResults: map[uuid.UUID]bool{paidID: true, declinedID: false},
+		},
+		{
+			name:        "payment service down",
+			client:      &paymentStatusClient{err: status.Error(codes.Unavailable, "connection refused")},
+			wantResults: map[uuid.UUID]bool{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			orderRepo := mocks.NewMockOrderRepository(ctrl)
+			orderRepo.EXPECT().FindStalePending(gomock.Any(), gomock.Any(), nil, paymentReconcileBatchSize).
+				DoAndReturn(func(_ context.Context, before time.Time, _ *model.Order, _ int) ([]model.Order, error) {
+					assert.WithinDuration(t, time.Now().Add(-15*time.Minute), before, time.Second)
+					return stale, nil
+				})
+			recorder := &paymentResultRecorder{results: map[uuid.UUID]bool{}}
+
+			r := NewPaymentReconciler(orderRepo, recorder, tt.client, 15*time.Minute, time.Minute)
+			r.reconcile(context.Background())
+
+			assert.Equal(t, tt.wantResults, recorder.results)
+		})
+	}
+}
+
+func TestPaymentReconciler_Reconcile_LoadFailure(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	orderRepo := mocks.NewMockOrderRepository(ctrl)
+	orderRepo.EXPECT().FindStalePending(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(nil, errors.New("connection refused"))
+	recorder := &paymentResultRecorder{results: map[uuid.UUID]bool{}}
+
+	r := NewPaymentReconciler(orderRepo, recorder, &paymentStatusClient{}, time.Minute, time.Minute)
+	r.reconcile(context.Background())
+
+	assert.Empty(t, recorder.results)
+}
+
+func TestPaymentReconciler_Reconcile_PagesPastUnknownOrders(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	// A full first page the payment service knows nothing about must not
+	// keep the pass from reaching the order behind it.
+	firstPage := make([]model.Order, paymentReconcileBatchSize)
+	for i := range firstPage {
+		firstPage[i] = model.Order{ID: uuid.New()}
+	}
+	paidID := uuid.New()
+
+	orderRepo := mocks.NewMockOrderRepository(ctrl)
+	gomock.InOrder(
+		orderRepo.EXPECT().FindStalePending(gomock.Any(), gomock.Any(), nil, paymentReconcileBatchSize).Return(firstPage, nil),
+		orderRepo.EXPECT().FindStalePending(gomock.Any(), gomock.Any(), &firstPage[len(firstPage)-1], paymentReconcileBatchSize).
+			Return([]model.Order{{ID: paidID}}, nil),
+	)
+	recorder := &paymentResultRecorder{results: map[uuid.UUID]bool{}}
+	client := &paymentStatusClient{statuses: map[string]string{paidID.String(): "success"}}
+
+	r := NewPaymentReconciler(orderRepo, recorder, client, time.Minute, time.Minute)
+	r.reconcile(context.Background())
+
+	assert.Equal(t, map[uuid.UUID]bool{paidID: true}, recorder.results)
+}
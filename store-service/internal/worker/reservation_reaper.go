@@ -0,0 +1,56 @@
+package worker
+
+import (
+	"context"
+	"time"
+
+	"github.com/1tsndre/mini-go-project/pkg/logger"
+	"github.com/1tsndre/mini-go-project/store-service/internal/service"
+)
+
+// ReservationReaper periodically cancels orders left unpaid past their stock
+// reservation and puts the stock back.
+type ReservationReaper struct {
+	orderService service.OrderService
+	interval     time.Duration
+}
+
+func NewReservationReaper(orderService service.OrderService, interval time.Duration) *ReservationReaper {
+	return &ReservationReaper{
+		orderService: orderService,
+		interval:     interval,
+	}
+}
+
+// Run reaps on every interval tick until ctx is cancelled.
+func (r *ReservationReaper) Run(ctx context.Context) {
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+
+	logger.Info(ctx, "reservation reaper started", map[string]interface{}{
+		"interval": r.interval.String(),
+	})
+
+	for {
+		select {
+		case <-ctx.Done():
+			logger.Info(context.Background(), "reservation reaper stopped")
+			return
+		case <-ticker.C:
+			r.reap(ctx)
+		}
+	}
+}
+
+func (r *ReservationReaper) reap(ctx context.Context) {
+	expired, err := r.orderService.ExpireReservations(ctx)
+	if err != nil {
+		logger.Error(ctx, "failed to expire stock reservations", err)
+		return
+	}
+	if expired > 0 {
+		logger.Info(ctx, "cancelled orders with expired stock reservations", map[string]interface{}{
+			"cancelled": expired,
+		})
+	}
+}